@@ -0,0 +1,320 @@
+// Package admin provides a per service admin http listener serving
+// health, readiness, build info, the registry and selector state,
+// connection pool stats and pprof, gated by an auth token.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro"
+	"github.com/micro/go-plugins/client/grpc"
+)
+
+// Admin is the admin listener of a service
+type Admin struct {
+	opts Options
+
+	sync.RWMutex
+	ready   bool
+	started time.Time
+	srv     *http.Server
+	addr    string
+}
+
+// statser is implemented by selectors which report their state
+type statser interface {
+	Stats() interface{}
+}
+
+func (a *Admin) auth(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(a.opts.Token) > 0 {
+			token := r.URL.Query().Get("token")
+			if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				token = strings.TrimPrefix(auth, "Bearer ")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(a.opts.Token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (a *Admin) write(w http.ResponseWriter, code int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// check runs the checks returning the failures by name
+func check(checks map[string]Check) map[string]string {
+	errs := make(map[string]string)
+	for name, c := range checks {
+		if err := c(); err != nil {
+			errs[name] = err.Error()
+		}
+	}
+	return errs
+}
+
+func (a *Admin) status(w http.ResponseWriter, errs map[string]string) {
+	if len(errs) > 0 {
+		a.write(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "failing",
+			"errors": errs,
+		})
+		return
+	}
+	a.write(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (a *Admin) health(w http.ResponseWriter, r *http.Request) {
+	a.status(w, check(a.opts.Health))
+}
+
+func (a *Admin) readiness(w http.ResponseWriter, r *http.Request) {
+	a.RLock()
+	ready := a.ready
+	a.RUnlock()
+
+	if !ready {
+		a.status(w, map[string]string{"service": "not ready"})
+		return
+	}
+	a.status(w, check(a.opts.Ready))
+}
+
+func (a *Admin) info(w http.ResponseWriter, r *http.Request) {
+	a.RLock()
+	started := a.started
+	a.RUnlock()
+
+	info := map[string]interface{}{
+		"name":        a.opts.Name,
+		"version":     a.opts.Info.Version,
+		"commit":      a.opts.Info.Commit,
+		"environment": a.opts.Info.Environment,
+		"pod":         a.opts.Info.Pod,
+		"go":          runtime.Version(),
+		"goroutines":  runtime.NumGoroutine(),
+	}
+	if !started.IsZero() {
+		info["started"] = started
+		info["uptime"] = time.Since(started).String()
+	}
+
+	a.write(w, http.StatusOK, info)
+}
+
+func (a *Admin) registry(w http.ResponseWriter, r *http.Request) {
+	if a.opts.Registry == nil {
+		http.Error(w, "registry not set", http.StatusNotFound)
+		return
+	}
+
+	// a single service with its nodes
+	if service := r.URL.Query().Get("service"); len(service) > 0 {
+		services, err := a.opts.Registry.GetService(service)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.write(w, http.StatusOK, services)
+		return
+	}
+
+	services, err := a.opts.Registry.ListServices()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, s := range services {
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+
+	a.write(w, http.StatusOK, map[string]interface{}{
+		"registry": a.opts.Registry.String(),
+		"services": names,
+	})
+}
+
+func (a *Admin) selector(w http.ResponseWriter, r *http.Request) {
+	if a.opts.Selector == nil {
+		http.Error(w, "selector not set", http.StatusNotFound)
+		return
+	}
+
+	state := map[string]interface{}{
+		"selector": a.opts.Selector.String(),
+	}
+	if s, ok := a.opts.Selector.(statser); ok {
+		state["stats"] = s.Stats()
+	}
+
+	a.write(w, http.StatusOK, state)
+}
+
+func (a *Admin) stats(w http.ResponseWriter, r *http.Request) {
+	stats := make(map[string]interface{})
+
+	if a.opts.Client != nil {
+		if pool, ok := grpc.Stats(a.opts.Client); ok {
+			stats["pool"] = pool
+		}
+		if payloads, ok := grpc.Payloads(a.opts.Client); ok {
+			stats["payloads"] = payloads
+		}
+	}
+	for name, fn := range a.opts.Stats {
+		stats[name] = fn()
+	}
+
+	a.write(w, http.StatusOK, stats)
+}
+
+// Handler returns the admin handler
+func (a *Admin) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", a.health)
+	mux.HandleFunc("/ready", a.readiness)
+	mux.HandleFunc("/info", a.info)
+	mux.HandleFunc("/registry", a.registry)
+	mux.HandleFunc("/selector", a.selector)
+	mux.HandleFunc("/stats", a.stats)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return a.auth(mux)
+}
+
+// SetReady marks the service ready or not ready
+func (a *Admin) SetReady(ready bool) {
+	a.Lock()
+	a.ready = ready
+	a.Unlock()
+}
+
+// Address returns the address the listener is bound to
+func (a *Admin) Address() string {
+	a.RLock()
+	defer a.RUnlock()
+	if len(a.addr) > 0 {
+		return a.addr
+	}
+	return a.opts.Address
+}
+
+// Start starts the admin listener
+func (a *Admin) Start() error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.srv != nil {
+		return nil
+	}
+
+	l, err := net.Listen("tcp", a.opts.Address)
+	if err != nil {
+		return err
+	}
+
+	a.srv = &http.Server{Handler: a.Handler()}
+	a.addr = l.Addr().String()
+	a.started = time.Now()
+
+	go func(srv *http.Server) {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Logf("[admin] listener error: %v", err)
+		}
+	}(a.srv)
+
+	log.Logf("[admin] listening on %s", a.addr)
+
+	return nil
+}
+
+// Stop stops the admin listener
+func (a *Admin) Stop() error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.srv == nil {
+		return nil
+	}
+
+	err := a.srv.Close()
+	a.srv = nil
+	a.ready = false
+	return err
+}
+
+func (a *Admin) String() string {
+	return "admin"
+}
+
+// NewAdmin returns an admin listener
+func NewAdmin(opts ...Option) *Admin {
+	return &Admin{
+		opts: newOptions(opts...),
+	}
+}
+
+// Hook returns a service option which starts the admin listener before
+// the service, marks it ready once started and not ready while stopping.
+// The registry, selector, client and name default to the service's.
+func Hook(a *Admin) micro.Option {
+	return func(o *micro.Options) {
+		o.BeforeStart = append(o.BeforeStart, func() error {
+			if a.opts.Registry == nil {
+				a.opts.Registry = o.Registry
+			}
+			if a.opts.Client == nil {
+				a.opts.Client = o.Client
+			}
+			if a.opts.Selector == nil && o.Client != nil {
+				a.opts.Selector = o.Client.Options().Selector
+			}
+			if len(a.opts.Name) == 0 && o.Server != nil {
+				a.opts.Name = o.Server.Options().Name
+			}
+			return a.Start()
+		})
+		o.AfterStart = append(o.AfterStart, func() error {
+			a.SetReady(true)
+			return nil
+		})
+		o.BeforeStop = append(o.BeforeStop, func() error {
+			a.SetReady(false)
+			return nil
+		})
+		o.AfterStop = append(o.AfterStop, a.Stop)
+	}
+}
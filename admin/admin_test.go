@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/micro/go-micro/registry/mock"
+)
+
+func get(t *testing.T, h http.Handler, path, token string) (int, map[string]interface{}) {
+	r := httptest.NewRequest("GET", path, nil)
+	if len(token) > 0 {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	var rsp map[string]interface{}
+	if w.Code != http.StatusUnauthorized {
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Fatalf("%s: unexpected body %q: %v", path, w.Body.String(), err)
+		}
+	}
+	return w.Code, rsp
+}
+
+func TestAuth(t *testing.T) {
+	a := NewAdmin(Token("secret"))
+	h := a.Handler()
+
+	if code, _ := get(t, h, "/health", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", code)
+	}
+	if code, _ := get(t, h, "/health", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", code)
+	}
+	if code, _ := get(t, h, "/health", "secret"); code != http.StatusOK {
+		t.Fatalf("expected 200 with token, got %d", code)
+	}
+	if code, _ := get(t, h, "/health?token=secret", ""); code != http.StatusOK {
+		t.Fatalf("expected 200 with token param, got %d", code)
+	}
+}
+
+func TestChecks(t *testing.T) {
+	var err error
+
+	a := NewAdmin(
+		Token(""),
+		Health("db", func() error { return err }),
+		Ready("cache", func() error { return err }),
+	)
+	h := a.Handler()
+
+	if code, _ := get(t, h, "/health", ""); code != http.StatusOK {
+		t.Fatalf("expected healthy, got %d", code)
+	}
+	if code, _ := get(t, h, "/ready", ""); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected not ready before start, got %d", code)
+	}
+
+	a.SetReady(true)
+	if code, _ := get(t, h, "/ready", ""); code != http.StatusOK {
+		t.Fatalf("expected ready, got %d", code)
+	}
+
+	err = errors.New("down")
+	code, rsp := get(t, h, "/health", "")
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected unhealthy, got %d", code)
+	}
+	if errs := rsp["errors"].(map[string]interface{}); errs["db"] != "down" {
+		t.Fatalf("expected db error, got %v", errs)
+	}
+	if code, _ := get(t, h, "/ready", ""); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected not ready, got %d", code)
+	}
+}
+
+func TestState(t *testing.T) {
+	a := NewAdmin(
+		Token(""),
+		Name("go.micro.srv.test"),
+		Registry(mock.NewRegistry()),
+		Stats("queue", func() interface{} { return 3 }),
+	)
+	h := a.Handler()
+
+	_, rsp := get(t, h, "/info", "")
+	if rsp["name"] != "go.micro.srv.test" {
+		t.Fatalf("expected name in info, got %v", rsp)
+	}
+
+	_, rsp = get(t, h, "/registry", "")
+	if services, ok := rsp["services"].([]interface{}); !ok || len(services) == 0 {
+		t.Fatalf("expected services, got %v", rsp)
+	}
+
+	_, rsp = get(t, h, "/stats", "")
+	if rsp["queue"] != float64(3) {
+		t.Fatalf("expected queue stats, got %v", rsp)
+	}
+
+	if code, _ := get(t, h, "/selector", ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 without selector, got %d", code)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	a := NewAdmin(Token(""), Address("127.0.0.1:0"))
+
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, err := http.Get("http://" + a.Address() + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rsp.StatusCode)
+	}
+
+	if err := a.Stop(); err != nil {
+		t.Fatal(err)
+	}
+}
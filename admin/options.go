@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"os"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"github.com/micro/go-plugins/wrapper/buildinfo"
+)
+
+// Check returns an error when a health or readiness check fails
+type Check func() error
+
+// Reporter returns stats served as json on /stats
+type Reporter func() interface{}
+
+type Options struct {
+	// Address the admin listener binds to
+	Address string
+	// Token required as a bearer token or token query param,
+	// requests aren't authenticated when empty
+	Token string
+	// Name of the service
+	Name string
+	// Info is the build info served on /info
+	Info buildinfo.Info
+	// Registry, Selector and Client whose state is served
+	Registry registry.Registry
+	Selector selector.Selector
+	Client   client.Client
+	// Health and Ready checks by name
+	Health map[string]Check
+	Ready  map[string]Check
+	// Stats reporters by name
+	Stats map[string]Reporter
+}
+
+type Option func(o *Options)
+
+var (
+	// DefaultAddress is the default admin listener address
+	DefaultAddress = "127.0.0.1:9901"
+)
+
+// Address sets the listener address, defaults to the
+// ADMIN_ADDRESS env var then DefaultAddress
+func Address(a string) Option {
+	return func(o *Options) {
+		o.Address = a
+	}
+}
+
+// Token sets the auth token, defaults to the ADMIN_TOKEN env var
+func Token(t string) Option {
+	return func(o *Options) {
+		o.Token = t
+	}
+}
+
+// Name sets the service name
+func Name(n string) Option {
+	return func(o *Options) {
+		o.Name = n
+	}
+}
+
+// Info sets the build info served on /info
+func Info(i buildinfo.Info) Option {
+	return func(o *Options) {
+		o.Info = i
+	}
+}
+
+// Registry sets the registry served on /registry
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// Selector sets the selector served on /selector
+func Selector(s selector.Selector) Option {
+	return func(o *Options) {
+		o.Selector = s
+	}
+}
+
+// Client sets the client whose connection pool
+// stats are served on /stats
+func Client(c client.Client) Option {
+	return func(o *Options) {
+		o.Client = c
+	}
+}
+
+// Health adds a named health check served on /health
+func Health(name string, c Check) Option {
+	return func(o *Options) {
+		o.Health[name] = c
+	}
+}
+
+// Ready adds a named readiness check served on /ready
+func Ready(name string, c Check) Option {
+	return func(o *Options) {
+		o.Ready[name] = c
+	}
+}
+
+// Stats adds a named stats reporter served on /stats
+func Stats(name string, r Reporter) Option {
+	return func(o *Options) {
+		o.Stats[name] = r
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Address: os.Getenv("ADMIN_ADDRESS"),
+		Token:   os.Getenv("ADMIN_TOKEN"),
+		Info:    buildinfo.NewInfo(),
+		Health:  make(map[string]Check),
+		Ready:   make(map[string]Check),
+		Stats:   make(map[string]Reporter),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if len(options.Address) == 0 {
+		options.Address = DefaultAddress
+	}
+
+	return options
+}
@@ -0,0 +1,427 @@
+// Package azureservicebus provides an Azure Service Bus broker
+package azureservicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-service-bus-go"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/cmd"
+	"github.com/pborman/uuid"
+)
+
+type sbBroker struct {
+	sync.RWMutex
+	opts   broker.Options
+	ns     *servicebus.Namespace
+	topics map[string]*servicebus.Topic
+}
+
+type subscriber struct {
+	opts  broker.SubscribeOptions
+	topic string
+	sub   *servicebus.Subscription
+	// the subscription is deleted on unsubscribe
+	ephemeral bool
+	tp        *servicebus.Topic
+
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+type publication struct {
+	ctx   context.Context
+	topic string
+	m     *broker.Message
+	sm    *servicebus.Message
+	acked bool
+}
+
+func init() {
+	cmd.DefaultBrokers["azureservicebus"] = NewBroker
+}
+
+func (p *publication) Topic() string {
+	return p.topic
+}
+
+func (p *publication) Message() *broker.Message {
+	return p.m
+}
+
+// Ack completes the message removing it from the subscription
+func (p *publication) Ack() error {
+	if p.acked {
+		return nil
+	}
+	p.acked = true
+	return p.sm.Complete(p.ctx)
+}
+
+func (s *subscriber) Options() broker.SubscribeOptions {
+	return s.opts
+}
+
+func (s *subscriber) Topic() string {
+	return s.topic
+}
+
+func (s *subscriber) Unsubscribe() error {
+	s.cancel()
+	<-s.done
+
+	if err := s.sub.Close(context.Background()); err != nil {
+		return err
+	}
+
+	if !s.ephemeral {
+		return nil
+	}
+
+	sm, err := s.tp.NewSubscriptionManager()
+	if err != nil {
+		return err
+	}
+	return sm.Delete(context.Background(), s.sub.Name)
+}
+
+// handle calls the broker handler renewing the lock until it returns
+func (s *subscriber) handle(ctx context.Context, sm *servicebus.Message, h broker.Handler, renew func(context.Context) error) error {
+	header := make(map[string]string, len(sm.UserProperties))
+	for k, v := range sm.UserProperties {
+		header[k] = fmt.Sprint(v)
+	}
+
+	p := &publication{
+		ctx:   ctx,
+		topic: s.topic,
+		m: &broker.Message{
+			Header: header,
+			Body:   sm.Data,
+		},
+		sm: sm,
+	}
+
+	if d, ok := s.opts.Context.Value(lockRenewalKey{}).(time.Duration); ok && d > 0 {
+		stop := make(chan bool)
+		defer close(stop)
+
+		go func() {
+			t := time.NewTicker(d)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-stop:
+					return
+				case <-t.C:
+					if err := renew(ctx); err != nil {
+						log.Logf("[azureservicebus] failed to renew lock on %s: %v", s.topic, err)
+					}
+				}
+			}
+		}()
+	}
+
+	if err := h(p); err != nil {
+		if p.acked {
+			return nil
+		}
+		if dl, _ := s.opts.Context.Value(deadLetterKey{}).(bool); dl {
+			return sm.DeadLetter(ctx, err)
+		}
+		return sm.Abandon(ctx)
+	}
+
+	if s.opts.AutoAck {
+		return p.Ack()
+	}
+
+	return nil
+}
+
+// sessionHandler processes the messages of one session in order
+type sessionHandler struct {
+	s  *subscriber
+	h  broker.Handler
+	ms *servicebus.MessageSession
+}
+
+func (sh *sessionHandler) Start(ms *servicebus.MessageSession) error {
+	sh.ms = ms
+	return nil
+}
+
+func (sh *sessionHandler) Handle(ctx context.Context, sm *servicebus.Message) error {
+	return sh.s.handle(ctx, sm, sh.h, func(ctx context.Context) error {
+		return sh.ms.RenewLock(ctx)
+	})
+}
+
+func (sh *sessionHandler) End() {}
+
+func (s *subscriber) run(ctx context.Context, h broker.Handler) {
+	defer close(s.done)
+
+	sessions, _ := s.opts.Context.Value(sessionsKey{}).(bool)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		var err error
+		if sessions {
+			// accept the next available session
+			err = s.sub.NewSession(nil).ReceiveOne(ctx, &sessionHandler{s: s, h: h})
+		} else {
+			err = s.sub.Receive(ctx, servicebus.HandlerFunc(func(ctx context.Context, sm *servicebus.Message) error {
+				return s.handle(ctx, sm, h, func(ctx context.Context) error {
+					return s.sub.RenewLocks(ctx, sm)
+				})
+			}))
+		}
+
+		if err != nil && ctx.Err() == nil {
+			log.Logf("[azureservicebus] error receiving from %s: %v", s.topic, err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (b *sbBroker) Options() broker.Options {
+	return b.opts
+}
+
+func (b *sbBroker) Address() string {
+	if b.ns != nil {
+		return b.ns.Name
+	}
+	return ""
+}
+
+func (b *sbBroker) connectionString() string {
+	if cs, ok := b.opts.Context.Value(connectionStringKey{}).(string); ok {
+		return cs
+	}
+	if len(b.opts.Addrs) > 0 {
+		return b.opts.Addrs[0]
+	}
+	return ""
+}
+
+func (b *sbBroker) Connect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.ns != nil {
+		return nil
+	}
+
+	cs := b.connectionString()
+	if len(cs) == 0 {
+		return errors.New("servicebus connection string required")
+	}
+
+	ns, err := servicebus.NewNamespace(servicebus.NamespaceWithConnectionString(cs))
+	if err != nil {
+		return err
+	}
+
+	b.ns = ns
+	return nil
+}
+
+func (b *sbBroker) Disconnect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	var err error
+	for name, t := range b.topics {
+		if cerr := t.Close(context.Background()); cerr != nil {
+			err = cerr
+		}
+		delete(b.topics, name)
+	}
+
+	return err
+}
+
+func (b *sbBroker) Init(opts ...broker.Option) error {
+	for _, o := range opts {
+		o(&b.opts)
+	}
+	return nil
+}
+
+// topic returns the topic creating it if it doesn't exist
+func (b *sbBroker) topic(ctx context.Context, name string) (*servicebus.Topic, error) {
+	b.RLock()
+	t, ok := b.topics[name]
+	ns := b.ns
+	b.RUnlock()
+
+	if ok {
+		return t, nil
+	}
+
+	if ns == nil {
+		return nil, errors.New("servicebus not connected")
+	}
+
+	tm := ns.NewTopicManager()
+	if _, err := tm.Get(ctx, name); err != nil {
+		if _, err := tm.Put(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := ns.NewTopic(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Lock()
+	if tt, ok := b.topics[name]; ok {
+		b.Unlock()
+		t.Close(ctx)
+		return tt, nil
+	}
+	b.topics[name] = t
+	b.Unlock()
+
+	return t, nil
+}
+
+func (b *sbBroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	options := broker.PublishOptions{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ctx := context.Background()
+
+	t, err := b.topic(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	sm := servicebus.NewMessage(msg.Body)
+	sm.UserProperties = make(map[string]interface{}, len(msg.Header))
+	for k, v := range msg.Header {
+		sm.UserProperties[k] = v
+	}
+
+	if id, ok := options.Context.Value(sessionIdKey{}).(string); ok && len(id) > 0 {
+		sm.SessionID = &id
+	}
+
+	if at, ok := options.Context.Value(scheduleKey{}).(time.Time); ok && at.After(time.Now()) {
+		_, err := t.ScheduleAt(ctx, at, sm)
+		return err
+	}
+
+	return t.Send(ctx, sm)
+}
+
+// Subscribe creates a subscription on the topic. Subscribers with the same queue
+// share a durable subscription, otherwise the subscription is removed on unsubscribe.
+func (b *sbBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.SubscribeOptions{
+		AutoAck: true,
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ctx := context.Background()
+
+	t, err := b.topic(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral := len(options.Queue) == 0
+	name := options.Queue
+	if ephemeral {
+		name = strings.Replace(uuid.NewUUID().String(), "-", "", -1)
+	}
+
+	sm, err := t.NewSubscriptionManager()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sm.Get(ctx, name); err != nil {
+		var sopts []servicebus.SubscriptionManagementOption
+		if sessions, _ := options.Context.Value(sessionsKey{}).(bool); sessions {
+			sopts = append(sopts, servicebus.SubscriptionWithRequiredSessions())
+		}
+		if ephemeral {
+			// remove abandoned subscriptions of crashed subscribers
+			sopts = append(sopts, servicebus.SubscriptionWithAutoDeleteOnIdle(durationPtr(time.Hour)))
+		}
+		if _, err := sm.Put(ctx, name, sopts...); err != nil {
+			return nil, err
+		}
+	}
+
+	sub, err := t.NewSubscription(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rctx, cancel := context.WithCancel(context.Background())
+
+	s := &subscriber{
+		opts:      options,
+		topic:     topic,
+		sub:       sub,
+		ephemeral: ephemeral,
+		tp:        t,
+		cancel:    cancel,
+		done:      make(chan bool),
+	}
+
+	go s.run(rctx, h)
+
+	return s, nil
+}
+
+func (b *sbBroker) String() string {
+	return "azureservicebus"
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+// NewBroker returns a new Azure Service Bus broker. The connection string
+// is set with the ConnectionString option or as the broker address.
+func NewBroker(opts ...broker.Option) broker.Broker {
+	options := broker.Options{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &sbBroker{
+		opts:   options,
+		topics: make(map[string]*servicebus.Topic),
+	}
+}
@@ -0,0 +1,96 @@
+package azureservicebus
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-service-bus-go"
+	"github.com/micro/go-micro/broker"
+)
+
+func TestConnectionString(t *testing.T) {
+	b := NewBroker().(*sbBroker)
+	if err := b.Connect(); err == nil {
+		t.Fatal("expected an error without a connection string")
+	}
+
+	b = NewBroker(broker.Addrs("Endpoint=sb://addr/")).(*sbBroker)
+	if cs := b.connectionString(); cs != "Endpoint=sb://addr/" {
+		t.Fatalf("expected the address as the connection string got %s", cs)
+	}
+
+	// the option takes precedence over the address
+	b = NewBroker(
+		broker.Addrs("Endpoint=sb://addr/"),
+		ConnectionString("Endpoint=sb://option/"),
+	).(*sbBroker)
+	if cs := b.connectionString(); cs != "Endpoint=sb://option/" {
+		t.Fatalf("expected the option as the connection string got %s", cs)
+	}
+}
+
+func TestNotConnected(t *testing.T) {
+	b := NewBroker()
+
+	if err := b.Publish("test.topic", &broker.Message{Body: []byte("hello")}); err == nil {
+		t.Fatal("expected publish to fail when not connected")
+	}
+
+	if _, err := b.Subscribe("test.topic", func(broker.Publication) error { return nil }); err == nil {
+		t.Fatal("expected subscribe to fail when not connected")
+	}
+}
+
+func TestHandle(t *testing.T) {
+	options := broker.SubscribeOptions{
+		Context: context.Background(),
+	}
+	LockRenewal(5 * time.Millisecond)(&options)
+
+	s := &subscriber{
+		opts:  options,
+		topic: "test.topic",
+	}
+
+	sm := servicebus.NewMessage([]byte("hello"))
+	sm.UserProperties = map[string]interface{}{
+		"Micro-Id": "1",
+		"Count":    2,
+	}
+
+	var renewed int32
+	var p broker.Publication
+
+	err := s.handle(context.TODO(), sm, func(pub broker.Publication) error {
+		p = pub
+		// the lock is renewed while the handler runs
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, func(context.Context) error {
+		atomic.AddInt32(&renewed, 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := atomic.LoadInt32(&renewed)
+	if n == 0 {
+		t.Fatal("expected the lock to be renewed")
+	}
+
+	// renewal stops once the handler returns, allowing for a tick in flight
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&renewed) > n+1 {
+		t.Fatal("expected the lock renewal to stop")
+	}
+
+	if p.Topic() != "test.topic" || string(p.Message().Body) != "hello" {
+		t.Fatalf("unexpected publication %s %s", p.Topic(), p.Message().Body)
+	}
+	if h := p.Message().Header; h["Micro-Id"] != "1" || h["Count"] != "2" {
+		t.Fatalf("unexpected header %v", h)
+	}
+}
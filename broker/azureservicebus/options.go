@@ -0,0 +1,77 @@
+package azureservicebus
+
+import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+)
+
+type connectionStringKey struct{}
+type sessionsKey struct{}
+type lockRenewalKey struct{}
+type deadLetterKey struct{}
+type sessionIdKey struct{}
+type scheduleKey struct{}
+
+// ConnectionString sets the Service Bus namespace connection string
+func ConnectionString(cs string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, connectionStringKey{}, cs)
+	}
+}
+
+// Sessions creates a session enabled subscription so messages with
+// the same session id are processed in order by one subscriber
+func Sessions() broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, sessionsKey{}, true)
+	}
+}
+
+// LockRenewal renews the message lock at the interval while the handler runs
+func LockRenewal(d time.Duration) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, lockRenewalKey{}, d)
+	}
+}
+
+// DeadLetter moves messages to the dead letter queue when the handler
+// fails rather than abandoning them for redelivery
+func DeadLetter() broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, deadLetterKey{}, true)
+	}
+}
+
+// SessionID sets the session of the message for ordered processing
+func SessionID(id string) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, sessionIdKey{}, id)
+	}
+}
+
+// ScheduleAt delivers the message at the given time
+func ScheduleAt(t time.Time) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, scheduleKey{}, t)
+	}
+}
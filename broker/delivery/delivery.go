@@ -0,0 +1,231 @@
+// Package delivery provides message TTL and scheduled delivery publish
+// options shared by the brokers. Brokers which support them natively read
+// the options when publishing. For the others they're emulated with headers
+// by a broker wrapped with Wrap, whose subscribers drop expired messages
+// and re-publish messages received before their delivery time.
+package delivery
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/pborman/uuid"
+)
+
+var (
+	// ExpiresHeader holds the unix time in milliseconds a message expires
+	ExpiresHeader = "Micro-Expires"
+	// DeliverAtHeader holds the unix time in milliseconds a message is delivered
+	DeliverAtHeader = "Micro-Deliver-At"
+	// DeliverToHeader holds the subscriber a re-published message is for
+	DeliverToHeader = "Micro-Deliver-To"
+
+	// MaxHold is the longest a subscriber holds a message until its
+	// delivery time. Messages due later are re-published so the
+	// subscriber isn't blocked past the broker's ack deadline.
+	MaxHold = time.Second
+)
+
+type ttlKey struct{}
+type delayKey struct{}
+
+// TTL expires the message if it isn't delivered within the duration
+func TTL(d time.Duration) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, ttlKey{}, d)
+	}
+}
+
+// Delay delivers the message after the duration
+func Delay(d time.Duration) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, delayKey{}, d)
+	}
+}
+
+// At delivers the message at the time
+func At(t time.Time) broker.PublishOption {
+	return Delay(time.Until(t))
+}
+
+// GetTTL returns the TTL set in the publish options
+func GetTTL(o broker.PublishOptions) (time.Duration, bool) {
+	if o.Context == nil {
+		return 0, false
+	}
+	d, ok := o.Context.Value(ttlKey{}).(time.Duration)
+	return d, ok && d > 0
+}
+
+// GetDelay returns the delay set in the publish options
+func GetDelay(o broker.PublishOptions) (time.Duration, bool) {
+	if o.Context == nil {
+		return 0, false
+	}
+	d, ok := o.Context.Value(delayKey{}).(time.Duration)
+	return d, ok && d > 0
+}
+
+func millis(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+func header(m *broker.Message, key string) (time.Time, bool) {
+	v, ok := m.Header[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), true
+}
+
+// Headers returns a copy of the message with the expiry and delivery
+// time headers set from the publish options
+func Headers(m *broker.Message, o broker.PublishOptions) *broker.Message {
+	ttl, hasTTL := GetTTL(o)
+	delay, hasDelay := GetDelay(o)
+	if !hasTTL && !hasDelay {
+		return m
+	}
+
+	header := make(map[string]string, len(m.Header)+2)
+	for k, v := range m.Header {
+		header[k] = v
+	}
+
+	now := time.Now()
+	if hasDelay {
+		header[DeliverAtHeader] = millis(now.Add(delay))
+	}
+	if hasTTL {
+		// the ttl runs from the delivery time
+		header[ExpiresHeader] = millis(now.Add(delay + ttl))
+	}
+
+	return &broker.Message{
+		Header: header,
+		Body:   m.Body,
+	}
+}
+
+// Expired returns true if the message has passed its expiry time
+func Expired(m *broker.Message) bool {
+	t, ok := header(m, ExpiresHeader)
+	return ok && time.Now().After(t)
+}
+
+// Wait returns how long until the message should be delivered
+func Wait(m *broker.Message) time.Duration {
+	t, ok := header(m, DeliverAtHeader)
+	if !ok {
+		return 0
+	}
+	if d := time.Until(t); d > 0 {
+		return d
+	}
+	return 0
+}
+
+type deliveryBroker struct {
+	broker.Broker
+}
+
+// handler drops expired messages and holds messages until their delivery
+// time before calling h. Messages due after MaxHold are re-published with
+// the remaining delay, addressed to the subscriber by its id so the
+// other subscribers of the topic ignore them.
+type handler struct {
+	b     broker.Broker
+	id    string
+	topic string
+	h     broker.Handler
+}
+
+func (h *handler) Handle(p broker.Publication) error {
+	m := p.Message()
+
+	// re-published for another subscriber
+	if to, ok := m.Header[DeliverToHeader]; ok && to != h.id {
+		return nil
+	}
+
+	d := Wait(m)
+	if d > MaxHold {
+		time.Sleep(MaxHold)
+		return h.republish(m, d-MaxHold)
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+
+	if Expired(m) {
+		log.Logf("[delivery] dropping expired message on %s", p.Topic())
+		return nil
+	}
+
+	return h.h(p)
+}
+
+// republish publishes the message again to be delivered after the delay,
+// which brokers supporting delays natively hold
+func (h *handler) republish(m *broker.Message, delay time.Duration) error {
+	header := make(map[string]string, len(m.Header)+1)
+	for k, v := range m.Header {
+		header[k] = v
+	}
+	header[DeliverToHeader] = h.id
+
+	return h.b.Publish(h.topic, &broker.Message{
+		Header: header,
+		Body:   m.Body,
+	}, Delay(delay))
+}
+
+func (b *deliveryBroker) Publish(topic string, m *broker.Message, opts ...broker.PublishOption) error {
+	var options broker.PublishOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	return b.Broker.Publish(topic, Headers(m, options), opts...)
+}
+
+func (b *deliveryBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	var options broker.SubscribeOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// subscribers of a queue share messages re-published for it
+	id := options.Queue
+	if len(id) == 0 {
+		id = uuid.NewUUID().String()
+	}
+
+	hd := &handler{
+		// the delivery time header is kept as it is
+		b:     b.Broker,
+		id:    id,
+		topic: topic,
+		h:     h,
+	}
+
+	return b.Broker.Subscribe(topic, hd.Handle, opts...)
+}
+
+// Wrap returns a broker emulating TTL and scheduled delivery with headers.
+// Options are still passed to the broker so native support is used first.
+func Wrap(b broker.Broker) broker.Broker {
+	return &deliveryBroker{b}
+}
@@ -0,0 +1,145 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/broker/memory"
+)
+
+func TestHeaders(t *testing.T) {
+	var options broker.PublishOptions
+	for _, o := range []broker.PublishOption{TTL(time.Millisecond * 50), Delay(time.Millisecond * 20)} {
+		o(&options)
+	}
+
+	m := Headers(&broker.Message{Header: map[string]string{"foo": "bar"}}, options)
+	if m.Header["foo"] != "bar" {
+		t.Fatal("expected headers to be copied")
+	}
+
+	if d := Wait(m); d <= 0 || d > time.Millisecond*20 {
+		t.Fatalf("expected to wait up to 20ms got %v", d)
+	}
+	if Expired(m) {
+		t.Fatal("expected message not to have expired")
+	}
+
+	time.Sleep(time.Millisecond * 80)
+
+	if Wait(m) != 0 {
+		t.Fatal("expected no wait once delivered")
+	}
+	if !Expired(m) {
+		t.Fatal("expected message to have expired")
+	}
+
+	// messages without options are unchanged
+	plain := &broker.Message{}
+	if Headers(plain, broker.PublishOptions{}) != plain || Expired(plain) || Wait(plain) != 0 {
+		t.Fatal("expected plain message to be unchanged")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	b := Wrap(memory.NewBroker())
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+
+	ch := make(chan time.Time, 2)
+	sub, err := b.Subscribe("test", func(p broker.Publication) error {
+		ch <- time.Now()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	start := time.Now()
+	if err := b.Publish("test", &broker.Message{Body: []byte(`delayed`)}, Delay(time.Millisecond*30)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case at := <-ch:
+		if at.Sub(start) < time.Millisecond*30 {
+			t.Fatal("expected delivery to be delayed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected message to be delivered")
+	}
+
+	// expired messages are dropped
+	if err := b.Publish("test", &broker.Message{Body: []byte(`expired`)}, TTL(time.Nanosecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ch:
+		t.Fatal("expected expired message to be dropped")
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestRepublish(t *testing.T) {
+	hold := MaxHold
+	MaxHold = time.Millisecond * 10
+	defer func() {
+		MaxHold = hold
+	}()
+
+	b := Wrap(memory.NewBroker())
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+
+	type delivered struct {
+		queue string
+		at    time.Time
+	}
+
+	ch := make(chan delivered, 10)
+	for _, queue := range []string{"a", "b"} {
+		queue := queue
+		sub, err := b.Subscribe("test", func(p broker.Publication) error {
+			ch <- delivered{queue, time.Now()}
+			return nil
+		}, broker.Queue(queue))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sub.Unsubscribe()
+	}
+
+	start := time.Now()
+	go b.Publish("test", &broker.Message{Body: []byte(`delayed`)}, Delay(time.Millisecond*50))
+
+	// each subscriber gets the message once at its delivery time
+	got := make(map[string]int)
+	for i := 0; i < 2; i++ {
+		select {
+		case d := <-ch:
+			if d.at.Sub(start) < time.Millisecond*50 {
+				t.Fatal("expected delivery to be delayed")
+			}
+			got[d.queue]++
+		case <-time.After(time.Second):
+			t.Fatal("expected message to be delivered")
+		}
+	}
+
+	select {
+	case d := <-ch:
+		t.Fatalf("unexpected delivery to %s", d.queue)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	if got["a"] != 1 || got["b"] != 1 {
+		t.Fatalf("expected one delivery per subscriber got %v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package eventbridge
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/micro/go-micro/broker"
+)
+
+// detail is the event detail of a published message. JSON bodies are
+// embedded as is so rules can match on them, other bodies are base64.
+type detail struct {
+	Header     map[string]string `json:"header,omitempty"`
+	Data       json.RawMessage   `json:"data,omitempty"`
+	DataBase64 []byte            `json:"data_base64,omitempty"`
+}
+
+// event is the envelope delivered by EventBridge to the SQS target
+type event struct {
+	Id         string          `json:"id"`
+	DetailType string          `json:"detail-type"`
+	Source     string          `json:"source"`
+	Time       string          `json:"time"`
+	Detail     json.RawMessage `json:"detail"`
+}
+
+func encode(m *broker.Message) (string, error) {
+	d := &detail{
+		Header: m.Header,
+	}
+
+	if json.Valid(m.Body) {
+		d.Data = m.Body
+	} else {
+		d.DataBase64 = m.Body
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decode returns the message for the event. Events not published by the
+// broker are delivered with their detail as the body.
+func decode(b []byte) (*broker.Message, error) {
+	var e event
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+
+	if len(e.Detail) == 0 {
+		return nil, errors.New("event has no detail")
+	}
+
+	header := map[string]string{
+		"EventBridge-Id":          e.Id,
+		"EventBridge-Source":      e.Source,
+		"EventBridge-Detail-Type": e.DetailType,
+		"EventBridge-Time":        e.Time,
+	}
+
+	var d detail
+	if err := json.Unmarshal(e.Detail, &d); err != nil || (d.Header == nil && d.Data == nil && d.DataBase64 == nil) {
+		return &broker.Message{
+			Header: header,
+			Body:   e.Detail,
+		}, nil
+	}
+
+	for k, v := range d.Header {
+		header[k] = v
+	}
+
+	body := []byte(d.Data)
+	if d.DataBase64 != nil {
+		body = d.DataBase64
+	}
+
+	return &broker.Message{
+		Header: header,
+		Body:   body,
+	}, nil
+}
@@ -0,0 +1,484 @@
+// Package eventbridge provides an AWS EventBridge broker. Messages are published
+// to an event bus and subscribers receive them through SQS queues which are
+// provisioned and attached to the bus with a rule.
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/cmd"
+	"github.com/pborman/uuid"
+)
+
+const (
+	defaultBus               = "default"
+	defaultSource            = "go.micro"
+	defaultQueuePrefix       = "micro-"
+	defaultMaxMessages       = 1
+	defaultVisibilityTimeout = 30
+	defaultWaitSeconds       = 10
+	defaultMaxReceives       = 10
+	// id of the queue target on the rule
+	targetId = "micro"
+)
+
+var (
+	// characters not allowed in queue and rule names
+	invalidName = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+)
+
+type ebBroker struct {
+	sync.RWMutex
+	options broker.Options
+	eb      eventbridgeiface.EventBridgeAPI
+	sqs     sqsiface.SQSAPI
+}
+
+// A subscriber polling the queue attached to the bus
+type subscriber struct {
+	options broker.SubscribeOptions
+	topic   string
+	rule    string
+	url     string
+	// the queue and rule are removed on unsubscribe
+	ephemeral bool
+
+	b    *ebBroker
+	exit chan bool
+	once sync.Once
+}
+
+type publication struct {
+	topic string
+	m     *broker.Message
+	sm    *sqs.Message
+	s     *subscriber
+}
+
+func init() {
+	cmd.DefaultBrokers["eventbridge"] = NewBroker
+}
+
+func (p *publication) Topic() string {
+	return p.topic
+}
+
+func (p *publication) Message() *broker.Message {
+	return p.m
+}
+
+func (p *publication) Ack() error {
+	_, err := p.s.b.sqs.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(p.s.url),
+		ReceiptHandle: p.sm.ReceiptHandle,
+	})
+	return err
+}
+
+func (s *subscriber) Options() broker.SubscribeOptions {
+	return s.options
+}
+
+func (s *subscriber) Topic() string {
+	return s.topic
+}
+
+func (s *subscriber) Unsubscribe() error {
+	var err error
+	s.once.Do(func() {
+		close(s.exit)
+		if s.ephemeral {
+			err = s.b.deprovision(s.rule, s.url)
+		}
+	})
+	return err
+}
+
+func (s *subscriber) int64Option(key interface{}, def int64) *int64 {
+	if v, ok := s.options.Context.Value(key).(int64); ok {
+		return aws.Int64(v)
+	}
+	return aws.Int64(def)
+}
+
+func (s *subscriber) run(h broker.Handler) {
+	log.Logf("EventBridge subscription started. Topic: %s, Queue: %s", s.topic, s.url)
+
+	for {
+		select {
+		case <-s.exit:
+			return
+		default:
+		}
+
+		rsp, err := s.b.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.url),
+			MaxNumberOfMessages: s.int64Option(maxMessagesKey{}, defaultMaxMessages),
+			VisibilityTimeout:   s.int64Option(visibilityTimeoutKey{}, defaultVisibilityTimeout),
+			WaitTimeSeconds:     s.int64Option(waitTimeSecondsKey{}, defaultWaitSeconds),
+			AttributeNames: aws.StringSlice([]string{
+				sqs.MessageSystemAttributeNameApproximateReceiveCount,
+			}),
+		})
+		if err != nil {
+			log.Logf("Error receiving EventBridge message: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, sm := range rsp.Messages {
+			s.handle(sm, h)
+		}
+	}
+}
+
+func (s *subscriber) handle(sm *sqs.Message, h broker.Handler) {
+	p := &publication{
+		topic: s.topic,
+		sm:    sm,
+		s:     s,
+	}
+
+	// poison messages are deleted once received too many times
+	// so they aren't redelivered forever
+	max := aws.Int64Value(s.int64Option(maxReceivesKey{}, defaultMaxReceives))
+	count, _ := strconv.ParseInt(aws.StringValue(sm.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]), 10, 64)
+	if max > 0 && count > max {
+		log.Logf("Dropping EventBridge message %s received %d times", aws.StringValue(sm.MessageId), count)
+		if err := p.Ack(); err != nil {
+			log.Logf("Failed to delete EventBridge message: %v", err)
+		}
+		return
+	}
+
+	m, err := decode([]byte(aws.StringValue(sm.Body)))
+	if err != nil {
+		log.Logf("Dropping EventBridge event which can't be decoded: %v", err)
+		if err := p.Ack(); err != nil {
+			log.Logf("Failed to delete EventBridge message: %v", err)
+		}
+		return
+	}
+	p.m = m
+
+	if err := h(p); err != nil {
+		log.Logf("Error handling EventBridge event: %v", err)
+		return
+	}
+
+	if s.options.AutoAck {
+		if err := p.Ack(); err != nil {
+			log.Logf("Failed auto-acknowledge of message: %v", err)
+		}
+	}
+}
+
+func (b *ebBroker) stringOption(key interface{}, def string) string {
+	if v, ok := b.options.Context.Value(key).(string); ok && len(v) > 0 {
+		return v
+	}
+	return def
+}
+
+func (b *ebBroker) source(topic string) string {
+	if fn, ok := b.options.Context.Value(sourceKey{}).(MappingFunc); ok {
+		return fn(topic)
+	}
+	return defaultSource
+}
+
+func (b *ebBroker) detailType(topic string) string {
+	if fn, ok := b.options.Context.Value(detailTypeKey{}).(MappingFunc); ok {
+		return fn(topic)
+	}
+	return topic
+}
+
+// name returns a valid queue and rule name
+func (b *ebBroker) name(s string) string {
+	name := b.stringOption(queuePrefixKey{}, defaultQueuePrefix) + invalidName.ReplaceAllString(s, "-")
+	// rule names are limited to 64 characters, names are
+	// kept unique when truncated by a hash of the whole name
+	if len(name) > 64 {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		name = fmt.Sprintf("%s-%08x", name[:55], h.Sum32())
+	}
+	return name
+}
+
+// pattern matches events published to the topic
+func (b *ebBroker) pattern(topic string) (string, error) {
+	p, err := json.Marshal(map[string][]string{
+		"source":      {b.source(topic)},
+		"detail-type": {b.detailType(topic)},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(p), nil
+}
+
+// provision creates the queue and the rule forwarding matching events to it
+func (b *ebBroker) provision(name, pattern string) (string, error) {
+	bus := b.stringOption(busKey{}, defaultBus)
+
+	q, err := b.sqs.CreateQueue(&sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create queue %s: %v", name, err)
+	}
+
+	attrs, err := b.sqs.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       q.QueueUrl,
+		AttributeNames: aws.StringSlice([]string{sqs.QueueAttributeNameQueueArn}),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get queue arn %s: %v", name, err)
+	}
+	queueArn := aws.StringValue(attrs.Attributes[sqs.QueueAttributeNameQueueArn])
+
+	rule, err := b.eb.PutRule(&eventbridge.PutRuleInput{
+		Name:         aws.String(name),
+		EventBusName: aws.String(bus),
+		EventPattern: aws.String(pattern),
+		Description:  aws.String("go-micro subscriber"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create rule %s: %v", name, err)
+	}
+
+	// allow the rule to send to the queue
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{{
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": "events.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueArn,
+			"Condition": map[string]interface{}{
+				"ArnEquals": map[string]string{"aws:SourceArn": aws.StringValue(rule.RuleArn)},
+			},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := b.sqs.SetQueueAttributes(&sqs.SetQueueAttributesInput{
+		QueueUrl: q.QueueUrl,
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNamePolicy: aws.String(string(policy)),
+		},
+	}); err != nil {
+		return "", fmt.Errorf("failed to set queue policy %s: %v", name, err)
+	}
+
+	rsp, err := b.eb.PutTargets(&eventbridge.PutTargetsInput{
+		Rule:         aws.String(name),
+		EventBusName: aws.String(bus),
+		Targets: []*eventbridge.Target{{
+			Id:  aws.String(targetId),
+			Arn: aws.String(queueArn),
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach queue to rule %s: %v", name, err)
+	}
+	if n := aws.Int64Value(rsp.FailedEntryCount); n > 0 {
+		return "", fmt.Errorf("failed to attach queue to rule %s: %s", name, aws.StringValue(rsp.FailedEntries[0].ErrorMessage))
+	}
+
+	return aws.StringValue(q.QueueUrl), nil
+}
+
+// deprovision removes the rule and queue of an ephemeral subscriber
+func (b *ebBroker) deprovision(name, url string) error {
+	bus := b.stringOption(busKey{}, defaultBus)
+
+	if _, err := b.eb.RemoveTargets(&eventbridge.RemoveTargetsInput{
+		Rule:         aws.String(name),
+		EventBusName: aws.String(bus),
+		Ids:          aws.StringSlice([]string{targetId}),
+	}); err != nil {
+		return err
+	}
+
+	if _, err := b.eb.DeleteRule(&eventbridge.DeleteRuleInput{
+		Name:         aws.String(name),
+		EventBusName: aws.String(bus),
+	}); err != nil {
+		return err
+	}
+
+	_, err := b.sqs.DeleteQueue(&sqs.DeleteQueueInput{
+		QueueUrl: aws.String(url),
+	})
+	return err
+}
+
+func (b *ebBroker) Options() broker.Options {
+	return b.options
+}
+
+func (b *ebBroker) Address() string {
+	return b.stringOption(busKey{}, defaultBus)
+}
+
+func (b *ebBroker) Connect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.eb != nil && b.sqs != nil {
+		return nil
+	}
+
+	sess, ok := b.options.Context.Value(sessionKey{}).(*session.Session)
+	if !ok {
+		var err error
+		sess, err = session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.eb == nil {
+		b.eb = eventbridge.New(sess)
+	}
+	if b.sqs == nil {
+		b.sqs = sqs.New(sess)
+	}
+
+	return nil
+}
+
+// Disconnect does nothing as there's no live connection to terminate
+func (b *ebBroker) Disconnect() error {
+	return nil
+}
+
+func (b *ebBroker) Init(opts ...broker.Option) error {
+	for _, o := range opts {
+		o(&b.options)
+	}
+	return nil
+}
+
+// Publish puts an event on the bus with the topic mapped to its source and detail-type
+func (b *ebBroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	detail, err := encode(msg)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := b.eb.PutEvents(&eventbridge.PutEventsInput{
+		Entries: []*eventbridge.PutEventsRequestEntry{{
+			EventBusName: aws.String(b.stringOption(busKey{}, defaultBus)),
+			Source:       aws.String(b.source(topic)),
+			DetailType:   aws.String(b.detailType(topic)),
+			Detail:       aws.String(detail),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if aws.Int64Value(rsp.FailedEntryCount) > 0 && len(rsp.Entries) > 0 {
+		e := rsp.Entries[0]
+		return fmt.Errorf("failed to publish event: %s %s", aws.StringValue(e.ErrorCode), aws.StringValue(e.ErrorMessage))
+	}
+
+	return nil
+}
+
+// Subscribe provisions a queue and rule for the topic. Subscribers with the same
+// queue share a durable queue, otherwise the queue is removed on unsubscribe.
+func (b *ebBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.SubscribeOptions{
+		AutoAck: true,
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// a named queue is shared by the subscribers of the topic
+	ephemeral := len(options.Queue) == 0
+	name := b.name(options.Queue + "-" + topic)
+	if ephemeral {
+		// keep the unique suffix within the name limit
+		id := strings.Replace(uuid.NewUUID().String(), "-", "", -1)
+		name = b.name(topic)
+		if len(name) > 64-len(id)-1 {
+			name = name[:64-len(id)-1]
+		}
+		name = name + "-" + id
+	}
+
+	pattern, ok := options.Context.Value(patternKey{}).(string)
+	if !ok {
+		p, err := b.pattern(topic)
+		if err != nil {
+			return nil, err
+		}
+		pattern = p
+	}
+
+	url, err := b.provision(name, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &subscriber{
+		options:   options,
+		topic:     topic,
+		rule:      name,
+		url:       url,
+		ephemeral: ephemeral,
+		b:         b,
+		exit:      make(chan bool),
+	}
+
+	go s.run(h)
+
+	return s, nil
+}
+
+func (b *ebBroker) String() string {
+	return "eventbridge"
+}
+
+// NewBroker returns a new EventBridge broker
+func NewBroker(opts ...broker.Option) broker.Broker {
+	options := broker.Options{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &ebBroker{
+		options: options,
+	}
+}
@@ -0,0 +1,151 @@
+package eventbridge
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/micro/go-micro/broker"
+)
+
+type testSQS struct {
+	sqsiface.SQSAPI
+	deleted []string
+}
+
+func (t *testSQS) DeleteMessage(in *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	t.deleted = append(t.deleted, aws.StringValue(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestEncodeDecode(t *testing.T) {
+	testData := []*broker.Message{
+		{Header: map[string]string{"Content-Type": "application/json"}, Body: []byte(`{"foo":"bar"}`)},
+		{Header: map[string]string{"Content-Type": "application/protobuf"}, Body: []byte{0x0a, 0x03, 0xff}},
+	}
+
+	for _, m := range testData {
+		detail, err := encode(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := json.Marshal(map[string]interface{}{
+			"id":          "1",
+			"source":      "go.micro",
+			"detail-type": "foo",
+			"detail":      json.RawMessage(detail),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rm, err := decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(rm.Body) != string(m.Body) {
+			t.Fatalf("expected body %q got %q", m.Body, rm.Body)
+		}
+		if ct := rm.Header["Content-Type"]; ct != m.Header["Content-Type"] {
+			t.Fatalf("expected content type %s got %s", m.Header["Content-Type"], ct)
+		}
+		if rm.Header["EventBridge-Detail-Type"] != "foo" {
+			t.Fatalf("expected detail type header got %v", rm.Header)
+		}
+	}
+}
+
+func TestDecodeForeignEvent(t *testing.T) {
+	b := []byte(`{"id":"1","source":"aws.s3","detail-type":"Object Created","detail":{"bucket":{"name":"foo"}}}`)
+
+	m, err := decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(m.Body) != `{"bucket":{"name":"foo"}}` {
+		t.Fatalf("expected the detail as the body got %s", m.Body)
+	}
+	if m.Header["EventBridge-Source"] != "aws.s3" {
+		t.Fatalf("expected source header got %v", m.Header)
+	}
+}
+
+func TestName(t *testing.T) {
+	b := NewBroker().(*ebBroker)
+
+	if n := b.name("go.micro.topic.foo"); n != "micro-go-micro-topic-foo" {
+		t.Fatalf("unexpected name %s", n)
+	}
+
+	// truncated names stay unique
+	long := strings.Repeat("a", 70)
+	n1, n2 := b.name(long+"-foo"), b.name(long+"-bar")
+	if len(n1) > 64 || len(n2) > 64 {
+		t.Fatalf("names exceed the limit: %s %s", n1, n2)
+	}
+	if n1 == n2 {
+		t.Fatalf("truncated names collide: %s", n1)
+	}
+
+	p, err := b.pattern("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != `{"detail-type":["foo"],"source":["go.micro"]}` {
+		t.Fatalf("unexpected pattern %s", p)
+	}
+}
+
+func TestPoisonMessage(t *testing.T) {
+	fake := &testSQS{}
+	b := NewBroker().(*ebBroker)
+	b.sqs = fake
+
+	options := broker.SubscribeOptions{AutoAck: true}
+	MaxReceives(3)(&options)
+
+	s := &subscriber{
+		options: options,
+		topic:   "foo",
+		b:       b,
+	}
+
+	body := `{"id":"1","source":"go.micro","detail-type":"foo","detail":{"data":{"foo":"bar"}}}`
+
+	var handled int
+	h := func(broker.Publication) error {
+		handled++
+		return errors.New("failed")
+	}
+
+	msg := func(handle, count, body string) *sqs.Message {
+		return &sqs.Message{
+			Body:          aws.String(body),
+			ReceiptHandle: aws.String(handle),
+			Attributes: map[string]*string{
+				sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String(count),
+			},
+		}
+	}
+
+	// a failed message is redelivered
+	s.handle(msg("a", "3", body), h)
+	// received too many times
+	s.handle(msg("b", "4", body), h)
+	// can't be decoded
+	s.handle(msg("c", "1", "{"), h)
+
+	if handled != 1 {
+		t.Fatalf("expected 1 message handled got %d", handled)
+	}
+	if strings.Join(fake.deleted, ",") != "b,c" {
+		t.Fatalf("expected b and c deleted got %v", fake.deleted)
+	}
+}
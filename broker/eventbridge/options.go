@@ -0,0 +1,127 @@
+package eventbridge
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/micro/go-micro/broker"
+)
+
+type sessionKey struct{}
+type busKey struct{}
+type sourceKey struct{}
+type detailTypeKey struct{}
+type queuePrefixKey struct{}
+type patternKey struct{}
+type maxMessagesKey struct{}
+type visibilityTimeoutKey struct{}
+type waitTimeSecondsKey struct{}
+type maxReceivesKey struct{}
+
+// MappingFunc maps a topic to an EventBridge field
+type MappingFunc func(topic string) string
+
+// Session sets the AWS session used for the EventBridge and SQS clients
+func Session(s *session.Session) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, sessionKey{}, s)
+	}
+}
+
+// Bus sets the event bus name or ARN, defaults to the default bus
+func Bus(name string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, busKey{}, name)
+	}
+}
+
+// Source sets the function mapping a topic to the event source.
+// The source is go.micro by default.
+func Source(fn MappingFunc) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, sourceKey{}, fn)
+	}
+}
+
+// DetailType sets the function mapping a topic to the event detail-type.
+// The detail-type is the topic by default.
+func DetailType(fn MappingFunc) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, detailTypeKey{}, fn)
+	}
+}
+
+// QueuePrefix sets the prefix of the SQS queues and rules provisioned for subscribers
+func QueuePrefix(p string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, queuePrefixKey{}, p)
+	}
+}
+
+// Pattern overrides the event pattern of the rule created for a subscriber
+// e.g to receive events published by other AWS services
+func Pattern(pattern string) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, patternKey{}, pattern)
+	}
+}
+
+// MaxReceiveMessages sets how many messages are received from the queue in one call
+func MaxReceiveMessages(max int64) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, maxMessagesKey{}, max)
+	}
+}
+
+// VisibilityTimeout sets how long a received message is hidden from other consumers
+func VisibilityTimeout(seconds int64) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, visibilityTimeoutKey{}, seconds)
+	}
+}
+
+// WaitTimeSeconds sets the long polling time for messages
+func WaitTimeSeconds(seconds int64) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, waitTimeSecondsKey{}, seconds)
+	}
+}
+
+// MaxReceives sets how many times a message is received before it's
+// dropped, so messages which always fail aren't redelivered forever.
+// The default is 10, zero never drops messages.
+func MaxReceives(n int64) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, maxReceivesKey{}, n)
+	}
+}
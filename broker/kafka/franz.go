@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-plugins/broker/security"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/oauth"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// franzDriver uses a franz-go client to publish
+// and a franz-go client per consumer group
+type franzDriver struct {
+	opts []kgo.Opt
+	p    *kgo.Client
+
+	sync.Mutex
+	consumers map[*kgo.Client]bool
+}
+
+func newFranzDriver() driver {
+	return &franzDriver{
+		consumers: make(map[*kgo.Client]bool),
+	}
+}
+
+// franzOpts returns the client options for the TLS and SASL options
+func franzOpts(addrs []string, opts broker.Options) ([]kgo.Opt, error) {
+	if err := security.Err(opts); err != nil {
+		return nil, err
+	}
+
+	kopts := []kgo.Opt{
+		kgo.SeedBrokers(addrs...),
+	}
+
+	if opts.Secure || opts.TLSConfig != nil {
+		config := opts.TLSConfig
+		if config == nil {
+			config = &tls.Config{}
+		}
+		kopts = append(kopts, kgo.DialTLSConfig(config))
+	}
+
+	s, ok := security.GetSASL(opts)
+	if !ok {
+		return kopts, nil
+	}
+
+	var m sasl.Mechanism
+
+	switch s.Mechanism {
+	case security.Plain:
+		m = plain.Auth{User: s.Username, Pass: s.Password}.AsMechanism()
+	case security.ScramSHA256:
+		m = scram.Auth{User: s.Username, Pass: s.Password}.AsSha256Mechanism()
+	case security.ScramSHA512:
+		m = scram.Auth{User: s.Username, Pass: s.Password}.AsSha512Mechanism()
+	case security.OAuthBearer:
+		fn := s.Token
+		m = oauth.Oauth(func(context.Context) (oauth.Auth, error) {
+			token, err := fn()
+			return oauth.Auth{Token: token}, err
+		})
+	default:
+		return nil, security.ErrUnsupported("kafka", s.Mechanism)
+	}
+
+	return append(kopts, kgo.SASL(m)), nil
+}
+
+func (f *franzDriver) Connect(addrs []string, opts broker.Options) error {
+	kopts, err := franzOpts(addrs, opts)
+	if err != nil {
+		return err
+	}
+
+	p, err := kgo.NewClient(kopts...)
+	if err != nil {
+		return err
+	}
+
+	f.opts = kopts
+	f.p = p
+
+	return nil
+}
+
+func (f *franzDriver) Disconnect() error {
+	f.Lock()
+	for c := range f.consumers {
+		c.Close()
+	}
+	f.consumers = make(map[*kgo.Client]bool)
+	f.Unlock()
+
+	f.p.Close()
+	return nil
+}
+
+func (f *franzDriver) Publish(topic string, key []byte, msg *broker.Message) error {
+	r := &kgo.Record{
+		Topic: topic,
+		Key:   key,
+		Value: msg.Body,
+	}
+
+	for k, v := range msg.Header {
+		r.Headers = append(r.Headers, kgo.RecordHeader{
+			Key:   k,
+			Value: []byte(v),
+		})
+	}
+
+	return f.p.ProduceSync(context.Background(), r).FirstErr()
+}
+
+func (f *franzDriver) Subscribe(topic, group string, fn func(*record)) (func() error, error) {
+	opts := append(f.opts[:len(f.opts):len(f.opts)],
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()),
+		// only acked records are committed
+		kgo.AutoCommitMarks(),
+	)
+
+	c, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	f.consumers[c] = true
+	f.Unlock()
+
+	go func() {
+		for {
+			fetches := c.PollFetches(context.Background())
+			if fetches.IsClientClosed() {
+				return
+			}
+
+			fetches.EachError(func(t string, p int32, err error) {
+				log.Log("consumer error:", err)
+			})
+
+			fetches.EachRecord(func(kr *kgo.Record) {
+				var headers map[string]string
+				if len(kr.Headers) > 0 {
+					headers = make(map[string]string, len(kr.Headers))
+					for _, h := range kr.Headers {
+						headers[h.Key] = string(h.Value)
+					}
+				}
+
+				fn(&record{
+					topic:   kr.Topic,
+					value:   kr.Value,
+					headers: headers,
+					ack: func() error {
+						c.MarkCommitRecords(kr)
+						return nil
+					},
+				})
+			})
+		}
+	}()
+
+	stop := func() error {
+		f.Lock()
+		delete(f.consumers, c)
+		f.Unlock()
+
+		// commit what was acked before leaving the group
+		err := c.CommitMarkedOffsets(context.Background())
+		c.Close()
+		return err
+	}
+
+	return stop, nil
+}
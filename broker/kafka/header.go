@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/broker/codec"
+)
+
+// recordHeaders maps the message header to record headers
+func recordHeaders(header map[string]string) []sarama.RecordHeader {
+	headers := make([]sarama.RecordHeader, 0, len(header))
+	for k, v := range header {
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(k),
+			Value: []byte(v),
+		})
+	}
+	return headers
+}
+
+// saramaHeaders maps record headers to the message header
+func saramaHeaders(headers []*sarama.RecordHeader) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	header := make(map[string]string, len(headers))
+	for _, h := range headers {
+		if h == nil {
+			continue
+		}
+		header[string(h.Key)] = string(h.Value)
+	}
+	return header
+}
+
+// decode returns the message for the record. Records published without
+// RecordHeaders hold the message encoded with the codec.
+func decode(c codec.Codec, r *record) *broker.Message {
+	if len(r.headers) > 0 {
+		return &broker.Message{
+			Header: r.headers,
+			Body:   r.value,
+		}
+	}
+
+	var m broker.Message
+	if err := c.Unmarshal(r.value, &m); err != nil || (m.Header == nil && m.Body == nil) {
+		// a record without headers from another producer
+		return &broker.Message{
+			Header: map[string]string{},
+			Body:   r.value,
+		}
+	}
+
+	return &m
+}
@@ -1,42 +1,63 @@
-// Package kafka provides a kafka broker using sarama cluster
+// Package kafka provides a kafka broker using sarama cluster or franz-go
 package kafka
 
 import (
+	"fmt"
 	"sync"
 
-	"github.com/Shopify/sarama"
-	"github.com/micro/go-log"
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/broker/codec/json"
 	"github.com/micro/go-micro/cmd"
 	"github.com/pborman/uuid"
-	sc "gopkg.in/bsm/sarama-cluster.v2"
 )
 
+// driver is the kafka client the broker publishes and consumes with
+type driver interface {
+	Connect(addrs []string, opts broker.Options) error
+	Disconnect() error
+	Publish(topic string, key []byte, msg *broker.Message) error
+	// Subscribe consumes the topic in the group calling fn for each
+	// record, returning a func which stops consuming
+	Subscribe(topic, group string, fn func(*record)) (func() error, error)
+}
+
+// record is a consumed record
+type record struct {
+	topic string
+	value []byte
+	// headers are nil for records without headers
+	headers map[string]string
+	// ack marks the record consumed
+	ack func() error
+}
+
 type kBroker struct {
 	addrs []string
 
-	c  sarama.Client
-	p  sarama.SyncProducer
-	sc []*sc.Client
-
-	scMutex sync.Mutex
-	opts    broker.Options
+	sync.Mutex
+	d    driver
+	opts broker.Options
 }
 
 type subscriber struct {
-	s    *sc.Consumer
 	t    string
 	opts broker.SubscribeOptions
+	stop func() error
 }
 
 type publication struct {
-	t  string
-	c  *sc.Consumer
-	km *sarama.ConsumerMessage
-	m  *broker.Message
+	t string
+	r *record
+	m *broker.Message
 }
 
+var (
+	drivers = map[string]func() driver{
+		SaramaDriver: newSaramaDriver,
+		FranzDriver:  newFranzDriver,
+	}
+)
+
 func init() {
 	cmd.DefaultBrokers["kafka"] = NewBroker
 }
@@ -50,8 +71,7 @@ func (p *publication) Message() *broker.Message {
 }
 
 func (p *publication) Ack() error {
-	p.c.MarkOffset(p.km, "")
-	return nil
+	return p.r.ack()
 }
 
 func (s *subscriber) Options() broker.SubscribeOptions {
@@ -63,7 +83,7 @@ func (s *subscriber) Topic() string {
 }
 
 func (s *subscriber) Unsubscribe() error {
-	return s.s.Close()
+	return s.stop()
 }
 
 func (k *kBroker) Address() string {
@@ -74,46 +94,45 @@ func (k *kBroker) Address() string {
 }
 
 func (k *kBroker) Connect() error {
-	if k.c != nil {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.d != nil {
 		return nil
 	}
 
-	pconfig := sarama.NewConfig()
-	// For implementation reasons, the SyncProducer requires
-	// `Producer.Return.Errors` and `Producer.Return.Successes`
-	// to be set to true in its configuration.
-	pconfig.Producer.Return.Successes = true
-	pconfig.Producer.Return.Errors = true
-
-	c, err := sarama.NewClient(k.addrs, pconfig)
-	if err != nil {
-		return err
+	name := SaramaDriver
+	if k.opts.Context != nil {
+		if n, ok := k.opts.Context.Value(driverKey{}).(string); ok && len(n) > 0 {
+			name = n
+		}
 	}
 
-	k.c = c
+	newDriver, ok := drivers[name]
+	if !ok {
+		return fmt.Errorf("unknown kafka driver %s", name)
+	}
 
-	p, err := sarama.NewSyncProducerFromClient(c)
-	if err != nil {
+	d := newDriver()
+	if err := d.Connect(k.addrs, k.opts); err != nil {
 		return err
 	}
 
-	k.p = p
-	k.scMutex.Lock()
-	defer k.scMutex.Unlock()
-	k.sc = make([]*sc.Client, 0)
-
+	k.d = d
 	return nil
 }
 
 func (k *kBroker) Disconnect() error {
-	k.scMutex.Lock()
-	defer k.scMutex.Unlock()
-	for _, client := range k.sc {
-		client.Close()
+	k.Lock()
+	defer k.Unlock()
+
+	if k.d == nil {
+		return nil
 	}
-	k.sc = nil
-	k.p.Close()
-	return k.c.Close()
+
+	err := k.d.Disconnect()
+	k.d = nil
+	return err
 }
 
 func (k *kBroker) Init(opts ...broker.Option) error {
@@ -127,33 +146,44 @@ func (k *kBroker) Options() broker.Options {
 	return k.opts
 }
 
+func (k *kBroker) getDriver() (driver, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.d == nil {
+		return nil, fmt.Errorf("kafka broker not connected")
+	}
+	return k.d, nil
+}
+
 func (k *kBroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
-	b, err := k.opts.Codec.Marshal(msg)
+	var options broker.PublishOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	d, err := k.getDriver()
 	if err != nil {
 		return err
 	}
-	_, _, err = k.p.SendMessage(&sarama.ProducerMessage{
-		Topic: topic,
-		Value: sarama.ByteEncoder(b),
-	})
-	return err
-}
 
-func (k *kBroker) getSaramaClusterClient(topic string) (*sc.Client, error) {
-	config := sc.NewConfig()
-
-	// TODO: make configurable offset as SubscriberOption
-	config.Config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	var key []byte
+	if options.Context != nil {
+		if s, ok := options.Context.Value(keyKey{}).(string); ok {
+			key = []byte(s)
+		}
+	}
 
-	cs, err := sc.NewClient(k.addrs, config)
-	if err != nil {
-		return nil, err
+	// the message is encoded in the value unless record headers are used
+	if !useRecordHeaders(k.opts) {
+		b, err := k.opts.Codec.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		msg = &broker.Message{Body: b}
 	}
 
-	k.scMutex.Lock()
-	defer k.scMutex.Unlock()
-	k.sc = append(k.sc, cs)
-	return cs, nil
+	return d.Publish(topic, key, msg)
 }
 
 func (k *kBroker) Subscribe(topic string, handler broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -166,44 +196,25 @@ func (k *kBroker) Subscribe(topic string, handler broker.Handler, opts ...broker
 		o(&opt)
 	}
 
-	// we need to create a new client per consumer
-	cs, err := k.getSaramaClusterClient(topic)
+	d, err := k.getDriver()
 	if err != nil {
 		return nil, err
 	}
 
-	c, err := sc.NewConsumerFromClient(cs, opt.Queue, []string{topic})
+	stop, err := d.Subscribe(topic, opt.Queue, func(r *record) {
+		if err := handler(&publication{
+			m: decode(k.opts.Codec, r),
+			t: r.topic,
+			r: r,
+		}); err == nil && opt.AutoAck {
+			r.ack()
+		}
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	go func() {
-		for {
-			select {
-			case err := <-c.Errors():
-				log.Log("consumer error:", err)
-			case sm := <-c.Messages():
-				// ensure message is not nil
-				if sm == nil {
-					continue
-				}
-				var m broker.Message
-				if err := k.opts.Codec.Unmarshal(sm.Value, &m); err != nil {
-					continue
-				}
-				if err := handler(&publication{
-					m:  &m,
-					t:  sm.Topic,
-					c:  c,
-					km: sm,
-				}); err == nil && opt.AutoAck {
-					c.MarkOffset(sm, "")
-				}
-			}
-		}
-	}()
-
-	return &subscriber{s: c, opts: opt}, nil
+	return &subscriber{t: topic, opts: opt, stop: stop}, nil
 }
 
 func (k *kBroker) String() string {
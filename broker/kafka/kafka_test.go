@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/broker/codec/json"
+)
+
+func TestDecode(t *testing.T) {
+	c := json.NewCodec()
+
+	// record headers
+	m := decode(c, &record{
+		headers: saramaHeaders([]*sarama.RecordHeader{{Key: []byte("foo"), Value: []byte("bar")}}),
+		value:   []byte("hello"),
+	})
+	if m.Header["foo"] != "bar" || string(m.Body) != "hello" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+
+	// message encoded in the value
+	b, err := c.Marshal(&broker.Message{Header: map[string]string{"foo": "baz"}, Body: []byte("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = decode(c, &record{value: b})
+	if m.Header["foo"] != "baz" || string(m.Body) != "hello" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+
+	// raw value from another producer
+	m = decode(c, &record{value: []byte("raw")})
+	if string(m.Body) != "raw" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+}
+
+func TestDriver(t *testing.T) {
+	b := NewBroker(broker.Addrs("127.0.0.1:1"), Driver("unknown"))
+	if err := b.Connect(); err == nil {
+		t.Fatal("expected unknown driver error")
+	}
+
+	if err := b.Publish("test", &broker.Message{}); err == nil {
+		t.Fatal("expected publish to fail when not connected")
+	}
+}
+
+type testDriver struct {
+	driver
+	msgs []*broker.Message
+}
+
+func (t *testDriver) Publish(topic string, key []byte, msg *broker.Message) error {
+	t.msgs = append(t.msgs, msg)
+	return nil
+}
+
+func TestPublishFormat(t *testing.T) {
+	msg := &broker.Message{Header: map[string]string{"foo": "bar"}, Body: []byte("hello")}
+
+	// the message is encoded in the value by default
+	d := &testDriver{}
+	b := NewBroker().(*kBroker)
+	b.d = d
+
+	if err := b.Publish("test", msg); err != nil {
+		t.Fatal(err)
+	}
+	m := decode(b.opts.Codec, &record{value: d.msgs[0].Body, headers: d.msgs[0].Header})
+	if len(d.msgs[0].Header) > 0 || m.Header["foo"] != "bar" || string(m.Body) != "hello" {
+		t.Fatalf("expected codec encoded message got %+v", d.msgs[0])
+	}
+
+	// record headers publish the header and body as is
+	d = &testDriver{}
+	b = NewBroker(RecordHeaders()).(*kBroker)
+	b.d = d
+
+	if err := b.Publish("test", msg); err != nil {
+		t.Fatal(err)
+	}
+	if d.msgs[0].Header["foo"] != "bar" || string(d.msgs[0].Body) != "hello" {
+		t.Fatalf("expected record headers got %+v", d.msgs[0])
+	}
+}
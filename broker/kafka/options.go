@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/broker"
+)
+
+type keyKey struct{}
+
+// Key sets the record key. Records with the same key are published to the
+// same partition and the key is used by log compaction.
+func Key(key string) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, keyKey{}, key)
+	}
+}
+
+type driverKey struct{}
+
+const (
+	// SaramaDriver uses the Shopify sarama client
+	SaramaDriver = "sarama"
+	// FranzDriver uses the franz-go client
+	FranzDriver = "franz"
+)
+
+// Driver sets the kafka client the broker uses, SaramaDriver by default.
+// FranzDriver has higher throughput and supports newer protocol features.
+func Driver(name string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, driverKey{}, name)
+	}
+}
+
+type recordHeadersKey struct{}
+
+// RecordHeaders publishes the message header as record headers and the body
+// as the record value rather than the message encoded with the codec. It
+// requires kafka 0.11 and consumers which read record headers, consumers
+// read both formats so enable it once every consumer is upgraded.
+func RecordHeaders() broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, recordHeadersKey{}, true)
+	}
+}
+
+func useRecordHeaders(o broker.Options) bool {
+	if o.Context == nil {
+		return false
+	}
+	b, _ := o.Context.Value(recordHeadersKey{}).(bool)
+	return b
+}
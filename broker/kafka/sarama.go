@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	sc "gopkg.in/bsm/sarama-cluster.v2"
+)
+
+// saramaDriver uses a sarama client to publish and
+// a sarama cluster client per consumer group
+type saramaDriver struct {
+	addrs []string
+	opts  broker.Options
+
+	c sarama.Client
+	p sarama.SyncProducer
+
+	sync.Mutex
+	sc []*sc.Client
+}
+
+func newSaramaDriver() driver {
+	return &saramaDriver{}
+}
+
+func (s *saramaDriver) Connect(addrs []string, opts broker.Options) error {
+	pconfig := sarama.NewConfig()
+	// For implementation reasons, the SyncProducer requires
+	// `Producer.Return.Errors` and `Producer.Return.Successes`
+	// to be set to true in its configuration.
+	pconfig.Producer.Return.Successes = true
+	pconfig.Producer.Return.Errors = true
+	// record headers require kafka 0.11
+	pconfig.Version = sarama.V0_11_0_0
+
+	if err := configure(pconfig, opts); err != nil {
+		return err
+	}
+
+	c, err := sarama.NewClient(addrs, pconfig)
+	if err != nil {
+		return err
+	}
+
+	p, err := sarama.NewSyncProducerFromClient(c)
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	s.addrs = addrs
+	s.opts = opts
+	s.c = c
+	s.p = p
+
+	return nil
+}
+
+func (s *saramaDriver) Disconnect() error {
+	s.Lock()
+	for _, client := range s.sc {
+		client.Close()
+	}
+	s.sc = nil
+	s.Unlock()
+
+	s.p.Close()
+	return s.c.Close()
+}
+
+func (s *saramaDriver) Publish(topic string, key []byte, msg *broker.Message) error {
+	pm := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Body),
+		Headers: recordHeaders(msg.Header),
+	}
+
+	if key != nil {
+		pm.Key = sarama.ByteEncoder(key)
+	}
+
+	_, _, err := s.p.SendMessage(pm)
+	return err
+}
+
+func (s *saramaDriver) getClusterClient() (*sc.Client, error) {
+	config := sc.NewConfig()
+
+	// TODO: make configurable offset as SubscriberOption
+	config.Config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	config.Config.Version = sarama.V0_11_0_0
+
+	if err := configure(&config.Config, s.opts); err != nil {
+		return nil, err
+	}
+
+	cs, err := sc.NewClient(s.addrs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.sc = append(s.sc, cs)
+	return cs, nil
+}
+
+func (s *saramaDriver) Subscribe(topic, group string, fn func(*record)) (func() error, error) {
+	// we need to create a new client per consumer
+	cs, err := s.getClusterClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := sc.NewConsumerFromClient(cs, group, []string{topic})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case err, ok := <-c.Errors():
+				if !ok {
+					return
+				}
+				log.Log("consumer error:", err)
+			case sm, ok := <-c.Messages():
+				if !ok {
+					return
+				}
+				// ensure message is not nil
+				if sm == nil {
+					continue
+				}
+				fn(&record{
+					topic:   sm.Topic,
+					value:   sm.Value,
+					headers: saramaHeaders(sm.Headers),
+					ack: func() error {
+						c.MarkOffset(sm, "")
+						return nil
+					},
+				})
+			}
+		}
+	}()
+
+	return c.Close, nil
+}
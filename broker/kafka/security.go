@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"github.com/Shopify/sarama"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-plugins/broker/security"
+	"github.com/xdg/scram"
+)
+
+var (
+	sha256Hash scram.HashGeneratorFcn = func() hash.Hash { return sha256.New() }
+	sha512Hash scram.HashGeneratorFcn = func() hash.Hash { return sha512.New() }
+)
+
+// scramClient implements sarama.SCRAMClient
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func (s *scramClient) Begin(username, password, authzID string) error {
+	c, err := s.HashGeneratorFcn.NewClient(username, password, authzID)
+	if err != nil {
+		return err
+	}
+	s.Client = c
+	s.ClientConversation = c.NewConversation()
+	return nil
+}
+
+func (s *scramClient) Step(challenge string) (string, error) {
+	return s.ClientConversation.Step(challenge)
+}
+
+func (s *scramClient) Done() bool {
+	return s.ClientConversation.Done()
+}
+
+// tokenProvider implements sarama.AccessTokenProvider
+type tokenProvider struct {
+	fn security.TokenFunc
+}
+
+func (t *tokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := t.fn()
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.AccessToken{Token: token}, nil
+}
+
+// configure applies the TLS and SASL options to the sarama config
+func configure(c *sarama.Config, opts broker.Options) error {
+	if err := security.Err(opts); err != nil {
+		return err
+	}
+
+	if opts.Secure || opts.TLSConfig != nil {
+		c.Net.TLS.Enable = true
+		c.Net.TLS.Config = opts.TLSConfig
+	}
+
+	s, ok := security.GetSASL(opts)
+	if !ok {
+		return nil
+	}
+
+	c.Net.SASL.Enable = true
+	c.Net.SASL.Handshake = true
+	c.Net.SASL.User = s.Username
+	c.Net.SASL.Password = s.Password
+
+	switch s.Mechanism {
+	case security.Plain:
+		c.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case security.ScramSHA256:
+		c.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		c.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha256Hash}
+		}
+	case security.ScramSHA512:
+		c.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		c.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha512Hash}
+		}
+	case security.OAuthBearer:
+		c.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		c.Net.SASL.TokenProvider = &tokenProvider{fn: s.Token}
+	default:
+		return security.ErrUnsupported("kafka", s.Mechanism)
+	}
+
+	return nil
+}
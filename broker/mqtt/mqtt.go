@@ -26,12 +26,15 @@ import (
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/broker/codec/json"
 	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-plugins/broker/security"
 )
 
 type mqttBroker struct {
 	addrs  []string
 	opts   broker.Options
 	client mqtt.Client
+	// v5 is used instead of client for MQTT 5
+	v5 *v5Client
 }
 
 func init() {
@@ -122,6 +125,23 @@ func newClient(addrs []string, opts broker.Options) mqtt.Client {
 		cOpts.SetTLSConfig(opts.TLSConfig)
 	}
 
+	// setup credentials, tokens are sent as the password
+	if s, ok := security.GetSASL(opts); ok {
+		switch s.Mechanism {
+		case security.Plain:
+			cOpts.SetUsername(s.Username)
+			cOpts.SetPassword(s.Password)
+		case security.OAuthBearer:
+			cOpts.SetCredentialsProvider(func() (string, string) {
+				token, err := s.Token()
+				if err != nil {
+					log.Logf("[mqtt] failed to get oauth token: %v", err)
+				}
+				return s.Username, token
+			})
+		}
+	}
+
 	// add brokers
 	for _, addr := range addrs {
 		cOpts.AddBroker(addr)
@@ -140,14 +160,30 @@ func newBroker(opts ...broker.Option) broker.Broker {
 		o(&options)
 	}
 
-	addrs := setAddrs(options.Addrs)
-	client := newClient(addrs, options)
+	m := &mqttBroker{
+		opts:  options,
+		addrs: setAddrs(options.Addrs),
+	}
+	m.setClient()
+	return m
+}
 
-	return &mqttBroker{
-		opts:   options,
-		client: client,
-		addrs:  addrs,
+// setClient creates the client for the protocol version
+func (m *mqttBroker) setClient() {
+	if isVersion5(m.opts) {
+		m.client = nil
+		m.v5 = newV5Client(m.addrs, m.opts)
+		return
 	}
+	m.client = newClient(m.addrs, m.opts)
+	m.v5 = nil
+}
+
+func (m *mqttBroker) isConnected() bool {
+	if m.v5 != nil {
+		return m.v5.IsConnected()
+	}
+	return m.client.IsConnected()
 }
 
 func (m *mqttBroker) Options() broker.Options {
@@ -159,10 +195,22 @@ func (m *mqttBroker) Address() string {
 }
 
 func (m *mqttBroker) Connect() error {
-	if m.client.IsConnected() {
+	if m.isConnected() {
 		return nil
 	}
 
+	if err := security.Err(m.opts); err != nil {
+		return err
+	}
+
+	if s, ok := security.GetSASL(m.opts); ok && s.Mechanism != security.Plain && s.Mechanism != security.OAuthBearer {
+		return security.ErrUnsupported("mqtt", s.Mechanism)
+	}
+
+	if m.v5 != nil {
+		return m.v5.Connect()
+	}
+
 	if t := m.client.Connect(); t.Wait() && t.Error() != nil {
 		return t.Error()
 	}
@@ -171,6 +219,9 @@ func (m *mqttBroker) Connect() error {
 }
 
 func (m *mqttBroker) Disconnect() error {
+	if m.v5 != nil {
+		return m.v5.Disconnect()
+	}
 	if !m.client.IsConnected() {
 		return nil
 	}
@@ -179,7 +230,7 @@ func (m *mqttBroker) Disconnect() error {
 }
 
 func (m *mqttBroker) Init(opts ...broker.Option) error {
-	if m.client.IsConnected() {
+	if m.isConnected() {
 		return errors.New("cannot init while connected")
 	}
 
@@ -188,11 +239,19 @@ func (m *mqttBroker) Init(opts ...broker.Option) error {
 	}
 
 	m.addrs = setAddrs(m.opts.Addrs)
-	m.client = newClient(m.addrs, m.opts)
+	m.setClient()
 	return nil
 }
 
 func (m *mqttBroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	if m.v5 != nil {
+		var options broker.PublishOptions
+		for _, o := range opts {
+			o(&options)
+		}
+		return m.v5.Publish(topic, msg, options)
+	}
+
 	if !m.client.IsConnected() {
 		return errors.New("not connected")
 	}
@@ -207,6 +266,16 @@ func (m *mqttBroker) Publish(topic string, msg *broker.Message, opts ...broker.P
 }
 
 func (m *mqttBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	if m.v5 != nil {
+		options := broker.SubscribeOptions{
+			AutoAck: true,
+		}
+		for _, o := range opts {
+			o(&options)
+		}
+		return m.v5.Subscribe(topic, h, options)
+	}
+
 	if !m.client.IsConnected() {
 		return nil, errors.New("not connected")
 	}
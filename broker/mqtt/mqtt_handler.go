@@ -9,6 +9,8 @@ import (
 type mqttPub struct {
 	topic string
 	msg   *broker.Message
+	// ack acknowledges MQTT 5 messages
+	ack func() error
 }
 
 // mqttPub is a broker.Subscriber
@@ -16,9 +18,14 @@ type mqttSub struct {
 	opts   broker.SubscribeOptions
 	topic  string
 	client mqtt.Client
+	// stop unsubscribes MQTT 5 subscribers
+	stop func() error
 }
 
 func (m *mqttPub) Ack() error {
+	if m.ack != nil {
+		return m.ack()
+	}
 	return nil
 }
 
@@ -39,6 +46,9 @@ func (m *mqttSub) Topic() string {
 }
 
 func (m *mqttSub) Unsubscribe() error {
+	if m.stop != nil {
+		return m.stop()
+	}
 	t := m.client.Unsubscribe(m.topic)
 	return t.Error()
 }
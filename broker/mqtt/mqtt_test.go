@@ -2,7 +2,9 @@ package mqtt
 
 import (
 	"testing"
+	"time"
 
+	"github.com/eclipse/paho.golang/paho"
 	"github.com/eclipse/paho.mqtt.golang"
 	"github.com/micro/go-micro/broker"
 )
@@ -87,3 +89,94 @@ func TestMQTT(t *testing.T) {
 
 	b.(*mqttBroker).client.Disconnect(0)
 }
+
+func TestMQTTVersion5(t *testing.T) {
+	b := NewBroker(Version5(), ReceiveMaximum(10))
+
+	m := b.(*mqttBroker)
+	if m.v5 == nil || m.client != nil {
+		t.Fatal("expected mqtt 5 client")
+	}
+
+	if n, ok := getReceiveMaximum(m.opts); !ok || n != 10 {
+		t.Fatalf("expected receive maximum 10 got %d", n)
+	}
+
+	if err := b.Publish("mock", &broker.Message{}); err == nil {
+		t.Fatal("expected error publishing when not connected")
+	}
+
+	var options broker.PublishOptions
+	MessageExpiry(time.Minute)(&options)
+
+	if d, ok := getExpiry(options); !ok || d != time.Minute {
+		t.Fatalf("expected expiry 1m got %v", d)
+	}
+}
+
+func TestMQTTVersion5Handle(t *testing.T) {
+	v := newV5Client(nil, broker.Options{})
+
+	p := &paho.Publish{
+		Topic:   "mock",
+		Payload: []byte(`hello`),
+		Properties: &paho.PublishProperties{
+			User: paho.UserProperties{
+				{Key: "Content-Type", Value: "text/plain"},
+			},
+		},
+	}
+
+	done := make(chan *broker.Message, 1)
+
+	// without auto ack the handler acknowledges the message
+	v.handle(nil, p, func(pub broker.Publication) error {
+		done <- pub.Message()
+		return nil
+	}, broker.SubscribeOptions{})
+
+	msg := <-done
+
+	if string(msg.Body) != "hello" {
+		t.Fatalf("expected body hello got %s", string(msg.Body))
+	}
+
+	if ct := msg.Header["Content-Type"]; ct != "text/plain" {
+		t.Fatalf("expected header Content-Type text/plain got %s", ct)
+	}
+}
+
+func TestMQTTVersion5Reconnect(t *testing.T) {
+	defer func(d time.Duration) {
+		minBackoff = d
+	}(minBackoff)
+	minBackoff = time.Millisecond
+
+	// nothing listens so reconnecting fails
+	v := newV5Client([]string{"tcp://127.0.0.1:1"}, broker.Options{})
+	exit := make(chan bool)
+	v.exit = exit
+
+	done := make(chan bool)
+	go func() {
+		v.reconnect(exit)
+		close(done)
+	}()
+
+	time.Sleep(time.Millisecond * 10)
+
+	if v.IsConnected() {
+		t.Fatal("expected client to be disconnected")
+	}
+
+	// disconnecting stops reconnecting
+	if err := v.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected reconnect to stop")
+	}
+}
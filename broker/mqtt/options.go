@@ -0,0 +1,71 @@
+package mqtt
+
+import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+)
+
+type versionKey struct{}
+type receiveMaximumKey struct{}
+type expiryKey struct{}
+
+// Version5 connects using MQTT 5. Message headers are sent as user
+// properties and the body as the payload so the codec isn't used.
+// Subscribers with a queue use a shared subscription, $share/queue/topic,
+// so each message is consumed by a single subscriber of the queue.
+func Version5() broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, versionKey{}, 5)
+	}
+}
+
+// ReceiveMaximum limits the unacknowledged messages the server sends
+// the MQTT 5 client, so at most n messages are handled concurrently.
+func ReceiveMaximum(n uint16) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, receiveMaximumKey{}, n)
+	}
+}
+
+// MessageExpiry sets how long an MQTT 5 server keeps the message
+// for subscribers before discarding it.
+func MessageExpiry(d time.Duration) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, expiryKey{}, d)
+	}
+}
+
+func isVersion5(o broker.Options) bool {
+	if o.Context == nil {
+		return false
+	}
+	v, _ := o.Context.Value(versionKey{}).(int)
+	return v == 5
+}
+
+func getReceiveMaximum(o broker.Options) (uint16, bool) {
+	if o.Context == nil {
+		return 0, false
+	}
+	n, ok := o.Context.Value(receiveMaximumKey{}).(uint16)
+	return n, ok && n > 0
+}
+
+func getExpiry(o broker.PublishOptions) (time.Duration, bool) {
+	if o.Context == nil {
+		return 0, false
+	}
+	d, ok := o.Context.Value(expiryKey{}).(time.Duration)
+	return d, ok && d > 0
+}
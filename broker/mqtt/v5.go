@@ -0,0 +1,407 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-plugins/broker/security"
+)
+
+var (
+	// backoff between attempts to reconnect
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// v5Client is an MQTT 5 client. Messages are acknowledged once handled
+// so the server's in flight window, set by ReceiveMaximum, applies
+// backpressure to subscribers. The paho client doesn't reconnect so
+// the connection is re-established with backoff when it's lost and
+// the subscriptions are restored.
+type v5Client struct {
+	addrs []string
+	opts  broker.Options
+
+	sync.Mutex
+	c         *paho.Client
+	router    *paho.StandardRouter
+	connected bool
+	// closed by Disconnect to stop reconnecting
+	exit chan bool
+	// subscriptions by filter, restored on reconnect
+	subs map[string]*v5Sub
+}
+
+type v5Sub struct {
+	h    broker.Handler
+	opts broker.SubscribeOptions
+}
+
+func newV5Client(addrs []string, opts broker.Options) *v5Client {
+	return &v5Client{
+		addrs: addrs,
+		opts:  opts,
+		subs:  make(map[string]*v5Sub),
+	}
+}
+
+// dial connects to the first address which accepts the connection
+func (v *v5Client) dial() (net.Conn, error) {
+	err := errors.New("no addresses")
+
+	for _, addr := range v.addrs {
+		var u *url.URL
+		if u, err = url.Parse(addr); err != nil {
+			continue
+		}
+
+		var conn net.Conn
+
+		switch u.Scheme {
+		case "tcp":
+			conn, err = net.DialTimeout("tcp", u.Host, 30*time.Second)
+		case "ssl":
+			config := v.opts.TLSConfig
+			if config == nil {
+				config = &tls.Config{}
+			}
+			conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", u.Host, config)
+		default:
+			err = fmt.Errorf("mqtt 5 does not support %s addresses", u.Scheme)
+		}
+
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, err
+}
+
+func (v *v5Client) IsConnected() bool {
+	v.Lock()
+	defer v.Unlock()
+	return v.connected
+}
+
+func (v *v5Client) Connect() error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.connected {
+		return nil
+	}
+
+	if err := v.connect(); err != nil {
+		return err
+	}
+
+	if v.exit == nil {
+		v.exit = make(chan bool)
+	}
+
+	return nil
+}
+
+// connect opens a new connection, the lock must be held
+func (v *v5Client) connect() error {
+	conn, err := v.dial()
+	if err != nil {
+		return err
+	}
+
+	router := paho.NewStandardRouter()
+
+	var c *paho.Client
+	c = paho.NewClient(paho.ClientConfig{
+		Conn:   conn,
+		Router: router,
+		// messages are acknowledged once handled
+		EnableManualAcknowledgment: true,
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			log.Logf("[mqtt] server disconnected: reason %d", d.ReasonCode)
+			v.disconnected(c)
+		},
+		OnClientError: func(err error) {
+			log.Logf("[mqtt] client error: %v", err)
+			v.disconnected(c)
+		},
+	})
+
+	cp := &paho.Connect{
+		ClientID:   fmt.Sprintf("%d%d", time.Now().UnixNano(), rand.Intn(10)),
+		KeepAlive:  30,
+		CleanStart: true,
+		Properties: &paho.ConnectProperties{},
+	}
+
+	if n, ok := getReceiveMaximum(v.opts); ok {
+		cp.Properties.ReceiveMaximum = &n
+	}
+
+	// setup credentials, tokens are sent as the password
+	if s, ok := security.GetSASL(v.opts); ok {
+		password := s.Password
+		if s.Mechanism == security.OAuthBearer {
+			if password, err = s.Token(); err != nil {
+				conn.Close()
+				return err
+			}
+		}
+		cp.Username = s.Username
+		cp.UsernameFlag = len(s.Username) > 0
+		cp.Password = []byte(password)
+		cp.PasswordFlag = len(password) > 0
+	}
+
+	ca, err := c.Connect(context.Background(), cp)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if ca.ReasonCode != 0 {
+		conn.Close()
+		return fmt.Errorf("mqtt connect failed: reason %d", ca.ReasonCode)
+	}
+
+	v.c = c
+	v.router = router
+	v.connected = true
+
+	return nil
+}
+
+// disconnected starts reconnecting when the current connection is lost
+func (v *v5Client) disconnected(c *paho.Client) {
+	v.Lock()
+	defer v.Unlock()
+
+	// ignore errors of old connections or after Disconnect
+	if !v.connected || v.c != c {
+		return
+	}
+
+	v.connected = false
+	go v.reconnect(v.exit)
+}
+
+// reconnect connects with backoff until connected or stopped
+// and restores the subscriptions
+func (v *v5Client) reconnect(exit chan bool) {
+	backoff := minBackoff
+
+	for {
+		select {
+		case <-exit:
+			return
+		case <-time.After(backoff):
+		}
+
+		v.Lock()
+		select {
+		case <-exit:
+			v.Unlock()
+			return
+		default:
+		}
+
+		// connected by the caller meanwhile
+		if v.connected {
+			v.Unlock()
+			return
+		}
+
+		if err := v.connect(); err != nil {
+			v.Unlock()
+			log.Logf("[mqtt] failed to reconnect: %v", err)
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			continue
+		}
+
+		c, router := v.c, v.router
+		subs := make(map[string]*v5Sub, len(v.subs))
+		for filter, sub := range v.subs {
+			subs[filter] = sub
+		}
+		v.Unlock()
+
+		// a failure here loses the connection which reconnects again
+		for filter, sub := range subs {
+			if err := v.subscribe(c, router, filter, sub); err != nil {
+				log.Logf("[mqtt] failed to resubscribe to %s: %v", filter, err)
+			}
+		}
+
+		return
+	}
+}
+
+func (v *v5Client) Disconnect() error {
+	v.Lock()
+	defer v.Unlock()
+
+	if v.exit != nil {
+		close(v.exit)
+		v.exit = nil
+	}
+	v.subs = make(map[string]*v5Sub)
+
+	if !v.connected {
+		return nil
+	}
+
+	v.connected = false
+	return v.c.Disconnect(&paho.Disconnect{ReasonCode: 0})
+}
+
+func (v *v5Client) client() (*paho.Client, *paho.StandardRouter, error) {
+	v.Lock()
+	defer v.Unlock()
+
+	if !v.connected {
+		return nil, nil, errors.New("not connected")
+	}
+	return v.c, v.router, nil
+}
+
+func (v *v5Client) Publish(topic string, msg *broker.Message, opts broker.PublishOptions) error {
+	c, _, err := v.client()
+	if err != nil {
+		return err
+	}
+
+	p := &paho.Publish{
+		Topic:      topic,
+		QoS:        1,
+		Payload:    msg.Body,
+		Properties: &paho.PublishProperties{},
+	}
+
+	for k, val := range msg.Header {
+		p.Properties.User.Add(k, val)
+	}
+
+	if d, ok := getExpiry(opts); ok {
+		// expiry is in seconds, round up so it never expires early
+		secs := uint32((d + time.Second - 1) / time.Second)
+		p.Properties.MessageExpiry = &secs
+	}
+
+	_, err = c.Publish(context.Background(), p)
+	return err
+}
+
+func (v *v5Client) Subscribe(topic string, h broker.Handler, opts broker.SubscribeOptions) (broker.Subscriber, error) {
+	c, router, err := v.client()
+	if err != nil {
+		return nil, err
+	}
+
+	// subscribers of a queue compete for messages
+	filter := topic
+	if len(opts.Queue) > 0 {
+		filter = fmt.Sprintf("$share/%s/%s", opts.Queue, topic)
+	}
+
+	sub := &v5Sub{h: h, opts: opts}
+
+	// recorded first so a reconnect meanwhile restores it
+	v.Lock()
+	v.subs[filter] = sub
+	v.Unlock()
+
+	if err := v.subscribe(c, router, filter, sub); err != nil {
+		v.Lock()
+		delete(v.subs, filter)
+		v.Unlock()
+		return nil, err
+	}
+
+	return &mqttSub{
+		opts:  opts,
+		topic: topic,
+		stop: func() error {
+			v.Lock()
+			delete(v.subs, filter)
+			v.Unlock()
+
+			// the subscription is on the current connection
+			c, router, err := v.client()
+			if err != nil {
+				return nil
+			}
+
+			router.UnregisterHandler(filter)
+			_, err = c.Unsubscribe(context.Background(), &paho.Unsubscribe{
+				Topics: []string{filter},
+			})
+			return err
+		},
+	}, nil
+}
+
+// subscribe registers the handler and subscribes on the connection
+func (v *v5Client) subscribe(c *paho.Client, router *paho.StandardRouter, filter string, sub *v5Sub) error {
+	router.RegisterHandler(filter, func(p *paho.Publish) {
+		// handle concurrently so the in flight window is used
+		go v.handle(c, p, sub.h, sub.opts)
+	})
+
+	if _, err := c.Subscribe(context.Background(), &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{
+			{Topic: filter, QoS: 1},
+		},
+	}); err != nil {
+		router.UnregisterHandler(filter)
+		return err
+	}
+
+	return nil
+}
+
+func (v *v5Client) handle(c *paho.Client, p *paho.Publish, h broker.Handler, opts broker.SubscribeOptions) {
+	msg := &broker.Message{
+		Header: make(map[string]string),
+		Body:   p.Payload,
+	}
+
+	if p.Properties != nil {
+		for _, u := range p.Properties.User {
+			msg.Header[u.Key] = u.Value
+		}
+	}
+
+	var once sync.Once
+	ack := func() error {
+		var err error
+		once.Do(func() {
+			err = c.Ack(p)
+		})
+		return err
+	}
+
+	err := h(&mqttPub{topic: p.Topic, msg: msg, ack: ack})
+	if err != nil {
+		log.Log(err)
+	}
+
+	// MQTT can't reject a message so failed messages are
+	// acknowledged too, otherwise they'd hold the window
+	if opts.AutoAck {
+		if err := ack(); err != nil {
+			log.Logf("[mqtt] failed to ack message: %v", err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package nats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/stan.go"
+)
+
+var (
+	// DefaultMigrateWindow is how long message ids are
+	// remembered to de-duplicate messages during a migration
+	DefaultMigrateWindow = time.Minute * 5
+	// DefaultMigrateIDHeader is the message header holding the message id
+	DefaultMigrateIDHeader = "Micro-Id"
+)
+
+// migration holds the NATS Streaming and JetStream connections
+// used to consume from both during a migration
+type migration struct {
+	sc     stan.Conn
+	js     nats.JetStreamContext
+	jc     *nats.Conn
+	header string
+	window time.Duration
+}
+
+// migrateSubscriber consumes from a NATS Streaming channel
+// and a JetStream stream at the same time
+type migrateSubscriber struct {
+	topic string
+	opts  broker.SubscribeOptions
+	ss    stan.Subscription
+	js    *nats.Subscription
+}
+
+type migratePublication struct {
+	t   string
+	m   *broker.Message
+	ack func() error
+}
+
+// dedup remembers message ids seen within the window
+type dedup struct {
+	window time.Duration
+
+	sync.Mutex
+	seen  map[string]time.Time
+	purge time.Time
+}
+
+func (p *migratePublication) Topic() string {
+	return p.t
+}
+
+func (p *migratePublication) Message() *broker.Message {
+	return p.m
+}
+
+func (p *migratePublication) Ack() error {
+	return p.ack()
+}
+
+func (s *migrateSubscriber) Options() broker.SubscribeOptions {
+	return s.opts
+}
+
+func (s *migrateSubscriber) Topic() string {
+	return s.topic
+}
+
+func (s *migrateSubscriber) Unsubscribe() error {
+	serr := s.ss.Unsubscribe()
+	if err := s.js.Unsubscribe(); err != nil {
+		return err
+	}
+	return serr
+}
+
+// duplicate returns true if the id was handled within the window
+func (d *dedup) duplicate(id string) bool {
+	if len(id) == 0 {
+		return false
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	now := time.Now()
+
+	// periodically forget old ids
+	if now.Sub(d.purge) > d.window {
+		for k, t := range d.seen {
+			if now.Sub(t) > d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.purge = now
+	}
+
+	t, ok := d.seen[id]
+	return ok && now.Sub(t) <= d.window
+}
+
+// handled records the id once its message was handled, so a
+// message which failed is still delivered when redelivered
+func (d *dedup) handled(id string) {
+	if len(id) == 0 {
+		return
+	}
+
+	d.Lock()
+	d.seen[id] = time.Now()
+	d.Unlock()
+}
+
+// connectMigration connects to the NATS Streaming cluster over the
+// existing connection and separately to JetStream
+func (n *nbroker) connectMigration(conn *nats.Conn, opts nats.Options) (*migration, error) {
+	clusterID, _ := n.opts.Context.Value(migrateClusterKey{}).(string)
+	clientID, _ := n.opts.Context.Value(migrateClientKey{}).(string)
+
+	sc, err := stan.Connect(clusterID, clientID, stan.NatsConn(conn))
+	if err != nil {
+		return nil, err
+	}
+
+	// the same options so credentials and tls carry over
+	jc, err := opts.Connect()
+	if err != nil {
+		sc.Close()
+		return nil, err
+	}
+
+	jsc, err := jc.JetStream()
+	if err != nil {
+		sc.Close()
+		jc.Close()
+		return nil, err
+	}
+
+	m := &migration{
+		sc:     sc,
+		js:     jsc,
+		jc:     jc,
+		header: DefaultMigrateIDHeader,
+		window: DefaultMigrateWindow,
+	}
+	if h, ok := n.opts.Context.Value(migrateIDHeaderKey{}).(string); ok && len(h) > 0 {
+		m.header = h
+	}
+	if w, ok := n.opts.Context.Value(migrateWindowKey{}).(time.Duration); ok && w > 0 {
+		m.window = w
+	}
+
+	return m, nil
+}
+
+func (m *migration) Close() {
+	m.sc.Close()
+	m.jc.Close()
+}
+
+// subscribe consumes the topic from both the NATS Streaming channel and
+// the JetStream stream capturing the subject, delivering each message id once
+func (n *nbroker) migrateSubscribe(topic string, handler broker.Handler, opt broker.SubscribeOptions) (broker.Subscriber, error) {
+	m := n.migration
+	subject := n.subject(topic)
+
+	d := &dedup{
+		window: m.window,
+		seen:   make(map[string]time.Time),
+	}
+
+	handle := func(subject string, data []byte, ack func() error) {
+		msg := n.message(subject, data)
+
+		// already delivered from the other side
+		if d.duplicate(msg.Header[m.header]) {
+			if err := ack(); err != nil {
+				log.Logf("[nats] failed to ack duplicate message on %s: %v", subject, err)
+			}
+			return
+		}
+
+		p := &migratePublication{t: n.topic(subject), m: msg, ack: ack}
+		if err := handler(p); err != nil {
+			return
+		}
+		d.handled(msg.Header[m.header])
+
+		if opt.AutoAck {
+			if err := p.Ack(); err != nil {
+				log.Logf("[nats] failed to ack message on %s: %v", subject, err)
+			}
+		}
+	}
+
+	sopts := []stan.SubscriptionOption{stan.SetManualAckMode()}
+	jopts := []nats.SubOpt{nats.ManualAck()}
+	if len(opt.Queue) > 0 {
+		sopts = append(sopts, stan.DurableName(opt.Queue))
+		jopts = append(jopts, nats.Durable(opt.Queue))
+	}
+
+	scb := func(msg *stan.Msg) {
+		handle(msg.Subject, msg.Data, msg.Ack)
+	}
+
+	var ss stan.Subscription
+	var err error
+	if len(opt.Queue) > 0 {
+		ss, err = m.sc.QueueSubscribe(subject, opt.Queue, scb, sopts...)
+	} else {
+		ss, err = m.sc.Subscribe(subject, scb, sopts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	jcb := func(msg *nats.Msg) {
+		handle(msg.Subject, msg.Data, func() error { return msg.Ack() })
+	}
+
+	var jsub *nats.Subscription
+	if len(opt.Queue) > 0 {
+		jsub, err = m.js.QueueSubscribe(subject, opt.Queue, jcb, jopts...)
+	} else {
+		jsub, err = m.js.Subscribe(subject, jcb, jopts...)
+	}
+	if err != nil {
+		ss.Unsubscribe()
+		return nil, err
+	}
+
+	return &migrateSubscriber{
+		topic: topic,
+		opts:  opt,
+		ss:    ss,
+		js:    jsub,
+	}, nil
+}
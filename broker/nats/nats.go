@@ -3,12 +3,15 @@ package nats
 
 import (
 	"context"
+	"errors"
 	"strings"
 
+	"github.com/micro/go-log"
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/broker/codec/json"
 	"github.com/micro/go-micro/cmd"
-	"github.com/nats-io/go-nats"
+	"github.com/micro/go-plugins/broker/security"
+	"github.com/nats-io/nats.go"
 )
 
 type nbroker struct {
@@ -16,6 +19,9 @@ type nbroker struct {
 	conn  *nats.Conn
 	opts  broker.Options
 	nopts nats.Options
+
+	// set when migrating from NATS Streaming to JetStream
+	migration *migration
 }
 
 type subscriber struct {
@@ -28,6 +34,12 @@ type publication struct {
 	m *broker.Message
 }
 
+var (
+	// SubjectHeader is the message header set to the concrete subject a
+	// message was received on, useful with wildcard subscriptions
+	SubjectHeader = "Nats-Subject"
+)
+
 func init() {
 	cmd.DefaultBrokers["nats"] = NewBroker
 }
@@ -84,11 +96,88 @@ func setAddrs(addrs []string) []string {
 	return cAddrs
 }
 
+// wildcard returns true if the subject contains a wildcard token
+func wildcard(subject string) bool {
+	for _, t := range strings.Split(subject, ".") {
+		if t == "*" || t == ">" {
+			return true
+		}
+	}
+	return false
+}
+
+// subject maps the topic to a subject, applying the
+// token transformation and then the prefix
+func (n *nbroker) subject(topic string) string {
+	if n.opts.Context == nil {
+		return topic
+	}
+
+	if fn, ok := n.opts.Context.Value(subjectTokensKey{}).(func(string) string); ok && fn != nil {
+		tokens := strings.Split(topic, ".")
+		for i, t := range tokens {
+			if t == "*" || t == ">" {
+				continue
+			}
+			tokens[i] = fn(t)
+		}
+		topic = strings.Join(tokens, ".")
+	}
+
+	if p, ok := n.opts.Context.Value(subjectPrefixKey{}).(string); ok && len(p) > 0 {
+		topic = strings.TrimSuffix(p, ".") + "." + topic
+	}
+
+	return topic
+}
+
+// topic maps the subject a message was received on back to a topic by
+// removing the prefix. Tokens are left as they're transformed one way.
+func (n *nbroker) topic(subject string) string {
+	if n.opts.Context == nil {
+		return subject
+	}
+	if p, ok := n.opts.Context.Value(subjectPrefixKey{}).(string); ok && len(p) > 0 {
+		return strings.TrimPrefix(subject, strings.TrimSuffix(p, ".")+".")
+	}
+	return subject
+}
+
+// message decodes the data received on the subject. Data which isn't an
+// encoded message, such as that published by other clients, is the body.
+func (n *nbroker) message(subject string, data []byte) *broker.Message {
+	var m broker.Message
+	if err := n.opts.Codec.Unmarshal(data, &m); err != nil || (m.Header == nil && m.Body == nil) {
+		m = broker.Message{Body: data}
+	}
+	if m.Header == nil {
+		m.Header = make(map[string]string)
+	}
+	m.Header[SubjectHeader] = subject
+	return &m
+}
+
+// tokenHandler fetches the token on each connect
+// so reconnects don't use an expired token
+func tokenHandler(fn security.TokenFunc) nats.AuthTokenHandler {
+	return func() string {
+		token, err := fn()
+		if err != nil {
+			log.Logf("[nats] failed to get oauth token: %v", err)
+		}
+		return token
+	}
+}
+
 func (n *nbroker) Connect() error {
 	if n.conn != nil {
 		return nil
 	}
 
+	if err := security.Err(n.opts); err != nil {
+		return err
+	}
+
 	opts := n.nopts
 	opts.Servers = n.addrs
 	opts.Secure = n.opts.Secure
@@ -99,15 +188,49 @@ func (n *nbroker) Connect() error {
 		opts.Secure = true
 	}
 
+	if s, ok := security.GetSASL(n.opts); ok {
+		switch s.Mechanism {
+		case security.Plain:
+			opts.User = s.Username
+			opts.Password = s.Password
+		case security.OAuthBearer:
+			// fail early if the token can't be fetched
+			if _, err := s.Token(); err != nil {
+				return err
+			}
+			opts.TokenHandler = tokenHandler(s.Token)
+		default:
+			return security.ErrUnsupported("nats", s.Mechanism)
+		}
+	}
+
+	if err := applyConnOptions(n.opts, &opts); err != nil {
+		return err
+	}
+
 	c, err := opts.Connect()
 	if err != nil {
 		return err
 	}
+
+	if _, ok := n.opts.Context.Value(migrateClusterKey{}).(string); ok {
+		m, err := n.connectMigration(c, opts)
+		if err != nil {
+			c.Close()
+			return err
+		}
+		n.migration = m
+	}
+
 	n.conn = c
 	return nil
 }
 
 func (n *nbroker) Disconnect() error {
+	if n.migration != nil {
+		n.migration.Close()
+		n.migration = nil
+	}
 	n.conn.Close()
 	return nil
 }
@@ -125,11 +248,16 @@ func (n *nbroker) Options() broker.Options {
 }
 
 func (n *nbroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	subject := n.subject(topic)
+	if wildcard(subject) {
+		return errors.New("nats: cannot publish to wildcard subject " + subject)
+	}
+
 	b, err := n.opts.Codec.Marshal(msg)
 	if err != nil {
 		return err
 	}
-	return n.conn.Publish(topic, b)
+	return n.conn.Publish(subject, b)
 }
 
 func (n *nbroker) Subscribe(topic string, handler broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -141,21 +269,24 @@ func (n *nbroker) Subscribe(topic string, handler broker.Handler, opts ...broker
 		o(&opt)
 	}
 
+	if n.migration != nil {
+		return n.migrateSubscribe(topic, handler, opt)
+	}
+
 	fn := func(msg *nats.Msg) {
-		var m broker.Message
-		if err := n.opts.Codec.Unmarshal(msg.Data, &m); err != nil {
-			return
-		}
-		handler(&publication{m: &m, t: msg.Subject})
+		handler(&publication{m: n.message(msg.Subject, msg.Data), t: n.topic(msg.Subject)})
 	}
 
 	var sub *nats.Subscription
 	var err error
 
+	// topics may contain wildcards such as orders.* or metrics.>
+	subject := n.subject(topic)
+
 	if len(opt.Queue) > 0 {
-		sub, err = n.conn.QueueSubscribe(topic, opt.Queue, fn)
+		sub, err = n.conn.QueueSubscribe(subject, opt.Queue, fn)
 	} else {
-		sub, err = n.conn.Subscribe(topic, fn)
+		sub, err = n.conn.Subscribe(subject, fn)
 	}
 	if err != nil {
 		return nil, err
@@ -2,10 +2,13 @@ package nats
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/broker"
-	"github.com/nats-io/go-nats"
+	"github.com/nats-io/nats.go"
 )
 
 var addrTestCases = []struct {
@@ -94,3 +97,124 @@ func TestInitAddrs(t *testing.T) {
 
 	}
 }
+
+func TestSubject(t *testing.T) {
+	testData := []struct {
+		opts    []broker.Option
+		topic   string
+		subject string
+	}{
+		{nil, "orders.created", "orders.created"},
+		{[]broker.Option{SubjectPrefix("legacy")}, "orders.created", "legacy.orders.created"},
+		{[]broker.Option{SubjectPrefix("legacy.")}, "orders.*", "legacy.orders.*"},
+		{[]broker.Option{SubjectTokens(strings.ToUpper)}, "metrics.>", "METRICS.>"},
+		{[]broker.Option{SubjectPrefix("a"), SubjectTokens(strings.ToUpper)}, "b.*.c", "a.B.*.C"},
+	}
+
+	for _, d := range testData {
+		n := NewBroker(d.opts...).(*nbroker)
+		if s := n.subject(d.topic); s != d.subject {
+			t.Fatalf("expected subject %s for topic %s, got %s", d.subject, d.topic, s)
+		}
+	}
+
+	// the prefix is removed from the topic of publications
+	n := NewBroker(SubjectPrefix("legacy")).(*nbroker)
+	if tp := n.topic("legacy.orders.created"); tp != "orders.created" {
+		t.Fatalf("expected topic orders.created got %s", tp)
+	}
+
+	if !wildcard("orders.*") || !wildcard("metrics.>") || wildcard("orders.created") {
+		t.Fatal("unexpected wildcard detection")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	n := NewBroker().(*nbroker)
+
+	b, err := n.opts.Codec.Marshal(&broker.Message{
+		Header: map[string]string{"Micro-Id": "1"},
+		Body:   []byte("hello"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := n.message("orders.created", b)
+	if m.Header["Micro-Id"] != "1" || string(m.Body) != "hello" || m.Header[SubjectHeader] != "orders.created" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+
+	// raw data from other clients is the body
+	m = n.message("orders.created", []byte("raw"))
+	if string(m.Body) != "raw" || m.Header[SubjectHeader] != "orders.created" {
+		t.Fatalf("expected the raw body got %+v", m)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	d := &dedup{
+		window: time.Millisecond * 10,
+		seen:   make(map[string]time.Time),
+	}
+
+	if d.duplicate("1") {
+		t.Fatal("expected first delivery not to be a duplicate")
+	}
+	// a failed message isn't handled so its redelivery isn't dropped
+	if d.duplicate("1") {
+		t.Fatal("expected unhandled redelivery not to be a duplicate")
+	}
+	d.handled("1")
+	if !d.duplicate("1") {
+		t.Fatal("expected second delivery to be a duplicate")
+	}
+	d.handled("")
+	if d.duplicate("") {
+		t.Fatal("expected messages without an id to never be duplicates")
+	}
+
+	time.Sleep(d.window * 2)
+
+	if d.duplicate("1") {
+		t.Fatal("expected id to be forgotten after the window")
+	}
+}
+
+func TestConnOptions(t *testing.T) {
+	b := NewBroker(Name("billing"), TLSHandshakeFirst())
+	if err := b.Init(JWT("jwt", "seed")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := nats.GetDefaultOptions()
+	if err := applyConnOptions(b.Options(), &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Name != "billing" {
+		t.Fatalf("expected connection name billing, got %q", opts.Name)
+	}
+	if !opts.TLSHandshakeFirst || !opts.Secure {
+		t.Fatal("expected a secure tls handshake first connection")
+	}
+	if opts.UserJWT == nil || opts.SignatureCB == nil {
+		t.Fatal("expected jwt auth callbacks to be set")
+	}
+}
+
+func TestTokenHandler(t *testing.T) {
+	var calls int
+	fn := tokenHandler(func() (string, error) {
+		calls++
+		return "token-" + strconv.Itoa(calls), nil
+	})
+
+	// the token is fetched on each connect
+	if tk := fn(); tk != "token-1" {
+		t.Fatalf("expected token-1 got %s", tk)
+	}
+	if tk := fn(); tk != "token-2" {
+		t.Fatalf("expected token-2 got %s", tk)
+	}
+}
@@ -2,9 +2,10 @@ package nats
 
 import (
 	"context"
+	"time"
 
 	"github.com/micro/go-micro/broker"
-	"github.com/nats-io/go-nats"
+	"github.com/nats-io/nats.go"
 )
 
 type optionsKey struct{}
@@ -18,3 +19,123 @@ func Options(opts nats.Options) broker.Option {
 		o.Context = context.WithValue(o.Context, optionsKey{}, opts)
 	}
 }
+
+type subjectPrefixKey struct{}
+
+type subjectTokensKey struct{}
+
+// SubjectPrefix prefixes the subject topics are published and subscribed
+// to with the given tokens, eg. SubjectPrefix("legacy") maps the topic
+// "orders.created" to "legacy.orders.created".
+func SubjectPrefix(prefix string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, subjectPrefixKey{}, prefix)
+	}
+}
+
+// SubjectTokens transforms each token of a topic when mapping it to a
+// subject. Wildcard tokens are left as is.
+func SubjectTokens(fn func(token string) string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, subjectTokensKey{}, fn)
+	}
+}
+
+type migrateClusterKey struct{}
+
+type migrateClientKey struct{}
+
+type migrateIDHeaderKey struct{}
+
+type migrateWindowKey struct{}
+
+// Migrate consumes subscriptions from both the NATS Streaming channel and
+// the JetStream stream capturing the subject during a migration away from
+// NATS Streaming. Messages are de-duplicated by the id header so those
+// published to both during the migration window are delivered once.
+func Migrate(clusterID, clientID string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, migrateClusterKey{}, clusterID)
+		o.Context = context.WithValue(o.Context, migrateClientKey{}, clientID)
+	}
+}
+
+// MigrateIDHeader sets the message header used to de-duplicate
+// messages during a migration, defaults to DefaultMigrateIDHeader
+func MigrateIDHeader(h string) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, migrateIDHeaderKey{}, h)
+	}
+}
+
+// MigrateWindow sets how long message ids are remembered
+// during a migration, defaults to DefaultMigrateWindow
+func MigrateWindow(d time.Duration) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, migrateWindowKey{}, d)
+	}
+}
+
+type connOptionsKey struct{}
+
+func connOption(opt nats.Option) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		prev, _ := o.Context.Value(connOptionsKey{}).([]nats.Option)
+		opts := append(prev[:len(prev):len(prev)], opt)
+		o.Context = context.WithValue(o.Context, connOptionsKey{}, opts)
+	}
+}
+
+// applyConnOptions applies the connection options to the nats options
+func applyConnOptions(o broker.Options, nopts *nats.Options) error {
+	if o.Context == nil {
+		return nil
+	}
+	opts, _ := o.Context.Value(connOptionsKey{}).([]nats.Option)
+	for _, opt := range opts {
+		if err := opt(nopts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Credentials authenticates with the user JWT and nkey seed
+// in a NATS 2.x .creds file, as issued by NGS
+func Credentials(file string) broker.Option {
+	return connOption(nats.UserCredentials(file))
+}
+
+// JWT authenticates with the user JWT and nkey seed
+func JWT(jwt, seed string) broker.Option {
+	return connOption(nats.UserJWTAndSeed(jwt, seed))
+}
+
+// TLSHandshakeFirst performs the TLS handshake before the server
+// sends its INFO, for servers configured with handshake_first
+func TLSHandshakeFirst() broker.Option {
+	return connOption(nats.TLSHandshakeFirst())
+}
+
+// Name sets the connection name shown in the server's monitoring
+func Name(name string) broker.Option {
+	return connOption(nats.Name(name))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/broker/codec/json"
 	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-plugins/broker/delivery"
 	"github.com/nsqio/go-nsq"
 	"github.com/pborman/uuid"
 )
@@ -191,6 +192,8 @@ func (n *nsqBroker) Publish(topic string, message *broker.Message, opts ...broke
 		}
 		if v, ok := options.Context.Value(deferredPublishKey{}).(time.Duration); ok {
 			delay = v
+		} else if v, ok := delivery.GetDelay(options); ok {
+			delay = v
 		}
 	}
 
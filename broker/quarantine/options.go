@@ -0,0 +1,114 @@
+package quarantine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+)
+
+// Options for the quarantine
+type Options struct {
+	// Store tracking failures and quarantined messages, defaults to memory
+	Store Store
+	// Broker messages are requeued to
+	Broker broker.Broker
+	// Threshold of consecutive failures before a message is quarantined
+	Threshold int
+	// FailureTTL is how long failures are tracked after the last one
+	FailureTTL time.Duration
+	// ID identifies a message across redeliveries
+	ID func(p broker.Publication) string
+}
+
+type Option func(*Options)
+
+var (
+	// DefaultThreshold is the default number of consecutive failures
+	DefaultThreshold = 5
+	// DefaultFailureTTL is how long failures are tracked by default
+	DefaultFailureTTL = 24 * time.Hour
+	// DefaultIDHeader is the header identifying messages by default
+	DefaultIDHeader = "Micro-Id"
+)
+
+// WithStore sets the store
+func WithStore(s Store) Option {
+	return func(o *Options) {
+		o.Store = s
+	}
+}
+
+// WithBroker sets the broker messages are requeued to
+func WithBroker(b broker.Broker) Option {
+	return func(o *Options) {
+		o.Broker = b
+	}
+}
+
+// Threshold sets the consecutive failures before a message is quarantined
+func Threshold(n int) Option {
+	return func(o *Options) {
+		o.Threshold = n
+	}
+}
+
+// FailureTTL sets how long failures of a message are tracked after the
+// last one. It should be longer than the broker takes to redeliver.
+func FailureTTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.FailureTTL = d
+	}
+}
+
+// ID sets the function identifying a message across redeliveries
+func ID(fn func(p broker.Publication) string) Option {
+	return func(o *Options) {
+		o.ID = fn
+	}
+}
+
+// defaultID uses the id header falling back to a hash of the topic,
+// headers and body, so messages with the same body but different
+// headers aren't counted as one
+func defaultID(p broker.Publication) string {
+	m := p.Message()
+	if id, ok := m.Header[DefaultIDHeader]; ok && len(id) > 0 {
+		return id
+	}
+
+	keys := make([]string, 0, len(m.Header))
+	for k := range m.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(p.Topic()))
+	h.Write([]byte{0})
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Header[k]))
+		h.Write([]byte{0})
+	}
+	h.Write(m.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Threshold:  DefaultThreshold,
+		FailureTTL: DefaultFailureTTL,
+		ID:         defaultID,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.Store == nil {
+		options.Store = NewMemoryStore()
+	}
+	return options
+}
@@ -0,0 +1,166 @@
+// Package quarantine provides a broker subscriber decorator which moves
+// messages failing repeatedly into a quarantine store so they stop being
+// redelivered, with an API to list, requeue and purge them
+package quarantine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+)
+
+// Message is a quarantined message
+type Message struct {
+	Id       string            `json:"id"`
+	Topic    string            `json:"topic"`
+	Header   map[string]string `json:"header"`
+	Body     []byte            `json:"body"`
+	Error    string            `json:"error"`
+	Failures int               `json:"failures"`
+	// Time the message was quarantined
+	Time time.Time `json:"time"`
+}
+
+// Quarantine tracks consecutive message failures
+type Quarantine struct {
+	opts Options
+}
+
+type quarantineBroker struct {
+	broker.Broker
+	q *Quarantine
+}
+
+func (b *quarantineBroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.SubscribeOptions{
+		AutoAck: true,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return b.Broker.Subscribe(topic, b.q.wrap(h, options.AutoAck), opts...)
+}
+
+func (q *Quarantine) wrap(h broker.Handler, autoAck bool) broker.Handler {
+	return func(p broker.Publication) error {
+		id := q.opts.ID(p)
+
+		err := h(p)
+		if err == nil {
+			if rerr := q.opts.Store.Reset(id); rerr != nil {
+				log.Logf("[quarantine] failed to reset %s: %v", id, rerr)
+			}
+			return nil
+		}
+
+		n, ferr := q.opts.Store.Fail(id, q.opts.FailureTTL)
+		if ferr != nil {
+			log.Logf("[quarantine] failed to track failure of %s: %v", id, ferr)
+			return err
+		}
+
+		// leave it to be redelivered
+		if n < q.opts.Threshold {
+			return err
+		}
+
+		m := p.Message()
+		if perr := q.opts.Store.Put(&Message{
+			Id:       id,
+			Topic:    p.Topic(),
+			Header:   m.Header,
+			Body:     m.Body,
+			Error:    err.Error(),
+			Failures: n,
+			Time:     time.Now(),
+		}); perr != nil {
+			log.Logf("[quarantine] failed to quarantine %s: %v", id, perr)
+			return err
+		}
+
+		q.opts.Store.Reset(id)
+
+		log.Logf("[quarantine] quarantined %s on %s after %d failures: %v", id, p.Topic(), n, err)
+
+		// acknowledge it so the broker stops redelivering
+		if !autoAck {
+			if aerr := p.Ack(); aerr != nil {
+				log.Logf("[quarantine] failed to ack %s: %v", id, aerr)
+			}
+		}
+
+		return nil
+	}
+}
+
+// Options returns the quarantine options
+func (q *Quarantine) Options() Options {
+	return q.opts
+}
+
+// Wrap returns a handler which quarantines messages the handler fails
+// to process Threshold consecutive times. Quarantined messages are
+// acknowledged by returning nil so subscribe with auto ack.
+func (q *Quarantine) Wrap(h broker.Handler) broker.Handler {
+	return q.wrap(h, true)
+}
+
+// NewBroker returns a broker which wraps the handler of every subscriber.
+// It's used to requeue messages if no broker was set.
+func (q *Quarantine) NewBroker(b broker.Broker) broker.Broker {
+	if q.opts.Broker == nil {
+		q.opts.Broker = b
+	}
+	return &quarantineBroker{
+		Broker: b,
+		q:      q,
+	}
+}
+
+// List returns the quarantined messages for the topic or all if empty
+func (q *Quarantine) List(topic string) ([]*Message, error) {
+	return q.opts.Store.List(topic)
+}
+
+// Requeue publishes the quarantined message back to its topic
+func (q *Quarantine) Requeue(id string) error {
+	if q.opts.Broker == nil {
+		return errors.New("quarantine: no broker to requeue to")
+	}
+
+	m, err := q.opts.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return errors.New("quarantine: message not found")
+	}
+
+	if err := q.opts.Broker.Publish(m.Topic, &broker.Message{
+		Header: m.Header,
+		Body:   m.Body,
+	}); err != nil {
+		return err
+	}
+
+	return q.opts.Store.Delete(id)
+}
+
+// Purge removes the quarantined messages
+func (q *Quarantine) Purge(ids ...string) error {
+	for _, id := range ids {
+		if err := q.opts.Store.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// New returns a quarantine
+func New(opts ...Option) *Quarantine {
+	return &Quarantine{
+		opts: newOptions(opts...),
+	}
+}
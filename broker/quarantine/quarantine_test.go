@@ -0,0 +1,190 @@
+package quarantine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/broker/memory"
+)
+
+type testPublication struct {
+	topic   string
+	message *broker.Message
+	acked   int
+}
+
+func (p *testPublication) Topic() string {
+	return p.topic
+}
+
+func (p *testPublication) Message() *broker.Message {
+	return p.message
+}
+
+func (p *testPublication) Ack() error {
+	p.acked++
+	return nil
+}
+
+func TestQuarantine(t *testing.T) {
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+
+	q := New(Threshold(3), WithBroker(b))
+
+	fail := true
+	h := q.wrap(func(p broker.Publication) error {
+		if fail {
+			return errors.New("poison")
+		}
+		return nil
+	}, false)
+
+	p := &testPublication{
+		topic: "test.topic",
+		message: &broker.Message{
+			Header: map[string]string{DefaultIDHeader: "1"},
+			Body:   []byte(`hello`),
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := h(p); err == nil {
+			t.Fatalf("expected failure %d to be returned", i)
+		}
+	}
+
+	// third failure quarantines and acks
+	if err := h(p); err != nil {
+		t.Fatalf("expected nil once quarantined got %v", err)
+	}
+	if p.acked != 1 {
+		t.Fatalf("expected message to be acked once got %d", p.acked)
+	}
+
+	list, err := q.List("test.topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 quarantined message got %d", len(list))
+	}
+	if m := list[0]; m.Id != "1" || m.Error != "poison" || m.Failures != 3 || string(m.Body) != "hello" {
+		t.Fatalf("unexpected quarantined message %+v", m)
+	}
+
+	if list, _ := q.List("other.topic"); len(list) != 0 {
+		t.Fatalf("expected no messages for other topic got %d", len(list))
+	}
+
+	// requeue the message
+	ch := make(chan *broker.Message, 1)
+	sub, err := b.Subscribe("test.topic", func(p broker.Publication) error {
+		ch <- p.Message()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	if err := q.Requeue("1"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case m := <-ch:
+		if string(m.Body) != "hello" {
+			t.Fatalf("expected requeued body hello got %s", m.Body)
+		}
+	default:
+		t.Fatal("expected message to be requeued")
+	}
+
+	if list, _ := q.List(""); len(list) != 0 {
+		t.Fatalf("expected requeued message to be removed got %d", len(list))
+	}
+
+	// successes reset the failure count
+	p.message.Header[DefaultIDHeader] = "2"
+	h(p)
+	fail = false
+	h(p)
+	fail = true
+	h(p)
+	h(p)
+	if list, _ := q.List(""); len(list) != 0 {
+		t.Fatalf("expected failures to be reset got %d quarantined", len(list))
+	}
+
+	if err := h(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Purge("2"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := q.List(""); len(list) != 0 {
+		t.Fatalf("expected purged message to be removed got %d", len(list))
+	}
+}
+
+func TestFailureTTL(t *testing.T) {
+	s := NewMemoryStore()
+
+	for i := 1; i <= 2; i++ {
+		n, err := s.Fail("1", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != i {
+			t.Fatalf("expected %d failures got %d", i, n)
+		}
+	}
+
+	// expired failures start again and are swept
+	if _, err := s.Fail("2", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if n, _ := s.Fail("2", time.Millisecond); n != 1 {
+		t.Fatalf("expected expired failures to reset got %d", n)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	s.Fail("3", time.Millisecond)
+
+	m := s.(*memoryStore)
+	if _, ok := m.failures["2"]; ok {
+		t.Fatal("expected expired failures to be swept")
+	}
+	if f := m.failures["1"]; f == nil || f.n != 2 {
+		t.Fatalf("expected unexpired failures to be kept got %+v", f)
+	}
+}
+
+func TestDefaultID(t *testing.T) {
+	id := func(header map[string]string, body string) string {
+		return defaultID(&testPublication{
+			topic:   "test.topic",
+			message: &broker.Message{Header: header, Body: []byte(body)},
+		})
+	}
+
+	if id(map[string]string{DefaultIDHeader: "1"}, "a") != "1" {
+		t.Fatal("expected the id header to be used")
+	}
+
+	a := id(map[string]string{"Key": "a"}, "body")
+	if a != id(map[string]string{"Key": "a"}, "body") {
+		t.Fatal("expected identical messages to have the same id")
+	}
+	if a == id(map[string]string{"Key": "b"}, "body") {
+		t.Fatal("expected messages with different headers to have different ids")
+	}
+}
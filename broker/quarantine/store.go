@@ -0,0 +1,212 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// Store tracks failures and holds quarantined messages
+type Store interface {
+	// Fail increments and returns the consecutive failures of the message.
+	// The failures expire ttl after the last one so messages which are
+	// never redelivered don't hold a counter forever.
+	Fail(id string, ttl time.Duration) (int, error)
+	// Reset clears the failures of the message
+	Reset(id string) error
+	// Put quarantines the message
+	Put(m *Message) error
+	// Get returns the quarantined message, nil if not found
+	Get(id string) (*Message, error)
+	// List returns the quarantined messages for the topic or all if empty
+	List(topic string) ([]*Message, error)
+	// Delete removes the quarantined message
+	Delete(id string) error
+}
+
+type failure struct {
+	n       int
+	expires time.Time
+}
+
+type memoryStore struct {
+	sync.RWMutex
+	failures map[string]*failure
+	messages map[string]*Message
+	// when expired failures were last swept
+	swept time.Time
+}
+
+func (m *memoryStore) Fail(id string, ttl time.Duration) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+
+	// sweep at most once per ttl so the cost is spread over the writes
+	if now.Sub(m.swept) > ttl {
+		for k, f := range m.failures {
+			if now.After(f.expires) {
+				delete(m.failures, k)
+			}
+		}
+		m.swept = now
+	}
+
+	f, ok := m.failures[id]
+	if !ok || now.After(f.expires) {
+		f = &failure{}
+		m.failures[id] = f
+	}
+	f.n++
+	f.expires = now.Add(ttl)
+
+	return f.n, nil
+}
+
+func (m *memoryStore) Reset(id string) error {
+	m.Lock()
+	delete(m.failures, id)
+	m.Unlock()
+	return nil
+}
+
+func (m *memoryStore) Put(msg *Message) error {
+	m.Lock()
+	m.messages[msg.Id] = msg
+	m.Unlock()
+	return nil
+}
+
+func (m *memoryStore) Get(id string) (*Message, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.messages[id], nil
+}
+
+func (m *memoryStore) List(topic string) ([]*Message, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	var list []*Message
+	for _, msg := range m.messages {
+		if len(topic) > 0 && msg.Topic != topic {
+			continue
+		}
+		list = append(list, msg)
+	}
+	return list, nil
+}
+
+func (m *memoryStore) Delete(id string) error {
+	m.Lock()
+	delete(m.messages, id)
+	m.Unlock()
+	return nil
+}
+
+// NewMemoryStore returns an in-memory store local to the process
+func NewMemoryStore() Store {
+	return &memoryStore{
+		failures: make(map[string]*failure),
+		messages: make(map[string]*Message),
+	}
+}
+
+type redisStore struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// failures are a key per message so each one expires on its own
+func (r *redisStore) Fail(id string, ttl time.Duration) (int, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	key := r.prefix + "failures:" + id
+
+	conn.Send("MULTI")
+	conn.Send("INCR", key)
+	conn.Send("PEXPIRE", key, int64(ttl/time.Millisecond))
+	v, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+	return redis.Int(v[0], nil)
+}
+
+func (r *redisStore) Reset(id string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("DEL", r.prefix+"failures:"+id)
+	return err
+}
+
+func (r *redisStore) Put(m *Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+	_, err = conn.Do("HSET", r.prefix+"messages", m.Id, b)
+	return err
+}
+
+func (r *redisStore) Get(id string) (*Message, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	b, err := redis.Bytes(conn.Do("HGET", r.prefix+"messages", id))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var m *Message
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (r *redisStore) List(topic string) ([]*Message, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	values, err := redis.ByteSlices(conn.Do("HVALS", r.prefix+"messages"))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Message
+	for _, b := range values {
+		var m *Message
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		if len(topic) > 0 && m.Topic != topic {
+			continue
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+func (r *redisStore) Delete(id string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("HDEL", r.prefix+"messages", id)
+	return err
+}
+
+// NewRedisStore returns a store shared across instances through redis
+func NewRedisStore(pool *redis.Pool) Store {
+	return &redisStore{
+		pool:   pool,
+		prefix: "micro:quarantine:",
+	}
+}
@@ -68,30 +68,52 @@ func (r *rabbitMQChannel) DeclareExchange(exchange string) error {
 	)
 }
 
-func (r *rabbitMQChannel) DeclareQueue(queue string) error {
+func (r *rabbitMQChannel) DeclareDelayedExchange(exchange string) error {
+	args := amqp.Table{"x-delayed-type": "topic"}
+	return r.channel.ExchangeDeclare(
+		exchange,            // name
+		"x-delayed-message", // kind
+		false,               // durable
+		false,               // autoDelete
+		false,               // internal
+		false,               // noWait
+		args,                // args
+	)
+}
+
+func (r *rabbitMQChannel) DeclareQueue(queue string, args amqp.Table) error {
 	_, err := r.channel.QueueDeclare(
 		queue, // name
 		false, // durable
 		true,  // autoDelete
 		false, // exclusive
 		false, // noWait
-		nil,   // args
+		args,  // args
 	)
 	return err
 }
 
-func (r *rabbitMQChannel) DeclareDurableQueue(queue string) error {
+func (r *rabbitMQChannel) DeclareDurableQueue(queue string, args amqp.Table) error {
 	_, err := r.channel.QueueDeclare(
 		queue, // name
 		true,  // durable
 		false, // autoDelete
 		false, // exclusive
 		false, // noWait
-		nil,   // args
+		args,  // args
 	)
 	return err
 }
 
+// Qos limits the number of unacknowledged deliveries sent to the channel
+func (r *rabbitMQChannel) Qos(prefetchCount int) error {
+	return r.channel.Qos(
+		prefetchCount, // prefetchCount
+		0,             // prefetchSize
+		false,         // global
+	)
+}
+
 func (r *rabbitMQChannel) DeclareReplyQueue(queue string) error {
 	_, err := r.channel.QueueDeclare(
 		queue, // name
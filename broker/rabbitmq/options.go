@@ -9,6 +9,11 @@ import (
 type durableQueueKey struct{}
 type headersKey struct{}
 type exchangeKey struct{}
+type prefetchCountKey struct{}
+type maxPriorityKey struct{}
+type singleActiveConsumerKey struct{}
+type priorityKey struct{}
+type delayedExchangeKey struct{}
 
 // DurableQueue creates a durable queue when subscribing.
 func DurableQueue() broker.SubscribeOption {
@@ -39,3 +44,60 @@ func Exchange(e string) broker.Option {
 		o.Context = context.WithValue(o.Context, exchangeKey{}, e)
 	}
 }
+
+// PrefetchCount sets the number of unacknowledged messages
+// delivered to the subscriber at a time using basic.qos
+func PrefetchCount(n int) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, prefetchCountKey{}, n)
+	}
+}
+
+// MaxPriority declares the queue as a priority queue supporting
+// message priorities up to n, see the Priority publish option
+func MaxPriority(n uint8) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, maxPriorityKey{}, n)
+	}
+}
+
+// SingleActiveConsumer declares the queue so only one subscriber at a time
+// receives messages, with others taking over if it goes away. Messages are
+// handled one at a time in order.
+func SingleActiveConsumer() broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, singleActiveConsumerKey{}, true)
+	}
+}
+
+// Priority sets the priority of a published message. The queue
+// must be declared with MaxPriority for it to take effect.
+func Priority(p uint8) broker.PublishOption {
+	return func(o *broker.PublishOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, priorityKey{}, p)
+	}
+}
+
+// DelayedExchange declares the exchange as an x-delayed-message exchange
+// so messages published with delivery.Delay are held by the broker. It
+// requires the rabbitmq_delayed_message_exchange plugin.
+func DelayedExchange() broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, delayedExchangeKey{}, true)
+	}
+}
@@ -4,9 +4,14 @@ package rabbitmq
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-plugins/broker/delivery"
+	"github.com/micro/go-plugins/broker/security"
 	"github.com/streadway/amqp"
 )
 
@@ -57,20 +62,84 @@ func (s *subscriber) Unsubscribe() error {
 }
 
 func (r *rbroker) Publish(topic string, msg *broker.Message, opts ...broker.PublishOption) error {
+	var options broker.PublishOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if r.conn == nil {
+		return errors.New("connection is nil")
+	}
+
+	m := publishing(msg, options, r.conn.delayed)
+
+	return r.conn.Publish(r.conn.exchange, topic, m)
+}
+
+// publishing returns the amqp message for the broker message,
+// delays are only set if the exchange supports them
+func publishing(msg *broker.Message, options broker.PublishOptions, delayed bool) amqp.Publishing {
 	m := amqp.Publishing{
 		Body:    msg.Body,
 		Headers: amqp.Table{},
 	}
 
+	if options.Context != nil {
+		if p, ok := options.Context.Value(priorityKey{}).(uint8); ok {
+			m.Priority = p
+		}
+	}
+
+	if ttl, ok := delivery.GetTTL(options); ok {
+		m.Expiration = strconv.FormatInt(int64(ttl/time.Millisecond), 10)
+	}
+
+	if delay, ok := delivery.GetDelay(options); ok && delayed {
+		m.Headers["x-delay"] = int64(delay / time.Millisecond)
+	}
+
 	for k, v := range msg.Header {
 		m.Headers[k] = v
 	}
 
-	if r.conn == nil {
-		return errors.New("connection is nil")
+	return m
+}
+
+// queueOptions returns the queue arguments, the prefetch count and
+// whether the queue has a single active consumer
+func queueOptions(opt broker.SubscribeOptions) (amqp.Table, int, bool) {
+	if opt.Context == nil {
+		return nil, 0, false
 	}
 
-	return r.conn.Publish(r.conn.exchange, topic, m)
+	args := amqp.Table{}
+
+	prefetchCount, _ := opt.Context.Value(prefetchCountKey{}).(int)
+	if p, ok := opt.Context.Value(maxPriorityKey{}).(uint8); ok {
+		args["x-max-priority"] = p
+	}
+	singleActive, _ := opt.Context.Value(singleActiveConsumerKey{}).(bool)
+	if singleActive {
+		args["x-single-active-consumer"] = true
+	}
+
+	if len(args) == 0 {
+		args = nil
+	}
+
+	return args, prefetchCount, singleActive
+}
+
+// dispatch handles the deliveries concurrently, or one at a
+// time in order for a single active consumer
+func dispatch(sub <-chan amqp.Delivery, fn func(amqp.Delivery), ordered bool) {
+	for d := range sub {
+		if ordered {
+			fn(d)
+			continue
+		}
+		go fn(d)
+	}
 }
 
 func (r *rbroker) Subscribe(topic string, handler broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
@@ -94,6 +163,8 @@ func (r *rbroker) Subscribe(topic string, handler broker.Handler, opts ...broker
 		}
 	}
 
+	queueArgs, prefetchCount, singleActive := queueOptions(opt)
+
 	if r.conn == nil {
 		return nil, errors.New("connection is nil")
 	}
@@ -102,6 +173,8 @@ func (r *rbroker) Subscribe(topic string, handler broker.Handler, opts ...broker
 		opt.Queue,
 		topic,
 		headers,
+		queueArgs,
+		prefetchCount,
 		opt.AutoAck,
 		durableQueue,
 	)
@@ -121,11 +194,7 @@ func (r *rbroker) Subscribe(topic string, handler broker.Handler, opts ...broker
 		handler(&publication{d: msg, m: m, t: msg.RoutingKey})
 	}
 
-	go func() {
-		for d := range sub {
-			go fn(d)
-		}
-	}()
+	go dispatch(sub, fn, singleActive)
 
 	return &subscriber{ch: ch, topic: topic, opts: opt}, nil
 }
@@ -153,8 +222,19 @@ func (r *rbroker) Init(opts ...broker.Option) error {
 }
 
 func (r *rbroker) Connect() error {
+	if err := security.Err(r.opts); err != nil {
+		return err
+	}
+
 	if r.conn == nil {
 		r.conn = newRabbitMQConn(r.getExchange(), r.opts.Addrs)
+
+		auth, err := saslAuth(r.opts)
+		if err != nil {
+			return err
+		}
+		r.conn.auth = auth
+		r.conn.delayed, _ = r.opts.Context.Value(delayedExchangeKey{}).(bool)
 	}
 	return r.conn.Connect(r.opts.Secure, r.opts.TLSConfig)
 }
@@ -187,3 +267,46 @@ func (r *rbroker) getExchange() string {
 	}
 	return DefaultExchange
 }
+
+// tokenAuth authenticates with an OAuth token as the PLAIN password
+// as expected by the RabbitMQ OAuth 2.0 auth backend. The token is
+// fetched before each dial so failures are returned by the dial.
+type tokenAuth struct {
+	fn    security.TokenFunc
+	token string
+}
+
+// refresh fetches the token used by the next dial
+func (t *tokenAuth) refresh() error {
+	token, err := t.fn()
+	if err != nil {
+		return fmt.Errorf("failed to get oauth token: %v", err)
+	}
+	t.token = token
+	return nil
+}
+
+func (t *tokenAuth) Mechanism() string {
+	return "PLAIN"
+}
+
+func (t *tokenAuth) Response() string {
+	return "\000\000" + t.token
+}
+
+// saslAuth returns the amqp authentication for the SASL options
+func saslAuth(opts broker.Options) ([]amqp.Authentication, error) {
+	s, ok := security.GetSASL(opts)
+	if !ok {
+		return nil, nil
+	}
+
+	switch s.Mechanism {
+	case security.Plain:
+		return []amqp.Authentication{&amqp.PlainAuth{Username: s.Username, Password: s.Password}}, nil
+	case security.OAuthBearer:
+		return []amqp.Authentication{&tokenAuth{fn: s.Token}}, nil
+	}
+
+	return nil, security.ErrUnsupported("rabbitmq", s.Mechanism)
+}
@@ -0,0 +1,135 @@
+package rabbitmq
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-plugins/broker/delivery"
+	"github.com/streadway/amqp"
+)
+
+func TestPublishing(t *testing.T) {
+	var options broker.PublishOptions
+	for _, o := range []broker.PublishOption{
+		Priority(5),
+		delivery.TTL(time.Minute),
+		delivery.Delay(time.Second),
+	} {
+		o(&options)
+	}
+
+	msg := &broker.Message{
+		Header: map[string]string{"Micro-Id": "1"},
+		Body:   []byte("hello"),
+	}
+
+	m := publishing(msg, options, true)
+	if m.Priority != 5 {
+		t.Fatalf("expected priority 5 got %d", m.Priority)
+	}
+	if m.Expiration != "60000" {
+		t.Fatalf("expected expiration 60000 got %s", m.Expiration)
+	}
+	if m.Headers["x-delay"] != int64(1000) || m.Headers["Micro-Id"] != "1" {
+		t.Fatalf("unexpected headers %v", m.Headers)
+	}
+
+	// delays aren't set without the delayed message exchange
+	if m := publishing(msg, options, false); m.Headers["x-delay"] != nil {
+		t.Fatalf("expected no delay got %v", m.Headers["x-delay"])
+	}
+
+	if m := publishing(msg, broker.PublishOptions{}, false); m.Priority != 0 || len(m.Expiration) > 0 {
+		t.Fatalf("expected no priority or expiration got %+v", m)
+	}
+}
+
+func TestQueueOptions(t *testing.T) {
+	args, prefetch, single := queueOptions(broker.SubscribeOptions{})
+	if args != nil || prefetch != 0 || single {
+		t.Fatalf("expected no queue options got %v %d %v", args, prefetch, single)
+	}
+
+	var opt broker.SubscribeOptions
+	for _, o := range []broker.SubscribeOption{
+		PrefetchCount(10),
+		MaxPriority(9),
+		SingleActiveConsumer(),
+	} {
+		o(&opt)
+	}
+
+	args, prefetch, single = queueOptions(opt)
+	if prefetch != 10 || !single {
+		t.Fatalf("expected prefetch 10 and single active consumer got %d %v", prefetch, single)
+	}
+	if args["x-max-priority"] != uint8(9) || args["x-single-active-consumer"] != true {
+		t.Fatalf("unexpected queue args %v", args)
+	}
+}
+
+func TestDispatchOrdered(t *testing.T) {
+	sub := make(chan amqp.Delivery, 10)
+	for i := 0; i < 10; i++ {
+		sub <- amqp.Delivery{Body: []byte{byte(i)}}
+	}
+	close(sub)
+
+	var mtx sync.Mutex
+	var order []byte
+	var active, overlaps int
+
+	dispatch(sub, func(d amqp.Delivery) {
+		mtx.Lock()
+		active++
+		if active > 1 {
+			overlaps++
+		}
+		mtx.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mtx.Lock()
+		active--
+		order = append(order, d.Body[0])
+		mtx.Unlock()
+	}, true)
+
+	if overlaps > 0 {
+		t.Fatalf("expected deliveries to be handled one at a time got %d overlaps", overlaps)
+	}
+	for i, b := range order {
+		if int(b) != i {
+			t.Fatalf("expected deliveries in order got %v", order)
+		}
+	}
+	if len(order) != 10 {
+		t.Fatalf("expected 10 deliveries got %d", len(order))
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	var calls int
+	auth := &tokenAuth{fn: func() (string, error) {
+		calls++
+		if calls > 1 {
+			return "", errors.New("unavailable")
+		}
+		return "token", nil
+	}}
+
+	if err := auth.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if r := auth.Response(); r != "\000\000token" {
+		t.Fatalf("unexpected response %q", r)
+	}
+
+	// provider errors are returned rather than dialling without a token
+	if err := auth.refresh(); err == nil {
+		t.Fatal("expected the provider error")
+	}
+}
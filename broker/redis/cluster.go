@@ -0,0 +1,545 @@
+package redis
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+)
+
+const (
+	// hashSlots is the number of slots keys and channels are hashed into
+	hashSlots = 16384
+
+	// publishAttempts bounds the redirects and retries of a sharded publish
+	publishAttempts = 3
+)
+
+// slotRange is a range of slots served by a master
+type slotRange struct {
+	start int
+	end   int
+	addr  string
+}
+
+// cluster publishes and subscribes on a Redis Cluster. With sharded
+// pub/sub, available from Redis 7, a topic is only published to and
+// subscribed on the master owning its slot. Otherwise messages are
+// published to any node and broadcast across the cluster.
+type cluster struct {
+	b     *redisBroker
+	addrs []string
+
+	sync.RWMutex
+	slots   []slotRange
+	pools   map[string]*redis.Pool
+	sharded bool
+	subs    map[*clusterSubscriber]bool
+}
+
+// clusterSubscriber is a subscription which reconnects when
+// its slot moves or the node fails
+type clusterSubscriber struct {
+	c      *cluster
+	topic  string
+	handle broker.Handler
+	opts   broker.SubscribeOptions
+	exit   chan bool
+	once   sync.Once
+
+	sync.Mutex
+	conn redis.Conn
+}
+
+// crc16 is the CRC16 XMODEM checksum used to hash keys into slots
+func crc16(b []byte) uint16 {
+	var crc uint16
+	for _, v := range b {
+		crc ^= uint16(v) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// slot returns the slot of the key, hashing only the
+// hash tag within braces if the key has one
+func slot(key string) int {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+1+e]
+		}
+	}
+	return int(crc16([]byte(key)) % hashSlots)
+}
+
+// moved returns the address of a MOVED redirection error
+func moved(err error) (string, bool) {
+	rerr, ok := err.(redis.Error)
+	if !ok || !strings.HasPrefix(string(rerr), "MOVED ") {
+		return "", false
+	}
+	parts := strings.Fields(string(rerr))
+	if len(parts) != 3 {
+		return "", false
+	}
+	return parts[2], true
+}
+
+func newCluster(b *redisBroker) (*cluster, error) {
+	var addrs []string
+	for _, addr := range b.opts.Addrs {
+		addr = strings.TrimPrefix(addr, "redis://")
+		addr = strings.TrimPrefix(addr, "rediss://")
+		if len(addr) > 0 {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		addrs = []string{"127.0.0.1:6379"}
+	}
+
+	c := &cluster{
+		b:     b,
+		addrs: addrs,
+		pools: make(map[string]*redis.Pool),
+		subs:  make(map[*clusterSubscriber]bool),
+	}
+
+	if err := c.refresh(); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	c.sharded = c.supportsSharded()
+
+	return c, nil
+}
+
+// pool returns the connection pool of the node
+func (c *cluster) pool(addr string) *redis.Pool {
+	c.Lock()
+	defer c.Unlock()
+
+	if p, ok := c.pools[addr]; ok {
+		return p
+	}
+
+	b := c.b
+	p := &redis.Pool{
+		MaxIdle:     b.bopts.maxIdle,
+		MaxActive:   b.bopts.maxActive,
+		IdleTimeout: b.bopts.idleTimeout,
+		Dial: func() (redis.Conn, error) {
+			return b.auth(redis.Dial("tcp", addr, b.dialOptions(b.bopts.readTimeout)...))
+		},
+		TestOnBorrow: func(conn redis.Conn, t time.Time) error {
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+	c.pools[addr] = p
+
+	return p
+}
+
+// nodes returns the known masters followed by the seed addresses
+func (c *cluster) nodes() []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	seen := make(map[string]bool)
+	var nodes []string
+	for _, s := range c.slots {
+		if !seen[s.addr] {
+			seen[s.addr] = true
+			nodes = append(nodes, s.addr)
+		}
+	}
+	for _, addr := range c.addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			nodes = append(nodes, addr)
+		}
+	}
+	return nodes
+}
+
+// refresh loads the slot ranges from the first node which responds
+func (c *cluster) refresh() error {
+	var err error
+
+	for _, addr := range c.nodes() {
+		var slots []slotRange
+		if slots, err = c.clusterSlots(addr); err != nil {
+			continue
+		}
+
+		sort.Slice(slots, func(i, j int) bool {
+			return slots[i].start < slots[j].start
+		})
+
+		c.Lock()
+		c.slots = slots
+		c.Unlock()
+		return nil
+	}
+
+	return err
+}
+
+// clusterSlots queries the node for the masters of each slot range
+func (c *cluster) clusterSlots(addr string) ([]slotRange, error) {
+	conn := c.pool(addr).Get()
+	defer conn.Close()
+
+	reply, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []slotRange
+
+	for _, r := range reply {
+		v, err := redis.Values(r, nil)
+		if err != nil || len(v) < 3 {
+			return nil, errors.New("redis: invalid cluster slots reply")
+		}
+
+		start, _ := redis.Int(v[0], nil)
+		end, _ := redis.Int(v[1], nil)
+
+		master, err := redis.Values(v[2], nil)
+		if err != nil || len(master) < 2 {
+			return nil, errors.New("redis: invalid cluster slots reply")
+		}
+
+		host, _ := redis.String(master[0], nil)
+		port, _ := redis.Int(master[1], nil)
+
+		// an empty host is the node queried
+		if len(host) == 0 {
+			host, _, _ = net.SplitHostPort(addr)
+		}
+
+		slots = append(slots, slotRange{
+			start: start,
+			end:   end,
+			addr:  net.JoinHostPort(host, strconv.Itoa(port)),
+		})
+	}
+
+	if len(slots) == 0 {
+		return nil, errors.New("redis: no cluster slots assigned")
+	}
+
+	return slots, nil
+}
+
+// supportsSharded returns whether the cluster supports sharded pub/sub
+func (c *cluster) supportsSharded() bool {
+	conn := c.pool(c.nodes()[0]).Get()
+	defer conn.Close()
+
+	reply, err := redis.Values(conn.Do("COMMAND", "INFO", "SPUBLISH"))
+	return err == nil && len(reply) > 0 && reply[0] != nil
+}
+
+// node returns the master owning the topic's slot
+func (c *cluster) node(topic string) string {
+	s := slot(topic)
+
+	c.RLock()
+	defer c.RUnlock()
+
+	i := sort.Search(len(c.slots), func(i int) bool {
+		return c.slots[i].end >= s
+	})
+	if i < len(c.slots) && c.slots[i].start <= s {
+		return c.slots[i].addr
+	}
+
+	return c.addrs[0]
+}
+
+// connErr returns whether the error is a connection error
+// rather than an error replied by the node
+func connErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := err.(redis.Error)
+	return !ok
+}
+
+func (c *cluster) publish(topic string, v []byte) error {
+	// messages are broadcast to every node, so any node which
+	// can be reached is published to
+	if !c.sharded {
+		var err error
+		for _, addr := range c.nodes() {
+			conn := c.pool(addr).Get()
+			_, err = conn.Do("PUBLISH", topic, v)
+			conn.Close()
+
+			if !connErr(err) {
+				return err
+			}
+		}
+		return err
+	}
+
+	addr := c.node(topic)
+
+	var err error
+
+	// follow a redirect if the slot moved, or the new master of
+	// the slot if the node failed
+	for i := 0; i < publishAttempts; i++ {
+		conn := c.pool(addr).Get()
+		_, err = conn.Do("SPUBLISH", topic, v)
+		conn.Close()
+
+		to, ok := moved(err)
+		if !ok && !connErr(err) {
+			return err
+		}
+
+		if rerr := c.refresh(); rerr != nil {
+			log.Logf("[redis] failed to refresh cluster slots: %v", rerr)
+		}
+
+		if ok {
+			addr = to
+		} else {
+			addr = c.node(topic)
+		}
+	}
+
+	return err
+}
+
+func (c *cluster) subscribe(topic string, handler broker.Handler, opts broker.SubscribeOptions) (broker.Subscriber, error) {
+	s := &clusterSubscriber{
+		c:      c,
+		topic:  topic,
+		handle: handler,
+		opts:   opts,
+		exit:   make(chan bool),
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	c.subs[s] = true
+	c.Unlock()
+
+	go s.recv()
+
+	return s, nil
+}
+
+func (c *cluster) close() error {
+	c.Lock()
+	subs := c.subs
+	pools := c.pools
+	c.subs = make(map[*clusterSubscriber]bool)
+	c.pools = make(map[string]*redis.Pool)
+	c.Unlock()
+
+	for s := range subs {
+		s.Unsubscribe()
+	}
+
+	var err error
+	for _, p := range pools {
+		if perr := p.Close(); perr != nil {
+			err = perr
+		}
+	}
+	return err
+}
+
+// command returns the subscribe command for the cluster
+func (s *clusterSubscriber) command() string {
+	if s.c.sharded {
+		return "SSUBSCRIBE"
+	}
+	return "SUBSCRIBE"
+}
+
+// connect subscribes on the node owning the topic, following a
+// redirect once, or on any node which can be reached without sharded
+// pub/sub. Subscriptions don't time out waiting for messages.
+func (s *clusterSubscriber) connect() error {
+	if !s.c.sharded {
+		var err error
+		for _, addr := range s.c.nodes() {
+			if err = s.dial(addr); !connErr(err) {
+				return err
+			}
+		}
+		return err
+	}
+
+	addr := s.c.node(s.topic)
+
+	var err error
+
+	for i := 0; i < 2; i++ {
+		err = s.dial(addr)
+
+		to, ok := moved(err)
+		if !ok {
+			return err
+		}
+
+		if rerr := s.c.refresh(); rerr != nil {
+			log.Logf("[redis] failed to refresh cluster slots: %v", rerr)
+		}
+		addr = to
+	}
+
+	return err
+}
+
+// dial subscribes on the node
+func (s *clusterSubscriber) dial(addr string) error {
+	b := s.c.b
+
+	conn, err := b.auth(redis.Dial("tcp", addr, b.dialOptions(0)...))
+	if err != nil {
+		return err
+	}
+
+	if err = conn.Send(s.command(), s.topic); err == nil {
+		err = conn.Flush()
+	}
+	if err == nil {
+		// the first reply confirms the subscription
+		_, err = conn.Receive()
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	// unsubscribed while connecting
+	select {
+	case <-s.exit:
+		conn.Close()
+		return errors.New("redis: unsubscribed")
+	default:
+	}
+
+	s.conn = conn
+	return nil
+}
+
+// reconnect subscribes again after the slot moved or the node failed
+func (s *clusterSubscriber) reconnect() bool {
+	for {
+		select {
+		case <-s.exit:
+			return false
+		default:
+		}
+
+		if err := s.c.refresh(); err != nil {
+			log.Logf("[redis] failed to refresh cluster slots: %v", err)
+		} else if err := s.connect(); err == nil {
+			return true
+		} else {
+			log.Logf("[redis] failed to resubscribe to %s: %v", s.topic, err)
+		}
+
+		select {
+		case <-s.exit:
+			return false
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *clusterSubscriber) recv() {
+	for {
+		s.Lock()
+		conn := s.conn
+		s.Unlock()
+
+		reply, err := conn.Receive()
+		if err != nil {
+			conn.Close()
+			if !s.reconnect() {
+				return
+			}
+			continue
+		}
+
+		v, err := redis.Values(reply, nil)
+		if err != nil || len(v) < 3 {
+			continue
+		}
+
+		kind, _ := redis.String(v[0], nil)
+
+		switch kind {
+		case "message", "smessage":
+			channel, _ := redis.String(v[1], nil)
+			data, _ := redis.Bytes(v[2], nil)
+			deliver(s.c.b.opts.Codec, s.handle, s.opts, channel, data)
+		case "sunsubscribe":
+			// the cluster unsubscribes when the slot moves
+			conn.Close()
+			if !s.reconnect() {
+				return
+			}
+		}
+	}
+}
+
+// Options returns the subscriber options.
+func (s *clusterSubscriber) Options() broker.SubscribeOptions {
+	return s.opts
+}
+
+// Topic returns the topic of the subscriber.
+func (s *clusterSubscriber) Topic() string {
+	return s.topic
+}
+
+// Unsubscribe closes the subscription's connection.
+func (s *clusterSubscriber) Unsubscribe() error {
+	var err error
+
+	s.once.Do(func() {
+		close(s.exit)
+
+		s.c.Lock()
+		delete(s.c.subs, s)
+		s.c.Unlock()
+
+		s.Lock()
+		defer s.Unlock()
+		err = s.conn.Close()
+	})
+
+	return err
+}
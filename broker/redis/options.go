@@ -25,6 +25,9 @@ type brokerOptions struct {
 	connectTimeout time.Duration
 	readTimeout    time.Duration
 	writeTimeout   time.Duration
+	cluster        bool
+	username       string
+	password       string
 }
 
 type optionsKeyType struct{}
@@ -70,3 +73,23 @@ func IdleTimeout(d time.Duration) broker.Option {
 		bo.idleTimeout = d
 	}
 }
+
+// Cluster connects to a Redis Cluster, using the broker addresses as the
+// seed nodes. Sharded pub/sub is used when the cluster supports it so
+// messages are only sent to the shard owning the topic.
+func Cluster() broker.Option {
+	return func(o *broker.Options) {
+		bo := o.Context.Value(optionsKey).(*brokerOptions)
+		bo.cluster = true
+	}
+}
+
+// Auth authenticates connections as the ACL user, overriding
+// any password in the address
+func Auth(username, password string) broker.Option {
+	return func(o *broker.Options) {
+		bo := o.Context.Value(optionsKey).(*brokerOptions)
+		bo.username = username
+		bo.password = password
+	}
+}
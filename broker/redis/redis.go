@@ -3,6 +3,7 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"strings"
 	"time"
@@ -58,30 +59,7 @@ func (s *subscriber) recv() {
 	for {
 		switch x := s.conn.Receive().(type) {
 		case redis.Message:
-			var m broker.Message
-
-			// Handle error? Only a log would be necessary since this type
-			// of issue cannot be fixed.
-			if err := s.codec.Unmarshal(x.Data, &m); err != nil {
-				break
-			}
-
-			p := publication{
-				topic:   x.Channel,
-				message: &m,
-			}
-
-			// Handle error? Retry?
-			if err := s.handle(&p); err != nil {
-				break
-			}
-
-			// Added for posterity, however Ack is a no-op.
-			if s.opts.AutoAck {
-				if err := p.Ack(); err != nil {
-					break
-				}
-			}
+			deliver(s.codec, s.handle, s.opts, x.Channel, x.Data)
 
 		case redis.Subscription:
 			if x.Count == 0 {
@@ -94,6 +72,32 @@ func (s *subscriber) recv() {
 	}
 }
 
+// deliver decodes a message and calls the handler with it
+func deliver(c codec.Codec, handle broker.Handler, opts broker.SubscribeOptions, channel string, data []byte) {
+	var m broker.Message
+
+	// Handle error? Only a log would be necessary since this type
+	// of issue cannot be fixed.
+	if err := c.Unmarshal(data, &m); err != nil {
+		return
+	}
+
+	p := publication{
+		topic:   channel,
+		message: &m,
+	}
+
+	// Handle error? Retry?
+	if err := handle(&p); err != nil {
+		return
+	}
+
+	// Added for posterity, however Ack is a no-op.
+	if opts.AutoAck {
+		p.Ack()
+	}
+}
+
 // Options returns the subscriber options.
 func (s *subscriber) Options() broker.SubscribeOptions {
 	return s.opts
@@ -111,10 +115,11 @@ func (s *subscriber) Unsubscribe() error {
 
 // broker implementation for Redis.
 type redisBroker struct {
-	addr  string
-	pool  *redis.Pool
-	opts  broker.Options
-	bopts *brokerOptions
+	addr    string
+	pool    *redis.Pool
+	cluster *cluster
+	opts    broker.Options
+	bopts   *brokerOptions
 }
 
 // String returns the name of the broker implementation.
@@ -135,7 +140,7 @@ func (b *redisBroker) Address() string {
 
 // Init sets or overrides broker options.
 func (b *redisBroker) Init(opts ...broker.Option) error {
-	if b.pool != nil {
+	if b.pool != nil || b.cluster != nil {
 		return errors.New("redis: cannot init while connected")
 	}
 
@@ -146,10 +151,55 @@ func (b *redisBroker) Init(opts ...broker.Option) error {
 	return nil
 }
 
+// dialOptions returns the timeout and TLS options connections are dialed with
+func (b *redisBroker) dialOptions(readTimeout time.Duration) []redis.DialOption {
+	opts := []redis.DialOption{
+		redis.DialConnectTimeout(b.bopts.connectTimeout),
+		redis.DialReadTimeout(readTimeout),
+		redis.DialWriteTimeout(b.bopts.writeTimeout),
+	}
+
+	if b.opts.Secure || b.opts.TLSConfig != nil {
+		config := b.opts.TLSConfig
+		if config == nil {
+			config = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+		opts = append(opts, redis.DialUseTLS(true), redis.DialTLSConfig(config))
+	}
+
+	return opts
+}
+
+// auth authenticates the connection as the ACL user if set
+func (b *redisBroker) auth(c redis.Conn, err error) (redis.Conn, error) {
+	if err != nil || len(b.bopts.username) == 0 {
+		return c, err
+	}
+
+	if _, err := c.Do("AUTH", b.bopts.username, b.bopts.password); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
 // Connect establishes a connection to Redis which provides the
 // pub/sub implementation.
 func (b *redisBroker) Connect() error {
-	if b.pool != nil {
+	if b.pool != nil || b.cluster != nil {
+		return nil
+	}
+
+	if b.bopts.cluster {
+		c, err := newCluster(b)
+		if err != nil {
+			return err
+		}
+		b.cluster = c
+		b.addr = c.addrs[0]
 		return nil
 	}
 
@@ -165,6 +215,11 @@ func (b *redisBroker) Connect() error {
 		}
 	}
 
+	// the scheme decides whether the url is dialed with tls
+	if b.opts.Secure || b.opts.TLSConfig != nil {
+		addr = strings.Replace(addr, "redis://", "rediss://", 1)
+	}
+
 	b.addr = addr
 
 	b.pool = &redis.Pool{
@@ -172,12 +227,7 @@ func (b *redisBroker) Connect() error {
 		MaxActive:   b.bopts.maxActive,
 		IdleTimeout: b.bopts.idleTimeout,
 		Dial: func() (redis.Conn, error) {
-			return redis.DialURL(
-				b.addr,
-				redis.DialConnectTimeout(b.bopts.connectTimeout),
-				redis.DialReadTimeout(b.bopts.readTimeout),
-				redis.DialWriteTimeout(b.bopts.writeTimeout),
-			)
+			return b.auth(redis.DialURL(b.addr, b.dialOptions(b.bopts.readTimeout)...))
 		},
 		TestOnBorrow: func(c redis.Conn, t time.Time) error {
 			_, err := c.Do("PING")
@@ -190,8 +240,14 @@ func (b *redisBroker) Connect() error {
 
 // Disconnect closes the connection pool.
 func (b *redisBroker) Disconnect() error {
-	err := b.pool.Close()
-	b.pool = nil
+	var err error
+	if b.cluster != nil {
+		err = b.cluster.close()
+		b.cluster = nil
+	} else if b.pool != nil {
+		err = b.pool.Close()
+		b.pool = nil
+	}
 	b.addr = ""
 	return err
 }
@@ -203,6 +259,10 @@ func (b *redisBroker) Publish(topic string, msg *broker.Message, opts ...broker.
 		return err
 	}
 
+	if b.cluster != nil {
+		return b.cluster.publish(topic, v)
+	}
+
 	conn := b.pool.Get()
 	_, err = redis.Int(conn.Do("PUBLISH", topic, v))
 	conn.Close()
@@ -217,6 +277,10 @@ func (b *redisBroker) Subscribe(topic string, handler broker.Handler, opts ...br
 		o(&options)
 	}
 
+	if b.cluster != nil {
+		return b.cluster.subscribe(topic, handler, options)
+	}
+
 	s := subscriber{
 		codec:  b.opts.Codec,
 		conn:   &redis.PubSubConn{Conn: b.pool.Get()},
@@ -1,12 +1,18 @@
 package redis
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/garyburd/redigo/redis"
 	"github.com/micro/go-micro/broker"
 )
 
@@ -106,3 +112,139 @@ func TestBroker(t *testing.T) {
 		t.Fatalf("expected %v, got %v", exp, actual)
 	}
 }
+
+func TestSlot(t *testing.T) {
+	testData := []struct {
+		key  string
+		slot int
+	}{
+		{"123456789", 12739},
+		{"foo", 12182},
+		// only the hash tag is hashed
+		{"{user1000}.following", slot("user1000")},
+		{"{user1000}.followers", slot("user1000")},
+		// empty tags hash the whole key
+		{"foo{}bar", slot("foo{}bar")},
+	}
+
+	for _, d := range testData {
+		if s := slot(d.key); s != d.slot {
+			t.Fatalf("expected slot %d for %s got %d", d.slot, d.key, s)
+		}
+	}
+
+	if addr, ok := moved(redis.Error("MOVED 3999 127.0.0.1:6381")); !ok || addr != "127.0.0.1:6381" {
+		t.Fatalf("expected redirect to 127.0.0.1:6381 got %s", addr)
+	}
+}
+
+// node is a fake redis node replying 1 to every command
+func node(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					// skip the arguments of the command
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					for i := 0; i < n*2; i++ {
+						if _, err := r.ReadString('\n'); err != nil {
+							return
+						}
+					}
+					conn.Write([]byte(":1\r\n"))
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestClusterFailover(t *testing.T) {
+	// nothing listens on the first node
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := l.Addr().String()
+	l.Close()
+
+	c := &cluster{
+		b:     NewBroker().(*redisBroker),
+		addrs: []string{down, node(t)},
+		pools: make(map[string]*redis.Pool),
+		subs:  make(map[*clusterSubscriber]bool),
+	}
+	defer c.close()
+
+	if err := c.publish("test", []byte("hello")); err != nil {
+		t.Fatalf("expected publish to fail over got %v", err)
+	}
+
+	s, err := c.subscribe("test", func(p broker.Publication) error {
+		return nil
+	}, broker.SubscribeOptions{})
+	if err != nil {
+		t.Fatalf("expected subscribe to fail over got %v", err)
+	}
+
+	// concurrent unsubscribes don't close twice
+	done := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func() {
+			s.Unsubscribe()
+			done <- true
+		}()
+	}
+	<-done
+	<-done
+}
+
+func TestClusterBroker(t *testing.T) {
+	addr := os.Getenv("REDIS_CLUSTER_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_CLUSTER_ADDR not defined")
+	}
+
+	b := NewBroker(broker.Addrs(addr), Cluster())
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+
+	msgs := make(chan string, 10)
+
+	s := subscribe(t, b, "test", func(p broker.Publication) error {
+		msgs <- string(p.Message().Body)
+		return nil
+	})
+	defer unsubscribe(t, s)
+
+	publish(t, b, "test", &broker.Message{
+		Body: []byte("hello"),
+	})
+
+	select {
+	case m := <-msgs:
+		if m != "hello" {
+			t.Fatalf("expected hello got %s", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
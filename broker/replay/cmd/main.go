@@ -0,0 +1,182 @@
+// Command replay republishes messages from a kafka topic or
+// jetstream stream to a topic via any registered broker
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-plugins/broker/replay"
+	"github.com/micro/go-plugins/broker/replay/jetstream"
+	"github.com/micro/go-plugins/broker/replay/kafka"
+	"github.com/nats-io/nats.go"
+
+	_ "github.com/micro/go-plugins/broker/kafka"
+	_ "github.com/micro/go-plugins/broker/nats"
+	_ "github.com/micro/go-plugins/broker/rabbitmq"
+)
+
+func source(c *cli.Context) (replay.Source, error) {
+	stream := c.String("stream")
+	addrs := strings.Split(c.String("source_address"), ",")
+
+	switch c.String("source") {
+	case "kafka":
+		config := sarama.NewConfig()
+		// record headers require kafka 0.11
+		config.Version = sarama.V0_11_0_0
+		client, err := sarama.NewClient(addrs, config)
+		if err != nil {
+			return nil, err
+		}
+		return kafka.NewSource(client, stream), nil
+	case "jetstream":
+		conn, err := nats.Connect(strings.Join(addrs, ","))
+		if err != nil {
+			return nil, err
+		}
+		js, err := conn.JetStream()
+		if err != nil {
+			return nil, err
+		}
+		return jetstream.NewSource(js, stream), nil
+	}
+
+	return nil, fmt.Errorf("unknown source %s", c.String("source"))
+}
+
+func run(c *cli.Context) error {
+	src, err := source(c)
+	if err != nil {
+		return err
+	}
+
+	newBroker, ok := cmd.DefaultBrokers[c.String("broker")]
+	if !ok {
+		return fmt.Errorf("unknown broker %s", c.String("broker"))
+	}
+
+	var bopts []broker.Option
+	if addrs := c.String("broker_address"); len(addrs) > 0 {
+		bopts = append(bopts, broker.Addrs(strings.Split(addrs, ",")...))
+	}
+
+	b := newBroker(bopts...)
+	if err := b.Connect(); err != nil {
+		return err
+	}
+	defer b.Disconnect()
+
+	opts := []replay.Option{
+		replay.Broker(b),
+		replay.Topic(c.String("topic")),
+		replay.After(c.String("after")),
+		replay.To(c.String("to")),
+		replay.Rate(c.Float64("rate")),
+		replay.DropHeader(c.StringSlice("drop_header")...),
+	}
+
+	for _, t := range []string{"since", "until"} {
+		v := c.String(t)
+		if len(v) == 0 {
+			continue
+		}
+		tm, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("invalid %s time: %v", t, err)
+		}
+		if t == "since" {
+			opts = append(opts, replay.Since(tm))
+		} else {
+			opts = append(opts, replay.Until(tm))
+		}
+	}
+
+	for _, h := range c.StringSlice("header") {
+		kv := strings.SplitN(h, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid header %s, expected key=value", h)
+		}
+		opts = append(opts, replay.SetHeader(kv[0], kv[1]))
+	}
+
+	n, err := replay.Replay(src, opts...)
+	log.Logf("[replay] republished %d messages from %s", n, src)
+	return err
+}
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "replay"
+	app.Usage = "Republish messages from a durable source"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "source",
+			Usage: "Source to read from; kafka or jetstream",
+		},
+		cli.StringFlag{
+			Name:  "source_address",
+			Usage: "Comma separated source addresses",
+		},
+		cli.StringFlag{
+			Name:  "stream",
+			Usage: "Kafka topic or jetstream stream to read",
+		},
+		cli.StringFlag{
+			Name:  "after",
+			Usage: "Replay after the offset or sequence",
+		},
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Replay up to and including the offset or sequence",
+		},
+		cli.StringFlag{
+			Name:  "since",
+			Usage: "Replay messages at or after the RFC3339 time",
+		},
+		cli.StringFlag{
+			Name:  "until",
+			Usage: "Replay messages at or before the RFC3339 time",
+		},
+		cli.StringFlag{
+			Name:  "broker",
+			Usage: "Broker to publish with",
+			Value: "kafka",
+		},
+		cli.StringFlag{
+			Name:  "broker_address",
+			Usage: "Comma separated broker addresses",
+		},
+		cli.StringFlag{
+			Name:  "topic",
+			Usage: "Topic to publish to, defaults to the message's topic",
+		},
+		cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Messages published per second, unlimited if zero",
+		},
+		cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "Header set on each message as key=value",
+		},
+		cli.StringSliceFlag{
+			Name:  "drop_header",
+			Usage: "Header removed from each message",
+		},
+	}
+	app.Action = func(c *cli.Context) {
+		if err := run(c); err != nil {
+			log.Logf("[replay] %v", err)
+			os.Exit(1)
+		}
+	}
+
+	app.Run(os.Args)
+}
@@ -0,0 +1,120 @@
+// Package jetstream provides a replay source reading a jetstream stream
+package jetstream
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/micro/go-plugins/broker/replay"
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	// Timeout waiting for the next message before giving up
+	Timeout = time.Second * 5
+)
+
+type source struct {
+	js     nats.JetStreamContext
+	stream string
+}
+
+// Read replays the stream in order, the offsets of the range are
+// stream sequences and the end is the last message when it starts
+func (s *source) Read(r replay.Range, fn func(*replay.Message) error) error {
+	info, err := s.js.StreamInfo(s.stream)
+	if err != nil {
+		return err
+	}
+
+	end := info.State.LastSeq
+	if len(r.To) > 0 {
+		to, err := strconv.ParseUint(r.To, 10, 64)
+		if err != nil {
+			return err
+		}
+		if to < end {
+			end = to
+		}
+	}
+
+	opts := []nats.SubOpt{
+		nats.BindStream(s.stream),
+		nats.OrderedConsumer(),
+	}
+
+	switch {
+	case len(r.After) > 0:
+		after, err := strconv.ParseUint(r.After, 10, 64)
+		if err != nil {
+			return err
+		}
+		if after >= end {
+			return nil
+		}
+		opts = append(opts, nats.StartSequence(after+1))
+	case !r.Since.IsZero():
+		opts = append(opts, nats.StartTime(r.Since))
+	default:
+		opts = append(opts, nats.DeliverAll())
+	}
+
+	if end == 0 || end < info.State.FirstSeq {
+		return nil
+	}
+
+	sub, err := s.js.SubscribeSync("", opts...)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		msg, err := sub.NextMsg(Timeout)
+		if err == nats.ErrTimeout {
+			// the remaining messages were deleted
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		meta, err := msg.Metadata()
+		if err != nil {
+			return err
+		}
+		if meta.Sequence.Stream > end {
+			return nil
+		}
+		if !r.Until.IsZero() && meta.Timestamp.After(r.Until) {
+			return nil
+		}
+
+		header := make(map[string]string, len(msg.Header))
+		for k := range msg.Header {
+			header[k] = msg.Header.Get(k)
+		}
+
+		if err := fn(&replay.Message{
+			Topic:     msg.Subject,
+			Offset:    strconv.FormatUint(meta.Sequence.Stream, 10),
+			Timestamp: meta.Timestamp,
+			Header:    header,
+			Body:      msg.Data,
+		}); err != nil {
+			return err
+		}
+
+		if meta.Sequence.Stream >= end {
+			return nil
+		}
+	}
+}
+
+func (s *source) String() string {
+	return "jetstream/" + s.stream
+}
+
+// NewSource returns a source reading the stream
+func NewSource(js nats.JetStreamContext, stream string) replay.Source {
+	return &source{js: js, stream: stream}
+}
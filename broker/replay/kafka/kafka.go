@@ -0,0 +1,174 @@
+// Package kafka provides a replay source reading every partition of a kafka topic
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/micro/go-plugins/broker/replay"
+)
+
+type source struct {
+	c     sarama.Client
+	topic string
+}
+
+// offset parses an offset of the range for the partition. Offsets of
+// the form partition/offset, as stamped on replayed messages, only
+// apply to their partition, plain offsets apply to every partition.
+func offset(p int32, v string) (int64, bool, error) {
+	if i := strings.IndexByte(v, '/'); i >= 0 {
+		partition, err := strconv.ParseInt(v[:i], 10, 32)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid offset %s: %v", v, err)
+		}
+		if int32(partition) != p {
+			return 0, false, nil
+		}
+		v = v[i+1:]
+	}
+
+	o, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid offset %s: %v", v, err)
+	}
+	return o, true, nil
+}
+
+// bounds returns the first and last offset of the partition in the range
+func (s *source) bounds(p int32, r replay.Range) (int64, int64, error) {
+	oldest, err := s.c.GetOffset(s.topic, p, sarama.OffsetOldest)
+	if err != nil {
+		return 0, 0, err
+	}
+	newest, err := s.c.GetOffset(s.topic, p, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start, end := oldest, newest-1
+
+	var after int64
+	var ok bool
+	if len(r.After) > 0 {
+		if after, ok, err = offset(p, r.After); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	switch {
+	case ok:
+		start = after + 1
+	case !r.Since.IsZero():
+		offset, err := s.c.GetOffset(s.topic, p, r.Since.UnixNano()/int64(time.Millisecond))
+		if err != nil {
+			return 0, 0, err
+		}
+		// nothing at or after the time
+		if offset < 0 {
+			offset = newest
+		}
+		start = offset
+	}
+
+	if len(r.To) > 0 {
+		to, ok, err := offset(p, r.To)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ok && to < end {
+			end = to
+		}
+	}
+
+	if start < oldest {
+		start = oldest
+	}
+
+	return start, end, nil
+}
+
+func (s *source) partition(cs sarama.Consumer, p int32, r replay.Range, fn func(*replay.Message) error) error {
+	start, end, err := s.bounds(p, r)
+	if err != nil {
+		return err
+	}
+	if start > end {
+		return nil
+	}
+
+	pc, err := cs.ConsumePartition(s.topic, p, start)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	for {
+		select {
+		case m := <-pc.Messages():
+			if !r.Until.IsZero() && m.Timestamp.After(r.Until) {
+				return nil
+			}
+
+			header := make(map[string]string, len(m.Headers))
+			for _, h := range m.Headers {
+				header[string(h.Key)] = string(h.Value)
+			}
+
+			if err := fn(&replay.Message{
+				Topic:     m.Topic,
+				Offset:    strconv.Itoa(int(p)) + "/" + strconv.FormatInt(m.Offset, 10),
+				Timestamp: m.Timestamp,
+				Header:    header,
+				Body:      m.Value,
+			}); err != nil {
+				return err
+			}
+
+			if m.Offset >= end {
+				return nil
+			}
+		case err := <-pc.Errors():
+			return err
+		case <-time.After(s.c.Config().Consumer.MaxWaitTime * 10):
+			// the remaining records may have been compacted away
+			return nil
+		}
+	}
+}
+
+// Read replays each partition in turn. Plain offsets of the range
+// apply to every partition, partition/offset only to its partition.
+func (s *source) Read(r replay.Range, fn func(*replay.Message) error) error {
+	partitions, err := s.c.Partitions(s.topic)
+	if err != nil {
+		return err
+	}
+
+	cs, err := sarama.NewConsumerFromClient(s.c)
+	if err != nil {
+		return err
+	}
+	defer cs.Close()
+
+	for _, p := range partitions {
+		if err := s.partition(cs, p, r, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *source) String() string {
+	return "kafka/" + s.topic
+}
+
+// NewSource returns a source reading the topic, the client
+// must be configured for kafka 0.11 to read record headers
+func NewSource(c sarama.Client, topic string) replay.Source {
+	return &source{c: c, topic: topic}
+}
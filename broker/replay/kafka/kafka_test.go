@@ -0,0 +1,35 @@
+package kafka
+
+import (
+	"testing"
+)
+
+func TestOffset(t *testing.T) {
+	testData := []struct {
+		partition int32
+		value     string
+		offset    int64
+		ok        bool
+		err       bool
+	}{
+		// plain offsets apply to every partition
+		{0, "10", 10, true, false},
+		{1, "10", 10, true, false},
+		// offsets of replayed messages only apply to their partition
+		{1, "1/42", 42, true, false},
+		{0, "1/42", 0, false, false},
+		{0, "foo", 0, false, true},
+		{0, "x/42", 0, false, true},
+		{1, "1/x", 0, false, true},
+	}
+
+	for _, d := range testData {
+		o, ok, err := offset(d.partition, d.value)
+		if (err != nil) != d.err {
+			t.Fatalf("expected error %v for %s got %v", d.err, d.value, err)
+		}
+		if o != d.offset || ok != d.ok {
+			t.Fatalf("expected offset %d %v for %s on partition %d got %d %v", d.offset, d.ok, d.value, d.partition, o, ok)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/broker"
+)
+
+// Range bounds the messages read from a source, zero values are
+// unbounded. Offsets are source specific, After is exclusive
+// and To inclusive, as offsets are read after like event stores.
+type Range struct {
+	After string
+	To    string
+	Since time.Time
+	Until time.Time
+}
+
+type Options struct {
+	// Broker messages are republished with
+	Broker broker.Broker
+	// Topic republished to, defaults to the message's topic
+	Topic string
+	// Range of messages replayed
+	Range Range
+	// Rate of messages republished per second, unlimited if zero
+	Rate float64
+	// Header values set on each message
+	Header map[string]string
+	// Drop header keys removed from each message
+	Drop []string
+	// Rewrite is called before each message is republished,
+	// returning false skips the message
+	Rewrite func(m *Message) bool
+}
+
+type Option func(o *Options)
+
+// Broker sets the broker messages are republished with,
+// defaults to the broker.DefaultBroker
+func Broker(b broker.Broker) Option {
+	return func(o *Options) {
+		o.Broker = b
+	}
+}
+
+// Topic sets the topic messages are republished to
+func Topic(t string) Option {
+	return func(o *Options) {
+		o.Topic = t
+	}
+}
+
+// After replays messages after the offset
+func After(offset string) Option {
+	return func(o *Options) {
+		o.Range.After = offset
+	}
+}
+
+// To replays messages up to and including the offset
+func To(offset string) Option {
+	return func(o *Options) {
+		o.Range.To = offset
+	}
+}
+
+// Since replays messages published at or after the time
+func Since(t time.Time) Option {
+	return func(o *Options) {
+		o.Range.Since = t
+	}
+}
+
+// Until replays messages published at or before the time
+func Until(t time.Time) Option {
+	return func(o *Options) {
+		o.Range.Until = t
+	}
+}
+
+// Rate limits the messages republished per second
+func Rate(r float64) Option {
+	return func(o *Options) {
+		o.Rate = r
+	}
+}
+
+// SetHeader sets the header on each republished message
+func SetHeader(k, v string) Option {
+	return func(o *Options) {
+		o.Header[k] = v
+	}
+}
+
+// DropHeader removes the headers from each republished message
+func DropHeader(keys ...string) Option {
+	return func(o *Options) {
+		o.Drop = append(o.Drop, keys...)
+	}
+}
+
+// Rewrite sets a func called on each message before it's
+// republished, returning false skips the message
+func Rewrite(fn func(m *Message) bool) Option {
+	return func(o *Options) {
+		o.Rewrite = fn
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Broker: broker.DefaultBroker,
+		Header: make(map[string]string),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
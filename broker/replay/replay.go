@@ -0,0 +1,89 @@
+// Package replay republishes messages read from a durable source, such as
+// a kafka topic, jetstream stream or quarantine store, between two offsets
+// or times to a topic via any broker, for recovering from incidents.
+package replay
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juju/ratelimit"
+	"github.com/micro/go-micro/broker"
+)
+
+// ReplayHeader is set on republished messages to the
+// source and offset the message was replayed from
+const ReplayHeader = "Micro-Replay"
+
+// Message is a message read from a source
+type Message struct {
+	Topic     string
+	Offset    string
+	Timestamp time.Time
+	Header    map[string]string
+	Body      []byte
+}
+
+// Source is a durable source messages are replayed from
+type Source interface {
+	// Read calls fn for the messages in the range in order,
+	// stopping at the end of the range or when fn errors
+	Read(r Range, fn func(*Message) error) error
+	String() string
+}
+
+// Replay republishes the messages of the source returning the
+// number republished. It stops at the first publish error.
+func Replay(src Source, opts ...Option) (int, error) {
+	options := newOptions(opts...)
+
+	if options.Broker == nil {
+		return 0, errors.New("replay: no broker to publish to")
+	}
+
+	var bucket *ratelimit.Bucket
+	if options.Rate > 0 {
+		bucket = ratelimit.NewBucketWithRate(options.Rate, 1)
+	}
+
+	var n int
+
+	err := src.Read(options.Range, func(m *Message) error {
+		header := make(map[string]string, len(m.Header)+len(options.Header)+1)
+		for k, v := range m.Header {
+			header[k] = v
+		}
+		for _, k := range options.Drop {
+			delete(header, k)
+		}
+		for k, v := range options.Header {
+			header[k] = v
+		}
+		header[ReplayHeader] = src.String() + ":" + m.Offset
+		m.Header = header
+
+		if len(options.Topic) > 0 {
+			m.Topic = options.Topic
+		}
+
+		if options.Rewrite != nil && !options.Rewrite(m) {
+			return nil
+		}
+
+		if bucket != nil {
+			bucket.Wait(1)
+		}
+
+		if err := options.Broker.Publish(m.Topic, &broker.Message{
+			Header: m.Header,
+			Body:   m.Body,
+		}); err != nil {
+			return err
+		}
+
+		n++
+		return nil
+	})
+
+	return n, err
+}
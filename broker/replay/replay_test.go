@@ -0,0 +1,126 @@
+package replay
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-plugins/broker/quarantine"
+	"github.com/micro/go-plugins/events"
+)
+
+type published struct {
+	topic string
+	msg   *broker.Message
+}
+
+type testBroker struct {
+	broker.Broker
+	published []published
+}
+
+func (t *testBroker) Publish(topic string, m *broker.Message, opts ...broker.PublishOption) error {
+	t.published = append(t.published, published{topic, m})
+	return nil
+}
+
+func TestReplayLog(t *testing.T) {
+	l := events.NewMemoryLog()
+
+	var offsets []string
+	for i := 0; i < 10; i++ {
+		e := &events.Event{
+			Header: map[string]string{"id": fmt.Sprint(i), "secret": "x"},
+			Body:   []byte(fmt.Sprint(i)),
+		}
+		if err := l.Append("orders", e); err != nil {
+			t.Fatal(err)
+		}
+		offsets = append(offsets, e.Offset)
+	}
+
+	b := new(testBroker)
+
+	n, err := Replay(LogSource(l, "orders"),
+		Broker(b),
+		Topic("orders.replay"),
+		After(offsets[1]),
+		To(offsets[5]),
+		SetHeader("replayed", "true"),
+		DropHeader("secret"),
+		Rewrite(func(m *Message) bool {
+			// skip the third message
+			return m.Header["id"] != "3"
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2, 4 and 5
+	if n != 3 || len(b.published) != 3 {
+		t.Fatalf("expected 3 messages replayed, got %d", n)
+	}
+
+	for _, p := range b.published {
+		if p.topic != "orders.replay" {
+			t.Fatalf("expected topic orders.replay, got %s", p.topic)
+		}
+		if p.msg.Header["replayed"] != "true" || len(p.msg.Header["secret"]) > 0 {
+			t.Fatalf("unexpected headers %v", p.msg.Header)
+		}
+		if len(p.msg.Header[ReplayHeader]) == 0 {
+			t.Fatal("expected replay header")
+		}
+	}
+
+	if id := b.published[0].msg.Header["id"]; id != "2" {
+		t.Fatalf("expected replay to start after the offset, got %s", id)
+	}
+	if id := b.published[2].msg.Header["id"]; id != "5" {
+		t.Fatalf("expected replay to end at the offset, got %s", id)
+	}
+}
+
+func TestReplayQuarantine(t *testing.T) {
+	s := quarantine.NewMemoryStore()
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		s.Put(&quarantine.Message{
+			Id:    fmt.Sprint(i),
+			Topic: "payments",
+			Body:  []byte(fmt.Sprint(i)),
+			Time:  now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	b := new(testBroker)
+
+	n, err := Replay(QuarantineSource(s, "payments"), Broker(b), Since(now.Add(time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 messages replayed, got %d", n)
+	}
+	if b.published[0].topic != "payments" || string(b.published[0].msg.Body) != "1" {
+		t.Fatalf("unexpected message %+v", b.published[0])
+	}
+}
+
+func TestRate(t *testing.T) {
+	l := events.NewMemoryLog()
+	for i := 0; i < 5; i++ {
+		l.Append("orders", &events.Event{Body: []byte(fmt.Sprint(i))})
+	}
+
+	start := time.Now()
+	if _, err := Replay(LogSource(l, "orders"), Broker(new(testBroker)), Rate(100)); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Fatalf("expected rate limiting to slow the replay, took %v", d)
+	}
+}
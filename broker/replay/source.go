@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"sort"
+
+	"github.com/micro/go-plugins/broker/quarantine"
+	"github.com/micro/go-plugins/events"
+)
+
+var (
+	// BatchSize is the number of events read from a log at a time
+	BatchSize = 100
+)
+
+type logSource struct {
+	l      events.Log
+	stream string
+}
+
+type quarantineSource struct {
+	s     quarantine.Store
+	topic string
+}
+
+func (l *logSource) Read(r Range, fn func(*Message) error) error {
+	offset := r.After
+	if len(offset) == 0 && !r.Since.IsZero() {
+		o, err := l.l.Seek(l.stream, r.Since)
+		if err != nil {
+			return err
+		}
+		offset = o
+	}
+
+	for {
+		list, err := l.l.Read(l.stream, offset, BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range list {
+			if !r.Until.IsZero() && e.Timestamp.After(r.Until) {
+				return nil
+			}
+
+			if err := fn(&Message{
+				Topic:     l.stream,
+				Offset:    e.Offset,
+				Timestamp: e.Timestamp,
+				Header:    e.Header,
+				Body:      e.Body,
+			}); err != nil {
+				return err
+			}
+
+			if e.Offset == r.To {
+				return nil
+			}
+			offset = e.Offset
+		}
+
+		// reached the end of the stream
+		if len(list) < BatchSize {
+			return nil
+		}
+	}
+}
+
+func (l *logSource) String() string {
+	return l.l.String() + "/" + l.stream
+}
+
+// Read replays the quarantined messages in the order they were
+// quarantined, the offsets of the range are message ids
+func (q *quarantineSource) Read(r Range, fn func(*Message) error) error {
+	list, err := q.s.List(q.topic)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Time.Before(list[j].Time)
+	})
+
+	skip := len(r.After) > 0
+
+	for _, m := range list {
+		if skip {
+			skip = m.Id != r.After
+			continue
+		}
+		if !r.Since.IsZero() && m.Time.Before(r.Since) {
+			continue
+		}
+		if !r.Until.IsZero() && m.Time.After(r.Until) {
+			return nil
+		}
+
+		if err := fn(&Message{
+			Topic:     m.Topic,
+			Offset:    m.Id,
+			Timestamp: m.Time,
+			Header:    m.Header,
+			Body:      m.Body,
+		}); err != nil {
+			return err
+		}
+
+		if m.Id == r.To {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (q *quarantineSource) String() string {
+	return "quarantine"
+}
+
+// LogSource reads the stream of an event store log, such as the
+// kafka, jetstream or redis streams logs of the events package
+func LogSource(l events.Log, stream string) Source {
+	return &logSource{l: l, stream: stream}
+}
+
+// QuarantineSource reads the messages quarantined for the
+// topic, or all topics when empty, from the store
+func QuarantineSource(s quarantine.Store, topic string) Source {
+	return &quarantineSource{s: s, topic: topic}
+}
@@ -0,0 +1,156 @@
+// Package security provides TLS and SASL options shared by the brokers
+package security
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/micro/go-micro/broker"
+)
+
+// Mechanism is a SASL mechanism
+type Mechanism string
+
+const (
+	Plain       Mechanism = "PLAIN"
+	ScramSHA256 Mechanism = "SCRAM-SHA-256"
+	ScramSHA512 Mechanism = "SCRAM-SHA-512"
+	OAuthBearer Mechanism = "OAUTHBEARER"
+)
+
+// TokenFunc returns an OAuth bearer token
+type TokenFunc func() (string, error)
+
+// SASL is the authentication used by the broker
+type SASL struct {
+	Mechanism Mechanism
+	Username  string
+	Password  string
+	// Token is used by the OAUTHBEARER mechanism
+	Token TokenFunc
+}
+
+// TLSOptions are the PEM files used to build a tls config
+type TLSOptions struct {
+	// CAFile verifies the server certificate, the system pool is used if empty
+	CAFile string
+	// CertFile and KeyFile are the client certificate for mutual TLS
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify skips verification of the server certificate
+	InsecureSkipVerify bool
+}
+
+type saslKey struct{}
+type errKey struct{}
+
+// ErrUnsupported is returned by brokers which don't support the mechanism
+func ErrUnsupported(broker string, m Mechanism) error {
+	return fmt.Errorf("%s broker does not support SASL mechanism %s", broker, m)
+}
+
+// NewTLSConfig returns a tls config from the PEM files
+func NewTLSConfig(o TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if len(o.CAFile) > 0 {
+		b, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if len(o.CertFile) > 0 || len(o.KeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func setErr(o *broker.Options, err error) {
+	if o.Context == nil {
+		o.Context = context.Background()
+	}
+	o.Context = context.WithValue(o.Context, errKey{}, err)
+}
+
+// TLS enables TLS with the config built from the PEM files. Errors loading
+// the files are returned by Err and when the broker connects.
+func TLS(opts TLSOptions) broker.Option {
+	return func(o *broker.Options) {
+		config, err := NewTLSConfig(opts)
+		if err != nil {
+			setErr(o, err)
+			return
+		}
+		o.Secure = true
+		o.TLSConfig = config
+	}
+}
+
+func withSASL(s *SASL) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, saslKey{}, s)
+	}
+}
+
+// PlainAuth authenticates with the PLAIN mechanism
+func PlainAuth(username, password string) broker.Option {
+	return withSASL(&SASL{Mechanism: Plain, Username: username, Password: password})
+}
+
+// ScramAuth authenticates with SCRAM-SHA-256 or SCRAM-SHA-512
+func ScramAuth(m Mechanism, username, password string) broker.Option {
+	if m != ScramSHA256 && m != ScramSHA512 {
+		return func(o *broker.Options) {
+			setErr(o, fmt.Errorf("invalid SCRAM mechanism %s", m))
+		}
+	}
+	return withSASL(&SASL{Mechanism: m, Username: username, Password: password})
+}
+
+// OAuthBearerAuth authenticates with OAUTHBEARER using the token func
+func OAuthBearerAuth(fn TokenFunc) broker.Option {
+	if fn == nil {
+		return func(o *broker.Options) {
+			setErr(o, errors.New("OAUTHBEARER requires a token func"))
+		}
+	}
+	return withSASL(&SASL{Mechanism: OAuthBearer, Token: fn})
+}
+
+// GetSASL returns the SASL config set on the options
+func GetSASL(o broker.Options) (*SASL, bool) {
+	if o.Context == nil {
+		return nil, false
+	}
+	s, ok := o.Context.Value(saslKey{}).(*SASL)
+	return s, ok
+}
+
+// Err returns an error from configuring the security options
+func Err(o broker.Options) error {
+	if o.Context == nil {
+		return nil
+	}
+	err, _ := o.Context.Value(errKey{}).(error)
+	return err
+}
@@ -0,0 +1,46 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/broker"
+)
+
+func TestSASL(t *testing.T) {
+	var opts broker.Options
+
+	if _, ok := GetSASL(opts); ok {
+		t.Fatal("expected no SASL config")
+	}
+
+	PlainAuth("user", "pass")(&opts)
+
+	s, ok := GetSASL(opts)
+	if !ok || s.Mechanism != Plain || s.Username != "user" || s.Password != "pass" {
+		t.Fatalf("unexpected SASL config %+v", s)
+	}
+
+	if err := Err(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	ScramAuth(Plain, "user", "pass")(&opts)
+	if err := Err(opts); err == nil {
+		t.Fatal("expected invalid SCRAM mechanism error")
+	}
+}
+
+func TestTLS(t *testing.T) {
+	var opts broker.Options
+
+	TLS(TLSOptions{InsecureSkipVerify: true})(&opts)
+	if !opts.Secure || opts.TLSConfig == nil || !opts.TLSConfig.InsecureSkipVerify {
+		t.Fatalf("expected insecure tls config got %+v", opts.TLSConfig)
+	}
+
+	opts = broker.Options{}
+	TLS(TLSOptions{CAFile: "does-not-exist.pem"})(&opts)
+	if opts.Secure || Err(opts) == nil {
+		t.Fatal("expected error loading ca file")
+	}
+}
@@ -0,0 +1,331 @@
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/pborman/uuid"
+)
+
+var (
+	// topic and queue names may only contain alphanumerics, hyphens and underscores
+	invalidName = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+)
+
+// topicSubscriber removes the sns subscription and ephemeral queue on unsubscribe
+type topicSubscriber struct {
+	*subscriber
+	topic           string
+	sns             *sns.SNS
+	subscriptionArn string
+	ephemeral       bool
+}
+
+func (s *topicSubscriber) Topic() string {
+	return s.topic
+}
+
+func (s *topicSubscriber) Unsubscribe() error {
+	if err := s.subscriber.Unsubscribe(); err != nil {
+		return err
+	}
+
+	if !s.ephemeral {
+		return nil
+	}
+
+	if _, err := s.sns.Unsubscribe(&sns.UnsubscribeInput{
+		SubscriptionArn: aws.String(s.subscriptionArn),
+	}); err != nil {
+		return err
+	}
+
+	_, err := s.svc.DeleteQueue(&sqs.DeleteQueueInput{
+		QueueUrl: aws.String(s.URL),
+	})
+	return err
+}
+
+func (b *sqsBroker) fanOut() bool {
+	v, _ := b.options.Context.Value(fanOutKey{}).(bool)
+	return v
+}
+
+func (b *sqsBroker) getSNSClient() *sns.SNS {
+	if s, ok := b.options.Context.Value(snsClientKey{}).(*sns.SNS); ok {
+		return s
+	}
+	return nil
+}
+
+// topicArn creates the sns topic, which is idempotent, returning its arn
+func (b *sqsBroker) topicArn(topic string) (string, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if arn, ok := b.topics[topic]; ok {
+		return arn, nil
+	}
+
+	input := &sns.CreateTopicInput{
+		Name: aws.String(invalidName.ReplaceAllString(topic, "-")),
+	}
+
+	// fifo topics need the suffix and attribute set
+	if isFifo(topic) {
+		input.Name = aws.String(invalidName.ReplaceAllString(strings.TrimSuffix(topic, ".fifo"), "-") + ".fifo")
+		input.Attributes = map[string]*string{"FifoTopic": aws.String("true")}
+	}
+
+	rsp, err := b.sns.CreateTopic(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to create topic %s: %v", topic, err)
+	}
+
+	arn := aws.StringValue(rsp.TopicArn)
+	b.topics[topic] = arn
+	return arn, nil
+}
+
+// publishTopic publishes the message to the sns topic
+func (b *sqsBroker) publishTopic(topic string, msg *broker.Message) error {
+	arn, err := b.topicArn(topic)
+	if err != nil {
+		return err
+	}
+
+	attribs := make(map[string]*sns.MessageAttributeValue)
+	for k, v := range msg.Header {
+		attribs[k] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+
+	input := &sns.PublishInput{
+		TopicArn:               aws.String(arn),
+		Message:                aws.String(string(msg.Body)),
+		MessageAttributes:      attribs,
+		MessageDeduplicationId: b.generateDedupID(msg),
+		MessageGroupId:         b.generateGroupID(msg),
+	}
+
+	log.Log(fmt.Sprintf("Publishing SNS message, %d bytes", len(msg.Body)))
+	_, err = b.sns.Publish(input)
+	return err
+}
+
+func isFifo(name string) bool {
+	return strings.HasSuffix(name, ".fifo")
+}
+
+// queueName returns the queue subscribing to the topic. Without a queue
+// an ephemeral queue unique to the subscriber is named after the topic.
+// Queues subscribed to fifo topics have the .fifo suffix.
+func queueName(topic, queue string) (string, bool) {
+	var suffix string
+	if isFifo(topic) {
+		suffix = ".fifo"
+		topic = strings.TrimSuffix(topic, suffix)
+		queue = strings.TrimSuffix(queue, suffix)
+	}
+
+	if len(queue) > 0 {
+		return invalidName.ReplaceAllString(queue, "-") + suffix, false
+	}
+
+	// keep the unique suffix within the queue name limit
+	id := strings.Replace(uuid.NewUUID().String(), "-", "", -1)
+	name := invalidName.ReplaceAllString(topic, "-")
+	if max := 80 - len(id) - len(suffix) - 1; len(name) > max {
+		name = name[:max]
+	}
+	return name + "-" + id + suffix, true
+}
+
+// policy is a queue access policy
+type policy struct {
+	Version   string                   `json:"Version"`
+	Id        string                   `json:"Id,omitempty"`
+	Statement []map[string]interface{} `json:"Statement"`
+}
+
+// addStatement adds a statement allowing the topic to send to the queue
+// to the existing policy, returning false if it's already allowed
+func addStatement(doc, queueArn, topicArn string) (string, bool, error) {
+	p := policy{Version: "2012-10-17"}
+	if len(doc) > 0 {
+		var raw struct {
+			Version   string          `json:"Version"`
+			Id        string          `json:"Id"`
+			Statement json.RawMessage `json:"Statement"`
+		}
+		if err := json.Unmarshal([]byte(doc), &raw); err != nil {
+			return "", false, err
+		}
+		p.Version = raw.Version
+		p.Id = raw.Id
+
+		// a single statement may be an object
+		st := bytes.TrimSpace(raw.Statement)
+		if len(st) > 0 && st[0] == '{' {
+			st = append(append([]byte{'['}, st...), ']')
+		}
+		if len(st) > 0 {
+			if err := json.Unmarshal(st, &p.Statement); err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	sid := "micro-" + invalidName.ReplaceAllString(topicArn, "-")
+	for _, st := range p.Statement {
+		if st["Sid"] == sid {
+			return doc, false, nil
+		}
+	}
+
+	p.Statement = append(p.Statement, map[string]interface{}{
+		"Sid":       sid,
+		"Effect":    "Allow",
+		"Principal": map[string]string{"Service": "sns.amazonaws.com"},
+		"Action":    "sqs:SendMessage",
+		"Resource":  queueArn,
+		"Condition": map[string]interface{}{
+			"ArnEquals": map[string]string{"aws:SourceArn": topicArn},
+		},
+	})
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// allowTopic merges a statement allowing the topic to send to the queue
+// into its policy, so a queue shared by topics keeps every topic's access.
+// It returns the queue arn.
+func (b *sqsBroker) allowTopic(url, topicArn string) (string, error) {
+	b.policyMtx.Lock()
+	defer b.policyMtx.Unlock()
+
+	attrs, err := b.svc.GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl: aws.String(url),
+		AttributeNames: aws.StringSlice([]string{
+			sqs.QueueAttributeNameQueueArn,
+			sqs.QueueAttributeNamePolicy,
+		}),
+	})
+	if err != nil {
+		return "", err
+	}
+	queueArn := aws.StringValue(attrs.Attributes[sqs.QueueAttributeNameQueueArn])
+
+	doc, changed, err := addStatement(aws.StringValue(attrs.Attributes[sqs.QueueAttributeNamePolicy]), queueArn, topicArn)
+	if err != nil || !changed {
+		return queueArn, err
+	}
+
+	_, err = b.svc.SetQueueAttributes(&sqs.SetQueueAttributesInput{
+		QueueUrl: aws.String(url),
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNamePolicy: aws.String(doc),
+		},
+	})
+	return queueArn, err
+}
+
+// subscribeTopic subscribes a queue to the sns topic with raw message
+// delivery. Without a queue option an ephemeral queue is created for the
+// subscriber so every subscriber receives every message, otherwise the
+// named queue is created or attached to and shared by its subscribers.
+func (b *sqsBroker) subscribeTopic(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.SubscribeOptions{
+		AutoAck: true,
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	topicArn, err := b.topicArn(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	name, ephemeral := queueName(topic, options.Queue)
+
+	input := &sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+	}
+	// fifo topics can only deliver to fifo queues
+	if isFifo(topic) {
+		input.Attributes = map[string]*string{
+			sqs.QueueAttributeNameFifoQueue: aws.String("true"),
+		}
+	}
+
+	q, err := b.svc.CreateQueue(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue %s: %v", name, err)
+	}
+
+	// remove the ephemeral queue if the subscription fails, a named
+	// queue may be shared by other subscribers so it's kept
+	cleanup := func() {
+		if !ephemeral {
+			return
+		}
+		if _, err := b.svc.DeleteQueue(&sqs.DeleteQueueInput{QueueUrl: q.QueueUrl}); err != nil {
+			log.Logf("[sqs] failed to delete queue %s: %v", name, err)
+		}
+	}
+
+	queueArn, err := b.allowTopic(aws.StringValue(q.QueueUrl), topicArn)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to set queue policy %s: %v", name, err)
+	}
+
+	sub, err := b.sns.Subscribe(&sns.SubscribeInput{
+		TopicArn: aws.String(topicArn),
+		Protocol: aws.String("sqs"),
+		Endpoint: aws.String(queueArn),
+		Attributes: map[string]*string{
+			"RawMessageDelivery": aws.String("true"),
+		},
+		ReturnSubscriptionArn: aws.Bool(true),
+	})
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to subscribe queue %s to topic %s: %v", name, topic, err)
+	}
+
+	s := &topicSubscriber{
+		subscriber: &subscriber{
+			options:   options,
+			URL:       aws.StringValue(q.QueueUrl),
+			queueName: name,
+			svc:       b.svc,
+			exit:      make(chan bool),
+		},
+		topic:           topic,
+		sns:             b.sns,
+		subscriptionArn: aws.StringValue(sub.SubscriptionArn),
+		ephemeral:       ephemeral,
+	}
+	go s.run(h)
+
+	return s, nil
+}
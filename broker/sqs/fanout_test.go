@@ -0,0 +1,69 @@
+package sqs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestQueueName(t *testing.T) {
+	if name, ephemeral := queueName("orders", "billing.queue"); name != "billing-queue" || ephemeral {
+		t.Fatalf("unexpected queue %s", name)
+	}
+
+	if name, ephemeral := queueName("orders.fifo", "billing"); name != "billing.fifo" || ephemeral {
+		t.Fatalf("expected fifo queue for fifo topic got %s", name)
+	}
+
+	name, ephemeral := queueName(strings.Repeat("a", 100)+".fifo", "")
+	if !ephemeral || len(name) > 80 || !strings.HasSuffix(name, ".fifo") {
+		t.Fatalf("unexpected ephemeral queue %s", name)
+	}
+
+	if other, _ := queueName("orders", ""); !strings.HasPrefix(other, "orders-") || other == name {
+		t.Fatalf("unexpected ephemeral queue %s", other)
+	}
+}
+
+func TestAddStatement(t *testing.T) {
+	queue := "arn:aws:sqs:eu-west-1:123:billing"
+	orders := "arn:aws:sns:eu-west-1:123:orders"
+	refunds := "arn:aws:sns:eu-west-1:123:refunds"
+
+	doc, changed, err := addStatement("", queue, orders)
+	if err != nil || !changed {
+		t.Fatalf("expected statement to be added %v", err)
+	}
+
+	// subscribing again leaves the policy as is
+	if _, changed, err := addStatement(doc, queue, orders); err != nil || changed {
+		t.Fatalf("expected policy to be unchanged %v", err)
+	}
+
+	// a second topic keeps the first topic's access
+	doc, changed, err = addStatement(doc, queue, refunds)
+	if err != nil || !changed {
+		t.Fatalf("expected statement to be added %v", err)
+	}
+
+	var p policy
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Statement) != 2 {
+		t.Fatalf("expected 2 statements got %d", len(p.Statement))
+	}
+
+	// existing policies with a single statement object are merged
+	single := `{"Version":"2012-10-17","Statement":{"Sid":"admin","Effect":"Allow","Action":"sqs:*"}}`
+	doc, _, err = addStatement(single, queue, orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Statement) != 2 || p.Statement[0]["Sid"] != "admin" {
+		t.Fatalf("expected existing statement kept got %v", p.Statement)
+	}
+}
@@ -3,6 +3,7 @@ package sqs
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/micro/go-micro/broker"
 )
@@ -13,6 +14,8 @@ type groupIdFunctionKey struct{}
 type maxMessagesKey struct{}
 type visiblityTimeoutKey struct{}
 type waitTimeSecondsKey struct{}
+type fanOutKey struct{}
+type snsClientKey struct{}
 
 type StringFromMessageFunc func(m *broker.Message) string
 
@@ -80,3 +83,27 @@ func Client(c *sqs.SQS) broker.Option {
 		o.Context = context.WithValue(o.Context, sqsClientKey{}, c)
 	}
 }
+
+// FanOut publishes to an SNS topic of the same name and subscribes by
+// provisioning an SQS queue subscribed to the topic with raw message
+// delivery, so every subscriber queue receives every message. Subscribers
+// with the same broker.Queue share a queue, otherwise each gets its own
+// queue which is removed when it unsubscribes.
+func FanOut() broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, fanOutKey{}, true)
+	}
+}
+
+// SNSClient receives an instantiated instance of an SNS client used for FanOut
+func SNSClient(c *sns.SNS) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, snsClientKey{}, c)
+	}
+}
@@ -4,27 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/micro/go-log"
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-plugins/broker/delivery"
 )
 
 const (
 	defaultMaxMessages       = 1
 	defaultVisibilityTimeout = 3
 	defaultWaitSeconds       = 10
+	maxDelaySeconds          = 900
+	maxVisibilitySeconds     = 43200
 )
 
 // Amazon SQS Broker
 type sqsBroker struct {
 	svc     *sqs.SQS
+	sns     *sns.SNS
 	options broker.Options
+
+	sync.Mutex
+	// sns topic arns by topic
+	topics map[string]string
+
+	// serialises queue policy updates
+	policyMtx sync.Mutex
 }
 
 // A subscriber (poller) to an SQS queue
@@ -128,6 +142,14 @@ func (s *subscriber) handleMessage(msg *sqs.Message, hdlr broker.Handler) {
 		svc:       s.svc,
 	}
 
+	// messages delivered early are hidden until their delivery time
+	if d := delivery.Wait(m); d > 0 {
+		if err := p.postpone(d); err != nil {
+			log.Log(fmt.Sprintf("Failed to defer SQS message: %s", err.Error()))
+		}
+		return
+	}
+
 	if err := hdlr(p); err != nil {
 		fmt.Println(err)
 	}
@@ -165,6 +187,21 @@ func (p *publication) Ack() error {
 	return err
 }
 
+// postpone hides the message for the duration, capped at the SQS
+// maximum of 12 hours after which it's received and deferred again
+func (p *publication) postpone(d time.Duration) error {
+	s := int64((d + time.Second - 1) / time.Second)
+	if s > maxVisibilitySeconds {
+		s = maxVisibilitySeconds
+	}
+	_, err := p.svc.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          &p.URL,
+		ReceiptHandle:     p.sMessage.ReceiptHandle,
+		VisibilityTimeout: aws.Int64(s),
+	})
+	return err
+}
+
 func (p *publication) Topic() string {
 	return p.queueName
 }
@@ -182,8 +219,10 @@ func (b *sqsBroker) Address() string {
 }
 
 func (b *sqsBroker) Connect() error {
-	if svc := b.getSQSClient(); svc != nil {
-		b.svc = svc
+	b.svc = b.getSQSClient()
+	b.sns = b.getSNSClient()
+
+	if b.svc != nil && (b.sns != nil || !b.fanOut()) {
 		return nil
 	}
 
@@ -191,8 +230,12 @@ func (b *sqsBroker) Connect() error {
 		SharedConfigState: session.SharedConfigEnable,
 	}))
 
-	svc := sqs.New(sess)
-	b.svc = svc
+	if b.svc == nil {
+		b.svc = sqs.New(sess)
+	}
+	if b.sns == nil {
+		b.sns = sns.New(sess)
+	}
 
 	return nil
 }
@@ -213,6 +256,19 @@ func (b *sqsBroker) Init(opts ...broker.Option) error {
 
 // Publish publishes a message via SQS
 func (b *sqsBroker) Publish(queueName string, msg *broker.Message, opts ...broker.PublishOption) error {
+	var options broker.PublishOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// the delivery time header is set so subscribers defer messages
+	// received early, SNS doesn't support delays and SQS caps them
+	msg = delivery.Headers(msg, options)
+
+	if b.fanOut() {
+		return b.publishTopic(queueName, msg)
+	}
+
 	queueURL, err := b.urlFromQueueName(queueName)
 	if err != nil {
 		return err
@@ -226,6 +282,11 @@ func (b *sqsBroker) Publish(queueName string, msg *broker.Message, opts ...broke
 	input.MessageDeduplicationId = b.generateDedupID(msg)
 	input.MessageGroupId = b.generateGroupID(msg)
 
+	// fifo queues only support a delay set on the queue
+	if delay, ok := delivery.GetDelay(options); ok && !strings.HasSuffix(queueName, ".fifo") {
+		input.DelaySeconds = aws.Int64(delaySeconds(delay))
+	}
+
 	log.Log(fmt.Sprintf("Publishing SQS message, %d bytes", len(msg.Body)))
 	_, err = b.svc.SendMessage(input)
 
@@ -237,8 +298,22 @@ func (b *sqsBroker) Publish(queueName string, msg *broker.Message, opts ...broke
 	return nil
 }
 
+// delaySeconds rounds the delay up to whole seconds capped at the SQS
+// maximum of 15 minutes, longer delays are deferred by the subscriber
+func delaySeconds(d time.Duration) int64 {
+	s := int64((d + time.Second - 1) / time.Second)
+	if s > maxDelaySeconds {
+		return maxDelaySeconds
+	}
+	return s
+}
+
 // Subscribe subscribes to an SQS queue, starting a goroutine to poll for messages
 func (b *sqsBroker) Subscribe(queueName string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	if b.fanOut() {
+		return b.subscribeTopic(queueName, h, opts...)
+	}
+
 	queueURL, err := b.urlFromQueueName(queueName)
 	if err != nil {
 		return nil, err
@@ -343,5 +418,6 @@ func NewBroker(opts ...broker.Option) broker.Broker {
 
 	return &sqsBroker{
 		options: options,
+		topics:  make(map[string]string),
 	}
 }
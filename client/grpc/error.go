@@ -1,10 +1,100 @@
 package grpc
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/micro/go-micro/errors"
 	"github.com/micro/grpc-go/status"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
 )
 
+// detail is the detail of errors mapped from a status with details. The
+// encoded status is carried in the detail so the errors remain plain
+// *errors.Error values and survive being copied or passed on.
+type detail struct {
+	Detail string `json:"detail"`
+	Status []byte `json:"status"`
+}
+
+func decode(err error) (*detail, bool) {
+	e, ok := err.(*errors.Error)
+	if !ok || len(e.Detail) == 0 || e.Detail[0] != '{' {
+		return nil, false
+	}
+	var d *detail
+	if jerr := json.Unmarshal([]byte(e.Detail), &d); jerr != nil || d == nil || len(d.Status) == 0 {
+		return nil, false
+	}
+	return d, true
+}
+
+// encode carries the status in the detail of the error
+func encode(err *errors.Error, s *status.Status) {
+	b, perr := proto.Marshal(s.Proto())
+	if perr != nil {
+		return
+	}
+	if d, jerr := json.Marshal(&detail{Detail: err.Detail, Status: b}); jerr == nil {
+		err.Detail = string(d)
+	}
+}
+
+// Detail returns the detail of an error returned by the client
+// without the encoded status
+func Detail(err error) string {
+	if err == nil {
+		return ""
+	}
+	if d, ok := decode(err); ok {
+		return d.Detail
+	}
+	if e, ok := err.(*errors.Error); ok {
+		return e.Detail
+	}
+	return err.Error()
+}
+
+// Status returns the gRPC status, with its details, an error returned
+// by the client was mapped from. It's nil if the status had no details.
+func Status(err error) *status.Status {
+	d, ok := decode(err)
+	if !ok {
+		return nil
+	}
+	var s spb.Status
+	if perr := proto.Unmarshal(d.Status, &s); perr != nil {
+		return nil
+	}
+	return status.FromProto(&s)
+}
+
+// Details returns the status details of the error such as
+// *errdetails.RetryInfo, *errdetails.QuotaFailure or *errdetails.BadRequest
+func Details(err error) []interface{} {
+	if s := Status(err); s != nil {
+		return s.Details()
+	}
+	return nil
+}
+
+// RetryAfter returns the delay of the retry info detail so retry
+// wrappers can honour the delay asked for by the server
+func RetryAfter(err error) (time.Duration, bool) {
+	ri := RetryInfo(err)
+	if ri == nil || ri.RetryDelay == nil {
+		return 0, false
+	}
+	d, derr := ptypes.Duration(ri.RetryDelay)
+	if derr != nil {
+		return 0, false
+	}
+	return d, true
+}
+
 func microError(err error) error {
 	// no error
 	switch err {
@@ -19,9 +109,44 @@ func microError(err error) error {
 
 	// grpc error
 	if s, ok := status.FromError(err); ok {
-		return errors.Parse(s.Message())
+		merr := errors.Parse(s.Message())
+		if len(s.Proto().GetDetails()) > 0 {
+			encode(merr, s)
+		}
+		return merr
 	}
 
 	// do nothing
 	return err
 }
+
+// RetryInfo returns the retry info detail of the error or nil
+func RetryInfo(err error) *errdetails.RetryInfo {
+	for _, d := range Details(err) {
+		if v, ok := d.(*errdetails.RetryInfo); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// QuotaFailure returns the quota failure detail of the error or nil
+func QuotaFailure(err error) *errdetails.QuotaFailure {
+	for _, d := range Details(err) {
+		if v, ok := d.(*errdetails.QuotaFailure); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// BadRequest returns the bad request detail, holding any
+// field violations, of the error or nil
+func BadRequest(err error) *errdetails.BadRequest {
+	for _, d := range Details(err) {
+		if v, ok := d.(*errdetails.BadRequest); ok {
+			return v
+		}
+	}
+	return nil
+}
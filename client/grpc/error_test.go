@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/grpc-go/codes"
+	"github.com/micro/grpc-go/status"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func TestMicroErrorDetails(t *testing.T) {
+	merr := errors.New("test", "slow down", 429)
+
+	s, err := status.New(codes.ResourceExhausted, merr.Error()).WithDetails(
+		&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(5e9)},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "required"},
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = microError(s.Err())
+
+	// errors with details remain plain micro errors
+	e, ok := err.(*errors.Error)
+	if !ok || e.Code != 429 || e.Id != "test" {
+		t.Fatalf("expected micro error to be preserved, got %v", err)
+	}
+	if d := Detail(err); d != "slow down" {
+		t.Fatalf("expected detail slow down, got %s", d)
+	}
+
+	// the details survive the error being copied
+	cp := *e
+	if RetryInfo(&cp) == nil {
+		t.Fatal("expected the details to be carried by the error")
+	}
+
+	ri := RetryInfo(err)
+	if ri == nil || ri.RetryDelay.Seconds != 5 {
+		t.Fatalf("expected retry info, got %v", ri)
+	}
+
+	if d, ok := RetryAfter(err); !ok || d != 5e9 {
+		t.Fatalf("expected retry after 5s, got %v", d)
+	}
+
+	br := BadRequest(err)
+	if br == nil || len(br.FieldViolations) != 1 || br.FieldViolations[0].Field != "name" {
+		t.Fatalf("expected field violations, got %v", br)
+	}
+
+	if QuotaFailure(err) != nil {
+		t.Fatal("expected no quota failure")
+	}
+
+	if Status(err) == nil {
+		t.Fatal("expected the status to be kept")
+	}
+
+	// errors without details have no status
+	err = microError(status.New(codes.Internal, merr.Error()).Err())
+	if Status(err) != nil || Details(err) != nil {
+		t.Fatal("expected no details")
+	}
+	if d := Detail(err); d != "slow down" {
+		t.Fatalf("expected detail slow down, got %s", d)
+	}
+}
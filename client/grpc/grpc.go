@@ -19,6 +19,7 @@ import (
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/selector"
 	"github.com/micro/go-micro/transport"
+	"github.com/micro/go-plugins/codec/negotiate"
 
 	"github.com/micro/grpc-go"
 	"github.com/micro/grpc-go/credentials"
@@ -26,9 +27,10 @@ import (
 )
 
 type grpcClient struct {
-	once sync.Once
-	opts client.Options
-	pool *pool
+	once     sync.Once
+	opts     client.Options
+	pool     *pool
+	payloads *payloads
 }
 
 var (
@@ -51,6 +53,14 @@ func (g *grpcClient) secure() grpc.DialOption {
 	return grpc.WithInsecure()
 }
 
+// nodeAddress returns the address to dial for the node
+func nodeAddress(node *registry.Node) string {
+	if node.Port > 0 {
+		return fmt.Sprintf("%s:%d", node.Address, node.Port)
+	}
+	return node.Address
+}
+
 func (g *grpcClient) next(request client.Request, opts client.CallOptions) (selector.Next, error) {
 	// return remote address
 	if len(opts.Address) > 0 {
@@ -61,8 +71,15 @@ func (g *grpcClient) next(request client.Request, opts client.CallOptions) (sele
 		}, nil
 	}
 
+	sopts := opts.SelectOptions
+
+	// skip nodes the pool knows are down
+	if g.pool.breaking() {
+		sopts = append(append([]selector.SelectOption{}, sopts...), selector.WithFilter(g.pool.filter))
+	}
+
 	// get next nodes from the selector
-	next, err := g.opts.Selector.Select(request.Service(), opts.SelectOptions...)
+	next, err := g.opts.Selector.Select(request.Service(), sopts...)
 	if err != nil && err == selector.ErrNotFound {
 		return nil, errors.NotFound("go.micro.client", err.Error())
 	} else if err != nil {
@@ -95,8 +112,22 @@ func (g *grpcClient) call(ctx context.Context, address string, req client.Reques
 
 	var grr error
 
-	cc, err := g.pool.getConn(address, grpc.WithCodec(cf), grpc.WithTimeout(opts.DialTimeout), g.secure())
-	if err != nil {
+	ctx = withCall(ctx, req.Service(), req.Method(), address, false)
+
+	dopts := []grpc.DialOption{
+		grpc.WithCodec(cf),
+		grpc.WithTimeout(opts.DialTimeout),
+		g.secure(),
+		grpc.WithStatsHandler(g.payloads),
+	}
+	if d, ok := proxyDialer(g.opts); ok {
+		dopts = append(dopts, d)
+	}
+
+	cc, err := g.pool.getConn(ctx, address, dopts...)
+	if err == errBreakerOpen || err == errPoolExhausted {
+		return errors.New("go.micro.client", fmt.Sprintf("Error sending request: %v", err), 503)
+	} else if err != nil {
 		return errors.InternalServerError("go.micro.client", fmt.Sprintf("Error sending request: %v", err))
 	}
 	defer func() {
@@ -108,6 +139,7 @@ func (g *grpcClient) call(ctx context.Context, address string, req client.Reques
 
 	go func() {
 		err := grpc.Invoke(ctx, methodToGRPC(req.Method(), req.Request()), req.Request(), rsp, cc.cc)
+		g.pool.mark(address, err)
 		ch <- microError(err)
 	}()
 
@@ -142,6 +174,10 @@ func (g *grpcClient) stream(ctx context.Context, address string, req client.Requ
 		return nil, errors.InternalServerError("go.micro.client", err.Error())
 	}
 
+	if err := g.pool.allow(address); err != nil {
+		return nil, errors.New("go.micro.client", fmt.Sprintf("Error sending request: %v", err), 503)
+	}
+
 	var dialCtx context.Context
 	var cancel context.CancelFunc
 	if opts.DialTimeout >= 0 {
@@ -150,8 +186,18 @@ func (g *grpcClient) stream(ctx context.Context, address string, req client.Requ
 		dialCtx, cancel = context.WithCancel(ctx)
 	}
 	defer cancel()
-	cc, err := grpc.DialContext(dialCtx, address, grpc.WithCodec(cf), g.secure())
+	dopts := []grpc.DialOption{
+		grpc.WithCodec(cf),
+		g.secure(),
+		grpc.WithStatsHandler(g.payloads),
+	}
+	if d, ok := proxyDialer(g.opts); ok {
+		dopts = append(dopts, d)
+	}
+
+	cc, err := grpc.DialContext(dialCtx, address, dopts...)
 	if err != nil {
+		g.pool.mark(address, err)
 		return nil, errors.InternalServerError("go.micro.client", fmt.Sprintf("Error sending request: %v", err))
 	}
 
@@ -161,7 +207,10 @@ func (g *grpcClient) stream(ctx context.Context, address string, req client.Requ
 		ServerStreams: true,
 	}
 
+	ctx = withCall(ctx, req.Service(), req.Method(), address, true)
+
 	st, err := cc.NewStream(ctx, desc, methodToGRPC(req.Method(), req.Request()))
+	g.pool.mark(address, err)
 	if err != nil {
 		return nil, errors.InternalServerError("go.micro.client", fmt.Sprintf("Error creating stream: %v", err))
 	}
@@ -200,6 +249,34 @@ func (g *grpcClient) newCodec(contentType string) (codec.NewCodec, error) {
 	return nil, fmt.Errorf("Unsupported Content-Type: %s", contentType)
 }
 
+// negotiate returns the request to send to the node, switching the
+// content type to one the node supports if negotiation is enabled
+func (g *grpcClient) negotiate(req client.Request, node *registry.Node) client.Request {
+	if g.opts.Context == nil {
+		return req
+	}
+
+	prefs, ok := g.opts.Context.Value(negotiateKey{}).([]string)
+	if !ok {
+		return req
+	}
+
+	ct := negotiate.Select(node, req.ContentType(), prefs, func(ct string) bool {
+		_, err := g.newGRPCCodec(ct)
+		return err == nil
+	})
+	if ct == req.ContentType() {
+		return req
+	}
+
+	var opts []client.RequestOption
+	if req.Stream() {
+		opts = append(opts, client.StreamingRequest())
+	}
+
+	return newGRPCRequest(req.Service(), req.Method(), req.Request(), ct, opts...)
+}
+
 func (g *grpcClient) Init(opts ...client.Option) error {
 	size := g.opts.PoolSize
 	ttl := g.opts.PoolTTL
@@ -216,6 +293,15 @@ func (g *grpcClient) Init(opts ...client.Option) error {
 		g.pool.Unlock()
 	}
 
+	g.pool.Lock()
+	g.pool.threshold, g.pool.cooldown = getBreaker(g.opts)
+	g.pool.max, g.pool.wait = getPoolLimit(g.opts)
+	g.pool.Unlock()
+
+	g.payloads.Lock()
+	g.payloads.slow = getSlowCalls(g.opts)
+	g.payloads.Unlock()
+
 	return nil
 }
 
@@ -292,13 +378,10 @@ func (g *grpcClient) Call(ctx context.Context, req client.Request, rsp interface
 		}
 
 		// set the address
-		addr := node.Address
-		if node.Port > 0 {
-			addr = fmt.Sprintf("%s:%d", addr, node.Port)
-		}
+		addr := nodeAddress(node)
 
 		// make the call
-		err = gcall(ctx, addr, req, rsp, callOpts)
+		err = gcall(ctx, addr, g.negotiate(req, node), rsp, callOpts)
 		g.opts.Selector.Mark(req.Service(), node, err)
 		return err
 	}
@@ -376,12 +459,9 @@ func (g *grpcClient) Stream(ctx context.Context, req client.Request, opts ...cli
 			return nil, errors.InternalServerError("go.micro.client", err.Error())
 		}
 
-		addr := node.Address
-		if node.Port > 0 {
-			addr = fmt.Sprintf("%s:%d", addr, node.Port)
-		}
+		addr := nodeAddress(node)
 
-		stream, err := g.stream(ctx, addr, req, callOpts)
+		stream, err := g.stream(ctx, addr, g.negotiate(req, node), callOpts)
 		g.opts.Selector.Mark(req.Service(), node, err)
 		return stream, err
 	}
@@ -452,6 +532,27 @@ func (g *grpcClient) Publish(ctx context.Context, p client.Message, opts ...clie
 	})
 }
 
+// Stats returns the connection pool stats by address of a client
+// created by NewClient. It returns false for other clients.
+func Stats(c client.Client) (map[string]PoolStats, bool) {
+	g, ok := c.(*grpcClient)
+	if !ok {
+		return nil, false
+	}
+	return g.pool.stats(), true
+}
+
+// Payloads returns the serialized request and response sizes by
+// "service/Endpoint" of a client created by NewClient. It returns
+// false for other clients.
+func Payloads(c client.Client) (map[string]PayloadStats, bool) {
+	g, ok := c.(*grpcClient)
+	if !ok {
+		return nil, false
+	}
+	return g.payloads.get(), true
+}
+
 func (g *grpcClient) String() string {
 	return "grpc"
 }
@@ -493,11 +594,16 @@ func newClient(opts ...client.Option) client.Client {
 	}
 
 	rc := &grpcClient{
-		once: sync.Once{},
-		opts: options,
-		pool: newPool(options.PoolSize, options.PoolTTL),
+		once:     sync.Once{},
+		opts:     options,
+		pool:     newPool(options.PoolSize, options.PoolTTL),
+		payloads: newPayloads(),
 	}
 
+	rc.pool.threshold, rc.pool.cooldown = getBreaker(options)
+	rc.pool.max, rc.pool.wait = getPoolLimit(options)
+	rc.payloads.slow = getSlowCalls(options)
+
 	c := client.Client(rc)
 
 	// wrap in reverse
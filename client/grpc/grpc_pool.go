@@ -1,18 +1,51 @@
 package grpc
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/micro/go-micro/registry"
 	"github.com/micro/grpc-go"
+	"github.com/micro/grpc-go/codes"
+	"github.com/micro/grpc-go/status"
+)
+
+var (
+	errBreakerOpen   = errors.New("circuit breaker is open")
+	errPoolExhausted = errors.New("connection pool exhausted")
 )
 
 type pool struct {
 	size int
 	ttl  int64
 
+	// consecutive failures which open an address breaker
+	// and how long it stays open before a probe is let through
+	threshold int
+	cooldown  time.Duration
+
+	// max connections per address, idle and in use, and
+	// how long to wait for one when none are available
+	max  int
+	wait time.Duration
+
 	sync.Mutex
-	conns map[string][]*poolConn
+	conns    map[string][]*poolConn
+	breakers map[string]*breaker
+	// open connections by address
+	open map[string]int
+	// callers waiting for a connection by address, sent a released
+	// connection or nil when they may dial a new one
+	waiters map[string][]chan *poolConn
+}
+
+// breaker tracks consecutive failures to an address
+type breaker struct {
+	failures int
+	opened   time.Time
+	probing  bool
 }
 
 type poolConn struct {
@@ -22,13 +55,134 @@ type poolConn struct {
 
 func newPool(size int, ttl time.Duration) *pool {
 	return &pool{
-		size:  size,
-		ttl:   int64(ttl.Seconds()),
-		conns: make(map[string][]*poolConn),
+		size:     size,
+		ttl:      int64(ttl.Seconds()),
+		conns:    make(map[string][]*poolConn),
+		breakers: make(map[string]*breaker),
+		open:     make(map[string]int),
+		waiters:  make(map[string][]chan *poolConn),
+	}
+}
+
+// unavailable returns true if the error means the address couldn't be reached
+func unavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code() == codes.Unavailable
+	}
+	return true
+}
+
+// breaking returns true if per address circuit breaking is enabled
+func (p *pool) breaking() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.threshold > 0
+}
+
+// available returns false if the breaker for the address is open
+func (p *pool) available(addr string) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	b, ok := p.breakers[addr]
+	if !ok || p.threshold <= 0 || b.failures < p.threshold {
+		return true
 	}
+	return !b.probing && time.Since(b.opened) >= p.cooldown
 }
 
-func (p *pool) getConn(addr string, opts ...grpc.DialOption) (*poolConn, error) {
+// allow returns errBreakerOpen if the breaker for the address is open.
+// Once the cooldown passes a single half open probe is let through.
+func (p *pool) allow(addr string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	b, ok := p.breakers[addr]
+	if !ok || p.threshold <= 0 || b.failures < p.threshold {
+		return nil
+	}
+	if b.probing || time.Since(b.opened) < p.cooldown {
+		return errBreakerOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// mark records the result of a dial or call to the address
+func (p *pool) mark(addr string, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.threshold <= 0 {
+		return
+	}
+
+	if !unavailable(err) {
+		delete(p.breakers, addr)
+		return
+	}
+
+	b, ok := p.breakers[addr]
+	if !ok {
+		b = &breaker{}
+		p.breakers[addr] = b
+	}
+	b.failures++
+	b.probing = false
+	if b.failures >= p.threshold {
+		b.opened = time.Now()
+	}
+}
+
+// unprobe clears a half open probe which never reached the address,
+// e.g when the pool is exhausted, so another probe can be let through
+func (p *pool) unprobe(addr string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if b, ok := p.breakers[addr]; ok {
+		b.probing = false
+	}
+}
+
+// filter is a selector filter which removes nodes with an open breaker
+func (p *pool) filter(old []*registry.Service) []*registry.Service {
+	var services []*registry.Service
+
+	for _, service := range old {
+		var nodes []*registry.Node
+		for _, node := range service.Nodes {
+			if p.available(nodeAddress(node)) {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		s := *service
+		s.Nodes = nodes
+		services = append(services, &s)
+	}
+
+	return services
+}
+
+func (p *pool) getConn(ctx context.Context, addr string, opts ...grpc.DialOption) (conn *poolConn, err error) {
+	if err := p.allow(addr); err != nil {
+		return nil, err
+	}
+
+	// failures to dial are marked which ends the probe,
+	// any other failure must clear it
+	defer func() {
+		if err != nil {
+			p.unprobe(addr)
+		}
+	}()
+
 	p.Lock()
 	conns := p.conns[addr]
 	now := time.Now().Unix()
@@ -43,6 +197,7 @@ func (p *pool) getConn(addr string, opts ...grpc.DialOption) (*poolConn, error)
 		// if conn is old kill it and move on
 		if d := now - conn.created; d > p.ttl {
 			conn.cc.Close()
+			p.open[addr]--
 			continue
 		}
 
@@ -52,28 +207,109 @@ func (p *pool) getConn(addr string, opts ...grpc.DialOption) (*poolConn, error)
 		return conn, nil
 	}
 
-	p.Unlock()
+	// wait for a conn to be released if at the limit
+	if p.max > 0 && p.open[addr] >= p.max {
+		if p.wait <= 0 {
+			p.Unlock()
+			return nil, errPoolExhausted
+		}
+
+		ch := make(chan *poolConn, 1)
+		p.waiters[addr] = append(p.waiters[addr], ch)
+		wait := p.wait
+		p.Unlock()
+
+		conn, err := p.await(ctx, addr, ch, wait)
+		if err != nil || conn != nil {
+			return conn, err
+		}
+	} else {
+		p.open[addr]++
+		p.Unlock()
+	}
 
 	// create new conn
 	cc, err := grpc.Dial(addr, opts...)
 	if err != nil {
+		p.mark(addr, err)
+		p.closed(addr)
 		return nil, err
 	}
 
 	return &poolConn{cc, time.Now().Unix()}, nil
 }
 
+// await waits for a released conn or a free slot, returning nil
+// if a new conn may be dialled
+func (p *pool) await(ctx context.Context, addr string, ch chan *poolConn, wait time.Duration) (*poolConn, error) {
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case conn := <-ch:
+		return conn, nil
+	case <-ctx.Done():
+	case <-t.C:
+	}
+
+	p.Lock()
+	waiting := false
+	for i, w := range p.waiters[addr] {
+		if w == ch {
+			p.waiters[addr] = append(p.waiters[addr][:i], p.waiters[addr][i+1:]...)
+			waiting = true
+			break
+		}
+	}
+	p.Unlock()
+
+	// handed a conn or slot while giving up so pass it on
+	if !waiting {
+		if conn := <-ch; conn != nil {
+			p.release(addr, conn, nil)
+		} else {
+			p.closed(addr)
+		}
+	}
+
+	return nil, errPoolExhausted
+}
+
+// closed hands the slot of a closed conn to a waiter
+func (p *pool) closed(addr string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if ws := p.waiters[addr]; len(ws) > 0 {
+		p.waiters[addr] = ws[1:]
+		ws[0] <- nil
+		return
+	}
+	p.open[addr]--
+}
+
 func (p *pool) release(addr string, conn *poolConn, err error) {
 	// don't store the conn if it has errored
 	if err != nil {
 		conn.cc.Close()
+		p.closed(addr)
 		return
 	}
 
-	// otherwise put it back for reuse
 	p.Lock()
+
+	// hand it to a waiter
+	if ws := p.waiters[addr]; len(ws) > 0 {
+		p.waiters[addr] = ws[1:]
+		p.Unlock()
+		ws[0] <- conn
+		return
+	}
+
+	// otherwise put it back for reuse
 	conns := p.conns[addr]
 	if len(conns) >= p.size {
+		p.open[addr]--
 		p.Unlock()
 		conn.cc.Close()
 		return
@@ -81,3 +317,55 @@ func (p *pool) release(addr string, conn *poolConn, err error) {
 	p.conns[addr] = append(conns, conn)
 	p.Unlock()
 }
+
+// PoolStats are the connection pool stats of an address
+type PoolStats struct {
+	// Idle connections held by the pool
+	Idle int `json:"idle"`
+	// Open connections, idle and in use, when a limit is set
+	Open int `json:"open"`
+	// Waiting callers when the limit is reached
+	Waiting int `json:"waiting"`
+	// Failures since the last successful call when breaking
+	Failures int `json:"failures,omitempty"`
+	// Breaker is "open" or "probing" when the breaker is open
+	Breaker string `json:"breaker,omitempty"`
+}
+
+// stats returns the pool stats by address
+func (p *pool) stats() map[string]PoolStats {
+	p.Lock()
+	defer p.Unlock()
+
+	stats := make(map[string]PoolStats)
+
+	for addr, conns := range p.conns {
+		s := stats[addr]
+		s.Idle = len(conns)
+		stats[addr] = s
+	}
+	for addr, n := range p.open {
+		s := stats[addr]
+		s.Open = n
+		stats[addr] = s
+	}
+	for addr, w := range p.waiters {
+		s := stats[addr]
+		s.Waiting = len(w)
+		stats[addr] = s
+	}
+	for addr, b := range p.breakers {
+		s := stats[addr]
+		s.Failures = b.failures
+		switch {
+		case p.threshold <= 0 || b.failures < p.threshold:
+		case b.probing:
+			s.Breaker = "probing"
+		default:
+			s.Breaker = "open"
+		}
+		stats[addr] = s
+	}
+
+	return stats
+}
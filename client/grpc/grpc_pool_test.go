@@ -1,12 +1,16 @@
 package grpc
 
 import (
+	"errors"
 	"net"
 	"testing"
 	"time"
 
 	"context"
+	"github.com/micro/go-micro/registry"
 	"github.com/micro/grpc-go"
+	"github.com/micro/grpc-go/codes"
+	"github.com/micro/grpc-go/status"
 	pgrpc "google.golang.org/grpc"
 	pb "google.golang.org/grpc/examples/helloworld/helloworld"
 )
@@ -30,7 +34,7 @@ func testPool(t *testing.T, size int, ttl time.Duration) {
 
 	for i := 0; i < 10; i++ {
 		// get a conn
-		cc, err := p.getConn(l.Addr().String(), grpc.WithInsecure())
+		cc, err := p.getConn(context.TODO(), l.Addr().String(), grpc.WithInsecure())
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -62,3 +66,148 @@ func TestGRPCPool(t *testing.T) {
 	testPool(t, 0, time.Minute)
 	testPool(t, 2, time.Minute)
 }
+
+func TestGRPCPoolBreaker(t *testing.T) {
+	p := newPool(1, time.Minute)
+	p.threshold = 2
+	p.cooldown = time.Millisecond * 10
+
+	addr := "127.0.0.1:1"
+	dialErr := errors.New("connection refused")
+
+	for i := 0; i < 2; i++ {
+		if err := p.allow(addr); err != nil {
+			t.Fatalf("expected breaker to be closed after %d failures", i)
+		}
+		p.mark(addr, dialErr)
+	}
+
+	if err := p.allow(addr); err != errBreakerOpen {
+		t.Fatalf("expected breaker to be open, got %v", err)
+	}
+
+	services := p.filter([]*registry.Service{{
+		Name:  "foo",
+		Nodes: []*registry.Node{{Id: "1", Address: "127.0.0.1", Port: 1}, {Id: "2", Address: "127.0.0.1", Port: 2}},
+	}})
+	if len(services) != 1 || len(services[0].Nodes) != 1 || services[0].Nodes[0].Id != "2" {
+		t.Fatalf("expected open node to be filtered, got %+v", services)
+	}
+
+	time.Sleep(p.cooldown)
+
+	// a single half open probe is let through
+	if err := p.allow(addr); err != nil {
+		t.Fatalf("expected half open probe, got %v", err)
+	}
+	if err := p.allow(addr); err != errBreakerOpen {
+		t.Fatalf("expected only one probe, got %v", err)
+	}
+
+	// application errors mean the address is reachable
+	p.mark(addr, status.Error(codes.NotFound, "not found"))
+
+	if err := p.allow(addr); err != nil {
+		t.Fatalf("expected breaker to be closed, got %v", err)
+	}
+}
+
+func TestGRPCPoolBreakerProbeExhausted(t *testing.T) {
+	p := newPool(1, time.Minute)
+	p.threshold = 1
+	p.cooldown = time.Millisecond * 10
+	p.max = 1
+
+	addr := "127.0.0.1:1"
+
+	// hold the only slot of the address
+	cc, err := p.getConn(context.TODO(), addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.release(addr, cc, nil)
+
+	p.mark(addr, errors.New("connection refused"))
+	time.Sleep(p.cooldown)
+
+	// the probe fails to get a conn so it must not stay in flight
+	if _, err := p.getConn(context.TODO(), addr, grpc.WithInsecure()); err != errPoolExhausted {
+		t.Fatalf("expected pool to be exhausted, got %v", err)
+	}
+
+	if !p.available(addr) {
+		t.Fatal("expected the address to be available for another probe")
+	}
+	if err := p.allow(addr); err != nil {
+		t.Fatalf("expected another half open probe, got %v", err)
+	}
+}
+
+func TestGRPCPoolLimit(t *testing.T) {
+	p := newPool(1, time.Minute)
+	p.max = 1
+
+	// dialling is lazy so the address needn't be listening
+	addr := "127.0.0.1:1"
+
+	cc, err := p.getConn(context.TODO(), addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fail fast without a wait
+	if _, err := p.getConn(context.TODO(), addr, grpc.WithInsecure()); err != errPoolExhausted {
+		t.Fatalf("expected pool to be exhausted, got %v", err)
+	}
+
+	// wait up to the context deadline
+	p.wait = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if _, err := p.getConn(ctx, addr, grpc.WithInsecure()); err != errPoolExhausted {
+		t.Fatalf("expected pool to be exhausted, got %v", err)
+	}
+
+	// a released conn is handed to the waiter
+	ch := make(chan *poolConn, 1)
+	go func() {
+		conn, _ := p.getConn(context.TODO(), addr, grpc.WithInsecure())
+		ch <- conn
+	}()
+	time.Sleep(time.Millisecond * 10)
+	p.release(addr, cc, nil)
+
+	select {
+	case conn := <-ch:
+		if conn != cc {
+			t.Fatal("expected the released conn")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected waiter to get the released conn")
+	}
+
+	// a closed conn frees its slot for the waiter to dial
+	go func() {
+		conn, _ := p.getConn(context.TODO(), addr, grpc.WithInsecure())
+		ch <- conn
+	}()
+	time.Sleep(time.Millisecond * 10)
+	p.release(addr, cc, errors.New("failed"))
+
+	select {
+	case conn := <-ch:
+		if conn == nil || conn == cc {
+			t.Fatal("expected a new conn")
+		}
+		p.release(addr, conn, nil)
+	case <-time.After(time.Second):
+		t.Fatal("expected waiter to dial a new conn")
+	}
+
+	p.Lock()
+	if n := p.open[addr]; n != 1 {
+		p.Unlock()
+		t.Fatalf("expected 1 open conn, got %d", n)
+	}
+	p.Unlock()
+}
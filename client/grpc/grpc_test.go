@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/client"
 	"github.com/micro/go-micro/registry"
@@ -89,3 +90,47 @@ func TestGRPCClient(t *testing.T) {
 		}
 	}
 }
+
+func TestPayloads(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	s := pgrpc.NewServer()
+	pb.RegisterGreeterServer(s, &greeterServer{})
+
+	go s.Serve(l)
+	defer s.Stop()
+
+	c := NewClient(SlowCalls(time.Nanosecond))
+
+	req := c.NewRequest("test", "Greeter.SayHello", &pb.HelloRequest{
+		Name: "John",
+	})
+
+	for i := 0; i < 2; i++ {
+		rsp := pb.HelloReply{}
+		if err := c.Call(context.TODO(), req, &rsp, client.WithAddress(l.Addr().String())); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payloads, ok := Payloads(c)
+	if !ok {
+		t.Fatal("expected payload stats")
+	}
+
+	p := payloads["test/Greeter.SayHello"]
+	if p.Calls != 2 || p.Errors != 0 {
+		t.Fatalf("expected 2 successful calls got %+v", p)
+	}
+	// "John" encodes to 6 bytes and "Hello John" to 12
+	if p.RequestBytes != 12 || p.MaxRequestBytes != 6 {
+		t.Fatalf("unexpected request sizes %+v", p)
+	}
+	if p.ResponseBytes != 24 || p.MaxResponseBytes != 12 {
+		t.Fatalf("unexpected response sizes %+v", p)
+	}
+}
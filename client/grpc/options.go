@@ -4,13 +4,31 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"net"
+	"time"
 
 	"github.com/micro/go-micro/client"
+	"github.com/micro/go-plugins/transport/proxy"
 	"github.com/micro/grpc-go"
 )
 
 type codecsKey struct{}
 type tlsAuth struct{}
+type negotiateKey struct{}
+type breakerKey struct{}
+type poolLimitKey struct{}
+type slowCallsKey struct{}
+type proxyKey struct{}
+
+type breakerOptions struct {
+	threshold int
+	cooldown  time.Duration
+}
+
+type poolLimitOptions struct {
+	max  int
+	wait time.Duration
+}
 
 // gRPC Codec to be used to encode/decode requests for a given content type
 func Codec(contentType string, c grpc.Codec) client.Option {
@@ -36,3 +54,113 @@ func AuthTLS(t *tls.Config) client.Option {
 		o.Context = context.WithValue(o.Context, tlsAuth{}, t)
 	}
 }
+
+// Negotiate enables codec negotiation with the nodes being called. When a node
+// advertises codecs which don't include the request content type the first of
+// the given preferences supported by both sides is used instead.
+func Negotiate(prefs ...string) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, negotiateKey{}, prefs)
+	}
+}
+
+// Breaker enables per address circuit breaking in the connection pool.
+// After threshold consecutive dial or unavailable errors the address is
+// removed from selection until the cooldown passes, when a single probe
+// request is let through to decide whether to close the breaker again.
+func Breaker(threshold int, cooldown time.Duration) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, breakerKey{}, breakerOptions{threshold, cooldown})
+	}
+}
+
+func getBreaker(o client.Options) (int, time.Duration) {
+	if o.Context == nil {
+		return 0, 0
+	}
+	b, ok := o.Context.Value(breakerKey{}).(breakerOptions)
+	if !ok {
+		return 0, 0
+	}
+	return b.threshold, b.cooldown
+}
+
+// PoolLimit caps the connections, idle and in use, the pool opens to an
+// address. When all are in use calls wait up to the wait time, or their
+// deadline if sooner, for one to be released before failing with a 503.
+// A wait of zero fails fast. Streams aren't pooled so aren't limited.
+func PoolLimit(max int, wait time.Duration) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, poolLimitKey{}, poolLimitOptions{max, wait})
+	}
+}
+
+func getPoolLimit(o client.Options) (int, time.Duration) {
+	if o.Context == nil {
+		return 0, 0
+	}
+	l, ok := o.Context.Value(poolLimitKey{}).(poolLimitOptions)
+	if !ok {
+		return 0, 0
+	}
+	return l.max, l.wait
+}
+
+// SlowCalls logs calls taking longer than the threshold with the
+// endpoint, the node called and the request and response sizes.
+// Streams aren't logged.
+func SlowCalls(threshold time.Duration) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, slowCallsKey{}, threshold)
+	}
+}
+
+func getSlowCalls(o client.Options) time.Duration {
+	if o.Context == nil {
+		return 0
+	}
+	d, _ := o.Context.Value(slowCallsKey{}).(time.Duration)
+	return d
+}
+
+// Proxy dials connections through the proxy returned by fn, such as
+// proxy.URL("socks5://bastion:1080"). The MICRO_PROXY env var is
+// used when it isn't set.
+func Proxy(fn proxy.Func) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, proxyKey{}, fn)
+	}
+}
+
+// proxyDialer returns the dial option connecting through the
+// proxy, or false if connections are dialed directly
+func proxyDialer(o client.Options) (grpc.DialOption, bool) {
+	var fn proxy.Func
+	if o.Context != nil {
+		fn, _ = o.Context.Value(proxyKey{}).(proxy.Func)
+	}
+
+	fn = proxy.Get(fn)
+	if fn == nil {
+		return nil, false
+	}
+
+	return grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return proxy.Dial(context.Background(), fn, "tcp", addr, timeout)
+	}), true
+}
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/grpc-go/stats"
+)
+
+// callKey carries the stats of a call to the stats handler
+type callKey struct{}
+
+// PayloadStats are the serialized payload sizes of an endpoint
+type PayloadStats struct {
+	// Calls completed, including streams
+	Calls uint64 `json:"calls"`
+	// Errors returned by completed calls
+	Errors uint64 `json:"errors"`
+	// RequestBytes sent in total
+	RequestBytes int64 `json:"request_bytes"`
+	// ResponseBytes received in total
+	ResponseBytes int64 `json:"response_bytes"`
+	// MaxRequestBytes sent by a single call
+	MaxRequestBytes int64 `json:"max_request_bytes"`
+	// MaxResponseBytes received by a single call
+	MaxResponseBytes int64 `json:"max_response_bytes"`
+}
+
+// callStats are the stats of a single call or stream
+type callStats struct {
+	service  string
+	endpoint string
+	address  string
+	stream   bool
+	start    time.Time
+
+	// updated atomically as messages are sent and received
+	request  int64
+	response int64
+}
+
+// payloads is the stats handler recording the payload
+// sizes of calls and logging slow calls
+type payloads struct {
+	sync.Mutex
+	slow  time.Duration
+	stats map[string]*PayloadStats
+}
+
+func newPayloads() *payloads {
+	return &payloads{
+		stats: make(map[string]*PayloadStats),
+	}
+}
+
+// withCall returns the context carrying the stats of the call
+func withCall(ctx context.Context, service, endpoint, address string, stream bool) context.Context {
+	return context.WithValue(ctx, callKey{}, &callStats{
+		service:  service,
+		endpoint: endpoint,
+		address:  address,
+		stream:   stream,
+		start:    time.Now(),
+	})
+}
+
+func (p *payloads) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (p *payloads) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	cs, ok := ctx.Value(callKey{}).(*callStats)
+	if !ok {
+		return
+	}
+
+	switch st := s.(type) {
+	case *stats.OutPayload:
+		atomic.AddInt64(&cs.request, int64(st.Length))
+	case *stats.InPayload:
+		atomic.AddInt64(&cs.response, int64(st.Length))
+	case *stats.End:
+		p.record(cs, st.Error)
+	}
+}
+
+func (p *payloads) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (p *payloads) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+// record adds a completed call to the stats of its endpoint
+func (p *payloads) record(cs *callStats, err error) {
+	req := atomic.LoadInt64(&cs.request)
+	rsp := atomic.LoadInt64(&cs.response)
+	d := time.Since(cs.start)
+
+	p.Lock()
+	key := cs.service + "/" + cs.endpoint
+	s, ok := p.stats[key]
+	if !ok {
+		s = &PayloadStats{}
+		p.stats[key] = s
+	}
+	s.Calls++
+	if err != nil {
+		s.Errors++
+	}
+	s.RequestBytes += req
+	s.ResponseBytes += rsp
+	if req > s.MaxRequestBytes {
+		s.MaxRequestBytes = req
+	}
+	if rsp > s.MaxResponseBytes {
+		s.MaxResponseBytes = rsp
+	}
+	slow := p.slow
+	p.Unlock()
+
+	// streams live as long as the caller wants
+	if cs.stream || slow <= 0 || d < slow {
+		return
+	}
+
+	log.Logf("[grpc] slow call to %s %s on %s took %v, request %d bytes, response %d bytes, error: %v",
+		cs.service, cs.endpoint, cs.address, d, req, rsp, err)
+}
+
+// get returns a copy of the stats by endpoint
+func (p *payloads) get() map[string]PayloadStats {
+	p.Lock()
+	defer p.Unlock()
+
+	stats := make(map[string]PayloadStats, len(p.stats))
+	for k, v := range p.stats {
+		stats[k] = *v
+	}
+	return stats
+}
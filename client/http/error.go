@@ -0,0 +1,92 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+)
+
+// detail is the detail of errors for responses with a Retry-After
+// header. The header is carried in the detail so the errors remain
+// plain *errors.Error values.
+type detail struct {
+	Detail     string `json:"detail"`
+	RetryAfter string `json:"retry_after"`
+}
+
+func decode(err error) (*detail, bool) {
+	e, ok := err.(*errors.Error)
+	if !ok || len(e.Detail) == 0 || e.Detail[0] != '{' {
+		return nil, false
+	}
+	var d *detail
+	if jerr := json.Unmarshal([]byte(e.Detail), &d); jerr != nil || d == nil || len(d.RetryAfter) == 0 {
+		return nil, false
+	}
+	return d, true
+}
+
+// parseError returns the micro error in the body or
+// one created from the status code
+func parseError(status int, header http.Header, b []byte) error {
+	err := errors.Parse(string(b))
+	if err.Code == 0 {
+		d := string(bytes.TrimSpace(b))
+		if len(d) == 0 {
+			d = http.StatusText(status)
+		}
+		err = errors.New("go.micro.client", d, int32(status)).(*errors.Error)
+	}
+
+	if ra := header.Get("Retry-After"); len(ra) > 0 {
+		if b, jerr := json.Marshal(&detail{Detail: err.Detail, RetryAfter: ra}); jerr == nil {
+			err.Detail = string(b)
+		}
+	}
+
+	return err
+}
+
+// Detail returns the detail of an error returned by the client
+// without the encoded Retry-After header
+func Detail(err error) string {
+	if err == nil {
+		return ""
+	}
+	if d, ok := decode(err); ok {
+		return d.Detail
+	}
+	if e, ok := err.(*errors.Error); ok {
+		return e.Detail
+	}
+	return err.Error()
+}
+
+// RetryAfter returns the delay of the Retry-After header of the response
+// so retry wrappers can honour the delay asked for by the server
+func RetryAfter(err error) (time.Duration, bool) {
+	d, ok := decode(err)
+	if !ok {
+		return 0, false
+	}
+
+	if s, perr := strconv.Atoi(d.RetryAfter); perr == nil {
+		if s < 0 {
+			return 0, false
+		}
+		return time.Duration(s) * time.Second, true
+	}
+
+	t, perr := http.ParseTime(d.RetryAfter)
+	if perr != nil {
+		return 0, false
+	}
+	if ra := t.Sub(time.Now()); ra > 0 {
+		return ra, true
+	}
+	return 0, true
+}
@@ -6,10 +6,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,11 +23,17 @@ import (
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/selector"
 	"github.com/micro/go-micro/transport"
+	"github.com/micro/go-plugins/codec/negotiate"
+	"github.com/micro/go-plugins/transport/proxy"
 )
 
 type httpClient struct {
-	once sync.Once
-	opts client.Options
+	once   sync.Once
+	opts   client.Options
+	client *http.Client
+
+	sync.Mutex
+	endpoints map[string]*endpoints
 }
 
 func init() {
@@ -66,37 +73,90 @@ func (h *httpClient) call(ctx context.Context, address string, req client.Reques
 	header.Set("Timeout", fmt.Sprintf("%d", opts.RequestTimeout))
 	// set the content type for the request
 	header.Set("Content-Type", req.ContentType())
+	// accept responses of the same type
+	header.Set("Accept", req.ContentType())
 
 	// get codec
-	cf, err := h.newHTTPCodec(req.ContentType())
+	cf, err := h.callCodec(req.ContentType(), opts)
 	if err != nil {
 		return errors.InternalServerError("go.micro.client", err.Error())
 	}
 
+	method := "POST"
+	path := req.Method()
+	var rawPath string
+	var values url.Values
+	var b []byte
+
+	// use the route from the endpoint metadata if the method isn't a path
+	if r := h.route(req); r != nil {
+		m, err := fields(req.Request())
+		if err != nil {
+			return errors.InternalServerError("go.micro.client", err.Error())
+		}
+
+		p, rest, err := expand(r.path, m)
+		if err != nil {
+			return errors.BadRequest("go.micro.client", err.Error())
+		}
+
+		method = r.method
+		path = p
+
+		// keep escaped path parameters intact
+		if up, err := url.PathUnescape(p); err == nil {
+			path = up
+			rawPath = p
+		}
+
+		if !r.hasBody() {
+			values = query(rest)
+		}
+	}
+
 	// marshal request
-	b, err := cf.Marshal(req.Request())
-	if err != nil {
-		return errors.InternalServerError("go.micro.client", err.Error())
+	if values == nil {
+		b, err = cf.Marshal(req.Request())
+		if err != nil {
+			return errors.InternalServerError("go.micro.client", err.Error())
+		}
 	}
 
-	buf := &buffer{bytes.NewBuffer(b)}
-	defer buf.Close()
+	// compress the body
+	if min, ok := h.compress(); ok && len(b) > 0 && len(b) >= min {
+		gz, err := gzipBytes(b)
+		if err != nil {
+			return errors.InternalServerError("go.micro.client", err.Error())
+		}
+		b = gz
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	var body io.ReadCloser = http.NoBody
+	if b != nil {
+		body = &buffer{bytes.NewBuffer(b)}
+	} else {
+		header.Del("Content-Type")
+	}
+	defer body.Close()
 
 	hreq := &http.Request{
-		Method: "POST",
+		Method: method,
 		URL: &url.URL{
-			Scheme: "http",
-			Host:   address,
-			Path:   req.Method(),
+			Scheme:   "http",
+			Host:     address,
+			Path:     path,
+			RawPath:  rawPath,
+			RawQuery: values.Encode(),
 		},
 		Header:        header,
-		Body:          buf,
+		Body:          body,
 		ContentLength: int64(len(b)),
 		Host:          address,
 	}
 
 	// make the request
-	hrsp, err := http.DefaultClient.Do(hreq.WithContext(ctx))
+	hrsp, err := h.client.Do(hreq.WithContext(ctx))
 	if err != nil {
 		return errors.InternalServerError("go.micro.client", err.Error())
 	}
@@ -108,9 +168,22 @@ func (h *httpClient) call(ctx context.Context, address string, req client.Reques
 		return errors.InternalServerError("go.micro.client", err.Error())
 	}
 
+	if hrsp.StatusCode >= 400 {
+		return parseError(hrsp.StatusCode, hrsp.Header, b)
+	}
+
+	// the service may respond with a different content type
+	if ct := hrsp.Header.Get("Content-Type"); len(ct) > 0 {
+		if c, err := h.callCodec(mediaType(ct), opts); err == nil {
+			cf = c
+		}
+	}
+
 	// unmarshal
-	if err := cf.Unmarshal(b, rsp); err != nil {
-		return errors.InternalServerError("go.micro.client", err.Error())
+	if len(b) > 0 {
+		if err := cf.Unmarshal(b, rsp); err != nil {
+			return errors.InternalServerError("go.micro.client", err.Error())
+		}
 	}
 
 	return nil
@@ -135,7 +208,7 @@ func (h *httpClient) stream(ctx context.Context, address string, req client.Requ
 		return nil, errors.InternalServerError("go.micro.client", err.Error())
 	}
 
-	cc, err := net.Dial("tcp", address)
+	cc, err := proxy.Dial(ctx, getProxy(h.opts), "tcp", address, opts.DialTimeout)
 	if err != nil {
 		return nil, errors.InternalServerError("go.micro.client", fmt.Sprintf("Error dialing: %v", err))
 	}
@@ -152,7 +225,41 @@ func (h *httpClient) stream(ctx context.Context, address string, req client.Requ
 	}, nil
 }
 
+// route returns the route for requests to endpoints rather than paths
+func (h *httpClient) route(req client.Request) *route {
+	if strings.HasPrefix(req.Method(), "/") {
+		return nil
+	}
+	return h.lookup(req.Service(), req.Method())
+}
+
+// callCodec returns the codec for the call, preferring one set with CallCodec
+func (h *httpClient) callCodec(contentType string, opts client.CallOptions) (Codec, error) {
+	if opts.Context != nil {
+		if c, ok := opts.Context.Value(callCodecKey{}).(Codec); ok {
+			return c, nil
+		}
+	}
+	return h.newHTTPCodec(contentType)
+}
+
+// compress returns the min size of bodies to compress if enabled
+func (h *httpClient) compress() (int, bool) {
+	if h.opts.Context == nil {
+		return 0, false
+	}
+	min, ok := h.opts.Context.Value(compressKey{}).(int)
+	return min, ok
+}
+
 func (h *httpClient) newHTTPCodec(contentType string) (Codec, error) {
+	if h.opts.Context != nil {
+		if codecs, ok := h.opts.Context.Value(codecsKey{}).(map[string]Codec); ok {
+			if c, ok := codecs[contentType]; ok {
+				return c, nil
+			}
+		}
+	}
 	if c, ok := defaultHTTPCodecs[contentType]; ok {
 		return c, nil
 	}
@@ -169,6 +276,34 @@ func (h *httpClient) newCodec(contentType string) (codec.NewCodec, error) {
 	return nil, fmt.Errorf("Unsupported Content-Type: %s", contentType)
 }
 
+// negotiate returns the request to send to the node, switching the
+// content type to one the node supports if negotiation is enabled
+func (h *httpClient) negotiate(req client.Request, node *registry.Node) client.Request {
+	if h.opts.Context == nil {
+		return req
+	}
+
+	prefs, ok := h.opts.Context.Value(negotiateKey{}).([]string)
+	if !ok {
+		return req
+	}
+
+	ct := negotiate.Select(node, req.ContentType(), prefs, func(ct string) bool {
+		_, err := h.newHTTPCodec(ct)
+		return err == nil
+	})
+	if ct == req.ContentType() {
+		return req
+	}
+
+	var opts []client.RequestOption
+	if req.Stream() {
+		opts = append(opts, client.StreamingRequest())
+	}
+
+	return newHTTPRequest(req.Service(), req.Method(), req.Request(), ct, opts...)
+}
+
 func (h *httpClient) Init(opts ...client.Option) error {
 	for _, o := range opts {
 		o(&h.opts)
@@ -256,7 +391,7 @@ func (h *httpClient) Call(ctx context.Context, req client.Request, rsp interface
 		}
 
 		// make the call
-		err = hcall(ctx, addr, req, rsp, callOpts)
+		err = hcall(ctx, addr, h.negotiate(req, node), rsp, callOpts)
 		h.opts.Selector.Mark(req.Service(), node, err)
 		return err
 	}
@@ -350,7 +485,7 @@ func (h *httpClient) Stream(ctx context.Context, req client.Request, opts ...cli
 			addr = fmt.Sprintf("%s:%d", addr, node.Port)
 		}
 
-		stream, err := h.stream(ctx, addr, req, callOpts)
+		stream, err := h.stream(ctx, addr, h.negotiate(req, node), callOpts)
 		h.opts.Selector.Mark(req.Service(), node, err)
 		return stream, err
 	}
@@ -459,8 +594,10 @@ func newClient(opts ...client.Option) client.Client {
 	}
 
 	rc := &httpClient{
-		once: sync.Once{},
-		opts: options,
+		once:      sync.Once{},
+		opts:      options,
+		client:    newHTTPClient(options),
+		endpoints: make(map[string]*endpoints),
 	}
 
 	c := client.Client(rc)
@@ -10,8 +10,10 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/selector"
 	"github.com/micro/go-plugins/client/http/test"
@@ -277,3 +279,192 @@ func TestHTTPClientStream(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPClientRoute(t *testing.T) {
+	r := memory.NewRegistry()
+	s := selector.NewSelector(selector.Registry(r))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if r.URL.Path != "/users/5" {
+				http.Error(w, "unexpected path "+r.URL.Path, 400)
+				return
+			}
+			if r.URL.Query().Get("data") != "hello world" {
+				http.Error(w, "unexpected query "+r.URL.RawQuery, 400)
+				return
+			}
+			// respond with a different content type
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"seq": 5, "data": "found"}`))
+		case "DELETE":
+			w.WriteHeader(404)
+			w.Write([]byte(`{"id": "users", "code": 404, "detail": "not found"}`))
+		default:
+			http.Error(w, "unexpected method", 405)
+		}
+	})
+	go http.Serve(l, mux)
+
+	host, sport, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(sport)
+
+	if err := r.Register(&registry.Service{
+		Name: "test.service",
+		Nodes: []*registry.Node{
+			{
+				Id:      "test.service.1",
+				Address: host,
+				Port:    port,
+			},
+		},
+		Endpoints: []*registry.Endpoint{
+			{
+				Name:     "Users.Get",
+				Metadata: map[string]string{"method": "GET", "path": "/users/{seq}"},
+			},
+			{
+				Name:     "Users.Delete",
+				Metadata: map[string]string{"method": "DELETE", "path": "/users/{seq}"},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(client.Selector(s), client.Registry(r))
+
+	req := c.NewRequest("test.service", "Users.Get", &test.Message{Seq: 5, Data: "hello world"})
+	rsp := new(test.Message)
+	if err := c.Call(context.TODO(), req, rsp); err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Seq != 5 || rsp.Data != "found" {
+		t.Fatalf("unexpected response %+v", rsp)
+	}
+
+	req = c.NewRequest("test.service", "Users.Delete", &test.Message{Seq: 5})
+	err = c.Call(context.TODO(), req, new(test.Message), client.WithRetries(1))
+	if verr, ok := err.(*errors.Error); !ok || verr.Code != 404 || verr.Detail != "not found" {
+		t.Fatalf("expected not found error got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	m := map[string]interface{}{
+		"id":   "a b",
+		"name": "bob",
+		"opts": map[string]interface{}{"limit": 10},
+		"tags": []interface{}{"x", "y"},
+	}
+
+	path, rest, err := expand("/users/{id}", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/users/a%20b" {
+		t.Fatalf("unexpected path %s", path)
+	}
+
+	if q := query(rest).Encode(); q != "name=bob&opts.limit=10&tags=x&tags=y" {
+		t.Fatalf("unexpected query %s", q)
+	}
+
+	if _, _, err := expand("/users/{missing}", m); err == nil {
+		t.Fatal("expected missing parameter error")
+	}
+}
+
+// failRegistry fails lookups until ok is set
+type failRegistry struct {
+	registry.Registry
+	ok bool
+}
+
+func (f *failRegistry) GetService(name string) ([]*registry.Service, error) {
+	if !f.ok {
+		return nil, fmt.Errorf("registry unavailable")
+	}
+	return f.Registry.GetService(name)
+}
+
+func TestHTTPClientLookup(t *testing.T) {
+	r := &failRegistry{Registry: memory.NewRegistry()}
+
+	if err := r.Register(&registry.Service{
+		Name:  "test.service",
+		Nodes: []*registry.Node{{Id: "test.service.1", Address: "127.0.0.1", Port: 8080}},
+		Endpoints: []*registry.Endpoint{
+			{
+				Name:     "Users.Get",
+				Metadata: map[string]string{"method": "GET", "path": "/users/{seq}"},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewClient(client.Registry(r)).(*httpClient)
+
+	// failures aren't cached
+	if rt := h.lookup("test.service", "Users.Get"); rt != nil {
+		t.Fatalf("expected no route got %+v", rt)
+	}
+	if _, ok := h.endpoints["test.service"]; ok {
+		t.Fatal("expected failed lookup not to be cached")
+	}
+
+	r.ok = true
+	rt := h.lookup("test.service", "Users.Get")
+	if rt == nil || rt.method != "GET" || rt.path != "/users/{seq}" {
+		t.Fatalf("unexpected route %+v", rt)
+	}
+
+	// expired routes are still used while the registry fails
+	r.ok = false
+	h.endpoints["test.service"].expires = time.Now().Add(-time.Second)
+	if rt := h.lookup("test.service", "Users.Get"); rt == nil {
+		t.Fatal("expected the previous route to be used")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "2")
+
+	err := parseError(503, header, []byte("busy"))
+	if e, ok := err.(*errors.Error); !ok || e.Code != 503 {
+		t.Fatalf("expected a micro error got %v", err)
+	}
+	if d := Detail(err); d != "busy" {
+		t.Fatalf("expected detail busy got %s", d)
+	}
+	if d, ok := RetryAfter(err); !ok || d != 2*time.Second {
+		t.Fatalf("expected retry after 2s got %v", d)
+	}
+
+	// micro errors in the body keep their detail
+	header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	err = parseError(429, header, []byte(errors.New("test", "slow down", 429).Error()))
+	if d := Detail(err); d != "slow down" {
+		t.Fatalf("expected detail slow down got %s", d)
+	}
+	if d, ok := RetryAfter(err); !ok || d <= 0 || d > time.Minute {
+		t.Fatalf("expected retry after up to a minute got %v", d)
+	}
+
+	if _, ok := RetryAfter(parseError(503, http.Header{}, nil)); ok {
+		t.Fatal("expected no retry after without the header")
+	}
+}
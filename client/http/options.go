@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-plugins/transport/proxy"
+)
+
+type negotiateKey struct{}
+
+// Negotiate enables codec negotiation with the nodes being called. When a node
+// advertises codecs which don't include the request content type the first of
+// the given preferences supported by both sides is used instead.
+func Negotiate(prefs ...string) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, negotiateKey{}, prefs)
+	}
+}
+
+type codecsKey struct{}
+type callCodecKey struct{}
+type poolKey struct{}
+type httpClientKey struct{}
+type compressKey struct{}
+type proxyKey struct{}
+
+// pool limits for the keep-alive connection pool
+type pool struct {
+	maxIdlePerHost int
+	maxPerHost     int
+	idleTimeout    time.Duration
+}
+
+// Codec registers a codec for the given content type. It's used to
+// encode requests of the content type and decode responses with it.
+func Codec(contentType string, c Codec) client.Option {
+	return func(o *client.Options) {
+		codecs := make(map[string]Codec)
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		if v := o.Context.Value(codecsKey{}); v != nil {
+			codecs = v.(map[string]Codec)
+		}
+		codecs[contentType] = c
+		o.Context = context.WithValue(o.Context, codecsKey{}, codecs)
+	}
+}
+
+// CallCodec sets the codec used for a single call, overriding
+// the codec of the request and response content types
+func CallCodec(c Codec) client.CallOption {
+	return func(o *client.CallOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, callCodecKey{}, c)
+	}
+}
+
+// PoolLimits sets the limits of the keep-alive connection pool. A
+// maxPerHost of 0 means no limit on the connections per host.
+func PoolLimits(maxIdlePerHost, maxPerHost int, idleTimeout time.Duration) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, poolKey{}, &pool{
+			maxIdlePerHost: maxIdlePerHost,
+			maxPerHost:     maxPerHost,
+			idleTimeout:    idleTimeout,
+		})
+	}
+}
+
+// HTTPClient sets the net/http client used to make requests.
+// PoolLimits are ignored when it's set.
+func HTTPClient(c *http.Client) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, httpClientKey{}, c)
+	}
+}
+
+// Compress gzips request bodies of at least minSize bytes.
+// Gzipped responses are always accepted.
+func Compress(minSize int) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, compressKey{}, minSize)
+	}
+}
+
+// Proxy dials connections through the proxy returned by fn, such as
+// proxy.URL("socks5://bastion:1080"). The MICRO_PROXY env var is used
+// when it isn't set, falling back to HTTP_PROXY for requests.
+func Proxy(fn proxy.Func) client.Option {
+	return func(o *client.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, proxyKey{}, fn)
+	}
+}
+
+func getProxy(o client.Options) proxy.Func {
+	var fn proxy.Func
+	if o.Context != nil {
+		fn, _ = o.Context.Value(proxyKey{}).(proxy.Func)
+	}
+	return proxy.Get(fn)
+}
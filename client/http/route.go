@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+var (
+	// DefaultEndpointTTL is how long endpoint metadata is cached for
+	DefaultEndpointTTL = time.Minute
+)
+
+// route is the http method and path template of an endpoint
+type route struct {
+	method string
+	path   string
+}
+
+type endpoints struct {
+	routes  map[string]*route
+	expires time.Time
+}
+
+// hasBody returns true if the method sends the request as the body
+func (r *route) hasBody() bool {
+	switch r.method {
+	case "GET", "HEAD", "DELETE":
+		return false
+	}
+	return true
+}
+
+// lookup returns the route for the endpoint from the registry. Endpoints
+// describe their route with the metadata keys "method" and "path". The
+// registry is queried without holding the lock and failures aren't
+// cached, the previous routes are used until a lookup succeeds.
+func (h *httpClient) lookup(service, endpoint string) *route {
+	h.Lock()
+	eps, ok := h.endpoints[service]
+	h.Unlock()
+
+	if ok && time.Now().Before(eps.expires) {
+		return eps.routes[endpoint]
+	}
+
+	neps, err := h.load(service)
+	if err != nil {
+		if ok {
+			return eps.routes[endpoint]
+		}
+		return nil
+	}
+
+	h.Lock()
+	h.endpoints[service] = neps
+	h.Unlock()
+
+	return neps.routes[endpoint]
+}
+
+func (h *httpClient) load(service string) (*endpoints, error) {
+	services, err := h.opts.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	eps := &endpoints{
+		routes:  make(map[string]*route),
+		expires: time.Now().Add(DefaultEndpointTTL),
+	}
+
+	for _, s := range services {
+		for _, ep := range s.Endpoints {
+			if r := endpointRoute(ep); r != nil {
+				eps.routes[ep.Name] = r
+			}
+		}
+	}
+
+	return eps, nil
+}
+
+func endpointRoute(ep *registry.Endpoint) *route {
+	if ep == nil || ep.Metadata == nil {
+		return nil
+	}
+
+	path := ep.Metadata["path"]
+	if len(path) == 0 {
+		return nil
+	}
+
+	method := strings.ToUpper(ep.Metadata["method"])
+	if len(method) == 0 {
+		method = "POST"
+	}
+
+	return &route{method: method, path: path}
+}
+
+// fields decodes the request into its top level fields
+func fields(req interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// expand replaces {field} in the path template with the request fields,
+// returning the path and the fields not used by the template
+func expand(tmpl string, m map[string]interface{}) (string, map[string]interface{}, error) {
+	var out bytes.Buffer
+	used := make(map[string]bool)
+
+	for len(tmpl) > 0 {
+		i := strings.Index(tmpl, "{")
+		if i < 0 {
+			out.WriteString(tmpl)
+			break
+		}
+
+		j := strings.Index(tmpl[i:], "}")
+		if j < 0 {
+			return "", nil, fmt.Errorf("invalid path template %s", tmpl)
+		}
+
+		out.WriteString(tmpl[:i])
+
+		name := tmpl[i+1 : i+j]
+		v, ok := m[name]
+		if !ok || v == nil {
+			return "", nil, fmt.Errorf("missing path parameter %s", name)
+		}
+
+		out.WriteString(url.PathEscape(fmt.Sprint(v)))
+		used[name] = true
+
+		tmpl = tmpl[i+j+1:]
+	}
+
+	rest := make(map[string]interface{})
+	for k, v := range m {
+		if !used[k] {
+			rest[k] = v
+		}
+	}
+
+	return out.String(), rest, nil
+}
+
+// query encodes the fields as query parameters. Nested
+// fields are flattened with dots e.g a.b=c
+func query(m map[string]interface{}) url.Values {
+	q := make(url.Values)
+	addQuery(q, "", m)
+	return q
+}
+
+func addQuery(q url.Values, prefix string, m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := k
+		if len(prefix) > 0 {
+			key = prefix + "." + k
+		}
+
+		switch v := m[k].(type) {
+		case nil:
+		case map[string]interface{}:
+			addQuery(q, key, v)
+		case []interface{}:
+			for _, e := range v {
+				if _, ok := e.(map[string]interface{}); ok {
+					continue
+				}
+				q.Add(key, fmt.Sprint(e))
+			}
+		default:
+			q.Set(key, fmt.Sprint(v))
+		}
+	}
+}
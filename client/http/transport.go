@@ -0,0 +1,80 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/micro/go-micro/client"
+)
+
+var (
+	// DefaultMaxIdleConnsPerHost is the default size of the keep-alive pool per host
+	DefaultMaxIdleConnsPerHost = 16
+	// DefaultIdleConnTimeout is how long idle connections are kept
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// newHTTPClient returns the net/http client with a dedicated
+// keep-alive pool so limits don't affect http.DefaultClient
+func newHTTPClient(opts client.Options) *http.Client {
+	p := &pool{
+		maxIdlePerHost: DefaultMaxIdleConnsPerHost,
+		idleTimeout:    DefaultIdleConnTimeout,
+	}
+
+	if opts.Context != nil {
+		if c, ok := opts.Context.Value(httpClientKey{}).(*http.Client); ok {
+			return c
+		}
+		if v, ok := opts.Context.Value(poolKey{}).(*pool); ok {
+			p = v
+		}
+	}
+
+	proxyFn := http.ProxyFromEnvironment
+	if fn := getProxy(opts); fn != nil {
+		proxyFn = func(r *http.Request) (*url.URL, error) {
+			return fn(r.URL.Host)
+		}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxyFn,
+			DialContext: (&net.Dialer{
+				Timeout:   opts.CallOptions.DialTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        p.maxIdlePerHost * 8,
+			MaxIdleConnsPerHost: p.maxIdlePerHost,
+			MaxConnsPerHost:     p.maxPerHost,
+			IdleConnTimeout:     p.idleTimeout,
+		},
+	}
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write(b); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mediaType strips any parameters from the content type
+func mediaType(ct string) string {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ct
+	}
+	return mt
+}
@@ -0,0 +1,74 @@
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"filippo.io/age"
+)
+
+type ageCipher struct {
+	id         string
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+func (a *ageCipher) KeyId() string {
+	return a.id
+}
+
+func (a *ageCipher) Encrypt(id string, plaintext []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w, err := age.Encrypt(buf, a.recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (a *ageCipher) Decrypt(id string, ciphertext []byte) ([]byte, error) {
+	if id != a.id {
+		return nil, fmt.Errorf("unknown key id %s", id)
+	}
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), a.identities...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func (a *ageCipher) String() string {
+	return "age"
+}
+
+// Age returns a cipher which encrypts to the X25519 recipients and decrypts
+// with the identities, both in their bech32 string form. The id names the
+// key pair so that receivers can tell which identity to use.
+func Age(id string, recipients, identities []string) (Cipher, error) {
+	a := &ageCipher{id: id}
+
+	for _, r := range recipients {
+		rcpt, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, err
+		}
+		a.recipients = append(a.recipients, rcpt)
+	}
+
+	for _, i := range identities {
+		ident, err := age.ParseX25519Identity(i)
+		if err != nil {
+			return nil, err
+		}
+		a.identities = append(a.identities, ident)
+	}
+
+	return a, nil
+}
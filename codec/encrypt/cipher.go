@@ -0,0 +1,81 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+var (
+	ErrShortCiphertext = errors.New("ciphertext too short")
+)
+
+// Cipher encrypts and decrypts message bodies
+type Cipher interface {
+	// KeyId returns the id of the key to encrypt with
+	KeyId() string
+	Encrypt(id string, plaintext []byte) ([]byte, error)
+	Decrypt(id string, ciphertext []byte) ([]byte, error)
+	String() string
+}
+
+type aesGCM struct {
+	kp KeyProvider
+}
+
+func (a *aesGCM) aead(id string) (cipher.AEAD, error) {
+	key, err := a.kp.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (a *aesGCM) KeyId() string {
+	return a.kp.Current()
+}
+
+func (a *aesGCM) Encrypt(id string, plaintext []byte) ([]byte, error) {
+	gcm, err := a.aead(id)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	// use the key id as additional data so it can't be swapped
+	return gcm.Seal(nonce, nonce, plaintext, []byte(id)), nil
+}
+
+func (a *aesGCM) Decrypt(id string, ciphertext []byte) ([]byte, error) {
+	gcm, err := a.aead(id)
+	if err != nil {
+		return nil, err
+	}
+
+	n := gcm.NonceSize()
+	if len(ciphertext) < n {
+		return nil, ErrShortCiphertext
+	}
+
+	return gcm.Open(nil, ciphertext[:n], ciphertext[n:], []byte(id))
+}
+
+func (a *aesGCM) String() string {
+	return "aes-gcm"
+}
+
+// AESGCM returns a cipher using AES-GCM with keys from the provider.
+// Keys must be 16, 24 or 32 bytes long.
+func AESGCM(kp KeyProvider) Cipher {
+	return &aesGCM{kp}
+}
@@ -0,0 +1,168 @@
+// Package encrypt provides a codec which encrypts message bodies end-to-end.
+// It wraps an existing codec, encrypting whatever it writes and decrypting
+// what it reads so that brokers and transports only see ciphertext.
+package encrypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/micro/go-micro/codec"
+)
+
+const (
+	// KeyIdHeader is the header set to the id of the key used to encrypt the body
+	KeyIdHeader = "X-Encryption-Key-Id"
+	// CipherHeader is the header set to the cipher used to encrypt the body
+	CipherHeader = "X-Encryption-Cipher"
+)
+
+var (
+	ErrBadFrame = errors.New("bad encrypted frame")
+	ErrNoCipher = errors.New("no cipher configured")
+)
+
+type encryptCodec struct {
+	rwc  io.ReadWriteCloser
+	opts Options
+
+	// the wrapped codec reads and writes plaintext here
+	rbuf *bytes.Buffer
+	wbuf *bytes.Buffer
+	c    codec.Codec
+}
+
+type buffer struct {
+	r *bytes.Buffer
+	w *bytes.Buffer
+}
+
+func (b *buffer) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
+
+func (b *buffer) Close() error {
+	return nil
+}
+
+func (e *encryptCodec) Close() error {
+	e.c.Close()
+	return e.rwc.Close()
+}
+
+func (e *encryptCodec) String() string {
+	return "encrypt-" + e.c.String()
+}
+
+func (e *encryptCodec) ReadHeader(m *codec.Message, mt codec.MessageType) error {
+	if e.opts.Cipher == nil {
+		return ErrNoCipher
+	}
+
+	e.rbuf.Reset()
+
+	frame, err := ioutil.ReadAll(e.rwc)
+	if err != nil {
+		return err
+	}
+
+	if len(frame) > 0 {
+		id, ciphertext, err := unframe(frame)
+		if err != nil {
+			return err
+		}
+
+		plaintext, err := e.opts.Cipher.Decrypt(id, ciphertext)
+		if err != nil {
+			return err
+		}
+
+		e.rbuf.Write(plaintext)
+	}
+
+	return e.c.ReadHeader(m, mt)
+}
+
+func (e *encryptCodec) ReadBody(b interface{}) error {
+	return e.c.ReadBody(b)
+}
+
+func (e *encryptCodec) Write(m *codec.Message, b interface{}) error {
+	if e.opts.Cipher == nil {
+		return ErrNoCipher
+	}
+
+	e.wbuf.Reset()
+
+	if err := e.c.Write(m, b); err != nil {
+		return err
+	}
+
+	id := e.opts.Cipher.KeyId()
+
+	ciphertext, err := e.opts.Cipher.Encrypt(id, e.wbuf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// headers aren't available for all message types
+	if m.Header != nil {
+		m.Header[KeyIdHeader] = id
+		m.Header[CipherHeader] = e.opts.Cipher.String()
+	}
+
+	_, err = e.rwc.Write(frame(id, ciphertext))
+	return err
+}
+
+// frame prefixes the ciphertext with the key id so the body
+// can be decrypted where headers are not carried
+func frame(id string, ciphertext []byte) []byte {
+	b := make([]byte, 2+len(id)+len(ciphertext))
+	binary.BigEndian.PutUint16(b, uint16(len(id)))
+	copy(b[2:], id)
+	copy(b[2+len(id):], ciphertext)
+	return b
+}
+
+func unframe(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, ErrBadFrame
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, ErrBadFrame
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+// NewCodec wraps the codec so that message bodies are encrypted
+// with the configured cipher before being written to the wire.
+// Without a cipher the codec returns ErrNoCipher rather than
+// sending plaintext.
+func NewCodec(c codec.NewCodec, opts ...Option) codec.NewCodec {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return func(rwc io.ReadWriteCloser) codec.Codec {
+		rbuf := bytes.NewBuffer(nil)
+		wbuf := bytes.NewBuffer(nil)
+
+		return &encryptCodec{
+			rwc:  rwc,
+			opts: options,
+			rbuf: rbuf,
+			wbuf: wbuf,
+			c:    c(&buffer{r: rbuf, w: wbuf}),
+		}
+	}
+}
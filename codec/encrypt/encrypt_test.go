@@ -0,0 +1,102 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/micro/go-micro/codec"
+	"github.com/micro/go-micro/codec/jsonrpc"
+)
+
+type buf struct {
+	*bytes.Buffer
+}
+
+func (b *buf) Close() error {
+	return nil
+}
+
+func TestEncryptCodec(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 32))
+	os.Setenv("TEST_KEY_V1", key)
+	defer os.Unsetenv("TEST_KEY_V1")
+
+	cf := NewCodec(jsonrpc.NewCodec, WithCipher(AESGCM(EnvProvider("TEST_KEY_", "v1"))))
+
+	rwc := &buf{bytes.NewBuffer(nil)}
+
+	m := &codec.Message{
+		Id:     1,
+		Type:   codec.Request,
+		Method: "Test.Method",
+		Header: map[string]string{},
+	}
+
+	if err := cf(rwc).Write(m, map[string]string{"foo": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Header[KeyIdHeader] != "v1" {
+		t.Fatalf("expected key id v1 got %s", m.Header[KeyIdHeader])
+	}
+
+	if bytes.Contains(rwc.Bytes(), []byte("bar")) {
+		t.Fatal("body was not encrypted")
+	}
+
+	c := cf(rwc)
+
+	var rm codec.Message
+	if err := c.ReadHeader(&rm, codec.Request); err != nil {
+		t.Fatal(err)
+	}
+
+	if rm.Method != m.Method {
+		t.Fatalf("expected method %s got %s", m.Method, rm.Method)
+	}
+
+	var body map[string]string
+	if err := c.ReadBody(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body["foo"] != "bar" {
+		t.Fatalf("expected bar got %s", body["foo"])
+	}
+}
+
+func TestNoCipher(t *testing.T) {
+	c := NewCodec(jsonrpc.NewCodec)(&buf{bytes.NewBuffer(nil)})
+
+	m := &codec.Message{
+		Id:     1,
+		Type:   codec.Request,
+		Method: "Test.Method",
+		Header: map[string]string{},
+	}
+
+	if err := c.Write(m, map[string]string{"foo": "bar"}); err != ErrNoCipher {
+		t.Fatalf("expected %v got %v", ErrNoCipher, err)
+	}
+
+	var rm codec.Message
+	if err := c.ReadHeader(&rm, codec.Request); err != ErrNoCipher {
+		t.Fatalf("expected %v got %v", ErrNoCipher, err)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	id, b, err := unframe(frame("key", []byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "key" || string(b) != "data" {
+		t.Fatalf("unexpected frame %s %s", id, b)
+	}
+
+	if _, _, err := unframe([]byte{0, 10, 'a'}); err != ErrBadFrame {
+		t.Fatalf("expected %v got %v", ErrBadFrame, err)
+	}
+}
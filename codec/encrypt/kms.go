@@ -0,0 +1,60 @@
+package encrypt
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+type kmsProvider struct {
+	svc     *kms.KMS
+	current string
+	blobs   map[string][]byte
+
+	sync.RWMutex
+	keys map[string][]byte
+}
+
+func (k *kmsProvider) Current() string {
+	return k.current
+}
+
+func (k *kmsProvider) Key(id string) ([]byte, error) {
+	k.RLock()
+	key, ok := k.keys[id]
+	k.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	blob, ok := k.blobs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %s", id)
+	}
+
+	rsp, err := k.svc.Decrypt(&kms.DecryptInput{
+		CiphertextBlob: blob,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k.Lock()
+	k.keys[id] = rsp.Plaintext
+	k.Unlock()
+
+	return rsp.Plaintext, nil
+}
+
+// KMSProvider decrypts data keys with AWS KMS. The blobs are the encrypted
+// data keys, as returned by GenerateDataKey, indexed by key id. Plaintext
+// keys are cached after the first use.
+func KMSProvider(svc *kms.KMS, current string, blobs map[string][]byte) KeyProvider {
+	return &kmsProvider{
+		svc:     svc,
+		current: current,
+		blobs:   blobs,
+		keys:    make(map[string][]byte),
+	}
+}
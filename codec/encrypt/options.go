@@ -0,0 +1,16 @@
+package encrypt
+
+// Options configures the encrypt codec
+type Options struct {
+	// Cipher used to encrypt and decrypt bodies
+	Cipher Cipher
+}
+
+type Option func(*Options)
+
+// WithCipher sets the cipher used to encrypt and decrypt bodies
+func WithCipher(c Cipher) Option {
+	return func(o *Options) {
+		o.Cipher = c
+	}
+}
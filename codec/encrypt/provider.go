@@ -0,0 +1,95 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// KeyProvider looks up encryption keys by id
+type KeyProvider interface {
+	// Current returns the id of the key to encrypt with
+	Current() string
+	// Key returns the key for the id
+	Key(id string) ([]byte, error)
+}
+
+type envProvider struct {
+	prefix  string
+	current string
+}
+
+func (e *envProvider) Current() string {
+	return e.current
+}
+
+func (e *envProvider) Key(id string) ([]byte, error) {
+	name := e.prefix + strings.ToUpper(id)
+	v := os.Getenv(name)
+	if len(v) == 0 {
+		return nil, fmt.Errorf("key %s not found in %s", id, name)
+	}
+	return base64.StdEncoding.DecodeString(v)
+}
+
+// EnvProvider reads base64 encoded keys from environment variables
+// named by the prefix followed by the upper cased key id
+func EnvProvider(prefix, current string) KeyProvider {
+	return &envProvider{prefix, current}
+}
+
+type fileProvider struct {
+	dir     string
+	current string
+
+	sync.RWMutex
+	keys map[string][]byte
+}
+
+func (f *fileProvider) Current() string {
+	return f.current
+}
+
+func (f *fileProvider) Key(id string) ([]byte, error) {
+	f.RLock()
+	key, ok := f.keys[id]
+	f.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	// guard against ids escaping the directory
+	if filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid key id %s", id)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(f.dir, id))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	f.keys[id] = key
+	f.Unlock()
+
+	return key, nil
+}
+
+// FileProvider reads base64 encoded keys from files in the directory
+// named by key id. Keys are cached once read.
+func FileProvider(dir, current string) KeyProvider {
+	return &fileProvider{
+		dir:     dir,
+		current: current,
+		keys:    make(map[string][]byte),
+	}
+}
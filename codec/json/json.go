@@ -0,0 +1,165 @@
+// Package json provides a json codec with streaming and protojson options
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/micro/go-micro/codec"
+)
+
+type jsonCodec struct {
+	rwc  io.ReadWriteCloser
+	opts Options
+	mt   codec.MessageType
+
+	m *jsonpb.Marshaler
+	u *jsonpb.Unmarshaler
+
+	// used in stream mode
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+func (c *jsonCodec) Close() error {
+	return c.rwc.Close()
+}
+
+func (c *jsonCodec) String() string {
+	return "json"
+}
+
+func (c *jsonCodec) ReadHeader(m *codec.Message, mt codec.MessageType) error {
+	switch mt {
+	case codec.Request, codec.Response, codec.Publication:
+		c.mt = mt
+	default:
+		return fmt.Errorf("Unrecognised message type: %v", mt)
+	}
+	return nil
+}
+
+func (c *jsonCodec) ReadBody(b interface{}) error {
+	if c.opts.Stream {
+		return c.decode(b)
+	}
+
+	data, err := ioutil.ReadAll(c.rwc)
+	if err != nil {
+		return err
+	}
+	if b == nil || len(data) == 0 {
+		return nil
+	}
+	if pb, ok := b.(proto.Message); ok {
+		return c.u.Unmarshal(bytes.NewReader(data), pb)
+	}
+	return json.Unmarshal(data, b)
+}
+
+func (c *jsonCodec) Write(m *codec.Message, b interface{}) error {
+	switch m.Type {
+	case codec.Request, codec.Response, codec.Publication:
+	default:
+		return fmt.Errorf("Unrecognised message type: %v", m.Type)
+	}
+
+	if b == nil {
+		return nil
+	}
+
+	if c.opts.Stream {
+		return c.encode(b)
+	}
+
+	if pb, ok := b.(proto.Message); ok {
+		s, err := c.m.MarshalToString(pb)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(c.rwc, s)
+		return err
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = c.rwc.Write(data)
+	return err
+}
+
+// encode writes the value straight to the connection
+func (c *jsonCodec) encode(b interface{}) error {
+	if pb, ok := b.(proto.Message); ok {
+		return c.m.Marshal(c.rwc, pb)
+	}
+	return c.enc.Encode(b)
+}
+
+// decode reads the next value straight from the connection
+func (c *jsonCodec) decode(b interface{}) error {
+	if b == nil {
+		var raw json.RawMessage
+		if err := c.dec.Decode(&raw); err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	}
+	if pb, ok := b.(proto.Message); ok {
+		err := c.u.UnmarshalNext(c.dec, pb)
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	if err := c.dec.Decode(b); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// NewCodec returns a json codec with the default options
+func NewCodec(rwc io.ReadWriteCloser) codec.Codec {
+	return newCodec(rwc, Options{})
+}
+
+// New returns a codec constructor configured with the given options
+// for use with client.Codec and server.Codec
+func New(opts ...Option) codec.NewCodec {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return func(rwc io.ReadWriteCloser) codec.Codec {
+		return newCodec(rwc, options)
+	}
+}
+
+func newCodec(rwc io.ReadWriteCloser, opts Options) codec.Codec {
+	c := &jsonCodec{
+		rwc:  rwc,
+		opts: opts,
+		m: &jsonpb.Marshaler{
+			OrigName:     opts.OrigName,
+			EnumsAsInts:  opts.EnumsAsInts,
+			EmitDefaults: opts.EmitDefaults,
+		},
+		u: &jsonpb.Unmarshaler{
+			AllowUnknownFields: opts.DiscardUnknown,
+		},
+	}
+
+	if opts.Stream {
+		c.enc = json.NewEncoder(rwc)
+		c.dec = json.NewDecoder(rwc)
+	}
+
+	return c
+}
@@ -0,0 +1,61 @@
+package json
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/micro/go-micro/codec"
+)
+
+type buffer struct {
+	*bytes.Buffer
+}
+
+func (b *buffer) Close() error {
+	return nil
+}
+
+type testBody struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestCodec(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		rwc := &buffer{bytes.NewBuffer(nil)}
+		c := New(Stream(stream))(rwc)
+
+		in := &testBody{Name: "foo", Count: 3}
+		if err := c.Write(&codec.Message{Type: codec.Request}, in); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.ReadHeader(&codec.Message{}, codec.Request); err != nil {
+			t.Fatal(err)
+		}
+
+		out := new(testBody)
+		if err := c.ReadBody(out); err != nil {
+			t.Fatal(err)
+		}
+
+		if *in != *out {
+			t.Fatalf("stream %v: expected %+v got %+v", stream, in, out)
+		}
+	}
+}
+
+func TestCodecNilBody(t *testing.T) {
+	rwc := &buffer{bytes.NewBufferString(`{"name":"foo"}`)}
+	c := New(Stream(true))(rwc)
+
+	if err := c.ReadHeader(&codec.Message{}, codec.Publication); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ReadBody(nil); err != nil {
+		t.Fatal(err)
+	}
+	if rwc.Len() != 0 {
+		t.Fatalf("expected body to be consumed, %d bytes left", rwc.Len())
+	}
+}
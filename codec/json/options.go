@@ -0,0 +1,54 @@
+package json
+
+// Options configures the json codec
+type Options struct {
+	// Stream encodes and decodes directly to and from the
+	// underlying connection rather than through a buffer
+	Stream bool
+	// OrigName uses the original proto field names rather
+	// than the lowerCamelCase json names
+	OrigName bool
+	// EnumsAsInts encodes enums as ints rather than strings
+	EnumsAsInts bool
+	// EmitDefaults encodes fields holding zero values
+	EmitDefaults bool
+	// DiscardUnknown ignores unknown fields when decoding
+	DiscardUnknown bool
+}
+
+type Option func(*Options)
+
+// Stream enables encoding and decoding without an intermediate buffer
+func Stream(b bool) Option {
+	return func(o *Options) {
+		o.Stream = b
+	}
+}
+
+// OrigName uses the proto field names as the json keys
+func OrigName(b bool) Option {
+	return func(o *Options) {
+		o.OrigName = b
+	}
+}
+
+// EnumsAsInts encodes proto enums as their integer values
+func EnumsAsInts(b bool) Option {
+	return func(o *Options) {
+		o.EnumsAsInts = b
+	}
+}
+
+// EmitDefaults encodes zero value fields
+func EmitDefaults(b bool) Option {
+	return func(o *Options) {
+		o.EmitDefaults = b
+	}
+}
+
+// DiscardUnknown ignores fields unknown to the proto message when decoding
+func DiscardUnknown(b bool) Option {
+	return func(o *Options) {
+		o.DiscardUnknown = b
+	}
+}
@@ -13,6 +13,24 @@ type msgpackCodec struct {
 	rwc  io.ReadWriteCloser
 	mt   codec.MessageType
 	body bool
+	opts Options
+}
+
+// Options configures the msgpack codec
+type Options struct {
+	// ZeroCopy decodes bodies which satisfy msgp.Unmarshaler from a
+	// single raw buffer so that string and bytes fields can reference
+	// it rather than being copied
+	ZeroCopy bool
+}
+
+type Option func(*Options)
+
+// ZeroCopy enables zero-copy decoding of message bodies
+func ZeroCopy(b bool) Option {
+	return func(o *Options) {
+		o.ZeroCopy = b
+	}
 }
 
 func (c *msgpackCodec) Close() error {
@@ -83,6 +101,9 @@ func (c *msgpackCodec) ReadBody(v interface{}) error {
 
 	switch c.mt {
 	case codec.Request, codec.Response, codec.Publication:
+		if c.opts.ZeroCopy {
+			return decodeBodyZC(r, v)
+		}
 		return decodeBody(r, v)
 	default:
 		return fmt.Errorf("Unrecognized message type: %v", c.mt)
@@ -130,3 +151,18 @@ func NewCodec(rwc io.ReadWriteCloser) codec.Codec {
 		rwc: rwc,
 	}
 }
+
+// New returns a codec constructor configured with the given options
+func New(opts ...Option) codec.NewCodec {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return func(rwc io.ReadWriteCloser) codec.Codec {
+		return &msgpackCodec{
+			rwc:  rwc,
+			opts: options,
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package msgpackrpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Extension types in the range [-128, -1] are reserved by the msgpack
+// specification and the types below are claimed by this package.
+const (
+	// UUIDExtension is the extension type used for UUID values
+	UUIDExtension int8 = 10
+	// DecimalExtension is the extension type used for Decimal values
+	DecimalExtension int8 = 11
+)
+
+var (
+	ErrReservedExtension   = errors.New("Reserved extension type")
+	ErrDuplicateExtension  = errors.New("Extension type already registered")
+	ErrBadExtensionLength  = errors.New("Bad extension length")
+	ErrNilExtensionFactory = errors.New("Nil extension factory")
+)
+
+var (
+	extMu      sync.RWMutex
+	extensions = map[int8]func() msgp.Extension{}
+)
+
+func init() {
+	RegisterExtension(UUIDExtension, func() msgp.Extension { return new(UUID) })
+	RegisterExtension(DecimalExtension, func() msgp.Extension { return new(Decimal) })
+}
+
+// RegisterExtension registers an application extension type so that it
+// can be decoded when found inside an interface{} value. Types below zero
+// are reserved by the msgpack spec and may not be registered. Time values
+// do not need registering; msgp encodes time.Time natively.
+func RegisterExtension(typ int8, f func() msgp.Extension) error {
+	if typ < 0 {
+		return ErrReservedExtension
+	}
+	if f == nil {
+		return ErrNilExtensionFactory
+	}
+
+	extMu.Lock()
+	defer extMu.Unlock()
+
+	if _, ok := extensions[typ]; ok {
+		return ErrDuplicateExtension
+	}
+
+	extensions[typ] = f
+	msgp.RegisterExtension(typ, f)
+	return nil
+}
+
+// Extensions returns the extension types registered through this package
+func Extensions() []int8 {
+	extMu.RLock()
+	defer extMu.RUnlock()
+
+	types := make([]int8, 0, len(extensions))
+	for typ := range extensions {
+		types = append(types, typ)
+	}
+	return types
+}
+
+// UUID is a 16 byte UUID encoded as a msgpack extension
+type UUID [16]byte
+
+func (u *UUID) ExtensionType() int8 {
+	return UUIDExtension
+}
+
+func (u *UUID) Len() int {
+	return len(u)
+}
+
+func (u *UUID) MarshalBinaryTo(b []byte) error {
+	copy(b, u[:])
+	return nil
+}
+
+func (u *UUID) UnmarshalBinary(b []byte) error {
+	if len(b) != len(u) {
+		return ErrBadExtensionLength
+	}
+	copy(u[:], b)
+	return nil
+}
+
+// Decimal is an arbitrary precision decimal, the unscaled value
+// multiplied by ten to the power of minus the scale. It's encoded
+// as the scale, the sign and the big endian magnitude.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// NewDecimal returns the decimal unscaled * 10^-scale
+func NewDecimal(unscaled *big.Int, scale int32) *Decimal {
+	return &Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+func (d *Decimal) ExtensionType() int8 {
+	return DecimalExtension
+}
+
+func (d *Decimal) unscaled() *big.Int {
+	if d.Unscaled == nil {
+		return new(big.Int)
+	}
+	return d.Unscaled
+}
+
+func (d *Decimal) Len() int {
+	return 5 + len(d.unscaled().Bytes())
+}
+
+func (d *Decimal) MarshalBinaryTo(b []byte) error {
+	u := d.unscaled()
+	binary.BigEndian.PutUint32(b, uint32(d.Scale))
+	b[4] = 0
+	if u.Sign() < 0 {
+		b[4] = 1
+	}
+	copy(b[5:], u.Bytes())
+	return nil
+}
+
+func (d *Decimal) UnmarshalBinary(b []byte) error {
+	if len(b) < 5 || b[4] > 1 {
+		return ErrBadExtensionLength
+	}
+	d.Scale = int32(binary.BigEndian.Uint32(b))
+	d.Unscaled = new(big.Int).SetBytes(b[5:])
+	if b[4] == 1 {
+		d.Unscaled.Neg(d.Unscaled)
+	}
+	return nil
+}
+
+// String returns the decimal in plain notation e.g. -12.345
+func (d *Decimal) String() string {
+	u := d.unscaled()
+	if d.Scale <= 0 {
+		return new(big.Int).Mul(u, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-d.Scale)), nil)).String()
+	}
+
+	digits := new(big.Int).Abs(u).String()
+	if n := int(d.Scale) + 1 - len(digits); n > 0 {
+		digits = strings.Repeat("0", n) + digits
+	}
+
+	s := digits[:len(digits)-int(d.Scale)] + "." + digits[len(digits)-int(d.Scale):]
+	if u.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
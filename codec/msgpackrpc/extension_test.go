@@ -0,0 +1,114 @@
+package msgpackrpc
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestRegisterExtension(t *testing.T) {
+	if err := RegisterExtension(-1, func() msgp.Extension { return new(UUID) }); err != ErrReservedExtension {
+		t.Fatalf("expected %v got %v", ErrReservedExtension, err)
+	}
+
+	if err := RegisterExtension(UUIDExtension, func() msgp.Extension { return new(UUID) }); err != ErrDuplicateExtension {
+		t.Fatalf("expected %v got %v", ErrDuplicateExtension, err)
+	}
+}
+
+func TestUUIDExtension(t *testing.T) {
+	u1 := UUID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	var buf bytes.Buffer
+	w := msgp.NewWriter(&buf)
+	if err := w.WriteExtension(&u1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var u2 UUID
+	if err := msgp.NewReader(&buf).ReadExtension(&u2); err != nil {
+		t.Fatal(err)
+	}
+
+	if u1 != u2 {
+		t.Errorf("expected %v got %v", u1, u2)
+	}
+}
+
+func TestDecimalExtension(t *testing.T) {
+	testData := []struct {
+		unscaled int64
+		scale    int32
+		str      string
+	}{
+		{-12345, 3, "-12.345"},
+		{5, 2, "0.05"},
+		{42, 0, "42"},
+		{7, -2, "700"},
+		{0, 1, "0.0"},
+	}
+
+	for _, d := range testData {
+		d1 := NewDecimal(big.NewInt(d.unscaled), d.scale)
+
+		var buf bytes.Buffer
+		w := msgp.NewWriter(&buf)
+		if err := w.WriteExtension(d1); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		var d2 Decimal
+		if err := msgp.NewReader(&buf).ReadExtension(&d2); err != nil {
+			t.Fatal(err)
+		}
+
+		if d2.Unscaled.Int64() != d.unscaled || d2.Scale != d.scale {
+			t.Errorf("expected %v got %v", d1, &d2)
+		}
+		if s := d2.String(); s != d.str {
+			t.Errorf("expected %s got %s", d.str, s)
+		}
+	}
+}
+
+func TestDecodeBodyZC(t *testing.T) {
+	// a body larger than the first peek
+	body := msgp.AppendString(nil, string(bytes.Repeat([]byte("a"), minPeek*3)))
+	data := append(append([]byte{}, body...), msgp.AppendString(nil, "next")...)
+
+	var raw msgp.Raw
+	if err := decodeBodyZC(msgp.NewReader(bytes.NewReader(data)), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, body) {
+		t.Fatalf("expected the body of %d bytes got %d", len(body), len(raw))
+	}
+
+	// a truncated body
+	if err := decodeBodyZC(msgp.NewReader(bytes.NewReader(body[:minPeek])), &raw); err == nil {
+		t.Fatal("expected an error for a truncated body")
+	}
+}
+
+func TestReadStringZC(t *testing.T) {
+	b := msgp.AppendString(nil, "hello")
+
+	s, o, err := ReadStringZC(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello" {
+		t.Errorf("expected hello got %s", s)
+	}
+	if len(o) != 0 {
+		t.Errorf("expected no remaining bytes got %d", len(o))
+	}
+}
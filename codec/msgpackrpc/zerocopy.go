@@ -0,0 +1,59 @@
+package msgpackrpc
+
+import (
+	"io"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// minPeek is the size of the first read of a zero-copy body
+const minPeek = 512
+
+// decodeBodyZC reads the raw body and unmarshals it in place. The body is
+// sliced from the buffer of the reader, which is created per body and
+// discarded, so the values decoded may reference it rather than copies.
+func decodeBodyZC(r *msgp.Reader, v interface{}) error {
+	u, ok := v.(msgp.Unmarshaler)
+	if !ok {
+		return decodeBody(r, v)
+	}
+
+	// peek more of the stream until it holds the whole body
+	for n := minPeek; ; n *= 2 {
+		b, err := r.R.Peek(n)
+
+		rest, serr := msgp.Skip(b)
+		if serr == msgp.ErrShortBytes {
+			if err != nil {
+				return io.ErrUnexpectedEOF
+			}
+			continue
+		} else if serr != nil {
+			return serr
+		}
+
+		size := len(b) - len(rest)
+		if _, err := u.UnmarshalMsg(b[:size]); err != nil {
+			return err
+		}
+		_, err = r.R.Skip(size)
+		return err
+	}
+}
+
+// ReadStringZC reads a string from b without copying it. The returned
+// string shares memory with b and is only valid while b is unmodified.
+// It's intended for use in hand written UnmarshalMsg methods.
+func ReadStringZC(b []byte) (string, []byte, error) {
+	v, o, err := msgp.ReadStringZC(b)
+	if err != nil {
+		return "", b, err
+	}
+	return msgp.UnsafeString(v), o, nil
+}
+
+// ReadBytesZC reads a byte slice from b without copying it. The returned
+// slice shares memory with b.
+func ReadBytesZC(b []byte) ([]byte, []byte, error) {
+	return msgp.ReadBytesZC(b)
+}
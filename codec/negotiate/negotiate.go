@@ -0,0 +1,100 @@
+// Package negotiate provides codec content negotiation between clients and servers.
+// Servers advertise the content types they support in their registry node metadata
+// and clients pick the best match for each node they call.
+package negotiate
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/micro/go-micro/registry"
+)
+
+const (
+	// MetadataKey is the node metadata key holding the advertised content types
+	MetadataKey = "codecs"
+)
+
+var (
+	// DefaultPreferences is the order in which content types are
+	// tried when the requested type isn't supported by the node
+	DefaultPreferences = []string{
+		"application/grpc+proto",
+		"application/protobuf",
+		"application/proto",
+		"application/grpc+json",
+		"application/json",
+		"application/octet-stream",
+	}
+)
+
+// Advertise sets the supported content types on the metadata
+func Advertise(md map[string]string, contentTypes []string) {
+	if md == nil || len(contentTypes) == 0 {
+		return
+	}
+
+	cts := make([]string, len(contentTypes))
+	copy(cts, contentTypes)
+	sort.Strings(cts)
+
+	md[MetadataKey] = strings.Join(cts, ",")
+}
+
+// Supported returns the content types advertised by the node.
+// Nil is returned if the node doesn't advertise any.
+func Supported(node *registry.Node) []string {
+	if node == nil || node.Metadata == nil {
+		return nil
+	}
+
+	v, ok := node.Metadata[MetadataKey]
+	if !ok || len(v) == 0 {
+		return nil
+	}
+
+	var cts []string
+	for _, ct := range strings.Split(v, ",") {
+		if ct = strings.TrimSpace(ct); len(ct) > 0 {
+			cts = append(cts, ct)
+		}
+	}
+	return cts
+}
+
+// Select returns the content type to use when calling the node. The
+// requested content type is kept if the node supports it or doesn't
+// advertise anything. Otherwise the first of the preferences which the
+// node supports and the client can encode, as reported by ok, is used.
+func Select(node *registry.Node, contentType string, prefs []string, ok func(string) bool) string {
+	supported := Supported(node)
+	if len(supported) == 0 {
+		return contentType
+	}
+
+	set := make(map[string]bool, len(supported))
+	for _, ct := range supported {
+		set[ct] = true
+	}
+
+	if set[contentType] {
+		return contentType
+	}
+
+	if len(prefs) == 0 {
+		prefs = DefaultPreferences
+	}
+
+	for _, ct := range prefs {
+		if !set[ct] {
+			continue
+		}
+		if ok != nil && !ok(ct) {
+			continue
+		}
+		return ct
+	}
+
+	// nothing in common, let the server reject it
+	return contentType
+}
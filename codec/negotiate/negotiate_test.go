@@ -0,0 +1,52 @@
+package negotiate
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestSelect(t *testing.T) {
+	md := map[string]string{}
+	Advertise(md, []string{"application/json", "application/grpc+json"})
+
+	node := &registry.Node{Metadata: md}
+
+	testData := []struct {
+		node     *registry.Node
+		ct       string
+		prefs    []string
+		expected string
+	}{
+		// no metadata
+		{&registry.Node{}, "application/grpc+proto", nil, "application/grpc+proto"},
+		// supported
+		{node, "application/json", nil, "application/json"},
+		// fallback to default preferences
+		{node, "application/grpc+proto", nil, "application/grpc+json"},
+		// fallback to client preferences
+		{node, "application/grpc+proto", []string{"application/json"}, "application/json"},
+		// nothing in common
+		{node, "application/grpc+proto", []string{"application/protobuf"}, "application/grpc+proto"},
+	}
+
+	for _, d := range testData {
+		if ct := Select(d.node, d.ct, d.prefs, nil); ct != d.expected {
+			t.Fatalf("expected %s got %s", d.expected, ct)
+		}
+	}
+}
+
+func TestSelectEncodable(t *testing.T) {
+	md := map[string]string{}
+	Advertise(md, []string{"application/json", "application/grpc+json"})
+
+	ok := func(ct string) bool {
+		return ct == "application/json"
+	}
+
+	ct := Select(&registry.Node{Metadata: md}, "application/grpc+proto", nil, ok)
+	if ct != "application/json" {
+		t.Fatalf("expected application/json got %s", ct)
+	}
+}
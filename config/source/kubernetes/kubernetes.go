@@ -0,0 +1,123 @@
+// Package kubernetes is a config source reading Kubernetes config maps and
+// secrets. Multiple resources are merged by priority and changes are watched
+// so services reload their config on kubectl apply.
+package kubernetes
+
+import (
+	"sort"
+	"time"
+
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-plugins/config/source/util"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	"github.com/micro/go-plugins/registry/kubernetes/client/api"
+)
+
+type kubernetes struct {
+	opts      source.Options
+	client    client.Kubernetes
+	resources []resource
+}
+
+func (k *kubernetes) watching(name string) bool {
+	for _, r := range k.resources {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *kubernetes) read(r resource) (map[string]interface{}, error) {
+	data := make(map[string][]byte)
+
+	if r.secret {
+		s, err := k.client.GetSecret(r.name)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range s.Data {
+			data[k] = v
+		}
+	} else {
+		cm, err := k.client.GetConfigMap(r.name)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+	}
+
+	return values(data), nil
+}
+
+func (k *kubernetes) Read() (*source.ChangeSet, error) {
+	data := make(map[string]interface{})
+
+	// resources are sorted by priority so higher priorities are merged last
+	for _, r := range k.resources {
+		v, err := k.read(r)
+		if err == api.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		merge(data, v)
+	}
+
+	b, err := k.opts.Encoder.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Format:    k.opts.Encoder.String(),
+		Source:    k.String(),
+		Data:      b,
+	}
+	cs.Checksum = cs.Sum()
+
+	return cs, nil
+}
+
+func (k *kubernetes) Watch() (source.Watcher, error) {
+	return newWatcher(k)
+}
+
+func (k *kubernetes) String() string {
+	return "kubernetes"
+}
+
+// NewSource returns a config source reading the config maps and secrets
+// set with ConfigMap and Secret
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	var c client.Kubernetes
+	var resources []resource
+
+	if options.Context != nil {
+		resources, _ = options.Context.Value(resourcesKey{}).([]resource)
+		c, _ = options.Context.Value(clientKey{}).(client.Kubernetes)
+
+		if host, ok := options.Context.Value(hostKey{}).(string); ok && c == nil {
+			c = client.NewClientByHost(host)
+		}
+	}
+
+	if c == nil {
+		c = client.NewClientInCluster()
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].priority < resources[j].priority
+	})
+
+	return &kubernetes{
+		opts:      options,
+		client:    c,
+		resources: resources,
+	}
+}
@@ -0,0 +1,201 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	"github.com/micro/go-plugins/registry/kubernetes/client/mock"
+	"github.com/micro/go-plugins/registry/kubernetes/client/watch"
+)
+
+// testWatch is a watch ended by closing its results
+type testWatch struct {
+	results chan watch.Event
+	once    sync.Once
+}
+
+func (w *testWatch) ResultChan() <-chan watch.Event {
+	return w.results
+}
+
+func (w *testWatch) Stop() {
+	w.once.Do(func() {
+		close(w.results)
+	})
+}
+
+// testClient returns a new watch for every call
+type testClient struct {
+	*mock.Client
+
+	sync.Mutex
+	watches []*testWatch
+}
+
+func (c *testClient) WatchConfigMaps(labels map[string]string) (watch.Watch, error) {
+	c.Lock()
+	defer c.Unlock()
+	w := &testWatch{results: make(chan watch.Event)}
+	c.watches = append(c.watches, w)
+	return w, nil
+}
+
+func (c *testClient) watch(i int) *testWatch {
+	c.Lock()
+	defer c.Unlock()
+	if i < len(c.watches) {
+		return c.watches[i]
+	}
+	return nil
+}
+
+func TestSource(t *testing.T) {
+	c := mock.NewClient()
+
+	c.ConfigMaps["defaults"] = &client.ConfigMap{
+		Metadata: &client.Meta{Name: "defaults"},
+		Data: map[string]string{
+			"config.yaml": "database:\n  host: localhost\n  port: 5432\n",
+			"log.level":   "info",
+		},
+	}
+	c.Secrets["db"] = &client.Secret{
+		Metadata: &client.Meta{Name: "db"},
+		Data: map[string][]byte{
+			"database.host":     []byte("db.prod"),
+			"database.password": []byte("secret"),
+		},
+	}
+
+	s := NewSource(
+		Client(c),
+		Secret("db", 10),
+		ConfigMap("defaults", 0),
+	)
+
+	cs, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data struct {
+		Database struct {
+			Host     string `json:"host"`
+			Port     int    `json:"port"`
+			Password string `json:"password"`
+		} `json:"database"`
+		Log struct {
+			Level string `json:"level"`
+		} `json:"log"`
+	}
+
+	if err := json.Unmarshal(cs.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Database.Host != "db.prod" {
+		t.Fatalf("expected the secret to override the host, got %s", data.Database.Host)
+	}
+	if data.Database.Port != 5432 || data.Database.Password != "secret" || data.Log.Level != "info" {
+		t.Fatalf("unexpected config %+v", data)
+	}
+
+	w, err := s.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	go c.UpdateConfigMap(&client.ConfigMap{
+		Metadata: &client.Meta{Name: "defaults"},
+		Data:     map[string]string{"log.level": "debug"},
+	})
+
+	done := make(chan bool)
+	go func() {
+		cs, err := w.Next()
+		if err != nil {
+			t.Error(err)
+		}
+		if err := json.Unmarshal(cs.Data, &data); err != nil {
+			t.Error(err)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected a change")
+	}
+
+	if data.Log.Level != "debug" {
+		t.Fatalf("expected debug got %s", data.Log.Level)
+	}
+}
+
+func TestRewatch(t *testing.T) {
+	c := &testClient{Client: mock.NewClient()}
+	c.ConfigMaps["defaults"] = &client.ConfigMap{
+		Metadata: &client.Meta{Name: "defaults"},
+		Data:     map[string]string{"log.level": "info"},
+	}
+
+	s := NewSource(Client(c), ConfigMap("defaults", 0))
+
+	w, err := s.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	ch := make(chan string, 1)
+	go func() {
+		cs, err := w.Next()
+		if err != nil {
+			return
+		}
+		var data struct {
+			Log struct {
+				Level string `json:"level"`
+			} `json:"log"`
+		}
+		json.Unmarshal(cs.Data, &data)
+		ch <- data.Log.Level
+	}()
+
+	// events for unchanged resources, e.g the initial events of a watch, aren't sent
+	b, _ := json.Marshal(c.ConfigMaps["defaults"])
+	c.watch(0).results <- watch.Event{Type: watch.Added, Object: json.RawMessage(b)}
+
+	select {
+	case l := <-ch:
+		t.Fatalf("unexpected change %s", l)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	// the api server ends the watch and the config changes meanwhile
+	c.watch(0).Stop()
+	c.Client.Lock()
+	c.ConfigMaps["defaults"] = &client.ConfigMap{
+		Metadata: &client.Meta{Name: "defaults"},
+		Data:     map[string]string{"log.level": "debug"},
+	}
+	c.Client.Unlock()
+
+	select {
+	case l := <-ch:
+		if l != "debug" {
+			t.Fatalf("expected debug got %s", l)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a change once re-watched")
+	}
+
+	if c.watch(1) == nil {
+		t.Fatal("expected the watch to be re-established")
+	}
+}
@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+)
+
+type resourcesKey struct{}
+type clientKey struct{}
+type hostKey struct{}
+
+// resource is a config map or secret read by the source
+type resource struct {
+	name     string
+	secret   bool
+	priority int
+}
+
+func addResource(r resource) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		rs, _ := o.Context.Value(resourcesKey{}).([]resource)
+		o.Context = context.WithValue(o.Context, resourcesKey{}, append(rs, r))
+	}
+}
+
+// ConfigMap reads the named config map. Values from resources with a
+// higher priority override those with a lower one.
+func ConfigMap(name string, priority int) source.Option {
+	return addResource(resource{name: name, priority: priority})
+}
+
+// Secret reads the named secret
+func Secret(name string, priority int) source.Option {
+	return addResource(resource{name: name, secret: true, priority: priority})
+}
+
+// Host sets the api server host, the in cluster config is used by default
+func Host(h string) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, hostKey{}, h)
+	}
+}
+
+// Client sets the kubernetes client
+func Client(c client.Kubernetes) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, clientKey{}, c)
+	}
+}
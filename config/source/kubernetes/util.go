@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/micro/go-plugins/config/source/util"
+)
+
+// document parses keys holding a whole json or yaml document
+func document(key string, value []byte) (map[string]interface{}, bool) {
+	var b []byte
+
+	switch path.Ext(key) {
+	case ".json":
+		b = value
+	case ".yaml", ".yml":
+		j, err := yaml.YAMLToJSON(value)
+		if err != nil {
+			return nil, false
+		}
+		b = j
+	default:
+		return nil, false
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// set sets the value at the dot separated key, creating nested maps
+func set(m map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[p] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// values converts the data of a config map or secret to a config map.
+// Keys with a json or yaml extension are parsed and merged at the root,
+// others are nested by their dot separated path.
+func values(data map[string][]byte) map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range data {
+		if doc, ok := document(k, v); ok {
+			util.Merge(m, doc)
+			continue
+		}
+		set(m, k, string(v))
+	}
+	return m
+}
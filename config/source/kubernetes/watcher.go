@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-log"
+	"github.com/micro/go-plugins/registry/kubernetes/client/watch"
+)
+
+var (
+	// backoff between attempts to re-establish a watch
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+type watcher struct {
+	k    *kubernetes
+	ch   chan *source.ChangeSet
+	exit chan bool
+	once sync.Once
+
+	sync.Mutex
+	// the watches currently open
+	watches map[int]watch.Watch
+	// checksum of the last change set
+	last string
+}
+
+func newWatcher(k *kubernetes) (source.Watcher, error) {
+	w := &watcher{
+		k:       k,
+		ch:      make(chan *source.ChangeSet),
+		exit:    make(chan bool),
+		watches: make(map[int]watch.Watch),
+	}
+
+	if cs, err := k.Read(); err == nil {
+		w.last = cs.Checksum
+	}
+
+	var cms, secrets bool
+	for _, r := range k.resources {
+		if r.secret {
+			secrets = true
+		} else {
+			cms = true
+		}
+	}
+
+	var fns []func() (watch.Watch, error)
+	if cms {
+		fns = append(fns, func() (watch.Watch, error) {
+			return k.client.WatchConfigMaps(nil)
+		})
+	}
+	if secrets {
+		fns = append(fns, func() (watch.Watch, error) {
+			return k.client.WatchSecrets(nil)
+		})
+	}
+
+	// the first watches are opened here so errors are returned
+	for i, fn := range fns {
+		ww, err := fn()
+		if err != nil {
+			w.Stop()
+			return nil, err
+		}
+		w.watches[i] = ww
+	}
+
+	for i, fn := range fns {
+		go w.run(i, w.watches[i], fn)
+	}
+
+	return w, nil
+}
+
+// run re-reads the source when a watched resource changes. The watch is
+// re-established with backoff when it ends, e.g when the api server
+// times it out, and the source is re-read for changes missed meanwhile.
+func (w *watcher) run(i int, ww watch.Watch, fn func() (watch.Watch, error)) {
+	backoff := minBackoff
+
+	for {
+		for e := range ww.ResultChan() {
+			if e.Type == watch.Error {
+				continue
+			}
+
+			var obj struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			}
+			if err := json.Unmarshal(e.Object, &obj); err != nil {
+				continue
+			}
+
+			if !w.k.watching(obj.Metadata.Name) {
+				continue
+			}
+
+			if !w.changed() {
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-w.exit:
+				return
+			case <-time.After(backoff):
+			}
+
+			var err error
+			if ww, err = fn(); err == nil {
+				break
+			}
+
+			log.Logf("[kubernetes] failed to watch config: %v", err)
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		backoff = minBackoff
+
+		w.Lock()
+		select {
+		case <-w.exit:
+			w.Unlock()
+			ww.Stop()
+			return
+		default:
+		}
+		w.watches[i] = ww
+		w.Unlock()
+
+		if !w.changed() {
+			return
+		}
+	}
+}
+
+// changed sends a change set if the source changed since the last one,
+// so the initial events of a watch only send real changes. It returns
+// false once the watcher is stopped.
+func (w *watcher) changed() bool {
+	cs, err := w.k.Read()
+	if err != nil {
+		log.Logf("[kubernetes] failed to read config: %v", err)
+		return true
+	}
+
+	w.Lock()
+	if cs.Checksum == w.last {
+		w.Unlock()
+		return true
+	}
+	w.last = cs.Checksum
+	w.Unlock()
+
+	select {
+	case w.ch <- cs:
+		return true
+	case <-w.exit:
+		return false
+	}
+}
+
+func (w *watcher) Next() (*source.ChangeSet, error) {
+	select {
+	case cs := <-w.ch:
+		return cs, nil
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.once.Do(func() {
+		w.Lock()
+		close(w.exit)
+		for _, ww := range w.watches {
+			ww.Stop()
+		}
+		w.Unlock()
+	})
+	return nil
+}
@@ -0,0 +1,57 @@
+package secretsmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/micro/go-config/source"
+)
+
+type secretsKey struct{}
+
+type secret struct {
+	id  string
+	key string
+}
+type sessionKey struct{}
+type intervalKey struct{}
+type queueKey struct{}
+
+func setOption(k, v interface{}) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// Secret reads the secret with the id or arn. JSON secrets are merged at
+// the key, the root if empty, other secrets are set as a string at the key.
+func Secret(id, key string) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		s, _ := o.Context.Value(secretsKey{}).([]secret)
+		o.Context = context.WithValue(o.Context, secretsKey{}, append(s, secret{id, key}))
+	}
+}
+
+// Session sets the aws session
+func Session(s *session.Session) source.Option {
+	return setOption(sessionKey{}, s)
+}
+
+// Interval sets how often secrets are polled for changes, zero disables polling
+func Interval(d time.Duration) source.Option {
+	return setOption(intervalKey{}, d)
+}
+
+// Queue sets an SQS queue receiving EventBridge events for secret changes,
+// e.g. PutSecretValue and RotationSucceeded. Any message on the queue
+// triggers a refresh.
+func Queue(url string) source.Option {
+	return setOption(queueKey{}, url)
+}
@@ -0,0 +1,141 @@
+// Package secretsmanager is a config source reading AWS Secrets Manager
+package secretsmanager
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-plugins/config/source/util"
+)
+
+var (
+	// DefaultInterval secrets are polled at
+	DefaultInterval = 5 * time.Minute
+)
+
+type smSource struct {
+	opts     source.Options
+	client   *secretsmanager.SecretsManager
+	sqs      *sqs.SQS
+	secrets  []secret
+	queue    string
+	interval time.Duration
+}
+
+// nest places the value under the dot separated key
+func nest(key string, value interface{}) map[string]interface{} {
+	parts := strings.Split(key, ".")
+	m := map[string]interface{}{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		m = map[string]interface{}{parts[i]: m}
+	}
+	return m
+}
+
+func (s *smSource) read(sec secret) (map[string]interface{}, error) {
+	rsp, err := s.client.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(sec.id),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+
+	if rsp.SecretString != nil {
+		var doc map[string]interface{}
+		if err := json.Unmarshal([]byte(*rsp.SecretString), &doc); err == nil {
+			value = doc
+		} else {
+			value = *rsp.SecretString
+		}
+	} else {
+		value = string(rsp.SecretBinary)
+	}
+
+	if len(sec.key) == 0 {
+		doc, ok := value.(map[string]interface{})
+		if !ok {
+			// plain secrets need a key, use the name
+			return nest(aws.StringValue(rsp.Name), value), nil
+		}
+		return doc, nil
+	}
+
+	return nest(sec.key, value), nil
+}
+
+func (s *smSource) Read() (*source.ChangeSet, error) {
+	data := make(map[string]interface{})
+
+	for _, sec := range s.secrets {
+		d, err := s.read(sec)
+		if err != nil {
+			return nil, err
+		}
+		util.Merge(data, d)
+	}
+
+	b, err := s.opts.Encoder.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Format:    s.opts.Encoder.String(),
+		Source:    s.String(),
+		Data:      b,
+	}
+	cs.Checksum = cs.Sum()
+
+	return cs, nil
+}
+
+func (s *smSource) Watch() (source.Watcher, error) {
+	return util.NewPoller("secretsmanager", s.Read, s.interval, s.sqs, s.queue), nil
+}
+
+func (s *smSource) String() string {
+	return "secretsmanager"
+}
+
+// NewSource returns a Secrets Manager config source
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	s := &smSource{
+		opts:     options,
+		interval: DefaultInterval,
+	}
+
+	var sess *session.Session
+
+	if options.Context != nil {
+		s.secrets, _ = options.Context.Value(secretsKey{}).([]secret)
+		if d, ok := options.Context.Value(intervalKey{}).(time.Duration); ok {
+			s.interval = d
+		}
+		s.queue, _ = options.Context.Value(queueKey{}).(string)
+		sess, _ = options.Context.Value(sessionKey{}).(*session.Session)
+	}
+
+	if sess == nil {
+		sess = session.Must(session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		}))
+	}
+
+	s.client = secretsmanager.New(sess)
+	if len(s.queue) > 0 {
+		s.sqs = sqs.New(sess)
+	}
+
+	return s
+}
@@ -0,0 +1,26 @@
+package secretsmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/micro/go-plugins/config/source/util"
+)
+
+func TestNest(t *testing.T) {
+	data := make(map[string]interface{})
+
+	util.Merge(data, nest("database", map[string]interface{}{"host": "db.prod"}))
+	util.Merge(data, nest("database.password", "secret"))
+
+	expected := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host":     "db.prod",
+			"password": "secret",
+		},
+	}
+
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("expected %v got %v", expected, data)
+	}
+}
@@ -0,0 +1,75 @@
+// Package sops decrypts SOPS encrypted config from any source. Values
+// encrypted with age, AWS KMS, GCP KMS or PGP are decrypted at load time so
+// secrets can live in git and still be read through the config api.
+package sops
+
+import (
+	"go.mozilla.org/sops/v3"
+	"go.mozilla.org/sops/v3/decrypt"
+
+	"github.com/micro/go-config/source"
+)
+
+type sopsSource struct {
+	source.Source
+}
+
+type watcher struct {
+	source.Watcher
+}
+
+// decryptChangeSet decrypts the change set in place. Change sets without
+// sops metadata aren't encrypted and are returned as is.
+func decryptChangeSet(cs *source.ChangeSet) (*source.ChangeSet, error) {
+	format := cs.Format
+	if format == "yml" {
+		format = "yaml"
+	}
+
+	b, err := decrypt.Data(cs.Data, format)
+	if err == sops.MetadataNotFound {
+		return cs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	dcs := *cs
+	dcs.Data = b
+	dcs.Checksum = dcs.Sum()
+	return &dcs, nil
+}
+
+func (s *sopsSource) Read() (*source.ChangeSet, error) {
+	cs, err := s.Source.Read()
+	if err != nil {
+		return nil, err
+	}
+	return decryptChangeSet(cs)
+}
+
+func (s *sopsSource) Watch() (source.Watcher, error) {
+	w, err := s.Source.Watch()
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{w}, nil
+}
+
+func (s *sopsSource) String() string {
+	return "sops+" + s.Source.String()
+}
+
+func (w *watcher) Next() (*source.ChangeSet, error) {
+	cs, err := w.Watcher.Next()
+	if err != nil {
+		return nil, err
+	}
+	return decryptChangeSet(cs)
+}
+
+// Wrap returns a source decrypting the change sets of the source. Keys are
+// resolved the same way as the sops cli, e.g. SOPS_AGE_KEY_FILE for age and
+// the default credential chains for AWS and GCP KMS.
+func Wrap(s source.Source) source.Source {
+	return &sopsSource{s}
+}
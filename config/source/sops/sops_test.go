@@ -0,0 +1,33 @@
+package sops
+
+import (
+	"testing"
+
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-config/source/memory"
+)
+
+func TestPlaintext(t *testing.T) {
+	data := []byte(`{"database": {"host": "localhost"}}`)
+
+	s := Wrap(memory.NewSource(memory.WithData(data)))
+
+	cs, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// config without sops metadata is passed through
+	if string(cs.Data) != string(data) {
+		t.Fatalf("expected %s got %s", data, cs.Data)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	data := []byte(`{"database": "ENC[AES256_GCM,data:abc,iv:abc,tag:abc,type:str]", "sops": {"version": "3.7.0"}}`)
+
+	_, err := decryptChangeSet(&source.ChangeSet{Data: data, Format: "json"})
+	if err == nil {
+		t.Fatal("expected error decrypting without keys")
+	}
+}
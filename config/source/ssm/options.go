@@ -0,0 +1,44 @@
+package ssm
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/micro/go-config/source"
+)
+
+type pathKey struct{}
+type sessionKey struct{}
+type intervalKey struct{}
+type queueKey struct{}
+
+func setOption(k, v interface{}) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// Path sets the parameter hierarchy read, e.g. /greeter/prod
+func Path(p string) source.Option {
+	return setOption(pathKey{}, p)
+}
+
+// Session sets the aws session
+func Session(s *session.Session) source.Option {
+	return setOption(sessionKey{}, s)
+}
+
+// Interval sets how often parameters are polled for changes, zero disables polling
+func Interval(d time.Duration) source.Option {
+	return setOption(intervalKey{}, d)
+}
+
+// Queue sets an SQS queue receiving EventBridge "Parameter Store Change"
+// events. Any message on the queue triggers a refresh.
+func Queue(url string) source.Option {
+	return setOption(queueKey{}, url)
+}
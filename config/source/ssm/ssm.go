@@ -0,0 +1,134 @@
+// Package ssm is a config source reading AWS SSM Parameter Store. Parameters
+// under a path are converted to nested config, /greeter/prod/database/host
+// read from /greeter/prod becomes database.host.
+package ssm
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-plugins/config/source/util"
+)
+
+var (
+	// DefaultInterval parameters are polled at
+	DefaultInterval = time.Minute
+)
+
+type ssmSource struct {
+	opts     source.Options
+	client   *ssm.SSM
+	sqs      *sqs.SQS
+	path     string
+	queue    string
+	interval time.Duration
+}
+
+// set sets the value at the slash separated key, creating nested maps
+func set(m map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(strings.Trim(key, "/"), "/")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[p] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+func (s *ssmSource) Read() (*source.ChangeSet, error) {
+	data := make(map[string]interface{})
+
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(s.path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+
+	err := s.client.GetParametersByPathPages(input, func(page *ssm.GetParametersByPathOutput, last bool) bool {
+		for _, p := range page.Parameters {
+			key := strings.TrimPrefix(aws.StringValue(p.Name), s.path)
+			if len(strings.Trim(key, "/")) == 0 {
+				continue
+			}
+
+			var value interface{} = aws.StringValue(p.Value)
+			if aws.StringValue(p.Type) == ssm.ParameterTypeStringList {
+				value = strings.Split(aws.StringValue(p.Value), ",")
+			}
+
+			set(data, key, value)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.opts.Encoder.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Format:    s.opts.Encoder.String(),
+		Source:    s.String(),
+		Data:      b,
+	}
+	cs.Checksum = cs.Sum()
+
+	return cs, nil
+}
+
+func (s *ssmSource) Watch() (source.Watcher, error) {
+	return util.NewPoller("ssm", s.Read, s.interval, s.sqs, s.queue), nil
+}
+
+func (s *ssmSource) String() string {
+	return "ssm"
+}
+
+// NewSource returns an SSM Parameter Store config source
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	s := &ssmSource{
+		opts:     options,
+		path:     "/",
+		interval: DefaultInterval,
+	}
+
+	var sess *session.Session
+
+	if options.Context != nil {
+		if p, ok := options.Context.Value(pathKey{}).(string); ok {
+			s.path = p
+		}
+		if d, ok := options.Context.Value(intervalKey{}).(time.Duration); ok {
+			s.interval = d
+		}
+		s.queue, _ = options.Context.Value(queueKey{}).(string)
+		sess, _ = options.Context.Value(sessionKey{}).(*session.Session)
+	}
+
+	if sess == nil {
+		sess = session.Must(session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		}))
+	}
+
+	s.client = ssm.New(sess)
+	if len(s.queue) > 0 {
+		s.sqs = sqs.New(sess)
+	}
+
+	return s
+}
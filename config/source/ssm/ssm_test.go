@@ -0,0 +1,26 @@
+package ssm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	m := make(map[string]interface{})
+
+	set(m, "/database/host", "db.prod")
+	set(m, "/database/port", "5432")
+	set(m, "/hosts", []string{"a", "b"})
+
+	expected := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "db.prod",
+			"port": "5432",
+		},
+		"hosts": []string{"a", "b"},
+	}
+
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("expected %v got %v", expected, m)
+	}
+}
@@ -0,0 +1,15 @@
+// Package util provides helpers shared by the config sources
+package util
+
+// Merge deep merges src into dst, src values winning
+func Merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sm, sok := v.(map[string]interface{})
+		dm, dok := dst[k].(map[string]interface{})
+		if sok && dok {
+			Merge(dm, sm)
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	dst := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"log": "info",
+	}
+
+	Merge(dst, map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "db.prod",
+		},
+		"log": map[string]interface{}{
+			"level": "debug",
+		},
+	})
+
+	expected := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "db.prod",
+			"port": 5432,
+		},
+		"log": map[string]interface{}{
+			"level": "debug",
+		},
+	}
+
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("expected %v got %v", expected, dst)
+	}
+}
@@ -0,0 +1,149 @@
+package util
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-log"
+)
+
+type poller struct {
+	name     string
+	read     func() (*source.ChangeSet, error)
+	interval time.Duration
+	sqs      sqsiface.SQSAPI
+	queue    string
+
+	ch      chan *source.ChangeSet
+	refresh chan bool
+	exit    chan bool
+	once    sync.Once
+}
+
+// NewPoller returns a watcher which reads the source every interval and
+// sends the change set when it changed. With a queue, every batch of
+// events received from the SQS queue also triggers a read. The name
+// of the source is used in logs.
+func NewPoller(name string, read func() (*source.ChangeSet, error), interval time.Duration, client sqsiface.SQSAPI, queue string) source.Watcher {
+	p := &poller{
+		name:     name,
+		read:     read,
+		interval: interval,
+		sqs:      client,
+		queue:    queue,
+		ch:       make(chan *source.ChangeSet),
+		refresh:  make(chan bool, 1),
+		exit:     make(chan bool),
+	}
+
+	if len(queue) > 0 {
+		go p.receive()
+	}
+
+	go p.run()
+
+	return p
+}
+
+// receive triggers a refresh for every batch of events on the queue
+func (p *poller) receive() {
+	for {
+		select {
+		case <-p.exit:
+			return
+		default:
+		}
+
+		rsp, err := p.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(p.queue),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(20),
+		})
+		if err != nil {
+			log.Logf("[%s] failed to receive events: %v", p.name, err)
+			select {
+			case <-p.exit:
+				return
+			case <-time.After(time.Second * 5):
+			}
+			continue
+		}
+
+		if len(rsp.Messages) == 0 {
+			continue
+		}
+
+		for _, m := range rsp.Messages {
+			p.sqs.DeleteMessage(&sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(p.queue),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+		}
+
+		select {
+		case p.refresh <- true:
+		default:
+		}
+	}
+}
+
+func (p *poller) run() {
+	var tick <-chan time.Time
+	if p.interval > 0 {
+		t := time.NewTicker(p.interval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	var last string
+	if cs, err := p.read(); err == nil {
+		last = cs.Checksum
+	}
+
+	for {
+		select {
+		case <-p.exit:
+			return
+		case <-tick:
+		case <-p.refresh:
+		}
+
+		cs, err := p.read()
+		if err != nil {
+			log.Logf("[%s] failed to read config: %v", p.name, err)
+			continue
+		}
+
+		if cs.Checksum == last {
+			continue
+		}
+		last = cs.Checksum
+
+		select {
+		case p.ch <- cs:
+		case <-p.exit:
+			return
+		}
+	}
+}
+
+func (p *poller) Next() (*source.ChangeSet, error) {
+	select {
+	case cs := <-p.ch:
+		return cs, nil
+	case <-p.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (p *poller) Stop() error {
+	p.once.Do(func() {
+		close(p.exit)
+	})
+	return nil
+}
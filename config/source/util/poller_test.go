@@ -0,0 +1,129 @@
+package util
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/micro/go-config/source"
+)
+
+type testSQS struct {
+	sqsiface.SQSAPI
+
+	sync.Mutex
+	messages []*sqs.Message
+	deleted  int
+}
+
+func (t *testSQS) ReceiveMessage(in *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	t.Lock()
+	msgs := t.messages
+	t.messages = nil
+	t.Unlock()
+
+	// long polling
+	if len(msgs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	return &sqs.ReceiveMessageOutput{Messages: msgs}, nil
+}
+
+func (t *testSQS) DeleteMessage(in *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.deleted++
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// testSource changes its checksum when the version changes
+type testSource struct {
+	sync.Mutex
+	version int
+}
+
+func (t *testSource) set(v int) {
+	t.Lock()
+	t.version = v
+	t.Unlock()
+}
+
+func (t *testSource) Read() (*source.ChangeSet, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	return &source.ChangeSet{Checksum: strconv.Itoa(t.version)}, nil
+}
+
+func next(t *testing.T, w source.Watcher) *source.ChangeSet {
+	ch := make(chan *source.ChangeSet, 1)
+	go func() {
+		cs, _ := w.Next()
+		ch <- cs
+	}()
+
+	select {
+	case cs := <-ch:
+		return cs
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for change set")
+	}
+	return nil
+}
+
+func TestPollerInterval(t *testing.T) {
+	s := &testSource{}
+
+	w := NewPoller("test", s.Read, time.Millisecond, nil, "")
+	defer w.Stop()
+
+	// wait for the first read
+	time.Sleep(time.Millisecond * 10)
+	s.set(1)
+
+	if cs := next(t, w); cs.Checksum != "1" {
+		t.Fatalf("expected checksum 1 got %s", cs.Checksum)
+	}
+}
+
+func TestPollerQueue(t *testing.T) {
+	s := &testSource{}
+	q := &testSQS{}
+
+	// without an interval only events trigger a read
+	w := NewPoller("test", s.Read, 0, q, "queue")
+	defer w.Stop()
+
+	// wait for the first read
+	time.Sleep(time.Millisecond * 10)
+	s.set(1)
+
+	q.Lock()
+	q.messages = []*sqs.Message{{ReceiptHandle: aws.String("1")}}
+	q.Unlock()
+
+	if cs := next(t, w); cs.Checksum != "1" {
+		t.Fatalf("expected checksum 1 got %s", cs.Checksum)
+	}
+
+	q.Lock()
+	defer q.Unlock()
+	if q.deleted != 1 {
+		t.Fatalf("expected 1 deleted event got %d", q.deleted)
+	}
+}
+
+func TestPollerStop(t *testing.T) {
+	w := NewPoller("test", (&testSource{}).Read, time.Millisecond, nil, "")
+	w.Stop()
+
+	if _, err := w.Next(); err == nil {
+		t.Fatal("expected error after stop")
+	}
+}
@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/micro/go-config/source"
+)
+
+type clientKey struct{}
+type kvKey struct{}
+type dynamicKey struct{}
+type intervalKey struct{}
+
+type kv struct {
+	mount string
+	path  string
+}
+
+type dynamic struct {
+	path string
+	key  string
+}
+
+func setOption(k, v interface{}) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// Client sets the vault client, by default one is created from the
+// VAULT_ADDR and VAULT_TOKEN env vars
+func Client(c *api.Client) source.Option {
+	return setOption(clientKey{}, c)
+}
+
+// KV reads the secret at the path of a KV v2 mount, e.g. KV("secret", "greeter").
+// Later secrets override earlier ones.
+func KV(mount, path string) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		kvs, _ := o.Context.Value(kvKey{}).([]kv)
+		o.Context = context.WithValue(o.Context, kvKey{}, append(kvs, kv{mount, path}))
+	}
+}
+
+// Dynamic reads a dynamic secret, e.g. Dynamic("database/creds/greeter", "database"),
+// placing its data under the key. The lease is renewed until it reaches its max ttl,
+// then new credentials are read and a change emitted before the old ones expire.
+func Dynamic(path, key string) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		ds, _ := o.Context.Value(dynamicKey{}).([]dynamic)
+		o.Context = context.WithValue(o.Context, dynamicKey{}, append(ds, dynamic{path, key}))
+	}
+}
+
+// Interval sets how often KV secrets are polled for changes
+func Interval(d time.Duration) source.Option {
+	return setOption(intervalKey{}, d)
+}
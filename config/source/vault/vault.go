@@ -0,0 +1,186 @@
+// Package vault is a config source backed by HashiCorp Vault. It reads KV v2
+// secrets and dynamic secrets, renewing their leases and rotating to fresh
+// credentials before they expire.
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-log"
+	"github.com/micro/go-plugins/config/source/util"
+)
+
+var (
+	// DefaultInterval KV secrets are polled at
+	DefaultInterval = time.Minute
+)
+
+type lease struct {
+	secret *api.Secret
+	data   map[string]interface{}
+}
+
+type vault struct {
+	opts     source.Options
+	client   *api.Client
+	kvs      []kv
+	dynamics []dynamic
+	interval time.Duration
+	// error creating the client
+	err error
+
+	sync.Mutex
+	// current dynamic secrets by path
+	leases map[string]*lease
+}
+
+func (v *vault) readKV(k kv) (map[string]interface{}, error) {
+	p := fmt.Sprintf("%s/data/%s", strings.Trim(k.mount, "/"), strings.Trim(k.path, "/"))
+
+	s, err := v.client.Logical().Read(p)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || s.Data == nil {
+		return nil, nil
+	}
+
+	data, _ := s.Data["data"].(map[string]interface{})
+	return data, nil
+}
+
+// readDynamic reads a new dynamic secret
+func (v *vault) readDynamic(d dynamic) (*lease, error) {
+	s, err := v.client.Logical().Read(d.path)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.New("no secret at " + d.path)
+	}
+
+	data := make(map[string]interface{})
+	util.Merge(data, map[string]interface{}{d.key: s.Data})
+
+	return &lease{secret: s, data: data}, nil
+}
+
+// dynamic returns the current lease for the secret, reading one if needed
+func (v *vault) dynamic(d dynamic) (*lease, error) {
+	v.Lock()
+	l, ok := v.leases[d.path]
+	v.Unlock()
+
+	if ok {
+		return l, nil
+	}
+
+	l, err := v.readDynamic(d)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Lock()
+	v.leases[d.path] = l
+	v.Unlock()
+
+	return l, nil
+}
+
+func (v *vault) Read() (*source.ChangeSet, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	data := make(map[string]interface{})
+
+	for _, k := range v.kvs {
+		d, err := v.readKV(k)
+		if err != nil {
+			return nil, err
+		}
+		util.Merge(data, d)
+	}
+
+	for _, d := range v.dynamics {
+		l, err := v.dynamic(d)
+		if err != nil {
+			return nil, err
+		}
+		util.Merge(data, l.data)
+	}
+
+	b, err := v.opts.Encoder.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Format:    v.opts.Encoder.String(),
+		Source:    v.String(),
+		Data:      b,
+	}
+	cs.Checksum = cs.Sum()
+
+	return cs, nil
+}
+
+// rotate replaces the lease with fresh credentials
+func (v *vault) rotate(d dynamic) error {
+	l, err := v.readDynamic(d)
+	if err != nil {
+		return err
+	}
+
+	v.Lock()
+	v.leases[d.path] = l
+	v.Unlock()
+
+	log.Logf("[vault] rotated credentials for %s", d.path)
+	return nil
+}
+
+func (v *vault) Watch() (source.Watcher, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return newWatcher(v)
+}
+
+func (v *vault) String() string {
+	return "vault"
+}
+
+// NewSource returns a vault config source. Errors creating
+// the client are returned by Read and Watch.
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	v := &vault{
+		opts:     options,
+		interval: DefaultInterval,
+		leases:   make(map[string]*lease),
+	}
+
+	if options.Context != nil {
+		v.client, _ = options.Context.Value(clientKey{}).(*api.Client)
+		v.kvs, _ = options.Context.Value(kvKey{}).([]kv)
+		v.dynamics, _ = options.Context.Value(dynamicKey{}).([]dynamic)
+		if d, ok := options.Context.Value(intervalKey{}).(time.Duration); ok && d > 0 {
+			v.interval = d
+		}
+	}
+
+	if v.client == nil {
+		v.client, v.err = api.NewClient(api.DefaultConfig())
+	}
+
+	return v
+}
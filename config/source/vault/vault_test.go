@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestSource(t *testing.T) {
+	var reads int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/v1/secret/data/greeter":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data": map[string]interface{}{
+						"database": map[string]interface{}{"host": "db.prod"},
+					},
+				},
+			})
+		case "/v1/database/creds/greeter":
+			reads++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "database/creds/greeter/1",
+				"lease_duration": 3600,
+				"renewable":      true,
+				"data": map[string]interface{}{
+					"username": "v-greeter",
+					"password": "secret",
+				},
+			})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := api.NewClient(&api.Config{Address: ts.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSource(
+		Client(c),
+		KV("secret", "greeter"),
+		Dynamic("database/creds/greeter", "database"),
+	)
+
+	for i := 0; i < 2; i++ {
+		cs, err := s.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var data struct {
+			Database struct {
+				Host     string `json:"host"`
+				Username string `json:"username"`
+			} `json:"database"`
+		}
+		if err := json.Unmarshal(cs.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+
+		if data.Database.Host != "db.prod" || data.Database.Username != "v-greeter" {
+			t.Fatalf("unexpected config %+v", data)
+		}
+	}
+
+	// the lease is reused rather than issuing new credentials
+	if reads != 1 {
+		t.Fatalf("expected 1 dynamic read got %d", reads)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	addr, ok := os.LookupEnv("VAULT_ADDR")
+	os.Setenv("VAULT_ADDR", "://invalid")
+	defer func() {
+		if ok {
+			os.Setenv("VAULT_ADDR", addr)
+		} else {
+			os.Unsetenv("VAULT_ADDR")
+		}
+	}()
+
+	s := NewSource(KV("secret", "greeter"))
+
+	if _, err := s.Read(); err == nil {
+		t.Fatal("expected the client error from Read")
+	}
+	if _, err := s.Watch(); err == nil {
+		t.Fatal("expected the client error from Watch")
+	}
+}
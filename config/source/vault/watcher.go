@@ -0,0 +1,196 @@
+package vault
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-log"
+)
+
+var (
+	// backoff between failed reads and renewals of dynamic secrets
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+type watcher struct {
+	v    *vault
+	ch   chan *source.ChangeSet
+	exit chan bool
+	once sync.Once
+}
+
+func newWatcher(v *vault) (source.Watcher, error) {
+	w := &watcher{
+		v:    v,
+		ch:   make(chan *source.ChangeSet),
+		exit: make(chan bool),
+	}
+
+	for _, d := range v.dynamics {
+		go w.renew(d)
+	}
+
+	if len(v.kvs) > 0 {
+		go w.poll()
+	}
+
+	return w, nil
+}
+
+func (w *watcher) emit() {
+	cs, err := w.v.Read()
+	if err != nil {
+		log.Logf("[vault] failed to read: %v", err)
+		return
+	}
+
+	select {
+	case w.ch <- cs:
+	case <-w.exit:
+	}
+}
+
+// renew keeps the lease of a dynamic secret alive. When it can no longer be
+// renewed new credentials are read and a change is emitted, leaving a grace
+// period to switch over before the old credentials expire. Failures are
+// retried with backoff so an unavailable vault isn't hammered.
+func (w *watcher) renew(d dynamic) {
+	backoff := minBackoff
+
+	// retry waits out the backoff, returning false once stopped
+	retry := func() bool {
+		select {
+		case <-w.exit:
+			return false
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		return true
+	}
+
+	for {
+		l, err := w.v.dynamic(d)
+		if err != nil {
+			log.Logf("[vault] failed to read %s: %v", d.path, err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		if !l.secret.Renewable {
+			// wait out most of the lease then rotate
+			wait := time.Duration(l.secret.LeaseDuration) * time.Second * 2 / 3
+			if wait <= 0 {
+				return
+			}
+			select {
+			case <-w.exit:
+				return
+			case <-time.After(wait):
+			}
+			backoff = minBackoff
+		} else {
+			r, err := w.v.client.NewRenewer(&api.RenewerInput{Secret: l.secret})
+			if err != nil {
+				log.Logf("[vault] failed to renew %s: %v", d.path, err)
+				if !retry() {
+					return
+				}
+				continue
+			}
+
+			go r.Renew()
+
+			var done bool
+			for !done {
+				select {
+				case <-w.exit:
+					r.Stop()
+					return
+				case err = <-r.DoneCh():
+					done = true
+				case <-r.RenewCh():
+					backoff = minBackoff
+				}
+			}
+			r.Stop()
+
+			// renewal failed rather than reaching the max ttl
+			if err != nil {
+				log.Logf("[vault] renewal of %s stopped: %v", d.path, err)
+				if !retry() {
+					return
+				}
+			}
+		}
+
+		if err := w.v.rotate(d); err != nil {
+			log.Logf("[vault] failed to rotate %s: %v", d.path, err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		w.emit()
+	}
+}
+
+// poll emits a change when the KV secrets change
+func (w *watcher) poll() {
+	t := time.NewTicker(w.v.interval)
+	defer t.Stop()
+
+	var last string
+	if cs, err := w.v.Read(); err == nil {
+		last = cs.Checksum
+	}
+
+	for {
+		select {
+		case <-w.exit:
+			return
+		case <-t.C:
+		}
+
+		cs, err := w.v.Read()
+		if err != nil {
+			log.Logf("[vault] failed to read: %v", err)
+			continue
+		}
+
+		if cs.Checksum == last {
+			continue
+		}
+		last = cs.Checksum
+
+		select {
+		case w.ch <- cs:
+		case <-w.exit:
+			return
+		}
+	}
+}
+
+func (w *watcher) Next() (*source.ChangeSet, error) {
+	select {
+	case cs := <-w.ch:
+		return cs, nil
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.once.Do(func() {
+		close(w.exit)
+	})
+	return nil
+}
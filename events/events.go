@@ -0,0 +1,70 @@
+// Package events provides an event store layered on durable logs such as
+// Kafka, JetStream and Redis Streams with ordered per stream consumption,
+// committed consumer offsets and replay from a timestamp
+package events
+
+import (
+	"time"
+)
+
+// Store is an append only event store
+type Store interface {
+	Init(...Option) error
+	Options() Options
+	// Append adds an event to the end of the stream
+	Append(stream string, header map[string]string, body []byte) (*Event, error)
+	// Read returns events of the stream in order
+	Read(stream string, opts ...ReadOption) ([]*Event, error)
+	// Consume delivers events of the stream in order to the handler
+	Consume(stream string, h Handler, opts ...ConsumeOption) (Consumer, error)
+	// Replay delivers the events appended since the time up
+	// to the end of the stream and then returns
+	Replay(stream string, since time.Time, h Handler) error
+	String() string
+}
+
+// Log is the durable ordered log a store is backed by. Offsets are opaque
+// to the store and only have to be understood by the log that issued them.
+type Log interface {
+	// Append adds the event to the end of the stream
+	// setting its offset and timestamp
+	Append(stream string, e *Event) error
+	// Read returns up to limit events after the offset.
+	// An empty offset reads from the start of the stream.
+	Read(stream, offset string, limit int) ([]*Event, error)
+	// Seek returns the offset to read after to
+	// receive the events at or after the time
+	Seek(stream string, t time.Time) (string, error)
+	// Commit stores the offset of the last event handled by the group
+	Commit(group, stream, offset string) error
+	// Committed returns the offset last committed by
+	// the group or empty if nothing was committed
+	Committed(group, stream string) (string, error)
+	String() string
+}
+
+// Event is an event in a stream
+type Event struct {
+	Stream    string
+	Offset    string
+	Timestamp time.Time
+	Header    map[string]string
+	Body      []byte
+}
+
+// Handler is used to process events. Returning an error stops the
+// offset being committed and the event is delivered again.
+type Handler func(*Event) error
+
+// Consumer is returned by Consume
+type Consumer interface {
+	Options() ConsumeOptions
+	// Offset of the last event handled
+	Offset() string
+	Stop() error
+}
+
+// NewStore returns an event store backed by the log
+func NewStore(opts ...Option) Store {
+	return newStore(opts...)
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(PollInterval(time.Millisecond), BatchSize(2))
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.Append("orders", nil, []byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := s.Read("orders", ReadOffset("1"), ReadLimit(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 || string(events[0].Body) != "1" {
+		t.Fatalf("expected to read 4 events after offset 1, got %d", len(events))
+	}
+
+	consume := func(n int) []string {
+		var mtx sync.Mutex
+		var got []string
+		done := make(chan bool)
+
+		c, err := s.Consume("orders", func(e *Event) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			got = append(got, string(e.Body))
+			if len(got) == n {
+				close(done)
+			}
+			return nil
+		}, Group("billing"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d events", n)
+		}
+		c.Stop()
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		return got
+	}
+
+	got := consume(5)
+	for i, b := range got {
+		if b != strconv.Itoa(i) {
+			t.Fatalf("expected events in order, got %v", got)
+		}
+	}
+
+	// the group resumes from its committed offset
+	s.Append("orders", nil, []byte("5"))
+
+	time.Sleep(time.Millisecond * 10)
+
+	if got := consume(1); got[0] != "5" {
+		t.Fatalf("expected consumer to resume at 5, got %v", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	l := NewMemoryLog()
+	s := NewStore(WithLog(l), BatchSize(2))
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		l.Append("orders", &Event{
+			Body:      []byte(strconv.Itoa(i)),
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	var got []string
+	err := s.Replay("orders", now.Add(2*time.Minute), func(e *Event) error {
+		got = append(got, string(e.Body))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 || got[0] != "2" || got[2] != "4" {
+		t.Fatalf("expected to replay events 2 to 4, got %v", got)
+	}
+}
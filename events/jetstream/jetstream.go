@@ -0,0 +1,240 @@
+// Package jetstream provides a NATS JetStream log for the event store.
+// Each event stream is a JetStream stream and offsets are stream sequences.
+package jetstream
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/micro/go-plugins/events"
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	// stream names can't contain dots, wildcards or whitespace
+	nameRe = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+)
+
+type jetstreamLog struct {
+	js     nats.JetStreamContext
+	kv     nats.KeyValue
+	prefix string
+
+	sync.Mutex
+	// streams which are known to exist
+	streams map[string]bool
+}
+
+func name(stream string) string {
+	return nameRe.ReplaceAllString(stream, "_")
+}
+
+func (j *jetstreamLog) subject(stream string) string {
+	return j.prefix + "." + name(stream)
+}
+
+// ensure creates the jetstream stream if it doesn't exist
+func (j *jetstreamLog) ensure(stream string) error {
+	j.Lock()
+	defer j.Unlock()
+
+	if j.streams[stream] {
+		return nil
+	}
+
+	_, err := j.js.StreamInfo(name(stream))
+	if err == nats.ErrStreamNotFound {
+		_, err = j.js.AddStream(&nats.StreamConfig{
+			Name:     name(stream),
+			Subjects: []string{j.subject(stream)},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	j.streams[stream] = true
+	return nil
+}
+
+func (j *jetstreamLog) Append(stream string, e *events.Event) error {
+	if err := j.ensure(stream); err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(j.subject(stream))
+	for k, v := range e.Header {
+		msg.Header.Set(k, v)
+	}
+	msg.Data = e.Body
+
+	ack, err := j.js.PublishMsg(msg)
+	if err != nil {
+		return err
+	}
+
+	e.Stream = stream
+	e.Offset = strconv.FormatUint(ack.Sequence, 10)
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	return nil
+}
+
+func (j *jetstreamLog) Read(stream, offset string, limit int) ([]*events.Event, error) {
+	if err := j.ensure(stream); err != nil {
+		return nil, err
+	}
+
+	var after uint64
+	if len(offset) > 0 {
+		o, err := strconv.ParseUint(offset, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		after = o
+	}
+
+	info, err := j.js.StreamInfo(name(stream))
+	if err != nil {
+		return nil, err
+	}
+
+	seq := after + 1
+	if seq < info.State.FirstSeq {
+		seq = info.State.FirstSeq
+	}
+
+	var list []*events.Event
+
+	for ; seq <= info.State.LastSeq; seq++ {
+		if limit > 0 && len(list) >= limit {
+			break
+		}
+
+		m, err := j.js.GetMsg(name(stream), seq)
+		if err == nats.ErrMsgNotFound {
+			// deleted from the stream
+			continue
+		} else if err != nil {
+			return list, err
+		}
+
+		header := make(map[string]string)
+		for k := range m.Header {
+			header[k] = m.Header.Get(k)
+		}
+
+		list = append(list, &events.Event{
+			Stream:    stream,
+			Offset:    strconv.FormatUint(m.Sequence, 10),
+			Timestamp: m.Time,
+			Header:    header,
+			Body:      m.Data,
+		})
+	}
+
+	return list, nil
+}
+
+func (j *jetstreamLog) Seek(stream string, t time.Time) (string, error) {
+	if err := j.ensure(stream); err != nil {
+		return "", err
+	}
+
+	info, err := j.js.StreamInfo(name(stream))
+	if err != nil {
+		return "", err
+	}
+
+	// nothing at or after the time so read from the end
+	if info.State.Msgs == 0 || info.State.LastTime.Before(t) {
+		return strconv.FormatUint(info.State.LastSeq, 10), nil
+	}
+
+	sub, err := j.js.SubscribeSync(j.subject(stream), nats.OrderedConsumer(), nats.StartTime(t))
+	if err != nil {
+		return "", err
+	}
+	defer sub.Unsubscribe()
+
+	m, err := sub.NextMsg(time.Second * 5)
+	if err != nil {
+		return "", err
+	}
+	meta, err := m.Metadata()
+	if err != nil {
+		return "", err
+	}
+
+	if meta.Sequence.Stream <= 1 {
+		return "", nil
+	}
+	return strconv.FormatUint(meta.Sequence.Stream-1, 10), nil
+}
+
+func key(group, stream string) string {
+	return name(group) + "." + name(stream)
+}
+
+func (j *jetstreamLog) Commit(group, stream, offset string) error {
+	_, err := j.kv.Put(key(group, stream), []byte(offset))
+	return err
+}
+
+func (j *jetstreamLog) Committed(group, stream string) (string, error) {
+	e, err := j.kv.Get(key(group, stream))
+	if err == nats.ErrKeyNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(e.Value()), nil
+}
+
+func (j *jetstreamLog) String() string {
+	return "jetstream"
+}
+
+// NewLog returns a JetStream backed log
+func NewLog(opts ...Option) (events.Log, error) {
+	options := Options{
+		Address: nats.DefaultURL,
+		Bucket:  "events_offsets",
+		Prefix:  "events",
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	nc := options.Conn
+	if nc == nil {
+		var err error
+		nc, err = nats.Connect(options.Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(options.Bucket)
+	if err == nats.ErrBucketNotFound {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: options.Bucket})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &jetstreamLog{
+		js:      js,
+		kv:      kv,
+		prefix:  options.Prefix,
+		streams: make(map[string]bool),
+	}, nil
+}
@@ -0,0 +1,48 @@
+package jetstream
+
+import (
+	"github.com/nats-io/nats.go"
+)
+
+// Options for the jetstream log
+type Options struct {
+	// Address of the nats server
+	Address string
+	// Conn to use instead of connecting to Address
+	Conn *nats.Conn
+	// Bucket is the key value bucket offsets are committed to
+	Bucket string
+	// Prefix of the subjects events are published to
+	Prefix string
+}
+
+// Option sets an option
+type Option func(*Options)
+
+// Address sets the nats server address
+func Address(addr string) Option {
+	return func(o *Options) {
+		o.Address = addr
+	}
+}
+
+// Conn sets the nats connection
+func Conn(c *nats.Conn) Option {
+	return func(o *Options) {
+		o.Conn = c
+	}
+}
+
+// Bucket sets the key value bucket offsets are committed to
+func Bucket(b string) Option {
+	return func(o *Options) {
+		o.Bucket = b
+	}
+}
+
+// Prefix sets the prefix of the subjects events are published to
+func Prefix(p string) Option {
+	return func(o *Options) {
+		o.Prefix = p
+	}
+}
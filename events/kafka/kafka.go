@@ -0,0 +1,284 @@
+// Package kafka provides a kafka log for the event store. Each stream is
+// a topic and only its first partition is used so events are ordered.
+package kafka
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/micro/go-plugins/events"
+)
+
+const partition = 0
+
+type kafkaLog struct {
+	c  sarama.Client
+	p  sarama.SyncProducer
+	cs sarama.Consumer
+
+	sync.Mutex
+	// partition consumers by stream
+	readers map[string]*reader
+	// offset managers by group
+	groups map[string]sarama.OffsetManager
+	// partition offset managers by group and stream
+	offsets map[string]sarama.PartitionOffsetManager
+}
+
+// reader is a partition consumer of a stream kept open between
+// reads so consumers polling the stream don't reopen it every time
+type reader struct {
+	sync.Mutex
+	pc sarama.PartitionConsumer
+	// offset of the next message
+	next int64
+}
+
+func parseOffset(offset string) (int64, error) {
+	if len(offset) == 0 {
+		return -1, nil
+	}
+	return strconv.ParseInt(offset, 10, 64)
+}
+
+func (k *kafkaLog) Append(stream string, e *events.Event) error {
+	var headers []sarama.RecordHeader
+	for hk, hv := range e.Header {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(hk), Value: []byte(hv)})
+	}
+
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	_, offset, err := k.p.SendMessage(&sarama.ProducerMessage{
+		Topic:     stream,
+		Partition: partition,
+		Value:     sarama.ByteEncoder(e.Body),
+		Headers:   headers,
+		Timestamp: e.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	e.Stream = stream
+	e.Offset = strconv.FormatInt(offset, 10)
+	return nil
+}
+
+func (k *kafkaLog) Read(stream, offset string, limit int) ([]*events.Event, error) {
+	after, err := parseOffset(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	oldest, err := k.c.GetOffset(stream, partition, sarama.OffsetOldest)
+	if err != nil {
+		return nil, err
+	}
+	newest, err := k.c.GetOffset(stream, partition, sarama.OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+
+	start := after + 1
+	if start < oldest {
+		start = oldest
+	}
+	if start >= newest {
+		return nil, nil
+	}
+
+	n := newest - start
+	if limit > 0 && int64(limit) < n {
+		n = int64(limit)
+	}
+
+	rd := k.reader(stream)
+	rd.Lock()
+	defer rd.Unlock()
+
+	// reopen when reading from another offset
+	if rd.pc == nil || rd.next != start {
+		if rd.pc != nil {
+			rd.pc.Close()
+			rd.pc = nil
+		}
+		pc, err := k.cs.ConsumePartition(stream, partition, start)
+		if err != nil {
+			return nil, err
+		}
+		rd.pc = pc
+		rd.next = start
+	}
+
+	var list []*events.Event
+
+	for int64(len(list)) < n {
+		select {
+		case m := <-rd.pc.Messages():
+			rd.next = m.Offset + 1
+
+			header := make(map[string]string)
+			for _, h := range m.Headers {
+				header[string(h.Key)] = string(h.Value)
+			}
+			list = append(list, &events.Event{
+				Stream:    stream,
+				Offset:    strconv.FormatInt(m.Offset, 10),
+				Timestamp: m.Timestamp,
+				Header:    header,
+				Body:      m.Value,
+			})
+		case err := <-rd.pc.Errors():
+			rd.pc.Close()
+			rd.pc = nil
+			return list, err
+		case <-time.After(k.c.Config().Consumer.MaxWaitTime * 10):
+			// messages may have been compacted away
+			return list, nil
+		}
+	}
+
+	return list, nil
+}
+
+func (k *kafkaLog) reader(stream string) *reader {
+	k.Lock()
+	defer k.Unlock()
+
+	rd, ok := k.readers[stream]
+	if !ok {
+		rd = &reader{}
+		k.readers[stream] = rd
+	}
+	return rd
+}
+
+func (k *kafkaLog) Seek(stream string, t time.Time) (string, error) {
+	offset, err := k.c.GetOffset(stream, partition, t.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return "", err
+	}
+
+	// nothing at or after the time so read from the end
+	if offset < 0 {
+		offset, err = k.c.GetOffset(stream, partition, sarama.OffsetNewest)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if offset <= 0 {
+		return "", nil
+	}
+	return strconv.FormatInt(offset-1, 10), nil
+}
+
+func (k *kafkaLog) partitionManager(group, stream string) (sarama.PartitionOffsetManager, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	key := group + "/" + stream
+	if pom, ok := k.offsets[key]; ok {
+		return pom, nil
+	}
+
+	om, ok := k.groups[group]
+	if !ok {
+		var err error
+		om, err = sarama.NewOffsetManagerFromClient(group, k.c)
+		if err != nil {
+			return nil, err
+		}
+		k.groups[group] = om
+	}
+
+	pom, err := om.ManagePartition(stream, partition)
+	if err != nil {
+		return nil, err
+	}
+	k.offsets[key] = pom
+	return pom, nil
+}
+
+func (k *kafkaLog) Commit(group, stream, offset string) error {
+	o, err := parseOffset(offset)
+	if err != nil {
+		return err
+	}
+	pom, err := k.partitionManager(group, stream)
+	if err != nil {
+		return err
+	}
+	// kafka commits the next offset to consume
+	pom.MarkOffset(o+1, "")
+	return nil
+}
+
+func (k *kafkaLog) Committed(group, stream string) (string, error) {
+	pom, err := k.partitionManager(group, stream)
+	if err != nil {
+		return "", err
+	}
+	next, _ := pom.NextOffset()
+	if next <= 0 {
+		return "", nil
+	}
+	return strconv.FormatInt(next-1, 10), nil
+}
+
+func (k *kafkaLog) String() string {
+	return "kafka"
+}
+
+// NewLog returns a kafka backed log
+func NewLog(opts ...Option) (events.Log, error) {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if len(options.Addrs) == 0 {
+		options.Addrs = []string{"127.0.0.1:9092"}
+	}
+
+	config := options.Config
+	if config == nil {
+		config = sarama.NewConfig()
+	}
+	config.Version = sarama.V0_11_0_0
+	config.Producer.Return.Successes = true
+	config.Producer.Partitioner = sarama.NewManualPartitioner
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	c, err := sarama.NewClient(options.Addrs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := sarama.NewSyncProducerFromClient(c)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	cs, err := sarama.NewConsumerFromClient(c)
+	if err != nil {
+		p.Close()
+		c.Close()
+		return nil, err
+	}
+
+	return &kafkaLog{
+		c:       c,
+		p:       p,
+		cs:      cs,
+		readers: make(map[string]*reader),
+		groups:  make(map[string]sarama.OffsetManager),
+		offsets: make(map[string]sarama.PartitionOffsetManager),
+	}, nil
+}
@@ -0,0 +1,29 @@
+package kafka
+
+import (
+	"github.com/Shopify/sarama"
+)
+
+// Options for the kafka log
+type Options struct {
+	Addrs  []string
+	Config *sarama.Config
+}
+
+// Option sets an option
+type Option func(*Options)
+
+// Addrs sets the kafka brokers
+func Addrs(addrs ...string) Option {
+	return func(o *Options) {
+		o.Addrs = addrs
+	}
+}
+
+// Config sets the sarama config. The producer is always set to
+// return successes and write to a single partition per stream.
+func Config(c *sarama.Config) Option {
+	return func(o *Options) {
+		o.Config = c
+	}
+}
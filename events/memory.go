@@ -0,0 +1,96 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryLog struct {
+	sync.RWMutex
+	streams map[string][]*Event
+	offsets map[string]string
+}
+
+func (m *memoryLog) Append(stream string, e *Event) error {
+	m.Lock()
+	defer m.Unlock()
+
+	events := m.streams[stream]
+	e.Stream = stream
+	e.Offset = strconv.Itoa(len(events) + 1)
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	m.streams[stream] = append(events, e)
+	return nil
+}
+
+func (m *memoryLog) Read(stream, offset string, limit int) ([]*Event, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	events := m.streams[stream]
+
+	var start int
+	if len(offset) > 0 {
+		i, err := strconv.Atoi(offset)
+		if err != nil {
+			return nil, err
+		}
+		start = i
+	}
+
+	if start >= len(events) {
+		return nil, nil
+	}
+
+	end := len(events)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	return append([]*Event{}, events[start:end]...), nil
+}
+
+func (m *memoryLog) Seek(stream string, t time.Time) (string, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	events := m.streams[stream]
+	for i, e := range events {
+		if e.Timestamp.Before(t) {
+			continue
+		}
+		if i == 0 {
+			return "", nil
+		}
+		return strconv.Itoa(i), nil
+	}
+	return strconv.Itoa(len(events)), nil
+}
+
+func (m *memoryLog) Commit(group, stream, offset string) error {
+	m.Lock()
+	m.offsets[group+"/"+stream] = offset
+	m.Unlock()
+	return nil
+}
+
+func (m *memoryLog) Committed(group, stream string) (string, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.offsets[group+"/"+stream], nil
+}
+
+func (m *memoryLog) String() string {
+	return "memory"
+}
+
+// NewMemoryLog returns an in memory log for testing
+func NewMemoryLog() Log {
+	return &memoryLog{
+		streams: make(map[string][]*Event),
+		offsets: make(map[string]string),
+	}
+}
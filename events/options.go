@@ -0,0 +1,131 @@
+package events
+
+import (
+	"time"
+)
+
+// Options for the store
+type Options struct {
+	// Log events are stored in
+	Log Log
+	// PollInterval consumers wait before reading
+	// again once they've caught up with a stream
+	PollInterval time.Duration
+	// BatchSize is the number of events read at a time
+	BatchSize int
+}
+
+// Option sets an option
+type Option func(*Options)
+
+// ReadOptions for reading a stream
+type ReadOptions struct {
+	// Offset to read after
+	Offset string
+	// Since reads events appended at or after the time
+	Since time.Time
+	// Limit of events returned
+	Limit int
+}
+
+// ReadOption sets a read option
+type ReadOption func(*ReadOptions)
+
+// ConsumeOptions for consuming a stream
+type ConsumeOptions struct {
+	// Group commits offsets as events are handled so consumption
+	// resumes where it left off. Offset and Since are only used
+	// when the group hasn't committed an offset yet.
+	Group string
+	// Offset to consume after
+	Offset string
+	// Since consumes events appended at or after the time
+	Since time.Time
+}
+
+// ConsumeOption sets a consume option
+type ConsumeOption func(*ConsumeOptions)
+
+var (
+	// DefaultPollInterval is the default consumer poll interval
+	DefaultPollInterval = time.Second
+	// DefaultBatchSize is the default number of events read at a time
+	DefaultBatchSize = 100
+)
+
+// WithLog sets the log events are stored in
+func WithLog(l Log) Option {
+	return func(o *Options) {
+		o.Log = l
+	}
+}
+
+// PollInterval sets how long consumers wait before reading again
+func PollInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.PollInterval = d
+	}
+}
+
+// BatchSize sets the number of events read at a time
+func BatchSize(n int) Option {
+	return func(o *Options) {
+		o.BatchSize = n
+	}
+}
+
+// ReadOffset reads events after the offset
+func ReadOffset(offset string) ReadOption {
+	return func(o *ReadOptions) {
+		o.Offset = offset
+	}
+}
+
+// ReadSince reads events appended at or after the time
+func ReadSince(t time.Time) ReadOption {
+	return func(o *ReadOptions) {
+		o.Since = t
+	}
+}
+
+// ReadLimit sets the maximum number of events read
+func ReadLimit(n int) ReadOption {
+	return func(o *ReadOptions) {
+		o.Limit = n
+	}
+}
+
+// Group sets the consumer group offsets are committed as
+func Group(name string) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.Group = name
+	}
+}
+
+// Offset consumes events after the offset
+func Offset(offset string) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.Offset = offset
+	}
+}
+
+// Since consumes events appended at or after the time
+func Since(t time.Time) ConsumeOption {
+	return func(o *ConsumeOptions) {
+		o.Since = t
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.Log == nil {
+		options.Log = NewMemoryLog()
+	}
+	return options
+}
@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"github.com/garyburd/redigo/redis"
+)
+
+// Options for the redis log
+type Options struct {
+	// Address of the redis server
+	Address string
+	// Pool to use instead of dialing Address
+	Pool *redis.Pool
+	// Prefix of the keys offsets are committed under
+	Prefix string
+}
+
+// Option sets an option
+type Option func(*Options)
+
+// Address sets the redis server address
+func Address(addr string) Option {
+	return func(o *Options) {
+		o.Address = addr
+	}
+}
+
+// Pool sets the connection pool
+func Pool(p *redis.Pool) Option {
+	return func(o *Options) {
+		o.Pool = p
+	}
+}
+
+// Prefix sets the prefix of the keys offsets are committed under
+func Prefix(p string) Option {
+	return func(o *Options) {
+		o.Prefix = p
+	}
+}
@@ -0,0 +1,191 @@
+// Package redis provides a Redis Streams log for the event store
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-plugins/events"
+)
+
+type redisLog struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// timestamp returns the time encoded in a stream entry id
+func timestamp(id string) time.Time {
+	ms, _ := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// entries parses stream entries of the form [[id, [field, value...]]...]
+func entries(stream string, reply interface{}) ([]*events.Event, error) {
+	values, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*events.Event
+
+	for _, v := range values {
+		entry, err := redis.Values(v, nil)
+		if err != nil || len(entry) != 2 {
+			return nil, fmt.Errorf("unexpected stream entry %v", v)
+		}
+		id, err := redis.String(entry[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := redis.StringMap(entry[1], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		e := &events.Event{
+			Stream:    stream,
+			Offset:    id,
+			Timestamp: timestamp(id),
+			Body:      []byte(fields["body"]),
+		}
+		if h, ok := fields["header"]; ok && len(h) > 0 {
+			if err := json.Unmarshal([]byte(h), &e.Header); err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, e)
+	}
+
+	return list, nil
+}
+
+func (r *redisLog) Append(stream string, e *events.Event) error {
+	header, err := json.Marshal(e.Header)
+	if err != nil {
+		return err
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	id, err := redis.String(conn.Do("XADD", stream, "*", "header", header, "body", e.Body))
+	if err != nil {
+		return err
+	}
+
+	e.Stream = stream
+	e.Offset = id
+	e.Timestamp = timestamp(id)
+	return nil
+}
+
+func (r *redisLog) Read(stream, offset string, limit int) ([]*events.Event, error) {
+	if len(offset) == 0 {
+		offset = "0-0"
+	}
+
+	args := []interface{}{}
+	if limit > 0 {
+		args = append(args, "COUNT", limit)
+	}
+	args = append(args, "STREAMS", stream, offset)
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	// XREAD returns entries with an id greater than the offset
+	reply, err := conn.Do("XREAD", args...)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+
+	streams, err := redis.Values(reply, nil)
+	if err != nil || len(streams) == 0 {
+		return nil, err
+	}
+	s, err := redis.Values(streams[0], nil)
+	if err != nil || len(s) != 2 {
+		return nil, fmt.Errorf("unexpected stream reply %v", streams[0])
+	}
+	return entries(stream, s[1])
+}
+
+func (r *redisLog) Seek(stream string, t time.Time) (string, error) {
+	ms := t.UnixNano() / int64(time.Millisecond)
+	if ms <= 0 {
+		return "", nil
+	}
+
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	// the last entry before the time
+	reply, err := conn.Do("XREVRANGE", stream, fmt.Sprintf("%d-%d", ms-1, uint64(1<<64-1)), "-", "COUNT", 1)
+	if err != nil {
+		return "", err
+	}
+	list, err := entries(stream, reply)
+	if err != nil || len(list) == 0 {
+		return "", err
+	}
+	return list[0].Offset, nil
+}
+
+func (r *redisLog) Commit(group, stream, offset string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("HSET", r.prefix+group, stream, offset)
+	return err
+}
+
+func (r *redisLog) Committed(group, stream string) (string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+	offset, err := redis.String(conn.Do("HGET", r.prefix+group, stream))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+	return offset, err
+}
+
+func (r *redisLog) String() string {
+	return "redis"
+}
+
+// NewLog returns a Redis Streams backed log
+func NewLog(opts ...Option) (events.Log, error) {
+	options := Options{
+		Address: "127.0.0.1:6379",
+		Prefix:  "events:offsets:",
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	pool := options.Pool
+	if pool == nil {
+		pool = &redis.Pool{
+			MaxIdle:     5,
+			IdleTimeout: 2 * time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", options.Address)
+			},
+		}
+	}
+
+	// check we can connect
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		return nil, err
+	}
+
+	return &redisLog{
+		pool:   pool,
+		prefix: options.Prefix,
+	}, nil
+}
@@ -0,0 +1,239 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+)
+
+type store struct {
+	sync.RWMutex
+	opts Options
+}
+
+type consumer struct {
+	stream  string
+	handler Handler
+	opts    ConsumeOptions
+	store   *store
+	exit    chan bool
+	once    sync.Once
+
+	sync.RWMutex
+	offset string
+}
+
+func newStore(opts ...Option) *store {
+	return &store{
+		opts: newOptions(opts...),
+	}
+}
+
+func (s *store) Init(opts ...Option) error {
+	s.Lock()
+	defer s.Unlock()
+	for _, o := range opts {
+		o(&s.opts)
+	}
+	return nil
+}
+
+func (s *store) Options() Options {
+	s.RLock()
+	defer s.RUnlock()
+	return s.opts
+}
+
+func (s *store) Append(stream string, header map[string]string, body []byte) (*Event, error) {
+	e := &Event{
+		Stream: stream,
+		Header: header,
+		Body:   body,
+	}
+	if err := s.Options().Log.Append(stream, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *store) Read(stream string, opts ...ReadOption) ([]*Event, error) {
+	options := s.Options()
+
+	ro := ReadOptions{
+		Limit: options.BatchSize,
+	}
+	for _, o := range opts {
+		o(&ro)
+	}
+
+	offset := ro.Offset
+	if !ro.Since.IsZero() {
+		o, err := options.Log.Seek(stream, ro.Since)
+		if err != nil {
+			return nil, err
+		}
+		offset = o
+	}
+
+	return options.Log.Read(stream, offset, ro.Limit)
+}
+
+func (s *store) Consume(stream string, h Handler, opts ...ConsumeOption) (Consumer, error) {
+	var co ConsumeOptions
+	for _, o := range opts {
+		o(&co)
+	}
+
+	l := s.Options().Log
+
+	// resume from the committed offset if there is one
+	var offset string
+	if len(co.Group) > 0 {
+		o, err := l.Committed(co.Group, stream)
+		if err != nil {
+			return nil, err
+		}
+		offset = o
+	}
+
+	if len(offset) == 0 {
+		offset = co.Offset
+		if !co.Since.IsZero() {
+			o, err := l.Seek(stream, co.Since)
+			if err != nil {
+				return nil, err
+			}
+			offset = o
+		}
+	}
+
+	c := &consumer{
+		stream:  stream,
+		handler: h,
+		opts:    co,
+		store:   s,
+		exit:    make(chan bool),
+		offset:  offset,
+	}
+
+	go c.run()
+
+	return c, nil
+}
+
+func (s *store) Replay(stream string, since time.Time, h Handler) error {
+	options := s.Options()
+
+	offset, err := options.Log.Seek(stream, since)
+	if err != nil {
+		return err
+	}
+
+	for {
+		events, err := options.Log.Read(stream, offset, options.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := h(e); err != nil {
+				return err
+			}
+			offset = e.Offset
+		}
+		// caught up with the end of the stream
+		if len(events) < options.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *store) String() string {
+	return "events"
+}
+
+// wait returns false if the consumer is stopped while waiting
+func (c *consumer) wait(d time.Duration) bool {
+	select {
+	case <-c.exit:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// handle delivers the events in order returning the number handled
+func (c *consumer) handle(events []*Event) int {
+	for i, e := range events {
+		select {
+		case <-c.exit:
+			return i
+		default:
+		}
+
+		if err := c.handler(e); err != nil {
+			log.Logf("[events] handler failed for %s offset %s: %v", c.stream, e.Offset, err)
+			return i
+		}
+
+		c.Lock()
+		c.offset = e.Offset
+		c.Unlock()
+	}
+	return len(events)
+}
+
+func (c *consumer) run() {
+	for {
+		select {
+		case <-c.exit:
+			return
+		default:
+		}
+
+		options := c.store.Options()
+		offset := c.Offset()
+
+		events, err := options.Log.Read(c.stream, offset, options.BatchSize)
+		if err != nil {
+			log.Logf("[events] failed to read %s: %v", c.stream, err)
+			if !c.wait(options.PollInterval) {
+				return
+			}
+			continue
+		}
+
+		n := c.handle(events)
+
+		// commit what was handled
+		if n > 0 && len(c.opts.Group) > 0 {
+			if err := options.Log.Commit(c.opts.Group, c.stream, c.Offset()); err != nil {
+				log.Logf("[events] failed to commit %s offset for %s: %v", c.stream, c.opts.Group, err)
+			}
+		}
+
+		// wait when caught up or before redelivering a failed event
+		if n < len(events) || len(events) < options.BatchSize {
+			if !c.wait(options.PollInterval) {
+				return
+			}
+		}
+	}
+}
+
+func (c *consumer) Options() ConsumeOptions {
+	return c.opts
+}
+
+func (c *consumer) Offset() string {
+	c.RLock()
+	defer c.RUnlock()
+	return c.offset
+}
+
+func (c *consumer) Stop() error {
+	c.once.Do(func() {
+		close(c.exit)
+	})
+	return nil
+}
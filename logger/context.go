@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"strings"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type loggerKey struct{}
+
+// NewContext returns a context holding the logger
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger from the context
+func FromContext(ctx context.Context) (Logger, bool) {
+	l, ok := ctx.Value(loggerKey{}).(Logger)
+	return l, ok
+}
+
+// TraceId returns the trace id from the incoming metadata
+func TraceId(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	for k, v := range md {
+		switch strings.ToLower(k) {
+		case "traceparent":
+			if parts := strings.Split(v, "-"); len(parts) == 4 {
+				return parts[1]
+			}
+		case "uber-trace-id":
+			if parts := strings.Split(v, ":"); len(parts) == 4 {
+				return parts[0]
+			}
+		case "x-b3-traceid", "x-trace-id", "x-amzn-trace-id":
+			return v
+		}
+	}
+	return ""
+}
+
+func fields(ctx context.Context, f Fields) Fields {
+	if id := TraceId(ctx); len(id) > 0 {
+		f["trace_id"] = id
+	}
+	return f
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which places a logger
+// with the service, endpoint and trace id fields in the context
+func NewHandlerWrapper(l Logger) server.HandlerWrapper {
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			ll := l.WithFields(fields(ctx, Fields{
+				"service":  req.Service(),
+				"endpoint": req.Method(),
+			}))
+			return h(NewContext(ctx, ll), req, rsp)
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which places a
+// logger with the topic and trace id fields in the context
+func NewSubscriberWrapper(l Logger) server.SubscriberWrapper {
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			ll := l.WithFields(fields(ctx, Fields{
+				"topic": msg.Topic(),
+			}))
+			return fn(NewContext(ctx, ll), msg)
+		}
+	}
+}
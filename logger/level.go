@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-log"
+)
+
+type levelRequest struct {
+	Level string `json:"level"`
+}
+
+// Handler returns a http handler to get and change the level at runtime.
+// GET returns the level and PUT or POST sets it from a json body
+// e.g {"level": "debug"}
+func Handler(l Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+		case "PUT", "POST":
+			var req levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			level, err := ParseLevel(req.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetLevel(level)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelRequest{l.Level().String()})
+	})
+}
+
+// Watch sets the level from the config value at the path and updates
+// it when the value changes until the watcher fails
+func Watch(l Logger, c config.Config, path ...string) error {
+	set := func(v string) {
+		if len(v) == 0 {
+			return
+		}
+		level, err := ParseLevel(v)
+		if err != nil {
+			log.Logf("[logger] %v", err)
+			return
+		}
+		l.SetLevel(level)
+	}
+
+	set(c.Get(path...).String(""))
+
+	w, err := c.Watch(path...)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer w.Stop()
+		for {
+			v, err := w.Next()
+			if err != nil {
+				log.Logf("[logger] level watcher stopped: %v", err)
+				return
+			}
+			set(v.String(""))
+		}
+	}()
+
+	return nil
+}
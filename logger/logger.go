@@ -0,0 +1,129 @@
+// Package logger provides levels, structured fields and runtime level
+// changes for go-log. Adapters such as zap, zerolog and logrus implement
+// Backend and are installed with log.SetLogger from go-log.
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Level of a log message
+type Level int32
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+// Fields are structured fields logged with a message
+type Fields map[string]interface{}
+
+// Backend writes messages to the underlying logger
+type Backend interface {
+	Write(level Level, fields Fields, msg string)
+}
+
+// Logger is a go-log logger with levels and structured fields. Messages
+// logged through go-log are logged at info.
+type Logger interface {
+	Log(v ...interface{})
+	Logf(format string, v ...interface{})
+	// Levelf logs a message at the level
+	Levelf(level Level, format string, v ...interface{})
+	// WithFields returns a logger sharing the level which
+	// logs the fields with every message
+	WithFields(fields Fields) Logger
+	Level() Level
+	SetLevel(level Level)
+}
+
+type logger struct {
+	backend Backend
+	level   *int32
+	fields  Fields
+}
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// ParseLevel returns the level for its name
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("unknown level %s", s)
+}
+
+func (l *logger) enabled(level Level) bool {
+	return level >= Level(atomic.LoadInt32(l.level))
+}
+
+func (l *logger) Log(v ...interface{}) {
+	if l.enabled(InfoLevel) {
+		l.backend.Write(InfoLevel, l.fields, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+	}
+}
+
+func (l *logger) Logf(format string, v ...interface{}) {
+	l.Levelf(InfoLevel, format, v...)
+}
+
+func (l *logger) Levelf(level Level, format string, v ...interface{}) {
+	if l.enabled(level) {
+		l.backend.Write(level, l.fields, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *logger) WithFields(fields Fields) Logger {
+	f := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		f[k] = v
+	}
+	for k, v := range fields {
+		f[k] = v
+	}
+	return &logger{
+		backend: l.backend,
+		level:   l.level,
+		fields:  f,
+	}
+}
+
+func (l *logger) Level() Level {
+	return Level(atomic.LoadInt32(l.level))
+}
+
+func (l *logger) SetLevel(level Level) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+// New returns a logger writing to the backend at or above the level
+func New(b Backend, level Level) Logger {
+	lvl := int32(level)
+	return &logger{
+		backend: b,
+		level:   &lvl,
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type entry struct {
+	level  Level
+	fields Fields
+	msg    string
+}
+
+type testBackend struct {
+	entries []entry
+}
+
+func (t *testBackend) Write(level Level, fields Fields, msg string) {
+	t.entries = append(t.entries, entry{level, fields, msg})
+}
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func TestLevels(t *testing.T) {
+	b := &testBackend{}
+	l := New(b, InfoLevel)
+	child := l.WithFields(Fields{"foo": "bar"})
+
+	l.Levelf(DebugLevel, "dropped")
+	l.Log("hello", "world")
+	child.Levelf(WarnLevel, "warn %d", 1)
+
+	// the level is shared with children
+	l.SetLevel(DebugLevel)
+	child.Levelf(DebugLevel, "debug")
+
+	if len(b.entries) != 3 {
+		t.Fatalf("expected 3 entries got %d", len(b.entries))
+	}
+	if e := b.entries[0]; e.level != InfoLevel || e.msg != "hello world" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e := b.entries[1]; e.level != WarnLevel || e.msg != "warn 1" || e.fields["foo"] != "bar" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e := b.entries[2]; e.level != DebugLevel {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	l := New(&testBackend{}, InfoLevel)
+	h := Handler(l)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/level", strings.NewReader(`{"level":"debug"}`)))
+	if w.Code != http.StatusOK || l.Level() != DebugLevel {
+		t.Fatalf("expected level to be set got %d %v", w.Code, l.Level())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/level", nil))
+	if strings.TrimSpace(w.Body.String()) != `{"level":"debug"}` {
+		t.Fatalf("unexpected level %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/level", strings.NewReader(`{"level":"loud"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected bad request got %d", w.Code)
+	}
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	b := &testBackend{}
+
+	fn := NewHandlerWrapper(New(b, InfoLevel))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		l, ok := FromContext(ctx)
+		if !ok {
+			t.Fatal("expected logger in context")
+		}
+		l.Logf("handled")
+		return nil
+	})
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"Traceparent": "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+	})
+	if err := fn(ctx, &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	f := b.entries[0].fields
+	if f["service"] != "test.service" || f["endpoint"] != "Test.Method" || f["trace_id"] != "0102030405060708090a0b0c0d0e0f10" {
+		t.Fatalf("unexpected fields %v", f)
+	}
+}
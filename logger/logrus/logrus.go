@@ -0,0 +1,37 @@
+// Package logrus provides a go-log logger writing to logrus
+package logrus
+
+import (
+	"github.com/micro/go-plugins/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type backend struct {
+	l *logrus.Logger
+}
+
+func (b *backend) Write(level logger.Level, fields logger.Fields, msg string) {
+	e := b.l.WithFields(logrus.Fields(fields))
+
+	switch level {
+	case logger.DebugLevel:
+		e.Debug(msg)
+	case logger.WarnLevel:
+		e.Warn(msg)
+	case logger.ErrorLevel:
+		e.Error(msg)
+	default:
+		e.Info(msg)
+	}
+}
+
+// NewLogger returns a logger writing to the logrus logger at or above
+// the level. The standard logger is used if l is nil. The logrus level
+// is set to debug so the level is controlled by the returned logger.
+func NewLogger(l *logrus.Logger, level logger.Level) logger.Logger {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	l.SetLevel(logrus.DebugLevel)
+	return logger.New(&backend{l}, level)
+}
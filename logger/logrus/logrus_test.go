@@ -0,0 +1,30 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/micro/go-plugins/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	ll := logrus.New()
+	ll.Out = &buf
+	ll.Formatter = &logrus.JSONFormatter{}
+
+	l := NewLogger(ll, logger.InfoLevel).WithFields(logger.Fields{"service": "foo"})
+	l.Levelf(logger.DebugLevel, "dropped")
+	l.Levelf(logger.WarnLevel, "hello %s", "world")
+
+	var e map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e["level"] != "warning" || e["msg"] != "hello world" || e["service"] != "foo" {
+		t.Fatalf("unexpected entry %v", e)
+	}
+}
@@ -0,0 +1,44 @@
+// Package zap provides a go-log logger writing to zap
+package zap
+
+import (
+	"github.com/micro/go-plugins/logger"
+	"go.uber.org/zap"
+)
+
+type backend struct {
+	l *zap.Logger
+}
+
+func (b *backend) Write(level logger.Level, fields logger.Fields, msg string) {
+	zf := make([]zap.Field, 0, len(fields))
+	for k, v := range fields {
+		zf = append(zf, zap.Any(k, v))
+	}
+
+	switch level {
+	case logger.DebugLevel:
+		b.l.Debug(msg, zf...)
+	case logger.WarnLevel:
+		b.l.Warn(msg, zf...)
+	case logger.ErrorLevel:
+		b.l.Error(msg, zf...)
+	default:
+		b.l.Info(msg, zf...)
+	}
+}
+
+// NewLogger returns a logger writing to the zap logger at or above the
+// level. A production logger is used if l is nil. The zap logger should
+// be built at debug so the level is controlled by the returned logger.
+func NewLogger(l *zap.Logger, level logger.Level) logger.Logger {
+	if l == nil {
+		cfg := zap.NewProductionConfig()
+		cfg.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+		l, _ = cfg.Build()
+	}
+	if l == nil {
+		l = zap.NewNop()
+	}
+	return logger.New(&backend{l}, level)
+}
@@ -0,0 +1,41 @@
+// Package zerolog provides a go-log logger writing to zerolog
+package zerolog
+
+import (
+	"os"
+
+	"github.com/micro/go-plugins/logger"
+	"github.com/rs/zerolog"
+)
+
+type backend struct {
+	l zerolog.Logger
+}
+
+func (b *backend) Write(level logger.Level, fields logger.Fields, msg string) {
+	var e *zerolog.Event
+
+	switch level {
+	case logger.DebugLevel:
+		e = b.l.Debug()
+	case logger.WarnLevel:
+		e = b.l.Warn()
+	case logger.ErrorLevel:
+		e = b.l.Error()
+	default:
+		e = b.l.Info()
+	}
+
+	e.Fields(map[string]interface{}(fields)).Msg(msg)
+}
+
+// NewLogger returns a logger writing to the zerolog logger at or above
+// the level. A logger writing json to stderr is used if l is nil. The
+// zerolog global level still applies.
+func NewLogger(l *zerolog.Logger, level logger.Level) logger.Logger {
+	if l == nil {
+		zl := zerolog.New(os.Stderr).With().Timestamp().Logger()
+		l = &zl
+	}
+	return logger.New(&backend{l.Level(zerolog.DebugLevel)}, level)
+}
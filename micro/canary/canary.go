@@ -0,0 +1,195 @@
+// Package canary is a micro plugin for routing traffic to a version of a service
+// by weight, header or cookie for canary and blue/green releases
+package canary
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/micro/plugin"
+)
+
+type canary struct {
+	sync.RWMutex
+	opts Options
+}
+
+var (
+	// header forwarded as metadata to the client wrapper
+	versionHeader = "X-Micro-Route-Version"
+	// cookie value for requests not routed to the version
+	stable = "stable"
+	// how long a weighted assignment sticks
+	cookieExpiry = time.Hour
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+}
+
+func (c *canary) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "canary_version",
+			Usage:  "Service version to route canary traffic to",
+			EnvVar: "CANARY_VERSION",
+		},
+		cli.Float64Flag{
+			Name:   "canary_weight",
+			Usage:  "Fraction of traffic between 0 and 1.0 routed to the canary version",
+			EnvVar: "CANARY_WEIGHT",
+		},
+		cli.StringFlag{
+			Name:   "canary_header",
+			Usage:  "Header which routes a request to the version it holds. Defaults to X-Micro-Canary",
+			EnvVar: "CANARY_HEADER",
+		},
+		cli.StringFlag{
+			Name:   "canary_cookie",
+			Usage:  "Cookie which pins a client to a version. Defaults to micro_canary",
+			EnvVar: "CANARY_COOKIE",
+		},
+	}
+}
+
+func (c *canary) Commands() []cli.Command {
+	return nil
+}
+
+// route returns the version for the request and whether
+// a sticky cookie should be set
+func (c *canary) route(r *http.Request) (string, bool) {
+	c.RLock()
+	opts := c.opts
+	c.RUnlock()
+
+	// explicit header
+	if len(opts.Header) > 0 {
+		if v := r.Header.Get(opts.Header); len(v) > 0 {
+			return v, false
+		}
+	}
+
+	if len(opts.Version) == 0 || opts.Weight <= 0 {
+		return "", false
+	}
+
+	// sticky assignment from a previous request
+	if len(opts.Cookie) > 0 {
+		if ck, err := r.Cookie(opts.Cookie); err == nil {
+			switch ck.Value {
+			case opts.Version:
+				return opts.Version, false
+			case stable:
+				// re-roll if the weight changed to all traffic
+				if opts.Weight < 1.0 {
+					return "", false
+				}
+			}
+		}
+	}
+
+	if opts.Weight >= 1.0 || rand.Float64() < opts.Weight {
+		return opts.Version, true
+	}
+
+	return "", true
+}
+
+func (c *canary) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// never trust the internal header from clients
+			r.Header.Del(versionHeader)
+
+			version, sticky := c.route(r)
+
+			if sticky {
+				c.RLock()
+				name := c.opts.Cookie
+				c.RUnlock()
+
+				if len(name) > 0 {
+					value := version
+					if len(value) == 0 {
+						value = stable
+					}
+					http.SetCookie(w, &http.Cookie{
+						Name:     name,
+						Value:    value,
+						Path:     "/",
+						Expires:  time.Now().Add(cookieExpiry),
+						HttpOnly: true,
+					})
+				}
+			}
+
+			if len(version) > 0 {
+				r.Header.Set(versionHeader, version)
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *canary) Init(ctx *cli.Context) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if v := ctx.String("canary_version"); len(v) > 0 {
+		c.opts.Version = v
+	}
+	if w := ctx.Float64("canary_weight"); w > 0 {
+		c.opts.Weight = w
+	}
+	if v := ctx.String("canary_header"); len(v) > 0 {
+		c.opts.Header = v
+	}
+	if v := ctx.String("canary_cookie"); len(v) > 0 {
+		c.opts.Cookie = v
+	}
+
+	client.DefaultClient = newClient(client.DefaultClient, c.version)
+
+	return nil
+}
+
+// version returns the canary version while it receives traffic
+func (c *canary) version() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.opts.Weight <= 0 {
+		return ""
+	}
+	return c.opts.Version
+}
+
+func (c *canary) String() string {
+	return "canary"
+}
+
+// Set updates the version and weight at runtime e.g to progress a rollout
+func (c *canary) Set(version string, weight float64) {
+	c.Lock()
+	c.opts.Version = version
+	c.opts.Weight = weight
+	c.Unlock()
+}
+
+// Canary is the plugin with runtime control of the rollout
+type Canary interface {
+	plugin.Plugin
+	Set(version string, weight float64)
+}
+
+// NewPlugin returns a plugin which routes traffic to a service version
+func NewPlugin(opts ...Option) Canary {
+	return &canary{
+		opts: newOptions(opts...),
+	}
+}
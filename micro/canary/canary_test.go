@@ -0,0 +1,107 @@
+package canary
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestRoute(t *testing.T) {
+	c := NewPlugin(Version("v2", 0.5)).(*canary)
+
+	h := c.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(versionHeader)))
+	}))
+
+	do := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// explicit header
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Micro-Canary", "v3")
+	if w := do(r); w.Body.String() != "v3" {
+		t.Fatalf("expected header version got %s", w.Body.String())
+	}
+
+	// sticky cookies
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "micro_canary", Value: "v2"})
+	if w := do(r); w.Body.String() != "v2" {
+		t.Fatalf("expected cookie version got %s", w.Body.String())
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "micro_canary", Value: "stable"})
+	r.Header.Set(versionHeader, "spoofed")
+	if w := do(r); w.Body.String() != "" {
+		t.Fatalf("expected stable got %s", w.Body.String())
+	}
+
+	// weighted split sets a cookie
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		w := do(httptest.NewRequest("GET", "/", nil))
+		counts[w.Body.String()]++
+		if len(w.Header().Get("Set-Cookie")) == 0 {
+			t.Fatal("expected sticky cookie")
+		}
+	}
+	if counts["v2"] < 400 || counts["v2"] > 600 {
+		t.Fatalf("unexpected split %v", counts)
+	}
+
+	// blue/green switches everything including stable clients
+	c.Set("v2", 1.0)
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "micro_canary", Value: "stable"})
+	if w := do(r); w.Body.String() != "v2" {
+		t.Fatalf("expected all traffic to v2 got %s", w.Body.String())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	services := []*registry.Service{
+		{Name: "foo", Version: "v1", Nodes: []*registry.Node{{Id: "1"}}},
+		{Name: "foo", Version: "v2", Nodes: []*registry.Node{{Id: "2"}}},
+	}
+
+	if s := filter("v2")(services); len(s) != 1 || s[0].Version != "v2" {
+		t.Fatalf("expected v2 got %+v", s)
+	}
+
+	// unknown versions fall back to all
+	if s := filter("v3")(services); len(s) != 2 {
+		t.Fatalf("expected fallback got %+v", s)
+	}
+
+	// stable traffic excludes the canary
+	if s := exclude("v2")(services); len(s) != 1 || s[0].Version != "v1" {
+		t.Fatalf("expected v1 got %+v", s)
+	}
+
+	// unless only the canary has nodes
+	if s := exclude("v2")(services[1:]); len(s) != 1 || s[0].Version != "v2" {
+		t.Fatalf("expected fallback got %+v", s)
+	}
+}
+
+func TestStable(t *testing.T) {
+	c := NewPlugin(Version("v2", 0.5)).(*canary)
+	w := &wrapper{canary: c.version}
+
+	if opts := w.route(context.Background(), nil); len(opts) != 1 {
+		t.Fatalf("expected stable requests to exclude the canary got %d options", len(opts))
+	}
+
+	// no canary once the rollout is stopped
+	c.Set("v2", 0)
+	if opts := w.route(context.Background(), nil); len(opts) != 0 {
+		t.Fatalf("expected no routing got %d options", len(opts))
+	}
+}
@@ -0,0 +1,51 @@
+package canary
+
+type Options struct {
+	// Version traffic is routed to
+	Version string
+	// Weight is the fraction of traffic between 0 and 1.0 routed to
+	// the version. 1.0 switches all traffic as in a blue/green release.
+	Weight float64
+	// Header which routes a request to the version it holds
+	Header string
+	// Cookie which routes a request to the version it holds. It's set
+	// on weighted requests so clients stick to their version.
+	Cookie string
+}
+
+type Option func(o *Options)
+
+// Version sets the version and fraction of traffic routed to it
+func Version(v string, weight float64) Option {
+	return func(o *Options) {
+		o.Version = v
+		o.Weight = weight
+	}
+}
+
+// Header sets the header used to select a version
+func Header(h string) Option {
+	return func(o *Options) {
+		o.Header = h
+	}
+}
+
+// Cookie sets the cookie used to select a version
+func Cookie(c string) Option {
+	return func(o *Options) {
+		o.Cookie = c
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Header: "X-Micro-Canary",
+		Cookie: "micro_canary",
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,83 @@
+package canary
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+type wrapper struct {
+	client.Client
+	// returns the canary version
+	canary func() string
+}
+
+// filter returns the services of the version, or all of them if
+// the version has no nodes so traffic isn't dropped
+func filter(version string) selector.Filter {
+	return func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+		for _, service := range services {
+			if service.Version == version && len(service.Nodes) > 0 {
+				filtered = append(filtered, service)
+			}
+		}
+		if len(filtered) == 0 {
+			return services
+		}
+		return filtered
+	}
+}
+
+// exclude returns the services other than the canary version so stable
+// traffic isn't routed to it, or all of them if only the canary has nodes
+func exclude(version string) selector.Filter {
+	return func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+		for _, service := range services {
+			if service.Version != version && len(service.Nodes) > 0 {
+				filtered = append(filtered, service)
+			}
+		}
+		if len(filtered) == 0 {
+			return services
+		}
+		return filtered
+	}
+}
+
+// version returns the version set by the handler
+func version(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return md[versionHeader]
+}
+
+// route sends requests to the version set by the handler and
+// stable requests away from the canary
+func (w *wrapper) route(ctx context.Context, opts []client.CallOption) []client.CallOption {
+	if v := version(ctx); len(v) > 0 {
+		return append(opts, client.WithSelectOption(selector.WithFilter(filter(v))))
+	}
+	if v := w.canary(); len(v) > 0 {
+		return append(opts, client.WithSelectOption(selector.WithFilter(exclude(v))))
+	}
+	return opts
+}
+
+func (w *wrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return w.Client.Call(ctx, req, rsp, w.route(ctx, opts)...)
+}
+
+func (w *wrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return w.Client.Stream(ctx, req, w.route(ctx, opts)...)
+}
+
+func newClient(c client.Client, canary func() string) client.Client {
+	return &wrapper{c, canary}
+}
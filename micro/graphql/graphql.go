@@ -0,0 +1,316 @@
+// Package graphql is a micro plugin which serves a GraphQL schema
+// generated from the endpoints of all registered services. Fields are
+// resolved by calling the services so one query may fetch data from
+// several services in a single round trip.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/micro/plugin"
+)
+
+type graphqlPlugin struct {
+	opts Options
+
+	sync.RWMutex
+	schema  *graphql.Schema
+	updated time.Time
+}
+
+// request is a graphql request sent as json or query parameters
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (g *graphqlPlugin) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "graphql_path",
+			Usage:  "Path to serve the GraphQL endpoint on e.g /graphql",
+			EnvVar: "GRAPHQL_PATH",
+		},
+		cli.StringFlag{
+			Name:   "graphql_namespace",
+			Usage:  "Namespace of the exposed services e.g go.micro.api",
+			EnvVar: "GRAPHQL_NAMESPACE",
+		},
+		cli.StringSliceFlag{
+			Name:   "graphql_service",
+			Usage:  "Service to expose, all services in the namespace if unset",
+			EnvVar: "GRAPHQL_SERVICE",
+		},
+		cli.BoolFlag{
+			Name:   "graphql_list_services",
+			Usage:  "Add the _services query listing the exposed services",
+			EnvVar: "GRAPHQL_LIST_SERVICES",
+		},
+		cli.IntFlag{
+			Name:   "graphql_interval",
+			Usage:  "Interval in seconds at which the schema is rebuilt",
+			EnvVar: "GRAPHQL_INTERVAL",
+		},
+	}
+}
+
+func (g *graphqlPlugin) Commands() []cli.Command {
+	return nil
+}
+
+// exposed returns true if the service is in the namespace and allowlist
+func (g *graphqlPlugin) exposed(name string) bool {
+	if len(g.opts.Namespace) > 0 && !strings.HasPrefix(name, g.opts.Namespace+".") {
+		return false
+	}
+
+	if len(g.opts.Services) == 0 {
+		return true
+	}
+
+	for _, s := range g.opts.Services {
+		if s == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generate builds the schema from the exposed services of the registry
+func (g *graphqlPlugin) generate() (*graphql.Schema, error) {
+	list, err := g.opts.Registry.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	var services []*registry.Service
+
+	for _, s := range list {
+		if !g.exposed(s.Name) {
+			continue
+		}
+
+		// list services does not include endpoints
+		svcs, err := g.opts.Registry.GetService(s.Name)
+		if err != nil {
+			log.Logf("[graphql] failed to get service %s: %v", s.Name, err)
+			continue
+		}
+		if s := merge(svcs); s != nil {
+			services = append(services, s)
+		}
+	}
+
+	schema, err := build(g.opts, services)
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// merge collapses versions of a service into one, keeping all nodes
+// so that descriptors may be found and all endpoints are described
+func merge(svcs []*registry.Service) *registry.Service {
+	if len(svcs) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	service := &registry.Service{
+		Name: svcs[0].Name,
+	}
+
+	for _, s := range svcs {
+		service.Nodes = append(service.Nodes, s.Nodes...)
+		for _, ep := range s.Endpoints {
+			if ep == nil || seen[ep.Name] {
+				continue
+			}
+			seen[ep.Name] = true
+			service.Endpoints = append(service.Endpoints, ep)
+		}
+	}
+
+	return service
+}
+
+// get returns the cached schema rebuilding it when stale
+func (g *graphqlPlugin) get() (*graphql.Schema, error) {
+	g.RLock()
+	schema, updated := g.schema, g.updated
+	g.RUnlock()
+
+	if schema != nil && time.Since(updated) < g.opts.Interval {
+		return schema, nil
+	}
+
+	s, err := g.generate()
+	if err != nil {
+		// serve stale rather than nothing
+		if schema != nil {
+			log.Logf("[graphql] failed to rebuild schema: %v", err)
+			return schema, nil
+		}
+		return nil, err
+	}
+
+	g.Lock()
+	g.schema = s
+	g.updated = time.Now()
+	g.Unlock()
+
+	return s, nil
+}
+
+// parse reads the graphql request from a GET or POST
+func parse(r *http.Request) (*request, error) {
+	req := new(request)
+
+	if r.Method == "GET" {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); len(v) > 0 {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return nil, err
+			}
+		}
+		return req, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// operation returns the type of the operation the request executes
+func operation(req *request) (string, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return "", err
+	}
+
+	for _, d := range doc.Definitions {
+		op, ok := d.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if len(req.OperationName) == 0 || (op.Name != nil && op.Name.Value == req.OperationName) {
+			return op.Operation, nil
+		}
+	}
+
+	return "", errors.New("operation not found")
+}
+
+// requestContext forwards the request headers as metadata to the services
+func requestContext(r *http.Request) context.Context {
+	md := make(metadata.Metadata, len(r.Header))
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			md[k] = v[0]
+		}
+	}
+	return metadata.NewContext(r.Context(), md)
+}
+
+func (g *graphqlPlugin) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// GET requests are only for queries, mutations must be posted
+	if r.Method == "GET" {
+		op, err := operation(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if op != ast.OperationTypeQuery {
+			http.Error(w, "mutations must be sent with POST", http.StatusMethodNotAllowed)
+			return
+		}
+	}
+
+	schema, err := g.get()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	res := graphql.Do(graphql.Params{
+		Schema:         *schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        requestContext(r),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (g *graphqlPlugin) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != g.opts.Path {
+				h.ServeHTTP(w, r)
+				return
+			}
+			g.serve(w, r)
+		})
+	}
+}
+
+func (g *graphqlPlugin) Init(ctx *cli.Context) error {
+	if p := ctx.String("graphql_path"); len(p) > 0 {
+		g.opts.Path = p
+	}
+	if n := ctx.String("graphql_namespace"); len(n) > 0 {
+		g.opts.Namespace = n
+	}
+	if s := ctx.StringSlice("graphql_service"); len(s) > 0 {
+		g.opts.Services = append(g.opts.Services, s...)
+	}
+	if ctx.Bool("graphql_list_services") {
+		g.opts.ListServices = true
+	}
+	if i := ctx.Int("graphql_interval"); i > 0 {
+		g.opts.Interval = time.Duration(i) * time.Second
+	}
+	return nil
+}
+
+func (g *graphqlPlugin) String() string {
+	return "graphql"
+}
+
+// NewPlugin returns a plugin which serves the GraphQL endpoint
+func NewPlugin(opts ...Option) plugin.Plugin {
+	return &graphqlPlugin{
+		opts: newOptions(opts...),
+	}
+}
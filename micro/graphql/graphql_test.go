@@ -0,0 +1,246 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+)
+
+type testRequest struct {
+	client.Request
+	service string
+	method  string
+	body    interface{}
+}
+
+func (t *testRequest) Service() string {
+	return t.service
+}
+
+func (t *testRequest) Method() string {
+	return t.method
+}
+
+type testClient struct {
+	client.Client
+	calls []string
+}
+
+func (t *testClient) NewRequest(service, method string, req interface{}, opts ...client.RequestOption) client.Request {
+	return &testRequest{service: service, method: method, body: req}
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	r := req.(*testRequest)
+	t.calls = append(t.calls, r.service+"."+r.method+" "+string(r.body.(json.RawMessage)))
+
+	var b string
+	switch r.method {
+	case "Greeter.Hello":
+		b = `{"msg":"hello","count":"2","tags":["a"],"meta":{"x":1}}`
+	case "Users.Create":
+		b = `{"id":"1"}`
+	}
+	*(rsp.(*json.RawMessage)) = json.RawMessage(b)
+	return nil
+}
+
+func testServices() []*registry.Service {
+	return []*registry.Service{
+		{
+			Name: "go.micro.api.greeter",
+			Endpoints: []*registry.Endpoint{
+				{
+					Name: "Greeter.Hello",
+					Request: &registry.Value{
+						Name: "Request",
+						Type: "Request",
+						Values: []*registry.Value{
+							{Name: "name", Type: "string"},
+						},
+					},
+					Response: &registry.Value{
+						Name: "Response",
+						Type: "Response",
+						Values: []*registry.Value{
+							{Name: "msg", Type: "string"},
+							{Name: "count", Type: "int64"},
+							{Name: "tags", Type: "[]string"},
+							{Name: "meta", Type: "map[string]int"},
+						},
+					},
+					Metadata: map[string]string{"method": "GET"},
+				},
+			},
+		},
+		{
+			Name: "go.micro.api.users",
+			Endpoints: []*registry.Endpoint{
+				{
+					Name: "Users.Create",
+					Request: &registry.Value{
+						Name: "Request",
+						Type: "Request",
+						Values: []*registry.Value{
+							{Name: "name", Type: "string"},
+						},
+					},
+					Response: &registry.Value{
+						Name: "Response",
+						Type: "Response",
+						Values: []*registry.Value{
+							{Name: "id", Type: "string"},
+						},
+					},
+					Metadata: map[string]string{"method": "POST"},
+				},
+			},
+		},
+		{
+			Name: "go.micro.srv.secrets",
+			Endpoints: []*registry.Endpoint{
+				{
+					Name:     "Secrets.Read",
+					Request:  &registry.Value{Name: "Request", Type: "Request"},
+					Response: &registry.Value{Name: "Response", Type: "Response"},
+					Metadata: map[string]string{"method": "GET"},
+				},
+			},
+		},
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := mock.NewRegistry()
+	for _, s := range testServices() {
+		if err := r.Register(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &testClient{}
+	p := NewPlugin(Registry(r), Client(c))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := p.Handler()(next)
+
+	do := func(query string) map[string]interface{} {
+		b, _ := json.Marshal(map[string]string{"query": query})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(b))))
+		if w.Code != 200 {
+			t.Fatalf("expected 200 got %d", w.Code)
+		}
+		var rsp map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+			t.Fatal(err)
+		}
+		if errs, ok := rsp["errors"]; ok {
+			t.Fatalf("unexpected errors %v", errs)
+		}
+		return rsp["data"].(map[string]interface{})
+	}
+
+	data := do(`{ greeter { Greeter_Hello(name: "john") { msg count tags meta } } }`)
+
+	hello := data["greeter"].(map[string]interface{})["Greeter_Hello"].(map[string]interface{})
+	if hello["msg"] != "hello" || hello["count"] != "2" {
+		t.Fatalf("unexpected response %v", hello)
+	}
+	if meta, ok := hello["meta"].(map[string]interface{}); !ok || meta["x"] != float64(1) {
+		t.Fatalf("unexpected meta %v", hello["meta"])
+	}
+	if len(c.calls) != 1 || c.calls[0] != `go.micro.api.greeter.Greeter.Hello {"name":"john"}` {
+		t.Fatalf("unexpected calls %v", c.calls)
+	}
+
+	data = do(`mutation { users { Users_Create(name: "john") { id } } }`)
+
+	create := data["users"].(map[string]interface{})["Users_Create"].(map[string]interface{})
+	if create["id"] != "1" {
+		t.Fatalf("unexpected response %v", create)
+	}
+
+	// passthrough
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/foo", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("expected passthrough got %d", w.Code)
+	}
+}
+
+func TestExposure(t *testing.T) {
+	r := mock.NewRegistry()
+	for _, s := range testServices() {
+		if err := r.Register(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(opts ...Option) []string {
+		p := NewPlugin(append([]Option{Registry(r), Client(&testClient{})}, opts...)...).(*graphqlPlugin)
+		schema, err := p.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var fields []string
+		for name := range schema.QueryType().Fields() {
+			fields = append(fields, name)
+		}
+		if m := schema.MutationType(); m != nil {
+			for name := range m.Fields() {
+				fields = append(fields, name)
+			}
+		}
+		sort.Strings(fields)
+		return fields
+	}
+
+	// services outside the namespace aren't exposed
+	if f := names(); strings.Join(f, ",") != "greeter,users" {
+		t.Fatalf("unexpected fields %v", f)
+	}
+
+	if f := names(Services("go.micro.api.users")); strings.Join(f, ",") != "_empty,users" {
+		t.Fatalf("unexpected fields %v", f)
+	}
+
+	if f := names(ListServices(true)); strings.Join(f, ",") != "_services,greeter,users" {
+		t.Fatalf("unexpected fields %v", f)
+	}
+}
+
+func TestGetMutation(t *testing.T) {
+	r := mock.NewRegistry()
+	for _, s := range testServices() {
+		if err := r.Register(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &testClient{}
+	h := NewPlugin(Registry(r), Client(c)).Handler()(http.NotFoundHandler())
+
+	q := url.Values{"query": {`mutation { users { Users_Create(name: "john") { id } } }`}}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?"+q.Encode(), nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 got %d", w.Code)
+	}
+	if len(c.calls) != 0 {
+		t.Fatalf("expected no calls got %v", c.calls)
+	}
+}
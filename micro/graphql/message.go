@@ -0,0 +1,225 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/micro/go-micro/registry"
+)
+
+// MetadataKey is the node metadata key holding a base64 encoded
+// serialised FileDescriptorSet for the service, as used by openapi
+var MetadataKey = "descriptor"
+
+// message describes a request or response independent of
+// whether it came from a proto descriptor or registry value
+type message struct {
+	name   string
+	fields []*field
+}
+
+type field struct {
+	// json name of the field
+	name string
+	// scalar type, one of the keys of scalars
+	scalar string
+	// nested message
+	message *message
+	// arbitrary json
+	json bool
+	list bool
+}
+
+// messages converts the descriptors and registry values of a service
+type messages struct {
+	desc  map[string]*descriptor.DescriptorProto
+	cache map[string]*message
+}
+
+func newMessages(service *registry.Service) *messages {
+	m := &messages{
+		cache: make(map[string]*message),
+	}
+	for _, node := range service.Nodes {
+		if d := parseDescriptors(node.Metadata); d != nil {
+			m.desc = d
+			break
+		}
+	}
+	return m
+}
+
+// parseDescriptors returns the messages found in node metadata or nil
+func parseDescriptors(md map[string]string) map[string]*descriptor.DescriptorProto {
+	v, ok := md[MetadataKey]
+	if !ok || len(v) == 0 {
+		return nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil
+	}
+
+	var set descriptor.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil
+	}
+
+	desc := make(map[string]*descriptor.DescriptorProto)
+
+	var add func(prefix string, m *descriptor.DescriptorProto)
+	add = func(prefix string, m *descriptor.DescriptorProto) {
+		name := join(prefix, m.GetName())
+		desc[name] = m
+		for _, n := range m.NestedType {
+			add(name, n)
+		}
+	}
+
+	for _, f := range set.File {
+		for _, m := range f.MessageType {
+			add(f.GetPackage(), m)
+		}
+	}
+
+	return desc
+}
+
+func join(prefix, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func shortName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// lookup finds a descriptor by its full or short name
+func (m *messages) lookup(name string) (string, *descriptor.DescriptorProto) {
+	name = strings.TrimPrefix(name, ".")
+	if d, ok := m.desc[name]; ok {
+		return name, d
+	}
+	for full, d := range m.desc {
+		if strings.HasSuffix(full, "."+name) {
+			return full, d
+		}
+	}
+	return "", nil
+}
+
+// value returns the message for an endpoint request or response,
+// preferring the descriptor since it carries json names
+func (m *messages) value(v *registry.Value) *message {
+	if v == nil {
+		return &message{}
+	}
+
+	name := shortName(strings.TrimPrefix(v.Type, "*"))
+	if len(name) == 0 {
+		name = v.Name
+	}
+
+	if full, d := m.lookup(name); d != nil {
+		return m.descriptor(full, d)
+	}
+
+	return m.fromValue(name, v)
+}
+
+func (m *messages) fromValue(name string, v *registry.Value) *message {
+	if msg, ok := m.cache[name]; ok {
+		return msg
+	}
+
+	msg := &message{name: name}
+	// cache before recursing to break cycles
+	m.cache[name] = msg
+
+	for _, f := range v.Values {
+		if f == nil {
+			continue
+		}
+		msg.fields = append(msg.fields, m.valueField(f.Name, strings.TrimPrefix(f.Type, "*"), f))
+	}
+
+	return msg
+}
+
+func (m *messages) valueField(name, typ string, v *registry.Value) *field {
+	if strings.HasPrefix(typ, "[]") && typ != "[]byte" && typ != "[]uint8" {
+		f := m.valueField(name, strings.TrimPrefix(strings.TrimPrefix(typ, "[]"), "*"), v)
+		f.list = true
+		return f
+	}
+
+	if strings.HasPrefix(typ, "map[") {
+		return &field{name: name, json: true}
+	}
+
+	if _, ok := scalars[typ]; ok {
+		return &field{name: name, scalar: typ}
+	}
+
+	if len(v.Values) == 0 {
+		return &field{name: name, json: true}
+	}
+
+	return &field{name: name, message: m.fromValue(shortName(typ), v)}
+}
+
+func (m *messages) descriptor(full string, d *descriptor.DescriptorProto) *message {
+	name := shortName(full)
+	if msg, ok := m.cache[name]; ok {
+		return msg
+	}
+
+	msg := &message{name: name}
+	m.cache[name] = msg
+
+	for _, f := range d.Field {
+		msg.fields = append(msg.fields, m.descriptorField(f))
+	}
+
+	return msg
+}
+
+func (m *messages) descriptorField(f *descriptor.FieldDescriptorProto) *field {
+	fd := &field{
+		name: f.GetJsonName(),
+		list: f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED,
+	}
+
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		full, d := m.lookup(f.GetTypeName())
+		switch {
+		case d == nil:
+			fd.json = true
+		case d.GetOptions().GetMapEntry():
+			// map fields are repeated synthetic entry messages
+			fd.json = true
+			fd.list = false
+		default:
+			fd.message = m.descriptor(full, d)
+		}
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		// enums are encoded by name
+		fd.scalar = "string"
+	default:
+		fd.scalar = strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+		if _, ok := scalars[fd.scalar]; !ok {
+			fd.json = true
+		}
+	}
+
+	return fd
+}
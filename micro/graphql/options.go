@@ -0,0 +1,94 @@
+package graphql
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/registry"
+)
+
+type Options struct {
+	// Registry to build the schema from
+	Registry registry.Registry
+	// Client used to resolve fields
+	Client client.Client
+	// Path the graphql endpoint is served on
+	Path string
+	// Namespace of the exposed services, stripped from service names
+	// when building fields. Services outside it are never exposed.
+	Namespace string
+	// Services is an allowlist of the exposed services by full name,
+	// all services in the namespace are exposed if empty
+	Services []string
+	// ListServices adds the _services query listing the exposed services
+	ListServices bool
+	// Interval at which the schema is rebuilt
+	Interval time.Duration
+}
+
+type Option func(o *Options)
+
+// Registry sets the registry used to discover services
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// Client sets the client used to call services
+func Client(c client.Client) Option {
+	return func(o *Options) {
+		o.Client = c
+	}
+}
+
+// Path sets the path the graphql endpoint is served on
+func Path(p string) Option {
+	return func(o *Options) {
+		o.Path = p
+	}
+}
+
+// Namespace sets the namespace of the exposed services e.g go.micro.api
+func Namespace(n string) Option {
+	return func(o *Options) {
+		o.Namespace = n
+	}
+}
+
+// Services only exposes the named services of the namespace
+func Services(names ...string) Option {
+	return func(o *Options) {
+		o.Services = append(o.Services, names...)
+	}
+}
+
+// ListServices adds the _services query listing the exposed services
+func ListServices(b bool) Option {
+	return func(o *Options) {
+		o.ListServices = b
+	}
+}
+
+// Interval sets how often the schema is rebuilt from the registry
+func Interval(d time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Registry:  registry.DefaultRegistry,
+		Client:    client.DefaultClient,
+		Path:      "/graphql",
+		Namespace: "go.micro.api",
+		Interval:  time.Minute,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,382 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/registry"
+)
+
+var (
+	validName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+	invalid   = regexp.MustCompile(`[^_0-9A-Za-z]`)
+)
+
+// scalars maps go and proto scalar types to graphql types
+var scalars = map[string]*graphql.Scalar{
+	"string": graphql.String,
+	"bool":   graphql.Boolean,
+	// 64 bit integers are encoded as strings by jsonpb
+	"int64":    graphql.String,
+	"uint64":   graphql.String,
+	"uint":     graphql.String,
+	"sint64":   graphql.String,
+	"fixed64":  graphql.String,
+	"sfixed64": graphql.String,
+	"int":      graphql.Int,
+	"int8":     graphql.Int,
+	"int16":    graphql.Int,
+	"int32":    graphql.Int,
+	"uint8":    graphql.Int,
+	"uint16":   graphql.Int,
+	"uint32":   graphql.Int,
+	"sint32":   graphql.Int,
+	"fixed32":  graphql.Int,
+	"sfixed32": graphql.Int,
+	"float32":  graphql.Float,
+	"float64":  graphql.Float,
+	"float":    graphql.Float,
+	"double":   graphql.Float,
+	// bytes are base64 encoded
+	"[]byte":  graphql.String,
+	"[]uint8": graphql.String,
+	"bytes":   graphql.String,
+}
+
+// jsonScalar holds maps, messages without known fields and other
+// values which can't be described as graphql types
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "Arbitrary JSON value",
+	Serialize:    func(v interface{}) interface{} { return v },
+	ParseValue:   func(v interface{}) interface{} { return v },
+	ParseLiteral: parseLiteral,
+})
+
+func parseLiteral(v ast.Value) interface{} {
+	switch v := v.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		i, _ := strconv.ParseInt(v.Value, 10, 64)
+		return i
+	case *ast.FloatValue:
+		f, _ := strconv.ParseFloat(v.Value, 64)
+		return f
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(v.Values))
+		for _, item := range v.Values {
+			list = append(list, parseLiteral(item))
+		}
+		return list
+	case *ast.ObjectValue:
+		obj := make(map[string]interface{}, len(v.Fields))
+		for _, f := range v.Fields {
+			obj[f.Name.Value] = parseLiteral(f.Value)
+		}
+		return obj
+	}
+	return nil
+}
+
+// typeName returns a valid graphql name for a service or endpoint
+func typeName(name string) string {
+	name = invalid.ReplaceAllString(name, "_")
+	if !validName.MatchString(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// builder generates a schema from registry services
+type builder struct {
+	opts Options
+
+	outputs map[string]graphql.Output
+	inputs  map[string]graphql.Input
+}
+
+// build generates a schema with a query and mutation field per service
+func build(opts Options, services []*registry.Service) (graphql.Schema, error) {
+	b := &builder{
+		opts:    opts,
+		outputs: make(map[string]graphql.Output),
+		inputs:  make(map[string]graphql.Input),
+	}
+
+	// deterministic output
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	var names []interface{}
+	query := graphql.Fields{}
+	mutation := graphql.Fields{}
+
+	for _, service := range services {
+		names = append(names, service.Name)
+		b.addService(service, query, mutation)
+	}
+
+	if opts.ListServices {
+		query["_services"] = &graphql.Field{
+			Type:        graphql.NewList(graphql.String),
+			Description: "Names of the services in the schema",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return names, nil
+			},
+		}
+	}
+
+	// the query type must have at least one field
+	if len(query) == 0 {
+		query["_empty"] = &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Placeholder as no service has queries",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return nil, nil
+			},
+		}
+	}
+
+	config := graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: query,
+		}),
+	}
+
+	if len(mutation) > 0 {
+		config.Mutation = graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Mutation",
+			Fields: mutation,
+		})
+	}
+
+	return graphql.NewSchema(config)
+}
+
+// serviceName strips the namespace from the service name
+func (b *builder) serviceName(name string) string {
+	if len(b.opts.Namespace) > 0 {
+		name = strings.TrimPrefix(name, b.opts.Namespace+".")
+	}
+	return typeName(name)
+}
+
+// mutation returns true unless the endpoint metadata sets the http
+// method GET, so only endpoints declared safe to repeat are queries
+func mutation(ep *registry.Endpoint) bool {
+	return strings.ToUpper(ep.Metadata["method"]) != "GET"
+}
+
+func (b *builder) addService(service *registry.Service, query, mutations graphql.Fields) {
+	name := b.serviceName(service.Name)
+	msgs := newMessages(service)
+
+	queryFields := graphql.Fields{}
+	mutationFields := graphql.Fields{}
+
+	for _, ep := range service.Endpoints {
+		if ep == nil {
+			continue
+		}
+
+		fields := queryFields
+		if mutation(ep) {
+			fields = mutationFields
+		}
+
+		fields[typeName(ep.Name)] = &graphql.Field{
+			Type:        b.output(name, msgs.value(ep.Response)),
+			Description: ep.Metadata["description"],
+			Args:        b.args(name, msgs.value(ep.Request)),
+			Resolve:     b.resolve(service.Name, ep.Name),
+		}
+	}
+
+	// services are objects so one query may call several endpoints
+	resolve := func(p graphql.ResolveParams) (interface{}, error) {
+		return struct{}{}, nil
+	}
+
+	if len(queryFields) > 0 {
+		query[name] = &graphql.Field{
+			Type: graphql.NewObject(graphql.ObjectConfig{
+				Name:        name + "_Query",
+				Description: service.Name,
+				Fields:      queryFields,
+			}),
+			Resolve: resolve,
+		}
+	}
+
+	if len(mutationFields) > 0 {
+		mutations[name] = &graphql.Field{
+			Type: graphql.NewObject(graphql.ObjectConfig{
+				Name:        name + "_Mutation",
+				Description: service.Name,
+				Fields:      mutationFields,
+			}),
+			Resolve: resolve,
+		}
+	}
+}
+
+// args returns the request fields as arguments
+func (b *builder) args(prefix string, m *message) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{}
+	for _, f := range m.fields {
+		if !validName.MatchString(f.name) {
+			continue
+		}
+		args[f.name] = &graphql.ArgumentConfig{
+			Type: b.fieldInput(prefix, f),
+		}
+	}
+	return args
+}
+
+// output returns the object type for the message, types are
+// prefixed with the service since message names aren't unique
+func (b *builder) output(prefix string, m *message) graphql.Output {
+	if len(m.fields) == 0 {
+		return jsonScalar
+	}
+
+	name := prefix + "_" + typeName(m.name)
+	if t, ok := b.outputs[name]; ok {
+		return t
+	}
+
+	obj := graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		// resolved lazily to allow recursive messages
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			fields := graphql.Fields{}
+			for _, f := range m.fields {
+				if !validName.MatchString(f.name) {
+					continue
+				}
+				fields[f.name] = &graphql.Field{
+					Type: b.fieldOutput(prefix, f),
+				}
+			}
+			return fields
+		}),
+	})
+	b.outputs[name] = obj
+
+	return obj
+}
+
+func (b *builder) fieldOutput(prefix string, f *field) graphql.Output {
+	var t graphql.Output
+
+	switch {
+	case f.json:
+		t = jsonScalar
+	case f.message != nil:
+		t = b.output(prefix, f.message)
+	default:
+		t = scalars[f.scalar]
+	}
+
+	if f.list {
+		return graphql.NewList(t)
+	}
+	return t
+}
+
+// input returns the input object type for the message
+func (b *builder) input(prefix string, m *message) graphql.Input {
+	if len(m.fields) == 0 {
+		return jsonScalar
+	}
+
+	name := prefix + "_" + typeName(m.name) + "Input"
+	if t, ok := b.inputs[name]; ok {
+		return t
+	}
+
+	obj := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: name,
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			fields := graphql.InputObjectConfigFieldMap{}
+			for _, f := range m.fields {
+				if !validName.MatchString(f.name) {
+					continue
+				}
+				fields[f.name] = &graphql.InputObjectFieldConfig{
+					Type: b.fieldInput(prefix, f),
+				}
+			}
+			return fields
+		}),
+	})
+	b.inputs[name] = obj
+
+	return obj
+}
+
+func (b *builder) fieldInput(prefix string, f *field) graphql.Input {
+	var t graphql.Input
+
+	switch {
+	case f.json:
+		t = jsonScalar
+	case f.message != nil:
+		t = b.input(prefix, f.message)
+	default:
+		t = scalars[f.scalar]
+	}
+
+	if f.list {
+		return graphql.NewList(t)
+	}
+	return t
+}
+
+// resolve calls the endpoint with the arguments as the json request
+func (b *builder) resolve(service, endpoint string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		body, err := json.Marshal(p.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx := p.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		req := b.opts.Client.NewRequest(
+			service,
+			endpoint,
+			json.RawMessage(body),
+			client.WithContentType("application/json"),
+		)
+
+		var rsp json.RawMessage
+		if err := b.opts.Client.Call(ctx, req, &rsp); err != nil {
+			return nil, err
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(rsp, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
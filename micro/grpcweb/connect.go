@@ -0,0 +1,145 @@
+package grpcweb
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/micro/grpc-go/codes"
+)
+
+// connectError is the json error of the connect protocol
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// endStream is the final message of a connect stream
+type endStream struct {
+	Error *connectError `json:"error,omitempty"`
+}
+
+func newConnectError(code codes.Code, msg string) (*connectError, int) {
+	c, ok := connectCodes[code]
+	if !ok {
+		c = connectCodes[codes.Unknown]
+	}
+	return &connectError{Code: c.name, Message: msg}, c.status
+}
+
+// writeConnectError writes a unary error response
+func writeConnectError(w http.ResponseWriter, code codes.Code, msg string) {
+	cerr, status := newConnectError(code, msg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(cerr)
+}
+
+// serveConnectUnary handles connect unary requests, where the body is
+// the message and errors are returned as json with a http status
+func (g *grpcWeb) serveConnectUnary(w http.ResponseWriter, r *http.Request, service, method string) {
+	if e := r.Header.Get("Content-Encoding"); len(e) > 0 && e != "identity" {
+		writeConnectError(w, codes.Unimplemented, errCompressed.Error())
+		return
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxFrameSize+1))
+	if err != nil {
+		writeConnectError(w, codes.InvalidArgument, err.Error())
+		return
+	}
+	if len(b) > maxFrameSize {
+		writeConnectError(w, codes.ResourceExhausted, "message too large")
+		return
+	}
+
+	isJSON := contentType(r) == "application/json"
+	if isJSON && len(b) == 0 {
+		b = []byte(`{}`)
+	}
+
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
+	req := g.newRequest(service, method, isJSON, b)
+	rsp := message(isJSON, nil)
+
+	if err := g.opts.Client.Call(ctx, req, rsp); err != nil {
+		code, msg := toStatus(err)
+		writeConnectError(w, code, msg)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType(r))
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload(rsp))
+}
+
+// serveConnectStream handles connect streaming requests, where messages
+// are enveloped and the status is sent in a final end stream message
+func (g *grpcWeb) serveConnectStream(w http.ResponseWriter, r *http.Request, service, method string) {
+	isJSON := strings.HasSuffix(contentType(r), "+json")
+
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.WriteHeader(http.StatusOK)
+
+	end := func(code codes.Code, msg string) {
+		var es endStream
+		if code != codes.OK {
+			es.Error, _ = newConnectError(code, msg)
+		}
+		b, _ := json.Marshal(es)
+		w.Write(frame(flagEndStream, b))
+		flush(w)
+	}
+
+	var msgs [][]byte
+	for {
+		flag, b, err := readFrame(r.Body)
+		if err == io.EOF {
+			break
+		} else if err == errCompressed {
+			end(codes.Unimplemented, err.Error())
+			return
+		} else if err != nil {
+			end(codes.InvalidArgument, err.Error())
+			return
+		}
+		if flag&flagEndStream == 0 {
+			msgs = append(msgs, b)
+		}
+	}
+
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
+	stream, err := g.opts.Client.Stream(ctx, g.newRequest(service, method, isJSON, nil))
+	if err != nil {
+		end(toStatus(err))
+		return
+	}
+
+	for _, b := range msgs {
+		if err := stream.Send(message(isJSON, b)); err != nil {
+			end(toStatus(err))
+			return
+		}
+	}
+	stream.Close()
+
+	for {
+		rsp := message(isJSON, nil)
+		if err := stream.Recv(rsp); err == io.EOF {
+			break
+		} else if err != nil {
+			end(toStatus(err))
+			return
+		}
+		w.Write(frame(0, payload(rsp)))
+		flush(w)
+	}
+
+	end(codes.OK, "")
+}
@@ -0,0 +1,100 @@
+package grpcweb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	// grpc-web frame flags
+	flagCompressed = 0x01
+	flagTrailer    = 0x80
+	// connect envelope flags
+	flagEndStream = 0x02
+
+	// maxFrameSize limits the size of request messages
+	maxFrameSize = 1024 * 1024 * 4
+)
+
+var errCompressed = errors.New("compressed messages are not supported")
+
+// readFrame reads a length prefixed message as used by both grpc-web
+// and the connect streaming protocol, returning io.EOF when done
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, errors.New("truncated message")
+		}
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(hdr[1:])
+	if size > maxFrameSize {
+		return 0, nil, errors.New("message too large")
+	}
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, nil, errors.New("truncated message")
+	}
+
+	if hdr[0]&flagCompressed != 0 {
+		return 0, nil, errCompressed
+	}
+
+	return hdr[0], b, nil
+}
+
+// frame returns the message with its length prefix
+func frame(flag byte, b []byte) []byte {
+	f := make([]byte, 5+len(b))
+	f[0] = flag
+	binary.BigEndian.PutUint32(f[1:], uint32(len(b)))
+	copy(f[5:], b)
+	return f
+}
+
+// grpcTimeout parses the grpc-timeout header e.g 10S or 500m
+func grpcTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
+
+// connectTimeout parses the Connect-Timeout-Ms header
+func connectTimeout(v string) (time.Duration, bool) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Millisecond, true
+}
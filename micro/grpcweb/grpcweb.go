@@ -0,0 +1,238 @@
+// Package grpcweb is a micro plugin which terminates gRPC-Web and Connect
+// protocol requests from browsers and translates them to gRPC calls to the
+// backend services, removing the need for a separate proxy such as Envoy.
+package grpcweb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/micro/plugin"
+)
+
+type grpcWeb struct {
+	opts Options
+}
+
+// headers not forwarded as metadata
+var skipHeaders = map[string]bool{
+	"Accept":                   true,
+	"Accept-Encoding":          true,
+	"Connection":               true,
+	"Connect-Protocol-Version": true,
+	"Connect-Timeout-Ms":       true,
+	"Content-Length":           true,
+	"Content-Type":             true,
+	"Grpc-Timeout":             true,
+	"Origin":                   true,
+	"Referer":                  true,
+	"X-Grpc-Web":               true,
+	"X-User-Agent":             true,
+}
+
+func (g *grpcWeb) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "grpcweb_namespace",
+			Usage:  "Namespace prepended to proto service names e.g go.micro.api",
+			EnvVar: "GRPCWEB_NAMESPACE",
+		},
+		cli.StringSliceFlag{
+			Name:   "grpcweb_origins",
+			Usage:  "Origins allowed to make cross origin requests, all if unset",
+			EnvVar: "GRPCWEB_ORIGINS",
+		},
+	}
+}
+
+func (g *grpcWeb) Commands() []cli.Command {
+	return nil
+}
+
+// route returns the proto service and method from a /pkg.Service/Method path
+func route(path string) (string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// contentType returns the media type without parameters
+func contentType(r *http.Request) string {
+	ct := r.Header.Get("Content-Type")
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(ct))
+}
+
+// cors sets the cross origin headers, returning false if the origin isn't allowed
+func (g *grpcWeb) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+
+	allowed := len(g.opts.Origins) == 0
+	for _, o := range g.opts.Origins {
+		if o == "*" || o == origin {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Add("Vary", "Origin")
+	w.Header().Set("Access-Control-Expose-Headers", "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin")
+	return true
+}
+
+// preflight returns true for cors preflight requests of browser clients
+func preflight(r *http.Request) bool {
+	if r.Method != "OPTIONS" {
+		return false
+	}
+	h := strings.ToLower(r.Header.Get("Access-Control-Request-Headers"))
+	return strings.Contains(h, "x-grpc-web") || strings.Contains(h, "connect-protocol-version")
+}
+
+// requestContext forwards the request headers as metadata and applies the timeout
+func requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	md := make(metadata.Metadata, len(r.Header))
+	for k, v := range r.Header {
+		if skipHeaders[k] || len(v) == 0 {
+			continue
+		}
+		md[k] = v[0]
+	}
+
+	ctx := metadata.NewContext(r.Context(), md)
+
+	if d, ok := grpcTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		return context.WithTimeout(ctx, d)
+	}
+	if d, ok := connectTimeout(r.Header.Get("Connect-Timeout-Ms")); ok {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// newRequest returns a request passing the message body through as is. The
+// method is the grpc path so the backend routes it without reflection.
+func (g *grpcWeb) newRequest(service, method string, isJSON bool, b []byte) client.Request {
+	endpoint := "/" + service + "/" + method
+	ct := "application/grpc+bytes"
+	if isJSON {
+		ct = "application/grpc+json"
+	}
+	return g.opts.Client.NewRequest(g.opts.resolve(service), endpoint, message(isJSON, b),
+		client.WithContentType(ct))
+}
+
+// message returns a container for a message to send or receive
+func message(isJSON bool, b []byte) interface{} {
+	if isJSON {
+		m := json.RawMessage(b)
+		return &m
+	}
+	return &b
+}
+
+// payload returns the bytes held by a message container
+func payload(v interface{}) []byte {
+	switch v := v.(type) {
+	case *json.RawMessage:
+		return *v
+	case *[]byte:
+		return *v
+	}
+	return nil
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (g *grpcWeb) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			service, method, ok := route(r.URL.Path)
+			if !ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if preflight(r) {
+				if !g.cors(w, r) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+				w.Header().Set("Access-Control-Max-Age", "7200")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			ct := contentType(r)
+
+			var serve func(w http.ResponseWriter, r *http.Request, service, method string)
+
+			switch {
+			case strings.HasPrefix(ct, "application/grpc-web"):
+				serve = g.serveWeb
+			case strings.HasPrefix(ct, "application/connect+"):
+				serve = g.serveConnectStream
+			case (ct == "application/proto" || ct == "application/json") && len(r.Header.Get("Connect-Protocol-Version")) > 0:
+				serve = g.serveConnectUnary
+			default:
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method != "POST" {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if !g.cors(w, r) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+
+			serve(w, r, service, method)
+		})
+	}
+}
+
+func (g *grpcWeb) Init(ctx *cli.Context) error {
+	if n := ctx.String("grpcweb_namespace"); len(n) > 0 {
+		g.opts.Namespace = n
+	}
+	if o := ctx.StringSlice("grpcweb_origins"); len(o) > 0 {
+		g.opts.Origins = o
+	}
+	return nil
+}
+
+func (g *grpcWeb) String() string {
+	return "grpcweb"
+}
+
+// NewPlugin returns a plugin which serves gRPC-Web and Connect requests
+func NewPlugin(opts ...Option) plugin.Plugin {
+	return &grpcWeb{
+		opts: newOptions(opts...),
+	}
+}
@@ -0,0 +1,170 @@
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+)
+
+type testRequest struct {
+	client.Request
+	service string
+	method  string
+	body    []byte
+}
+
+func (t *testRequest) Service() string {
+	return t.service
+}
+
+func (t *testRequest) Method() string {
+	return t.method
+}
+
+type testStream struct {
+	client.Stream
+	recv [][]byte
+	sent [][]byte
+}
+
+func (t *testStream) Send(msg interface{}) error {
+	t.sent = append(t.sent, payload(msg))
+	return nil
+}
+
+func (t *testStream) Recv(msg interface{}) error {
+	if len(t.recv) == 0 {
+		return io.EOF
+	}
+	*(msg.(*[]byte)) = t.recv[0]
+	t.recv = t.recv[1:]
+	return nil
+}
+
+func (t *testStream) Close() error {
+	return nil
+}
+
+type testClient struct {
+	client.Client
+	stream *testStream
+	req    *testRequest
+}
+
+func (t *testClient) NewRequest(service, method string, req interface{}, opts ...client.RequestOption) client.Request {
+	return &testRequest{service: service, method: method, body: payload(req)}
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	t.req = req.(*testRequest)
+	if t.req.method == "/users.Users/Read" {
+		return errors.NotFound("go.micro.api.users", "user not found")
+	}
+	*(rsp.(*json.RawMessage)) = json.RawMessage(`{"msg":"hello"}`)
+	return nil
+}
+
+func (t *testClient) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	t.req = req.(*testRequest)
+	return t.stream, nil
+}
+
+func TestWeb(t *testing.T) {
+	c := &testClient{
+		stream: &testStream{recv: [][]byte{[]byte("pong1"), []byte("pong2")}},
+	}
+	h := NewPlugin(Client(c)).Handler()(http.NotFoundHandler())
+
+	r := httptest.NewRequest("POST", "/greeter.Greeter/Ping", bytes.NewReader(frame(0, []byte("ping"))))
+	r.Header.Set("Content-Type", "application/grpc-web+proto")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if c.req.service != "go.micro.api.greeter" || c.req.method != "/greeter.Greeter/Ping" {
+		t.Fatalf("unexpected request %s %s", c.req.service, c.req.method)
+	}
+	if len(c.stream.sent) != 1 || string(c.stream.sent[0]) != "ping" {
+		t.Fatalf("unexpected messages sent %q", c.stream.sent)
+	}
+
+	var got []string
+	for {
+		flag, b, err := readFrame(w.Body)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if flag&flagTrailer != 0 {
+			got = append(got, strings.TrimSpace(string(b)))
+			continue
+		}
+		got = append(got, string(b))
+	}
+
+	expect := []string{"pong1", "pong2", "grpc-status: 0"}
+	if strings.Join(got, ",") != strings.Join(expect, ",") {
+		t.Fatalf("expected %v got %v", expect, got)
+	}
+}
+
+func TestConnectUnary(t *testing.T) {
+	c := &testClient{}
+	h := NewPlugin(Client(c), Namespace("go.micro.srv")).Handler()(http.NotFoundHandler())
+
+	do := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", path, strings.NewReader(`{"name":"john"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Connect-Protocol-Version", "1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do("/greeter.Greeter/Hello")
+	if w.Code != 200 || w.Body.String() != `{"msg":"hello"}` {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if c.req.service != "go.micro.srv.greeter" {
+		t.Fatalf("unexpected service %s", c.req.service)
+	}
+	if string(c.req.body) != `{"name":"john"}` {
+		t.Fatalf("unexpected request body %s", string(c.req.body))
+	}
+
+	w = do("/users.Users/Read")
+	if w.Code != 404 {
+		t.Fatalf("expected 404 got %d", w.Code)
+	}
+	var cerr connectError
+	if err := json.Unmarshal(w.Body.Bytes(), &cerr); err != nil {
+		t.Fatal(err)
+	}
+	if cerr.Code != "not_found" || cerr.Message != "user not found" {
+		t.Fatalf("unexpected error %+v", cerr)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	h := NewPlugin(Client(&testClient{})).Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	// plain json requests to rpc style paths are left to the api
+	r := httptest.NewRequest("POST", "/greeter/hello", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("expected passthrough got %d", w.Code)
+	}
+}
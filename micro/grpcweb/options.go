@@ -0,0 +1,81 @@
+package grpcweb
+
+import (
+	"strings"
+
+	"github.com/micro/go-micro/client"
+)
+
+type Options struct {
+	// Client used to call the backend services
+	Client client.Client
+	// Namespace prepended to service names by the default resolver
+	Namespace string
+	// Resolver returns the micro service for a proto service,
+	// defaults to the namespace and lower cased service name
+	Resolver Resolver
+	// Origins allowed to make cross origin requests, all if empty
+	Origins []string
+}
+
+type Option func(o *Options)
+
+// Resolver returns the micro service name for the fully qualified
+// proto service name taken from the request path e.g greeter.Greeter
+type Resolver func(service string) string
+
+// Client sets the client used to call services
+func Client(c client.Client) Option {
+	return func(o *Options) {
+		o.Client = c
+	}
+}
+
+// Namespace sets the namespace used by the default resolver
+func Namespace(n string) Option {
+	return func(o *Options) {
+		o.Namespace = n
+	}
+}
+
+// WithResolver sets the resolver mapping proto services to micro services
+func WithResolver(r Resolver) Option {
+	return func(o *Options) {
+		o.Resolver = r
+	}
+}
+
+// Origins sets the origins allowed to make cross origin requests
+func Origins(origins ...string) Option {
+	return func(o *Options) {
+		o.Origins = origins
+	}
+}
+
+// resolve maps greeter.Greeter to [namespace].greeter unless a resolver is set
+func (o Options) resolve(service string) string {
+	if o.Resolver != nil {
+		return o.Resolver(service)
+	}
+	if i := strings.LastIndex(service, "."); i >= 0 {
+		service = service[i+1:]
+	}
+	service = strings.ToLower(service)
+	if len(o.Namespace) == 0 {
+		return service
+	}
+	return o.Namespace + "." + service
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Client:    client.DefaultClient,
+		Namespace: "go.micro.api",
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,72 @@
+package grpcweb
+
+import (
+	"strings"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/grpc-go/codes"
+	"github.com/micro/grpc-go/status"
+)
+
+// httpCodes maps micro error codes to grpc codes
+var httpCodes = map[int32]codes.Code{
+	400: codes.InvalidArgument,
+	401: codes.Unauthenticated,
+	403: codes.PermissionDenied,
+	404: codes.NotFound,
+	408: codes.DeadlineExceeded,
+	409: codes.Aborted,
+	429: codes.ResourceExhausted,
+	499: codes.Canceled,
+	500: codes.Internal,
+	501: codes.Unimplemented,
+	503: codes.Unavailable,
+	504: codes.DeadlineExceeded,
+}
+
+// connectCodes maps grpc codes to the connect code names and http status
+var connectCodes = map[codes.Code]struct {
+	name   string
+	status int
+}{
+	codes.Canceled:           {"canceled", 499},
+	codes.Unknown:            {"unknown", 500},
+	codes.InvalidArgument:    {"invalid_argument", 400},
+	codes.DeadlineExceeded:   {"deadline_exceeded", 504},
+	codes.NotFound:           {"not_found", 404},
+	codes.AlreadyExists:      {"already_exists", 409},
+	codes.PermissionDenied:   {"permission_denied", 403},
+	codes.ResourceExhausted:  {"resource_exhausted", 429},
+	codes.FailedPrecondition: {"failed_precondition", 400},
+	codes.Aborted:            {"aborted", 409},
+	codes.OutOfRange:         {"out_of_range", 400},
+	codes.Unimplemented:      {"unimplemented", 501},
+	codes.Internal:           {"internal", 500},
+	codes.Unavailable:        {"unavailable", 503},
+	codes.DataLoss:           {"data_loss", 500},
+	codes.Unauthenticated:    {"unauthenticated", 401},
+}
+
+// toStatus returns the grpc code and message for an error
+// returned by the client, which may be a micro or grpc error
+func toStatus(err error) (codes.Code, string) {
+	code := codes.Unknown
+	msg := err.Error()
+
+	// grpc status errors
+	if s, ok := status.FromError(err); ok {
+		code, msg = s.Code(), s.Message()
+	}
+
+	// micro errors are json, possibly carried in the status message
+	if strings.HasPrefix(msg, "{") {
+		if merr := errors.Parse(msg); merr.Code > 0 {
+			if c, ok := httpCodes[merr.Code]; ok && code == codes.Unknown {
+				code = c
+			}
+			msg = merr.Detail
+		}
+	}
+
+	return code, msg
+}
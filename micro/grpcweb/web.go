@@ -0,0 +1,106 @@
+package grpcweb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/micro/grpc-go/codes"
+)
+
+// webWriter writes grpc-web frames, base64 encoding them for grpc-web-text
+type webWriter struct {
+	w    http.ResponseWriter
+	text bool
+}
+
+func (ww *webWriter) write(flag byte, b []byte) {
+	f := frame(flag, b)
+	if ww.text {
+		ww.w.Write([]byte(base64.StdEncoding.EncodeToString(f)))
+	} else {
+		ww.w.Write(f)
+	}
+	flush(ww.w)
+}
+
+// trailer ends the response with the status in a trailer frame
+func (ww *webWriter) trailer(code codes.Code, msg string) {
+	t := fmt.Sprintf("grpc-status: %d\r\n", code)
+	if len(msg) > 0 {
+		t += fmt.Sprintf("grpc-message: %s\r\n", url.PathEscape(msg))
+	}
+	ww.write(flagTrailer, []byte(t))
+}
+
+func (ww *webWriter) error(err error) {
+	ww.trailer(toStatus(err))
+}
+
+// serveWeb handles unary and server streaming grpc-web requests. Both are
+// sent to the backend as a stream, which is the same on the wire.
+func (g *grpcWeb) serveWeb(w http.ResponseWriter, r *http.Request, service, method string) {
+	ct := contentType(r)
+	text := strings.HasPrefix(ct, "application/grpc-web-text")
+	isJSON := strings.HasSuffix(ct, "+json")
+
+	var body io.Reader = r.Body
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, r.Body)
+	}
+
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.WriteHeader(http.StatusOK)
+
+	ww := &webWriter{w: w, text: text}
+
+	var msgs [][]byte
+	for {
+		flag, b, err := readFrame(body)
+		if err == io.EOF {
+			break
+		} else if err == errCompressed {
+			ww.trailer(codes.Unimplemented, err.Error())
+			return
+		} else if err != nil {
+			ww.trailer(codes.InvalidArgument, err.Error())
+			return
+		}
+		if flag&flagTrailer == 0 {
+			msgs = append(msgs, b)
+		}
+	}
+
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
+	stream, err := g.opts.Client.Stream(ctx, g.newRequest(service, method, isJSON, nil))
+	if err != nil {
+		ww.error(err)
+		return
+	}
+
+	for _, b := range msgs {
+		if err := stream.Send(message(isJSON, b)); err != nil {
+			ww.error(err)
+			return
+		}
+	}
+	stream.Close()
+
+	for {
+		rsp := message(isJSON, nil)
+		if err := stream.Recv(rsp); err == io.EOF {
+			break
+		} else if err != nil {
+			ww.error(err)
+			return
+		}
+		ww.write(0, payload(rsp))
+	}
+
+	ww.trailer(codes.OK, "")
+}
@@ -1,9 +1,8 @@
-// Package gzip is a micro plugin for gzipping http response
+// Package gzip is a micro plugin for compressing http responses with gzip or brotli
 package gzip
 
 import (
 	"compress/gzip"
-	"io"
 	"net/http"
 	"strings"
 
@@ -11,47 +10,159 @@ import (
 	"github.com/micro/micro/plugin"
 )
 
-type gzipWriter struct {
-	io.Writer
-	http.ResponseWriter
-}
+var (
+	// DefaultMinSize is the minimum response size compressed
+	DefaultMinSize = 1024
+	// DefaultTypes are the content types compressed
+	DefaultTypes = []string{
+		"application/json",
+		"application/javascript",
+		"application/xml",
+		"text/",
+	}
+)
 
-type gzipper struct{}
+type gzipper struct {
+	brotli  bool
+	level   int
+	minSize int
+	types   []string
+}
 
 func (g *gzipper) Flags() []cli.Flag {
-	return nil
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:   "gzip_brotli",
+			Usage:  "Prefer brotli compression when accepted by the client",
+			EnvVar: "GZIP_BROTLI",
+		},
+		cli.IntFlag{
+			Name:   "gzip_level",
+			Usage:  "Compression level for gzip (1-9) and brotli (0-11)",
+			EnvVar: "GZIP_LEVEL",
+		},
+		cli.IntFlag{
+			Name:   "gzip_min_size",
+			Usage:  "Minimum response size in bytes to compress",
+			EnvVar: "GZIP_MIN_SIZE",
+		},
+		cli.StringFlag{
+			Name:   "gzip_types",
+			Usage:  "Comma separated list of content types to compress. Entries ending in / match by prefix",
+			EnvVar: "GZIP_TYPES",
+		},
+	}
 }
 
 func (g *gzipper) Commands() []cli.Command {
 	return nil
 }
 
+// encoding returns the encoding to use based on the accept-encoding header
+func (g *gzipper) encoding(r *http.Request) string {
+	accept := r.Header.Get("Accept-Encoding")
+	if len(accept) == 0 {
+		return ""
+	}
+
+	var br, gz bool
+
+	for _, part := range strings.Split(accept, ",") {
+		enc := strings.TrimSpace(part)
+		// ignore explicitly refused encodings
+		if i := strings.Index(enc, ";"); i >= 0 {
+			if q := strings.TrimSpace(enc[i+1:]); q == "q=0" || q == "q=0.0" {
+				continue
+			}
+			enc = strings.TrimSpace(enc[:i])
+		}
+		switch enc {
+		case "br":
+			br = true
+		case "gzip":
+			gz = true
+		}
+	}
+
+	switch {
+	case br && g.brotli:
+		return "br"
+	case gz:
+		return "gzip"
+	}
+
+	return ""
+}
+
+// compressible checks the content type against the allowlist
+func (g *gzipper) compressible(ct string) bool {
+	if len(g.types) == 0 {
+		return true
+	}
+
+	// strip params e.g charset
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.ToLower(strings.TrimSpace(ct))
+
+	for _, t := range g.types {
+		if strings.HasSuffix(t, "/") {
+			if strings.HasPrefix(ct, t) {
+				return true
+			}
+			continue
+		}
+		if ct == t {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (g *gzipper) Handler() plugin.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// has gzip accept-encoding?
-			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			enc := g.encoding(r)
+
+			// no supported accept-encoding or nothing to compress
+			if len(enc) == 0 || r.Method == "HEAD" {
 				h.ServeHTTP(w, r)
 				return
 			}
 
-			// set the content-encoding
-			w.Header().Set("Content-Encoding", "gzip")
-
-			// create gzip writer
-			gz := gzip.NewWriter(w)
-			defer gz.Close()
-
-			// create http response writer
-			gzw := gzipWriter{gz, w}
+			// create a compressing response writer
+			cw := newWriter(g, w, enc)
+			defer cw.Close()
 
 			// serve the request
-			h.ServeHTTP(gzw, r)
+			h.ServeHTTP(cw, r)
 		})
 	}
 }
 
 func (g *gzipper) Init(ctx *cli.Context) error {
+	if ctx.Bool("gzip_brotli") {
+		g.brotli = true
+	}
+	if l := ctx.Int("gzip_level"); l > 0 {
+		g.level = l
+	}
+	if s := ctx.Int("gzip_min_size"); s > 0 {
+		g.minSize = s
+	}
+	if t := ctx.String("gzip_types"); len(t) > 0 {
+		var types []string
+		for _, v := range strings.Split(t, ",") {
+			if v = strings.ToLower(strings.TrimSpace(v)); len(v) > 0 {
+				types = append(types, v)
+			}
+		}
+		g.types = types
+	}
 	return nil
 }
 
@@ -59,10 +170,10 @@ func (g *gzipper) String() string {
 	return "gzip"
 }
 
-func (w gzipWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
-}
-
 func New() plugin.Plugin {
-	return new(gzipper)
+	return &gzipper{
+		level:   gzip.DefaultCompression,
+		minSize: DefaultMinSize,
+		types:   DefaultTypes,
+	}
 }
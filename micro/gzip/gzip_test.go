@@ -0,0 +1,114 @@
+package gzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func testHandler(ct string, size int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(ct) > 0 {
+			w.Header().Set("Content-Type", ct)
+		}
+		w.Write(bytes.Repeat([]byte("a"), size))
+	})
+}
+
+func serve(g *gzipper, h http.Handler, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", accept)
+	w := httptest.NewRecorder()
+	g.Handler()(h).ServeHTTP(w, r)
+	return w
+}
+
+func TestGzip(t *testing.T) {
+	g := New().(*gzipper)
+
+	w := serve(g, testHandler("application/json", 4096), "gzip, deflate")
+
+	if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("expected gzip got %q", enc)
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 4096 {
+		t.Fatalf("expected 4096 bytes got %d", len(b))
+	}
+}
+
+func TestBrotli(t *testing.T) {
+	g := New().(*gzipper)
+	g.brotli = true
+
+	w := serve(g, testHandler("application/json", 4096), "gzip, br")
+
+	if enc := w.Header().Get("Content-Encoding"); enc != "br" {
+		t.Fatalf("expected br got %q", enc)
+	}
+
+	b, err := ioutil.ReadAll(brotli.NewReader(w.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 4096 {
+		t.Fatalf("expected 4096 bytes got %d", len(b))
+	}
+
+	// brotli disabled falls back to gzip
+	g.brotli = false
+	w = serve(g, testHandler("application/json", 4096), "gzip, br")
+	if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("expected gzip got %q", enc)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	g := New().(*gzipper)
+
+	testCases := []struct {
+		name    string
+		handler http.Handler
+		accept  string
+	}{
+		{"below min size", testHandler("application/json", 10), "gzip"},
+		{"content type", testHandler("image/png", 4096), "gzip"},
+		{"not accepted", testHandler("application/json", 4096), "identity"},
+		{"refused", testHandler("application/json", 4096), "gzip;q=0"},
+		{"precompressed", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(bytes.Repeat([]byte("a"), 4096))
+		}), "gzip"},
+	}
+
+	for _, tc := range testCases {
+		w := serve(g, tc.handler, tc.accept)
+
+		if tc.name == "precompressed" {
+			if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+				t.Fatalf("%s: expected upstream encoding got %q", tc.name, enc)
+			}
+		} else if enc := w.Header().Get("Content-Encoding"); len(enc) > 0 {
+			t.Fatalf("%s: expected no encoding got %q", tc.name, enc)
+		}
+
+		if strings.Count(w.Body.String(), "a") != w.Body.Len() {
+			t.Fatalf("%s: expected raw body", tc.name)
+		}
+	}
+}
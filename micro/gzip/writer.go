@@ -0,0 +1,200 @@
+package gzip
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+)
+
+// writer buffers the response until it can decide whether to compress
+// based on the status, content type, upstream encoding and size
+type writer struct {
+	http.ResponseWriter
+
+	g   *gzipper
+	enc string
+
+	// buffered bytes until the min size is reached
+	buf []byte
+	// status to write once decided
+	status int
+	// decided is true once the compress or passthrough decision is made
+	decided bool
+	// compressor if compressing
+	cw io.WriteCloser
+}
+
+func newWriter(g *gzipper, w http.ResponseWriter, enc string) *writer {
+	return &writer{
+		ResponseWriter: w,
+		g:              g,
+		enc:            enc,
+	}
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.status > 0 {
+		return
+	}
+	w.status = code
+
+	// decide immediately if there's no point buffering
+	if w.skip() {
+		w.passthrough()
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.decided {
+		if w.cw != nil {
+			return w.cw.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	// sniff the content type as net/http would
+	if len(w.Header().Get("Content-Type")) == 0 {
+		w.Header().Set("Content-Type", http.DetectContentType(b))
+		if !w.g.compressible(w.Header().Get("Content-Type")) {
+			if err := w.passthrough(); err != nil {
+				return 0, err
+			}
+			return w.ResponseWriter.Write(b)
+		}
+	}
+
+	w.buf = append(w.buf, b...)
+
+	if len(w.buf) >= w.g.minSize {
+		if err := w.compress(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// skip returns true if the response should not be compressed
+func (w *writer) skip() bool {
+	switch {
+	// no body
+	case w.status < 200, w.status == http.StatusNoContent, w.status == http.StatusNotModified:
+		return true
+	// already compressed upstream
+	case len(w.Header().Get("Content-Encoding")) > 0:
+		return true
+	}
+
+	// only compress allowed content types
+	if ct := w.Header().Get("Content-Type"); len(ct) > 0 && !w.g.compressible(ct) {
+		return true
+	}
+
+	return false
+}
+
+// passthrough writes the response and anything buffered as is
+func (w *writer) passthrough() error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// compress writes the headers and flushes the buffer through the compressor
+func (w *writer) compress() error {
+	w.decided = true
+
+	h := w.Header()
+	h.Set("Content-Encoding", w.enc)
+	h.Del("Content-Length")
+	h.Del("Accept-Ranges")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	switch w.enc {
+	case "br":
+		level := w.g.level
+		if level < 0 || level > brotli.BestCompression {
+			level = brotli.DefaultCompression
+		}
+		w.cw = brotli.NewWriterLevel(w.ResponseWriter, level)
+	default:
+		level := w.g.level
+		if level > gzip.BestCompression {
+			level = gzip.BestCompression
+		}
+		gz, err := gzip.NewWriterLevel(w.ResponseWriter, level)
+		if err != nil {
+			gz = gzip.NewWriter(w.ResponseWriter)
+		}
+		w.cw = gz
+	}
+
+	buf := w.buf
+	w.buf = nil
+	_, err := w.cw.Write(buf)
+	return err
+}
+
+// Flush compresses any buffered data and flushes the underlying writer
+func (w *writer) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if w.skip() {
+			w.passthrough()
+		} else {
+			w.compress()
+		}
+	}
+
+	if f, ok := w.cw.(interface {
+		Flush() error
+	}); ok {
+		f.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close completes the response writing any data below the min size uncompressed
+func (w *writer) Close() error {
+	if w.cw != nil {
+		return w.cw.Close()
+	}
+
+	if w.decided {
+		return nil
+	}
+
+	// nothing was written
+	if w.status == 0 && len(w.buf) == 0 {
+		return nil
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	// below the min size
+	return w.passthrough()
+}
@@ -0,0 +1,298 @@
+// Package ip_filter is a micro plugin for filtering requests by ip address and country
+package ip_filter
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-config"
+	"github.com/micro/go-log"
+	"github.com/micro/micro/plugin"
+	"github.com/oschwald/geoip2-golang"
+)
+
+// locator resolves the iso country code for an ip
+type locator interface {
+	Country(ip net.IP) (string, error)
+}
+
+type geoip struct {
+	db *geoip2.Reader
+}
+
+type filter struct {
+	opts Options
+
+	sync.RWMutex
+	rules *rules
+	geo   locator
+}
+
+var (
+	// DefaultPath is the config path rules are read from
+	DefaultPath = []string{"ip_filter"}
+
+	// ErrNoGeoIP is returned for country rules without a GeoIP database
+	ErrNoGeoIP = errors.New("country rules require a GeoIP database")
+)
+
+func (g *geoip) Country(ip net.IP) (string, error) {
+	c, err := g.db.Country(ip)
+	if err != nil {
+		return "", err
+	}
+	return c.Country.IsoCode, nil
+}
+
+func split(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); len(p) > 0 {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func (f *filter) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "ip_allow",
+			Usage:  "Comma separated list of allowed IPs or CIDRs",
+			EnvVar: "IP_ALLOW",
+		},
+		cli.StringFlag{
+			Name:   "ip_deny",
+			Usage:  "Comma separated list of denied IPs or CIDRs",
+			EnvVar: "IP_DENY",
+		},
+		cli.StringFlag{
+			Name:   "ip_trusted_proxies",
+			Usage:  "Comma separated list of proxy IPs or CIDRs trusted to set X-Forwarded-For",
+			EnvVar: "IP_TRUSTED_PROXIES",
+		},
+		cli.StringFlag{
+			Name:   "ip_geoip_db",
+			Usage:  "Path to a MaxMind GeoIP2 or GeoLite2 country database",
+			EnvVar: "IP_GEOIP_DB",
+		},
+		cli.StringFlag{
+			Name:   "ip_allow_countries",
+			Usage:  "Comma separated list of allowed ISO country codes",
+			EnvVar: "IP_ALLOW_COUNTRIES",
+		},
+		cli.StringFlag{
+			Name:   "ip_deny_countries",
+			Usage:  "Comma separated list of denied ISO country codes",
+			EnvVar: "IP_DENY_COUNTRIES",
+		},
+	}
+}
+
+func (f *filter) Commands() []cli.Command {
+	return nil
+}
+
+// load parses and sets the rules. Country rules can't
+// be checked, so are rejected, without a GeoIP database.
+func (f *filter) load(r Rules) error {
+	rules, err := newRules(r)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if rules.geo() && f.geo == nil && len(f.opts.GeoIPDatabase) == 0 {
+		return ErrNoGeoIP
+	}
+
+	f.rules = rules
+	return nil
+}
+
+// run loads rules from config and watches for updates
+func (f *filter) run(c config.Config) {
+	var watcher config.Watcher
+
+	// try to get a watch
+	for i := 0; i < 100; i++ {
+		w, err := c.Watch(DefaultPath...)
+		if err != nil {
+			log.Logf("[ip_filter] failed to get watcher: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		watcher = w
+		break
+	}
+
+	if watcher == nil {
+		log.Logf("[ip_filter] failed to get watcher in 100 attempts")
+		return
+	}
+
+	for {
+		v, err := watcher.Next()
+		if err != nil {
+			log.Logf("[ip_filter] watcher error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var r Rules
+		if err := v.Scan(&r); err != nil {
+			log.Logf("[ip_filter] failed to scan rules... skipping update: %v", err)
+			continue
+		}
+
+		if err := f.load(r); err != nil {
+			log.Logf("[ip_filter] failed to load rules... skipping update: %v", err)
+		}
+	}
+}
+
+// check returns true if the request is allowed
+func (f *filter) check(r *http.Request) bool {
+	f.RLock()
+	rules, geo := f.rules, f.geo
+	f.RUnlock()
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := rules.clientIP(host, r.Header["X-Forwarded-For"])
+	// if we can't parse it passes through as ip_whitelist does
+	if ip == nil {
+		return true
+	}
+
+	if !rules.allowed(ip) {
+		return false
+	}
+
+	if geo == nil || !rules.geo() {
+		return true
+	}
+
+	code, err := geo.Country(ip)
+	if err != nil {
+		log.Logf("[ip_filter] failed to lookup country for %v: %v", ip, err)
+	}
+
+	return rules.allowedCountry(code)
+}
+
+func (f *filter) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.check(r) {
+				http.Error(w, "forbidden", 403)
+				return
+			}
+
+			// serve the request
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (f *filter) Init(ctx *cli.Context) error {
+	if v := ctx.String("ip_allow"); len(v) > 0 {
+		f.opts.Allow = split(v)
+	}
+	if v := ctx.String("ip_deny"); len(v) > 0 {
+		f.opts.Deny = split(v)
+	}
+	if v := ctx.String("ip_trusted_proxies"); len(v) > 0 {
+		f.opts.TrustedProxies = split(v)
+	}
+	if v := ctx.String("ip_geoip_db"); len(v) > 0 {
+		f.opts.GeoIPDatabase = v
+	}
+	if v := ctx.String("ip_allow_countries"); len(v) > 0 {
+		f.opts.AllowCountries = split(v)
+	}
+	if v := ctx.String("ip_deny_countries"); len(v) > 0 {
+		f.opts.DenyCountries = split(v)
+	}
+
+	return f.init()
+}
+
+// init loads rules from options, opens the geoip database and
+// starts watching config if set
+func (f *filter) init() error {
+	r := Rules{
+		Allow:          f.opts.Allow,
+		Deny:           f.opts.Deny,
+		TrustedProxies: f.opts.TrustedProxies,
+		AllowCountries: f.opts.AllowCountries,
+		DenyCountries:  f.opts.DenyCountries,
+	}
+
+	// config takes precedence over options
+	if f.opts.Config != nil {
+		if err := f.opts.Config.Get(DefaultPath...).Scan(&r); err != nil {
+			log.Logf("[ip_filter] failed to get rules from config: %v", err)
+		}
+	}
+
+	if len(f.opts.GeoIPDatabase) > 0 {
+		db, err := geoip2.Open(f.opts.GeoIPDatabase)
+		if err != nil {
+			return err
+		}
+		f.Lock()
+		f.geo = &geoip{db}
+		f.Unlock()
+	}
+
+	if err := f.load(r); err != nil {
+		return err
+	}
+
+	if f.opts.Config != nil {
+		go f.run(f.opts.Config)
+	}
+
+	return nil
+}
+
+func (f *filter) String() string {
+	return "ip_filter"
+}
+
+// NewIPFilter returns a plugin which filters requests by ip and country
+func NewIPFilter(opts ...Option) plugin.Plugin {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	f := &filter{
+		opts: options,
+	}
+
+	// load the initial rules
+	r := Rules{
+		Allow:          options.Allow,
+		Deny:           options.Deny,
+		TrustedProxies: options.TrustedProxies,
+		AllowCountries: options.AllowCountries,
+		DenyCountries:  options.DenyCountries,
+	}
+	if err := f.load(r); err != nil {
+		log.Fatalf("[ip_filter] failed to load rules: %v", err)
+	}
+
+	return f
+}
@@ -0,0 +1,133 @@
+package ip_filter
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testLocator map[string]string
+
+func (t testLocator) Country(ip net.IP) (string, error) {
+	c, ok := t[ip.String()]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return c, nil
+}
+
+func TestClientIP(t *testing.T) {
+	r, err := newRules(Rules{
+		TrustedProxies: []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		remote string
+		xff    []string
+		expect string
+	}{
+		// untrusted remote ignores the header
+		{"1.1.1.1", []string{"2.2.2.2"}, "1.1.1.1"},
+		// trusted remote uses the last untrusted hop
+		{"10.0.0.1", []string{"3.3.3.3, 2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		// multiple headers
+		{"10.0.0.1", []string{"3.3.3.3", "2.2.2.2"}, "2.2.2.2"},
+		// all trusted
+		{"10.0.0.1", []string{"10.0.0.3"}, "10.0.0.3"},
+		// malformed hop stops the walk
+		{"10.0.0.1", []string{"2.2.2.2, bogus"}, "10.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		ip := r.clientIP(tc.remote, tc.xff)
+		if ip.String() != tc.expect {
+			t.Fatalf("expected %s got %s for %s %v", tc.expect, ip, tc.remote, tc.xff)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	p := NewIPFilter(
+		Allow("192.168.0.0/16", "1.1.1.1", "2.2.2.2", "3.3.3.3"),
+		Deny("192.168.1.0/24"),
+		TrustedProxies("192.168.0.1"),
+		DenyCountries("xx"),
+		GeoIPDatabase("GeoLite2-Country.mmdb"),
+	)
+
+	f := p.(*filter)
+	f.geo = testLocator{
+		"2.2.2.2": "XX",
+		"3.3.3.3": "GB",
+	}
+
+	h := p.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	testCases := []struct {
+		remote string
+		xff    string
+		code   int
+	}{
+		{"192.168.0.5:1000", "", 200},
+		{"192.168.1.5:1000", "", 403},
+		{"1.1.1.1:1000", "", 200},
+		{"4.4.4.4:1000", "", 403},
+		{"2.2.2.2:1000", "", 403},
+		{"3.3.3.3:1000", "", 200},
+		// forwarded by a trusted proxy
+		{"192.168.0.1:1000", "4.4.4.4", 403},
+		{"192.168.0.1:1000", "1.1.1.1", 200},
+		// spoofed header from an untrusted client
+		{"4.4.4.4:1000", "1.1.1.1", 403},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remote
+		if len(tc.xff) > 0 {
+			r.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tc.code {
+			t.Fatalf("expected %d got %d for %s %s", tc.code, w.Code, tc.remote, tc.xff)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	f := NewIPFilter().(*filter)
+
+	if err := f.load(Rules{Deny: []string{"not an ip"}}); err == nil {
+		t.Fatal("expected error loading invalid rules")
+	}
+
+	if err := f.load(Rules{Deny: []string{"1.1.1.1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "1.1.1.1:1000"
+	if f.check(r) {
+		t.Fatal("expected reloaded rules to deny")
+	}
+}
+
+func TestNoGeoIP(t *testing.T) {
+	f := NewIPFilter().(*filter)
+
+	if err := f.load(Rules{AllowCountries: []string{"GB"}}); err != ErrNoGeoIP {
+		t.Fatalf("expected %v got %v", ErrNoGeoIP, err)
+	}
+
+	f.opts.AllowCountries = []string{"GB"}
+	if err := f.init(); err != ErrNoGeoIP {
+		t.Fatalf("expected %v got %v", ErrNoGeoIP, err)
+	}
+}
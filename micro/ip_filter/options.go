@@ -0,0 +1,74 @@
+package ip_filter
+
+import (
+	"github.com/micro/go-config"
+)
+
+type Options struct {
+	// Allowed ips or cidrs. If set only matching ips are allowed
+	Allow []string
+	// Denied ips or cidrs. Takes precedence over allow
+	Deny []string
+	// Proxies trusted to set X-Forwarded-For
+	TrustedProxies []string
+	// Path to a MaxMind GeoIP2 or GeoLite2 country database
+	GeoIPDatabase string
+	// ISO country codes allowed. If set only matching countries are allowed
+	AllowCountries []string
+	// ISO country codes denied
+	DenyCountries []string
+	// Config to load and watch rules from
+	Config config.Config
+}
+
+type Option func(o *Options)
+
+// Allow sets the allowed ips or cidrs
+func Allow(ips ...string) Option {
+	return func(o *Options) {
+		o.Allow = append(o.Allow, ips...)
+	}
+}
+
+// Deny sets the denied ips or cidrs
+func Deny(ips ...string) Option {
+	return func(o *Options) {
+		o.Deny = append(o.Deny, ips...)
+	}
+}
+
+// TrustedProxies sets the proxies whose X-Forwarded-For header is trusted
+func TrustedProxies(ips ...string) Option {
+	return func(o *Options) {
+		o.TrustedProxies = append(o.TrustedProxies, ips...)
+	}
+}
+
+// GeoIPDatabase sets the path of the MaxMind country database
+func GeoIPDatabase(path string) Option {
+	return func(o *Options) {
+		o.GeoIPDatabase = path
+	}
+}
+
+// AllowCountries sets the allowed ISO country codes
+func AllowCountries(codes ...string) Option {
+	return func(o *Options) {
+		o.AllowCountries = append(o.AllowCountries, codes...)
+	}
+}
+
+// DenyCountries sets the denied ISO country codes
+func DenyCountries(codes ...string) Option {
+	return func(o *Options) {
+		o.DenyCountries = append(o.DenyCountries, codes...)
+	}
+}
+
+// Config sets a config to load rules from. Rules are read
+// from the path "ip_filter" and reloaded on change.
+func Config(c config.Config) Option {
+	return func(o *Options) {
+		o.Config = c
+	}
+}
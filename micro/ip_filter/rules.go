@@ -0,0 +1,162 @@
+package ip_filter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Rules are the filter rules as loaded from config
+type Rules struct {
+	Allow          []string `json:"allow"`
+	Deny           []string `json:"deny"`
+	TrustedProxies []string `json:"trusted_proxies"`
+	AllowCountries []string `json:"allow_countries"`
+	DenyCountries  []string `json:"deny_countries"`
+}
+
+// rules are the parsed form of Rules
+type rules struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	trusted []*net.IPNet
+
+	allowCountries map[string]bool
+	denyCountries  map[string]bool
+}
+
+// parseNets parses ips and cidrs; ips are treated as single host networks
+func parseNets(ips []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, ip := range ips {
+		ip = strings.TrimSpace(ip)
+		if len(ip) == 0 {
+			continue
+		}
+
+		if strings.Contains(ip, "/") {
+			_, ipnet, err := net.ParseCIDR(ip)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %v: %v", ip, err)
+			}
+			nets = append(nets, ipnet)
+			continue
+		}
+
+		nip := net.ParseIP(ip)
+		if nip == nil {
+			return nil, fmt.Errorf("failed to parse %v", ip)
+		}
+
+		bits := 128
+		if v4 := nip.To4(); v4 != nil {
+			nip = v4
+			bits = 32
+		}
+
+		nets = append(nets, &net.IPNet{IP: nip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return nets, nil
+}
+
+func parseCountries(codes []string) map[string]bool {
+	m := make(map[string]bool)
+	for _, c := range codes {
+		if c = strings.ToUpper(strings.TrimSpace(c)); len(c) > 0 {
+			m[c] = true
+		}
+	}
+	return m
+}
+
+func newRules(r Rules) (*rules, error) {
+	allow, err := parseNets(r.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseNets(r.Deny)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := parseNets(r.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rules{
+		allow:          allow,
+		deny:           deny,
+		trusted:        trusted,
+		allowCountries: parseCountries(r.AllowCountries),
+		denyCountries:  parseCountries(r.DenyCountries),
+	}, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the ip of the client. X-Forwarded-For is only
+// considered when the remote address is a trusted proxy in which case
+// it's walked right to left skipping any further trusted proxies.
+func (r *rules) clientIP(remote string, xff []string) net.IP {
+	ip := net.ParseIP(remote)
+	if ip == nil || !contains(r.trusted, ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, h := range xff {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// can't trust anything beyond a malformed hop
+			break
+		}
+		ip = hop
+		if !contains(r.trusted, hop) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// allowed checks the ip against the cidr rules
+func (r *rules) allowed(ip net.IP) bool {
+	if contains(r.deny, ip) {
+		return false
+	}
+	if len(r.allow) > 0 && !contains(r.allow, ip) {
+		return false
+	}
+	return true
+}
+
+// allowedCountry checks the iso code against the country rules.
+// An unknown country is only allowed if no allow list is set.
+func (r *rules) allowedCountry(code string) bool {
+	code = strings.ToUpper(code)
+	if r.denyCountries[code] {
+		return false
+	}
+	if len(r.allowCountries) > 0 && !r.allowCountries[code] {
+		return false
+	}
+	return true
+}
+
+// geo returns true if country rules are set
+func (r *rules) geo() bool {
+	return len(r.allowCountries) > 0 || len(r.denyCountries) > 0
+}
@@ -0,0 +1,341 @@
+// Package mirror is a micro plugin for mirroring gateway traffic to a shadow version of a service
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/micro/plugin"
+)
+
+// Mirror is the plugin with access to the recorded deltas
+type Mirror interface {
+	plugin.Plugin
+	Stats() Stats
+}
+
+// Stats are the deltas recorded between primary and shadow requests
+type Stats struct {
+	// Mirrored requests completed
+	Mirrored int64
+	// Dropped requests which weren't mirrored due to limits
+	Dropped int64
+	// Mismatched status codes between primary and shadow
+	Mismatched int64
+	// Errors are 5xx responses
+	PrimaryErrors int64
+	ShadowErrors  int64
+	// Latency totals of mirrored requests
+	PrimaryLatency time.Duration
+	ShadowLatency  time.Duration
+}
+
+type mirror struct {
+	opts Options
+
+	inflight chan struct{}
+
+	mtx   sync.Mutex
+	stats Stats
+}
+
+// recorder captures the status of a response
+type recorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// discard drops the shadow response
+type discard struct {
+	header http.Header
+	status int
+}
+
+// transport drops shadow requests proxied over http
+type transport struct {
+	http.RoundTripper
+}
+
+var (
+	// header forwarded as metadata to the client wrapper
+	mirrorHeader = "X-Micro-Mirror-Version"
+
+	// content types of requests served by rpc handlers
+	rpcContentTypes = []string{
+		"application/json",
+		"application/json-rpc",
+		"application/protobuf",
+		"application/proto-rpc",
+		"application/octet-stream",
+	}
+
+	errShadow = errors.New("mirrored request dropped")
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (d *discard) Header() http.Header {
+	return d.header
+}
+
+func (d *discard) Write(b []byte) (int, error) {
+	if d.status == 0 {
+		d.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (d *discard) WriteHeader(code int) {
+	if d.status == 0 {
+		d.status = code
+	}
+}
+
+// RoundTrip only lets requests through which aren't tagged as a shadow.
+// Handlers proxying http, rather than calling a service with the client,
+// would otherwise send the shadow request to the primary version.
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if len(r.Header.Get(mirrorHeader)) > 0 {
+		return nil, errShadow
+	}
+	return t.RoundTripper.RoundTrip(r)
+}
+
+func (m *mirror) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "mirror_version",
+			Usage:  "Service version to mirror requests to",
+			EnvVar: "MIRROR_VERSION",
+		},
+		cli.Float64Flag{
+			Name:   "mirror_percent",
+			Usage:  "Percent of requests mirrored between 0 and 100",
+			EnvVar: "MIRROR_PERCENT",
+		},
+		cli.IntFlag{
+			Name:   "mirror_timeout",
+			Usage:  "Timeout in milliseconds for mirrored requests",
+			EnvVar: "MIRROR_TIMEOUT",
+		},
+	}
+}
+
+func (m *mirror) Commands() []cli.Command {
+	return nil
+}
+
+// sample returns true if the request should be mirrored
+func (m *mirror) sample() bool {
+	if len(m.opts.Version) == 0 || m.opts.Percent <= 0 {
+		return false
+	}
+	return m.opts.Percent >= 100 || rand.Float64()*100 < m.opts.Percent
+}
+
+// isRPC returns true if the request is an rpc which can be routed
+// to the shadow version, other requests are never mirrored
+func isRPC(r *http.Request) bool {
+	if r.Method != "POST" {
+		return false
+	}
+	ct := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	for _, t := range rpcContentTypes {
+		if ct == t {
+			return true
+		}
+	}
+	return false
+}
+
+// shadow clones the request for the shadow version
+func (m *mirror) shadow(ctx context.Context, r *http.Request, body []byte) *http.Request {
+	sr := r.WithContext(ctx)
+	u := *r.URL
+	sr.URL = &u
+	sr.Header = make(http.Header, len(r.Header))
+	for k, v := range r.Header {
+		sr.Header[k] = append([]string(nil), v...)
+	}
+	sr.Header.Set(mirrorHeader, m.opts.Version)
+	sr.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return sr
+}
+
+func isError(status int) bool {
+	return status >= 500
+}
+
+func (m *mirror) record(primary, shadow int, pl, sl time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.stats.Mirrored++
+	m.stats.PrimaryLatency += pl
+	m.stats.ShadowLatency += sl
+
+	if primary != shadow {
+		m.stats.Mismatched++
+	}
+	if isError(primary) {
+		m.stats.PrimaryErrors++
+	}
+	if isError(shadow) {
+		m.stats.ShadowErrors++
+	}
+}
+
+func (m *mirror) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// never trust the internal header from clients
+			r.Header.Del(mirrorHeader)
+
+			if !m.sample() || !isRPC(r) || r.ContentLength > m.opts.MaxBodySize {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			// limit concurrent mirrors so a slow shadow can't pile up
+			select {
+			case m.inflight <- struct{}{}:
+			default:
+				m.mtx.Lock()
+				m.stats.Dropped++
+				m.mtx.Unlock()
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				// the content length may be unknown so the body is capped
+				b, err := ioutil.ReadAll(io.LimitReader(r.Body, m.opts.MaxBodySize+1))
+				if err != nil {
+					r.Body.Close()
+					<-m.inflight
+					http.Error(w, "bad request", 400)
+					return
+				}
+
+				// too large to mirror, the primary reads the whole body
+				if int64(len(b)) > m.opts.MaxBodySize {
+					<-m.inflight
+					r.Body = struct {
+						io.Reader
+						io.Closer
+					}{io.MultiReader(bytes.NewReader(b), r.Body), r.Body}
+					h.ServeHTTP(w, r)
+					return
+				}
+
+				r.Body.Close()
+				body = b
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			// the shadow must not be cancelled with the primary request
+			ctx, cancel := context.WithTimeout(context.Background(), m.opts.Timeout)
+			sr := m.shadow(ctx, r, body)
+
+			done := make(chan time.Duration, 1)
+			rec := &recorder{ResponseWriter: w}
+
+			go func() {
+				defer func() {
+					cancel()
+					<-m.inflight
+				}()
+
+				d := &discard{header: make(http.Header)}
+				start := time.Now()
+				h.ServeHTTP(d, sr)
+				sl := time.Since(start)
+
+				if d.status == 0 {
+					d.status = http.StatusOK
+				}
+
+				// wait for the primary to compare
+				pl := <-done
+				m.record(rec.status, d.status, pl, sl)
+
+				if rec.status != d.status {
+					log.Logf("[mirror] %s %s status mismatch primary %d shadow %d", sr.Method, sr.URL.Path, rec.status, d.status)
+				}
+			}()
+
+			start := time.Now()
+			h.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			done <- time.Since(start)
+		})
+	}
+}
+
+func (m *mirror) Init(ctx *cli.Context) error {
+	if v := ctx.String("mirror_version"); len(v) > 0 {
+		m.opts.Version = v
+	}
+	if p := ctx.Float64("mirror_percent"); p > 0 {
+		m.opts.Percent = p
+	}
+	if t := ctx.Int("mirror_timeout"); t > 0 {
+		m.opts.Timeout = time.Duration(t) * time.Millisecond
+	}
+
+	client.DefaultClient = newClient(client.DefaultClient, m.opts.Version)
+	http.DefaultTransport = &transport{http.DefaultTransport}
+
+	return nil
+}
+
+func (m *mirror) String() string {
+	return "mirror"
+}
+
+// Stats returns a copy of the recorded deltas
+func (m *mirror) Stats() Stats {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.stats
+}
+
+// NewPlugin returns a plugin which mirrors requests to a shadow version
+func NewPlugin(opts ...Option) Mirror {
+	options := newOptions(opts...)
+
+	return &mirror{
+		opts:     options,
+		inflight: make(chan struct{}, options.MaxInflight),
+	}
+}
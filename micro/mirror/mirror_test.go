@@ -0,0 +1,186 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+)
+
+func TestMirror(t *testing.T) {
+	m := NewPlugin(Version("v2", 100)).(*mirror)
+
+	var mtx sync.Mutex
+	var bodies []string
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	h := m.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer wg.Done()
+
+		b, _ := ioutil.ReadAll(r.Body)
+		mtx.Lock()
+		bodies = append(bodies, string(b))
+		mtx.Unlock()
+
+		// the shadow fails
+		if r.Header.Get(mirrorHeader) == "v2" {
+			w.WriteHeader(500)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	r := httptest.NewRequest("POST", "/foo", bytes.NewReader([]byte("hello")))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(mirrorHeader, "spoofed")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != 200 || w.Body.String() != "ok" {
+		t.Fatalf("expected primary response got %d %s", w.Code, w.Body.String())
+	}
+
+	wg.Wait()
+
+	// wait for the stats to be recorded
+	for i := 0; i < 100; i++ {
+		if m.Stats().Mirrored == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	s := m.Stats()
+	if s.Mirrored != 1 || s.Mismatched != 1 || s.ShadowErrors != 1 || s.PrimaryErrors != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(bodies) != 2 || bodies[0] != "hello" || bodies[1] != "hello" {
+		t.Fatalf("expected body to be mirrored got %v", bodies)
+	}
+}
+
+func TestSample(t *testing.T) {
+	m := NewPlugin().(*mirror)
+	if m.sample() {
+		t.Fatal("expected no mirroring without a version")
+	}
+
+	m = NewPlugin(Version("v2", 25)).(*mirror)
+
+	var n int
+	for i := 0; i < 1000; i++ {
+		if m.sample() {
+			n++
+		}
+	}
+	if n < 150 || n > 350 {
+		t.Fatalf("unexpected sample count %d", n)
+	}
+}
+
+func TestNotMirrored(t *testing.T) {
+	m := NewPlugin(Version("v2", 100), MaxBodySize(4)).(*mirror)
+
+	var mtx sync.Mutex
+	var bodies []string
+
+	h := m.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mtx.Lock()
+		bodies = append(bodies, string(b))
+		mtx.Unlock()
+	}))
+
+	testData := []*http.Request{
+		// not an rpc
+		httptest.NewRequest("GET", "/foo", nil),
+		httptest.NewRequest("POST", "/foo", strings.NewReader("a=b")),
+		// the body is too large
+		httptest.NewRequest("POST", "/foo", strings.NewReader("hello")),
+	}
+	testData[1].Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	testData[2].Header.Set("Content-Type", "application/json")
+	// unknown length
+	testData[2].ContentLength = -1
+
+	for _, r := range testData {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if strings.Join(bodies, ",") != ",a=b,hello" {
+		t.Fatalf("expected only the primary requests got %v", bodies)
+	}
+	if s := m.Stats(); s.Mirrored != 0 {
+		t.Fatalf("expected no mirrored requests got %+v", s)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var called int
+	tr := &transport{roundTripper(func(r *http.Request) (*http.Response, error) {
+		called++
+		return &http.Response{StatusCode: 200}, nil
+	})}
+
+	r := httptest.NewRequest("POST", "http://foo/bar", nil)
+	if _, err := tr.RoundTrip(r); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Header.Set(mirrorHeader, "v2")
+	if _, err := tr.RoundTrip(r); err != errShadow {
+		t.Fatalf("expected shadow request to be dropped got %v", err)
+	}
+	if called != 1 {
+		t.Fatalf("expected 1 request sent got %d", called)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	services := []*registry.Service{
+		{Name: "foo", Version: "v1"},
+		{Name: "foo", Version: "v2"},
+	}
+
+	w := &wrapper{version: "v2"}
+
+	if s := exclude(w.version)(services); len(s) != 1 || s[0].Version != "v1" {
+		t.Fatalf("expected primary requests to exclude the shadow got %v", s)
+	}
+	if s := filter(w.version)(services); len(s) != 1 || s[0].Version != "v2" {
+		t.Fatalf("expected shadow requests to only use the shadow got %v", s)
+	}
+
+	if opts := w.route(context.Background(), nil); len(opts) != 1 {
+		t.Fatalf("expected primary requests to be routed got %d options", len(opts))
+	}
+
+	ctx := metadata.NewContext(context.Background(), metadata.Metadata{mirrorHeader: "v2"})
+	if opts := w.route(ctx, nil); len(opts) != 1 {
+		t.Fatalf("expected shadow requests to be routed got %d options", len(opts))
+	}
+
+	if opts := (&wrapper{}).route(context.Background(), nil); len(opts) != 0 {
+		t.Fatalf("expected no routing without a shadow got %d options", len(opts))
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
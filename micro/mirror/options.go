@@ -0,0 +1,63 @@
+package mirror
+
+import (
+	"time"
+)
+
+type Options struct {
+	// Version of the services requests are mirrored to
+	Version string
+	// Percent of requests mirrored between 0 and 100
+	Percent float64
+	// Timeout for mirrored requests
+	Timeout time.Duration
+	// MaxInflight mirrored requests. Further requests aren't mirrored.
+	MaxInflight int
+	// MaxBodySize of requests mirrored. Larger requests aren't mirrored.
+	MaxBodySize int64
+}
+
+type Option func(o *Options)
+
+// Version sets the shadow version and percent of requests mirrored to it
+func Version(v string, percent float64) Option {
+	return func(o *Options) {
+		o.Version = v
+		o.Percent = percent
+	}
+}
+
+// Timeout sets the timeout for mirrored requests
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// MaxInflight sets the max concurrent mirrored requests
+func MaxInflight(n int) Option {
+	return func(o *Options) {
+		o.MaxInflight = n
+	}
+}
+
+// MaxBodySize sets the max size of request bodies mirrored
+func MaxBodySize(n int64) Option {
+	return func(o *Options) {
+		o.MaxBodySize = n
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Timeout:     time.Second * 5,
+		MaxInflight: 100,
+		MaxBodySize: 1 << 20,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
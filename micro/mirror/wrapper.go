@@ -0,0 +1,86 @@
+package mirror
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+type wrapper struct {
+	client.Client
+	// the shadow version
+	version string
+}
+
+// filter returns only the services of the shadow version. Unlike
+// canary routing there's no fallback; a mirror must never hit the
+// primary version twice.
+func filter(version string) selector.Filter {
+	return func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+		for _, service := range services {
+			if service.Version == version {
+				filtered = append(filtered, service)
+			}
+		}
+		return filtered
+	}
+}
+
+// exclude returns the services other than the shadow version so
+// primary requests are never served by it
+func exclude(version string) selector.Filter {
+	return func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+		for _, service := range services {
+			if service.Version != version {
+				filtered = append(filtered, service)
+			}
+		}
+		return filtered
+	}
+}
+
+func version(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return md[mirrorHeader]
+}
+
+// route sends shadow requests to the shadow version and
+// every other request away from it
+func (w *wrapper) route(ctx context.Context, opts []client.CallOption) []client.CallOption {
+	if v := version(ctx); len(v) > 0 {
+		return append(opts, client.WithSelectOption(selector.WithFilter(filter(v))))
+	}
+	if len(w.version) > 0 {
+		return append(opts, client.WithSelectOption(selector.WithFilter(exclude(w.version))))
+	}
+	return opts
+}
+
+func (w *wrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return w.Client.Call(ctx, req, rsp, w.route(ctx, opts)...)
+}
+
+func (w *wrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return w.Client.Stream(ctx, req, w.route(ctx, opts)...)
+}
+
+// Publish drops messages published on behalf of a mirrored request
+// so events aren't duplicated
+func (w *wrapper) Publish(ctx context.Context, msg client.Message, opts ...client.PublishOption) error {
+	if v := version(ctx); len(v) > 0 {
+		return nil
+	}
+	return w.Client.Publish(ctx, msg, opts...)
+}
+
+func newClient(c client.Client, version string) client.Client {
+	return &wrapper{c, version}
+}
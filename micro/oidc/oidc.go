@@ -0,0 +1,543 @@
+// Package oidc is a micro plugin for OpenID Connect login and session management
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc"
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/micro/plugin"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+type provider struct {
+	opts Options
+
+	codec    *codec
+	config   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+	callback string
+
+	// refreshes of a session are shared by its concurrent requests
+	// as refresh tokens may only be used once
+	refreshes singleflight.Group
+}
+
+var (
+	// DefaultLogoutPath clears the session
+	DefaultLogoutPath = "/oidc/logout"
+	// DefaultStateCookie holds the login state
+	DefaultStateCookie = "micro_oidc_state"
+
+	// maxCookieSize is the largest cookie value set, larger
+	// values are split across cookies to stay within the
+	// 4KB browsers allow per cookie
+	maxCookieSize = 3800
+
+	// headers set from the session; any sent by the client are removed
+	userHeader  = "X-Micro-User"
+	emailHeader = "X-Micro-Email"
+	rolesHeader = "X-Micro-Roles"
+)
+
+func (p *provider) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "oidc_issuer",
+			Usage:  "OIDC issuer url e.g https://accounts.google.com",
+			EnvVar: "OIDC_ISSUER",
+		},
+		cli.StringFlag{
+			Name:   "oidc_client_id",
+			Usage:  "OIDC client id",
+			EnvVar: "OIDC_CLIENT_ID",
+		},
+		cli.StringFlag{
+			Name:   "oidc_client_secret",
+			Usage:  "OIDC client secret",
+			EnvVar: "OIDC_CLIENT_SECRET",
+		},
+		cli.StringFlag{
+			Name:   "oidc_redirect_url",
+			Usage:  "OIDC redirect url e.g https://api.example.com/oidc/callback",
+			EnvVar: "OIDC_REDIRECT_URL",
+		},
+		cli.StringFlag{
+			Name:   "oidc_scopes",
+			Usage:  "Comma separated list of additional scopes to request",
+			EnvVar: "OIDC_SCOPES",
+		},
+		cli.StringFlag{
+			Name:   "oidc_cookie_secret",
+			Usage:  "Secret used to encrypt the session cookie",
+			EnvVar: "OIDC_COOKIE_SECRET",
+		},
+		cli.StringFlag{
+			Name:   "oidc_groups_claim",
+			Usage:  "Claim holding the user groups. Defaults to groups",
+			EnvVar: "OIDC_GROUPS_CLAIM",
+		},
+		cli.StringSliceFlag{
+			Name:   "oidc_role",
+			Usage:  "Group to role mapping e.g admins=admin",
+			EnvVar: "OIDC_ROLE",
+		},
+		cli.StringFlag{
+			Name:   "oidc_skip_paths",
+			Usage:  "Comma separated list of paths which don't require login",
+			EnvVar: "OIDC_SKIP_PATHS",
+		},
+	}
+}
+
+func (p *provider) Commands() []cli.Command {
+	return nil
+}
+
+// skip returns true if the path doesn't require authentication
+func (p *provider) skip(path string) bool {
+	for _, s := range p.opts.SkipPaths {
+		if strings.HasSuffix(s, "/") && strings.HasPrefix(path, s) {
+			return true
+		}
+		if s == path {
+			return true
+		}
+	}
+	return false
+}
+
+// chunk returns the name of the nth cookie of a value, the
+// first has the name and the rest a suffix e.g name_1
+func chunk(name string, n int) string {
+	if n == 0 {
+		return name
+	}
+	return name + "_" + strconv.Itoa(n)
+}
+
+// chunks returns the number of cookies of the value in the request
+func chunks(r *http.Request, name string) int {
+	n := 0
+	for {
+		if _, err := r.Cookie(chunk(name, n)); err != nil {
+			return n
+		}
+		n++
+	}
+}
+
+// setCookie encrypts the value into one or more cookies and
+// clears the remaining cookies of a previous, larger, value
+func (p *provider) setCookie(w http.ResponseWriter, r *http.Request, name string, v interface{}, expiry time.Duration) error {
+	value, err := p.codec.encode(name, v)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for ; len(value) > 0; n++ {
+		part := value
+		if len(part) > maxCookieSize {
+			part = part[:maxCookieSize]
+		}
+		value = value[len(part):]
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     chunk(name, n),
+			Value:    part,
+			Path:     "/",
+			Expires:  time.Now().Add(expiry),
+			HttpOnly: true,
+			Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	for i := chunks(r, name) - 1; i >= n; i-- {
+		p.clearChunk(w, chunk(name, i))
+	}
+
+	return nil
+}
+
+// cookie returns the value of the cookies of the name
+func (p *provider) cookie(r *http.Request, name string) (string, error) {
+	n := chunks(r, name)
+	if n == 0 {
+		return "", http.ErrNoCookie
+	}
+
+	var value string
+	for i := 0; i < n; i++ {
+		c, _ := r.Cookie(chunk(name, i))
+		value += c.Value
+	}
+
+	return value, nil
+}
+
+func (p *provider) clearCookie(w http.ResponseWriter, r *http.Request, name string) {
+	// the first cookie is always cleared
+	n := chunks(r, name)
+	if n == 0 {
+		n = 1
+	}
+
+	for i := 0; i < n; i++ {
+		p.clearChunk(w, chunk(name, i))
+	}
+}
+
+func (p *provider) clearChunk(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:    name,
+		Value:   "",
+		Path:    "/",
+		Expires: time.Unix(0, 0),
+		MaxAge:  -1,
+	})
+}
+
+// login redirects to the provider
+func (p *provider) login(w http.ResponseWriter, r *http.Request) {
+	st, err := random()
+	if err != nil {
+		http.Error(w, "internal server error", 500)
+		return
+	}
+	nonce, err := random()
+	if err != nil {
+		http.Error(w, "internal server error", 500)
+		return
+	}
+
+	s := &state{
+		State:    st,
+		Nonce:    nonce,
+		Redirect: r.URL.RequestURI(),
+	}
+
+	if err := p.setCookie(w, r, DefaultStateCookie, s, time.Minute*10); err != nil {
+		http.Error(w, "internal server error", 500)
+		return
+	}
+
+	http.Redirect(w, r, p.config.AuthCodeURL(st, gooidc.Nonce(nonce)), http.StatusFound)
+}
+
+// groups extracts the groups claim
+func (p *provider) groups(token *gooidc.IDToken) []string {
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return nil
+	}
+
+	var groups []string
+
+	switch v := claims[p.opts.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	case string:
+		groups = strings.Split(v, ",")
+	}
+
+	return groups
+}
+
+// verify verifies the id token returned with an oauth2 token
+func (p *provider) verify(ctx context.Context, tok *oauth2.Token) (*gooidc.IDToken, string, error) {
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, "", errors.New("missing id_token")
+	}
+
+	id, err := p.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var claims struct {
+		Email string `json:"email"`
+	}
+	id.Claims(&claims)
+
+	return id, claims.Email, nil
+}
+
+// callback completes the authorization code flow
+func (p *provider) handleCallback(w http.ResponseWriter, r *http.Request) {
+	c, err := p.cookie(r, DefaultStateCookie)
+	if err != nil {
+		http.Error(w, "missing state", 400)
+		return
+	}
+
+	var s state
+	if err := p.codec.decode(DefaultStateCookie, c, &s); err != nil {
+		http.Error(w, "invalid state", 400)
+		return
+	}
+
+	p.clearCookie(w, r, DefaultStateCookie)
+
+	if r.URL.Query().Get("state") != s.State {
+		http.Error(w, "invalid state", 400)
+		return
+	}
+
+	if e := r.URL.Query().Get("error"); len(e) > 0 {
+		http.Error(w, "login failed: "+e, 401)
+		return
+	}
+
+	tok, err := p.config.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		log.Logf("[oidc] failed to exchange code: %v", err)
+		http.Error(w, "login failed", 401)
+		return
+	}
+
+	id, email, err := p.verify(r.Context(), tok)
+	if err != nil {
+		log.Logf("[oidc] failed to verify id token: %v", err)
+		http.Error(w, "login failed", 401)
+		return
+	}
+
+	if id.Nonce != s.Nonce {
+		http.Error(w, "invalid nonce", 401)
+		return
+	}
+
+	sess := &Session{
+		Subject:      id.Subject,
+		Email:        email,
+		Roles:        roles(p.opts.Roles, p.groups(id)),
+		AccessToken:  tok.AccessToken,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+		Created:      time.Now(),
+	}
+
+	if err := p.setCookie(w, r, p.opts.CookieName, sess, p.opts.CookieExpiry); err != nil {
+		http.Error(w, "internal server error", 500)
+		return
+	}
+
+	// only redirect to local paths
+	redirect := s.Redirect
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		redirect = "/"
+	}
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// refresh renews the tokens of an expired session
+func (p *provider) refresh(ctx context.Context, sess *Session) error {
+	if len(sess.RefreshToken) == 0 {
+		return errors.New("no refresh token")
+	}
+
+	v, err, _ := p.refreshes.Do(sess.RefreshToken, func() (interface{}, error) {
+		return p.config.TokenSource(ctx, &oauth2.Token{
+			RefreshToken: sess.RefreshToken,
+			Expiry:       time.Now().Add(-time.Second),
+		}).Token()
+	})
+	if err != nil {
+		return err
+	}
+
+	tok := v.(*oauth2.Token)
+
+	sess.AccessToken = tok.AccessToken
+	sess.Expiry = tok.Expiry
+	if len(tok.RefreshToken) > 0 {
+		sess.RefreshToken = tok.RefreshToken
+	}
+
+	// group membership may have changed
+	if id, email, err := p.verify(ctx, tok); err == nil {
+		sess.Email = email
+		sess.Roles = roles(p.opts.Roles, p.groups(id))
+	}
+
+	return nil
+}
+
+// session returns the current session refreshing it if required
+func (p *provider) session(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	c, err := p.cookie(r, p.opts.CookieName)
+	if err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := p.codec.decode(p.opts.CookieName, c, &sess); err != nil {
+		return nil, err
+	}
+
+	if time.Since(sess.Created) > p.opts.CookieExpiry {
+		return nil, errors.New("session expired")
+	}
+
+	if !sess.Expiry.IsZero() && time.Now().After(sess.Expiry) {
+		if err := p.refresh(r.Context(), &sess); err != nil {
+			return nil, err
+		}
+		// keep the original lifetime
+		remaining := p.opts.CookieExpiry - time.Since(sess.Created)
+		if err := p.setCookie(w, r, p.opts.CookieName, &sess, remaining); err != nil {
+			return nil, err
+		}
+	}
+
+	return &sess, nil
+}
+
+func (p *provider) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// never trust identity headers from the client
+			r.Header.Del(userHeader)
+			r.Header.Del(emailHeader)
+			r.Header.Del(rolesHeader)
+
+			switch r.URL.Path {
+			case p.callback:
+				p.handleCallback(w, r)
+				return
+			case DefaultLogoutPath:
+				p.clearCookie(w, r, p.opts.CookieName)
+				http.Redirect(w, r, "/", http.StatusFound)
+				return
+			}
+
+			if p.skip(r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			sess, err := p.session(w, r)
+			if err != nil {
+				// browsers are sent to login, everything else is unauthorized
+				if r.Method == "GET" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+					p.login(w, r)
+					return
+				}
+				http.Error(w, "unauthorized", 401)
+				return
+			}
+
+			r.Header.Set(userHeader, sess.Subject)
+			if len(sess.Email) > 0 {
+				r.Header.Set(emailHeader, sess.Email)
+			}
+			if len(sess.Roles) > 0 {
+				r.Header.Set(rolesHeader, strings.Join(sess.Roles, ","))
+			}
+			if len(sess.AccessToken) > 0 {
+				r.Header.Set("Authorization", "Bearer "+sess.AccessToken)
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (p *provider) Init(ctx *cli.Context) error {
+	if v := ctx.String("oidc_issuer"); len(v) > 0 {
+		p.opts.Issuer = v
+	}
+	if v := ctx.String("oidc_client_id"); len(v) > 0 {
+		p.opts.ClientId = v
+	}
+	if v := ctx.String("oidc_client_secret"); len(v) > 0 {
+		p.opts.ClientSecret = v
+	}
+	if v := ctx.String("oidc_redirect_url"); len(v) > 0 {
+		p.opts.RedirectURL = v
+	}
+	if v := ctx.String("oidc_scopes"); len(v) > 0 {
+		p.opts.Scopes = strings.Split(v, ",")
+	}
+	if v := ctx.String("oidc_cookie_secret"); len(v) > 0 {
+		p.opts.CookieSecret = v
+	}
+	if v := ctx.String("oidc_groups_claim"); len(v) > 0 {
+		p.opts.GroupsClaim = v
+	}
+	for _, v := range ctx.StringSlice("oidc_role") {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid role mapping %s", v)
+		}
+		p.opts.Roles[parts[0]] = append(p.opts.Roles[parts[0]], strings.Split(parts[1], ",")...)
+	}
+	if v := ctx.String("oidc_skip_paths"); len(v) > 0 {
+		p.opts.SkipPaths = strings.Split(v, ",")
+	}
+
+	return p.init(context.Background())
+}
+
+// init discovers the provider and sets up the oauth2 config
+func (p *provider) init(ctx context.Context) error {
+	if len(p.opts.Issuer) == 0 || len(p.opts.ClientId) == 0 || len(p.opts.RedirectURL) == 0 {
+		return errors.New("oidc issuer, client id and redirect url are required")
+	}
+
+	c, err := newCodec(p.opts.CookieSecret)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(p.opts.RedirectURL)
+	if err != nil {
+		return err
+	}
+
+	prov, err := gooidc.NewProvider(ctx, p.opts.Issuer)
+	if err != nil {
+		return err
+	}
+
+	p.codec = c
+	p.callback = u.Path
+	p.verifier = prov.Verifier(&gooidc.Config{ClientID: p.opts.ClientId})
+	p.config = &oauth2.Config{
+		ClientID:     p.opts.ClientId,
+		ClientSecret: p.opts.ClientSecret,
+		RedirectURL:  p.opts.RedirectURL,
+		Endpoint:     prov.Endpoint(),
+		Scopes:       append([]string{gooidc.ScopeOpenID}, p.opts.Scopes...),
+	}
+
+	return nil
+}
+
+func (p *provider) String() string {
+	return "oidc"
+}
+
+// NewPlugin returns a plugin which requires an OIDC login for requests
+func NewPlugin(opts ...Option) plugin.Plugin {
+	return &provider{
+		opts: newOptions(opts...),
+	}
+}
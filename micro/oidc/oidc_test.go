@@ -0,0 +1,202 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func testProvider(t *testing.T) *provider {
+	c, err := newCodec("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &provider{
+		opts:     newOptions(SkipPaths("/health", "/public/")),
+		codec:    c,
+		callback: "/oidc/callback",
+		config: &oauth2.Config{
+			ClientID:    "client",
+			RedirectURL: "http://localhost/oidc/callback",
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "http://idp/auth",
+				TokenURL: "http://idp/token",
+			},
+		},
+	}
+}
+
+func TestCodec(t *testing.T) {
+	c, err := newCodec("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := c.encode("session", &Session{Subject: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s Session
+	if err := c.decode("session", v, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Subject != "bob" {
+		t.Fatalf("expected bob got %s", s.Subject)
+	}
+
+	// bound to the cookie name
+	if err := c.decode("other", v, &s); err == nil {
+		t.Fatal("expected error decoding with a different name")
+	}
+
+	// different secret
+	c2, _ := newCodec("other")
+	if err := c2.decode("session", v, &s); err == nil {
+		t.Fatal("expected error decoding with a different secret")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	mapping := map[string][]string{
+		"admins": {"admin", "user"},
+		"devs":   {"user"},
+	}
+
+	r := roles(mapping, []string{"admins", "devs", "other"})
+	if len(r) != 2 || r[0] != "admin" || r[1] != "user" {
+		t.Fatalf("unexpected roles %v", r)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	p := testProvider(t)
+
+	h := p.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(userHeader) + ":" + r.Header.Get(rolesHeader)))
+	}))
+
+	// browser without a session is sent to login
+	r := httptest.NewRequest("GET", "/foo", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusFound || !strings.HasPrefix(w.Header().Get("Location"), "http://idp/auth") {
+		t.Fatalf("expected redirect to login got %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	// api clients are unauthorized
+	r = httptest.NewRequest("POST", "/foo", nil)
+	r.Header.Set(userHeader, "spoofed")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 401 {
+		t.Fatalf("expected 401 got %d", w.Code)
+	}
+
+	// skipped paths
+	r = httptest.NewRequest("GET", "/public/index.html", nil)
+	r.Header.Set(userHeader, "spoofed")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 200 || w.Body.String() != ":" {
+		t.Fatalf("expected unauthenticated passthrough got %d %s", w.Code, w.Body.String())
+	}
+
+	// valid session
+	v, err := p.codec.encode(p.opts.CookieName, &Session{
+		Subject: "bob",
+		Roles:   []string{"admin"},
+		Expiry:  time.Now().Add(time.Hour),
+		Created: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest("GET", "/foo", nil)
+	r.AddCookie(&http.Cookie{Name: p.opts.CookieName, Value: v})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 200 || w.Body.String() != "bob:admin" {
+		t.Fatalf("expected session headers got %d %s", w.Code, w.Body.String())
+	}
+
+	// session past its lifetime
+	v, _ = p.codec.encode(p.opts.CookieName, &Session{
+		Subject: "bob",
+		Created: time.Now().Add(-p.opts.CookieExpiry - time.Minute),
+	})
+	r = httptest.NewRequest("GET", "/foo", nil)
+	r.AddCookie(&http.Cookie{Name: p.opts.CookieName, Value: v})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 401 {
+		t.Fatalf("expected 401 for expired session got %d", w.Code)
+	}
+}
+
+func TestCookieChunks(t *testing.T) {
+	p := testProvider(t)
+
+	sess := &Session{Subject: "bob"}
+	for i := 0; i < 500; i++ {
+		sess.Roles = append(sess.Roles, "role-"+strconv.Itoa(i))
+	}
+
+	w := httptest.NewRecorder()
+	if err := p.setCookie(w, httptest.NewRequest("GET", "/", nil), "session", sess, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) < 2 {
+		t.Fatalf("expected the session to be split got %d cookies", len(cookies))
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range cookies {
+		if len(c.Value) > maxCookieSize {
+			t.Fatalf("expected cookie within %d bytes got %d", maxCookieSize, len(c.Value))
+		}
+		r.AddCookie(c)
+	}
+
+	v, err := p.cookie(r, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out Session
+	if err := p.codec.decode("session", v, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Roles) != 500 {
+		t.Fatalf("expected 500 roles got %d", len(out.Roles))
+	}
+
+	// a smaller session clears the remaining chunks
+	w = httptest.NewRecorder()
+	if err := p.setCookie(w, r, "session", &Session{Subject: "bob"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	var set, cleared int
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge < 0 {
+			cleared++
+		} else {
+			set++
+		}
+	}
+	if set != 1 || cleared != len(cookies)-1 {
+		t.Fatalf("expected 1 cookie set and %d cleared got %d and %d", len(cookies)-1, set, cleared)
+	}
+}
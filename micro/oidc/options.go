@@ -0,0 +1,114 @@
+package oidc
+
+import (
+	"time"
+)
+
+type Options struct {
+	// Issuer url used for discovery
+	Issuer string
+	// Client id and secret registered with the provider
+	ClientId     string
+	ClientSecret string
+	// Redirect url; its path is served as the callback
+	RedirectURL string
+	// Scopes requested in addition to openid
+	Scopes []string
+	// Secret used to derive the session encryption key
+	CookieSecret string
+	// Name of the session cookie
+	CookieName string
+	// Lifetime of the session cookie
+	CookieExpiry time.Duration
+	// Claim holding the user groups
+	GroupsClaim string
+	// Roles granted per group
+	Roles map[string][]string
+	// Paths which skip authentication. Entries ending in / match by prefix
+	SkipPaths []string
+}
+
+type Option func(o *Options)
+
+// Issuer sets the OIDC issuer url
+func Issuer(url string) Option {
+	return func(o *Options) {
+		o.Issuer = url
+	}
+}
+
+// Client sets the client credentials
+func Client(id, secret string) Option {
+	return func(o *Options) {
+		o.ClientId = id
+		o.ClientSecret = secret
+	}
+}
+
+// RedirectURL sets the callback url registered with the provider
+func RedirectURL(url string) Option {
+	return func(o *Options) {
+		o.RedirectURL = url
+	}
+}
+
+// Scopes sets additional scopes to request
+func Scopes(s ...string) Option {
+	return func(o *Options) {
+		o.Scopes = append(o.Scopes, s...)
+	}
+}
+
+// CookieSecret sets the secret session cookies are encrypted with
+func CookieSecret(s string) Option {
+	return func(o *Options) {
+		o.CookieSecret = s
+	}
+}
+
+// CookieExpiry sets the session lifetime
+func CookieExpiry(d time.Duration) Option {
+	return func(o *Options) {
+		o.CookieExpiry = d
+	}
+}
+
+// GroupsClaim sets the claim groups are read from
+func GroupsClaim(c string) Option {
+	return func(o *Options) {
+		o.GroupsClaim = c
+	}
+}
+
+// Role maps a group to one or more roles
+func Role(group string, roles ...string) Option {
+	return func(o *Options) {
+		if o.Roles == nil {
+			o.Roles = make(map[string][]string)
+		}
+		o.Roles[group] = append(o.Roles[group], roles...)
+	}
+}
+
+// SkipPaths sets paths which don't require authentication
+func SkipPaths(p ...string) Option {
+	return func(o *Options) {
+		o.SkipPaths = append(o.SkipPaths, p...)
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Scopes:       []string{"profile", "email"},
+		CookieName:   "micro_session",
+		CookieExpiry: time.Hour * 24,
+		GroupsClaim:  "groups",
+		Roles:        make(map[string][]string),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,127 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// Session is stored encrypted in the session cookie
+type Session struct {
+	Subject      string    `json:"sub"`
+	Email        string    `json:"email,omitempty"`
+	Roles        []string  `json:"roles,omitempty"`
+	AccessToken  string    `json:"at,omitempty"`
+	RefreshToken string    `json:"rt,omitempty"`
+	Expiry       time.Time `json:"exp"`
+	// Created is used to expire the session regardless of refresh
+	Created time.Time `json:"iat"`
+}
+
+// state is stored in a short lived cookie during login
+type state struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Redirect string `json:"redirect"`
+}
+
+var (
+	errInvalidCookie = errors.New("invalid cookie")
+)
+
+// codec encrypts cookie values with AES-GCM
+type codec struct {
+	aead cipher.AEAD
+}
+
+func newCodec(secret string) (*codec, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("cookie secret required")
+	}
+
+	// derive a 256 bit key from the secret
+	key := sha256.Sum256([]byte(secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &codec{aead}, nil
+}
+
+// encode marshals and encrypts v
+func (c *codec) encode(name string, v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	// the cookie name is authenticated so values can't be swapped between cookies
+	out := c.aead.Seal(nonce, nonce, b, []byte(name))
+
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// decode decrypts and unmarshals into v
+func (c *codec) decode(name, value string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return errInvalidCookie
+	}
+
+	ns := c.aead.NonceSize()
+	if len(b) < ns {
+		return errInvalidCookie
+	}
+
+	plain, err := c.aead.Open(nil, b[:ns], b[ns:], []byte(name))
+	if err != nil {
+		return errInvalidCookie
+	}
+
+	return json.Unmarshal(plain, v)
+}
+
+// random returns a url safe random string
+func random() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// roles maps groups to roles
+func roles(mapping map[string][]string, groups []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+
+	for _, g := range groups {
+		for _, r := range mapping[g] {
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
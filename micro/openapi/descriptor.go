@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+// MetadataKey is the node metadata key holding a base64 encoded
+// serialised FileDescriptorSet for the service
+var MetadataKey = "descriptor"
+
+// descriptors indexes the messages and enums of a file descriptor set
+type descriptors struct {
+	messages map[string]*descriptor.DescriptorProto
+	enums    map[string]*descriptor.EnumDescriptorProto
+}
+
+// parseDescriptors returns the descriptors found in node metadata or nil
+func parseDescriptors(md map[string]string) *descriptors {
+	v, ok := md[MetadataKey]
+	if !ok || len(v) == 0 {
+		return nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil
+	}
+
+	var set descriptor.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil
+	}
+
+	d := &descriptors{
+		messages: make(map[string]*descriptor.DescriptorProto),
+		enums:    make(map[string]*descriptor.EnumDescriptorProto),
+	}
+
+	for _, f := range set.File {
+		prefix := f.GetPackage()
+		for _, m := range f.MessageType {
+			d.addMessage(prefix, m)
+		}
+		for _, e := range f.EnumType {
+			d.enums[join(prefix, e.GetName())] = e
+		}
+	}
+
+	return d
+}
+
+func join(prefix, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (d *descriptors) addMessage(prefix string, m *descriptor.DescriptorProto) {
+	name := join(prefix, m.GetName())
+	d.messages[name] = m
+	for _, n := range m.NestedType {
+		d.addMessage(name, n)
+	}
+	for _, e := range m.EnumType {
+		d.enums[join(name, e.GetName())] = e
+	}
+}
+
+// lookup finds a message by its full or short name
+func (d *descriptors) lookup(name string) (string, *descriptor.DescriptorProto) {
+	name = strings.TrimPrefix(name, ".")
+	if m, ok := d.messages[name]; ok {
+		return name, m
+	}
+	for full, m := range d.messages {
+		if strings.HasSuffix(full, "."+name) {
+			return full, m
+		}
+	}
+	return "", nil
+}
+
+// schema registers the named message and any messages it references
+// in schemas under their fully qualified name, which it returns. It
+// returns an empty name if the message is not described.
+func (d *descriptors) schema(name string, schemas map[string]*Schema) string {
+	full, m := d.lookup(name)
+	if m == nil {
+		return ""
+	}
+
+	if _, ok := schemas[full]; ok {
+		return full
+	}
+
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	// register before recursing to break cycles
+	schemas[full] = s
+
+	for _, f := range m.Field {
+		s.Properties[f.GetJsonName()] = d.field(f, schemas)
+	}
+
+	return full
+}
+
+func (d *descriptors) field(f *descriptor.FieldDescriptorProto, schemas map[string]*Schema) *Schema {
+	var s *Schema
+
+	switch f.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		full, m := d.lookup(f.GetTypeName())
+		// map fields are repeated synthetic entry messages
+		if m != nil && m.GetOptions().GetMapEntry() && len(m.Field) == 2 {
+			return &Schema{
+				Type:                 "object",
+				AdditionalProperties: d.field(m.Field[1], schemas),
+			}
+		}
+		if m == nil {
+			s = &Schema{Type: "object"}
+			break
+		}
+		s = ref(d.schema(full, schemas))
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		s = &Schema{Type: "string"}
+		if e, ok := d.enums[strings.TrimPrefix(f.GetTypeName(), ".")]; ok {
+			for _, v := range e.Value {
+				s.Enum = append(s.Enum, v.GetName())
+			}
+		}
+	default:
+		typ := strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+		if s = scalar(typ); s == nil {
+			s = &Schema{}
+		}
+	}
+
+	if f.GetLabel() == descriptor.FieldDescriptorProto_LABEL_REPEATED {
+		return &Schema{Type: "array", Items: s}
+	}
+
+	return s
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
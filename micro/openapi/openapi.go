@@ -0,0 +1,197 @@
+// Package openapi is a micro plugin which serves a merged OpenAPI document
+// and Swagger UI for the endpoints of all registered services
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/micro/plugin"
+)
+
+type openapi struct {
+	opts Options
+
+	sync.RWMutex
+	spec    []byte
+	updated time.Time
+}
+
+var uiTemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function() {
+      SwaggerUIBundle({url: "%s", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+func (o *openapi) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "openapi_path",
+			Usage:  "Path to serve the OpenAPI document on e.g /openapi.json",
+			EnvVar: "OPENAPI_PATH",
+		},
+		cli.StringFlag{
+			Name:   "openapi_ui_path",
+			Usage:  "Path to serve the Swagger UI on e.g /swagger",
+			EnvVar: "OPENAPI_UI_PATH",
+		},
+		cli.StringFlag{
+			Name:   "openapi_title",
+			Usage:  "Title of the OpenAPI document",
+			EnvVar: "OPENAPI_TITLE",
+		},
+		cli.IntFlag{
+			Name:   "openapi_interval",
+			Usage:  "Interval in seconds at which the document is rebuilt",
+			EnvVar: "OPENAPI_INTERVAL",
+		},
+	}
+}
+
+func (o *openapi) Commands() []cli.Command {
+	return nil
+}
+
+// generate builds the document from the registry
+func (o *openapi) generate() ([]byte, error) {
+	list, err := o.opts.Registry.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	var services []*registry.Service
+
+	for _, s := range list {
+		// list services does not include endpoints
+		svcs, err := o.opts.Registry.GetService(s.Name)
+		if err != nil {
+			log.Logf("[openapi] failed to get service %s: %v", s.Name, err)
+			continue
+		}
+		services = append(services, merge(svcs)...)
+	}
+
+	return json.Marshal(build(o.opts, services))
+}
+
+// merge collapses versions of a service into one, keeping all nodes
+// so that descriptors may be found and all endpoints are described
+func merge(svcs []*registry.Service) []*registry.Service {
+	if len(svcs) <= 1 {
+		return svcs
+	}
+
+	seen := make(map[string]bool)
+	service := &registry.Service{
+		Name: svcs[0].Name,
+	}
+
+	for _, s := range svcs {
+		service.Nodes = append(service.Nodes, s.Nodes...)
+		for _, ep := range s.Endpoints {
+			if ep == nil || seen[ep.Name] {
+				continue
+			}
+			seen[ep.Name] = true
+			service.Endpoints = append(service.Endpoints, ep)
+		}
+	}
+
+	return []*registry.Service{service}
+}
+
+// get returns the cached document rebuilding it when stale
+func (o *openapi) get() ([]byte, error) {
+	o.RLock()
+	spec, updated := o.spec, o.updated
+	o.RUnlock()
+
+	if spec != nil && time.Since(updated) < o.opts.Interval {
+		return spec, nil
+	}
+
+	b, err := o.generate()
+	if err != nil {
+		// serve stale rather than nothing
+		if spec != nil {
+			log.Logf("[openapi] failed to rebuild document: %v", err)
+			return spec, nil
+		}
+		return nil, err
+	}
+
+	o.Lock()
+	o.spec = b
+	o.updated = time.Now()
+	o.Unlock()
+
+	return b, nil
+}
+
+func (o *openapi) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case o.opts.Path:
+				b, err := o.get()
+				if err != nil {
+					http.Error(w, err.Error(), 500)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(b)
+			case o.opts.UIPath:
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprintf(w, uiTemplate, html.EscapeString(o.opts.Title), html.EscapeString(o.opts.Path))
+			default:
+				h.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func (o *openapi) Init(ctx *cli.Context) error {
+	if p := ctx.String("openapi_path"); len(p) > 0 {
+		o.opts.Path = p
+	}
+	if p := ctx.String("openapi_ui_path"); len(p) > 0 {
+		o.opts.UIPath = p
+	}
+	if t := ctx.String("openapi_title"); len(t) > 0 {
+		o.opts.Title = t
+	}
+	if i := ctx.Int("openapi_interval"); i > 0 {
+		o.opts.Interval = time.Duration(i) * time.Second
+	}
+	return nil
+}
+
+func (o *openapi) String() string {
+	return "openapi"
+}
+
+// NewPlugin returns a plugin which serves the OpenAPI document and Swagger UI
+func NewPlugin(opts ...Option) plugin.Plugin {
+	return &openapi{
+		opts: newOptions(opts...),
+	}
+}
@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+)
+
+func testService() *registry.Service {
+	return &registry.Service{
+		Name:    "go.micro.api.greeter",
+		Version: "latest",
+		Nodes: []*registry.Node{
+			{Id: "greeter-1", Address: "127.0.0.1", Port: 8080},
+		},
+		Endpoints: []*registry.Endpoint{
+			{
+				Name: "Greeter.Hello",
+				Request: &registry.Value{
+					Name: "Request",
+					Type: "Request",
+					Values: []*registry.Value{
+						{Name: "name", Type: "string"},
+						{Name: "tags", Type: "[]string"},
+					},
+				},
+				Response: &registry.Value{
+					Name: "Response",
+					Type: "Response",
+					Values: []*registry.Value{
+						{Name: "msg", Type: "string"},
+						{Name: "count", Type: "int64"},
+					},
+				},
+			},
+			{
+				Name: "Greeter.List",
+				Request: &registry.Value{
+					Name: "ListRequest",
+					Type: "ListRequest",
+				},
+				Response: &registry.Value{
+					Name: "ListResponse",
+					Type: "ListResponse",
+				},
+				Metadata: map[string]string{
+					"method": "GET",
+					"path":   "/greetings",
+				},
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	doc := build(newOptions(), []*registry.Service{testService()})
+
+	hello, ok := doc.Paths["/greeter/hello"]
+	if !ok || hello.Post == nil {
+		t.Fatalf("expected post /greeter/hello got %+v", doc.Paths)
+	}
+
+	if ref := hello.Post.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/go.micro.api.greeter.Request" {
+		t.Fatalf("unexpected request ref %s", ref)
+	}
+
+	req := doc.Components.Schemas["go.micro.api.greeter.Request"]
+	if req == nil {
+		t.Fatal("expected request schema")
+	}
+	if s := req.Properties["tags"]; s == nil || s.Type != "array" || s.Items.Type != "string" {
+		t.Fatalf("unexpected tags schema %+v", s)
+	}
+
+	rsp := doc.Components.Schemas["go.micro.api.greeter.Response"]
+	if s := rsp.Properties["count"]; s == nil || s.Format != "int64" {
+		t.Fatalf("unexpected count schema %+v", s)
+	}
+
+	list, ok := doc.Paths["/greetings"]
+	if !ok || list.Get == nil {
+		t.Fatalf("expected get /greetings got %+v", doc.Paths)
+	}
+	if list.Get.RequestBody != nil {
+		t.Fatal("expected no request body for get")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := mock.NewRegistry()
+	if err := r.Register(testService()); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPlugin(Registry(r), Title("Test API"))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := p.Handler()(next)
+
+	// spec
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Title != "Test API" {
+		t.Fatalf("unexpected title %s", doc.Info.Title)
+	}
+	if _, ok := doc.Paths["/greeter/hello"]; !ok {
+		t.Fatalf("expected /greeter/hello in %+v", doc.Paths)
+	}
+
+	// ui
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/swagger", nil))
+	if !strings.Contains(w.Body.String(), "/openapi.json") {
+		t.Fatal("expected ui to reference the document")
+	}
+
+	// passthrough
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/foo", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("expected passthrough got %d", w.Code)
+	}
+}
+
+func TestQualifiedNames(t *testing.T) {
+	// a service describing its request with a descriptor of the package
+	described := func(name, pkg, field string) *registry.Service {
+		b, err := proto.Marshal(&descriptor.FileDescriptorSet{
+			File: []*descriptor.FileDescriptorProto{{
+				Package: proto.String(pkg),
+				MessageType: []*descriptor.DescriptorProto{{
+					Name: proto.String("Request"),
+					Field: []*descriptor.FieldDescriptorProto{{
+						Name:     proto.String(field),
+						JsonName: proto.String(field),
+						Type:     descriptor.FieldDescriptorProto_TYPE_STRING.Enum(),
+					}},
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return &registry.Service{
+			Name: name,
+			Nodes: []*registry.Node{{
+				Id:       name + "-1",
+				Metadata: map[string]string{MetadataKey: base64.StdEncoding.EncodeToString(b)},
+			}},
+			Endpoints: []*registry.Endpoint{{
+				Name:     "Handler.Call",
+				Request:  &registry.Value{Name: "Request", Type: "Request"},
+				Response: &registry.Value{Name: "Request", Type: "Request"},
+			}},
+		}
+	}
+
+	// a service only described by the registry
+	plain := testService()
+	plain.Name = "go.micro.api.other"
+
+	doc := build(newOptions(), []*registry.Service{
+		described("go.micro.api.foo", "foo", "name"),
+		described("go.micro.api.bar", "bar", "id"),
+		testService(),
+		plain,
+	})
+
+	testData := map[string]string{
+		"foo.Request":                  "name",
+		"bar.Request":                  "id",
+		"go.micro.api.greeter.Request": "tags",
+		"go.micro.api.other.Request":   "tags",
+	}
+
+	for name, field := range testData {
+		s, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Fatalf("expected schema %s in %v", name, doc.Components.Schemas)
+		}
+		if _, ok := s.Properties[field]; !ok {
+			t.Fatalf("expected field %s in schema %s got %+v", field, name, s.Properties)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type Options struct {
+	// Registry to aggregate endpoints from
+	Registry registry.Registry
+	// Path the merged spec is served on
+	Path string
+	// Path the swagger ui is served on
+	UIPath string
+	// Title of the generated document
+	Title string
+	// Version of the generated document
+	Version string
+	// Namespace is stripped from service names when building paths
+	Namespace string
+	// Interval at which the spec is regenerated
+	Interval time.Duration
+}
+
+type Option func(o *Options)
+
+// Registry sets the registry used to discover services
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// Path sets the path the openapi document is served on
+func Path(p string) Option {
+	return func(o *Options) {
+		o.Path = p
+	}
+}
+
+// UIPath sets the path the swagger ui is served on
+func UIPath(p string) Option {
+	return func(o *Options) {
+		o.UIPath = p
+	}
+}
+
+// Title sets the info title of the document
+func Title(t string) Option {
+	return func(o *Options) {
+		o.Title = t
+	}
+}
+
+// Version sets the info version of the document
+func Version(v string) Option {
+	return func(o *Options) {
+		o.Version = v
+	}
+}
+
+// Namespace is stripped from service names e.g go.micro.api
+func Namespace(n string) Option {
+	return func(o *Options) {
+		o.Namespace = n
+	}
+}
+
+// Interval sets how often the document is rebuilt from the registry
+func Interval(d time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Registry:  registry.DefaultRegistry,
+		Path:      "/openapi.json",
+		UIPath:    "/swagger",
+		Title:     "Micro API",
+		Version:   "1.0.0",
+		Namespace: "go.micro.api",
+		Interval:  time.Minute,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
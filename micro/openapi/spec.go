@@ -0,0 +1,338 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/micro/go-micro/registry"
+)
+
+// Document is a minimal OpenAPI 3 document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	Tags       []Tag                `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// generator builds a document from registry services
+type generator struct {
+	opts Options
+	doc  *Document
+}
+
+func newDocument(opts Options) *Document {
+	return &Document{
+		OpenAPI: "3.0.0",
+		Info: Info{
+			Title:   opts.Title,
+			Version: opts.Version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+}
+
+// build generates a merged document for the given services
+func build(opts Options, services []*registry.Service) *Document {
+	g := &generator{
+		opts: opts,
+		doc:  newDocument(opts),
+	}
+
+	// deterministic output
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	for _, service := range services {
+		g.addService(service)
+	}
+
+	return g.doc
+}
+
+func (g *generator) addService(service *registry.Service) {
+	// load any descriptors published in node metadata
+	var desc *descriptors
+	for _, node := range service.Nodes {
+		if d := parseDescriptors(node.Metadata); d != nil {
+			desc = d
+			break
+		}
+	}
+
+	tag := g.serviceName(service.Name)
+	g.doc.Tags = append(g.doc.Tags, Tag{
+		Name:        tag,
+		Description: service.Name,
+	})
+
+	for _, ep := range service.Endpoints {
+		if ep == nil {
+			continue
+		}
+
+		path, method := g.route(service.Name, ep)
+
+		op := &Operation{
+			OperationId: service.Name + "." + ep.Name,
+			Summary:     ep.Metadata["description"],
+			Tags:        []string{tag},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "OK",
+					Content: map[string]*MediaType{
+						"application/json": {Schema: g.schema(service.Name, desc, ep.Response)},
+					},
+				},
+				"default": {
+					Description: "Error",
+					Content: map[string]*MediaType{
+						"application/json": {Schema: errorSchema},
+					},
+				},
+			},
+		}
+
+		if method != "get" && method != "delete" {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					"application/json": {Schema: g.schema(service.Name, desc, ep.Request)},
+				},
+			}
+		}
+
+		item, ok := g.doc.Paths[path]
+		if !ok {
+			item = new(PathItem)
+			g.doc.Paths[path] = item
+		}
+
+		switch method {
+		case "get":
+			item.Get = op
+		case "put":
+			item.Put = op
+		case "delete":
+			item.Delete = op
+		case "patch":
+			item.Patch = op
+		default:
+			item.Post = op
+		}
+	}
+}
+
+// serviceName strips the namespace from the service name
+func (g *generator) serviceName(name string) string {
+	if len(g.opts.Namespace) > 0 {
+		name = strings.TrimPrefix(name, g.opts.Namespace+".")
+	}
+	return name
+}
+
+// route returns the http path and method for an endpoint. The endpoint
+// metadata keys "path" and "method" take precedence over the default
+// /[service]/[method] rpc mapping used by the api.
+func (g *generator) route(service string, ep *registry.Endpoint) (string, string) {
+	method := strings.ToLower(ep.Metadata["method"])
+	if len(method) == 0 {
+		method = "post"
+	}
+
+	if path := ep.Metadata["path"]; len(path) > 0 {
+		return path, method
+	}
+
+	parts := []string{strings.Replace(g.serviceName(service), ".", "/", -1)}
+
+	// Greeter.Hello => greeter/hello; drop the handler if it matches the service
+	epParts := strings.Split(ep.Name, ".")
+	if len(epParts) == 2 && strings.EqualFold(epParts[0], parts[0]) {
+		epParts = epParts[1:]
+	}
+
+	for _, p := range epParts {
+		parts = append(parts, strings.ToLower(p))
+	}
+
+	return "/" + strings.Join(parts, "/"), method
+}
+
+// qualify returns the component name of a type. Descriptors name messages
+// by their package, registry values only by their type so they're
+// qualified by the service to keep types of other services apart.
+func qualify(service, typ string) string {
+	if strings.Contains(typ, ".") {
+		return typ
+	}
+	return service + "." + typ
+}
+
+// schema returns a reference to the named component schema for the value,
+// registering it if required
+func (g *generator) schema(service string, desc *descriptors, v *registry.Value) *Schema {
+	if v == nil {
+		return &Schema{Type: "object"}
+	}
+
+	typ := strings.TrimPrefix(v.Type, "*")
+	if len(typ) == 0 {
+		typ = v.Name
+	}
+
+	// prefer the descriptor since it carries json names and enums
+	if desc != nil {
+		if name := desc.schema(typ, g.doc.Components.Schemas); len(name) > 0 {
+			return ref(name)
+		}
+	}
+
+	name := qualify(service, typ)
+	if _, ok := g.doc.Components.Schemas[name]; !ok {
+		// reserve the name before recursing
+		g.doc.Components.Schemas[name] = &Schema{Type: "object"}
+		g.doc.Components.Schemas[name] = g.valueSchema(service, v, true)
+	}
+
+	return ref(name)
+}
+
+// valueSchema converts a registry value to a schema
+func (g *generator) valueSchema(service string, v *registry.Value, top bool) *Schema {
+	typ := strings.TrimPrefix(v.Type, "*")
+
+	if strings.HasPrefix(typ, "[]") && typ != "[]byte" && typ != "[]uint8" {
+		elem := &registry.Value{Name: v.Name, Type: strings.TrimPrefix(typ, "[]"), Values: v.Values}
+		return &Schema{Type: "array", Items: g.valueSchema(service, elem, false)}
+	}
+
+	if strings.HasPrefix(typ, "map[") {
+		if i := strings.Index(typ, "]"); i > 0 {
+			elem := &registry.Value{Name: v.Name, Type: typ[i+1:], Values: v.Values}
+			return &Schema{Type: "object", AdditionalProperties: g.valueSchema(service, elem, false)}
+		}
+	}
+
+	if s := scalar(typ); s != nil {
+		return s
+	}
+
+	// nested messages get their own component
+	if !top && len(typ) > 0 {
+		name := qualify(service, typ)
+		if _, ok := g.doc.Components.Schemas[name]; !ok {
+			g.doc.Components.Schemas[name] = &Schema{Type: "object"}
+			g.doc.Components.Schemas[name] = g.valueSchema(service, &registry.Value{Type: typ, Values: v.Values}, true)
+		}
+		return ref(name)
+	}
+
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for _, f := range v.Values {
+		if f == nil {
+			continue
+		}
+		s.Properties[f.Name] = g.valueSchema(service, f, false)
+	}
+
+	return s
+}
+
+// scalar maps go and proto scalar types to schemas
+func scalar(typ string) *Schema {
+	switch typ {
+	case "string":
+		return &Schema{Type: "string"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "int", "int32", "uint32", "sint32", "fixed32", "sfixed32", "int8", "int16", "uint8", "uint16":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "int64", "uint64", "uint", "sint64", "fixed64", "sfixed64":
+		// encoded as strings by jsonpb
+		return &Schema{Type: "string", Format: "int64"}
+	case "float32", "float":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64", "double":
+		return &Schema{Type: "number", Format: "double"}
+	case "[]byte", "[]uint8", "bytes":
+		return &Schema{Type: "string", Format: "byte"}
+	case "interface {}", "interface{}":
+		return &Schema{}
+	}
+	return nil
+}
+
+var errorSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"id":     {Type: "string"},
+		"code":   {Type: "integer", Format: "int32"},
+		"detail": {Type: "string"},
+		"status": {Type: "string"},
+	},
+}
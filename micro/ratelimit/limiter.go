@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// result of taking a token from a bucket
+type result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until a token is available
+	RetryAfter time.Duration
+}
+
+// limiter takes tokens from a bucket
+type limiter interface {
+	take(key string, q Quota) (*result, error)
+}
+
+type redisLimiter struct {
+	pool *redis.Pool
+}
+
+// tokenBucket refills the bucket based on the time elapsed since the
+// last request and takes a token if one is available. It returns
+// whether the token was taken, the tokens remaining, the time in
+// milliseconds until the bucket is full and until a token is available.
+var tokenBucket = redis.NewScript(1, `
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + (elapsed * rate / 1000))
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HMSET", key, "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", key, math.ceil(capacity / rate * 1000) + 1000)
+
+local reset = math.ceil((capacity - tokens) / rate * 1000)
+local retry = 0
+if tokens < 1 then
+	retry = math.ceil((1 - tokens) / rate * 1000)
+end
+
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+func (r *redisLimiter) take(key string, q Quota) (*result, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	// tokens per second
+	rate := float64(q.Limit) / q.Window.Seconds()
+	capacity := q.Limit + q.Burst
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	vals, err := redis.Int64s(tokenBucket.Do(conn, key, rate, capacity, now))
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{
+		Allowed:    vals[0] == 1,
+		Limit:      q.Limit,
+		Remaining:  vals[1],
+		Reset:      time.Duration(vals[2]) * time.Millisecond,
+		RetryAfter: time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// Quota is the number of requests allowed per window with
+// an additional burst allowance
+type Quota struct {
+	Limit  int64
+	Window time.Duration
+	Burst  int64
+}
+
+// Route is a quota applied to a path. Paths ending in / match by prefix.
+type Route struct {
+	Path  string
+	Quota Quota
+}
+
+type Options struct {
+	// Pool of redis connections holding the bucket state
+	Pool *redis.Pool
+	// Prefix for the bucket keys
+	Prefix string
+	// Header the api key is read from. The remote ip is used if not set.
+	Header string
+	// Authenticate reports whether the api key is valid. Only valid
+	// keys have their own bucket, requests are otherwise limited by
+	// remote ip so changing the key doesn't bypass the limit.
+	Authenticate func(key string) bool
+	// Quota applied per api key
+	Quota Quota
+	// Routes override the default quota for matching paths
+	Routes []Route
+	// DryRun logs requests which would be limited rather than rejecting them
+	DryRun bool
+}
+
+type Option func(o *Options)
+
+// Pool sets the redis connection pool
+func Pool(p *redis.Pool) Option {
+	return func(o *Options) {
+		o.Pool = p
+	}
+}
+
+// Prefix sets the key prefix
+func Prefix(p string) Option {
+	return func(o *Options) {
+		o.Prefix = p
+	}
+}
+
+// Header sets the header the api key is read from
+func Header(h string) Option {
+	return func(o *Options) {
+		o.Header = h
+	}
+}
+
+// Authenticate sets the func validating api keys
+func Authenticate(fn func(key string) bool) Option {
+	return func(o *Options) {
+		o.Authenticate = fn
+	}
+}
+
+// Keys authenticates a fixed set of api keys
+func Keys(keys ...string) Option {
+	return func(o *Options) {
+		o.Authenticate = keySet(keys)
+	}
+}
+
+func keySet(keys []string) func(string) bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return func(key string) bool {
+		return set[key]
+	}
+}
+
+// Limit sets the default quota per api key
+func Limit(limit int64, window time.Duration, burst int64) Option {
+	return func(o *Options) {
+		o.Quota = Quota{Limit: limit, Window: window, Burst: burst}
+	}
+}
+
+// WithRoute sets a quota for a path
+func WithRoute(path string, limit int64, window time.Duration, burst int64) Option {
+	return func(o *Options) {
+		o.Routes = append(o.Routes, Route{
+			Path:  path,
+			Quota: Quota{Limit: limit, Window: window, Burst: burst},
+		})
+	}
+}
+
+// DryRun enables log only mode
+func DryRun(b bool) Option {
+	return func(o *Options) {
+		o.DryRun = b
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Prefix: "micro:gateway:ratelimit:",
+		Header: "X-Api-Key",
+		Quota: Quota{
+			Limit:  100,
+			Window: time.Minute,
+		},
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
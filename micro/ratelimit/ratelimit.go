@@ -0,0 +1,235 @@
+// Package ratelimit is a micro plugin for distributed rate limiting keyed by api key
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/micro/plugin"
+)
+
+type rateLimit struct {
+	opts Options
+
+	limiter limiter
+}
+
+func (r *rateLimit) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "ratelimit_redis",
+			Usage:  "Redis address for rate limit state e.g 127.0.0.1:6379",
+			EnvVar: "RATELIMIT_REDIS",
+		},
+		cli.StringFlag{
+			Name:   "ratelimit_header",
+			Usage:  "Header the api key is read from. Defaults to X-Api-Key",
+			EnvVar: "RATELIMIT_HEADER",
+		},
+		cli.StringSliceFlag{
+			Name:   "ratelimit_key",
+			Usage:  "Valid api key with its own quota. Other requests are limited by remote ip",
+			EnvVar: "RATELIMIT_KEY",
+		},
+		cli.StringFlag{
+			Name:   "ratelimit_quota",
+			Usage:  "Default quota per api key as limit/window[+burst] e.g 100/1m+20",
+			EnvVar: "RATELIMIT_QUOTA",
+		},
+		cli.StringSliceFlag{
+			Name:   "ratelimit_route",
+			Usage:  "Quota for a path as path=limit/window[+burst] e.g /foo/=10/1s",
+			EnvVar: "RATELIMIT_ROUTE",
+		},
+		cli.BoolFlag{
+			Name:   "ratelimit_dry_run",
+			Usage:  "Log requests which would be limited rather than rejecting them",
+			EnvVar: "RATELIMIT_DRY_RUN",
+		},
+	}
+}
+
+func (r *rateLimit) Commands() []cli.Command {
+	return nil
+}
+
+// parseQuota parses limit/window[+burst]
+func parseQuota(s string) (Quota, error) {
+	var q Quota
+
+	parts := strings.SplitN(s, "+", 2)
+	if len(parts) == 2 {
+		b, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid burst in quota %s", s)
+		}
+		q.Burst = b
+	}
+
+	lw := strings.SplitN(parts[0], "/", 2)
+	if len(lw) != 2 {
+		return q, fmt.Errorf("invalid quota %s", s)
+	}
+
+	l, err := strconv.ParseInt(lw[0], 10, 64)
+	if err != nil || l <= 0 {
+		return q, fmt.Errorf("invalid limit in quota %s", s)
+	}
+
+	w, err := time.ParseDuration(lw[1])
+	if err != nil || w <= 0 {
+		return q, fmt.Errorf("invalid window in quota %s", s)
+	}
+
+	q.Limit = l
+	q.Window = w
+
+	return q, nil
+}
+
+// key returns the authenticated api key or remote ip of the request
+func (r *rateLimit) key(req *http.Request) string {
+	if v := req.Header.Get(r.opts.Header); len(v) > 0 && r.opts.Authenticate != nil && r.opts.Authenticate(v) {
+		return "key:" + v
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// quota returns the quota and bucket suffix for the path
+func (r *rateLimit) quota(path string) (Quota, string) {
+	for _, route := range r.opts.Routes {
+		if strings.HasSuffix(route.Path, "/") && strings.HasPrefix(path, route.Path) {
+			return route.Quota, route.Path
+		}
+		if route.Path == path {
+			return route.Quota, route.Path
+		}
+	}
+	return r.opts.Quota, ""
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+func (r *rateLimit) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := r.key(req)
+			q, route := r.quota(req.URL.Path)
+
+			bucket := r.opts.Prefix + key
+			if len(route) > 0 {
+				bucket += ":" + route
+			}
+
+			res, err := r.limiter.take(bucket, q)
+			if err != nil {
+				// fail open rather than take the gateway down with redis
+				log.Logf("[ratelimit] failed to check rate limit: %v", err)
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			if !res.Allowed && r.opts.DryRun {
+				log.Logf("[ratelimit] dry run: %s would be limited on %s", key, req.URL.Path)
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			hd := w.Header()
+			hd.Set("RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+			hd.Set("RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+			hd.Set("RateLimit-Reset", seconds(res.Reset))
+
+			if !res.Allowed {
+				hd.Set("Retry-After", seconds(res.RetryAfter))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+func (r *rateLimit) Init(ctx *cli.Context) error {
+	if v := ctx.String("ratelimit_header"); len(v) > 0 {
+		r.opts.Header = v
+	}
+	if v := ctx.StringSlice("ratelimit_key"); len(v) > 0 {
+		r.opts.Authenticate = keySet(v)
+	}
+	if v := ctx.String("ratelimit_quota"); len(v) > 0 {
+		q, err := parseQuota(v)
+		if err != nil {
+			return err
+		}
+		r.opts.Quota = q
+	}
+	for _, v := range ctx.StringSlice("ratelimit_route") {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid route quota %s", v)
+		}
+		q, err := parseQuota(parts[1])
+		if err != nil {
+			return err
+		}
+		r.opts.Routes = append(r.opts.Routes, Route{Path: parts[0], Quota: q})
+	}
+	if ctx.Bool("ratelimit_dry_run") {
+		r.opts.DryRun = true
+	}
+	if v := ctx.String("ratelimit_redis"); len(v) > 0 {
+		r.opts.Pool = newPool(v)
+		r.limiter = &redisLimiter{r.opts.Pool}
+	}
+	return nil
+}
+
+func (r *rateLimit) String() string {
+	return "ratelimit"
+}
+
+func newPool(addr string) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     10,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr,
+				redis.DialConnectTimeout(time.Millisecond*100),
+				redis.DialReadTimeout(time.Millisecond*100),
+				redis.DialWriteTimeout(time.Millisecond*100),
+			)
+		},
+	}
+}
+
+// NewRateLimit returns a plugin which rate limits requests per api key
+func NewRateLimit(opts ...Option) plugin.Plugin {
+	options := newOptions(opts...)
+
+	if options.Pool == nil {
+		options.Pool = newPool("127.0.0.1:6379")
+	}
+
+	return &rateLimit{
+		opts:    options,
+		limiter: &redisLimiter{options.Pool},
+	}
+}
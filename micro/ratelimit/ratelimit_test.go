@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testLimiter struct {
+	keys   []string
+	tokens map[string]int64
+	err    error
+}
+
+func (t *testLimiter) take(key string, q Quota) (*result, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	t.keys = append(t.keys, key)
+
+	tokens, ok := t.tokens[key]
+	if !ok {
+		tokens = q.Limit + q.Burst
+	}
+
+	res := &result{
+		Limit: q.Limit,
+		Reset: q.Window,
+	}
+
+	if tokens > 0 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Second
+	}
+
+	t.tokens[key] = tokens
+	res.Remaining = tokens
+
+	return res, nil
+}
+
+func TestParseQuota(t *testing.T) {
+	q, err := parseQuota("100/1m+20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Limit != 100 || q.Window != time.Minute || q.Burst != 20 {
+		t.Fatalf("unexpected quota %+v", q)
+	}
+
+	for _, s := range []string{"100", "x/1m", "100/x", "100/1m+x", "0/1m"} {
+		if _, err := parseQuota(s); err == nil {
+			t.Fatalf("expected error parsing %s", s)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	l := &testLimiter{tokens: make(map[string]int64)}
+
+	p := NewRateLimit(
+		Limit(2, time.Minute, 1),
+		WithRoute("/slow/", 1, time.Minute, 0),
+		Keys("a", "b"),
+	).(*rateLimit)
+	p.limiter = l
+
+	h := p.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// limit plus burst
+	for i := 0; i < 3; i++ {
+		if w := do("/foo", "a"); w.Code != 200 {
+			t.Fatalf("expected request %d allowed got %d", i, w.Code)
+		}
+	}
+
+	w := do("/foo", "a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 got %d", w.Code)
+	}
+	if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+	if w.Header().Get("Retry-After") != "1" || w.Header().Get("RateLimit-Reset") != "60" {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+
+	// other keys have their own quota
+	if w := do("/foo", "b"); w.Code != 200 {
+		t.Fatalf("expected other key allowed got %d", w.Code)
+	}
+
+	// unknown keys share the remote ip's bucket
+	if w := do("/foo", "c"); w.Code != 200 {
+		t.Fatalf("expected unknown key allowed got %d", w.Code)
+	}
+	if l.keys[len(l.keys)-1] != "micro:gateway:ratelimit:ip:192.0.2.1" {
+		t.Fatalf("unexpected bucket %s", l.keys[len(l.keys)-1])
+	}
+
+	// route quotas
+	if w := do("/slow/bar", "b"); w.Code != 200 {
+		t.Fatalf("expected route request allowed got %d", w.Code)
+	}
+	if w := do("/slow/bar", "b"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected route request limited got %d", w.Code)
+	}
+	if l.keys[len(l.keys)-1] != "micro:gateway:ratelimit:key:b:/slow/" {
+		t.Fatalf("unexpected bucket %s", l.keys[len(l.keys)-1])
+	}
+
+	// dry run
+	p.opts.DryRun = true
+	if w := do("/foo", "a"); w.Code != 200 {
+		t.Fatalf("expected dry run to allow got %d", w.Code)
+	}
+
+	// fail open
+	l.err = errors.New("unavailable")
+	p.opts.DryRun = false
+	if w := do("/foo", "a"); w.Code != 200 {
+		t.Fatalf("expected fail open got %d", w.Code)
+	}
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/client"
+)
+
+// Route maps a path to a streaming endpoint
+type Route struct {
+	// Path the stream is served on e.g /events
+	Path string
+	// Service and Endpoint called e.g go.micro.srv.events Events.Stream
+	Service  string
+	Endpoint string
+	// Heartbeat overrides the default heartbeat interval
+	Heartbeat time.Duration
+	// Protocols allowed, "sse" and "websocket", both if empty
+	Protocols []string
+}
+
+type Options struct {
+	// Client used to call the streaming endpoints
+	Client client.Client
+	// Routes served
+	Routes []Route
+	// Heartbeat is the interval at which idle connections are pinged
+	Heartbeat time.Duration
+	// Retry is the reconnect delay sent to event source clients
+	Retry time.Duration
+}
+
+type Option func(o *Options)
+
+var (
+	// DefaultHeartbeat is the default heartbeat interval
+	DefaultHeartbeat = time.Second * 15
+	// DefaultRetry is the default reconnect delay for event source clients
+	DefaultRetry = time.Second * 3
+)
+
+// Client sets the client used to call services
+func Client(c client.Client) Option {
+	return func(o *Options) {
+		o.Client = c
+	}
+}
+
+// Routes adds routes to serve
+func Routes(routes ...Route) Option {
+	return func(o *Options) {
+		o.Routes = append(o.Routes, routes...)
+	}
+}
+
+// Heartbeat sets the default interval at which idle connections are pinged
+func Heartbeat(d time.Duration) Option {
+	return func(o *Options) {
+		o.Heartbeat = d
+	}
+}
+
+// Retry sets the reconnect delay sent to event source clients
+func Retry(d time.Duration) Option {
+	return func(o *Options) {
+		o.Retry = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Client:    client.DefaultClient,
+		Heartbeat: DefaultHeartbeat,
+		Retry:     DefaultRetry,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// writeEvent writes a server-sent event, splitting the data over
+// multiple data lines if it contains newlines
+func writeEvent(w io.Writer, id, name string, data []byte) {
+	var buf bytes.Buffer
+	if len(id) > 0 {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	if len(name) > 0 {
+		fmt.Fprintf(&buf, "event: %s\n", name)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	w.Write(buf.Bytes())
+}
+
+// serveSSE streams events to an event source. The stream ends with an
+// "end" event so clients can close rather than reconnect, or an "error"
+// event holding the error.
+func (s *streamPlugin) serveSSE(w http.ResponseWriter, r *http.Request, route Route) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", 500)
+		return
+	}
+
+	req, err := request(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(requestContext(r))
+	defer cancel()
+
+	stream, events, err := s.open(ctx, route, req)
+	if err != nil {
+		http.Error(w, err.Error(), status(err))
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disable proxy buffering e.g nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", s.opts.Retry/time.Millisecond)
+	flusher.Flush()
+
+	_, id := lastEventId(r)
+
+	ticker := time.NewTicker(s.heartbeat(route))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-events:
+			switch {
+			case e.err == io.EOF:
+				writeEvent(w, "", "end", []byte("{}"))
+				flusher.Flush()
+				return
+			case e.err != nil:
+				writeEvent(w, "", "error", []byte(e.err.Error()))
+				flusher.Flush()
+				return
+			}
+			id++
+			writeEvent(w, strconv.FormatInt(id, 10), "", e.data)
+			flusher.Flush()
+		case <-ticker.C:
+			// comments keep the connection open through proxies
+			io.WriteString(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
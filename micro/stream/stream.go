@@ -0,0 +1,266 @@
+// Package stream is a micro plugin which bridges streaming endpoints to
+// browsers over server-sent events and websockets. Each route maps a path
+// to an endpoint. Connections are kept alive with heartbeats and events
+// are numbered so clients may resume with the last event id.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/micro/plugin"
+)
+
+const (
+	// LastEventIdHeader is the metadata key holding the id of the last
+	// event received by a reconnecting client so services may resume
+	LastEventIdHeader = "Last-Event-Id"
+
+	// maxRequestSize limits the size of request bodies
+	maxRequestSize = 1024 * 1024
+)
+
+type streamPlugin struct {
+	opts Options
+
+	sync.RWMutex
+	routes map[string]Route
+}
+
+// event is a message received from the stream
+type event struct {
+	data json.RawMessage
+	err  error
+}
+
+func (s *streamPlugin) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:   "stream_route",
+			Usage:  "Route a path to a streaming endpoint e.g /events=go.micro.srv.events:Events.Stream",
+			EnvVar: "STREAM_ROUTE",
+		},
+		cli.IntFlag{
+			Name:   "stream_heartbeat",
+			Usage:  "Interval in seconds at which idle connections are pinged",
+			EnvVar: "STREAM_HEARTBEAT",
+		},
+	}
+}
+
+func (s *streamPlugin) Commands() []cli.Command {
+	return nil
+}
+
+// parseRoute parses a route flag of the form path=service:endpoint
+func parseRoute(v string) (Route, error) {
+	parts := strings.SplitN(v, "=", 2)
+	if len(parts) != 2 {
+		return Route{}, fmt.Errorf("invalid route %s", v)
+	}
+	target := strings.SplitN(parts[1], ":", 2)
+	if len(target) != 2 || len(target[0]) == 0 || len(target[1]) == 0 {
+		return Route{}, fmt.Errorf("invalid route %s", v)
+	}
+	return Route{
+		Path:     parts[0],
+		Service:  target[0],
+		Endpoint: target[1],
+	}, nil
+}
+
+func (s *streamPlugin) add(routes ...Route) {
+	s.Lock()
+	defer s.Unlock()
+	for _, r := range routes {
+		s.routes[r.Path] = r
+	}
+}
+
+// allowed returns true if the route may be served over the protocol
+func (r Route) allowed(protocol string) bool {
+	if len(r.Protocols) == 0 {
+		return true
+	}
+	for _, p := range r.Protocols {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// lastEventId returns the id of the last event the client received
+func lastEventId(r *http.Request) (string, int64) {
+	id := r.Header.Get("Last-Event-ID")
+	if len(id) == 0 {
+		id = r.URL.Query().Get("last_event_id")
+	}
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return id, n
+}
+
+// request returns the json request from the query parameters of a GET
+// or the body of a POST
+func request(r *http.Request) (json.RawMessage, error) {
+	if r.Method == "POST" {
+		b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > maxRequestSize {
+			return nil, errors.BadRequest("go.micro.api", "request too large")
+		}
+		if len(b) == 0 {
+			b = []byte(`{}`)
+		}
+		return json.RawMessage(b), nil
+	}
+
+	req := make(map[string]interface{})
+	for k, v := range r.URL.Query() {
+		if k == "last_event_id" || len(v) == 0 {
+			continue
+		}
+		if len(v) == 1 {
+			req[k] = v[0]
+		} else {
+			req[k] = v
+		}
+	}
+	return json.Marshal(req)
+}
+
+// requestContext forwards the request headers and last event id as metadata
+func requestContext(r *http.Request) context.Context {
+	md := make(metadata.Metadata, len(r.Header))
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			md[k] = v[0]
+		}
+	}
+	if id, _ := lastEventId(r); len(id) > 0 {
+		md[LastEventIdHeader] = id
+	}
+	return metadata.NewContext(r.Context(), md)
+}
+
+// open starts the stream, sending the request if any and
+// receiving events until the stream ends
+func (s *streamPlugin) open(ctx context.Context, route Route, msg json.RawMessage) (client.Stream, <-chan event, error) {
+	req := s.opts.Client.NewRequest(route.Service, route.Endpoint, &msg, client.WithContentType("application/json"))
+
+	stream, err := s.opts.Client.Stream(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if msg != nil {
+		if err := stream.Send(&msg); err != nil {
+			stream.Close()
+			return nil, nil, err
+		}
+	}
+
+	events := make(chan event)
+
+	go func() {
+		for {
+			var rsp json.RawMessage
+			err := stream.Recv(&rsp)
+
+			select {
+			case events <- event{rsp, err}:
+			case <-ctx.Done():
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return stream, events, nil
+}
+
+// heartbeat returns the heartbeat interval of the route
+func (s *streamPlugin) heartbeat(route Route) time.Duration {
+	if route.Heartbeat > 0 {
+		return route.Heartbeat
+	}
+	return s.opts.Heartbeat
+}
+
+// status returns the http status for an error
+func status(err error) int {
+	if merr := errors.Parse(err.Error()); merr.Code >= 400 {
+		return int(merr.Code)
+	}
+	return 500
+}
+
+func (s *streamPlugin) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.RLock()
+			route, ok := s.routes[r.URL.Path]
+			s.RUnlock()
+
+			if !ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			switch {
+			case strings.EqualFold(r.Header.Get("Upgrade"), "websocket") && route.allowed("websocket"):
+				s.serveWebSocket(w, r, route)
+			case route.allowed("sse"):
+				s.serveSSE(w, r, route)
+			default:
+				http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+			}
+		})
+	}
+}
+
+func (s *streamPlugin) Init(ctx *cli.Context) error {
+	for _, v := range ctx.StringSlice("stream_route") {
+		route, err := parseRoute(v)
+		if err != nil {
+			return err
+		}
+		s.add(route)
+	}
+	if i := ctx.Int("stream_heartbeat"); i > 0 {
+		s.opts.Heartbeat = time.Duration(i) * time.Second
+	}
+	return nil
+}
+
+func (s *streamPlugin) String() string {
+	return "stream"
+}
+
+// NewPlugin returns a plugin which serves streaming endpoints over
+// server-sent events and websockets
+func NewPlugin(opts ...Option) plugin.Plugin {
+	s := &streamPlugin{
+		opts:   newOptions(opts...),
+		routes: make(map[string]Route),
+	}
+	s.add(s.opts.Routes...)
+	return s
+}
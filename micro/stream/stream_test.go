@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+)
+
+type testStream struct {
+	client.Stream
+	sent chan string
+	recv []string
+}
+
+func (t *testStream) Send(msg interface{}) error {
+	t.sent <- string(*(msg.(*json.RawMessage)))
+	return nil
+}
+
+func (t *testStream) Recv(msg interface{}) error {
+	if len(t.recv) == 0 {
+		return io.EOF
+	}
+	*(msg.(*json.RawMessage)) = json.RawMessage(t.recv[0])
+	t.recv = t.recv[1:]
+	return nil
+}
+
+func (t *testStream) Close() error {
+	return nil
+}
+
+type testClient struct {
+	client.Client
+	stream *testStream
+	md     metadata.Metadata
+}
+
+func (t *testClient) NewRequest(service, method string, req interface{}, opts ...client.RequestOption) client.Request {
+	return nil
+}
+
+func (t *testClient) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	t.md, _ = metadata.FromContext(ctx)
+	return t.stream, nil
+}
+
+func newTestClient(events ...string) *testClient {
+	return &testClient{
+		stream: &testStream{
+			sent: make(chan string, 10),
+			recv: events,
+		},
+	}
+}
+
+func TestSSE(t *testing.T) {
+	c := newTestClient(`{"n":1}`, `{"n":2}`)
+
+	p := NewPlugin(Client(c), Routes(Route{
+		Path:     "/events",
+		Service:  "go.micro.srv.events",
+		Endpoint: "Events.Stream",
+	}))
+	h := p.Handler()(http.NotFoundHandler())
+
+	r := httptest.NewRequest("GET", "/events?topic=foo", nil)
+	r.Header.Set("Last-Event-ID", "5")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+	if req := <-c.stream.sent; req != `{"topic":"foo"}` {
+		t.Fatalf("unexpected request %s", req)
+	}
+	if c.md[LastEventIdHeader] != "5" {
+		t.Fatalf("expected last event id to be forwarded got %v", c.md)
+	}
+
+	expect := "retry: 3000\n\n" +
+		"id: 6\ndata: {\"n\":1}\n\n" +
+		"id: 7\ndata: {\"n\":2}\n\n" +
+		"event: end\ndata: {}\n\n"
+	if w.Body.String() != expect {
+		t.Fatalf("expected %q got %q", expect, w.Body.String())
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	c := newTestClient(`{"n":1}`)
+
+	p := NewPlugin(Client(c), Routes(Route{
+		Path:      "/events",
+		Service:   "go.micro.srv.events",
+		Endpoint:  "Events.Stream",
+		Protocols: []string{"websocket"},
+	}))
+	s := httptest.NewServer(p.Handler()(http.NotFoundHandler()))
+	defer s.Close()
+
+	// sse isn't allowed on the route
+	rsp, err := http.Get(s.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusUpgradeRequired {
+		t.Fatalf("expected %d got %d", http.StatusUpgradeRequired, rsp.StatusCode)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"topic":"foo"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case req := <-c.stream.sent:
+		if req != `{"topic":"foo"}` {
+			t.Fatalf("unexpected request %s", req)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected request to be sent")
+	}
+
+	var msg message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Id != "1" || string(msg.Data) != `{"n":1}` {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	// the stream ended so the socket is closed normally
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected normal closure got %v", err)
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	r, err := parseRoute("/events=go.micro.srv.events:Events.Stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Path != "/events" || r.Service != "go.micro.srv.events" || r.Endpoint != "Events.Stream" {
+		t.Fatalf("unexpected route %+v", r)
+	}
+	if _, err := parseRoute("/events=go.micro.srv.events"); err == nil {
+		t.Fatal("expected invalid route error")
+	}
+}
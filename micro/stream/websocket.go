@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/errors"
+)
+
+const (
+	readLimit     = 1024 * 64
+	writeDeadline = 10 * time.Second
+	// maxCloseReason is the longest close reason allowed in a control frame
+	maxCloseReason = 123
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// message is the envelope of events sent over websockets
+type message struct {
+	Id   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// serveWebSocket bridges a websocket to the stream. Each message from
+// the client is sent on the stream, the first being the request, and
+// events are sent back as messages with their id.
+func (s *streamPlugin) serveWebSocket(w http.ResponseWriter, r *http.Request, route Route) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has written the error
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(requestContext(r))
+	defer cancel()
+
+	heartbeat := s.heartbeat(route)
+
+	closeWith := func(code int, reason string) {
+		if len(reason) > maxCloseReason {
+			reason = reason[:maxCloseReason]
+		}
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeDeadline))
+	}
+
+	conn.SetReadLimit(readLimit)
+	conn.SetReadDeadline(time.Now().Add(heartbeat * 2))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(heartbeat * 2))
+		return nil
+	})
+
+	// the first message is the request
+	_, req, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+
+	stream, events, err := s.open(ctx, route, json.RawMessage(req))
+	if err != nil {
+		closeWith(websocket.CloseInternalServerErr, errors.Parse(err.Error()).Detail)
+		return
+	}
+	defer stream.Close()
+
+	// read further messages from the client onto the stream
+	go func() {
+		defer cancel()
+		for {
+			_, b, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := json.RawMessage(b)
+			if err := stream.Send(&msg); err != nil {
+				log.Logf("[stream] failed to send message to %s: %v", route.Service, err)
+				return
+			}
+		}
+	}()
+
+	_, id := lastEventId(r)
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-events:
+			switch {
+			case e.err == io.EOF:
+				closeWith(websocket.CloseNormalClosure, "")
+				return
+			case e.err != nil:
+				closeWith(websocket.CloseInternalServerErr, errors.Parse(e.err.Error()).Detail)
+				return
+			}
+			id++
+			conn.SetWriteDeadline(time.Now().Add(writeDeadline))
+			if err := conn.WriteJSON(message{strconv.FormatInt(id, 10), e.data}); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeDeadline)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package transform
+
+import (
+	"github.com/micro/go-config"
+)
+
+type Options struct {
+	Config config.Config
+}
+
+type Option func(o *Options)
+
+// Config sets the config rules are loaded from. Rules are read
+// from the path "transform" and reloaded on change.
+func Config(c config.Config) Option {
+	return func(o *Options) {
+		o.Config = c
+	}
+}
@@ -0,0 +1,215 @@
+package transform
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// Rules is the config expected to be loaded
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule describes the transformations applied to requests
+// and responses of a matching route
+type Rule struct {
+	Match    Match     `json:"match"`
+	Request  Transform `json:"request"`
+	Response Transform `json:"response"`
+}
+
+// Match describes the route a rule applies to. Empty fields match anything.
+type Match struct {
+	Method string `json:"method"`
+	Host   string `json:"host"`
+	// Path is matched exactly or as a prefix if it ends in /
+	Path string `json:"path"`
+}
+
+// Transform describes the transformations applied to a message
+type Transform struct {
+	Header Header `json:"header"`
+	Path   *Path  `json:"path"`
+	Body   *Body  `json:"body"`
+}
+
+// Header operations are applied in the order rename, remove, add
+type Header struct {
+	Add    map[string]string `json:"add"`
+	Remove []string          `json:"remove"`
+	Rename map[string]string `json:"rename"`
+}
+
+// Path is a regexp path rewrite e.g {"from": "^/v1/(.*)", "to": "/v2/$1"}.
+// It only applies to requests.
+type Path struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Body maps JSON fields of the output to JMESPath expressions
+// evaluated against the original JSON body
+type Body struct {
+	Mapping map[string]string `json:"mapping"`
+	// Merge the mapped fields into the original body
+	// rather than replacing it
+	Merge bool `json:"merge"`
+}
+
+// compiled is the parsed form of a rule
+type compiled struct {
+	rule     Rule
+	path     *regexp.Regexp
+	request  map[string]*jmespath.JMESPath
+	response map[string]*jmespath.JMESPath
+}
+
+func compileMapping(b *Body) (map[string]*jmespath.JMESPath, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	m := make(map[string]*jmespath.JMESPath)
+	for field, expr := range b.Mapping {
+		jp, err := jmespath.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		m[field] = jp
+	}
+
+	return m, nil
+}
+
+func compile(rules Rules) ([]*compiled, error) {
+	var cs []*compiled
+
+	for _, r := range rules.Rules {
+		c := &compiled{rule: r}
+
+		if r.Request.Path != nil {
+			re, err := regexp.Compile(r.Request.Path.From)
+			if err != nil {
+				return nil, err
+			}
+			c.path = re
+		}
+
+		var err error
+
+		if c.request, err = compileMapping(r.Request.Body); err != nil {
+			return nil, err
+		}
+		if c.response, err = compileMapping(r.Response.Body); err != nil {
+			return nil, err
+		}
+
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
+
+// match returns true if the rule applies to the request
+func (c *compiled) match(r *http.Request) bool {
+	m := c.rule.Match
+
+	if len(m.Method) > 0 && !strings.EqualFold(m.Method, r.Method) {
+		return false
+	}
+
+	if len(m.Host) > 0 && m.Host != r.Host {
+		return false
+	}
+
+	if len(m.Path) > 0 {
+		if strings.HasSuffix(m.Path, "/") {
+			return strings.HasPrefix(r.URL.Path, m.Path)
+		}
+		return m.Path == r.URL.Path
+	}
+
+	return true
+}
+
+// header applies the header operations
+func (h Header) apply(hd http.Header) {
+	for from, to := range h.Rename {
+		if v, ok := hd[http.CanonicalHeaderKey(from)]; ok {
+			hd.Del(from)
+			hd[http.CanonicalHeaderKey(to)] = v
+		}
+	}
+
+	for _, k := range h.Remove {
+		hd.Del(k)
+	}
+
+	for k, v := range h.Add {
+		hd.Set(k, v)
+	}
+}
+
+// deepCopy copies the maps and slices of a decoded JSON value
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = deepCopy(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = deepCopy(v)
+		}
+		return s
+	}
+	return v
+}
+
+// body applies the mapping to a JSON body. Search results are copied
+// as they may be values of the compiled expressions, e.g. literals, or
+// of the body which every expression must see unchanged.
+func body(b []byte, opts *Body, mapping map[string]*jmespath.JMESPath) ([]byte, error) {
+	if len(mapping) == 0 || len(b) == 0 {
+		return b, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{})
+
+	if opts.Merge {
+		if m, ok := deepCopy(data).(map[string]interface{}); ok {
+			out = m
+		}
+	}
+
+	for field, jp := range mapping {
+		v, err := jp.Search(data)
+		if err != nil {
+			return nil, err
+		}
+		out[field] = deepCopy(v)
+	}
+
+	return json.Marshal(out)
+}
+
+// isJSON checks the content type
+func isJSON(ct string) bool {
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.TrimSpace(strings.ToLower(ct))
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
@@ -0,0 +1,307 @@
+// Package transform is a micro plugin for transforming http requests and responses
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-config"
+	"github.com/micro/go-config/source"
+	"github.com/micro/go-config/source/file"
+	"github.com/micro/go-log"
+	"github.com/micro/micro/plugin"
+)
+
+type transform struct {
+	opts Options
+
+	sync.RWMutex
+	rules []*compiled
+}
+
+// response buffers the response so it can be transformed
+type response struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+var (
+	// Default config source file
+	DefaultFile = "transform.json"
+	DefaultPath = []string{"transform"}
+)
+
+func (r *response) Header() http.Header {
+	return r.header
+}
+
+func (r *response) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.buf.Write(b)
+}
+
+func (r *response) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (t *transform) update(rules Rules) error {
+	c, err := compile(rules)
+	if err != nil {
+		return err
+	}
+	t.Lock()
+	t.rules = c
+	t.Unlock()
+	return nil
+}
+
+func (t *transform) run(c config.Config) {
+	var rules Rules
+
+	// load rules immediately if possible
+	if err := c.Get(DefaultPath...).Scan(&rules); err != nil {
+		log.Logf("[transform] failed to get rules: %v", err)
+	} else if err := t.update(rules); err != nil {
+		log.Logf("[transform] failed to load rules: %v", err)
+	}
+
+	var watcher config.Watcher
+
+	// try to get a watch
+	for i := 0; i < 100; i++ {
+		w, err := c.Watch(DefaultPath...)
+		if err != nil {
+			log.Logf("[transform] failed to get watcher: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		watcher = w
+		break
+	}
+
+	// if the watch is nil we exit
+	if watcher == nil {
+		log.Fatalf("[transform] failed to get watcher in 100 attempts")
+	}
+
+	// watch and update rules
+	for {
+		v, err := watcher.Next()
+		if err != nil {
+			log.Logf("[transform] watcher error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var rules Rules
+
+		if err := v.Scan(&rules); err != nil {
+			log.Logf("[transform] failed to scan rules... skipping update: %v", err)
+			continue
+		}
+
+		if err := t.update(rules); err != nil {
+			log.Logf("[transform] failed to load rules... skipping update: %v", err)
+		}
+	}
+}
+
+func (t *transform) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "transform_config",
+			EnvVar: "TRANSFORM_CONFIG",
+			Usage:  "Source to read transform rules from e.g file:path/to/file",
+		},
+	}
+}
+
+func (t *transform) Commands() []cli.Command {
+	return nil
+}
+
+// match returns the first rule matching the request
+func (t *transform) match(r *http.Request) *compiled {
+	t.RLock()
+	defer t.RUnlock()
+
+	for _, c := range t.rules {
+		if c.match(r) {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// request applies the request transformations
+func (c *compiled) request(r *http.Request) error {
+	tr := c.rule.Request
+
+	tr.Header.apply(r.Header)
+
+	if c.path != nil {
+		r.URL.Path = c.path.ReplaceAllString(r.URL.Path, tr.Path.To)
+		r.URL.RawPath = ""
+		r.RequestURI = r.URL.RequestURI()
+	}
+
+	if len(c.request) == 0 || r.Body == nil || !isJSON(r.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	b, err = body(b, tr.Body, c.request)
+	if err != nil {
+		return err
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	r.ContentLength = int64(len(b))
+	r.Header.Set("Content-Length", strconv.Itoa(len(b)))
+
+	return nil
+}
+
+// response applies the response transformations and writes it
+func (c *compiled) response(w http.ResponseWriter, rsp *response) {
+	tr := c.rule.Response
+
+	tr.Header.apply(rsp.header)
+
+	b := rsp.buf.Bytes()
+
+	if len(c.response) > 0 && isJSON(rsp.header.Get("Content-Type")) && len(rsp.header.Get("Content-Encoding")) == 0 {
+		tb, err := body(b, tr.Body, c.response)
+		if err != nil {
+			// write the original response rather than fail it
+			log.Logf("[transform] failed to transform response: %v", err)
+		} else {
+			b = tb
+		}
+	}
+
+	for k, v := range rsp.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+
+	if rsp.status == 0 {
+		rsp.status = http.StatusOK
+	}
+
+	w.WriteHeader(rsp.status)
+	w.Write(b)
+}
+
+func (t *transform) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := t.match(r)
+
+			// no rule; serve as is
+			if c == nil {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if err := c.request(r); err != nil {
+				http.Error(w, "bad request", 400)
+				return
+			}
+
+			rt := c.rule.Response
+
+			// nothing to do for the response
+			if len(rt.Header.Add) == 0 && len(rt.Header.Remove) == 0 && len(rt.Header.Rename) == 0 && len(c.response) == 0 {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			rsp := &response{
+				header: make(http.Header),
+			}
+
+			h.ServeHTTP(rsp, r)
+
+			c.response(w, rsp)
+		})
+	}
+}
+
+func (t *transform) Init(ctx *cli.Context) error {
+	var conf config.Config
+
+	if c := ctx.String("transform_config"); len(c) == 0 && t.opts.Config == nil {
+		return errors.New("transform config source must be defined")
+	} else if len(c) > 0 {
+		var source source.Source
+
+		parts := strings.Split(c, ":")
+
+		switch parts[0] {
+		case "file":
+			fileName := DefaultFile
+
+			if len(parts) > 1 {
+				fileName = parts[1]
+			}
+
+			source = file.NewSource(file.WithPath(fileName))
+		default:
+			return errors.New("Unknown config source " + c)
+		}
+
+		conf = config.NewConfig(config.WithSource(source))
+	} else {
+		conf = t.opts.Config
+	}
+
+	go t.run(conf)
+
+	return nil
+}
+
+func (t *transform) String() string {
+	return "transform"
+}
+
+// NewTransform returns a plugin which applies transformation rules to matching routes
+func NewTransform(opts ...Option) plugin.Plugin {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	t := &transform{
+		opts: options,
+	}
+
+	if options.Config != nil {
+		var rules Rules
+		if err := options.Config.Get(DefaultPath...).Scan(&rules); err == nil {
+			if err := t.update(rules); err != nil {
+				log.Logf("[transform] failed to load rules: %v", err)
+			}
+		}
+	}
+
+	return t
+}
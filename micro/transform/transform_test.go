@@ -0,0 +1,166 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-config/source/memory"
+)
+
+func TestTransform(t *testing.T) {
+	rules := Rules{
+		Rules: []Rule{
+			{
+				Match: Match{
+					Method: "POST",
+					Path:   "/v1/",
+				},
+				Request: Transform{
+					Header: Header{
+						Add:    map[string]string{"X-Api-Version": "2"},
+						Remove: []string{"X-Internal"},
+						Rename: map[string]string{"X-User": "X-Micro-User"},
+					},
+					Path: &Path{
+						From: "^/v1/(.*)$",
+						To:   "/v2/$1",
+					},
+					Body: &Body{
+						Mapping: map[string]string{
+							"name": "user.full_name",
+						},
+					},
+				},
+				Response: Transform{
+					Header: Header{
+						Remove: []string{"Server"},
+					},
+					Body: &Body{
+						Mapping: map[string]string{
+							"greeting": "msg",
+						},
+						Merge: true,
+					},
+				},
+			},
+		},
+	}
+
+	b, err := json.Marshal(map[string]interface{}{"transform": rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.NewConfig(config.WithSource(memory.NewSource(memory.WithData(b))))
+
+	p := NewTransform(Config(c))
+
+	h := p.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/greeter/hello" {
+			t.Fatalf("expected rewritten path got %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Api-Version") != "2" {
+			t.Fatal("expected added header")
+		}
+		if len(r.Header.Get("X-Internal")) > 0 {
+			t.Fatal("expected removed header")
+		}
+		if r.Header.Get("X-Micro-User") != "bob" || len(r.Header.Get("X-User")) > 0 {
+			t.Fatal("expected renamed header")
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		var req map[string]interface{}
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Fatal(err)
+		}
+		if req["name"] != "Bob Smith" {
+			t.Fatalf("expected mapped request body got %s", string(b))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Server", "greeter")
+		w.WriteHeader(201)
+		w.Write([]byte(`{"msg":"hello Bob Smith"}`))
+	}))
+
+	r := httptest.NewRequest("POST", "/v1/greeter/hello", bytes.NewReader([]byte(`{"user":{"full_name":"Bob Smith"}}`)))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Internal", "secret")
+	r.Header.Set("X-User", "bob")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != 201 {
+		t.Fatalf("expected 201 got %d", w.Code)
+	}
+	if len(w.Header().Get("Server")) > 0 {
+		t.Fatal("expected server header removed")
+	}
+
+	var rsp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &rsp); err != nil {
+		t.Fatal(err)
+	}
+	if rsp["greeting"] != "hello Bob Smith" || rsp["msg"] != "hello Bob Smith" {
+		t.Fatalf("expected merged response body got %s", w.Body.String())
+	}
+
+	// unmatched routes pass through untouched
+	r = httptest.NewRequest("GET", "/v1/greeter/hello", nil)
+	w = httptest.NewRecorder()
+	p.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/greeter/hello" {
+			t.Fatalf("expected original path got %s", r.URL.Path)
+		}
+	})).ServeHTTP(w, r)
+}
+
+func TestCompileError(t *testing.T) {
+	_, err := compile(Rules{
+		Rules: []Rule{
+			{Request: Transform{Body: &Body{Mapping: map[string]string{"a": "[[["}}}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected invalid jmespath error")
+	}
+}
+
+func TestBodyMerge(t *testing.T) {
+	opts := &Body{
+		Mapping: map[string]string{
+			"name": "user.name",
+			"user": "`\"hidden\"`",
+		},
+		Merge: true,
+	}
+
+	mapping, err := compileMapping(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fields are mapped from the original body whatever the order
+	for i := 0; i < 20; i++ {
+		b, err := body([]byte(`{"user":{"name":"foo"}}`), opts, mapping)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out map[string]interface{}
+		if err := json.Unmarshal(b, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		if out["name"] != "foo" || out["user"] != "hidden" {
+			t.Fatalf("unexpected body %s", b)
+		}
+	}
+}
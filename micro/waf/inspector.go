@@ -0,0 +1,312 @@
+package waf
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Inspector inspects a request. The body is buffered so may be
+// read by all inspectors. A nil violation allows the request.
+type Inspector interface {
+	Inspect(r *http.Request, body []byte) *Violation
+	String() string
+}
+
+// BodyInspector is implemented by inspectors which only inspect the body
+// of some requests. Bodies are only read, and limited to the max body size,
+// when an inspector needs them. Inspectors which don't implement it are
+// assumed to inspect the body of every request.
+type BodyInspector interface {
+	InspectsBody(r *http.Request) bool
+}
+
+// Violation describes why a request was flagged
+type Violation struct {
+	// Rule which was violated
+	Rule string
+	// Reason for the violation
+	Reason string
+	// Status code returned when blocking
+	Status int
+}
+
+// InspectorFunc adapts a func to an Inspector
+type InspectorFunc func(r *http.Request, body []byte) *Violation
+
+func (f InspectorFunc) Inspect(r *http.Request, body []byte) *Violation {
+	return f(r, body)
+}
+
+func (f InspectorFunc) String() string {
+	return "func"
+}
+
+type contentTypes struct {
+	types map[string]bool
+}
+
+type signatures struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+type schema struct {
+	path   string
+	schema *gojsonschema.Schema
+}
+
+var (
+	sqliPatterns = []string{
+		`(?i)\bunion\b[\s\S]{0,50}\bselect\b`,
+		`(?i)\bselect\b[\s\S]{0,100}\bfrom\b[\s\S]{0,100}\bwhere\b`,
+		`(?i)\b(insert\s+into|delete\s+from|drop\s+table|truncate\s+table)\b`,
+		`(?i)'\s*(or|and)\s*'?\d+'?\s*=\s*'?\d+`,
+		`(?i)'\s*(or|and)\s+'[^']*'\s*=\s*'`,
+		`(?i)(;|')\s*--`,
+		`(?i)\b(sleep|benchmark|pg_sleep)\s*\(`,
+		`(?i)\bwaitfor\s+delay\b`,
+	}
+
+	xssPatterns = []string{
+		`(?i)<\s*script\b`,
+		`(?i)<\s*/\s*script\s*>`,
+		`(?i)\bjavascript\s*:`,
+		`(?i)\bon(error|load|click|mouseover|focus|submit)\s*=`,
+		`(?i)<\s*(iframe|object|embed|svg)\b`,
+		`(?i)\bdocument\.(cookie|location|write)\b`,
+	}
+)
+
+// ContentTypes only allows requests with a body to have one of the given content types
+func ContentTypes(types ...string) Inspector {
+	m := make(map[string]bool)
+	for _, t := range types {
+		m[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &contentTypes{m}
+}
+
+// hasBody returns true if the request has a body, whether or not it was read
+func hasBody(r *http.Request) bool {
+	if r.ContentLength > 0 {
+		return true
+	}
+	return r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody
+}
+
+func (c *contentTypes) Inspect(r *http.Request, body []byte) *Violation {
+	if len(body) == 0 && !hasBody(r) {
+		return nil
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.ToLower(strings.TrimSpace(ct))
+
+	if c.types[ct] {
+		return nil
+	}
+
+	return &Violation{
+		Rule:   c.String(),
+		Reason: "unsupported content type " + ct,
+		Status: http.StatusUnsupportedMediaType,
+	}
+}
+
+func (c *contentTypes) InspectsBody(r *http.Request) bool {
+	return false
+}
+
+func (c *contentTypes) String() string {
+	return "content_type"
+}
+
+func compile(name string, patterns []string) Inspector {
+	s := &signatures{name: name}
+	for _, p := range patterns {
+		s.patterns = append(s.patterns, regexp.MustCompile(p))
+	}
+	return s
+}
+
+// SQLi matches basic sql injection signatures in the path, query, headers and body
+func SQLi() Inspector {
+	return compile("sqli", sqliPatterns)
+}
+
+// XSS matches basic cross site scripting signatures in the path, query, headers and body
+func XSS() Inspector {
+	return compile("xss", xssPatterns)
+}
+
+// Signatures matches the given regexps in the path, query, headers and body
+func Signatures(name string, patterns ...string) Inspector {
+	return compile(name, patterns)
+}
+
+func (s *signatures) match(v string) bool {
+	for _, p := range s.patterns {
+		if p.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the name of the first header with a matching value
+func (s *signatures) header(h http.Header) (string, bool) {
+	for k, vs := range h {
+		for _, v := range vs {
+			if s.match(v) {
+				return k, true
+			}
+			// decode so encoded payloads are matched
+			if dv, err := url.QueryUnescape(v); err == nil && dv != v && s.match(dv) {
+				return k, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchBody matches the body, decoding form values first
+func (s *signatures) matchBody(r *http.Request, body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+	if s.match(string(body)) {
+		return true
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	if !strings.EqualFold(strings.TrimSpace(ct), "application/x-www-form-urlencoded") {
+		return false
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		// match what can be decoded
+		dv, err := url.QueryUnescape(string(body))
+		return err == nil && s.match(dv)
+	}
+	for k, vs := range values {
+		if s.match(k) {
+			return true
+		}
+		for _, v := range vs {
+			if s.match(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *signatures) Inspect(r *http.Request, body []byte) *Violation {
+	var where string
+
+	// decode so encoded payloads are matched
+	path, err := url.PathUnescape(r.URL.Path)
+	if err != nil {
+		path = r.URL.Path
+	}
+
+	query, err := url.QueryUnescape(r.URL.RawQuery)
+	if err != nil {
+		query = r.URL.RawQuery
+	}
+
+	if s.match(path) {
+		where = "path"
+	} else if s.match(query) {
+		where = "query"
+	} else if h, ok := s.header(r.Header); ok {
+		where = "header " + h
+	} else if s.matchBody(r, body) {
+		where = "body"
+	} else {
+		return nil
+	}
+
+	return &Violation{
+		Rule:   s.String(),
+		Reason: s.name + " signature matched in " + where,
+		Status: http.StatusForbidden,
+	}
+}
+
+func (s *signatures) InspectsBody(r *http.Request) bool {
+	return true
+}
+
+func (s *signatures) String() string {
+	return s.name
+}
+
+// Schema validates JSON bodies of requests to the path against a JSON schema.
+// Paths ending in / match by prefix.
+func Schema(path, jsonSchema string) (Inspector, error) {
+	sch, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(jsonSchema))
+	if err != nil {
+		return nil, err
+	}
+	return &schema{path: path, schema: sch}, nil
+}
+
+// InspectsBody returns true for requests with a body to the path
+func (s *schema) InspectsBody(r *http.Request) bool {
+	if strings.HasSuffix(s.path, "/") {
+		if !strings.HasPrefix(r.URL.Path, s.path) {
+			return false
+		}
+	} else if r.URL.Path != s.path {
+		return false
+	}
+
+	// only bodies are validated
+	return r.Method != "GET" && r.Method != "HEAD" && r.Method != "DELETE"
+}
+
+func (s *schema) Inspect(r *http.Request, body []byte) *Violation {
+	if !s.InspectsBody(r) {
+		return nil
+	}
+
+	res, err := s.schema.Validate(gojsonschema.NewBytesLoader(body))
+	if err != nil {
+		return &Violation{
+			Rule:   s.String(),
+			Reason: "invalid json: " + err.Error(),
+			Status: http.StatusBadRequest,
+		}
+	}
+
+	if res.Valid() {
+		return nil
+	}
+
+	var reasons []string
+	for _, e := range res.Errors() {
+		reasons = append(reasons, e.String())
+	}
+
+	return &Violation{
+		Rule:   s.String(),
+		Reason: strings.Join(reasons, "; "),
+		Status: http.StatusBadRequest,
+	}
+}
+
+func (s *schema) String() string {
+	return "schema"
+}
@@ -0,0 +1,56 @@
+package waf
+
+// Mode determines what happens to requests with violations
+type Mode int
+
+const (
+	// Block rejects requests with violations
+	Block Mode = iota
+	// Log only logs violations
+	Log
+)
+
+type Options struct {
+	Mode Mode
+	// Inspectors run in order for every request
+	Inspectors []Inspector
+	// MaxBodySize is the most bytes of a body buffered for inspection.
+	// Larger bodies are rejected when an inspector needs the body.
+	MaxBodySize int64
+}
+
+type Option func(o *Options)
+
+// WithMode sets block or log mode
+func WithMode(m Mode) Option {
+	return func(o *Options) {
+		o.Mode = m
+	}
+}
+
+// WithInspector adds inspectors
+func WithInspector(i ...Inspector) Option {
+	return func(o *Options) {
+		o.Inspectors = append(o.Inspectors, i...)
+	}
+}
+
+// MaxBodySize sets the max request body size
+func MaxBodySize(n int64) Option {
+	return func(o *Options) {
+		o.MaxBodySize = n
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Mode:        Block,
+		MaxBodySize: 1 << 20,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
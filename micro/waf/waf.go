@@ -0,0 +1,213 @@
+// Package waf is a micro plugin for inspecting requests at the gateway
+package waf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/micro/cli"
+	"github.com/micro/go-log"
+	"github.com/micro/micro/plugin"
+)
+
+type waf struct {
+	opts Options
+}
+
+func (w *waf) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "waf_mode",
+			Usage:  "block or log requests with violations. Defaults to block",
+			EnvVar: "WAF_MODE",
+		},
+		cli.Int64Flag{
+			Name:   "waf_max_body_size",
+			Usage:  "Max request body size in bytes",
+			EnvVar: "WAF_MAX_BODY_SIZE",
+		},
+		cli.StringFlag{
+			Name:   "waf_content_types",
+			Usage:  "Comma separated list of allowed request content types",
+			EnvVar: "WAF_CONTENT_TYPES",
+		},
+		cli.StringFlag{
+			Name:   "waf_signatures",
+			Usage:  "Comma separated list of signature sets to match; sqli, xss",
+			EnvVar: "WAF_SIGNATURES",
+		},
+		cli.StringSliceFlag{
+			Name:   "waf_schema",
+			Usage:  "JSON schema file for a path e.g /foo/bar=schema.json",
+			EnvVar: "WAF_SCHEMA",
+		},
+	}
+}
+
+func (w *waf) Commands() []cli.Command {
+	return nil
+}
+
+// read buffers the body up to the max size. It returns
+// a violation if the body is larger.
+func (w *waf) read(r *http.Request) ([]byte, *Violation, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil, nil
+	}
+
+	if r.ContentLength > w.opts.MaxBodySize {
+		return nil, w.tooLarge(), nil
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, w.opts.MaxBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if int64(len(b)) > w.opts.MaxBodySize {
+		return nil, w.tooLarge(), nil
+	}
+
+	// restore the body for the next handler
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	return b, nil, nil
+}
+
+func (w *waf) tooLarge() *Violation {
+	return &Violation{
+		Rule:   "size",
+		Reason: fmt.Sprintf("body larger than %d bytes", w.opts.MaxBodySize),
+		Status: http.StatusRequestEntityTooLarge,
+	}
+}
+
+// inspectsBody returns true if any inspector needs the body of the request
+func (w *waf) inspectsBody(r *http.Request) bool {
+	for _, i := range w.opts.Inspectors {
+		bi, ok := i.(BodyInspector)
+		if !ok || bi.InspectsBody(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// inspect runs the inspectors returning the first violation. The body
+// is only read, and its size limited, if an inspector needs it.
+func (w *waf) inspect(r *http.Request) (*Violation, error) {
+	var body []byte
+
+	if w.inspectsBody(r) {
+		b, v, err := w.read(r)
+		if err != nil || v != nil {
+			return v, err
+		}
+		body = b
+	}
+
+	for _, i := range w.opts.Inspectors {
+		if v := i.Inspect(r, body); v != nil {
+			return v, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (w *waf) Handler() plugin.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			v, err := w.inspect(r)
+			if err != nil {
+				http.Error(rw, "bad request", 400)
+				return
+			}
+
+			if v == nil {
+				h.ServeHTTP(rw, r)
+				return
+			}
+
+			log.Logf("[waf] %s %s %s violated %s: %s", r.RemoteAddr, r.Method, r.URL.Path, v.Rule, v.Reason)
+
+			// the body may not have been restored if it was too large
+			if w.opts.Mode == Log && v.Status != http.StatusRequestEntityTooLarge {
+				h.ServeHTTP(rw, r)
+				return
+			}
+
+			status := v.Status
+			if status == 0 {
+				status = http.StatusForbidden
+			}
+
+			http.Error(rw, http.StatusText(status), status)
+		})
+	}
+}
+
+func (w *waf) Init(ctx *cli.Context) error {
+	switch ctx.String("waf_mode") {
+	case "", "block":
+	case "log":
+		w.opts.Mode = Log
+	default:
+		return fmt.Errorf("unknown waf mode %s", ctx.String("waf_mode"))
+	}
+
+	if n := ctx.Int64("waf_max_body_size"); n > 0 {
+		w.opts.MaxBodySize = n
+	}
+
+	if v := ctx.String("waf_content_types"); len(v) > 0 {
+		w.opts.Inspectors = append(w.opts.Inspectors, ContentTypes(strings.Split(v, ",")...))
+	}
+
+	if v := ctx.String("waf_signatures"); len(v) > 0 {
+		for _, s := range strings.Split(v, ",") {
+			switch strings.TrimSpace(s) {
+			case "sqli":
+				w.opts.Inspectors = append(w.opts.Inspectors, SQLi())
+			case "xss":
+				w.opts.Inspectors = append(w.opts.Inspectors, XSS())
+			default:
+				return fmt.Errorf("unknown signature set %s", s)
+			}
+		}
+	}
+
+	for _, v := range ctx.StringSlice("waf_schema") {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid schema %s", v)
+		}
+		b, err := ioutil.ReadFile(parts[1])
+		if err != nil {
+			return err
+		}
+		i, err := Schema(parts[0], string(b))
+		if err != nil {
+			return err
+		}
+		w.opts.Inspectors = append(w.opts.Inspectors, i)
+	}
+
+	return nil
+}
+
+func (w *waf) String() string {
+	return "waf"
+}
+
+// NewPlugin returns a plugin which inspects requests with the given inspectors
+func NewPlugin(opts ...Option) plugin.Plugin {
+	return &waf{
+		opts: newOptions(opts...),
+	}
+}
@@ -0,0 +1,166 @@
+package waf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSignatures(t *testing.T) {
+	testCases := []struct {
+		inspector Inspector
+		query     string
+		body      string
+		match     bool
+	}{
+		{SQLi(), "id=1", "", false},
+		{SQLi(), "id=1%27%20OR%20%271%27%3D%271", "", true},
+		{SQLi(), "", `{"q": "1 UNION ALL SELECT password"}`, true},
+		{SQLi(), "", `{"q": "select a laptop from the shelf"}`, false},
+		{XSS(), "q=%3Cscript%3Ealert(1)%3C/script%3E", "", true},
+		{XSS(), "", `<img src=x onerror=alert(1)>`, true},
+		{XSS(), "q=hello", `{"name": "bob"}`, false},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("POST", "/foo?"+tc.query, nil)
+		v := tc.inspector.Inspect(r, []byte(tc.body))
+		if (v != nil) != tc.match {
+			t.Fatalf("%s: expected match %v for %q %q got %+v", tc.inspector, tc.match, tc.query, tc.body, v)
+		}
+	}
+}
+
+func TestSignaturesHeadersAndForms(t *testing.T) {
+	r := httptest.NewRequest("GET", "/foo", nil)
+	r.Header.Set("X-Forwarded-For", "1' OR '1'='1")
+	if v := SQLi().Inspect(r, nil); v == nil || v.Reason != "sqli signature matched in header X-Forwarded-For" {
+		t.Fatalf("expected a header match got %+v", v)
+	}
+
+	r = httptest.NewRequest("GET", "/foo", nil)
+	r.Header.Set("Referer", "http://example.com/?q=%3Cscript%3E")
+	if v := XSS().Inspect(r, nil); v == nil {
+		t.Fatal("expected an encoded header match")
+	}
+
+	// form values are decoded
+	body := []byte("q=1%27%20OR%20%271%27%3D%271&name=bob")
+	r = httptest.NewRequest("POST", "/foo", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if v := SQLi().Inspect(r, body); v == nil || v.Reason != "sqli signature matched in body" {
+		t.Fatalf("expected a form match got %+v", v)
+	}
+
+	body = []byte("q=%3Cscript%3Ealert(1)")
+	r = httptest.NewRequest("POST", "/foo", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if v := XSS().Inspect(r, body); v == nil {
+		t.Fatal("expected a form match")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s, err := Schema("/users/", `{
+		"type": "object",
+		"properties": {"name": {"type": "string"}},
+		"required": ["name"]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("POST", "/users/create", nil)
+
+	if v := s.Inspect(r, []byte(`{"name": "bob"}`)); v != nil {
+		t.Fatalf("unexpected violation %+v", v)
+	}
+	if v := s.Inspect(r, []byte(`{"age": 1}`)); v == nil || v.Status != 400 {
+		t.Fatalf("expected violation got %+v", v)
+	}
+	if v := s.Inspect(r, []byte(`{`)); v == nil {
+		t.Fatal("expected violation for invalid json")
+	}
+
+	// other paths are ignored
+	r = httptest.NewRequest("POST", "/other", nil)
+	if v := s.Inspect(r, []byte(`{}`)); v != nil {
+		t.Fatalf("unexpected violation %+v", v)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var body string
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	})
+
+	p := NewPlugin(
+		MaxBodySize(16),
+		WithInspector(ContentTypes("application/json"), SQLi()),
+	).(*waf)
+
+	h := p.Handler()(next)
+
+	do := func(ct, b string) int {
+		r := httptest.NewRequest("POST", "/foo", bytes.NewReader([]byte(b)))
+		r.Header.Set("Content-Type", ct)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := do("application/json", `{"a":1}`); code != 200 || body != `{"a":1}` {
+		t.Fatalf("expected allowed with body restored got %d %q", code, body)
+	}
+	if code := do("text/plain", `hello`); code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415 got %d", code)
+	}
+	if code := do("application/json", strings.Repeat("a", 17)); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 got %d", code)
+	}
+	if code := do("application/json", `"' or 1=1 --"`); code != http.StatusForbidden {
+		t.Fatalf("expected 403 got %d", code)
+	}
+
+	// log mode allows through
+	p.opts.Mode = Log
+	if code := do("application/json", `"' or 1=1 --"`); code != 200 {
+		t.Fatalf("expected log mode to allow got %d", code)
+	}
+}
+
+func TestHandlerBodySize(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// the size is only limited when the body is inspected
+	s, err := Schema("/users", `{"type": "object"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewPlugin(
+		MaxBodySize(16),
+		WithInspector(ContentTypes("application/json"), s),
+	).Handler()(next)
+
+	do := func(path string) int {
+		r := httptest.NewRequest("POST", path, strings.NewReader(`{"a":"`+strings.Repeat("a", 32)+`"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := do("/other"); code != 200 {
+		t.Fatalf("expected large body to pass without body inspectors got %d", code)
+	}
+	if code := do("/users"); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 got %d", code)
+	}
+}
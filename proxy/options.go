@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+// Resolver returns the service a request is routed to
+type Resolver func(r *http.Request) (string, error)
+
+// Options for the proxy
+type Options struct {
+	// Address the proxy listens on
+	Address string
+	// Registry used to create the default selector
+	Registry registry.Registry
+	// Selector chooses the node for a service
+	Selector selector.Selector
+	// SelectOptions e.g a strategy or filters applied when selecting a node
+	SelectOptions []selector.SelectOption
+	// Namespace of services routed by http path
+	Namespace string
+	// Resolver overrides how the service is resolved
+	Resolver Resolver
+	// Retries of requests failing to connect or returning a 502, 503 or 504
+	Retries int
+	// Timeout of a request including retries, no timeout if zero
+	Timeout time.Duration
+	// MaxRetryBody is the largest body buffered to retry a request
+	MaxRetryBody int64
+}
+
+type Option func(*Options)
+
+// Address sets the address the proxy listens on
+func Address(a string) Option {
+	return func(o *Options) {
+		o.Address = a
+	}
+}
+
+// Registry sets the registry services are resolved with
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// Selector sets the selector used to choose a node
+func Selector(s selector.Selector) Option {
+	return func(o *Options) {
+		o.Selector = s
+	}
+}
+
+// SelectOption adds options applied when selecting a node e.g a strategy
+func SelectOption(so ...selector.SelectOption) Option {
+	return func(o *Options) {
+		o.SelectOptions = append(o.SelectOptions, so...)
+	}
+}
+
+// Namespace sets the namespace of services routed by http path
+func Namespace(n string) Option {
+	return func(o *Options) {
+		o.Namespace = n
+	}
+}
+
+// WithResolver sets the function resolving the service of a request
+func WithResolver(r Resolver) Option {
+	return func(o *Options) {
+		o.Resolver = r
+	}
+}
+
+// Retries sets the number of times a failed request is retried
+func Retries(n int) Option {
+	return func(o *Options) {
+		o.Retries = n
+	}
+}
+
+// Timeout sets the timeout of a request including retries
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// MaxRetryBody sets the largest request body buffered so the request can be retried
+func MaxRetryBody(n int64) Option {
+	return func(o *Options) {
+		o.MaxRetryBody = n
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Address:      ":8081",
+		Namespace:    "go.micro.srv",
+		Retries:      1,
+		MaxRetryBody: 1024 * 1024,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Registry == nil {
+		options.Registry = registry.DefaultRegistry
+	}
+
+	if options.Selector == nil {
+		options.Selector = selector.NewSelector(selector.Registry(options.Registry))
+	}
+
+	return options
+}
@@ -0,0 +1,266 @@
+// Package proxy is a gRPC and http reverse proxy which routes requests to
+// services resolved from the registry. Nodes are chosen with the selector
+// and failed requests are retried against another node.
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Proxy is a http.Handler forwarding requests to services
+type Proxy struct {
+	opts Options
+
+	// transports for http and gRPC backends
+	http *http.Transport
+	h2c  *http2.Transport
+}
+
+// gRPC status codes
+const (
+	grpcNotFound    = 5
+	grpcUnavailable = 14
+)
+
+// hop-by-hop headers removed when forwarding
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+func address(node *registry.Node) string {
+	if node.Port > 0 {
+		return fmt.Sprintf("%s:%d", node.Address, node.Port)
+	}
+	return node.Address
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// error writes the error as a gRPC status or http error
+func (p *Proxy) error(w http.ResponseWriter, r *http.Request, status, code int, msg string) {
+	if isGRPC(r) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", strconv.Itoa(code))
+		w.Header().Set("Grpc-Message", msg)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Error(w, msg, status)
+}
+
+// outbound returns the request forwarded to the node
+func outbound(ctx context.Context, r *http.Request, addr string, body []byte) *http.Request {
+	out := r.WithContext(ctx)
+
+	u := *r.URL
+	u.Scheme = "http"
+	u.Host = addr
+	out.URL = &u
+	out.RequestURI = ""
+
+	out.Header = make(http.Header, len(r.Header))
+	for k, v := range r.Header {
+		out.Header[k] = append([]string(nil), v...)
+	}
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if prior := out.Header.Get("X-Forwarded-For"); len(prior) > 0 {
+			ip = prior + ", " + ip
+		}
+		out.Header.Set("X-Forwarded-For", ip)
+	}
+
+	if body != nil {
+		out.Body = ioutil.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	return out
+}
+
+// copy writes the response flushing as data arrives for streams
+func (p *Proxy) copy(w http.ResponseWriter, rsp *http.Response) {
+	defer rsp.Body.Close()
+
+	for k, v := range rsp.Header {
+		w.Header()[k] = v
+	}
+	for _, h := range hopHeaders {
+		w.Header().Del(h)
+	}
+	w.WriteHeader(rsp.StatusCode)
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := rsp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Logf("[proxy] error reading response: %v", err)
+			}
+			break
+		}
+	}
+
+	// trailers carry the gRPC status
+	for k, v := range rsp.Trailer {
+		w.Header()[http.TrailerPrefix+k] = v
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service, err := p.resolve(r)
+	if err != nil {
+		p.error(w, r, http.StatusNotFound, grpcNotFound, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if p.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+		defer cancel()
+	}
+
+	// buffer small bodies so the request can be retried
+	var body []byte
+	retries := p.opts.Retries
+	if r.Body != nil && r.ContentLength != 0 {
+		if retries > 0 && r.ContentLength > 0 && r.ContentLength <= p.opts.MaxRetryBody {
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				p.error(w, r, http.StatusBadRequest, grpcUnavailable, err.Error())
+				return
+			}
+			body = b
+		} else {
+			// streamed bodies can only be sent once
+			retries = 0
+		}
+	}
+
+	next, err := p.opts.Selector.Select(service, p.opts.SelectOptions...)
+	if err != nil {
+		if err == selector.ErrNotFound {
+			p.error(w, r, http.StatusNotFound, grpcNotFound, fmt.Sprintf("service %s not found", service))
+			return
+		}
+		p.error(w, r, http.StatusServiceUnavailable, grpcUnavailable, err.Error())
+		return
+	}
+
+	var rt http.RoundTripper = p.http
+	if isGRPC(r) {
+		rt = p.h2c
+	}
+
+	var lastErr error
+
+	for i := 0; i <= retries; i++ {
+		node, err := next()
+		if err != nil {
+			lastErr = err
+			break
+		}
+
+		rsp, err := rt.RoundTrip(outbound(ctx, r, address(node), body))
+		if err == nil && retryable(rsp.StatusCode) {
+			err = fmt.Errorf("%s returned %s", address(node), rsp.Status)
+			if i < retries {
+				rsp.Body.Close()
+			}
+		}
+
+		p.opts.Selector.Mark(service, node, err)
+
+		if err != nil && i < retries && ctx.Err() == nil {
+			log.Logf("[proxy] retrying %s %s: %v", service, r.URL.Path, err)
+			lastErr = err
+			continue
+		}
+
+		if rsp != nil {
+			p.copy(w, rsp)
+			return
+		}
+
+		lastErr = err
+		break
+	}
+
+	p.error(w, r, http.StatusBadGateway, grpcUnavailable, lastErr.Error())
+}
+
+// ListenAndServe serves the proxy on the address. gRPC is
+// accepted over cleartext HTTP/2 alongside HTTP/1.1.
+func (p *Proxy) ListenAndServe() error {
+	srv := &http.Server{
+		Addr:    p.opts.Address,
+		Handler: h2c.NewHandler(p, &http2.Server{}),
+	}
+
+	log.Logf("[proxy] listening on %s", p.opts.Address)
+
+	return srv.ListenAndServe()
+}
+
+// Options returns the proxy options
+func (p *Proxy) Options() Options {
+	return p.opts
+}
+
+// NewProxy returns a new proxy
+func NewProxy(opts ...Option) *Proxy {
+	return &Proxy{
+		opts: newOptions(opts...),
+		http: &http.Transport{
+			MaxIdleConnsPerHost: 64,
+			IdleConnTimeout:     time.Minute,
+		},
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+)
+
+func register(t *testing.T, r registry.Registry, name string, srvs ...*httptest.Server) {
+	var nodes []*registry.Node
+	for i, srv := range srvs {
+		host, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+		p, _ := strconv.Atoi(port)
+		nodes = append(nodes, &registry.Node{
+			Id:      name + "-" + strconv.Itoa(i),
+			Address: host,
+			Port:    p,
+		})
+	}
+
+	if err := r.Register(&registry.Service{Name: name, Version: "latest", Nodes: nodes}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	r := mock.NewRegistry()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte(r.URL.Path + " " + string(b)))
+	}))
+	defer srv.Close()
+
+	register(t, r, "go.micro.srv.greeter", srv)
+
+	p := NewProxy(Registry(r))
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("POST", "/greeter/hello", strings.NewReader("world")))
+
+	if w.Code != 200 || w.Body.String() != "/greeter/hello world" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/missing/hello", nil))
+	if w.Code != 404 {
+		t.Fatalf("expected 404 got %d", w.Code)
+	}
+}
+
+func TestProxyRetry(t *testing.T) {
+	r := mock.NewRegistry()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	register(t, r, "go.micro.srv.foo", srv)
+
+	p := NewProxy(Registry(r), Retries(1))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("hello"))
+	req.Header.Set(ServiceHeader, "go.micro.srv.foo")
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if w.Code != 200 || w.Body.String() != "hello" {
+		t.Fatalf("expected retried request to succeed got %d %s", w.Code, w.Body.String())
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("expected 2 calls got %d", n)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	p := NewProxy(Registry(mock.NewRegistry()))
+
+	req := httptest.NewRequest("POST", "/go.micro.srv.greeter.Say/Hello", nil)
+	req.ProtoMajor = 2
+	req.Header.Set("Content-Type", "application/grpc+proto")
+
+	if s, err := p.resolve(req); err != nil || s != "go.micro.srv.greeter" {
+		t.Fatalf("unexpected service %s %v", s, err)
+	}
+
+	req = httptest.NewRequest("GET", "/greeter/hello", nil)
+	if s, err := p.resolve(req); err != nil || s != "go.micro.srv.greeter" {
+		t.Fatalf("unexpected service %s %v", s, err)
+	}
+
+	// the header can't name services outside the namespace
+	req.Header.Set(ServiceHeader, "go.micro.srv.users")
+	if s, err := p.resolve(req); err != nil || s != "go.micro.srv.users" {
+		t.Fatalf("unexpected service %s %v", s, err)
+	}
+
+	req.Header.Set(ServiceHeader, "go.micro.admin.users")
+	if _, err := p.resolve(req); err != errNamespace {
+		t.Fatalf("expected namespace error got %v", err)
+	}
+}
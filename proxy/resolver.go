@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ServiceHeader explicitly sets the service of a request
+	ServiceHeader = "X-Micro-Service"
+
+	errNoService = errors.New("unable to resolve service")
+	errNamespace = errors.New("service not in namespace")
+)
+
+func isGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// resolve returns the service of the request. The service header is used
+// if set. gRPC requests are routed by the package of the fully qualified
+// service e.g /go.micro.srv.greeter.Say/Hello routes to go.micro.srv.greeter
+// and http requests by the first path segment in the namespace e.g
+// /greeter/hello routes to go.micro.srv.greeter. The service header
+// can only name services in the namespace.
+func (p *Proxy) resolve(r *http.Request) (string, error) {
+	if s := r.Header.Get(ServiceHeader); len(s) > 0 {
+		if ns := p.opts.Namespace; len(ns) > 0 && !strings.HasPrefix(s, ns+".") {
+			return "", errNamespace
+		}
+		return s, nil
+	}
+
+	if p.opts.Resolver != nil {
+		return p.opts.Resolver(r)
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+
+	if isGRPC(r) {
+		parts := strings.SplitN(path, "/", 2)
+		i := strings.LastIndex(parts[0], ".")
+		if i <= 0 {
+			return "", errNoService
+		}
+		return parts[0][:i], nil
+	}
+
+	name := strings.SplitN(path, "/", 2)[0]
+	if len(name) == 0 {
+		return "", errNoService
+	}
+
+	if len(p.opts.Namespace) == 0 {
+		return name, nil
+	}
+	return p.opts.Namespace + "." + name, nil
+}
@@ -0,0 +1,290 @@
+// Package azure provides a registry backed by Azure Table storage. Nodes are
+// stored with an expiry from the register ttl and watchers poll for changes.
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+)
+
+var (
+	// DefaultTable is the table nodes are stored in
+	DefaultTable = "microregistry"
+	// DefaultTTL is the expiry of nodes registered without a ttl
+	DefaultTTL = time.Minute
+	// DefaultPollInterval is how often watchers poll for changes
+	DefaultPollInterval = time.Second * 10
+
+	// characters not allowed in row keys
+	keyReplacer = strings.NewReplacer("/", "_", "\\", "_", "#", "_", "?", "_")
+)
+
+type azureRegistry struct {
+	opts registry.Options
+
+	sync.RWMutex
+	store store
+}
+
+func init() {
+	cmd.DefaultRegistries["azure"] = NewRegistry
+}
+
+func escape(s string) string {
+	return strings.Replace(s, "'", "''", -1)
+}
+
+// key is the row key of the node
+func key(version, node string) string {
+	return keyReplacer.Replace(version + "-" + node)
+}
+
+func (a *azureRegistry) configure() error {
+	var client storage.Client
+	var err error
+
+	switch {
+	case a.opts.Context.Value(connectionStringKey{}) != nil:
+		client, err = storage.NewClientFromConnectionString(a.opts.Context.Value(connectionStringKey{}).(string))
+	case a.opts.Context.Value(accountKey{}) != nil:
+		acc := a.opts.Context.Value(accountKey{}).(account)
+		client, err = storage.NewBasicClient(acc.name, acc.key)
+	default:
+		return errors.New("azure storage account or connection string required")
+	}
+	if err != nil {
+		return err
+	}
+
+	table := DefaultTable
+	if t, ok := a.opts.Context.Value(tableKey{}).(string); ok && len(t) > 0 {
+		table = t
+	}
+
+	s, err := newTableStore(client, table)
+	if err != nil {
+		return err
+	}
+
+	a.Lock()
+	a.store = s
+	a.Unlock()
+
+	return nil
+}
+
+func (a *azureRegistry) getStore() (store, error) {
+	a.RLock()
+	s := a.store
+	a.RUnlock()
+
+	if s == nil {
+		return nil, errors.New("azure registry not configured")
+	}
+	return s, nil
+}
+
+func (a *azureRegistry) pollInterval() time.Duration {
+	if d, ok := a.opts.Context.Value(pollIntervalKey{}).(time.Duration); ok && d > 0 {
+		return d
+	}
+	return DefaultPollInterval
+}
+
+// nodes returns the unexpired nodes of the service, or all services if empty,
+// keyed by service and row key. Expired nodes are removed.
+func (a *azureRegistry) nodes(service string) (map[string]*registry.Service, error) {
+	s, err := a.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.Query(service)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	nodes := make(map[string]*registry.Service, len(entries))
+
+	for _, e := range entries {
+		if now.After(e.Expiry) {
+			if err := s.Delete(e.Service, e.Id); err != nil {
+				log.Logf("[azure] failed to delete expired node %s: %v", e.Id, err)
+			}
+			continue
+		}
+
+		var svc *registry.Service
+		if err := json.Unmarshal([]byte(e.Value), &svc); err != nil || svc == nil {
+			continue
+		}
+
+		nodes[e.Service+"/"+e.Id] = svc
+	}
+
+	return nodes, nil
+}
+
+func (a *azureRegistry) Init(opts ...registry.Option) error {
+	for _, o := range opts {
+		o(&a.opts)
+	}
+	return a.configure()
+}
+
+func (a *azureRegistry) Options() registry.Options {
+	return a.opts
+}
+
+func (a *azureRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	if len(s.Nodes) == 0 {
+		return errors.New("Require at least one node")
+	}
+
+	st, err := a.getStore()
+	if err != nil {
+		return err
+	}
+
+	var options registry.RegisterOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ttl := options.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	for _, node := range s.Nodes {
+		b, err := json.Marshal(&registry.Service{
+			Name:      s.Name,
+			Version:   s.Version,
+			Metadata:  s.Metadata,
+			Endpoints: s.Endpoints,
+			Nodes:     []*registry.Node{node},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := st.Put(&entry{
+			Service: s.Name,
+			Id:      key(s.Version, node.Id),
+			Expiry:  time.Now().Add(ttl),
+			Value:   string(b),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *azureRegistry) Deregister(s *registry.Service) error {
+	if len(s.Nodes) == 0 {
+		return errors.New("Require at least one node")
+	}
+
+	st, err := a.getStore()
+	if err != nil {
+		return err
+	}
+
+	for _, node := range s.Nodes {
+		if err := st.Delete(s.Name, key(s.Version, node.Id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *azureRegistry) GetService(name string) ([]*registry.Service, error) {
+	nodes, err := a.nodes(name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]*registry.Service)
+
+	for _, s := range nodes {
+		v, ok := versions[s.Version]
+		if !ok {
+			versions[s.Version] = s
+			continue
+		}
+		v.Nodes = append(v.Nodes, s.Nodes...)
+	}
+
+	if len(versions) == 0 {
+		return nil, registry.ErrNotFound
+	}
+
+	services := make([]*registry.Service, 0, len(versions))
+	for _, s := range versions {
+		services = append(services, s)
+	}
+
+	return services, nil
+}
+
+func (a *azureRegistry) ListServices() ([]*registry.Service, error) {
+	nodes, err := a.nodes("")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var services []*registry.Service
+
+	for _, s := range nodes {
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+		services = append(services, &registry.Service{Name: s.Name})
+	}
+
+	return services, nil
+}
+
+func (a *azureRegistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	return newWatcher(a, opts...)
+}
+
+func (a *azureRegistry) String() string {
+	return "azure"
+}
+
+// NewRegistry returns a registry backed by an Azure Storage table. Set the
+// account with the Account or ConnectionString options.
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	options := registry.Options{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	a := &azureRegistry{
+		opts: options,
+	}
+
+	if err := a.configure(); err != nil {
+		log.Logf("[azure] %v", err)
+	}
+
+	return a
+}
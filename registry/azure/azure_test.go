@@ -0,0 +1,157 @@
+package azure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type memoryStore struct {
+	sync.Mutex
+	entries map[string]*entry
+}
+
+func (m *memoryStore) Put(e *entry) error {
+	m.Lock()
+	defer m.Unlock()
+	m.entries[e.Service+"/"+e.Id] = e
+	return nil
+}
+
+func (m *memoryStore) Delete(service, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.entries, service+"/"+id)
+	return nil
+}
+
+func (m *memoryStore) Query(service string) ([]*entry, error) {
+	m.Lock()
+	defer m.Unlock()
+	var entries []*entry
+	for _, e := range m.entries {
+		if len(service) == 0 || e.Service == service {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func newTestRegistry() *azureRegistry {
+	return &azureRegistry{
+		opts: registry.Options{
+			Context: context.WithValue(context.Background(), pollIntervalKey{}, time.Millisecond*10),
+		},
+		store: &memoryStore{entries: make(map[string]*entry)},
+	}
+}
+
+func testService(version, id string) *registry.Service {
+	return &registry.Service{
+		Name:    "foo",
+		Version: version,
+		Nodes: []*registry.Node{
+			{Id: id, Address: "10.0.0.1", Port: 8080},
+		},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := newTestRegistry()
+
+	for _, s := range []*registry.Service{
+		testService("1.0.0", "foo-1"),
+		testService("1.0.0", "foo-2"),
+		testService("2.0.0", "foo-3"),
+	} {
+		if err := r.Register(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services, err := r.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 2 {
+		t.Fatalf("expected 2 versions got %d", len(services))
+	}
+
+	for _, s := range services {
+		if s.Version == "1.0.0" && len(s.Nodes) != 2 {
+			t.Fatalf("expected 2 nodes got %d", len(s.Nodes))
+		}
+	}
+
+	list, err := r.ListServices()
+	if err != nil || len(list) != 1 || list[0].Name != "foo" {
+		t.Fatalf("unexpected services %v %v", list, err)
+	}
+
+	if err := r.Deregister(testService("2.0.0", "foo-3")); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err = r.GetService("foo")
+	if err != nil || len(services) != 1 {
+		t.Fatalf("expected 1 version got %d %v", len(services), err)
+	}
+}
+
+func TestRegistryExpiry(t *testing.T) {
+	r := newTestRegistry()
+
+	if err := r.Register(testService("1.0.0", "foo-1"), registry.RegisterTTL(time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 5)
+
+	if _, err := r.GetService("foo"); err != registry.ErrNotFound {
+		t.Fatalf("expected %v got %v", registry.ErrNotFound, err)
+	}
+
+	if entries, _ := r.store.Query(""); len(entries) != 0 {
+		t.Fatalf("expected expired node to be removed got %d", len(entries))
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	r := newTestRegistry()
+
+	w, err := r.Watch(registry.WatchService("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	s := testService("1.0.0", "foo-1")
+
+	if err := r.Register(s); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Action != "create" || res.Service.Nodes[0].Id != "foo-1" {
+		t.Fatalf("unexpected result %s %+v", res.Action, res.Service)
+	}
+
+	if err := r.Deregister(s); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Action != "delete" {
+		t.Fatalf("expected delete got %s", res.Action)
+	}
+}
@@ -0,0 +1,57 @@
+package azure
+
+import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type accountKey struct{}
+type connectionStringKey struct{}
+type tableKey struct{}
+type pollIntervalKey struct{}
+
+type account struct {
+	name, key string
+}
+
+// Account sets the storage account name and key
+func Account(name, key string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, accountKey{}, account{name, key})
+	}
+}
+
+// ConnectionString sets the storage account connection string
+func ConnectionString(cs string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, connectionStringKey{}, cs)
+	}
+}
+
+// Table sets the name of the table nodes are stored in
+func Table(name string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, tableKey{}, name)
+	}
+}
+
+// PollInterval sets how often watchers poll the table for changes
+func PollInterval(d time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, pollIntervalKey{}, d)
+	}
+}
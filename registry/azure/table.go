@@ -0,0 +1,106 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// entry is a node of a service stored with its expiry
+type entry struct {
+	Service string
+	Id      string
+	Expiry  time.Time
+	// Value is the json encoded service with the single node
+	Value string
+}
+
+// store persists the registry entries
+type store interface {
+	Put(e *entry) error
+	Delete(service, id string) error
+	// Query returns the entries of the service or all entries if empty
+	Query(service string) ([]*entry, error)
+}
+
+// tableStore is a store backed by an Azure Storage table.
+// The service is the partition key and the node the row key.
+type tableStore struct {
+	table *storage.Table
+}
+
+const timeout = 30
+
+func newTableStore(client storage.Client, name string) (*tableStore, error) {
+	ts := client.GetTableService()
+	table := ts.GetTableReference(name)
+
+	if err := table.Create(timeout, storage.NoMetadata, nil); err != nil {
+		if serr, ok := err.(storage.AzureStorageServiceError); !ok || serr.StatusCode != http.StatusConflict {
+			return nil, err
+		}
+	}
+
+	return &tableStore{table: table}, nil
+}
+
+func (t *tableStore) Put(e *entry) error {
+	ent := t.table.GetEntityReference(e.Service, e.Id)
+	ent.Properties = map[string]interface{}{
+		"Expiry": e.Expiry.UTC().Format(time.RFC3339Nano),
+		"Value":  e.Value,
+	}
+	return ent.InsertOrReplace(nil)
+}
+
+func (t *tableStore) Delete(service, id string) error {
+	ent := t.table.GetEntityReference(service, id)
+	err := ent.Delete(true, nil)
+	if serr, ok := err.(storage.AzureStorageServiceError); ok && serr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (t *tableStore) Query(service string) ([]*entry, error) {
+	opts := &storage.QueryOptions{}
+	if len(service) > 0 {
+		opts.Filter = fmt.Sprintf("PartitionKey eq '%s'", escape(service))
+	}
+
+	res, err := t.table.QueryEntities(timeout, storage.NoMetadata, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*entry
+
+	for {
+		for _, ent := range res.Entities {
+			e := &entry{
+				Service: ent.PartitionKey,
+				Id:      ent.RowKey,
+			}
+			if v, ok := ent.Properties["Value"].(string); ok {
+				e.Value = v
+			}
+			if v, ok := ent.Properties["Expiry"].(string); ok {
+				e.Expiry, _ = time.Parse(time.RFC3339Nano, v)
+			}
+			entries = append(entries, e)
+		}
+
+		if res.NextLink == nil {
+			break
+		}
+
+		res, err = res.NextResults(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
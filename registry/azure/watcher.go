@@ -0,0 +1,108 @@
+package azure
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type azureWatcher struct {
+	a       *azureRegistry
+	service string
+	exit    chan bool
+	results chan *registry.Result
+}
+
+func newWatcher(a *azureRegistry, opts ...registry.WatchOption) (registry.Watcher, error) {
+	var wo registry.WatchOptions
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	// take the initial snapshot so only changes are sent
+	nodes, err := a.nodes(wo.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &azureWatcher{
+		a:       a,
+		service: wo.Service,
+		exit:    make(chan bool),
+		results: make(chan *registry.Result),
+	}
+
+	go w.poll(nodes)
+
+	return w, nil
+}
+
+func (w *azureWatcher) send(action string, s *registry.Service) bool {
+	select {
+	case w.results <- &registry.Result{Action: action, Service: s}:
+		return true
+	case <-w.exit:
+		return false
+	}
+}
+
+func (w *azureWatcher) poll(last map[string]*registry.Service) {
+	t := time.NewTicker(w.a.pollInterval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-w.exit:
+			return
+		case <-t.C:
+		}
+
+		nodes, err := w.a.nodes(w.service)
+		if err != nil {
+			continue
+		}
+
+		for k, s := range nodes {
+			old, ok := last[k]
+			switch {
+			case !ok:
+				if !w.send("create", s) {
+					return
+				}
+			case !reflect.DeepEqual(old, s):
+				if !w.send("update", s) {
+					return
+				}
+			}
+		}
+
+		for k, s := range last {
+			if _, ok := nodes[k]; !ok {
+				if !w.send("delete", s) {
+					return
+				}
+			}
+		}
+
+		last = nodes
+	}
+}
+
+func (w *azureWatcher) Next() (*registry.Result, error) {
+	select {
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	case r := <-w.results:
+		return r, nil
+	}
+}
+
+func (w *azureWatcher) Stop() {
+	select {
+	case <-w.exit:
+	default:
+		close(w.exit)
+	}
+}
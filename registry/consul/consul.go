@@ -7,5 +7,14 @@ import (
 )
 
 func NewRegistry(opts ...registry.Option) registry.Registry {
+	var options registry.Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if p := getTokenProvider(options); p != nil {
+		opts = append(opts, withTokens(options, p)...)
+	}
+
 	return consul.NewRegistry(opts...)
 }
@@ -0,0 +1,107 @@
+package consul
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestTokenTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(tokenHeader)
+	}))
+	defer srv.Close()
+
+	var options registry.Options
+	ReadToken("read")(&options)
+	WriteToken("write")(&options)
+
+	c := &http.Client{
+		Transport: &tokenTransport{
+			rt:    http.DefaultTransport,
+			token: getTokenProvider(options),
+		},
+	}
+
+	testData := []struct {
+		method string
+		token  string
+	}{
+		{"GET", "read"},
+		{"PUT", "write"},
+		{"DELETE", "write"},
+	}
+
+	for _, d := range testData {
+		req, _ := http.NewRequest(d.method, srv.URL+"/v1/agent/service/register", nil)
+		rsp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsp.Body.Close()
+
+		if got != d.token {
+			t.Fatalf("%s: expected token %q, got %q", d.method, d.token, got)
+		}
+		if req.Header.Get(tokenHeader) != "" {
+			t.Fatalf("%s: request was modified", d.method)
+		}
+	}
+}
+
+func TestTokenProvider(t *testing.T) {
+	var options registry.Options
+	os.Unsetenv("CONSUL_HTTP_READ_TOKEN")
+	os.Unsetenv("CONSUL_HTTP_WRITE_TOKEN")
+
+	if p := getTokenProvider(options); p != nil {
+		t.Fatal("expected no provider without tokens")
+	}
+
+	Token("token")(&options)
+	WriteToken("write")(&options)
+
+	p := getTokenProvider(options)
+	if tk, _ := p(false); tk != "token" {
+		t.Fatalf("expected read to fall back to token, got %q", tk)
+	}
+	if tk, _ := p(true); tk != "write" {
+		t.Fatalf("expected write token, got %q", tk)
+	}
+}
+
+func TestFileToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "consul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := FileToken(path)
+	if tk, err := p(false); err != nil || tk != "first" {
+		t.Fatalf("expected first, got %q %v", tk, err)
+	}
+
+	// rotate the token
+	if err := ioutil.WriteFile(path, []byte("second"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+
+	if tk, err := p(true); err != nil || tk != "second" {
+		t.Fatalf("expected rotated token, got %q %v", tk, err)
+	}
+}
@@ -0,0 +1,97 @@
+package consul
+
+import (
+	"context"
+	"os"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type tokenKey struct{}
+
+type readTokenKey struct{}
+
+type writeTokenKey struct{}
+
+type tokenProviderKey struct{}
+
+// TokenProvider returns the ACL token for a request. Write is true for
+// registrations, deregistrations and health check updates. It's called
+// for every request so rotated tokens are used without a restart.
+type TokenProvider func(write bool) (string, error)
+
+func setOption(k, v interface{}) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// Token sets the ACL token used for all requests
+func Token(t string) registry.Option {
+	return setOption(tokenKey{}, t)
+}
+
+// ReadToken sets the ACL token used to read services and watch,
+// defaults to the CONSUL_HTTP_READ_TOKEN env var then the Token
+func ReadToken(t string) registry.Option {
+	return setOption(readTokenKey{}, t)
+}
+
+// WriteToken sets the ACL token used to register and deregister,
+// defaults to the CONSUL_HTTP_WRITE_TOKEN env var then the Token
+func WriteToken(t string) registry.Option {
+	return setOption(writeTokenKey{}, t)
+}
+
+// Tokens sets a provider returning the ACL token for each request,
+// taking precedence over the static tokens
+func Tokens(p TokenProvider) registry.Option {
+	return setOption(tokenProviderKey{}, p)
+}
+
+func getString(o registry.Options, k interface{}, env string) string {
+	if o.Context != nil {
+		if v, ok := o.Context.Value(k).(string); ok && len(v) > 0 {
+			return v
+		}
+	}
+	return os.Getenv(env)
+}
+
+// getTokenProvider returns the provider for the options or
+// nil when the tokens are left to the consul config
+func getTokenProvider(o registry.Options) TokenProvider {
+	if o.Context != nil {
+		if p, ok := o.Context.Value(tokenProviderKey{}).(TokenProvider); ok {
+			return p
+		}
+	}
+
+	token := getString(o, tokenKey{}, "CONSUL_HTTP_TOKEN")
+	read := getString(o, readTokenKey{}, "CONSUL_HTTP_READ_TOKEN")
+	write := getString(o, writeTokenKey{}, "CONSUL_HTTP_WRITE_TOKEN")
+
+	if len(read) == 0 && len(write) == 0 {
+		// a single token set in code still needs applying
+		if o.Context == nil || o.Context.Value(tokenKey{}) == nil {
+			return nil
+		}
+	}
+
+	if len(read) == 0 {
+		read = token
+	}
+	if len(write) == 0 {
+		write = token
+	}
+
+	return func(w bool) (string, error) {
+		if w {
+			return write, nil
+		}
+		return read, nil
+	}
+}
@@ -0,0 +1,129 @@
+package consul
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/consul"
+)
+
+// tokenHeader is the header consul reads the ACL token from
+const tokenHeader = "X-Consul-Token"
+
+// tokenTransport sets the read or write token on each request
+type tokenTransport struct {
+	rt    http.RoundTripper
+	token TokenProvider
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	write := r.Method != "GET" && r.Method != "HEAD"
+
+	token, err := t.token(write)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(token) > 0 {
+		// a round tripper mustn't modify the request
+		req := new(http.Request)
+		*req = *r
+		req.Header = make(http.Header, len(r.Header))
+		for k, v := range r.Header {
+			req.Header[k] = v
+		}
+		req.Header.Set(tokenHeader, token)
+		r = req
+	}
+
+	return t.rt.RoundTrip(r)
+}
+
+// FileToken returns a provider reading the token from a file, such as
+// one written by a vault agent. The file is read again when it changes.
+func FileToken(path string) TokenProvider {
+	var mtx sync.Mutex
+	var token string
+	var modified time.Time
+
+	return func(write bool) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		if fi.ModTime().Equal(modified) {
+			return token, nil
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		token = strings.TrimSpace(string(b))
+		modified = fi.ModTime()
+
+		return token, nil
+	}
+}
+
+// withTokens returns the options configuring the consul client
+// to set the provider's tokens on every request
+func withTokens(o registry.Options, p TokenProvider) []registry.Option {
+	config := api.DefaultConfig()
+	if o.Context != nil {
+		if c, ok := o.Context.Value("consul_config").(*api.Config); ok {
+			config = c
+		}
+	}
+
+	// the provider's tokens replace the config's
+	config.Token = ""
+
+	var rt http.RoundTripper = http.DefaultTransport
+	if config.HttpClient != nil && config.HttpClient.Transport != nil {
+		rt = config.HttpClient.Transport
+	}
+
+	var opts []registry.Option
+
+	// the registry replaces the transport of secure clients
+	// so set up tls here and leave it insecure
+	if o.Secure || o.TLSConfig != nil {
+		tlsConfig := o.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+		rt = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSHandshakeTimeout: 10 * time.Second,
+			TLSClientConfig:     tlsConfig,
+		}
+		config.Scheme = "https"
+		opts = append(opts, registry.Secure(false), registry.TLSConfig(nil))
+	}
+
+	client := new(http.Client)
+	if config.HttpClient != nil {
+		*client = *config.HttpClient
+	}
+	client.Transport = &tokenTransport{
+		rt:    rt,
+		token: p,
+	}
+	config.HttpClient = client
+
+	return append(opts, consul.Config(config))
+}
@@ -0,0 +1,30 @@
+// Package dedup provides a registry decorator which de-duplicates,
+// coalesces and orders watch results to stabilise downstream caches
+package dedup
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+type dedupRegistry struct {
+	registry.Registry
+	opts []Option
+}
+
+// Watch returns a de-duplicating watcher for the underlying registry
+func (d *dedupRegistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	w, err := d.Registry.Watch(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewWatcher(w, d.opts...), nil
+}
+
+// NewRegistry wraps the registry so its watchers de-duplicate
+// identical results, coalesce flaps and preserve ordering
+func NewRegistry(r registry.Registry, opts ...Option) registry.Registry {
+	return &dedupRegistry{
+		Registry: r,
+		opts:     opts,
+	}
+}
@@ -0,0 +1,97 @@
+package dedup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type testWatcher struct {
+	results chan *registry.Result
+}
+
+func (t *testWatcher) Next() (*registry.Result, error) {
+	r, ok := <-t.results
+	if !ok {
+		return nil, errors.New("closed")
+	}
+	return r, nil
+}
+
+func (t *testWatcher) Stop() {}
+
+func result(action, node string) *registry.Result {
+	return &registry.Result{
+		Action: action,
+		Service: &registry.Service{
+			Name:    "foo",
+			Version: "1",
+			Nodes:   []*registry.Node{{Id: node, Address: "10.0.0.1"}},
+		},
+	}
+}
+
+func collect(w registry.Watcher) []*registry.Result {
+	var results []*registry.Result
+	for {
+		r, err := w.Next()
+		if err != nil {
+			return results
+		}
+		results = append(results, r)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	testData := []struct {
+		name    string
+		window  time.Duration
+		results []*registry.Result
+		expect  []string
+	}{
+		{
+			name:    "duplicates",
+			results: []*registry.Result{result("create", "a"), result("update", "a"), result("update", "a")},
+			expect:  []string{"create:a"},
+		},
+		{
+			name:    "flap",
+			window:  time.Second,
+			results: []*registry.Result{result("create", "a"), result("delete", "a")},
+			expect:  nil,
+		},
+		{
+			name:    "coalesce",
+			window:  time.Second,
+			results: []*registry.Result{result("create", "a"), result("create", "b"), result("delete", "a"), result("create", "a")},
+			expect:  []string{"create:a", "create:b"},
+		},
+		{
+			name:    "ordering",
+			results: []*registry.Result{result("create", "a"), result("create", "b"), result("delete", "a"), result("delete", "b")},
+			expect:  []string{"create:a", "create:b", "delete:a", "delete:b"},
+		},
+	}
+
+	for _, d := range testData {
+		tw := &testWatcher{results: make(chan *registry.Result, len(d.results))}
+		for _, r := range d.results {
+			tw.results <- r
+		}
+		close(tw.results)
+
+		w := NewWatcher(tw, Window(d.window))
+		got := collect(w)
+
+		if len(got) != len(d.expect) {
+			t.Fatalf("%s: expected %d results got %d", d.name, len(d.expect), len(got))
+		}
+		for i, r := range got {
+			if s := r.Action + ":" + r.Service.Nodes[0].Id; s != d.expect[i] {
+				t.Fatalf("%s: expected %s got %s", d.name, d.expect[i], s)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package dedup
+
+import (
+	"time"
+)
+
+// Options for the de-duplicating watcher
+type Options struct {
+	// Window results for the same service nodes are
+	// held and coalesced within before delivery
+	Window time.Duration
+}
+
+// Option sets an option
+type Option func(*Options)
+
+var (
+	// DefaultWindow is the default coalescing window
+	DefaultWindow = time.Millisecond * 100
+)
+
+// Window sets how long results are held to coalesce create/delete flaps.
+// A zero window only de-duplicates identical consecutive results.
+func Window(d time.Duration) Option {
+	return func(o *Options) {
+		o.Window = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Window: DefaultWindow,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
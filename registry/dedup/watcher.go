@@ -0,0 +1,215 @@
+package dedup
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type watcher struct {
+	w    registry.Watcher
+	opts Options
+
+	next chan *registry.Result
+	exit chan bool
+	once sync.Once
+	// error returned by the underlying watcher
+	err error
+
+	// last delivered service per key for keys present downstream
+	state map[string]string
+}
+
+// entry is a result held for the coalescing window
+type entry struct {
+	key      string
+	result   *registry.Result
+	created  bool
+	deadline time.Time
+}
+
+// key identifies the service nodes a result applies to
+func key(r *registry.Result) string {
+	if r.Service == nil {
+		return ""
+	}
+	var nodes []string
+	for _, n := range r.Service.Nodes {
+		nodes = append(nodes, n.Id)
+	}
+	sort.Strings(nodes)
+	return r.Service.Name + "/" + r.Service.Version + "/" + strings.Join(nodes, ",")
+}
+
+// resolve compares the coalesced result against what was last delivered
+// and returns the result to send, or nil if it should be dropped.
+func (w *watcher) resolve(e *entry) *registry.Result {
+	r := e.result
+	prev, present := w.state[e.key]
+
+	if r.Action == "delete" {
+		// created and deleted within the window
+		if e.created && !present {
+			return nil
+		}
+		delete(w.state, e.key)
+		return r
+	}
+
+	b, err := json.Marshal(r.Service)
+	if err != nil {
+		return r
+	}
+	// identical to what was last delivered
+	if present && prev == string(b) {
+		return nil
+	}
+	w.state[e.key] = string(b)
+	return r
+}
+
+func (w *watcher) send(r *registry.Result) bool {
+	select {
+	case w.next <- r:
+		return true
+	case <-w.exit:
+		return false
+	}
+}
+
+func (w *watcher) run() {
+	results := make(chan *registry.Result)
+	errs := make(chan error, 1)
+
+	go func() {
+		for {
+			r, err := w.w.Next()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case results <- r:
+			case <-w.exit:
+				return
+			}
+		}
+	}()
+
+	// pending results in arrival order
+	var pending []*entry
+	index := make(map[string]*entry)
+
+	flush := func(now time.Time) bool {
+		for len(pending) > 0 && !pending[0].deadline.After(now) {
+			e := pending[0]
+			pending = pending[1:]
+			delete(index, e.key)
+
+			if r := w.resolve(e); r != nil && !w.send(r) {
+				return false
+			}
+		}
+		return true
+	}
+
+	timer := time.NewTimer(w.opts.Window)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		var tc <-chan time.Time
+		if len(pending) > 0 {
+			timer.Reset(time.Until(pending[0].deadline))
+			tc = timer.C
+		}
+
+		select {
+		case r := <-results:
+			k := key(r)
+			if w.opts.Window <= 0 {
+				// nothing to coalesce, only de-duplicate
+				if r := w.resolve(&entry{key: k, result: r}); r != nil && !w.send(r) {
+					return
+				}
+			} else if e, ok := index[k]; ok {
+				// coalesce into the pending result keeping its position
+				e.result = r
+			} else {
+				e := &entry{
+					key:      k,
+					result:   r,
+					created:  r.Action == "create",
+					deadline: time.Now().Add(w.opts.Window),
+				}
+				pending = append(pending, e)
+				index[k] = e
+			}
+		case <-tc:
+			if !flush(time.Now()) {
+				return
+			}
+		case err := <-errs:
+			// deliver what we have before surfacing the error
+			for _, e := range pending {
+				e.deadline = time.Time{}
+			}
+			if !flush(time.Now()) {
+				return
+			}
+			w.err = err
+			close(w.next)
+			return
+		case <-w.exit:
+			return
+		}
+
+		if tc != nil && !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+func (w *watcher) Next() (*registry.Result, error) {
+	select {
+	case r, ok := <-w.next:
+		if !ok {
+			return nil, w.err
+		}
+		return r, nil
+	case <-w.exit:
+		return nil, errors.New("watcher stopped")
+	}
+}
+
+func (w *watcher) Stop() {
+	w.once.Do(func() {
+		close(w.exit)
+		w.w.Stop()
+	})
+}
+
+// NewWatcher wraps a watcher so identical consecutive results are
+// dropped, create/delete flaps within the window are coalesced and
+// results are delivered in the order they were first seen.
+func NewWatcher(w registry.Watcher, opts ...Option) registry.Watcher {
+	dw := &watcher{
+		w:     w,
+		opts:  newOptions(opts...),
+		next:  make(chan *registry.Result),
+		exit:  make(chan bool),
+		state: make(map[string]string),
+	}
+	go dw.run()
+	return dw
+}
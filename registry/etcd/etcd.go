@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"path"
@@ -14,8 +15,10 @@ import (
 	"time"
 
 	etcd "github.com/coreos/etcd/client"
+	"github.com/micro/go-log"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/etcd/etcdopts"
 )
 
 var (
@@ -25,6 +28,8 @@ var (
 type etcdRegistry struct {
 	client  etcd.KeysAPI
 	options registry.Options
+	// err is a configuration error returned by every call
+	err error
 }
 
 func init() {
@@ -61,6 +66,10 @@ func (e *etcdRegistry) Deregister(s *registry.Service) error {
 		return errors.New("Require at least one node")
 	}
 
+	if e.err != nil {
+		return e.err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
 	defer cancel()
 
@@ -80,6 +89,10 @@ func (e *etcdRegistry) Register(s *registry.Service, opts ...registry.RegisterOp
 		return errors.New("Require at least one node")
 	}
 
+	if e.err != nil {
+		return e.err
+	}
+
 	var options registry.RegisterOptions
 	for _, o := range opts {
 		o(&options)
@@ -112,6 +125,10 @@ func (e *etcdRegistry) Register(s *registry.Service, opts ...registry.RegisterOp
 }
 
 func (e *etcdRegistry) GetService(name string) ([]*registry.Service, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
 	defer cancel()
 
@@ -158,6 +175,10 @@ func (e *etcdRegistry) GetService(name string) ([]*registry.Service, error) {
 func (e *etcdRegistry) ListServices() ([]*registry.Service, error) {
 	var services []*registry.Service
 
+	if e.err != nil {
+		return nil, e.err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
 	defer cancel()
 
@@ -183,6 +204,9 @@ func (e *etcdRegistry) ListServices() ([]*registry.Service, error) {
 }
 
 func (e *etcdRegistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
 	return newEtcdWatcher(e, opts...)
 }
 
@@ -190,6 +214,28 @@ func (e *etcdRegistry) String() string {
 	return "etcd"
 }
 
+// autoSync keeps the client's endpoints in sync with the cluster
+// members until exit is closed
+func autoSync(c etcd.Client, interval, timeout time.Duration, exit chan bool) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := c.Sync(ctx)
+		cancel()
+		if err != nil {
+			log.Logf("[etcd] failed to sync endpoints: %v", err)
+		}
+
+		select {
+		case <-exit:
+			return
+		case <-t.C:
+		}
+	}
+}
+
 func NewRegistry(opts ...registry.Option) registry.Registry {
 	config := etcd.Config{
 		Endpoints: []string{"http://127.0.0.1:2379"},
@@ -204,6 +250,23 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		options.Timeout = etcd.DefaultRequestTimeout
 	}
 
+	if a := etcdopts.GetAuth(options); a != nil {
+		config.Username = a.Username
+		config.Password = a.Password
+	}
+
+	if f := etcdopts.GetTLS(options); f != nil {
+		tlsConfig, err := etcdopts.NewTLSConfig(f)
+		if err != nil {
+			return &etcdRegistry{
+				options: options,
+				err:     fmt.Errorf("failed to load etcd tls config: %v", err),
+			}
+		}
+		options.TLSConfig = tlsConfig
+		options.Secure = true
+	}
+
 	if options.Secure || options.TLSConfig != nil {
 		tlsConfig := options.TLSConfig
 		if tlsConfig == nil {
@@ -258,12 +321,27 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		config.Endpoints = cAddrs
 	}
 
-	c, _ := etcd.New(config)
+	c, err := etcd.New(config)
+	if err != nil {
+		return &etcdRegistry{
+			options: options,
+			err:     err,
+		}
+	}
 
 	e := &etcdRegistry{
 		client:  etcd.NewKeysAPI(c),
 		options: options,
 	}
 
+	// stop syncing once the registry is no longer used
+	if interval := etcdopts.GetAutoSync(options); interval > 0 {
+		exit := make(chan bool)
+		go autoSync(c, interval, options.Timeout, exit)
+		runtime.SetFinalizer(e, func(*etcdRegistry) {
+			close(exit)
+		})
+	}
+
 	return e
 }
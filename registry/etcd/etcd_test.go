@@ -0,0 +1,24 @@
+package etcd
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestTLSError(t *testing.T) {
+	r := NewRegistry(TLS("missing.pem", "missing.pem", ""))
+
+	if _, err := r.GetService("foo"); err == nil {
+		t.Fatal("expected the tls config error")
+	}
+	if err := r.Register(&registry.Service{
+		Name:  "foo",
+		Nodes: []*registry.Node{{Id: "foo-1"}},
+	}); err == nil {
+		t.Fatal("expected the tls config error")
+	}
+	if _, err := r.Watch(); err == nil {
+		t.Fatal("expected the tls config error")
+	}
+}
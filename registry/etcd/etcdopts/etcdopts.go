@@ -0,0 +1,144 @@
+// Package etcdopts provides the auth, TLS and autosync options
+// shared by the etcd and etcdv3 registries
+package etcdopts
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type authKey struct{}
+
+type tlsKey struct{}
+
+type autoSyncKey struct{}
+
+// Credentials authenticate with etcd
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// TLSFiles are the PEM files of the client certificate and CA
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Auth sets the username and password used to authenticate with etcd,
+// defaults to the ETCD_USERNAME and ETCD_PASSWORD env vars
+func Auth(username, password string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, authKey{}, &Credentials{
+			Username: username,
+			Password: password,
+		})
+	}
+}
+
+// TLS sets the client certificate, key and CA files used to connect to
+// etcd, defaults to the ETCD_CERT_FILE, ETCD_KEY_FILE and ETCD_CA_FILE
+// env vars. The CA file is optional, the system roots are used without it.
+func TLS(certFile, keyFile, caFile string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, tlsKey{}, &TLSFiles{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+			CAFile:   caFile,
+		})
+	}
+}
+
+// AutoSync periodically syncs the endpoints from the cluster members
+// so the registry fails over to members not in the initial addresses
+func AutoSync(interval time.Duration) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, autoSyncKey{}, interval)
+	}
+}
+
+// GetAuth returns the credentials set by Auth or the env vars
+func GetAuth(o registry.Options) *Credentials {
+	if o.Context != nil {
+		if a, ok := o.Context.Value(authKey{}).(*Credentials); ok {
+			return a
+		}
+	}
+	if u := os.Getenv("ETCD_USERNAME"); len(u) > 0 {
+		return &Credentials{
+			Username: u,
+			Password: os.Getenv("ETCD_PASSWORD"),
+		}
+	}
+	return nil
+}
+
+// GetTLS returns the files set by TLS or the env vars
+func GetTLS(o registry.Options) *TLSFiles {
+	if o.Context != nil {
+		if t, ok := o.Context.Value(tlsKey{}).(*TLSFiles); ok {
+			return t
+		}
+	}
+	if c := os.Getenv("ETCD_CERT_FILE"); len(c) > 0 {
+		return &TLSFiles{
+			CertFile: c,
+			KeyFile:  os.Getenv("ETCD_KEY_FILE"),
+			CAFile:   os.Getenv("ETCD_CA_FILE"),
+		}
+	}
+	return nil
+}
+
+// GetAutoSync returns the interval set by AutoSync
+func GetAutoSync(o registry.Options) time.Duration {
+	if o.Context != nil {
+		if d, ok := o.Context.Value(autoSyncKey{}).(time.Duration); ok {
+			return d
+		}
+	}
+	return 0
+}
+
+// NewTLSConfig loads the client certificate and CA
+func NewTLSConfig(f *TLSFiles) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if len(f.CAFile) > 0 {
+		b, err := ioutil.ReadFile(f.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.New("no certificates found in " + f.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
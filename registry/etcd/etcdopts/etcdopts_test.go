@@ -0,0 +1,61 @@
+package etcdopts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestOptions(t *testing.T) {
+	var o registry.Options
+	Auth("user", "pass")(&o)
+	TLS("cert.pem", "key.pem", "ca.pem")(&o)
+	AutoSync(time.Minute)(&o)
+
+	if a := GetAuth(o); a == nil || a.Username != "user" || a.Password != "pass" {
+		t.Fatalf("unexpected auth %+v", a)
+	}
+	if f := GetTLS(o); f == nil || f.CertFile != "cert.pem" || f.KeyFile != "key.pem" || f.CAFile != "ca.pem" {
+		t.Fatalf("unexpected tls files %+v", f)
+	}
+	if d := GetAutoSync(o); d != time.Minute {
+		t.Fatalf("expected autosync 1m got %v", d)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	os.Setenv("ETCD_USERNAME", "env")
+	os.Setenv("ETCD_PASSWORD", "secret")
+	defer os.Unsetenv("ETCD_USERNAME")
+	defer os.Unsetenv("ETCD_PASSWORD")
+
+	if a := GetAuth(registry.Options{}); a == nil || a.Username != "env" || a.Password != "secret" {
+		t.Fatalf("unexpected auth %+v", a)
+	}
+	if f := GetTLS(registry.Options{}); f != nil {
+		t.Fatalf("expected no tls files got %+v", f)
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	if _, err := NewTLSConfig(&TLSFiles{CertFile: "missing.pem", KeyFile: "missing.pem"}); err == nil {
+		t.Fatal("expected error loading missing files")
+	}
+
+	dir, err := ioutil.TempDir("", "etcdopts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(ca, []byte("not a certificate"), 0600)
+
+	if _, err := NewTLSConfig(&TLSFiles{CAFile: ca}); err == nil {
+		t.Fatal("expected error loading invalid files")
+	}
+}
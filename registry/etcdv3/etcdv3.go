@@ -6,14 +6,17 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"path"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/micro/go-log"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/etcd/etcdopts"
 
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	hash "github.com/mitchellh/hashstructure"
@@ -24,9 +27,13 @@ var (
 )
 
 type etcdv3Registry struct {
-	client  *clientv3.Client
+	config  clientv3.Config
 	options registry.Options
 	sync.Mutex
+	// client is nil until connected
+	client *clientv3.Client
+	// err is a configuration error returned by every call
+	err      error
 	register map[string]uint64
 	leases   map[string]clientv3.LeaseID
 }
@@ -56,6 +63,27 @@ func servicePath(s string) string {
 	return path.Join(prefix, strings.Replace(s, "/", "-", -1))
 }
 
+// getClient returns the client, connecting if not yet connected
+// so the registry recovers once etcd is available
+func (e *etcdv3Registry) getClient() (*clientv3.Client, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	cli, err := clientv3.New(e.config)
+	if err != nil {
+		return nil, err
+	}
+	e.client = cli
+	return cli, nil
+}
+
 func (e *etcdv3Registry) Options() registry.Options {
 	return e.options
 }
@@ -65,6 +93,11 @@ func (e *etcdv3Registry) Deregister(s *registry.Service) error {
 		return errors.New("Require at least one node")
 	}
 
+	client, err := e.getClient()
+	if err != nil {
+		return err
+	}
+
 	e.Lock()
 	// delete our hash of the service
 	delete(e.register, s.Name)
@@ -76,7 +109,7 @@ func (e *etcdv3Registry) Deregister(s *registry.Service) error {
 	defer cancel()
 
 	for _, node := range s.Nodes {
-		_, err := e.client.Delete(ctx, nodePath(s.Name, node.Id))
+		_, err := client.Delete(ctx, nodePath(s.Name, node.Id))
 		if err != nil {
 			return err
 		}
@@ -89,11 +122,18 @@ func (e *etcdv3Registry) Register(s *registry.Service, opts ...registry.Register
 		return errors.New("Require at least one node")
 	}
 
+	client, err := e.getClient()
+	if err != nil {
+		return err
+	}
+
 	var leaseNotFound bool
 	//refreshing lease if existing
+	e.Lock()
 	leaseID, ok := e.leases[s.Name]
+	e.Unlock()
 	if ok {
-		if _, err := e.client.KeepAliveOnce(context.TODO(), leaseID); err != nil {
+		if _, err := client.KeepAliveOnce(context.TODO(), leaseID); err != nil {
 			if err != rpctypes.ErrLeaseNotFound {
 				return err
 			}
@@ -136,7 +176,7 @@ func (e *etcdv3Registry) Register(s *registry.Service, opts ...registry.Register
 
 	var lgr *clientv3.LeaseGrantResponse
 	if options.TTL.Seconds() > 0 {
-		lgr, err = e.client.Grant(ctx, int64(options.TTL.Seconds()))
+		lgr, err = client.Grant(ctx, int64(options.TTL.Seconds()))
 		if err != nil {
 			return err
 		}
@@ -145,9 +185,9 @@ func (e *etcdv3Registry) Register(s *registry.Service, opts ...registry.Register
 	for _, node := range s.Nodes {
 		service.Nodes = []*registry.Node{node}
 		if lgr != nil {
-			_, err = e.client.Put(ctx, nodePath(service.Name, node.Id), encode(service), clientv3.WithLease(lgr.ID))
+			_, err = client.Put(ctx, nodePath(service.Name, node.Id), encode(service), clientv3.WithLease(lgr.ID))
 		} else {
-			_, err = e.client.Put(ctx, nodePath(service.Name, node.Id), encode(service))
+			_, err = client.Put(ctx, nodePath(service.Name, node.Id), encode(service))
 		}
 		if err != nil {
 			return err
@@ -167,10 +207,15 @@ func (e *etcdv3Registry) Register(s *registry.Service, opts ...registry.Register
 }
 
 func (e *etcdv3Registry) GetService(name string) ([]*registry.Service, error) {
+	client, err := e.getClient()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
 	defer cancel()
 
-	rsp, err := e.client.Get(ctx, servicePath(name)+"/", clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend))
+	rsp, err := client.Get(ctx, servicePath(name)+"/", clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend))
 	if err != nil {
 		return nil, err
 	}
@@ -211,10 +256,15 @@ func (e *etcdv3Registry) ListServices() ([]*registry.Service, error) {
 	var services []*registry.Service
 	nameSet := make(map[string]struct{})
 
+	client, err := e.getClient()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), e.options.Timeout)
 	defer cancel()
 
-	rsp, err := e.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend))
+	rsp, err := client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend))
 	if err != nil {
 		return nil, err
 	}
@@ -259,6 +309,30 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		options.Timeout = 5 * time.Second
 	}
 
+	// fail over to another endpoint rather than waiting on a dead one
+	config.DialTimeout = options.Timeout
+	config.AutoSyncInterval = etcdopts.GetAutoSync(options)
+
+	if a := etcdopts.GetAuth(options); a != nil {
+		config.Username = a.Username
+		config.Password = a.Password
+	}
+
+	e := &etcdv3Registry{
+		register: make(map[string]uint64),
+		leases:   make(map[string]clientv3.LeaseID),
+	}
+
+	if f := etcdopts.GetTLS(options); f != nil {
+		tlsConfig, err := etcdopts.NewTLSConfig(f)
+		if err != nil {
+			e.options = options
+			e.err = fmt.Errorf("failed to load etcd tls config: %v", err)
+			return e
+		}
+		options.TLSConfig = tlsConfig
+	}
+
 	if options.Secure || options.TLSConfig != nil {
 		tlsConfig := options.TLSConfig
 		if tlsConfig == nil {
@@ -284,12 +358,12 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		config.Endpoints = cAddrs
 	}
 
-	cli, _ := clientv3.New(config)
-	e := &etcdv3Registry{
-		client:   cli,
-		options:  options,
-		register: make(map[string]uint64),
-		leases:   make(map[string]clientv3.LeaseID),
+	e.config = config
+	e.options = options
+
+	// connect now, failures are retried on use
+	if _, err := e.getClient(); err != nil {
+		log.Logf("[etcdv3] failed to connect: %v", err)
 	}
 
 	return e
@@ -0,0 +1,37 @@
+package etcdv3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestTLSError(t *testing.T) {
+	r := NewRegistry(TLS("missing.pem", "missing.pem", ""))
+
+	if _, err := r.GetService("foo"); err == nil {
+		t.Fatal("expected the tls config error")
+	}
+	if _, err := r.Watch(); err == nil {
+		t.Fatal("expected the tls config error")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	r := NewRegistry(
+		registry.Addrs("127.0.0.1:1"),
+		registry.Timeout(time.Millisecond*100),
+	)
+
+	// calls fail rather than panic while etcd is down
+	if _, err := r.ListServices(); err == nil {
+		t.Fatal("expected error without etcd")
+	}
+	if err := r.Deregister(&registry.Service{
+		Name:  "foo",
+		Nodes: []*registry.Node{{Id: "foo-1"}},
+	}); err == nil {
+		t.Fatal("expected error without etcd")
+	}
+}
@@ -0,0 +1,17 @@
+package etcdv3
+
+import (
+	"github.com/micro/go-plugins/registry/etcd/etcdopts"
+)
+
+var (
+	// Auth sets the username and password used to authenticate with etcd,
+	// defaults to the ETCD_USERNAME and ETCD_PASSWORD env vars
+	Auth = etcdopts.Auth
+	// TLS sets the client certificate, key and CA files used to connect
+	// to etcd, defaults to the ETCD_CERT_FILE, ETCD_KEY_FILE and
+	// ETCD_CA_FILE env vars
+	TLS = etcdopts.TLS
+	// AutoSync periodically syncs the endpoints from the cluster members
+	AutoSync = etcdopts.AutoSync
+)
@@ -22,6 +22,11 @@ func newEtcdv3Watcher(r *etcdv3Registry, timeout time.Duration, opts ...registry
 		o(&wo)
 	}
 
+	client, err := r.getClient()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	stop := make(chan bool, 1)
 
@@ -37,8 +42,8 @@ func newEtcdv3Watcher(r *etcdv3Registry, timeout time.Duration, opts ...registry
 
 	return &etcdv3Watcher{
 		stop:    stop,
-		w:       r.client.Watch(ctx, watchPath, clientv3.WithPrefix(), clientv3.WithPrevKV()),
-		client:  r.client,
+		w:       client.Watch(ctx, watchPath, clientv3.WithPrefix(), clientv3.WithPrevKV()),
+		client:  client,
 		timeout: timeout,
 	}, nil
 }
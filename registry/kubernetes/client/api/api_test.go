@@ -139,3 +139,81 @@ func TestRequest(t *testing.T) {
 		ts.Close()
 	}
 }
+
+func TestHooks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") != "trace" {
+			t.Error("expected hook to set the header")
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Error("expected the authorization to be sent")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	var req *RequestInfo
+	var rsp *ResponseInfo
+
+	hook := HookFuncs{
+		Request: func(r *RequestInfo) {
+			if len(r.Header.Get("Authorization")) > 0 {
+				t.Error("expected the authorization to be redacted")
+			}
+			r.Header.Set("Traceparent", "trace")
+			req = r
+		},
+		Response: func(r *RequestInfo, s *ResponseInfo) {
+			if r != req {
+				t.Error("expected the same request info")
+			}
+			rsp = s
+		},
+	}
+
+	token := "token"
+	res := NewRequest(&Options{
+		Host:        ts.URL,
+		Client:      &http.Client{},
+		Namespace:   "default",
+		BearerToken: &token,
+		Hooks:       []Hook{hook},
+	}).Get().Resource("pods").Name("foo").Do()
+
+	if res.Error() != ErrNotFound {
+		t.Fatalf("expected not found got %v", res.Error())
+	}
+	if req == nil || req.Verb != "GET" || req.Resource != "pods" || req.Name != "foo" || req.Namespace != "default" {
+		t.Fatalf("unexpected request info %+v", req)
+	}
+	if rsp == nil || rsp.StatusCode != http.StatusNotFound || rsp.Error != ErrNotFound || rsp.Latency <= 0 {
+		t.Fatalf("unexpected response info %+v", rsp)
+	}
+}
+
+func TestWatchHooks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var rsp *ResponseInfo
+
+	w, err := NewRequest(&Options{
+		Host:   ts.URL,
+		Client: &http.Client{},
+		Hooks: []Hook{HookFuncs{
+			Response: func(r *RequestInfo, s *ResponseInfo) {
+				rsp = s
+			},
+		}},
+	}).Get().Resource("pods").Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if rsp == nil || rsp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the watch status code got %+v", rsp)
+	}
+}
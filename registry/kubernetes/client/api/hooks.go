@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// Hook observes every request made to the api server so metrics or
+// trace spans can be recorded. The same RequestInfo is passed to both
+// calls so hooks can correlate them.
+type Hook interface {
+	OnRequest(r *RequestInfo)
+	OnResponse(r *RequestInfo, rsp *ResponseInfo)
+}
+
+// RequestInfo describes a request to the api server
+type RequestInfo struct {
+	Verb      string
+	Group     string
+	Resource  string
+	Namespace string
+	Name      string
+	Watch     bool
+	// Header of the request which hooks may set, e.g. to propagate a trace.
+	// The Authorization header is left out so it isn't exposed to hooks.
+	Header http.Header
+	// Start time of the request
+	Start time.Time
+}
+
+// ResponseInfo describes the response from the api server. For watches
+// it's the response establishing the watch.
+type ResponseInfo struct {
+	// StatusCode of the response, zero if no response was received
+	StatusCode int
+	Latency    time.Duration
+	Error      error
+}
+
+// HookFuncs adapts functions to a Hook, either may be nil
+type HookFuncs struct {
+	Request  func(r *RequestInfo)
+	Response func(r *RequestInfo, rsp *ResponseInfo)
+}
+
+// OnRequest ...
+func (h HookFuncs) OnRequest(r *RequestInfo) {
+	if h.Request != nil {
+		h.Request(r)
+	}
+}
+
+// OnResponse ...
+func (h HookFuncs) OnResponse(r *RequestInfo, rsp *ResponseInfo) {
+	if h.Response != nil {
+		h.Response(r, rsp)
+	}
+}
+
+var hooks = struct {
+	sync.RWMutex
+	list []Hook
+}{}
+
+// RegisterHook adds a hook called for the requests of every client in
+// the process, such as those used by the registry, config and leader
+// plugins
+func RegisterHook(h Hook) {
+	hooks.Lock()
+	hooks.list = append(hooks.list, h)
+	hooks.Unlock()
+}
+
+// requestHooks returns the global hooks followed by those of the client
+func requestHooks(opts []Hook) []Hook {
+	hooks.RLock()
+	defer hooks.RUnlock()
+
+	if len(hooks.list) == 0 {
+		return opts
+	}
+	list := make([]Hook, 0, len(hooks.list)+len(opts))
+	list = append(list, hooks.list...)
+	return append(list, opts...)
+}
+
+// redacted headers aren't passed to hooks
+var redacted = []string{"Authorization"}
+
+func isRedacted(key string) bool {
+	for _, k := range redacted {
+		if http.CanonicalHeaderKey(key) == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Request) info(watch bool) *RequestInfo {
+	header := make(http.Header, len(r.header))
+	for k, v := range r.header {
+		if !isRedacted(k) {
+			header[k] = v
+		}
+	}
+
+	info := &RequestInfo{
+		Verb:      r.method,
+		Group:     r.group,
+		Resource:  r.resource,
+		Namespace: r.namespace,
+		Watch:     watch,
+		Header:    header,
+		Start:     time.Now(),
+	}
+	if r.resourceName != nil {
+		info.Name = *r.resourceName
+	}
+	return info
+}
+
+// observe calls fn between the hooks with the request labelled
+// by verb and resource so it can be attributed in profiles
+func (r *Request) observe(watch bool, fn func() (int, error)) {
+	list := requestHooks(r.hooks)
+	info := r.info(watch)
+
+	for _, h := range list {
+		h.OnRequest(info)
+	}
+
+	// apply the headers set by hooks
+	for k, v := range info.Header {
+		if !isRedacted(k) {
+			r.header[k] = v
+		}
+	}
+
+	var code int
+	var err error
+
+	labels := pprof.Labels("k8s_verb", info.Verb, "k8s_resource", info.Resource)
+	pprof.Do(context.Background(), labels, func(context.Context) {
+		code, err = fn()
+	})
+
+	rsp := &ResponseInfo{
+		StatusCode: code,
+		Latency:    time.Since(info.Start),
+		Error:      err,
+	}
+
+	for _, h := range list {
+		h.OnResponse(info, rsp)
+	}
+}
@@ -19,10 +19,12 @@ type Request struct {
 	method    string
 	host      string
 	namespace string
+	group     string
 
 	resource     string
 	resourceName *string
 	body         io.Reader
+	hooks        []Hook
 
 	err error
 }
@@ -72,6 +74,13 @@ func (r *Request) Namespace(s string) *Request {
 	return r
 }
 
+// Group is the API group and version of the resource, such as
+// "coordination.k8s.io/v1". The core API is used when not set.
+func (r *Request) Group(s string) *Request {
+	r.group = s
+	return r
+}
+
 // Resource is the type of resource the operation is
 // for, such as "services", "endpoints" or "pods"
 func (r *Request) Resource(s string) *Request {
@@ -115,7 +124,12 @@ func (r *Request) SetHeader(key, value string) *Request {
 
 // request builds the http.Request from the options
 func (r *Request) request() (*http.Request, error) {
-	url := fmt.Sprintf("%s/api/v1/namespaces/%s/%s/", r.host, r.namespace, r.resource)
+	api := "api/v1"
+	if len(r.group) > 0 {
+		api = "apis/" + r.group
+	}
+
+	url := fmt.Sprintf("%s/%s/namespaces/%s/%s/", r.host, api, r.namespace, r.resource)
 
 	// append resourceName if it is present
 	if r.resourceName != nil {
@@ -146,22 +160,30 @@ func (r *Request) Do() *Response {
 		}
 	}
 
-	req, err := r.request()
-	if err != nil {
-		return &Response{
-			err: err,
+	var rsp *Response
+
+	r.observe(false, func() (int, error) {
+		req, err := r.request()
+		if err != nil {
+			rsp = &Response{
+				err: err,
+			}
+			return 0, err
 		}
-	}
 
-	res, err := r.client.Do(req)
-	if err != nil {
-		return &Response{
-			err: err,
+		res, err := r.client.Do(req)
+		if err != nil {
+			rsp = &Response{
+				err: err,
+			}
+			return 0, err
 		}
-	}
 
-	// return res, err
-	return newResponse(res, err)
+		rsp = newResponse(res, err)
+		return res.StatusCode, rsp.err
+	})
+
+	return rsp
 }
 
 // Watch builds and triggers the request, but
@@ -173,12 +195,28 @@ func (r *Request) Watch() (watch.Watch, error) {
 
 	r.params.Set("watch", "true")
 
-	req, err := r.request()
-	if err != nil {
-		return nil, err
-	}
+	var w watch.Watch
+	var err error
+
+	r.observe(true, func() (int, error) {
+		var req *http.Request
+		req, err = r.request()
+		if err != nil {
+			return 0, err
+		}
+
+		w, err = watch.NewBodyWatcher(req, r.client)
+		if err != nil {
+			return 0, err
+		}
+		if sc, ok := w.(interface {
+			StatusCode() int
+		}); ok {
+			return sc.StatusCode(), nil
+		}
+		return 0, nil
+	})
 
-	w, err := watch.NewBodyWatcher(req, r.client)
 	return w, err
 }
 
@@ -188,6 +226,8 @@ type Options struct {
 	Namespace   string
 	BearerToken *string
 	Client      *http.Client
+	// Hooks called for every request in addition to those registered
+	Hooks []Hook
 }
 
 // NewRequest creates a k8s api request
@@ -198,6 +238,7 @@ func NewRequest(opts *Options) *Request {
 		client:    opts.Client,
 		namespace: opts.Namespace,
 		host:      opts.Host,
+		hooks:     opts.Hooks,
 	}
 
 	if opts.BearerToken != nil {
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-plugins/registry/kubernetes/client/watch"
+)
+
+var (
+	// shared caches by client and selector
+	caches = struct {
+		sync.Mutex
+		m map[string]*Cache
+	}{m: make(map[string]*Cache)}
+
+	// cacheRetry is the delay before relisting after a failed watch
+	cacheRetry = time.Second
+)
+
+// Cache is a local cache of the pods matching a label selector kept up
+// to date by a list and watch, with lookups by name and label. It's
+// safe to share between registries and other plugins in a process so
+// the pods returned must not be modified.
+type Cache struct {
+	client Kubernetes
+	labels map[string]string
+
+	sync.RWMutex
+	pods map[string]*Pod
+	// pod names by label key and value
+	index map[string]map[string]map[string]bool
+	// labels each pod was indexed with
+	indexed map[string]map[string]string
+
+	exit chan bool
+	once sync.Once
+}
+
+// SharedCache returns the cache of pods matching the labels shared by
+// callers using the same api server and namespace, starting it if it
+// isn't already running. Shared caches run for the life of the process.
+func SharedCache(c Kubernetes, labels map[string]string) (*Cache, error) {
+	key := cacheKey(c, labels)
+
+	caches.Lock()
+	defer caches.Unlock()
+
+	if cache, ok := caches.m[key]; ok {
+		return cache, nil
+	}
+
+	cache := NewCache(c, labels)
+	if err := cache.Start(); err != nil {
+		return nil, err
+	}
+	caches.m[key] = cache
+	return cache, nil
+}
+
+// cacheKey identifies the api server, namespace and selector
+func cacheKey(c Kubernetes, labels map[string]string) string {
+	var sel []string
+	for k, v := range labels {
+		sel = append(sel, k+"="+v)
+	}
+	sort.Strings(sel)
+
+	if cl, ok := c.(*client); ok {
+		return cl.opts.Host + "/" + cl.opts.Namespace + "?" + strings.Join(sel, ",")
+	}
+	return fmt.Sprintf("%p?%s", c, strings.Join(sel, ","))
+}
+
+// NewCache returns a cache of the pods matching the labels
+func NewCache(c Kubernetes, labels map[string]string) *Cache {
+	return &Cache{
+		client:  c,
+		labels:  labels,
+		pods:    make(map[string]*Pod),
+		index:   make(map[string]map[string]map[string]bool),
+		indexed: make(map[string]map[string]string),
+		exit:    make(chan bool),
+	}
+}
+
+// Start lists the pods and keeps them up to date until stopped
+func (c *Cache) Start() error {
+	w, err := c.list()
+	if err != nil {
+		return err
+	}
+	go c.run(w)
+	return nil
+}
+
+// Stop stops watching for changes
+func (c *Cache) Stop() {
+	c.once.Do(func() {
+		close(c.exit)
+	})
+}
+
+// list replaces the cache with the current pods and starts a watch
+func (c *Cache) list() (watch.Watch, error) {
+	// watch before listing so no changes are missed
+	w, err := c.client.WatchPods(c.labels)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := c.client.ListPods(c.labels)
+	if err != nil {
+		w.Stop()
+		return nil, err
+	}
+
+	c.Lock()
+	c.pods = make(map[string]*Pod)
+	c.index = make(map[string]map[string]map[string]bool)
+	c.indexed = make(map[string]map[string]string)
+	for i := range pods.Items {
+		c.add(&pods.Items[i])
+	}
+	c.Unlock()
+
+	return w, nil
+}
+
+func (c *Cache) run(w watch.Watch) {
+	for {
+		c.watch(w)
+
+		// relist after the watch ends
+		for {
+			select {
+			case <-c.exit:
+				return
+			default:
+			}
+
+			var err error
+			if w, err = c.list(); err == nil {
+				break
+			}
+
+			log.Logf("[kubernetes] cache failed to relist pods: %v", err)
+
+			select {
+			case <-c.exit:
+				return
+			case <-time.After(cacheRetry):
+			}
+		}
+	}
+}
+
+// watch applies events until the watch ends or the cache is stopped
+func (c *Cache) watch(w watch.Watch) {
+	defer w.Stop()
+
+	for {
+		select {
+		case <-c.exit:
+			return
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+
+			var pod Pod
+			if e.Type == watch.Error {
+				log.Logf("[kubernetes] cache watch error: %s", e.Object)
+				return
+			}
+			if err := json.Unmarshal([]byte(e.Object), &pod); err != nil || pod.Metadata == nil {
+				continue
+			}
+
+			c.Lock()
+			c.remove(pod.Metadata.Name)
+			if e.Type != watch.Deleted {
+				c.add(&pod)
+			}
+			c.Unlock()
+		}
+	}
+}
+
+// add indexes the pod, the lock must be held
+func (c *Cache) add(pod *Pod) {
+	if pod.Metadata == nil {
+		return
+	}
+	name := pod.Metadata.Name
+	c.pods[name] = pod
+
+	labels := make(map[string]string, len(pod.Metadata.Labels))
+	c.indexed[name] = labels
+
+	for k, v := range pod.Metadata.Labels {
+		if v == nil {
+			continue
+		}
+		labels[k] = *v
+
+		values, ok := c.index[k]
+		if !ok {
+			values = make(map[string]map[string]bool)
+			c.index[k] = values
+		}
+		names, ok := values[*v]
+		if !ok {
+			names = make(map[string]bool)
+			values[*v] = names
+		}
+		names[name] = true
+	}
+}
+
+// remove unindexes the pod, the lock must be held
+func (c *Cache) remove(name string) {
+	if _, ok := c.pods[name]; !ok {
+		return
+	}
+	delete(c.pods, name)
+
+	for k, v := range c.indexed[name] {
+		names := c.index[k][v]
+		delete(names, name)
+		if len(names) == 0 {
+			delete(c.index[k], v)
+		}
+		if len(c.index[k]) == 0 {
+			delete(c.index, k)
+		}
+	}
+	delete(c.indexed, name)
+}
+
+// GetByName returns the pod with the name
+func (c *Cache) GetByName(name string) (*Pod, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	pod, ok := c.pods[name]
+	return pod, ok
+}
+
+// GetByLabel returns the pods with the label set to the value
+func (c *Cache) GetByLabel(key, value string) []*Pod {
+	c.RLock()
+	defer c.RUnlock()
+
+	var pods []*Pod
+	for name := range c.index[key][value] {
+		pods = append(pods, c.pods[name])
+	}
+	return pods
+}
+
+// List returns all the cached pods
+func (c *Cache) List() []*Pod {
+	c.RLock()
+	defer c.RUnlock()
+
+	pods := make([]*Pod, 0, len(c.pods))
+	for _, pod := range c.pods {
+		pods = append(pods, pod)
+	}
+	return pods
+}
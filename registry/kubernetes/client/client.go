@@ -38,11 +38,72 @@ func (c *client) UpdatePod(name string, p *Pod) (*Pod, error) {
 	return &pod, err
 }
 
+// UpdatePodStatus patches the status subresource, such as the
+// conditions of the pod's readiness gates
+func (c *client) UpdatePodStatus(name string, p *Pod) (*Pod, error) {
+	var pod Pod
+	err := api.NewRequest(c.opts).Patch().Resource("pods").Name(name + "/status").Body(p).Do().Into(&pod)
+	return &pod, err
+}
+
 // WatchPods ...
 func (c *client) WatchPods(labels map[string]string) (watch.Watch, error) {
 	return api.NewRequest(c.opts).Get().Resource("pods").Params(&api.Params{LabelSelector: labels}).Watch()
 }
 
+// GetLease ...
+func (c *client) GetLease(name string) (*Lease, error) {
+	var lease Lease
+	err := api.NewRequest(c.opts).Get().Group("coordination.k8s.io/v1").Resource("leases").Name(name).Do().Into(&lease)
+	return &lease, err
+}
+
+// CreateLease ...
+func (c *client) CreateLease(l *Lease) (*Lease, error) {
+	var lease Lease
+	err := api.NewRequest(c.opts).Post().Group("coordination.k8s.io/v1").Resource("leases").Body(l).Do().Into(&lease)
+	return &lease, err
+}
+
+// UpdateLease replaces the lease, failing with api.ErrConflict if the
+// resource version doesn't match
+func (c *client) UpdateLease(name string, l *Lease) (*Lease, error) {
+	var lease Lease
+	err := api.NewRequest(c.opts).Put().Group("coordination.k8s.io/v1").Resource("leases").Name(name).Body(l).Do().Into(&lease)
+	return &lease, err
+}
+
+// GetConfigMap ...
+func (c *client) GetConfigMap(name string) (*ConfigMap, error) {
+	var cm ConfigMap
+	err := api.NewRequest(c.opts).Get().Resource("configmaps").Name(name).Do().Into(&cm)
+	return &cm, err
+}
+
+// WatchConfigMaps ...
+func (c *client) WatchConfigMaps(labels map[string]string) (watch.Watch, error) {
+	return api.NewRequest(c.opts).Get().Resource("configmaps").Params(&api.Params{LabelSelector: labels}).Watch()
+}
+
+// GetSecret ...
+func (c *client) GetSecret(name string) (*Secret, error) {
+	var secret Secret
+	err := api.NewRequest(c.opts).Get().Resource("secrets").Name(name).Do().Into(&secret)
+	return &secret, err
+}
+
+// WatchSecrets ...
+func (c *client) WatchSecrets(labels map[string]string) (watch.Watch, error) {
+	return api.NewRequest(c.opts).Get().Resource("secrets").Params(&api.Params{LabelSelector: labels}).Watch()
+}
+
+// CreateEvent ...
+func (c *client) CreateEvent(e *Event) (*Event, error) {
+	var event Event
+	err := api.NewRequest(c.opts).Post().Resource("events").Body(e).Do().Into(&event)
+	return &event, err
+}
+
 func detectNamespace() (string, error) {
 	nsPath := path.Join(serviceAccountPath, "namespace")
 
@@ -1,12 +1,26 @@
 package client
 
-import "github.com/micro/go-plugins/registry/kubernetes/client/watch"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/micro/go-plugins/registry/kubernetes/client/watch"
+)
 
 // Kubernetes ...
 type Kubernetes interface {
 	ListPods(labels map[string]string) (*PodList, error)
 	UpdatePod(podName string, pod *Pod) (*Pod, error)
+	UpdatePodStatus(podName string, pod *Pod) (*Pod, error)
 	WatchPods(labels map[string]string) (watch.Watch, error)
+	GetLease(name string) (*Lease, error)
+	CreateLease(lease *Lease) (*Lease, error)
+	UpdateLease(name string, lease *Lease) (*Lease, error)
+	GetConfigMap(name string) (*ConfigMap, error)
+	WatchConfigMaps(labels map[string]string) (watch.Watch, error)
+	GetSecret(name string) (*Secret, error)
+	WatchSecrets(labels map[string]string) (watch.Watch, error)
+	CreateEvent(event *Event) (*Event, error)
 }
 
 // PodList ...
@@ -22,13 +36,114 @@ type Pod struct {
 
 // Meta ...
 type Meta struct {
-	Name        string             `json:"name,omitempty"`
-	Labels      map[string]*string `json:"labels,omitempty"`
-	Annotations map[string]*string `json:"annotations,omitempty"`
+	Name            string             `json:"name,omitempty"`
+	GenerateName    string             `json:"generateName,omitempty"`
+	Namespace       string             `json:"namespace,omitempty"`
+	UID             string             `json:"uid,omitempty"`
+	ResourceVersion string             `json:"resourceVersion,omitempty"`
+	Labels          map[string]*string `json:"labels,omitempty"`
+	Annotations     map[string]*string `json:"annotations,omitempty"`
 }
 
 // Status ...
 type Status struct {
-	PodIP string `json:"podIP"`
-	Phase string `json:"phase"`
+	PodIP      string          `json:"podIP,omitempty"`
+	Phase      string          `json:"phase,omitempty"`
+	Conditions []*PodCondition `json:"conditions,omitempty"`
+}
+
+// PodCondition is a pod condition, merged by type when patched
+type PodCondition struct {
+	Type               string     `json:"type"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	Message            string     `json:"message,omitempty"`
+	LastProbeTime      *time.Time `json:"lastProbeTime,omitempty"`
+	LastTransitionTime *time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ConfigMap ...
+type ConfigMap struct {
+	Metadata *Meta             `json:"metadata"`
+	Data     map[string]string `json:"data"`
+}
+
+// Secret values are base64 encoded by the api and decoded on unmarshal
+type Secret struct {
+	Metadata *Meta             `json:"metadata"`
+	Type     string            `json:"type,omitempty"`
+	Data     map[string][]byte `json:"data"`
+}
+
+// Event is a core/v1 event recorded against an object
+type Event struct {
+	Metadata       *Meta            `json:"metadata"`
+	InvolvedObject *ObjectReference `json:"involvedObject"`
+	Reason         string           `json:"reason,omitempty"`
+	Message        string           `json:"message,omitempty"`
+	Type           string           `json:"type,omitempty"`
+	Source         *EventSource     `json:"source,omitempty"`
+	FirstTimestamp time.Time        `json:"firstTimestamp"`
+	LastTimestamp  time.Time        `json:"lastTimestamp"`
+	Count          int              `json:"count,omitempty"`
+}
+
+// ObjectReference ...
+type ObjectReference struct {
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	UID        string `json:"uid,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// EventSource ...
+type EventSource struct {
+	Component string `json:"component,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+// Lease is a coordination.k8s.io/v1 lease
+type Lease struct {
+	Metadata *Meta      `json:"metadata"`
+	Spec     *LeaseSpec `json:"spec"`
+}
+
+// LeaseSpec ...
+type LeaseSpec struct {
+	HolderIdentity       string     `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int        `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *MicroTime `json:"acquireTime,omitempty"`
+	RenewTime            *MicroTime `json:"renewTime,omitempty"`
+	LeaseTransitions     int        `json:"leaseTransitions,omitempty"`
+}
+
+// MicroTime is a time with microsecond precision as used by leases
+type MicroTime struct {
+	time.Time
+}
+
+const microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// MarshalJSON ...
+func (t MicroTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(microTimeFormat))
+}
+
+// UnmarshalJSON ...
+func (t *MicroTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if len(s) == 0 {
+		t.Time = time.Time{}
+		return nil
+	}
+	pt, err := time.Parse(microTimeFormat, s)
+	if err != nil {
+		return err
+	}
+	t.Time = pt
+	return nil
 }
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"encoding/json"
+	"strconv"
 	"sync"
 
 	"github.com/micro/go-plugins/registry/kubernetes/client"
@@ -12,9 +13,13 @@ import (
 // Client ...
 type Client struct {
 	sync.Mutex
-	Pods     map[string]*client.Pod
-	events   chan watch.Event
-	watchers []*mockWatcher
+	Pods       map[string]*client.Pod
+	Leases     map[string]*client.Lease
+	ConfigMaps map[string]*client.ConfigMap
+	Secrets    map[string]*client.Secret
+	Events     []*client.Event
+	events     chan watch.Event
+	watchers   []*mockWatcher
 }
 
 // UpdatePod ...
@@ -36,6 +41,40 @@ func (m *Client) UpdatePod(podName string, pod *client.Pod) (*client.Pod, error)
 	return nil, nil
 }
 
+// UpdatePodStatus merges the conditions by type
+func (m *Client) UpdatePodStatus(podName string, pod *client.Pod) (*client.Pod, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	p, ok := m.Pods[podName]
+	if !ok {
+		return nil, api.ErrNotFound
+	}
+
+	if pod.Status == nil {
+		return p, nil
+	}
+	if p.Status == nil {
+		p.Status = &client.Status{}
+	}
+
+	for _, c := range pod.Status.Conditions {
+		var found bool
+		for i, pc := range p.Status.Conditions {
+			if pc.Type == c.Type {
+				p.Status.Conditions[i] = c
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.Status.Conditions = append(p.Status.Conditions, c)
+		}
+	}
+
+	return p, nil
+}
+
 // ListPods ...
 func (m *Client) ListPods(labels map[string]string) (*client.PodList, error) {
 	var pods []client.Pod
@@ -68,6 +107,117 @@ func (m *Client) WatchPods(labels map[string]string) (watch.Watch, error) {
 	return w, nil
 }
 
+// GetLease ...
+func (m *Client) GetLease(name string) (*client.Lease, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	l, ok := m.Leases[name]
+	if !ok {
+		return nil, api.ErrNotFound
+	}
+	return copyLease(l), nil
+}
+
+// CreateLease ...
+func (m *Client) CreateLease(lease *client.Lease) (*client.Lease, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.Leases[lease.Metadata.Name]; ok {
+		return nil, api.ErrConflict
+	}
+
+	l := copyLease(lease)
+	l.Metadata.ResourceVersion = "1"
+	m.Leases[l.Metadata.Name] = l
+	return copyLease(l), nil
+}
+
+// UpdateLease ...
+func (m *Client) UpdateLease(name string, lease *client.Lease) (*client.Lease, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	l, ok := m.Leases[name]
+	if !ok {
+		return nil, api.ErrNotFound
+	}
+	if l.Metadata.ResourceVersion != lease.Metadata.ResourceVersion {
+		return nil, api.ErrConflict
+	}
+
+	v, _ := strconv.Atoi(l.Metadata.ResourceVersion)
+
+	l = copyLease(lease)
+	l.Metadata.ResourceVersion = strconv.Itoa(v + 1)
+	m.Leases[name] = l
+	return copyLease(l), nil
+}
+
+// GetConfigMap ...
+func (m *Client) GetConfigMap(name string) (*client.ConfigMap, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	cm, ok := m.ConfigMaps[name]
+	if !ok {
+		return nil, api.ErrNotFound
+	}
+	return cm, nil
+}
+
+// UpdateConfigMap sets the config map and notifies watchers
+func (m *Client) UpdateConfigMap(cm *client.ConfigMap) {
+	m.Lock()
+	m.ConfigMaps[cm.Metadata.Name] = cm
+	m.Unlock()
+
+	b, _ := json.Marshal(cm)
+	m.events <- watch.Event{
+		Type:   watch.Modified,
+		Object: json.RawMessage(b),
+	}
+}
+
+// WatchConfigMaps ...
+func (m *Client) WatchConfigMaps(labels map[string]string) (watch.Watch, error) {
+	return m.WatchPods(labels)
+}
+
+// GetSecret ...
+func (m *Client) GetSecret(name string) (*client.Secret, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	s, ok := m.Secrets[name]
+	if !ok {
+		return nil, api.ErrNotFound
+	}
+	return s, nil
+}
+
+// WatchSecrets ...
+func (m *Client) WatchSecrets(labels map[string]string) (watch.Watch, error) {
+	return m.WatchPods(labels)
+}
+
+// CreateEvent records the event
+func (m *Client) CreateEvent(event *client.Event) (*client.Event, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.Events = append(m.Events, event)
+	return event, nil
+}
+
+func copyLease(l *client.Lease) *client.Lease {
+	var c client.Lease
+	b, _ := json.Marshal(l)
+	json.Unmarshal(b, &c)
+	return &c
+}
+
 // newClient ...
 func newClient() client.Kubernetes {
 	return &Client{}
@@ -76,8 +226,11 @@ func newClient() client.Kubernetes {
 // NewClient ...
 func NewClient() *Client {
 	c := &Client{
-		Pods:   make(map[string]*client.Pod),
-		events: make(chan watch.Event),
+		Pods:       make(map[string]*client.Pod),
+		Leases:     make(map[string]*client.Lease),
+		ConfigMaps: make(map[string]*client.ConfigMap),
+		Secrets:    make(map[string]*client.Secret),
+		events:     make(chan watch.Event),
 	}
 
 	// broadcast events to watchers
@@ -105,4 +258,8 @@ func Teardown(c *Client) {
 	}
 
 	c.Pods = make(map[string]*client.Pod)
+
+	c.Lock()
+	c.Events = nil
+	c.Unlock()
 }
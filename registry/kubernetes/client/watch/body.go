@@ -4,7 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"net/http"
-	"time"
+	"sync"
 )
 
 // bodyWatcher scans the body of a request for chunks
@@ -13,6 +13,12 @@ type bodyWatcher struct {
 	stop    chan struct{}
 	res     *http.Response
 	req     *http.Request
+	once    sync.Once
+}
+
+// StatusCode of the response establishing the watch
+func (wr *bodyWatcher) StatusCode() int {
+	return wr.res.StatusCode
 }
 
 // Changes returns the results channel
@@ -20,53 +26,41 @@ func (wr *bodyWatcher) ResultChan() <-chan Event {
 	return wr.results
 }
 
-// Stop cancels the request
+// Stop cancels the request, the results channel
+// is closed once the stream has ended
 func (wr *bodyWatcher) Stop() {
-	select {
-	case <-wr.stop:
-		return
-	default:
+	wr.once.Do(func() {
 		close(wr.stop)
-		close(wr.results)
-	}
+	})
 }
 
+// stream sends the events read from the body until the watch
+// ends or is stopped, then closes the results channel so
+// consumers know to watch again
 func (wr *bodyWatcher) stream() {
-	reader := bufio.NewReader(wr.res.Body)
-
-	// ignore first few messages from stream,
-	// as they are usually old.
-	ignore := true
+	defer close(wr.results)
+	defer wr.res.Body.Close()
 
-	go func() {
-		<-time.After(time.Second)
-		ignore = false
-	}()
-
-	go func() {
-		for {
-			// read a line
-			b, err := reader.ReadBytes('\n')
-			if err != nil {
-				return
-			}
+	reader := bufio.NewReader(wr.res.Body)
 
-			// ignore for the first second
-			if ignore {
-				continue
-			}
+	for {
+		// read a line, the server ending the watch is an error too
+		b, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
 
-			// send the event
-			var event Event
-			if err := json.Unmarshal(b, &event); err != nil {
-				continue
-			}
-			wr.results <- event
+		var event Event
+		if err := json.Unmarshal(b, &event); err != nil {
+			continue
 		}
 
-		// stop the watcher
-		wr.Stop()
-	}()
+		select {
+		case wr.results <- event:
+		case <-wr.stop:
+			return
+		}
+	}
 }
 
 // NewBodyWatcher creates a k8s body watcher for
@@ -46,8 +46,6 @@ func TestBodyWatcher(t *testing.T) {
 		t.Fatalf("did not expect NewBodyWatcher to return %v", err)
 	}
 
-	<-time.After(time.Second)
-
 	// send action strings in, and expect result back
 	ch <- actions[0]
 	if r := <-w.ResultChan(); r.Type != "create" {
@@ -68,4 +66,46 @@ func TestBodyWatcher(t *testing.T) {
 	// stop should clean up all channels.
 	w.Stop()
 	close(ch)
+
+	select {
+	case _, ok := <-w.ResultChan():
+		if ok {
+			t.Fatal("expected no more results")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected results to be closed after stop")
+	}
+}
+
+func TestBodyWatcherEnd(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s\n", actions[0])
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewBodyWatcher(req, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// initial events aren't dropped
+	if r := <-w.ResultChan(); r.Type != "create" {
+		t.Fatalf("expected result to be create got %s", r.Type)
+	}
+
+	// the server ending the watch closes the results
+	select {
+	case _, ok := <-w.ResultChan():
+		if ok {
+			t.Fatal("expected no more results")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected results to be closed when the watch ends")
+	}
 }
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/micro/go-log"
 	"github.com/micro/go-plugins/registry/kubernetes/client"
 
 	"github.com/micro/go-micro/cmd"
@@ -20,6 +23,11 @@ type kregistry struct {
 	client  client.Kubernetes
 	timeout time.Duration
 	options registry.Options
+
+	sync.Mutex
+	// last registered service per name, used to
+	// only record events when a registration changes
+	registered map[string]string
 }
 
 var (
@@ -40,6 +48,11 @@ var (
 
 	// label name regex
 	labelRe = regexp.MustCompilePOSIX("[-A-Za-z0-9_.]")
+
+	// Event reasons and default source component
+	eventRegistered    = "Registered"
+	eventDeregistered  = "Deregistered"
+	defaultEventSource = "micro-registry"
 )
 
 // podSelector
@@ -101,10 +114,25 @@ func (c *kregistry) Register(s *registry.Service, opts ...registry.RegisterOptio
 		},
 	}
 
-	if _, err := c.client.UpdatePod(podName, pod); err != nil {
+	p, err := c.client.UpdatePod(podName, pod)
+	if err != nil {
 		return err
 	}
 
+	// Register is called on every heartbeat so only
+	// record an event when the registration changes
+	c.Lock()
+	if c.registered == nil {
+		c.registered = make(map[string]string)
+	}
+	changed := c.registered[svcName] != svc
+	c.registered[svcName] = svc
+	c.Unlock()
+
+	if changed {
+		c.event(podName, p, eventRegistered, s)
+	}
+
 	return nil
 
 }
@@ -130,39 +158,129 @@ func (c *kregistry) Deregister(s *registry.Service) error {
 		},
 	}
 
-	if _, err := c.client.UpdatePod(podName, pod); err != nil {
+	p, err := c.client.UpdatePod(podName, pod)
+	if err != nil {
 		return err
 	}
 
+	c.Lock()
+	delete(c.registered, svcName)
+	c.Unlock()
+
+	c.event(podName, p, eventDeregistered, s)
+
 	return nil
 
 }
 
-// GetService will get all the pods with the given service selector,
-// and build services from the annotations.
-func (c *kregistry) GetService(name string) ([]*registry.Service, error) {
-	pods, err := c.client.ListPods(map[string]string{
-		svcSelectorPrefix + serviceName(name): svcSelectorValue,
-	})
+// event records a kubernetes event against the pod if enabled. Failures
+// are logged rather than returned so they never block registration.
+func (c *kregistry) event(podName string, pod *client.Pod, reason string, s *registry.Service) {
+	source, ok := eventSource(c.options)
+	if !ok {
+		return
+	}
+
+	ref := &client.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Name:       podName,
+	}
+	if pod != nil && pod.Metadata != nil {
+		ref.Namespace = pod.Metadata.Namespace
+		ref.UID = pod.Metadata.UID
+	}
+
+	var nodes []string
+	for _, n := range s.Nodes {
+		nodes = append(nodes, n.Id)
+	}
+
+	now := time.Now()
+
+	event := &client.Event{
+		Metadata: &client.Meta{
+			GenerateName: podName + ".",
+			Namespace:    ref.Namespace,
+		},
+		InvolvedObject: ref,
+		Reason:         reason,
+		Message:        fmt.Sprintf("%s service %s version %s nodes %s", reason, s.Name, s.Version, strings.Join(nodes, ",")),
+		Type:           "Normal",
+		Source: &client.EventSource{
+			Component: source,
+			Host:      podName,
+		},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	if _, err := c.client.CreateEvent(event); err != nil {
+		log.Logf("[kubernetes] failed to record %s event for %s: %v", reason, s.Name, err)
+	}
+}
+
+// listPods lists the pods with the labels from the shared cache if
+// enabled or otherwise the api
+func (c *kregistry) listPods(labels map[string]string) (*client.PodList, error) {
+	if !useCache(c.options) {
+		return c.client.ListPods(labels)
+	}
+
+	cache, err := client.SharedCache(c.client, podSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(pods.Items) == 0 {
-		return nil, registry.ErrNotFound
+	var pods []*client.Pod
+	for k, v := range labels {
+		pods = cache.GetByLabel(k, v)
+		break
+	}
+
+	var list client.PodList
+	for _, pod := range pods {
+		if matchLabels(pod, labels) {
+			list.Items = append(list.Items, *pod)
+		}
+	}
+	return &list, nil
+}
+
+func matchLabels(pod *client.Pod, labels map[string]string) bool {
+	for k, v := range labels {
+		if l, ok := pod.Metadata.Labels[k]; !ok || l == nil || *l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// getService builds the services registered by running pods grouped by
+// version, filtered to the version unless it's empty. Nodes of the same
+// version registered by more than one pod are merged.
+func (c *kregistry) getService(name, version string) ([]*registry.Service, error) {
+	pods, err := c.listPods(map[string]string{
+		svcSelectorPrefix + serviceName(name): svcSelectorValue,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// svcs mapped by version
 	svcs := make(map[string]*registry.Service)
+	// node ids seen by version
+	seen := make(map[string]map[string]bool)
 
 	// loop through items
 	for _, pod := range pods.Items {
-		if pod.Status.Phase != podRunning {
+		if pod.Metadata == nil || pod.Status == nil || pod.Status.Phase != podRunning {
 			continue
 		}
 		// get serialised service from annotation
 		svcStr, ok := pod.Metadata.Annotations[annotationServiceKeyPrefix+serviceName(name)]
-		if !ok {
+		if !ok || svcStr == nil {
 			continue
 		}
 
@@ -173,26 +291,86 @@ func (c *kregistry) GetService(name string) ([]*registry.Service, error) {
 			return nil, fmt.Errorf("could not unmarshal service '%s' from pod annotation", name)
 		}
 
+		if len(version) > 0 && svc.Version != version {
+			continue
+		}
+
 		// merge up pod service & ip with versioned service.
 		vs, ok := svcs[svc.Version]
 		if !ok {
-			svcs[svc.Version] = &svc
-			continue
+			vs = &registry.Service{
+				Name:      svc.Name,
+				Version:   svc.Version,
+				Metadata:  svc.Metadata,
+				Endpoints: svc.Endpoints,
+			}
+			svcs[svc.Version] = vs
+			seen[svc.Version] = make(map[string]bool)
+		}
+
+		for _, n := range svc.Nodes {
+			// a replaced pod may still be listed with the same node
+			if seen[svc.Version][n.Id] {
+				continue
+			}
+			seen[svc.Version][n.Id] = true
+			vs.Nodes = append(vs.Nodes, n)
 		}
+	}
 
-		vs.Nodes = append(vs.Nodes, svc.Nodes...)
+	if len(svcs) == 0 {
+		return nil, registry.ErrNotFound
 	}
 
 	var list []*registry.Service
 	for _, val := range svcs {
 		list = append(list, val)
 	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return versionLess(list[i].Version, list[j].Version)
+	})
+
+	return list, nil
+}
+
+// GetService will get all the pods with the given service selector,
+// and build a service per version from the annotations.
+func (c *kregistry) GetService(name string) ([]*registry.Service, error) {
+	return c.getService(name, "")
+}
+
+// GetVersion returns the service of a single version. Pods of other
+// versions are skipped when r is a kubernetes registry, otherwise the
+// services returned by GetService are filtered. It returns
+// registry.ErrNotFound when no nodes of the version are registered.
+func GetVersion(r registry.Registry, name, version string) ([]*registry.Service, error) {
+	if k, ok := r.(*kregistry); ok {
+		return k.getService(name, version)
+	}
+
+	services, err := r.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*registry.Service
+	for _, s := range services {
+		if s.Version == version {
+			list = append(list, s)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, registry.ErrNotFound
+	}
+
 	return list, nil
 }
 
 // ListServices will list all the service names
 func (c *kregistry) ListServices() ([]*registry.Service, error) {
-	pods, err := c.client.ListPods(podSelector)
+	pods, err := c.listPods(podSelector)
 	if err != nil {
 		return nil, err
 	}
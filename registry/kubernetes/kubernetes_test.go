@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -72,9 +74,15 @@ func teardownRegistry() {
 }
 
 func setupRegistry(opts ...registry.Option) registry.Registry {
+	var options registry.Options
+	for _, o := range opts {
+		o(&options)
+	}
+
 	return &kregistry{
 		client:  mockClient,
 		timeout: time.Second * 1,
+		options: options,
 	}
 }
 
@@ -309,6 +317,41 @@ func TestDeregister(t *testing.T) {
 
 }
 
+func TestEvents(t *testing.T) {
+	r := setupRegistry(Events("test"))
+	defer teardownRegistry()
+
+	svc := &registry.Service{Name: "foo.service", Version: "1"}
+	register(r, "pod-1", svc)
+
+	// heartbeat re-registrations should not record events
+	os.Setenv("HOSTNAME", "pod-1")
+	if err := r.Register(svc); err != nil {
+		t.Fatalf("did not expect Register() to fail: %v", err)
+	}
+	if err := r.Deregister(svc); err != nil {
+		t.Fatalf("did not expect Deregister() to fail: %v", err)
+	}
+	os.Setenv("HOSTNAME", "")
+
+	if len(mockClient.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(mockClient.Events))
+	}
+
+	for i, reason := range []string{eventRegistered, eventDeregistered} {
+		e := mockClient.Events[i]
+		if e.Reason != reason {
+			t.Fatalf("expected event reason %s, got %s", reason, e.Reason)
+		}
+		if e.InvolvedObject.Kind != "Pod" || e.InvolvedObject.Name != "pod-1" {
+			t.Fatalf("expected event to reference pod-1, got %+v", e.InvolvedObject)
+		}
+		if e.Source.Component != "test" {
+			t.Fatalf("expected event source test, got %s", e.Source.Component)
+		}
+	}
+}
+
 func TestGetService(t *testing.T) {
 	r := setupRegistry()
 	defer teardownRegistry()
@@ -378,6 +421,43 @@ func TestGetServiceTwoVersionsTwoPods(t *testing.T) {
 	}
 }
 
+func TestGetVersion(t *testing.T) {
+	r := setupRegistry()
+	defer teardownRegistry()
+
+	svc1 := &registry.Service{Name: "foo.service", Version: "2"}
+	svc2 := &registry.Service{Name: "foo.service", Version: "1"}
+	svc3 := &registry.Service{Name: "foo.service", Version: "2"}
+
+	register(r, "pod-1", svc1)
+	register(r, "pod-2", svc2)
+	register(r, "pod-3", svc3)
+
+	// services are ordered by version
+	service, err := r.GetService("foo.service")
+	if err != nil {
+		t.Fatalf("did not expect GetService to fail %v", err)
+	}
+	if len(service) != 2 || service[0].Version != "1" || service[1].Version != "2" {
+		t.Fatalf("expected versions 1 and 2 got %+v", service)
+	}
+
+	service, err = GetVersion(r, "foo.service", "2")
+	if err != nil {
+		t.Fatalf("did not expect GetVersion to fail %v", err)
+	}
+	if len(service) != 1 || service[0].Version != "2" {
+		t.Fatalf("expected only version 2 got %+v", service)
+	}
+	if !hasNodes(service[0].Nodes, []*registry.Node{svc1.Nodes[0], svc3.Nodes[0]}) {
+		t.Fatal("expected the nodes of both version 2 pods")
+	}
+
+	if _, err := GetVersion(r, "foo.service", "3"); err != registry.ErrNotFound {
+		t.Fatalf("expected not found for an unregistered version got %v", err)
+	}
+}
+
 func TestListServices(t *testing.T) {
 	r := setupRegistry()
 	defer teardownRegistry()
@@ -425,6 +505,48 @@ func TestListServices(t *testing.T) {
 
 }
 
+func TestGarbageCollection(t *testing.T) {
+	r := setupRegistry()
+	defer teardownRegistry()
+
+	svc1 := &registry.Service{Name: "foo.service", Version: "1"}
+	svc2 := &registry.Service{Name: "foo.service", Version: "1"}
+
+	register(r, "pod-1", svc1)
+	register(r, "pod-2", svc2)
+
+	// registrations live on the pods so nothing else is left behind
+	if len(mockClient.Secrets) != 0 || len(mockClient.ConfigMaps) != 0 {
+		t.Fatalf("expected no objects besides the pods got %d secrets %d config maps", len(mockClient.Secrets), len(mockClient.ConfigMaps))
+	}
+
+	// delete a pod out-of-band without deregistering
+	delete(mockClient.Pods, "pod-1")
+
+	services, err := r.GetService("foo.service")
+	if err != nil {
+		t.Fatalf("did not expect GetService to fail %v", err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 1 || services[0].Nodes[0].Id != svc2.Nodes[0].Id {
+		t.Fatalf("expected only the node of the remaining pod got %+v", services)
+	}
+
+	// pods which are no longer running are skipped
+	mockClient.Pods["pod-2"].Status.Phase = "Failed"
+
+	if _, err := r.GetService("foo.service"); err != registry.ErrNotFound {
+		t.Fatalf("expected %v got %v", registry.ErrNotFound, err)
+	}
+
+	services, err = r.ListServices()
+	if err != nil {
+		t.Fatalf("did not expect ListServices to fail %v", err)
+	}
+	if len(services) != 0 {
+		t.Fatalf("expected no services got %d", len(services))
+	}
+}
+
 func TestWatcher(t *testing.T) {
 	r := setupRegistry()
 	c := cache.NewSelector(selector.Registry(r))
@@ -503,6 +625,87 @@ func TestWatcher(t *testing.T) {
 
 }
 
+func TestWatcherBuffer(t *testing.T) {
+	var dropped int32
+	r := setupRegistry(WatchBuffer(1), WatchDropped(func(*registry.Result) {
+		atomic.AddInt32(&dropped, 1)
+	}))
+	defer teardownRegistry()
+
+	w, err := r.Watch()
+	if err != nil {
+		t.Fatalf("did not expect Watch() to fail: %v", err)
+	}
+
+	// register without consuming so the buffer overflows
+	for i := 0; i < 3; i++ {
+		svc := &registry.Service{Name: "foo.service." + strconv.Itoa(i), Version: "1"}
+		register(r, "pod-1", svc)
+	}
+	time.Sleep(time.Millisecond * 10)
+
+	if n := atomic.LoadInt32(&dropped); n != 2 {
+		t.Fatalf("expected 2 dropped results, got %d", n)
+	}
+
+	if _, err := w.Next(); err != ErrResync {
+		t.Fatalf("expected ErrResync, got %v", err)
+	}
+
+	res, err := w.Next()
+	if err != nil {
+		t.Fatalf("did not expect Next() to fail: %v", err)
+	}
+	if res.Service.Name != "foo.service.2" {
+		t.Fatalf("expected newest result to be kept, got %s", res.Service.Name)
+	}
+}
+
+func TestCache(t *testing.T) {
+	r := setupRegistry(Cache())
+	defer teardownRegistry()
+
+	svc1 := &registry.Service{Name: "foo.service"}
+	svc2 := &registry.Service{Name: "foo.service"}
+	register(r, "pod-1", svc1)
+	register(r, "pod-2", svc2)
+
+	service, err := r.GetService("foo.service")
+	if err != nil {
+		t.Fatalf("did not expect GetService to fail %v", err)
+	}
+	if len(service) != 1 || len(service[0].Nodes) != 2 {
+		t.Fatalf("expected 2 nodes got %+v", service)
+	}
+
+	// registries share the cache
+	cache, err := client.SharedCache(mockClient, podSelector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod, ok := cache.GetByName("pod-1"); !ok || pod.Metadata.Name != "pod-1" {
+		t.Fatal("expected pod-1 to be cached")
+	}
+	if c, _ := client.SharedCache(mockClient, podSelector); c != cache {
+		t.Fatal("expected the cache to be shared")
+	}
+
+	// changes are applied from the watch
+	os.Setenv("HOSTNAME", "pod-1")
+	if err := r.Deregister(svc1); err != nil {
+		t.Fatalf("did not expect Deregister to fail %v", err)
+	}
+	os.Setenv("HOSTNAME", "")
+
+	deadline := time.Now().Add(time.Second)
+	for len(cache.GetByLabel(svcSelectorPrefix+"foo.service", svcSelectorValue)) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected deregistered pod to be removed from the label index")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
 func hasNodes(a, b []*registry.Node) bool {
 	found := 0
 	for _, aV := range a {
@@ -536,3 +739,17 @@ func hasServices(a, b []*registry.Service) bool {
 	}
 	return found == len(b)
 }
+
+func TestVersionLess(t *testing.T) {
+	versions := []string{"latest", "1.10.0", "v1.2.0", "1.2.0-rc.10", "1.2.0-rc.2", "1.2.0-beta", "1.9", "2"}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+
+	expected := []string{"1.2.0-beta", "1.2.0-rc.2", "1.2.0-rc.10", "v1.2.0", "1.9", "1.10.0", "2", "latest"}
+	for i, v := range expected {
+		if versions[i] != v {
+			t.Fatalf("expected %v got %v", expected, versions)
+		}
+	}
+}
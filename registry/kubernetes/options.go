@@ -0,0 +1,104 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type eventsKey struct{}
+
+// Events records a kubernetes event with reason Registered or
+// Deregistered against the pod whenever a service registration changes.
+// The component is reported as the event source, defaulting to
+// "micro-registry".
+func Events(component string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, eventsKey{}, component)
+	}
+}
+
+func eventSource(o registry.Options) (string, bool) {
+	if o.Context == nil {
+		return "", false
+	}
+	c, ok := o.Context.Value(eventsKey{}).(string)
+	if !ok {
+		return "", false
+	}
+	if len(c) == 0 {
+		c = defaultEventSource
+	}
+	return c, true
+}
+
+type watchBufferKey struct{}
+
+type watchDroppedKey struct{}
+
+// WatchBuffer sets the number of results a watcher buffers for a slow
+// consumer. Once full the oldest result is dropped and the next call to
+// Next returns ErrResync. Defaults to DefaultWatchBuffer.
+func WatchBuffer(size int) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, watchBufferKey{}, size)
+	}
+}
+
+// WatchDropped is called with each result a watcher drops because its
+// buffer is full. It can be used to export a dropped events metric.
+func WatchDropped(fn func(*registry.Result)) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, watchDroppedKey{}, fn)
+	}
+}
+
+func watchBuffer(o registry.Options) int {
+	if o.Context == nil {
+		return DefaultWatchBuffer
+	}
+	if size, ok := o.Context.Value(watchBufferKey{}).(int); ok && size > 0 {
+		return size
+	}
+	return DefaultWatchBuffer
+}
+
+func watchDropped(o registry.Options) func(*registry.Result) {
+	if o.Context == nil {
+		return nil
+	}
+	fn, _ := o.Context.Value(watchDroppedKey{}).(func(*registry.Result))
+	return fn
+}
+
+type cacheKey struct{}
+
+// Cache serves GetService and ListServices from a local cache of pods
+// kept up to date by a watch rather than listing pods on every call.
+// The cache is shared by registries in the process using the same api
+// server and namespace.
+func Cache() registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, cacheKey{}, true)
+	}
+}
+
+func useCache(o registry.Options) bool {
+	if o.Context == nil {
+		return false
+	}
+	b, _ := o.Context.Value(cacheKey{}).(bool)
+	return b
+}
@@ -0,0 +1,102 @@
+package readiness
+
+import (
+	"os"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+)
+
+// Check returns an error when a dependency isn't ready
+type Check func() error
+
+type Options struct {
+	// Registry registrations are passed to
+	Registry registry.Registry
+	// Checks by name which must pass to be ready
+	Checks map[string]Check
+	// Client used to update the pod condition, the
+	// condition isn't updated when not set
+	Client client.Kubernetes
+	// Pod whose condition is updated
+	Pod string
+	// Condition type listed in the pod's readiness gates
+	Condition string
+	// Interval at which checks are run to update the condition
+	Interval time.Duration
+}
+
+type Option func(o *Options)
+
+var (
+	// DefaultCondition is the default readiness gate condition type
+	DefaultCondition = "micro.mu/registered"
+	// DefaultInterval is the default interval checks are run at
+	DefaultInterval = time.Second * 10
+)
+
+// Registry sets the registry registrations are passed to,
+// defaults to the registry.DefaultRegistry
+func Registry(r registry.Registry) Option {
+	return func(o *Options) {
+		o.Registry = r
+	}
+}
+
+// WithCheck adds a named dependency check
+func WithCheck(name string, c Check) Option {
+	return func(o *Options) {
+		o.Checks[name] = c
+	}
+}
+
+// Client sets the kubernetes client used to update the pod condition
+func Client(c client.Kubernetes) Option {
+	return func(o *Options) {
+		o.Client = c
+	}
+}
+
+// Pod sets the pod name, defaults to the POD_NAME env var then the hostname
+func Pod(p string) Option {
+	return func(o *Options) {
+		o.Pod = p
+	}
+}
+
+// Condition sets the readiness gate condition type
+func Condition(c string) Option {
+	return func(o *Options) {
+		o.Condition = c
+	}
+}
+
+// Interval sets the interval checks are run at to update the condition
+func Interval(d time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	hostname, _ := os.Hostname()
+
+	options := Options{
+		Registry:  registry.DefaultRegistry,
+		Checks:    make(map[string]Check),
+		Pod:       os.Getenv("POD_NAME"),
+		Condition: DefaultCondition,
+		Interval:  DefaultInterval,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if len(options.Pod) == 0 {
+		options.Pod = hostname
+	}
+
+	return options
+}
@@ -0,0 +1,200 @@
+// Package readiness gates a service's kubernetes readiness on its registry
+// registration. The gate wraps a registry, serves a readiness probe which
+// passes only once the service is registered and its dependency checks
+// pass, and optionally sets a pod readiness gate condition to match.
+package readiness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+)
+
+var (
+	// ErrNotRegistered is returned by Check before a successful Register
+	ErrNotRegistered = errors.New("service not registered")
+
+	// condition reasons
+	reasonReady         = "Ready"
+	reasonNotRegistered = "NotRegistered"
+	reasonCheckFailed   = "CheckFailed"
+)
+
+// Gate is a registry which tracks registration for readiness
+type Gate struct {
+	registry.Registry
+	opts Options
+
+	sync.RWMutex
+	registered map[string]bool
+
+	// serialises condition updates
+	mtx sync.Mutex
+	// last condition status set on the pod
+	status string
+
+	exit chan bool
+	once sync.Once
+}
+
+// Check returns nil when the service is registered and all checks pass
+func (g *Gate) Check() error {
+	g.RLock()
+	registered := len(g.registered) > 0
+	g.RUnlock()
+
+	if !registered {
+		return ErrNotRegistered
+	}
+
+	var errs []string
+	for name, c := range g.opts.Checks {
+		if err := c(); err != nil {
+			errs = append(errs, name+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("checks failed: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// ServeHTTP serves the readiness probe
+func (g *Gate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	code := http.StatusOK
+	rsp := map[string]string{"status": "ready"}
+
+	if err := g.Check(); err != nil {
+		code = http.StatusServiceUnavailable
+		rsp = map[string]string{"status": "not ready", "error": err.Error()}
+	}
+
+	b, _ := json.Marshal(rsp)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// update sets the pod condition if the readiness changed
+func (g *Gate) update() {
+	if g.opts.Client == nil {
+		return
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	status, reason, message := "True", reasonReady, ""
+	if err := g.Check(); err == ErrNotRegistered {
+		status, reason, message = "False", reasonNotRegistered, err.Error()
+	} else if err != nil {
+		status, reason, message = "False", reasonCheckFailed, err.Error()
+	}
+
+	if status == g.status {
+		return
+	}
+
+	now := time.Now()
+
+	_, err := g.opts.Client.UpdatePodStatus(g.opts.Pod, &client.Pod{
+		Status: &client.Status{
+			Conditions: []*client.PodCondition{
+				{
+					Type:               g.opts.Condition,
+					Status:             status,
+					Reason:             reason,
+					Message:            message,
+					LastProbeTime:      &now,
+					LastTransitionTime: &now,
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Logf("[readiness] failed to set %s condition on pod %s: %v", g.opts.Condition, g.opts.Pod, err)
+		return
+	}
+
+	g.status = status
+}
+
+func (g *Gate) run() {
+	t := time.NewTicker(g.opts.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-g.exit:
+			return
+		case <-t.C:
+			g.update()
+		}
+	}
+}
+
+// Register registers the service, a failure marks it not ready
+// as its previous registration may have expired
+func (g *Gate) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	err := g.Registry.Register(s, opts...)
+
+	g.Lock()
+	if err != nil {
+		delete(g.registered, s.Name)
+	} else {
+		g.registered[s.Name] = true
+	}
+	g.Unlock()
+
+	g.update()
+
+	return err
+}
+
+// Deregister marks the service not ready before deregistering it,
+// so the pod is removed from the kubernetes endpoints first
+func (g *Gate) Deregister(s *registry.Service) error {
+	g.Lock()
+	delete(g.registered, s.Name)
+	g.Unlock()
+
+	g.update()
+
+	return g.Registry.Deregister(s)
+}
+
+// Stop stops running the checks
+func (g *Gate) Stop() {
+	g.once.Do(func() {
+		close(g.exit)
+	})
+}
+
+// NewGate returns a gate wrapping the registry
+func NewGate(opts ...Option) *Gate {
+	options := newOptions(opts...)
+
+	g := &Gate{
+		Registry:   options.Registry,
+		opts:       options,
+		registered: make(map[string]bool),
+		exit:       make(chan bool),
+	}
+
+	if options.Client != nil {
+		go g.run()
+	}
+
+	return g
+}
@@ -0,0 +1,101 @@
+package readiness
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	kmock "github.com/micro/go-plugins/registry/kubernetes/client/mock"
+)
+
+func condition(t *testing.T, c *kmock.Client) string {
+	c.Lock()
+	defer c.Unlock()
+
+	p := c.Pods["pod-1"]
+	if p.Status == nil || len(p.Status.Conditions) == 0 {
+		return ""
+	}
+	if len(p.Status.Conditions) != 1 {
+		t.Fatalf("expected one condition, got %d", len(p.Status.Conditions))
+	}
+	if p.Status.Conditions[0].Type != DefaultCondition {
+		t.Fatalf("unexpected condition %s", p.Status.Conditions[0].Type)
+	}
+	return p.Status.Conditions[0].Status
+}
+
+func probe(g *Gate) int {
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+	return w.Code
+}
+
+func TestGate(t *testing.T) {
+	c := kmock.NewClient()
+	c.Pods["pod-1"] = &client.Pod{
+		Metadata: &client.Meta{Name: "pod-1"},
+		Status:   &client.Status{Phase: "Running"},
+	}
+
+	var dep error
+
+	g := NewGate(
+		Registry(mock.NewRegistry()),
+		Client(c),
+		Pod("pod-1"),
+		WithCheck("db", func() error { return dep }),
+	)
+	defer g.Stop()
+
+	if err := g.Check(); err != ErrNotRegistered {
+		t.Fatalf("expected not registered, got %v", err)
+	}
+	if code := probe(g); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before register, got %d", code)
+	}
+
+	s := &registry.Service{
+		Name: "go.micro.srv.test",
+		Nodes: []*registry.Node{
+			{Id: "test-1", Address: "10.0.0.1", Port: 8080},
+		},
+	}
+
+	if err := g.Register(s); err != nil {
+		t.Fatal(err)
+	}
+	if code := probe(g); code != http.StatusOK {
+		t.Fatalf("expected 200 after register, got %d", code)
+	}
+	if status := condition(t, c); status != "True" {
+		t.Fatalf("expected condition True, got %q", status)
+	}
+
+	// a failing dependency fails the probe and the next update
+	dep = errors.New("down")
+	if code := probe(g); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 with failing check, got %d", code)
+	}
+	g.update()
+	if status := condition(t, c); status != "False" {
+		t.Fatalf("expected condition False, got %q", status)
+	}
+
+	dep = nil
+	g.update()
+	if status := condition(t, c); status != "True" {
+		t.Fatalf("expected condition True, got %q", status)
+	}
+
+	if err := g.Deregister(s); err != nil {
+		t.Fatal(err)
+	}
+	if status := condition(t, c); status != "False" {
+		t.Fatalf("expected condition False after deregister, got %q", status)
+	}
+}
@@ -0,0 +1,108 @@
+package kubernetes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version
+type semver struct {
+	parts [3]int
+	pre   []string
+}
+
+// parseSemver parses versions such as 1.2.3, v1.2 or 1.2.3-rc.1+build.
+// Missing minor and patch versions are zero.
+func parseSemver(v string) (*semver, bool) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+
+	var s semver
+	if i := strings.Index(v, "-"); i >= 0 {
+		s.pre = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return nil, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		s.parts[i] = n
+	}
+
+	return &s, true
+}
+
+// comparePre compares pre-release identifiers, a release
+// without any is greater than a pre-release
+func comparePre(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, aerr := strconv.Atoi(a[i])
+		nb, berr := strconv.Atoi(b[i])
+
+		switch {
+		case aerr == nil && berr == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case aerr == nil:
+			// numeric identifiers are lower than alphanumeric
+			return -1
+		case berr == nil:
+			return 1
+		case a[i] != b[i]:
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// versionLess orders versions as semantic versions when both
+// parse, otherwise they're compared as strings
+func versionLess(a, b string) bool {
+	sa, aok := parseSemver(a)
+	sb, bok := parseSemver(b)
+	if !aok || !bok {
+		return a < b
+	}
+
+	for i := range sa.parts {
+		if sa.parts[i] != sb.parts[i] {
+			return sa.parts[i] < sb.parts[i]
+		}
+	}
+
+	if c := comparePre(sa.pre, sb.pre); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/micro/go-log"
 	"github.com/micro/go-micro/registry"
@@ -12,10 +13,24 @@ import (
 	"github.com/micro/go-plugins/registry/kubernetes/client/watch"
 )
 
+var (
+	// DefaultWatchBuffer is the number of results buffered per watcher
+	DefaultWatchBuffer = 64
+
+	// ErrResync is returned by Next when results were dropped because the
+	// consumer fell behind. The consumer should relist services to resync.
+	ErrResync = errors.New("watcher dropped results, resync required")
+)
+
 type k8sWatcher struct {
 	registry *kregistry
 	watcher  watch.Watch
 	next     chan *registry.Result
+	dropped  func(*registry.Result)
+
+	// set when results have been dropped
+	resync int32
+	once   sync.Once
 
 	sync.RWMutex
 	pods map[string]*client.Pod
@@ -158,7 +173,7 @@ func (k *k8sWatcher) handleEvent(event watch.Event) {
 			if pod.Status.Phase != podRunning {
 				result.Action = "delete"
 			}
-			k.next <- result
+			k.send(result)
 		}
 
 		k.Lock()
@@ -173,7 +188,7 @@ func (k *k8sWatcher) handleEvent(event watch.Event) {
 
 		for _, result := range results {
 			result.Action = "delete"
-			k.next <- result
+			k.send(result)
 		}
 
 		k.Lock()
@@ -184,8 +199,34 @@ func (k *k8sWatcher) handleEvent(event watch.Event) {
 
 }
 
-// Next will block until a new result comes in
+// send delivers the result without blocking the watch. When the buffer
+// is full the oldest result is dropped and a resync is forced.
+func (k *k8sWatcher) send(r *registry.Result) {
+	for {
+		select {
+		case k.next <- r:
+			return
+		default:
+		}
+
+		select {
+		case old := <-k.next:
+			atomic.StoreInt32(&k.resync, 1)
+			if k.dropped != nil {
+				k.dropped(old)
+			}
+		default:
+		}
+	}
+}
+
+// Next will block until a new result comes in. ErrResync is returned
+// once if results were dropped since the last call.
 func (k *k8sWatcher) Next() (*registry.Result, error) {
+	if atomic.CompareAndSwapInt32(&k.resync, 1, 0) {
+		return nil, ErrResync
+	}
+
 	r, ok := <-k.next
 	if !ok {
 		return nil, errors.New("result chan closed")
@@ -193,16 +234,10 @@ func (k *k8sWatcher) Next() (*registry.Result, error) {
 	return r, nil
 }
 
-// Stop will cancel any requests, and close channels
+// Stop will cancel any requests. The result channel is closed
+// once the watch goroutine exits.
 func (k *k8sWatcher) Stop() {
-	k.watcher.Stop()
-
-	select {
-	case <-k.next:
-		return
-	default:
-		close(k.next)
-	}
+	k.once.Do(k.watcher.Stop)
 }
 
 func newWatcher(kr *kregistry, opts ...registry.WatchOption) (registry.Watcher, error) {
@@ -227,7 +262,8 @@ func newWatcher(kr *kregistry, opts ...registry.WatchOption) (registry.Watcher,
 	k := &k8sWatcher{
 		registry: kr,
 		watcher:  watcher,
-		next:     make(chan *registry.Result),
+		next:     make(chan *registry.Result, watchBuffer(kr.options)),
+		dropped:  watchDropped(kr.options),
 		pods:     make(map[string]*client.Pod),
 	}
 
@@ -243,6 +279,7 @@ func newWatcher(kr *kregistry, opts ...registry.WatchOption) (registry.Watcher,
 			k.handleEvent(event)
 		}
 		k.Stop()
+		close(k.next)
 	}()
 
 	return k, nil
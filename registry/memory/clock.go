@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time registrations with a TTL expire against
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a clock which only moves when told to,
+// for deterministic tests of TTL expiry
+type ManualClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+// Now returns the clock's time
+func (c *ManualClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Lock()
+	c.now = c.now.Add(d)
+	c.Unlock()
+}
+
+// Set sets the clock's time
+func (c *ManualClock) Set(t time.Time) {
+	c.Lock()
+	c.now = t
+	c.Unlock()
+}
+
+// NewManualClock returns a clock stopped at t
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
@@ -1,6 +1,8 @@
 package memory
 
 import (
+	"reflect"
+
 	"github.com/micro/go-micro/registry"
 )
 
@@ -74,3 +76,30 @@ func delServices(old, del []*registry.Service) []*registry.Service {
 	}
 	return services
 }
+
+// registered returns true if the service and all its nodes are
+// already registered unchanged, e.g. when a ttl is renewed
+func registered(old []*registry.Service, s *registry.Service) bool {
+	for _, o := range old {
+		if o.Version != s.Version {
+			continue
+		}
+		if !reflect.DeepEqual(o.Metadata, s.Metadata) || !reflect.DeepEqual(o.Endpoints, s.Endpoints) {
+			return false
+		}
+		for _, n := range s.Nodes {
+			var seen bool
+			for _, on := range o.Nodes {
+				if on.Id == n.Id {
+					seen = reflect.DeepEqual(on, n)
+					break
+				}
+			}
+			if !seen {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
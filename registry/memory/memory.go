@@ -6,21 +6,43 @@ import (
 	"sync"
 	"time"
 
+	"github.com/micro/go-log"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
 	"github.com/pborman/uuid"
 )
 
 type memoryRegistry struct {
-	options registry.Options
+	options  registry.Options
+	clock    Clock
+	snapshot string
 
 	sync.RWMutex
 	services map[string][]*registry.Service
 	watchers map[string]*memoryWatcher
+	// expiry of nodes registered with a ttl
+	expires map[nodeKey]time.Time
+
+	// whether the reaper is running
+	reaping bool
+
+	// serialises snapshot writes
+	mtx  sync.Mutex
+	exit chan bool
+	once sync.Once
+}
+
+type nodeKey struct {
+	service string
+	version string
+	id      string
 }
 
 var (
 	timeout = time.Millisecond * 10
+
+	// reapInterval is how often expired nodes are removed
+	reapInterval = time.Second
 )
 
 func init() {
@@ -51,11 +73,97 @@ func (m *memoryRegistry) watch(r *registry.Result) {
 	}
 }
 
+// expire removes the nodes whose ttl has passed
+func (m *memoryRegistry) expire() {
+	now := m.clock.Now()
+
+	var expired []*registry.Service
+
+	m.Lock()
+	for key, t := range m.expires {
+		if now.Before(t) {
+			continue
+		}
+		delete(m.expires, key)
+
+		for _, s := range m.services[key.service] {
+			if s.Version != key.version {
+				continue
+			}
+			for _, n := range s.Nodes {
+				if n.Id != key.id {
+					continue
+				}
+				expired = append(expired, &registry.Service{
+					Name:      s.Name,
+					Version:   s.Version,
+					Metadata:  s.Metadata,
+					Endpoints: s.Endpoints,
+					Nodes:     []*registry.Node{n},
+				})
+			}
+		}
+	}
+	for _, s := range expired {
+		m.services[s.Name] = delServices(m.services[s.Name], []*registry.Service{s})
+	}
+	m.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+
+	for _, s := range expired {
+		go m.watch(&registry.Result{Action: "delete", Service: s})
+	}
+
+	m.persist()
+}
+
+// reaper expires nodes in the background so watchers are told of
+// expiry without the registry being read. It stops once no nodes
+// registered with a ttl remain or the registry is closed.
+func (m *memoryRegistry) reaper() {
+	t := time.NewTicker(reapInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.exit:
+			m.Lock()
+			m.reaping = false
+			m.Unlock()
+			return
+		case <-t.C:
+			m.expire()
+		}
+
+		m.Lock()
+		if len(m.expires) == 0 {
+			m.reaping = false
+			m.Unlock()
+			return
+		}
+		m.Unlock()
+	}
+}
+
+// reap starts the reaper if it isn't running, the lock must be held
+func (m *memoryRegistry) reap() {
+	if m.reaping {
+		return
+	}
+	m.reaping = true
+	go m.reaper()
+}
+
 func (m *memoryRegistry) Options() registry.Options {
 	return m.options
 }
 
 func (m *memoryRegistry) GetService(service string) ([]*registry.Service, error) {
+	m.expire()
+
 	m.RLock()
 	s, ok := m.services[service]
 	if !ok || len(s) == 0 {
@@ -67,6 +175,8 @@ func (m *memoryRegistry) GetService(service string) ([]*registry.Service, error)
 }
 
 func (m *memoryRegistry) ListServices() ([]*registry.Service, error) {
+	m.expire()
+
 	m.RLock()
 	var services []*registry.Service
 	for _, service := range m.services {
@@ -77,12 +187,41 @@ func (m *memoryRegistry) ListServices() ([]*registry.Service, error) {
 }
 
 func (m *memoryRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	var options registry.RegisterOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
 	go m.watch(&registry.Result{Action: "update", Service: s})
 
+	// the registered nodes, s.Nodes is merged with existing ones
+	nodes := s.Nodes
+
 	m.Lock()
+	// renewing a ttl doesn't change the snapshot
+	changed := !registered(m.services[s.Name], s)
 	services := addServices(m.services[s.Name], []*registry.Service{s})
 	m.services[s.Name] = services
+	for _, n := range nodes {
+		key := nodeKey{s.Name, s.Version, n.Id}
+		// adding or removing a ttl does
+		if _, ok := m.expires[key]; ok != (options.TTL > 0) {
+			changed = true
+		}
+		if options.TTL > 0 {
+			m.expires[key] = m.clock.Now().Add(options.TTL)
+		} else {
+			delete(m.expires, key)
+		}
+	}
+	if options.TTL > 0 {
+		m.reap()
+	}
 	m.Unlock()
+
+	if changed {
+		m.persist()
+	}
 	return nil
 }
 
@@ -90,9 +229,14 @@ func (m *memoryRegistry) Deregister(s *registry.Service) error {
 	go m.watch(&registry.Result{Action: "delete", Service: s})
 
 	m.Lock()
+	for _, n := range s.Nodes {
+		delete(m.expires, nodeKey{s.Name, s.Version, n.Id})
+	}
 	services := delServices(m.services[s.Name], []*registry.Service{s})
 	m.services[s.Name] = services
 	m.Unlock()
+
+	m.persist()
 	return nil
 }
 
@@ -119,6 +263,15 @@ func (m *memoryRegistry) String() string {
 	return "memory"
 }
 
+// Close stops expiring nodes in the background. Nodes registered with a
+// ttl are still expired when the registry is read.
+func (m *memoryRegistry) Close() error {
+	m.once.Do(func() {
+		close(m.exit)
+	})
+	return nil
+}
+
 func NewRegistry(opts ...registry.Option) registry.Registry {
 	options := registry.Options{
 		Context: context.Background(),
@@ -133,9 +286,27 @@ func NewRegistry(opts ...registry.Option) registry.Registry {
 		services = make(map[string][]*registry.Service)
 	}
 
-	return &memoryRegistry{
+	m := &memoryRegistry{
 		options:  options,
+		clock:    getClock(options.Context),
+		snapshot: getSnapshot(options.Context),
 		services: services,
 		watchers: make(map[string]*memoryWatcher),
+		expires:  make(map[nodeKey]time.Time),
+		exit:     make(chan bool),
 	}
+
+	if len(m.snapshot) > 0 {
+		if err := m.restore(); err != nil {
+			log.Logf("[memory] failed to restore snapshot %s: %v", m.snapshot, err)
+		}
+	}
+
+	m.Lock()
+	if len(m.expires) > 0 {
+		m.reap()
+	}
+	m.Unlock()
+
+	return m
 }
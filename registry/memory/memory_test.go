@@ -1,7 +1,11 @@
 package memory
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/registry"
 )
@@ -130,3 +134,215 @@ func TestMemoryRegistry(t *testing.T) {
 		}
 	}
 }
+
+func TestTTL(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	m := NewRegistry(WithClock(clock))
+
+	s := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "foo-1", Address: "localhost", Port: 9999},
+		},
+	}
+	p := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "foo-2", Address: "localhost", Port: 9998},
+		},
+	}
+
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	// registered without a ttl so never expires
+	if err := m.Register(p); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(59 * time.Second)
+	services, err := m.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services[0].Nodes) != 2 {
+		t.Fatalf("expected 2 nodes before expiry, got %d", len(services[0].Nodes))
+	}
+
+	// re-registering renews the ttl
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(59 * time.Second)
+	services, _ = m.GetService("foo")
+	if len(services[0].Nodes) != 2 {
+		t.Fatalf("expected renewed node, got %d nodes", len(services[0].Nodes))
+	}
+
+	clock.Advance(time.Second)
+	services, _ = m.GetService("foo")
+	if len(services[0].Nodes) != 1 || services[0].Nodes[0].Id != "foo-2" {
+		t.Fatalf("expected only foo-2 after expiry, got %+v", services[0].Nodes)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "registry.json")
+	clock := NewManualClock(time.Now())
+
+	m := NewRegistry(Snapshot(path), WithClock(clock))
+
+	// registering and deregistering shares the service, so use new ones
+	for _, version := range []string{"1.0.0", "1.0.1"} {
+		if err := m.Register(&registry.Service{
+			Name:    "foo",
+			Version: version,
+			Nodes: []*registry.Node{
+				{Id: "foo-" + version, Address: "localhost", Port: 9999},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expiring := &registry.Service{
+		Name:    "baz",
+		Version: "latest",
+		Nodes: []*registry.Node{
+			{Id: "baz-1", Address: "localhost", Port: 9999},
+		},
+	}
+	if err := m.Register(expiring, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// restore into a new registry
+	r := NewRegistry(Snapshot(path), WithClock(clock))
+
+	services, err := r.GetService("foo")
+	if err != nil {
+		t.Fatalf("expected foo to be restored: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected 2 versions of foo, got %d", len(services))
+	}
+	if _, err := r.GetService("baz"); err != nil {
+		t.Fatalf("expected baz to be restored: %v", err)
+	}
+
+	// the ttl is restored with it
+	clock.Advance(time.Minute)
+	if _, err := r.GetService("baz"); err != registry.ErrNotFound {
+		t.Fatalf("expected baz to expire, got %v", err)
+	}
+
+	// expired registrations aren't restored
+	m.ListServices()
+	r = NewRegistry(Snapshot(path), WithClock(clock))
+	if _, err := r.GetService("baz"); err != registry.ErrNotFound {
+		t.Fatalf("expected expired baz not to be restored, got %v", err)
+	}
+}
+
+func TestSnapshotRenew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "registry.json")
+	m := NewRegistry(Snapshot(path))
+	defer m.(*memoryRegistry).Close()
+
+	s := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "foo-1", Address: "localhost", Port: 9999},
+		},
+	}
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected snapshot to be written: %v", err)
+	}
+
+	// renewing the ttl doesn't rewrite the snapshot
+	os.Remove(path)
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no snapshot write on renewal, got %v", err)
+	}
+
+	// a changed node does
+	s.Nodes = []*registry.Node{
+		{Id: "foo-1", Address: "localhost", Port: 9998},
+	}
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected snapshot to be written on change: %v", err)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	interval := reapInterval
+	reapInterval = time.Millisecond
+	defer func() {
+		reapInterval = interval
+	}()
+
+	reaping := func(m *memoryRegistry) bool {
+		m.RLock()
+		defer m.RUnlock()
+		return m.reaping
+	}
+
+	m := NewRegistry().(*memoryRegistry)
+
+	s := &registry.Service{
+		Name:    "foo",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "foo-1", Address: "localhost", Port: 9999},
+		},
+	}
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if !reaping(m) {
+		t.Fatal("expected the reaper to run")
+	}
+
+	// stops once no ttl registrations remain
+	if err := m.Deregister(s); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if reaping(m) {
+		t.Fatal("expected the reaper to stop without ttl registrations")
+	}
+
+	// and when closed
+	if err := m.Register(s, registry.RegisterTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	m.Close()
+	time.Sleep(20 * time.Millisecond)
+	if reaping(m) {
+		t.Fatal("expected the reaper to stop when closed")
+	}
+}
@@ -25,3 +25,42 @@ func Services(s map[string][]*registry.Service) registry.Option {
 		o.Context = context.WithValue(o.Context, servicesKey{}, s)
 	}
 }
+
+type clockKey struct{}
+
+type snapshotKey struct{}
+
+func getClock(ctx context.Context) Clock {
+	c, ok := ctx.Value(clockKey{}).(Clock)
+	if !ok {
+		return realClock{}
+	}
+	return c
+}
+
+func getSnapshot(ctx context.Context) string {
+	s, _ := ctx.Value(snapshotKey{}).(string)
+	return s
+}
+
+// WithClock sets the clock registrations with a TTL expire against,
+// pass a ManualClock to test expiry deterministically
+func WithClock(c Clock) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, clockKey{}, c)
+	}
+}
+
+// Snapshot restores the registry from the file at path on start and
+// writes it back on every change, persisting it across restarts
+func Snapshot(path string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, snapshotKey{}, path)
+	}
+}
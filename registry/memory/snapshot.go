@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+)
+
+// entry is a snapshotted registration of a single node
+type entry struct {
+	Service *registry.Service `json:"service"`
+	Expires *time.Time        `json:"expires,omitempty"`
+}
+
+// entries returns the registrations as single node entries
+func (m *memoryRegistry) entries() []*entry {
+	m.RLock()
+	defer m.RUnlock()
+
+	var entries []*entry
+
+	for name, services := range m.services {
+		for _, s := range services {
+			for _, n := range s.Nodes {
+				e := &entry{
+					Service: &registry.Service{
+						Name:      s.Name,
+						Version:   s.Version,
+						Metadata:  s.Metadata,
+						Endpoints: s.Endpoints,
+						Nodes:     []*registry.Node{n},
+					},
+				}
+				if t, ok := m.expires[nodeKey{name, s.Version, n.Id}]; ok {
+					e.Expires = &t
+				}
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	return entries
+}
+
+// persist writes the snapshot if enabled, replacing
+// the file so a crash never leaves it half written
+func (m *memoryRegistry) persist() {
+	if len(m.snapshot) == 0 {
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	b, err := json.Marshal(m.entries())
+	if err != nil {
+		log.Logf("[memory] failed to encode snapshot: %v", err)
+		return
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.snapshot), filepath.Base(m.snapshot))
+	if err != nil {
+		log.Logf("[memory] failed to write snapshot: %v", err)
+		return
+	}
+
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), m.snapshot)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Logf("[memory] failed to write snapshot: %v", err)
+	}
+}
+
+// restore loads the snapshot, skipping expired registrations
+func (m *memoryRegistry) restore() error {
+	b, err := ioutil.ReadFile(m.snapshot)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var entries []*entry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	now := m.clock.Now()
+
+	m.Lock()
+	defer m.Unlock()
+
+	for _, e := range entries {
+		if e.Service == nil || len(e.Service.Nodes) == 0 {
+			continue
+		}
+		if e.Expires != nil && !now.Before(*e.Expires) {
+			continue
+		}
+
+		n := e.Service.Nodes[0]
+
+		m.services[e.Service.Name] = addServices(m.services[e.Service.Name], []*registry.Service{e.Service})
+
+		if e.Expires != nil {
+			m.expires[nodeKey{e.Service.Name, e.Service.Version, n.Id}] = *e.Expires
+		}
+	}
+
+	return nil
+}
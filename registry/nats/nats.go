@@ -10,7 +10,7 @@ import (
 
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
-	"github.com/nats-io/go-nats"
+	"github.com/nats-io/nats.go"
 )
 
 type natsRegistry struct {
@@ -63,6 +63,10 @@ func (n *natsRegistry) newConn() (*nats.Conn, error) {
 		opts.Secure = true
 	}
 
+	if err := applyConnOptions(n.opts, &opts); err != nil {
+		return nil, err
+	}
+
 	return opts.Connect()
 }
 
@@ -4,7 +4,7 @@ import (
 	"context"
 
 	"github.com/micro/go-micro/registry"
-	"github.com/nats-io/go-nats"
+	"github.com/nats-io/nats.go"
 )
 
 type contextQuorumKey struct{}
@@ -70,3 +70,52 @@ func WatchTopic(s string) registry.Option {
 		o.Context = context.WithValue(o.Context, watchTopicKey{}, s)
 	}
 }
+
+type connOptionsKey struct{}
+
+func connOption(opt nats.Option) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		prev, _ := o.Context.Value(connOptionsKey{}).([]nats.Option)
+		opts := append(prev[:len(prev):len(prev)], opt)
+		o.Context = context.WithValue(o.Context, connOptionsKey{}, opts)
+	}
+}
+
+// applyConnOptions applies the connection options to the nats options
+func applyConnOptions(o registry.Options, nopts *nats.Options) error {
+	if o.Context == nil {
+		return nil
+	}
+	opts, _ := o.Context.Value(connOptionsKey{}).([]nats.Option)
+	for _, opt := range opts {
+		if err := opt(nopts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Credentials authenticates with the user JWT and nkey seed
+// in a NATS 2.x .creds file, as issued by NGS
+func Credentials(file string) registry.Option {
+	return connOption(nats.UserCredentials(file))
+}
+
+// JWT authenticates with the user JWT and nkey seed
+func JWT(jwt, seed string) registry.Option {
+	return connOption(nats.UserJWTAndSeed(jwt, seed))
+}
+
+// TLSHandshakeFirst performs the TLS handshake before the server
+// sends its INFO, for servers configured with handshake_first
+func TLSHandshakeFirst() registry.Option {
+	return connOption(nats.TLSHandshakeFirst())
+}
+
+// Name sets the connection name shown in the server's monitoring
+func Name(name string) registry.Option {
+	return connOption(nats.Name(name))
+}
@@ -0,0 +1,70 @@
+package region
+
+import (
+	"os"
+)
+
+// Options for the region aware registry
+type Options struct {
+	// Region of the local services, defaults to the REGION env var
+	Region string
+	// Failover is the ordered list of remote regions to fail over to
+	Failover []string
+	// MinNodes is the local capacity below which remote regions are used
+	MinNodes int
+	// Key is the node metadata key holding the region
+	Key string
+}
+
+// Option sets an option
+type Option func(*Options)
+
+var (
+	// DefaultKey is the default node metadata key holding the region
+	DefaultKey = "region"
+	// DefaultMinNodes is the default local capacity threshold
+	DefaultMinNodes = 1
+)
+
+// Region sets the local region
+func Region(r string) Option {
+	return func(o *Options) {
+		o.Region = r
+	}
+}
+
+// Failover sets the ordered list of remote regions used when
+// the local region has fewer than MinNodes nodes
+func Failover(regions ...string) Option {
+	return func(o *Options) {
+		o.Failover = regions
+	}
+}
+
+// MinNodes sets how many nodes of a service must be available in the
+// local region before remote regions are no longer used. Remote regions
+// are added in failover order until the threshold is met.
+func MinNodes(n int) Option {
+	return func(o *Options) {
+		o.MinNodes = n
+	}
+}
+
+// Key sets the node metadata key holding the region
+func Key(k string) Option {
+	return func(o *Options) {
+		o.Key = k
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Region:   os.Getenv("REGION"),
+		MinNodes: DefaultMinNodes,
+		Key:      DefaultKey,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
@@ -0,0 +1,140 @@
+// Package region provides a registry decorator which annotates nodes with
+// their region and filters lookups to the local region, failing over to an
+// ordered list of remote regions when local capacity is too low.
+package region
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+type regionRegistry struct {
+	registry.Registry
+	opts Options
+}
+
+// region returns the region of the node, nodes
+// without a region are treated as local
+func (r *regionRegistry) region(node *registry.Node) string {
+	if node.Metadata == nil {
+		return r.opts.Region
+	}
+	if v, ok := node.Metadata[r.opts.Key]; ok && len(v) > 0 {
+		return v
+	}
+	return r.opts.Region
+}
+
+// regions returns the regions to use for the services, the local region
+// followed by failover regions until there are at least MinNodes nodes
+func (r *regionRegistry) regions(services []*registry.Service) map[string]bool {
+	count := make(map[string]int)
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			count[r.region(node)]++
+		}
+	}
+
+	regions := map[string]bool{r.opts.Region: true}
+	nodes := count[r.opts.Region]
+
+	for _, region := range r.opts.Failover {
+		if nodes >= r.opts.MinNodes {
+			break
+		}
+		if regions[region] {
+			continue
+		}
+		regions[region] = true
+		nodes += count[region]
+	}
+
+	return regions
+}
+
+// filter returns copies of the services with only nodes in the regions
+func (r *regionRegistry) filter(services []*registry.Service, regions map[string]bool) []*registry.Service {
+	var filtered []*registry.Service
+
+	for _, service := range services {
+		var nodes []*registry.Node
+		for _, node := range service.Nodes {
+			if regions[r.region(node)] {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+		s := *service
+		s.Nodes = nodes
+		filtered = append(filtered, &s)
+	}
+
+	return filtered
+}
+
+// annotate returns a copy of the service with the
+// local region set on nodes without a region
+func (r *regionRegistry) annotate(s *registry.Service) *registry.Service {
+	if len(r.opts.Region) == 0 {
+		return s
+	}
+
+	service := *s
+	service.Nodes = make([]*registry.Node, 0, len(s.Nodes))
+
+	for _, n := range s.Nodes {
+		node := *n
+		node.Metadata = make(map[string]string, len(n.Metadata)+1)
+		for k, v := range n.Metadata {
+			node.Metadata[k] = v
+		}
+		if len(node.Metadata[r.opts.Key]) == 0 {
+			node.Metadata[r.opts.Key] = r.opts.Region
+		}
+		service.Nodes = append(service.Nodes, &node)
+	}
+
+	return &service
+}
+
+func (r *regionRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	return r.Registry.Register(r.annotate(s), opts...)
+}
+
+func (r *regionRegistry) Deregister(s *registry.Service) error {
+	return r.Registry.Deregister(r.annotate(s))
+}
+
+// GetService returns the service with nodes filtered to the local
+// region and any failover regions needed to meet MinNodes
+func (r *regionRegistry) GetService(name string) ([]*registry.Service, error) {
+	services, err := r.Registry.GetService(name)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := r.filter(services, r.regions(services))
+	if len(filtered) == 0 {
+		return nil, registry.ErrNotFound
+	}
+
+	return filtered, nil
+}
+
+func (r *regionRegistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	w, err := r.Registry.Watch(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newWatcher(r, w), nil
+}
+
+// NewRegistry wraps the registry so registered nodes are annotated
+// with the local region and lookups prefer the local region
+func NewRegistry(r registry.Registry, opts ...Option) registry.Registry {
+	return &regionRegistry{
+		Registry: r,
+		opts:     newOptions(opts...),
+	}
+}
@@ -0,0 +1,193 @@
+package region
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-plugins/registry/memory"
+)
+
+type testWatcher struct {
+	results chan *registry.Result
+}
+
+func (t *testWatcher) Next() (*registry.Result, error) {
+	r, ok := <-t.results
+	if !ok {
+		return nil, errors.New("closed")
+	}
+	return r, nil
+}
+
+func (t *testWatcher) Stop() {}
+
+// testRegistry counts lookups
+type testRegistry struct {
+	registry.Registry
+	lookups int
+}
+
+func (t *testRegistry) GetService(name string) ([]*registry.Service, error) {
+	t.lookups++
+	return t.Registry.GetService(name)
+}
+
+func node(id, region string) *registry.Node {
+	return &registry.Node{
+		Id:       id,
+		Address:  "10.0.0.1",
+		Metadata: map[string]string{"region": region},
+	}
+}
+
+func service(nodes ...*registry.Node) *registry.Service {
+	return &registry.Service{
+		Name:    "foo",
+		Version: "1",
+		Nodes:   nodes,
+	}
+}
+
+func ids(services []*registry.Service) string {
+	var ids []string
+	for _, s := range services {
+		for _, n := range s.Nodes {
+			ids = append(ids, n.Id)
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func TestGetService(t *testing.T) {
+	testData := []struct {
+		name   string
+		nodes  []*registry.Node
+		expect string
+	}{
+		{"local", []*registry.Node{node("eu1", "eu"), node("eu2", "eu"), node("us1", "us")}, "eu1,eu2"},
+		{"failover", []*registry.Node{node("eu1", "eu"), node("us1", "us"), node("ap1", "ap")}, "ap1,eu1,us1"},
+		{"order", []*registry.Node{node("us1", "us"), node("us2", "us"), node("ap1", "ap")}, "us1,us2"},
+		{"unlisted", []*registry.Node{node("sa1", "sa")}, ""},
+	}
+
+	for _, d := range testData {
+		m := memory.NewRegistry()
+		r := NewRegistry(m, Region("eu"), Failover("us", "ap"), MinNodes(2))
+
+		if err := m.Register(service(d.nodes...)); err != nil {
+			t.Fatal(err)
+		}
+
+		services, err := r.GetService("foo")
+		if len(d.expect) == 0 {
+			if err != registry.ErrNotFound {
+				t.Fatalf("%s: expected not found got %v", d.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", d.name, err)
+		}
+		if got := ids(services); got != d.expect {
+			t.Fatalf("%s: expected %s got %s", d.name, d.expect, got)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	m := memory.NewRegistry()
+	r := NewRegistry(m, Region("eu"))
+
+	s := service(&registry.Node{Id: "eu1"}, node("us1", "us"))
+	if err := r.Register(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Nodes[0].Metadata != nil {
+		t.Fatal("expected registered service not to be modified")
+	}
+
+	services, err := m.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regions := make(map[string]string)
+	for _, n := range services[0].Nodes {
+		regions[n.Id] = n.Metadata["region"]
+	}
+	if regions["eu1"] != "eu" || regions["us1"] != "us" {
+		t.Fatalf("unexpected regions %v", regions)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	m := &testRegistry{Registry: memory.NewRegistry()}
+	r := &regionRegistry{
+		Registry: m,
+		opts:     newOptions(Region("eu"), Failover("us")),
+	}
+
+	tw := &testWatcher{results: make(chan *registry.Result, 3)}
+	w := newWatcher(r, tw)
+
+	next := func() string {
+		res, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Action + ":" + ids([]*registry.Service{res.Service})
+	}
+
+	// local and remote nodes, the remote node is filtered
+	m.Register(service(node("eu1", "eu"), node("us1", "us")))
+	tw.results <- &registry.Result{Action: "create", Service: service(node("eu1", "eu"), node("us1", "us"))}
+	if got := next(); got != "create:eu1" {
+		t.Fatalf("expected create:eu1 got %s", got)
+	}
+
+	// the local node goes away so the remote region is added
+	m.Deregister(service(node("eu1", "eu")))
+	tw.results <- &registry.Result{Action: "delete", Service: service(node("eu1", "eu"))}
+	if got := next(); got != "delete:eu1" {
+		t.Fatalf("expected delete:eu1 got %s", got)
+	}
+	if got := next(); got != "create:us1" {
+		t.Fatalf("expected create:us1 got %s", got)
+	}
+
+	// the local node comes back so the remote region is removed
+	m.Register(service(node("eu2", "eu")))
+	tw.results <- &registry.Result{Action: "create", Service: service(node("eu2", "eu"))}
+	if got := next(); got != "create:eu2" {
+		t.Fatalf("expected create:eu2 got %s", got)
+	}
+	if got := next(); got != "delete:us1" {
+		t.Fatalf("expected delete:us1 got %s", got)
+	}
+
+	// the service is only looked up the first time it's seen
+	if m.lookups != 1 {
+		t.Fatalf("expected 1 lookup got %d", m.lookups)
+	}
+}
+
+func TestApply(t *testing.T) {
+	services := apply(nil, &registry.Result{Action: "create", Service: service(node("eu1", "eu"))})
+	services = apply(services, &registry.Result{Action: "update", Service: service(node("eu2", "eu"))})
+	if got := ids(services); got != "eu1,eu2" {
+		t.Fatalf("expected eu1,eu2 got %s", got)
+	}
+
+	v2 := service(node("eu3", "eu"))
+	v2.Version = "2"
+	services = apply(services, &registry.Result{Action: "create", Service: v2})
+	services = apply(services, &registry.Result{Action: "delete", Service: service(node("eu1", "eu"), node("eu2", "eu"))})
+	if got := ids(services); got != "eu3" || len(services) != 1 {
+		t.Fatalf("expected eu3 got %s", got)
+	}
+}
@@ -0,0 +1,178 @@
+package region
+
+import (
+	"github.com/micro/go-micro/registry"
+)
+
+// watcher filters results to the regions in use for each service. When
+// failover changes the regions in use it sends creates for the nodes of
+// added regions and deletes for the nodes of removed regions so
+// downstream caches follow the same preference as GetService.
+type watcher struct {
+	r *regionRegistry
+	w registry.Watcher
+
+	// regions in use per service
+	regions map[string]map[string]bool
+	// services as last seen, built from the results
+	services map[string][]*registry.Service
+	// results waiting to be returned
+	queue []*registry.Result
+}
+
+func newWatcher(r *regionRegistry, w registry.Watcher) registry.Watcher {
+	return &watcher{
+		r:        r,
+		w:        w,
+		regions:  make(map[string]map[string]bool),
+		services: make(map[string][]*registry.Service),
+	}
+}
+
+// apply returns copies of the services with the result applied
+func apply(services []*registry.Service, res *registry.Result) []*registry.Service {
+	var applied []*registry.Service
+	var found bool
+
+	for _, service := range services {
+		if service.Version != res.Service.Version {
+			applied = append(applied, service)
+			continue
+		}
+		found = true
+
+		ids := make(map[string]bool, len(res.Service.Nodes))
+		for _, node := range res.Service.Nodes {
+			ids[node.Id] = true
+		}
+
+		s := *service
+		s.Nodes = nil
+		for _, node := range service.Nodes {
+			if !ids[node.Id] {
+				s.Nodes = append(s.Nodes, node)
+			}
+		}
+		if res.Action != "delete" {
+			s.Nodes = append(s.Nodes, res.Service.Nodes...)
+			if len(res.Service.Endpoints) > 0 {
+				s.Endpoints = res.Service.Endpoints
+			}
+		}
+		if len(s.Nodes) > 0 {
+			applied = append(applied, &s)
+		}
+	}
+
+	if !found && res.Action != "delete" && len(res.Service.Nodes) > 0 {
+		applied = append(applied, res.Service)
+	}
+
+	return applied
+}
+
+// update applies the result to the service as last seen. The registry is
+// only asked for the service the first time it's seen, later results are
+// changes to it.
+func (w *watcher) update(res *registry.Result) []*registry.Service {
+	name := res.Service.Name
+
+	services, ok := w.services[name]
+	if !ok {
+		services, _ = w.r.Registry.GetService(name)
+	}
+
+	services = apply(services, res)
+	if len(services) == 0 {
+		delete(w.services, name)
+	} else {
+		w.services[name] = services
+	}
+
+	return services
+}
+
+func (w *watcher) push(action string, services []*registry.Service) {
+	for _, service := range services {
+		w.queue = append(w.queue, &registry.Result{
+			Action:  action,
+			Service: service,
+		})
+	}
+}
+
+func (w *watcher) process(res *registry.Result) {
+	if res.Service == nil {
+		w.queue = append(w.queue, res)
+		return
+	}
+
+	name := res.Service.Name
+
+	// the service as it is now to work out the regions in use
+	services := w.update(res)
+
+	current := w.r.regions(services)
+	prev, ok := w.regions[name]
+	if !ok {
+		prev = current
+	}
+
+	if len(services) == 0 {
+		delete(w.regions, name)
+	} else {
+		w.regions[name] = current
+	}
+
+	// deletes pass for nodes downstream may still hold
+	regions := current
+	if res.Action == "delete" {
+		regions = make(map[string]bool, len(prev)+len(current))
+		for region := range prev {
+			regions[region] = true
+		}
+		for region := range current {
+			regions[region] = true
+		}
+	}
+	w.push(res.Action, w.r.filter([]*registry.Service{res.Service}, regions))
+
+	added := make(map[string]bool)
+	for region := range current {
+		if !prev[region] {
+			added[region] = true
+		}
+	}
+
+	removed := make(map[string]bool)
+	for region := range prev {
+		if !current[region] {
+			removed[region] = true
+		}
+	}
+
+	if len(added) > 0 {
+		w.push("create", w.r.filter(services, added))
+	}
+	if len(removed) > 0 {
+		w.push("delete", w.r.filter(services, removed))
+	}
+}
+
+func (w *watcher) Next() (*registry.Result, error) {
+	for len(w.queue) == 0 {
+		res, err := w.w.Next()
+		if err != nil {
+			return nil, err
+		}
+		w.process(res)
+	}
+
+	res := w.queue[0]
+	w.queue = w.queue[1:]
+	return res, nil
+}
+
+func (w *watcher) Stop() {
+	w.w.Stop()
+}
@@ -0,0 +1,193 @@
+// Package canary is a selector wrapper which splits traffic between the
+// versions of a service by weight. Weights are loaded from a go-config
+// source and updated as it changes, so canary releases can be driven
+// entirely by configuration.
+package canary
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+var (
+	// DefaultPath of the weights in the config
+	DefaultPath = []string{"canary"}
+)
+
+type canarySelector struct {
+	selector.Selector
+	opts Options
+	exit chan bool
+
+	sync.RWMutex
+	weights map[string]Weights
+}
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["canary"] = NewSelector
+}
+
+// pick returns a version chosen by weight
+func (w Weights) pick() (string, bool) {
+	var total int
+	for _, v := range w {
+		if v > 0 {
+			total += v
+		}
+	}
+	if total == 0 {
+		return "", false
+	}
+
+	n := rand.Intn(total)
+	for version, v := range w {
+		if v <= 0 {
+			continue
+		}
+		n -= v
+		if n < 0 {
+			return version, true
+		}
+	}
+
+	return "", false
+}
+
+// filter returns the services of the version, or all of them if
+// the version has no nodes so traffic isn't dropped
+func filter(version string) selector.Filter {
+	return func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+		for _, service := range services {
+			if service.Version == version && len(service.Nodes) > 0 {
+				filtered = append(filtered, service)
+			}
+		}
+		if len(filtered) == 0 {
+			return services
+		}
+		return filtered
+	}
+}
+
+func (c *canarySelector) update(weights map[string]Weights) {
+	merged := make(map[string]Weights, len(c.opts.Weights)+len(weights))
+	for k, v := range c.opts.Weights {
+		merged[k] = v
+	}
+	for k, v := range weights {
+		merged[k] = v
+	}
+
+	c.Lock()
+	c.weights = merged
+	c.Unlock()
+}
+
+func (c *canarySelector) run(conf config.Config) {
+	w, err := conf.Watch(c.opts.Path...)
+	if err != nil {
+		log.Logf("[canary] failed to watch weights: %v", err)
+		return
+	}
+
+	go func() {
+		<-c.exit
+		w.Stop()
+	}()
+
+	for {
+		v, err := w.Next()
+		if err != nil {
+			select {
+			case <-c.exit:
+				return
+			default:
+			}
+			log.Logf("[canary] watcher error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var weights map[string]Weights
+		if err := v.Scan(&weights); err != nil {
+			log.Logf("[canary] failed to scan weights, skipping update: %v", err)
+			continue
+		}
+
+		c.update(weights)
+	}
+}
+
+func (c *canarySelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	c.RLock()
+	w := c.weights[service]
+	c.RUnlock()
+
+	if version, ok := w.pick(); ok {
+		opts = append(opts, selector.WithFilter(filter(version)))
+	}
+
+	return c.Selector.Select(service, opts...)
+}
+
+func (c *canarySelector) Close() error {
+	select {
+	case <-c.exit:
+	default:
+		close(c.exit)
+	}
+	return c.Selector.Close()
+}
+
+func (c *canarySelector) String() string {
+	return "canary"
+}
+
+// Wrap adds weighted version routing to the selector
+func Wrap(s selector.Selector, opts ...Option) selector.Selector {
+	options := Options{
+		Path: DefaultPath,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	c := &canarySelector{
+		Selector: s,
+		opts:     options,
+		exit:     make(chan bool),
+	}
+
+	var weights map[string]Weights
+	if options.Config != nil {
+		if err := options.Config.Get(options.Path...).Scan(&weights); err != nil {
+			log.Logf("[canary] failed to load weights: %v", err)
+		}
+	}
+
+	// apply the initial weights before watching so an early
+	// change isn't overwritten by them
+	c.update(weights)
+
+	if options.Config != nil {
+		go c.run(options.Config)
+	}
+
+	return c
+}
+
+// NewSelector returns the default selector with static weights, use
+// Wrap to load them from config
+func NewSelector(opts ...selector.Option) selector.Selector {
+	return Wrap(selector.NewSelector(opts...))
+}
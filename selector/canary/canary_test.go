@@ -0,0 +1,69 @@
+package canary
+
+import (
+	"testing"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-config/source/memory"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+	"github.com/micro/go-micro/selector"
+)
+
+func testSelector(t *testing.T, opts ...Option) selector.Selector {
+	r := mock.NewRegistry()
+
+	for _, v := range []string{"1.2", "1.3"} {
+		err := r.Register(&registry.Service{
+			Name:    "canary.test",
+			Version: v,
+			Nodes: []*registry.Node{
+				{Id: "node-" + v, Address: "localhost", Port: 8080},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return Wrap(selector.NewSelector(selector.Registry(r)), opts...)
+}
+
+func count(t *testing.T, s selector.Selector) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		next, err := s.Select("canary.test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[node.Id]++
+	}
+	return counts
+}
+
+func TestWeights(t *testing.T) {
+	s := testSelector(t, WithWeights("canary.test", Weights{"1.2": 100}))
+	defer s.Close()
+
+	if c := count(t, s); c["node-1.3"] != 0 {
+		t.Fatalf("expected no traffic to 1.3 got %v", c)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	data := []byte(`{"canary": {"canary.test": {"1.2": 90, "1.3": 10}}}`)
+
+	c := config.NewConfig(config.WithSource(memory.NewSource(memory.WithData(data))))
+
+	s := testSelector(t, Config(c))
+	defer s.Close()
+
+	counts := count(t, s)
+	if counts["node-1.3"] == 0 || counts["node-1.3"] > 200 {
+		t.Fatalf("expected around 10%% traffic to 1.3 got %v", counts)
+	}
+}
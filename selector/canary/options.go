@@ -0,0 +1,45 @@
+package canary
+
+import (
+	"github.com/micro/go-config"
+)
+
+// Weights is the traffic weight of each version of a service,
+// e.g. {"1.2": 95, "1.3": 5}
+type Weights map[string]int
+
+// Options for the canary selector
+type Options struct {
+	// Config the weights are loaded from and watched
+	Config config.Config
+	// Path of the weights within the config, keyed by service name
+	Path []string
+	// Weights set statically, overridden by the config
+	Weights map[string]Weights
+}
+
+type Option func(*Options)
+
+// Config sets the config the weights are loaded from
+func Config(c config.Config) Option {
+	return func(o *Options) {
+		o.Config = c
+	}
+}
+
+// Path sets the path of the weights within the config
+func Path(path ...string) Option {
+	return func(o *Options) {
+		o.Path = path
+	}
+}
+
+// WithWeights sets the weights for a service
+func WithWeights(service string, w Weights) Option {
+	return func(o *Options) {
+		if o.Weights == nil {
+			o.Weights = make(map[string]Weights)
+		}
+		o.Weights[service] = w
+	}
+}
@@ -1,8 +1,12 @@
-// Package dns provides a dns SRV selector
+// Package dns provides a dns SRV selector. Records are cached for their
+// ttl, nodes are picked by SRV priority and weight and multiple resolvers
+// can be configured for failover.
 package dns
 
 import (
-	"net"
+	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
@@ -10,8 +14,9 @@ import (
 )
 
 type dnsSelector struct {
-	options selector.Options
-	domain  string
+	options  selector.Options
+	domain   string
+	resolver *resolver
 }
 
 var (
@@ -19,9 +24,22 @@ var (
 )
 
 func init() {
+	rand.Seed(time.Now().UnixNano())
 	cmd.DefaultSelectors["dns"] = NewSelector
 }
 
+func newResolverFromOptions(options selector.Options) *resolver {
+	var servers []string
+	var t ttl
+
+	if options.Context != nil {
+		servers, _ = options.Context.Value(resolversKey{}).([]string)
+		t, _ = options.Context.Value(ttlKey{}).(ttl)
+	}
+
+	return newResolver(servers, t.min, t.max)
+}
+
 func (r *dnsSelector) Init(opts ...selector.Option) error {
 	for _, o := range opts {
 		o(&r.options)
@@ -34,6 +52,8 @@ func (r *dnsSelector) Init(opts ...selector.Option) error {
 		}
 	}
 
+	r.resolver = newResolverFromOptions(r.options)
+
 	return nil
 }
 
@@ -42,20 +62,11 @@ func (r *dnsSelector) Options() selector.Options {
 }
 
 func (r *dnsSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
-	_, srv, err := net.LookupSRV(service, "tcp", r.domain)
+	nodes, err := r.resolver.Resolve(fmt.Sprintf("_%s._tcp.%s", service, r.domain))
 	if err != nil {
 		return nil, err
 	}
 
-	var nodes []*registry.Node
-	for _, node := range srv {
-		nodes = append(nodes, &registry.Node{
-			Id:      node.Target,
-			Address: node.Target,
-			Port:    int(node.Port),
-		})
-	}
-
 	services := []*registry.Service{
 		&registry.Service{
 			Name:  service,
@@ -89,7 +100,7 @@ func (r *dnsSelector) Mark(service string, node *registry.Node, err error) {
 }
 
 func (r *dnsSelector) Reset(service string) {
-	return
+	r.resolver.Reset(fmt.Sprintf("_%s._tcp.%s", service, r.domain))
 }
 
 func (r *dnsSelector) Close() error {
@@ -102,7 +113,7 @@ func (r *dnsSelector) String() string {
 
 func NewSelector(opts ...selector.Option) selector.Selector {
 	options := selector.Options{
-		Strategy: selector.Random,
+		Strategy: Weighted,
 	}
 
 	for _, o := range opts {
@@ -118,5 +129,9 @@ func NewSelector(opts ...selector.Option) selector.Selector {
 		}
 	}
 
-	return &dnsSelector{options: options, domain: domain}
+	return &dnsSelector{
+		options:  options,
+		domain:   domain,
+		resolver: newResolverFromOptions(options),
+	}
 }
@@ -0,0 +1,146 @@
+package dns
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/miekg/dns"
+)
+
+func TestWeighted(t *testing.T) {
+	services := []*registry.Service{
+		{
+			Name: "foo",
+			Nodes: []*registry.Node{
+				{Id: "primary", Metadata: map[string]string{"priority": "10", "weight": "1"}},
+				{Id: "backup", Metadata: map[string]string{"priority": "20", "weight": "100"}},
+			},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		node, err := Weighted(services)()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Id != "primary" {
+			t.Fatalf("expected the lowest priority node got %s", node.Id)
+		}
+	}
+
+	// retries fall back to the next priority then start over
+	next := Weighted(services)
+	for _, id := range []string{"primary", "backup", "primary"} {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Id != id {
+			t.Fatalf("expected node %s got %s", id, node.Id)
+		}
+	}
+}
+
+func testServer(t *testing.T, queries *int32) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc("micro.local.", func(w dns.ResponseWriter, r *dns.Msg) {
+		atomic.AddInt32(queries, 1)
+
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Answer = append(m.Answer, &dns.SRV{
+			Hdr:      dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 60},
+			Priority: 10,
+			Weight:   5,
+			Port:     8080,
+			Target:   "node1.micro.local.",
+		})
+		m.Extra = append(m.Extra, &dns.A{
+			Hdr: dns.RR_Header{Name: "node1.micro.local.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.ParseIP("10.0.0.1"),
+		})
+		w.WriteMsg(m)
+	})
+
+	srv := &dns.Server{PacketConn: pc, Handler: mux}
+	go srv.ActivateAndServe()
+
+	return pc.LocalAddr().String(), func() { srv.Shutdown() }
+}
+
+func TestResolver(t *testing.T) {
+	var queries int32
+
+	addr, stop := testServer(t, &queries)
+	defer stop()
+
+	// the first resolver is down so the query fails over
+	r := newResolver([]string{"127.0.0.1:1", addr}, 0, 0)
+	r.client.Timeout = 100 * time.Millisecond
+
+	nodes, err := r.Resolve("_foo._tcp.micro.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 1 || nodes[0].Address != "10.0.0.1" || nodes[0].Port != 8080 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+	if nodes[0].Metadata["weight"] != "5" {
+		t.Fatalf("expected weight 5 got %s", nodes[0].Metadata["weight"])
+	}
+	if r.servers[0] != addr {
+		t.Fatalf("expected the failed resolver to be rotated, got %v", r.servers)
+	}
+
+	// served from cache within the ttl
+	if _, err := r.Resolve("_foo._tcp.micro.local"); err != nil {
+		t.Fatal(err)
+	}
+	if q := atomic.LoadInt32(&queries); q != 1 {
+		t.Fatalf("expected 1 query got %d", q)
+	}
+}
+
+func TestStale(t *testing.T) {
+	var queries int32
+
+	addr, stop := testServer(t, &queries)
+
+	r := newResolver([]string{addr}, time.Millisecond, time.Millisecond)
+	r.client.Timeout = 100 * time.Millisecond
+
+	if _, err := r.Resolve("_foo._tcp.micro.local"); err != nil {
+		t.Fatal(err)
+	}
+
+	stop()
+	time.Sleep(time.Millisecond * 5)
+
+	// the stale records are served and kept rather than retried
+	for i := 0; i < 3; i++ {
+		nodes, err := r.Resolve("_foo._tcp.micro.local")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nodes) != 1 {
+			t.Fatalf("expected stale nodes got %+v", nodes)
+		}
+	}
+
+	r.Lock()
+	expires := r.cache["_foo._tcp.micro.local"].expires
+	r.Unlock()
+
+	if time.Until(expires) < DefaultRetry/2 {
+		t.Fatalf("expected stale records to be extended got %v", expires)
+	}
+}
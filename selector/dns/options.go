@@ -2,6 +2,7 @@ package dns
 
 import (
 	"context"
+	"time"
 
 	"github.com/micro/go-micro/selector"
 )
@@ -17,3 +18,33 @@ func Domain(d string) selector.Option {
 		o.Context = context.WithValue(o.Context, domainKey{}, d)
 	}
 }
+
+type resolversKey struct{}
+type ttlKey struct{}
+
+type ttl struct {
+	min time.Duration
+	max time.Duration
+}
+
+// Resolvers sets the dns servers queried, e.g. 10.0.0.2:53. They're tried in
+// turn and a failing resolver is rotated to the back. Defaults to the
+// servers in /etc/resolv.conf.
+func Resolvers(addrs ...string) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, resolversKey{}, addrs)
+	}
+}
+
+// TTL bounds how long records are cached for regardless of the record TTL
+func TTL(min, max time.Duration) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, ttlKey{}, ttl{min, max})
+	}
+}
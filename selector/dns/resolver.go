@@ -0,0 +1,207 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/miekg/dns"
+)
+
+var (
+	// DefaultTTL is used when records are looked up without a ttl
+	DefaultTTL = 30 * time.Second
+	// DefaultTimeout of a single dns query
+	DefaultTimeout = 2 * time.Second
+	// DefaultRetry is how long stale records are served
+	// after a failed lookup before it's retried
+	DefaultRetry = 5 * time.Second
+)
+
+type record struct {
+	nodes   []*registry.Node
+	expires time.Time
+}
+
+type resolver struct {
+	client *dns.Client
+	min    time.Duration
+	max    time.Duration
+
+	sync.Mutex
+	servers []string
+	cache   map[string]*record
+}
+
+func newResolver(servers []string, min, max time.Duration) *resolver {
+	if len(servers) == 0 {
+		if c, err := dns.ClientConfigFromFile("/etc/resolv.conf"); err == nil {
+			for _, s := range c.Servers {
+				servers = append(servers, net.JoinHostPort(s, c.Port))
+			}
+		}
+	}
+
+	return &resolver{
+		client:  &dns.Client{Timeout: DefaultTimeout},
+		min:     min,
+		max:     max,
+		servers: servers,
+		cache:   make(map[string]*record),
+	}
+}
+
+// exchange sends the query to each server in turn, rotating
+// failed servers to the back of the list
+func (r *resolver) exchange(m *dns.Msg) (*dns.Msg, error) {
+	r.Lock()
+	servers := make([]string, len(r.servers))
+	copy(servers, r.servers)
+	r.Unlock()
+
+	if len(servers) == 0 {
+		return nil, errors.New("no dns servers")
+	}
+
+	var lastErr error
+
+	for _, server := range servers {
+		rsp, _, err := r.client.Exchange(m, server)
+		if err == nil && rsp.Rcode != dns.RcodeServerFailure {
+			return rsp, nil
+		}
+		if err == nil {
+			err = errors.New(dns.RcodeToString[rsp.Rcode])
+		}
+		lastErr = err
+		r.rotate(server)
+	}
+
+	return nil, lastErr
+}
+
+func (r *resolver) rotate(server string) {
+	r.Lock()
+	defer r.Unlock()
+
+	for i, s := range r.servers {
+		if s == server {
+			r.servers = append(append(r.servers[:i:i], r.servers[i+1:]...), server)
+			return
+		}
+	}
+}
+
+func (r *resolver) clamp(ttl time.Duration) time.Duration {
+	if r.min > 0 && ttl < r.min {
+		return r.min
+	}
+	if r.max > 0 && ttl > r.max {
+		return r.max
+	}
+	return ttl
+}
+
+// lookup resolves the SRV records of the name into nodes
+func (r *resolver) lookup(name string) ([]*registry.Node, time.Duration, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeSRV)
+
+	rsp, err := r.exchange(m)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if rsp.Rcode == dns.RcodeNameError {
+		return nil, 0, errors.New("no such host " + name)
+	}
+
+	// addresses of the targets are usually in the additional section
+	addrs := make(map[string]string)
+	for _, rr := range rsp.Extra {
+		if a, ok := rr.(*dns.A); ok {
+			addrs[a.Hdr.Name] = a.A.String()
+		}
+	}
+
+	var nodes []*registry.Node
+	ttl := time.Duration(-1)
+
+	for _, rr := range rsp.Answer {
+		srv, ok := rr.(*dns.SRV)
+		if !ok {
+			continue
+		}
+
+		if t := time.Duration(srv.Hdr.Ttl) * time.Second; ttl < 0 || t < ttl {
+			ttl = t
+		}
+
+		address := strings.TrimSuffix(srv.Target, ".")
+		if a, ok := addrs[srv.Target]; ok {
+			address = a
+		}
+
+		nodes = append(nodes, &registry.Node{
+			Id:      strings.TrimSuffix(srv.Target, "."),
+			Address: address,
+			Port:    int(srv.Port),
+			Metadata: map[string]string{
+				"priority": itoa(srv.Priority),
+				"weight":   itoa(srv.Weight),
+			},
+		})
+	}
+
+	if ttl < 0 {
+		ttl = DefaultTTL
+	}
+
+	return nodes, ttl, nil
+}
+
+// Resolve returns the nodes for the name, served from cache
+// until the record ttl expires
+func (r *resolver) Resolve(name string) ([]*registry.Node, error) {
+	r.Lock()
+	rec, ok := r.cache[name]
+	r.Unlock()
+
+	if ok && time.Now().Before(rec.expires) {
+		return rec.nodes, nil
+	}
+
+	nodes, ttl, err := r.lookup(name)
+	if err != nil {
+		// serve stale records rather than failing, and keep
+		// serving them for a while rather than retrying each time
+		if ok {
+			r.Lock()
+			r.cache[name] = &record{
+				nodes:   rec.nodes,
+				expires: time.Now().Add(DefaultRetry),
+			}
+			r.Unlock()
+			return rec.nodes, nil
+		}
+		return nil, err
+	}
+
+	r.Lock()
+	r.cache[name] = &record{
+		nodes:   nodes,
+		expires: time.Now().Add(r.clamp(ttl)),
+	}
+	r.Unlock()
+
+	return nodes, nil
+}
+
+func (r *resolver) Reset(name string) {
+	r.Lock()
+	delete(r.cache, name)
+	r.Unlock()
+}
@@ -0,0 +1,102 @@
+package dns
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+func itoa(i uint16) string {
+	return strconv.Itoa(int(i))
+}
+
+func metadataInt(node *registry.Node, key string) int {
+	if node.Metadata == nil {
+		return 0
+	}
+	i, _ := strconv.Atoi(node.Metadata[key])
+	return i
+}
+
+// pick picks a node in proportion to weight
+func pick(nodes []*registry.Node) *registry.Node {
+	var total int
+	for _, node := range nodes {
+		total += metadataInt(node, "weight")
+	}
+
+	// zero weights are picked uniformly
+	if total == 0 {
+		return nodes[rand.Intn(len(nodes))]
+	}
+
+	n := rand.Intn(total)
+	for _, node := range nodes {
+		n -= metadataInt(node, "weight")
+		if n < 0 {
+			return node
+		}
+	}
+
+	return nodes[len(nodes)-1]
+}
+
+// Weighted is a strategy following RFC 2782. Nodes with the lowest
+// priority are used first and picked in proportion to weight. Once
+// each of them has been returned, e.g. by retries after failures, it
+// falls back to the nodes of the next priority.
+func Weighted(services []*registry.Service) selector.Next {
+	groups := make(map[int][]*registry.Node)
+	var priorities []int
+
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			p := metadataInt(node, "priority")
+			if _, ok := groups[p]; !ok {
+				priorities = append(priorities, p)
+			}
+			groups[p] = append(groups[p], node)
+		}
+	}
+
+	sort.Ints(priorities)
+
+	var mtx sync.Mutex
+	var i int
+	tried := make(map[*registry.Node]bool)
+
+	return func() (*registry.Node, error) {
+		if len(priorities) == 0 {
+			return nil, selector.ErrNoneAvailable
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		var nodes []*registry.Node
+		for {
+			for _, node := range groups[priorities[i]] {
+				if !tried[node] {
+					nodes = append(nodes, node)
+				}
+			}
+			if len(nodes) > 0 {
+				break
+			}
+
+			// start over once every node has been tried
+			if i++; i == len(priorities) {
+				i = 0
+				tried = make(map[*registry.Node]bool)
+			}
+		}
+
+		node := pick(nodes)
+		tried[node] = true
+		return node, nil
+	}
+}
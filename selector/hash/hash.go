@@ -0,0 +1,260 @@
+// Package hash is a consistent hashing selector with bounded loads.
+// Requests with the same key are routed to the same node, unless that
+// node already carries more than its share of in flight requests, in
+// which case they overflow to the next node on the ring.
+package hash
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+var (
+	// DefaultReplicas is the number of virtual nodes per node
+	DefaultReplicas = 100
+	// DefaultLoad is the load bound as a factor of the average load
+	DefaultLoad = 1.25
+)
+
+type hashSelector struct {
+	so       selector.Options
+	replicas int
+	load     float64
+
+	sync.Mutex
+	// rings by service
+	rings map[string]*ring
+	// in flight requests by node id, counted
+	// when selected and released by Mark
+	loads map[string]int
+}
+
+func init() {
+	cmd.DefaultSelectors["hash"] = NewSelector
+}
+
+func (h *hashSelector) configure() {
+	if h.so.Context == nil {
+		return
+	}
+	if r, ok := h.so.Context.Value(replicasKey{}).(int); ok && r > 0 {
+		h.replicas = r
+	}
+	if l, ok := h.so.Context.Value(loadKey{}).(float64); ok && l >= 1 {
+		h.load = l
+	}
+}
+
+func (h *hashSelector) ring(service string, nodes []*registry.Node) *ring {
+	h.Lock()
+	defer h.Unlock()
+
+	r, ok := h.rings[service]
+	if !ok || r.id != nodeSet(nodes) {
+		r = newRing(nodes, h.replicas)
+		h.rings[service] = r
+	}
+	return r
+}
+
+// capacity is the maximum in flight requests for a node of the ring,
+// the load factor times the average load of its nodes including the
+// request being placed
+func (h *hashSelector) capacity(r *ring) int {
+	var total int
+	for _, id := range r.ids {
+		total += h.loads[id]
+	}
+	return int(math.Ceil(float64(total+1) * h.load / float64(r.count)))
+}
+
+// count counts the nodes selected by the strategy so
+// every node released by Mark was counted first
+func (h *hashSelector) count(next selector.Next) selector.Next {
+	return func() (*registry.Node, error) {
+		node, err := next()
+		if err != nil {
+			return nil, err
+		}
+		h.Lock()
+		h.loads[node.Id]++
+		h.Unlock()
+		return node, nil
+	}
+}
+
+func (h *hashSelector) next(r *ring, key string) selector.Next {
+	tried := make(map[string]bool)
+
+	return func() (*registry.Node, error) {
+		h.Lock()
+		defer h.Unlock()
+
+		c := h.capacity(r)
+
+		var node, fallback *registry.Node
+		r.walk(key, func(n *registry.Node) bool {
+			if tried[n.Id] {
+				return false
+			}
+			if fallback == nil {
+				fallback = n
+			}
+			if h.loads[n.Id] < c {
+				node = n
+				return true
+			}
+			return false
+		})
+
+		if node == nil {
+			node = fallback
+		}
+		if node == nil {
+			return nil, selector.ErrNoneAvailable
+		}
+
+		tried[node.Id] = true
+		h.loads[node.Id]++
+		return node, nil
+	}
+}
+
+func (h *hashSelector) Init(opts ...selector.Option) error {
+	for _, o := range opts {
+		o(&h.so)
+	}
+	h.configure()
+	return nil
+}
+
+func (h *hashSelector) Options() selector.Options {
+	return h.so
+}
+
+func (h *hashSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	sopts := selector.SelectOptions{
+		Strategy: h.so.Strategy,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	// get the service
+	services, err := h.so.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	// no key, fallback to the strategy
+	var key string
+	if sopts.Context != nil {
+		key, _ = sopts.Context.Value(hashKey{}).(string)
+	}
+	if len(key) == 0 {
+		return h.count(sopts.Strategy(services)), nil
+	}
+
+	var nodes []*registry.Node
+	for _, service := range services {
+		nodes = append(nodes, service.Nodes...)
+	}
+
+	if len(nodes) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	return h.next(h.ring(service, nodes), key), nil
+}
+
+// Mark completes a request to the node, releasing its load
+func (h *hashSelector) Mark(service string, node *registry.Node, err error) {
+	h.Lock()
+	defer h.Unlock()
+
+	switch l := h.loads[node.Id]; {
+	case l > 1:
+		h.loads[node.Id]--
+	case l == 1:
+		delete(h.loads, node.Id)
+	}
+}
+
+func (h *hashSelector) Reset(service string) {
+	h.Lock()
+	delete(h.rings, service)
+	h.Unlock()
+}
+
+func (h *hashSelector) Close() error {
+	return nil
+}
+
+func (h *hashSelector) String() string {
+	return "hash"
+}
+
+type hashWrapper struct {
+	client.Client
+}
+
+func (h *hashWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	if k, ok := FromContext(ctx); ok {
+		opts = append(opts, client.WithSelectOption(Key(k)))
+	}
+	return h.Client.Call(ctx, req, rsp, opts...)
+}
+
+func (h *hashWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	if k, ok := FromContext(ctx); ok {
+		opts = append(opts, client.WithSelectOption(Key(k)))
+	}
+	return h.Client.Stream(ctx, req, opts...)
+}
+
+// NewClientWrapper passes the key set with NewContext to the selector
+func NewClientWrapper() client.Wrapper {
+	return func(c client.Client) client.Client {
+		return &hashWrapper{c}
+	}
+}
+
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.Background(),
+		Registry: registry.DefaultRegistry,
+		Strategy: selector.Random,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	h := &hashSelector{
+		so:       sopts,
+		replicas: DefaultReplicas,
+		load:     DefaultLoad,
+		rings:    make(map[string]*ring),
+		loads:    make(map[string]int),
+	}
+	h.configure()
+
+	return h
+}
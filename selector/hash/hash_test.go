@@ -0,0 +1,155 @@
+package hash
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/registry/mock"
+	"github.com/micro/go-micro/selector"
+)
+
+func testSelector(t *testing.T, n int) selector.Selector {
+	var nodes []*registry.Node
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, &registry.Node{
+			Id:      fmt.Sprintf("node-%d", i),
+			Address: "localhost",
+			Port:    10000 + i,
+		})
+	}
+
+	r := mock.NewRegistry()
+	if err := r.Register(&registry.Service{Name: "hash.test", Version: "1", Nodes: nodes}); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewSelector(selector.Registry(r))
+}
+
+func TestAffinity(t *testing.T) {
+	s := testSelector(t, 5)
+
+	var id string
+	for i := 0; i < 10; i++ {
+		next, err := s.Select("hash.test", Key("session-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) > 0 && node.Id != id {
+			t.Fatalf("expected %s got %s", id, node.Id)
+		}
+		id = node.Id
+		s.Mark("hash.test", node, nil)
+	}
+}
+
+func TestBoundedLoad(t *testing.T) {
+	s := testSelector(t, 4)
+
+	// never mark, so load builds up on the node owning the key
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		next, err := s.Select("hash.test", Key("hot"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, _ := next()
+		counts[node.Id]++
+	}
+
+	if len(counts) != 4 {
+		t.Fatalf("expected load to spread across 4 nodes got %v", counts)
+	}
+	for id, c := range counts {
+		// ceil(100 / 4 * 1.25) plus rounding
+		if c > 32 {
+			t.Fatalf("node %s exceeded its bound with %d", id, c)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	s := testSelector(t, 3)
+
+	next, err := s.Select("hash.test", Key("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[node.Id] {
+			t.Fatalf("node %s returned twice", node.Id)
+		}
+		seen[node.Id] = true
+	}
+
+	if _, err := next(); err != selector.ErrNoneAvailable {
+		t.Fatalf("expected none available got %v", err)
+	}
+}
+
+func TestLoads(t *testing.T) {
+	s := testSelector(t, 4).(*hashSelector)
+
+	total := func() int {
+		s.Lock()
+		defer s.Unlock()
+		var n int
+		for _, l := range s.loads {
+			n += l
+		}
+		return n
+	}
+
+	for i := 0; i < 10; i++ {
+		next, err := s.Select("hash.test", Key("hot"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// requests without a key are counted and released too
+	next, err := s.Select("hash.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := total(); n != 11 {
+		t.Fatalf("expected 11 in flight got %d", n)
+	}
+	s.Mark("hash.test", node, nil)
+	if n := total(); n != 10 {
+		t.Fatalf("expected 10 in flight got %d", n)
+	}
+
+	// releasing a node never selected doesn't change the loads
+	s.Mark("hash.test", &registry.Node{Id: "unknown"}, nil)
+	if n := total(); n != 10 {
+		t.Fatalf("expected 10 in flight got %d", n)
+	}
+
+	// the capacity of another service only counts its own nodes
+	r := newRing([]*registry.Node{{Id: "other-1"}, {Id: "other-2"}}, 10)
+	s.Lock()
+	c := s.capacity(r)
+	s.Unlock()
+	if c != 1 {
+		t.Fatalf("expected capacity 1 got %d", c)
+	}
+}
@@ -0,0 +1,54 @@
+package hash
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/selector"
+)
+
+type replicasKey struct{}
+type loadKey struct{}
+type hashKey struct{}
+
+// Replicas sets the number of virtual nodes per node on the ring
+func Replicas(n int) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, replicasKey{}, n)
+	}
+}
+
+// Load sets the load bound as a factor of the average load, e.g. 1.25
+// allows a node 25% more in flight requests than the average before
+// keys overflow to the next node on the ring.
+func Load(f float64) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, loadKey{}, f)
+	}
+}
+
+// Key sets the key hashed onto the ring for a single selection
+func Key(k string) selector.SelectOption {
+	return func(o *selector.SelectOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, hashKey{}, k)
+	}
+}
+
+// NewContext returns a context carrying the hash key, used by the client wrapper
+func NewContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+// FromContext returns the hash key from the context
+func FromContext(ctx context.Context) (string, bool) {
+	k, ok := ctx.Value(hashKey{}).(string)
+	return k, ok
+}
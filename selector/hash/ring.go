@@ -0,0 +1,72 @@
+package hash
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type ring struct {
+	// id identifies the node set the ring was built from
+	id     string
+	hashes []uint32
+	nodes  map[uint32]*registry.Node
+	count  int
+	// ids of the nodes
+	ids []string
+}
+
+func nodeSet(nodes []*registry.Node) string {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.Id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func newRing(nodes []*registry.Node, replicas int) *ring {
+	r := &ring{
+		id:    nodeSet(nodes),
+		nodes: make(map[uint32]*registry.Node, len(nodes)*replicas),
+		count: len(nodes),
+	}
+
+	for _, node := range nodes {
+		r.ids = append(r.ids, node.Id)
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(node.Id + "-" + strconv.Itoa(i)))
+			r.hashes = append(r.hashes, h)
+			r.nodes[h] = node
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// walk calls fn for each distinct node clockwise from the key
+// until fn returns true
+func (r *ring) walk(key string, fn func(*registry.Node) bool) {
+	if len(r.hashes) == 0 {
+		return
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+
+	seen := make(map[string]bool, r.count)
+	for j := 0; j < len(r.hashes) && len(seen) < r.count; j++ {
+		node := r.nodes[r.hashes[(i+j)%len(r.hashes)]]
+		if seen[node.Id] {
+			continue
+		}
+		seen[node.Id] = true
+		if fn(node) {
+			return
+		}
+	}
+}
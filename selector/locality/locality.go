@@ -0,0 +1,223 @@
+// Package locality is a weighted, locality aware selector. Nodes in the
+// same zone are preferred, then nodes in the same region, and within each
+// tier nodes are picked in proportion to their weight.
+package locality
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+var (
+	// ZoneKey is the node metadata key holding the zone
+	ZoneKey = "zone"
+	// RegionKey is the node metadata key holding the region
+	RegionKey = "region"
+	// WeightKey is the node metadata key holding the weight
+	WeightKey = "weight"
+	// DefaultWeight of nodes without a valid weight
+	DefaultWeight = 100
+)
+
+type localitySelector struct {
+	so selector.Options
+}
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+	cmd.DefaultSelectors["locality"] = NewSelector
+}
+
+// weight returns the weight of the node
+func weight(node *registry.Node) int {
+	if node.Metadata == nil {
+		return DefaultWeight
+	}
+	w, err := strconv.Atoi(node.Metadata[WeightKey])
+	if err != nil || w < 0 {
+		return DefaultWeight
+	}
+	return w
+}
+
+// pick returns a node chosen in proportion to its weight
+func pick(nodes []*registry.Node) *registry.Node {
+	var total int
+	for _, node := range nodes {
+		total += weight(node)
+	}
+
+	// all zero weights, pick uniformly
+	if total == 0 {
+		return nodes[rand.Intn(len(nodes))]
+	}
+
+	n := rand.Intn(total)
+	for _, node := range nodes {
+		n -= weight(node)
+		if n < 0 {
+			return node
+		}
+	}
+
+	return nodes[len(nodes)-1]
+}
+
+// tiers splits the nodes into same zone, same region and remote
+func tiers(services []*registry.Service, zone, region string) [3][]*registry.Node {
+	var t [3][]*registry.Node
+
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			var z, r string
+			if node.Metadata != nil {
+				z = node.Metadata[ZoneKey]
+				r = node.Metadata[RegionKey]
+			}
+
+			switch {
+			case len(zone) > 0 && z == zone:
+				t[0] = append(t[0], node)
+			case len(region) > 0 && r == region:
+				t[1] = append(t[1], node)
+			default:
+				t[2] = append(t[2], node)
+			}
+		}
+	}
+
+	return t
+}
+
+// Strategy returns a selector strategy preferring nodes in the zone, then
+// the region. The spillover fractions send some requests to the next tier
+// even when the local one has nodes.
+func Strategy(zone, region string, zoneSpill, regionSpill float64) selector.Strategy {
+	return func(services []*registry.Service) selector.Next {
+		t := tiers(services, zone, region)
+		spill := [2]float64{zoneSpill, regionSpill}
+
+		return func() (*registry.Node, error) {
+			for i, nodes := range t {
+				if len(nodes) == 0 {
+					continue
+				}
+				// spill over to the next non empty tier
+				if i < 2 && spill[i] > 0 && rand.Float64() < spill[i] {
+					var remote bool
+					for _, n := range t[i+1:] {
+						if len(n) > 0 {
+							remote = true
+							break
+						}
+					}
+					if remote {
+						continue
+					}
+				}
+				return pick(nodes), nil
+			}
+
+			return nil, selector.ErrNoneAvailable
+		}
+	}
+}
+
+func (l *localitySelector) strategy() selector.Strategy {
+	ctx := l.so.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	zone, ok := ctx.Value(zoneKey{}).(string)
+	if !ok {
+		zone = os.Getenv("ZONE")
+	}
+
+	region, ok := ctx.Value(regionKey{}).(string)
+	if !ok {
+		region = os.Getenv("REGION")
+	}
+
+	s, _ := ctx.Value(spilloverKey{}).(spillover)
+
+	return Strategy(zone, region, s.zone, s.region)
+}
+
+func (l *localitySelector) Init(opts ...selector.Option) error {
+	for _, o := range opts {
+		o(&l.so)
+	}
+	l.so.Strategy = l.strategy()
+	return nil
+}
+
+func (l *localitySelector) Options() selector.Options {
+	return l.so
+}
+
+func (l *localitySelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	sopts := selector.SelectOptions{
+		Strategy: l.so.Strategy,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	// get the service
+	services, err := l.so.Registry.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the filters
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	// if there's nothing left, return
+	if len(services) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	return sopts.Strategy(services), nil
+}
+
+func (l *localitySelector) Mark(service string, node *registry.Node, err error) {
+	return
+}
+
+func (l *localitySelector) Reset(service string) {
+	return
+}
+
+func (l *localitySelector) Close() error {
+	return nil
+}
+
+func (l *localitySelector) String() string {
+	return "locality"
+}
+
+func NewSelector(opts ...selector.Option) selector.Selector {
+	sopts := selector.Options{
+		Context:  context.Background(),
+		Registry: registry.DefaultRegistry,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	l := &localitySelector{so: sopts}
+	l.so.Strategy = l.strategy()
+	return l
+}
@@ -0,0 +1,62 @@
+package locality
+
+import (
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func testServices() []*registry.Service {
+	return []*registry.Service{
+		{
+			Name: "foo",
+			Nodes: []*registry.Node{
+				{Id: "a1", Metadata: map[string]string{"zone": "a", "region": "eu"}},
+				{Id: "a2", Metadata: map[string]string{"zone": "a", "region": "eu", "weight": "0"}},
+				{Id: "b1", Metadata: map[string]string{"zone": "b", "region": "eu"}},
+				{Id: "c1", Metadata: map[string]string{"zone": "c", "region": "us"}},
+			},
+		},
+	}
+}
+
+func TestStrategy(t *testing.T) {
+	next := Strategy("a", "eu", 0, 0)(testServices())
+
+	for i := 0; i < 100; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a2 has no weight so a1 always wins
+		if node.Id != "a1" {
+			t.Fatalf("expected a1 got %s", node.Id)
+		}
+	}
+
+	// no zone match falls back to the region
+	next = Strategy("x", "eu", 0, 0)(testServices())
+	for i := 0; i < 100; i++ {
+		node, _ := next()
+		if node.Id != "a1" && node.Id != "b1" {
+			t.Fatalf("expected eu node got %s", node.Id)
+		}
+	}
+}
+
+func TestSpillover(t *testing.T) {
+	next := Strategy("a", "eu", 0.5, 0)(testServices())
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		node, _ := next()
+		counts[node.Id]++
+	}
+
+	if counts["b1"] == 0 {
+		t.Fatal("expected traffic to spill over to the region")
+	}
+	if counts["c1"] != 0 {
+		t.Fatalf("unexpected traffic outside the region %d", counts["c1"])
+	}
+}
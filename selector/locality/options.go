@@ -0,0 +1,42 @@
+package locality
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/selector"
+)
+
+type zoneKey struct{}
+type regionKey struct{}
+type spilloverKey struct{}
+
+type spillover struct {
+	zone   float64
+	region float64
+}
+
+func setOption(k, v interface{}) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// Zone sets the zone of the caller, defaults to the ZONE env var
+func Zone(z string) selector.Option {
+	return setOption(zoneKey{}, z)
+}
+
+// Region sets the region of the caller, defaults to the REGION env var
+func Region(r string) selector.Option {
+	return setOption(regionKey{}, r)
+}
+
+// Spillover sets the fraction of requests sent outside the zone and
+// outside the region even when local nodes are available, e.g. 0.05
+// keeps a trickle of traffic warming remote nodes.
+func Spillover(zone, region float64) selector.Option {
+	return setOption(spilloverKey{}, spillover{zone, region})
+}
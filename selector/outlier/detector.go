@@ -0,0 +1,200 @@
+package outlier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+type stats struct {
+	service string
+	// consecutive failures
+	consecutive int
+	// requests and failures in the current interval
+	requests int
+	failures int
+	interval time.Time
+	// number of times ejected, drives the ejection time
+	ejections int
+	// ejected until, zero if not ejected
+	until time.Time
+	// last time the node was healthy
+	healthy time.Time
+	// last time a result was marked
+	last time.Time
+}
+
+type detector struct {
+	opts Options
+
+	sync.RWMutex
+	// stats by node id
+	nodes map[string]*stats
+	// known node count by service
+	counts map[string]int
+	// last time idle stats were swept
+	swept time.Time
+}
+
+func newDetector(opts Options) *detector {
+	return &detector{
+		opts:   opts,
+		nodes:  make(map[string]*stats),
+		counts: make(map[string]int),
+	}
+}
+
+// ejectionTime is the exponential ejection time for the nth ejection
+func (d *detector) ejectionTime(n int) time.Duration {
+	t := d.opts.BaseEjection
+	for i := 1; i < n; i++ {
+		t *= 2
+		if t >= d.opts.MaxEjection {
+			return d.opts.MaxEjection
+		}
+	}
+	return t
+}
+
+// ejected returns the number of nodes of a service currently ejected
+func (d *detector) ejected(service string, now time.Time) int {
+	var n int
+	for _, s := range d.nodes {
+		if s.service == service && now.Before(s.until) {
+			n++
+		}
+	}
+	return n
+}
+
+// sweep removes the stats of nodes which haven't been marked for longer
+// than the stats are relevant, such as nodes which have left the registry
+func (d *detector) sweep(now time.Time) {
+	idle := d.opts.MaxEjection
+	if d.opts.Interval > idle {
+		idle = d.opts.Interval
+	}
+
+	for id, s := range d.nodes {
+		if !now.Before(s.until) && now.Sub(s.last) > idle {
+			delete(d.nodes, id)
+		}
+	}
+
+	d.swept = now
+}
+
+func (d *detector) eject(s *stats, now time.Time) {
+	// respect the cap on ejected nodes
+	if total := d.counts[s.service]; total > 0 {
+		if (d.ejected(s.service, now)+1)*100 > total*d.opts.MaxEjectedPercent {
+			return
+		}
+	}
+
+	s.ejections++
+	s.until = now.Add(d.ejectionTime(s.ejections))
+	s.consecutive = 0
+	s.requests = 0
+	s.failures = 0
+}
+
+func (d *detector) mark(service string, node *registry.Node, err error) {
+	now := time.Now()
+
+	d.Lock()
+	defer d.Unlock()
+
+	s, ok := d.nodes[node.Id]
+	if !ok {
+		s = &stats{service: service, interval: now, healthy: now}
+		d.nodes[node.Id] = s
+	}
+	s.last = now
+
+	// reset the interval
+	if now.Sub(s.interval) > d.opts.Interval {
+		s.interval = now
+		s.requests = 0
+		s.failures = 0
+	}
+
+	s.requests++
+
+	if err == nil || !d.opts.Failure(err) {
+		s.consecutive = 0
+		// forget past ejections once a node stays healthy
+		if s.ejections > 0 && now.Sub(s.healthy) > d.opts.MaxEjection {
+			s.ejections = 0
+		}
+		if !now.Before(s.until) && s.healthy.Before(s.until) {
+			s.healthy = now
+		}
+		return
+	}
+
+	s.failures++
+	s.consecutive++
+
+	// a failed probe after ejection ejects again straight away
+	probing := !s.until.IsZero() && !now.Before(s.until) && s.healthy.Before(s.until)
+
+	switch {
+	case probing:
+		d.eject(s, now)
+	case d.opts.Consecutive > 0 && s.consecutive >= d.opts.Consecutive:
+		d.eject(s, now)
+	case d.opts.ErrorRate > 0 && s.requests >= d.opts.MinRequests &&
+		float64(s.failures)/float64(s.requests) >= d.opts.ErrorRate:
+		d.eject(s, now)
+	}
+}
+
+// filter removes ejected nodes. Nodes whose ejection has expired are
+// returned again and re-probed by the next request.
+func (d *detector) filter(services []*registry.Service) []*registry.Service {
+	now := time.Now()
+
+	d.Lock()
+	defer d.Unlock()
+
+	if now.Sub(d.swept) > d.opts.Interval {
+		d.sweep(now)
+	}
+
+	var filtered []*registry.Service
+
+	for _, service := range services {
+		d.counts[service.Name] = len(service.Nodes)
+
+		var nodes []*registry.Node
+		for _, node := range service.Nodes {
+			if s, ok := d.nodes[node.Id]; ok && now.Before(s.until) {
+				continue
+			}
+			nodes = append(nodes, node)
+		}
+
+		if len(nodes) == 0 {
+			continue
+		}
+
+		svc := *service
+		svc.Nodes = nodes
+		filtered = append(filtered, &svc)
+	}
+
+	return filtered
+}
+
+func (d *detector) reset(service string) {
+	d.Lock()
+	defer d.Unlock()
+
+	for id, s := range d.nodes {
+		if s.service == service {
+			delete(d.nodes, id)
+		}
+	}
+}
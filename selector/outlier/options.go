@@ -0,0 +1,98 @@
+package outlier
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/errors"
+)
+
+// Options for outlier detection
+type Options struct {
+	// Consecutive failures before a node is ejected
+	Consecutive int
+	// ErrorRate within an interval before a node is ejected
+	ErrorRate float64
+	// MinRequests within an interval before the error rate applies
+	MinRequests int
+	// Interval the error rate is measured over
+	Interval time.Duration
+	// BaseEjection is the first ejection time, doubled on each
+	// subsequent ejection up to MaxEjection
+	BaseEjection time.Duration
+	// MaxEjection caps the ejection time
+	MaxEjection time.Duration
+	// MaxEjectedPercent of a service's nodes which may be ejected at once
+	MaxEjectedPercent int
+	// Failure decides whether an error counts against the node
+	Failure func(error) bool
+}
+
+type Option func(*Options)
+
+// Consecutive sets the consecutive failures before ejection
+func Consecutive(n int) Option {
+	return func(o *Options) {
+		o.Consecutive = n
+	}
+}
+
+// ErrorRate sets the error rate over the interval before ejection,
+// applied once a node has served the minimum number of requests
+func ErrorRate(rate float64, min int, interval time.Duration) Option {
+	return func(o *Options) {
+		o.ErrorRate = rate
+		o.MinRequests = min
+		o.Interval = interval
+	}
+}
+
+// Ejection sets the base and maximum ejection times
+func Ejection(base, max time.Duration) Option {
+	return func(o *Options) {
+		o.BaseEjection = base
+		o.MaxEjection = max
+	}
+}
+
+// MaxEjectedPercent sets the percentage of nodes which can be ejected
+func MaxEjectedPercent(p int) Option {
+	return func(o *Options) {
+		o.MaxEjectedPercent = p
+	}
+}
+
+// Failure sets the func deciding which errors count as failures
+func Failure(fn func(error) bool) Option {
+	return func(o *Options) {
+		o.Failure = fn
+	}
+}
+
+// serverError counts transport errors, timeouts and 5xx errors as failures.
+// Client errors such as bad requests are the caller's fault not the node's.
+func serverError(err error) bool {
+	merr, ok := err.(*errors.Error)
+	if !ok {
+		return true
+	}
+	return merr.Code == 408 || merr.Code >= 500
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Consecutive:       5,
+		ErrorRate:         0.5,
+		MinRequests:       10,
+		Interval:          10 * time.Second,
+		BaseEjection:      30 * time.Second,
+		MaxEjection:       5 * time.Minute,
+		MaxEjectedPercent: 50,
+		Failure:           serverError,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,53 @@
+// Package outlier is a selector wrapper providing passive health checking.
+// Failures reported through Mark are tracked per node and nodes with too many
+// consecutive failures or too high an error rate are ejected for an
+// exponentially increasing time, then re-probed.
+package outlier
+
+import (
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+type outlierSelector struct {
+	selector.Selector
+	d *detector
+}
+
+func init() {
+	cmd.DefaultSelectors["outlier"] = NewSelector
+}
+
+func (o *outlierSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	// the filter is applied after any filters passed by the caller
+	opts = append(opts, selector.WithFilter(o.d.filter))
+	return o.Selector.Select(service, opts...)
+}
+
+func (o *outlierSelector) Mark(service string, node *registry.Node, err error) {
+	o.d.mark(service, node, err)
+	o.Selector.Mark(service, node, err)
+}
+
+func (o *outlierSelector) Reset(service string) {
+	o.d.reset(service)
+	o.Selector.Reset(service)
+}
+
+func (o *outlierSelector) String() string {
+	return "outlier"
+}
+
+// Wrap adds outlier detection to the selector
+func Wrap(s selector.Selector, opts ...Option) selector.Selector {
+	return &outlierSelector{
+		Selector: s,
+		d:        newDetector(newOptions(opts...)),
+	}
+}
+
+// NewSelector returns the default selector with outlier detection
+func NewSelector(opts ...selector.Option) selector.Selector {
+	return Wrap(selector.NewSelector(opts...))
+}
@@ -0,0 +1,99 @@
+package outlier
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/registry"
+)
+
+func testServices(n int) []*registry.Service {
+	var nodes []*registry.Node
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, &registry.Node{Id: fmt.Sprintf("node-%d", i)})
+	}
+	return []*registry.Service{{Name: "foo", Nodes: nodes}}
+}
+
+func count(services []*registry.Service) int {
+	var n int
+	for _, s := range services {
+		n += len(s.Nodes)
+	}
+	return n
+}
+
+func TestConsecutive(t *testing.T) {
+	d := newDetector(newOptions(Consecutive(3), Ejection(50*time.Millisecond, time.Second)))
+	services := testServices(4)
+	d.filter(services)
+
+	node := services[0].Nodes[0]
+	fail := errors.InternalServerError("foo", "failed")
+
+	// client errors don't count
+	for i := 0; i < 5; i++ {
+		d.mark("foo", node, errors.BadRequest("foo", "bad"))
+	}
+	if n := count(d.filter(services)); n != 4 {
+		t.Fatalf("expected 4 nodes got %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		d.mark("foo", node, fail)
+	}
+	if n := count(d.filter(services)); n != 3 {
+		t.Fatalf("expected node to be ejected, got %d nodes", n)
+	}
+
+	// re-probed after the ejection
+	time.Sleep(60 * time.Millisecond)
+	if n := count(d.filter(services)); n != 4 {
+		t.Fatalf("expected node to be re-probed, got %d nodes", n)
+	}
+
+	// a failed probe ejects for twice as long
+	d.mark("foo", node, fail)
+	if s := d.nodes[node.Id]; s.ejections != 2 || s.until.Sub(time.Now()) <= 50*time.Millisecond {
+		t.Fatalf("expected exponential ejection, got %d ejections until %v", s.ejections, s.until)
+	}
+}
+
+func TestMaxEjected(t *testing.T) {
+	d := newDetector(newOptions(Consecutive(1), MaxEjectedPercent(50)))
+	services := testServices(4)
+	d.filter(services)
+
+	for _, node := range services[0].Nodes {
+		d.mark("foo", node, fmt.Errorf("connection refused"))
+	}
+
+	if n := count(d.filter(services)); n != 2 {
+		t.Fatalf("expected at most half the nodes ejected, got %d remaining", n)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	d := newDetector(newOptions(Consecutive(1), Ejection(time.Minute, time.Minute)))
+	services := testServices(2)
+	d.filter(services)
+
+	idle, ejected := services[0].Nodes[0], services[0].Nodes[1]
+	d.mark("foo", idle, nil)
+	d.mark("foo", ejected, fmt.Errorf("connection refused"))
+
+	// both nodes left the registry a while ago
+	for _, s := range d.nodes {
+		s.last = time.Now().Add(-2 * time.Minute)
+	}
+	d.sweep(time.Now())
+
+	if _, ok := d.nodes[idle.Id]; ok {
+		t.Fatal("expected idle node stats to be removed")
+	}
+	if _, ok := d.nodes[ejected.Id]; !ok {
+		t.Fatal("expected ejected node stats to be kept")
+	}
+}
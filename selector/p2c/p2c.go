@@ -0,0 +1,87 @@
+// Package p2c is a power of two choices selector strategy. Two nodes are
+// sampled at random and the one with the lower latency weighted by its
+// in flight requests is picked. Latency is fed back by the call wrapper.
+package p2c
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+var (
+	// DefaultDecay is the time over which latency observations decay
+	DefaultDecay = 10 * time.Second
+	// DefaultTracker is used by Strategy and NewCallWrapper
+	DefaultTracker = NewTracker(DefaultDecay)
+)
+
+func init() {
+	rand.Seed(time.Now().UnixNano())
+}
+
+func address(node *registry.Node) string {
+	if node.Port > 0 {
+		return fmt.Sprintf("%s:%d", node.Address, node.Port)
+	}
+	return node.Address
+}
+
+// Strategy returns a p2c strategy using the tracker's observations
+func (t *Tracker) Strategy() selector.Strategy {
+	return func(services []*registry.Service) selector.Next {
+		var nodes []*registry.Node
+		for _, service := range services {
+			nodes = append(nodes, service.Nodes...)
+		}
+
+		return func() (*registry.Node, error) {
+			switch len(nodes) {
+			case 0:
+				return nil, selector.ErrNoneAvailable
+			case 1:
+				return nodes[0], nil
+			}
+
+			i := rand.Intn(len(nodes))
+			j := rand.Intn(len(nodes) - 1)
+			if j >= i {
+				j++
+			}
+
+			a, b := nodes[i], nodes[j]
+			if t.Cost(address(b)) < t.Cost(address(a)) {
+				return b, nil
+			}
+			return a, nil
+		}
+	}
+}
+
+// CallWrapper returns a call wrapper feeding latency back to the tracker
+func (t *Tracker) CallWrapper() client.CallWrapper {
+	return func(cf client.CallFunc) client.CallFunc {
+		return func(ctx context.Context, addr string, req client.Request, rsp interface{}, opts client.CallOptions) error {
+			t.start(addr)
+			start := time.Now()
+			err := cf(ctx, addr, req, rsp, opts)
+			t.done(addr, time.Since(start))
+			return err
+		}
+	}
+}
+
+// Strategy is the p2c strategy using the default tracker
+func Strategy(services []*registry.Service) selector.Next {
+	return DefaultTracker.Strategy()(services)
+}
+
+// NewCallWrapper returns a call wrapper feeding the default tracker
+func NewCallWrapper() client.CallWrapper {
+	return DefaultTracker.CallWrapper()
+}
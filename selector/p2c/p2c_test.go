@@ -0,0 +1,64 @@
+package p2c
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func TestStrategy(t *testing.T) {
+	tr := NewTracker(time.Second)
+
+	services := []*registry.Service{
+		{
+			Name: "foo",
+			Nodes: []*registry.Node{
+				{Id: "fast", Address: "10.0.0.1", Port: 8080},
+				{Id: "slow", Address: "10.0.0.2", Port: 8080},
+			},
+		},
+	}
+
+	tr.start("10.0.0.1:8080")
+	tr.done("10.0.0.1:8080", time.Millisecond)
+	tr.start("10.0.0.2:8080")
+	tr.done("10.0.0.2:8080", 100*time.Millisecond)
+
+	next := tr.Strategy()(services)
+	for i := 0; i < 10; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Id != "fast" {
+			t.Fatalf("expected fast node got %s", node.Id)
+		}
+	}
+
+	// load up the fast node until the slow one wins
+	for i := 0; i < 200; i++ {
+		tr.start("10.0.0.1:8080")
+	}
+
+	node, _ := next()
+	if node.Id != "slow" {
+		t.Fatalf("expected slow node with fast node overloaded, got %s", node.Id)
+	}
+}
+
+func TestDecay(t *testing.T) {
+	tr := NewTracker(10 * time.Millisecond)
+
+	tr.done("a", 100*time.Millisecond)
+	if c := tr.Cost("a"); c < float64(100*time.Millisecond) {
+		t.Fatalf("expected the spike to be taken straight away, got %v", c)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	tr.done("a", time.Millisecond)
+
+	if c := tr.Cost("a"); c > float64(10*time.Millisecond) {
+		t.Fatalf("expected the latency to decay, got %v", time.Duration(c))
+	}
+}
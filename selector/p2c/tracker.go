@@ -0,0 +1,86 @@
+package p2c
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type stat struct {
+	// ewma latency in nanoseconds
+	ewma float64
+	// in flight requests
+	pending int64
+	// time of the last observation
+	last time.Time
+}
+
+// Tracker records the latency and in flight requests of nodes by address
+type Tracker struct {
+	// decay is the time over which older observations lose weight
+	decay time.Duration
+
+	sync.RWMutex
+	stats map[string]*stat
+}
+
+func (t *Tracker) stat(addr string) *stat {
+	s, ok := t.stats[addr]
+	if !ok {
+		s = &stat{}
+		t.stats[addr] = s
+	}
+	return s
+}
+
+// start records the start of a request to the address
+func (t *Tracker) start(addr string) {
+	t.Lock()
+	t.stat(addr).pending++
+	t.Unlock()
+}
+
+// done records the end of a request and its latency. The average is
+// time decayed and jumps straight to latency spikes so slow nodes are
+// avoided quickly and recover gradually.
+func (t *Tracker) done(addr string, rtt time.Duration) {
+	now := time.Now()
+
+	t.Lock()
+	defer t.Unlock()
+
+	s := t.stat(addr)
+	if s.pending > 0 {
+		s.pending--
+	}
+
+	v := float64(rtt)
+	if s.last.IsZero() || v > s.ewma {
+		s.ewma = v
+	} else {
+		w := math.Exp(-float64(now.Sub(s.last)) / float64(t.decay))
+		s.ewma = s.ewma*w + v*(1-w)
+	}
+	s.last = now
+}
+
+// Cost of sending a request to the address. Nodes without observations
+// have no latency so are tried, their cost growing with in flight requests.
+func (t *Tracker) Cost(addr string) float64 {
+	t.RLock()
+	defer t.RUnlock()
+
+	s, ok := t.stats[addr]
+	if !ok {
+		return 0
+	}
+	return (s.ewma + 1) * float64(s.pending+1)
+}
+
+// NewTracker returns a tracker decaying observations over the duration
+func NewTracker(decay time.Duration) *Tracker {
+	return &Tracker{
+		decay: decay,
+		stats: make(map[string]*stat),
+	}
+}
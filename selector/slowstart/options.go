@@ -0,0 +1,71 @@
+package slowstart
+
+import (
+	"math"
+	"time"
+)
+
+// Curve maps the progress through the window, between 0 and 1,
+// and the minimum weight to the weight of a node
+type Curve func(progress, min float64) float64
+
+// Options for slow start
+type Options struct {
+	// Window over which traffic to a new node ramps up
+	Window time.Duration
+	// MinWeight is the share of traffic a new node starts with
+	MinWeight float64
+	// Curve of the ramp, Linear by default
+	Curve Curve
+}
+
+type Option func(*Options)
+
+// Linear ramps traffic up evenly over the window
+func Linear(progress, min float64) float64 {
+	return min + (1-min)*progress
+}
+
+// Exponential ramps traffic up slowly at first, doubling at a steady
+// rate from the minimum weight to full traffic over the window
+func Exponential(progress, min float64) float64 {
+	if min <= 0 {
+		min = 0.01
+	}
+	return min * math.Pow(1/min, progress)
+}
+
+// Window sets the time over which traffic ramps up
+func Window(d time.Duration) Option {
+	return func(o *Options) {
+		o.Window = d
+	}
+}
+
+// MinWeight sets the share of traffic, between 0 and 1, a new node starts with
+func MinWeight(w float64) Option {
+	return func(o *Options) {
+		o.MinWeight = w
+	}
+}
+
+// WithCurve sets the curve traffic ramps up with
+func WithCurve(c Curve) Option {
+	return func(o *Options) {
+		o.Curve = c
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Window:    time.Minute,
+		MinWeight: 0.1,
+		Curve:     Linear,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,180 @@
+// Package slowstart is a selector wrapper which ramps traffic up to newly
+// registered nodes gradually over a window, avoiding latency spikes and
+// cache stampedes on cold nodes. The registration time is read from the
+// node metadata, set with Metadata, or else the time the node was first seen.
+package slowstart
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/cmd"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+var (
+	// RegisteredKey is the node metadata key holding the unix registration time
+	RegisteredKey = "registered"
+
+	// attempts to pick a node before using the warmest
+	attempts = 3
+)
+
+type slowStartSelector struct {
+	selector.Selector
+	opts Options
+
+	sync.Mutex
+	// first seen time of nodes by service and node id
+	seen map[string]map[string]time.Time
+}
+
+func init() {
+	cmd.DefaultSelectors["slowstart"] = NewSelector
+}
+
+// Metadata returns the node metadata holding the registration time,
+// to be passed to the server with server.Metadata
+func Metadata() map[string]string {
+	return map[string]string{
+		RegisteredKey: strconv.FormatInt(time.Now().Unix(), 10),
+	}
+}
+
+// filter records when nodes are first seen. The nodes of a service
+// seen on the first select are treated as warm. It runs before the
+// caller's filters so nodes hidden by a per request filter aren't
+// forgotten and ramped again when they reappear.
+func (s *slowStartSelector) filter(services []*registry.Service) []*registry.Service {
+	if len(services) == 0 {
+		return services
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	name := services[0].Name
+
+	seen, ok := s.seen[name]
+	if !ok {
+		seen = make(map[string]time.Time)
+		s.seen[name] = seen
+	}
+
+	current := make(map[string]bool)
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			current[node.Id] = true
+			if _, known := seen[node.Id]; known {
+				continue
+			}
+			if !ok {
+				seen[node.Id] = time.Time{}
+			} else {
+				seen[node.Id] = now
+			}
+		}
+	}
+
+	// forget nodes which have gone
+	for id := range seen {
+		if !current[id] {
+			delete(seen, id)
+		}
+	}
+
+	return services
+}
+
+// registered returns the time the node was registered
+func (s *slowStartSelector) registered(service string, node *registry.Node) time.Time {
+	if v, ok := node.Metadata[RegisteredKey]; ok {
+		if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(sec, 0)
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	return s.seen[service][node.Id]
+}
+
+// weight returns the share of traffic, between the minimum weight and 1,
+// the node should receive
+func (s *slowStartSelector) weight(service string, node *registry.Node) float64 {
+	if s.opts.Window <= 0 {
+		return 1
+	}
+
+	elapsed := time.Since(s.registered(service, node))
+	if elapsed >= s.opts.Window {
+		return 1
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	w := s.opts.Curve(float64(elapsed)/float64(s.opts.Window), s.opts.MinWeight)
+	if w < s.opts.MinWeight {
+		return s.opts.MinWeight
+	}
+	return w
+}
+
+func (s *slowStartSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	// the filter sees the registry's nodes before the caller's filters
+	opts = append([]selector.SelectOption{selector.WithFilter(s.filter)}, opts...)
+
+	next, err := s.Selector.Select(service, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// nodes warming up are skipped in proportion to their weight
+	return func() (*registry.Node, error) {
+		var warmest *registry.Node
+		var max float64
+
+		for i := 0; i < attempts; i++ {
+			node, err := next()
+			if err != nil {
+				if warmest != nil {
+					return warmest, nil
+				}
+				return nil, err
+			}
+
+			w := s.weight(service, node)
+			if w >= 1 || rand.Float64() < w {
+				return node, nil
+			}
+			if warmest == nil || w > max {
+				warmest, max = node, w
+			}
+		}
+
+		return warmest, nil
+	}, nil
+}
+
+func (s *slowStartSelector) String() string {
+	return "slowstart"
+}
+
+// Wrap adds slow start to the selector
+func Wrap(s selector.Selector, opts ...Option) selector.Selector {
+	return &slowStartSelector{
+		Selector: s,
+		opts:     newOptions(opts...),
+		seen:     make(map[string]map[string]time.Time),
+	}
+}
+
+// NewSelector returns the default selector with slow start
+func NewSelector(opts ...selector.Option) selector.Selector {
+	return Wrap(selector.NewSelector(opts...))
+}
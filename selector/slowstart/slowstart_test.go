@@ -0,0 +1,122 @@
+package slowstart
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+type testSelector struct {
+	selector.Selector
+	nodes []*registry.Node
+}
+
+// round robins over the nodes after applying the filters
+func (t *testSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
+	var options selector.SelectOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	services := []*registry.Service{{Name: service, Nodes: t.nodes}}
+	for _, f := range options.Filters {
+		services = f(services)
+	}
+
+	var i int
+	return func() (*registry.Node, error) {
+		node := services[0].Nodes[i%len(services[0].Nodes)]
+		i++
+		return node, nil
+	}, nil
+}
+
+func TestWeight(t *testing.T) {
+	s := Wrap(&testSelector{}, Window(time.Minute), MinWeight(0.1)).(*slowStartSelector)
+
+	node := func(age time.Duration) *registry.Node {
+		return &registry.Node{
+			Id: "node",
+			Metadata: map[string]string{
+				RegisteredKey: strconv.FormatInt(time.Now().Add(-age).Unix(), 10),
+			},
+		}
+	}
+
+	if w := s.weight("foo", node(0)); w < 0.1 || w > 0.15 {
+		t.Fatalf("expected minimum weight got %v", w)
+	}
+	if w := s.weight("foo", node(30*time.Second)); w < 0.5 || w > 0.6 {
+		t.Fatalf("expected half weight got %v", w)
+	}
+	if w := s.weight("foo", node(time.Hour)); w != 1 {
+		t.Fatalf("expected full weight got %v", w)
+	}
+
+	s.opts.Curve = Exponential
+	if w := s.weight("foo", node(30*time.Second)); w < 0.3 || w > 0.35 {
+		t.Fatalf("expected exponential weight got %v", w)
+	}
+}
+
+func TestSlowStart(t *testing.T) {
+	ts := &testSelector{
+		nodes: []*registry.Node{{Id: "warm-1"}, {Id: "warm-2"}},
+	}
+	s := Wrap(ts, Window(time.Hour), MinWeight(0.01))
+
+	// nodes seen on the first select are warm
+	if _, err := s.Select("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	ts.nodes = append(ts.nodes, &registry.Node{Id: "cold"})
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		next, err := s.Select("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 3; j++ {
+			node, err := next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[node.Id]++
+		}
+	}
+
+	if counts["cold"] > 30 {
+		t.Fatalf("expected cold node to receive little traffic got %v", counts)
+	}
+	if counts["warm-1"] == 0 || counts["warm-2"] == 0 {
+		t.Fatalf("expected warm nodes to receive traffic got %v", counts)
+	}
+}
+
+func TestFilteredNodes(t *testing.T) {
+	ts := &testSelector{
+		nodes: []*registry.Node{{Id: "warm-1"}, {Id: "warm-2"}},
+	}
+	s := Wrap(ts, Window(time.Hour), MinWeight(0.01)).(*slowStartSelector)
+
+	if _, err := s.Select("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a per request filter hides a warm node
+	hide := selector.WithFilter(func(services []*registry.Service) []*registry.Service {
+		return []*registry.Service{{Name: "foo", Nodes: services[0].Nodes[:1]}}
+	})
+	if _, err := s.Select("foo", hide); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := s.weight("foo", ts.nodes[1]); w != 1 {
+		t.Fatalf("expected hidden node to stay warm got weight %v", w)
+	}
+}
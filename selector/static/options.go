@@ -0,0 +1,31 @@
+package static
+
+import (
+	"context"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-micro/selector"
+)
+
+type fileKey struct{}
+type configKey struct{}
+
+// File loads the route table from a json or yaml file, reloading it on change
+func File(path string) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, fileKey{}, path)
+	}
+}
+
+// Config loads the route table from a go-config config, reloading it on change
+func Config(c config.Config) selector.Option {
+	return func(o *selector.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, configKey{}, c)
+	}
+}
@@ -0,0 +1,94 @@
+package static
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-config/source/file"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/registry"
+)
+
+var (
+	// DefaultPath of the route table within the config
+	DefaultPath = []string{"routes"}
+)
+
+// routes maps a service name to its addresses
+type routes map[string][]string
+
+// nodes converts the addresses of a route to nodes
+func (r routes) nodes(service string) []*registry.Node {
+	var nodes []*registry.Node
+	for _, addr := range r[service] {
+		node := &registry.Node{
+			Id:      service + "-" + addr,
+			Address: addr,
+		}
+		// split the port so the node looks like a registered one
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if p, err := strconv.Atoi(port); err == nil {
+				node.Address = host
+				node.Port = p
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func newFileConfig(path string) config.Config {
+	return config.NewConfig(config.WithSource(file.NewSource(file.WithPath(path))))
+}
+
+func (s *staticSelector) load(c config.Config) {
+	var r routes
+	if err := c.Get(DefaultPath...).Scan(&r); err != nil {
+		log.Logf("[static] failed to load routes: %v", err)
+		return
+	}
+	s.update(r)
+}
+
+func (s *staticSelector) update(r routes) {
+	s.Lock()
+	s.routes = r
+	s.Unlock()
+}
+
+func (s *staticSelector) watch(c config.Config, exit chan bool) {
+	w, err := c.Watch(DefaultPath...)
+	if err != nil {
+		log.Logf("[static] failed to watch routes: %v", err)
+		return
+	}
+
+	go func() {
+		<-exit
+		w.Stop()
+	}()
+
+	for {
+		v, err := w.Next()
+		if err != nil {
+			select {
+			case <-exit:
+				return
+			default:
+			}
+			log.Logf("[static] watcher error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var r routes
+		if err := v.Scan(&r); err != nil {
+			log.Logf("[static] failed to scan routes, skipping update: %v", err)
+			continue
+		}
+
+		s.update(r)
+	}
+}
@@ -1,11 +1,15 @@
 // Package static is a selector which always returns the name specified with a port-number appended.
-// AN optional domain-name will also be added.
+// AN optional domain-name will also be added. A route table loaded from a file or config source
+// can map names to explicit addresses and is reloaded on change.
 package static
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sync"
 
+	"github.com/micro/go-config"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/selector"
@@ -18,9 +22,15 @@ const (
 )
 
 type staticSelector struct {
+	opts          selector.Options
 	addressSuffix string
 	envDomainName string
 	envPortNumber string
+	exit          chan bool
+
+	sync.RWMutex
+	routes routes
+	next   map[string]int
 }
 
 func init() {
@@ -28,19 +38,99 @@ func init() {
 }
 
 func (s *staticSelector) Init(opts ...selector.Option) error {
+	for _, o := range opts {
+		o(&s.opts)
+	}
+	s.configure()
 	return nil
 }
 
 func (s *staticSelector) Options() selector.Options {
-	return selector.Options{}
+	return s.opts
+}
+
+// configure loads and watches the route table if one is set.
+// Any existing watcher is stopped first, even without a new table.
+func (s *staticSelector) configure() {
+	s.Lock()
+	select {
+	case <-s.exit:
+	default:
+		close(s.exit)
+	}
+	exit := make(chan bool)
+	s.exit = exit
+	s.Unlock()
+
+	var c config.Config
+
+	if v, ok := s.opts.Context.Value(configKey{}).(config.Config); ok {
+		c = v
+	} else if path, ok := s.opts.Context.Value(fileKey{}).(string); ok {
+		c = newFileConfig(path)
+	}
+
+	if c == nil {
+		s.update(nil)
+		return
+	}
+
+	s.load(c)
+	go s.watch(c, exit)
 }
 
 func (s *staticSelector) Select(service string, opts ...selector.SelectOption) (selector.Next, error) {
-	node := &registry.Node{
-		Id:      service,
-		Address: fmt.Sprintf("%v%v", service, s.addressSuffix),
+	sopts := selector.SelectOptions{
+		Strategy: s.opts.Strategy,
+	}
+
+	for _, opt := range opts {
+		opt(&sopts)
+	}
+
+	s.RLock()
+	nodes := s.routes.nodes(service)
+	s.RUnlock()
+
+	routed := len(nodes) > 0
+	if !routed {
+		nodes = []*registry.Node{{
+			Id:      service,
+			Address: fmt.Sprintf("%v%v", service, s.addressSuffix),
+		}}
 	}
 
+	// apply the filters to the nodes as a service
+	services := []*registry.Service{{Name: service, Nodes: nodes}}
+	for _, filter := range sopts.Filters {
+		services = filter(services)
+	}
+
+	nodes = nil
+	for _, svc := range services {
+		nodes = append(nodes, svc.Nodes...)
+	}
+
+	if len(nodes) == 0 {
+		return nil, selector.ErrNoneAvailable
+	}
+
+	// route table entries are round robined
+	if routed {
+		s.Lock()
+		i := s.next[service]
+		s.next[service]++
+		s.Unlock()
+
+		return func() (*registry.Node, error) {
+			node := nodes[i%len(nodes)]
+			i++
+			return node, nil
+		}, nil
+	}
+
+	node := nodes[0]
+
 	return func() (*registry.Node, error) {
 		return node, nil
 	}, nil
@@ -55,6 +145,14 @@ func (s *staticSelector) Reset(service string) {
 }
 
 func (s *staticSelector) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	select {
+	case <-s.exit:
+	default:
+		close(s.exit)
+	}
 	return nil
 }
 
@@ -64,8 +162,19 @@ func (s *staticSelector) String() string {
 
 func NewSelector(opts ...selector.Option) selector.Selector {
 
+	options := selector.Options{
+		Context: context.Background(),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
 	// Build a new
 	s := &staticSelector{
+		opts:          options,
+		exit:          make(chan bool),
+		next:          make(map[string]int),
 		addressSuffix: "",
 		envDomainName: os.Getenv(ENV_STATIC_SELECTOR_DOMAIN_NAME),
 		envPortNumber: os.Getenv(ENV_STATIC_SELECTOR_PORT_NUMBER),
@@ -83,5 +192,7 @@ func NewSelector(opts ...selector.Option) selector.Selector {
 		s.addressSuffix += fmt.Sprintf(":%v", s.envPortNumber)
 	}
 
+	s.configure()
+
 	return s
 }
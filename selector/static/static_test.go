@@ -1,9 +1,15 @@
 package static
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-config/source/memory"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
 )
 
 const (
@@ -130,3 +136,121 @@ func TestStaticSelectorWithBothOverrides(t *testing.T) {
 		}
 	}
 }
+
+func TestStaticSelectorWithRoutes(t *testing.T) {
+	os.Setenv(ENV_STATIC_SELECTOR_DOMAIN_NAME, "")
+	os.Setenv(ENV_STATIC_SELECTOR_PORT_NUMBER, "")
+
+	data := []byte(`{"routes": {"foo": ["10.0.0.1:9090", "10.0.0.2:9090"]}}`)
+	conf := config.NewConfig(config.WithSource(memory.NewSource(memory.WithData(data))))
+
+	s := NewSelector(Config(conf))
+	defer s.Close()
+
+	next, err := s.Select("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Port != 9090 {
+			t.Fatalf("got port %d expected 9090", node.Port)
+		}
+		seen[node.Address] = true
+	}
+
+	if !seen["10.0.0.1"] || !seen["10.0.0.2"] {
+		t.Fatalf("expected both route addresses got %v", seen)
+	}
+
+	// names without a route fall back to the suffix
+	next, err = s.Select("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, _ := next()
+	if expectedAddress := fmt.Sprintf("bar:%v", DEFAULT_PORT_NUMBER); node.Address != expectedAddress {
+		t.Fatalf("got %s expected %s", node.Address, expectedAddress)
+	}
+}
+
+func TestStaticSelectorFilters(t *testing.T) {
+	os.Setenv(ENV_STATIC_SELECTOR_DOMAIN_NAME, "")
+	os.Setenv(ENV_STATIC_SELECTOR_PORT_NUMBER, "")
+
+	data := []byte(`{"routes": {"foo": ["10.0.0.1:9090", "10.0.0.2:9090"]}}`)
+	conf := config.NewConfig(config.WithSource(memory.NewSource(memory.WithData(data))))
+
+	s := NewSelector(Config(conf))
+	defer s.Close()
+
+	only := func(addr string) selector.Filter {
+		return func(services []*registry.Service) []*registry.Service {
+			var filtered []*registry.Service
+			for _, svc := range services {
+				var nodes []*registry.Node
+				for _, node := range svc.Nodes {
+					if node.Address == addr {
+						nodes = append(nodes, node)
+					}
+				}
+				if len(nodes) > 0 {
+					filtered = append(filtered, &registry.Service{Name: svc.Name, Nodes: nodes})
+				}
+			}
+			return filtered
+		}
+	}
+
+	next, err := s.Select("foo", selector.WithFilter(only("10.0.0.2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Address != "10.0.0.2" {
+			t.Fatalf("got %s expected 10.0.0.2", node.Address)
+		}
+	}
+
+	// filtering out every node leaves none available
+	if _, err := s.Select("foo", selector.WithFilter(only("10.0.0.3"))); err != selector.ErrNoneAvailable {
+		t.Fatalf("expected %v got %v", selector.ErrNoneAvailable, err)
+	}
+	if _, err := s.Select("bar", selector.WithFilter(only("10.0.0.3"))); err != selector.ErrNoneAvailable {
+		t.Fatalf("expected %v got %v", selector.ErrNoneAvailable, err)
+	}
+}
+
+func TestStaticSelectorInitStopsWatcher(t *testing.T) {
+	data := []byte(`{"routes": {"foo": ["10.0.0.1:9090"]}}`)
+	conf := config.NewConfig(config.WithSource(memory.NewSource(memory.WithData(data))))
+
+	s := NewSelector(Config(conf)).(*staticSelector)
+	defer s.Close()
+
+	s.RLock()
+	exit := s.exit
+	s.RUnlock()
+
+	// reinitialising without a route table stops the old watcher
+	s.opts.Context = context.Background()
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-exit:
+	default:
+		t.Fatal("expected the old watcher to be stopped")
+	}
+}
@@ -3,10 +3,42 @@ package grpc
 import (
 	"net/http"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/micro/go-micro/errors"
 	"github.com/micro/grpc-go/codes"
+	"github.com/micro/grpc-go/status"
 )
 
+// statusError is implemented by errors which carry their own gRPC status,
+// such as those returned by WithDetails
+type statusError interface {
+	GRPCStatus() *status.Status
+}
+
+type detailError struct {
+	*errors.Error
+	status *status.Status
+}
+
+func (e *detailError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// WithDetails returns the go-micro error with gRPC status details attached,
+// such as *errdetails.RetryInfo, *errdetails.QuotaFailure or
+// *errdetails.BadRequest, so rich error metadata reaches grpc clients.
+// The error is returned as is if the details can't be marshalled.
+func WithDetails(err *errors.Error, details ...proto.Message) error {
+	s, derr := status.New(microError(err), err.Error()).WithDetails(details...)
+	if derr != nil {
+		return err
+	}
+	return &detailError{
+		Error:  err,
+		status: s,
+	}
+}
+
 func microError(err *errors.Error) codes.Code {
 	switch err {
 	case nil:
@@ -36,6 +68,8 @@ func microError(err *errors.Error) codes.Code {
 		return codes.Internal
 	case http.StatusServiceUnavailable:
 		return codes.Unavailable
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
 	}
 
 	return codes.Unknown
@@ -23,6 +23,7 @@ import (
 	meta "github.com/micro/go-micro/metadata"
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/server"
+	"github.com/micro/go-plugins/codec/negotiate"
 	"github.com/micro/util/go/lib/addr"
 	mgrpc "github.com/micro/util/go/lib/grpc"
 
@@ -50,6 +51,8 @@ type grpcServer struct {
 	subscribers map[*subscriber][]broker.Subscriber
 	// used for first registration
 	registered bool
+	// rejecting new requests while stopping
+	draining bool
 }
 
 func init() {
@@ -139,6 +142,12 @@ func (g *grpcServer) accept(rawConn net.Conn) {
 	serverConfig := g.getHttp2TransportConfig()
 	serverConfig.AuthInfo = authInfo
 
+	if g.opts.Context != nil {
+		if n, ok := g.opts.Context.Value(maxConcurrentStreams{}).(uint32); ok && n > 0 {
+			serverConfig.MaxStreams = n
+		}
+	}
+
 	st, err := transport.NewServerTransport("http2", conn, &serverConfig)
 	if err != nil {
 		conn.Close()
@@ -169,6 +178,18 @@ func (g *grpcServer) accept(rawConn net.Conn) {
 }
 
 func (g *grpcServer) serveStream(t transport.ServerTransport, stream *transport.Stream) {
+	g.RLock()
+	draining := g.draining
+	g.RUnlock()
+
+	// reject new requests so the client retries another node
+	if draining {
+		if err := t.WriteStatus(stream, status.New(codes.Unavailable, "server is draining")); err != nil {
+			log.Logf("grpc: Server.serveStream failed to write status: %v", err)
+		}
+		return
+	}
+
 	// get Go method from stream method
 	serviceName, methodName, err := mgrpc.ServiceMethod(stream.Method())
 	if err != nil {
@@ -235,10 +256,19 @@ func (g *grpcServer) serveStream(t transport.ServerTransport, stream *transport.
 	// set the timeout if we have it
 	if len(to) > 0 {
 		if n, err := strconv.ParseUint(to, 10, 64); err == nil {
-			ctx, _ = context.WithTimeout(ctx, time.Duration(n))
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(n))
+			defer cancel()
 		}
 	}
 
+	// set the endpoint timeout, the sooner deadline wins
+	if d := g.handlerTimeout(serviceName + "." + methodName); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
 	// process unary
 	if !mtype.stream {
 		g.processRequest(t, stream, service, mtype, codec, ct, ctx)
@@ -369,9 +399,27 @@ func (g *grpcServer) processRequest(t transport.ServerTransport, stream *transpo
 			fn = g.opts.HdlrWrappers[i-1](fn)
 		}
 
+		// the interceptors call the wrapped handler
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			r.request = req
+			if err := fn(ctx, r, replyv.Interface()); err != nil {
+				return nil, err
+			}
+			return replyv.Interface(), nil
+		}
+
+		info := &grpc.UnaryServerInfo{
+			Server:     service.rcvr.Interface(),
+			FullMethod: stream.Method(),
+		}
+
 		// execute the handler
-		if appErr := fn(ctx, r, replyv.Interface()); appErr != nil {
-			if err, ok := appErr.(*rpcError); ok {
+		reply, appErr := g.unaryInterceptor()(ctx, argv.Interface(), info, handler)
+		if appErr != nil {
+			var st *status.Status
+			if err, ok := appErr.(statusError); ok {
+				st = err.GRPCStatus()
+			} else if err, ok := appErr.(*rpcError); ok {
 				statusCode = err.code
 				statusDesc = err.desc
 			} else if err, ok := appErr.(*errors.Error); ok {
@@ -381,7 +429,10 @@ func (g *grpcServer) processRequest(t transport.ServerTransport, stream *transpo
 				statusCode = convertCode(appErr)
 				statusDesc = appErr.Error()
 			}
-			if err := t.WriteStatus(stream, status.New(statusCode, statusDesc)); err != nil {
+			if st == nil {
+				st = status.New(statusCode, statusDesc)
+			}
+			if err := t.WriteStatus(stream, st); err != nil {
 				log.Logf("grpc: Server.processUnaryRPC failed to write status: %v", err)
 				return err
 			}
@@ -391,7 +442,7 @@ func (g *grpcServer) processRequest(t transport.ServerTransport, stream *transpo
 			Last:  true,
 			Delay: false,
 		}
-		if err := g.sendResponse(t, stream, replyv.Interface(), codec, opts); err != nil {
+		if err := g.sendResponse(t, stream, reply, codec, opts); err != nil {
 			switch err := err.(type) {
 			case transport.ConnectionError:
 				// Nothing to do here.
@@ -425,6 +476,7 @@ func (g *grpcServer) processStream(t transport.ServerTransport, stream *transpor
 		p:          &parser{r: stream},
 		codec:      codec,
 		maxMsgSize: defaultMaxMsgSize,
+		ctx:        ctx,
 	}
 
 	function := mtype.method.Func
@@ -444,9 +496,26 @@ func (g *grpcServer) processStream(t transport.ServerTransport, stream *transpor
 		fn = opts.HdlrWrappers[i-1](fn)
 	}
 
-	appErr := fn(ctx, r, ss)
+	// the interceptors call the wrapped handler
+	handler := func(srv interface{}, gs grpc.ServerStream) error {
+		var st server.Stream = ss
+		if gs != grpc.ServerStream(ss) {
+			st = &serverStream{ServerStream: gs, request: r}
+		}
+		return fn(gs.Context(), r, st)
+	}
+
+	info := &grpc.StreamServerInfo{
+		FullMethod:     stream.Method(),
+		IsClientStream: true,
+		IsServerStream: true,
+	}
+
+	appErr := g.streamInterceptor()(service.rcvr.Interface(), ss, info, handler)
 	if appErr != nil {
-		if err, ok := appErr.(*rpcError); ok {
+		if err, ok := appErr.(statusError); ok {
+			return t.WriteStatus(ss.s, err.GRPCStatus())
+		} else if err, ok := appErr.(*rpcError); ok {
 			ss.statusCode = err.code
 			ss.statusDesc = err.desc
 		} else if err, ok := appErr.(*errors.Error); ok {
@@ -480,6 +549,27 @@ func (g *grpcServer) newGRPCCodec(contentType string) (grpc.Codec, error) {
 	return nil, fmt.Errorf("Unsupported Content-Type: %s", contentType)
 }
 
+// contentTypes returns the content types the server can decode
+func (g *grpcServer) contentTypes() []string {
+	codecs := make(map[string]bool)
+	for ct := range defaultGRPCCodecs {
+		codecs[ct] = true
+	}
+	if g.opts.Context != nil {
+		if v := g.opts.Context.Value(codecsKey{}); v != nil {
+			for ct := range v.(map[string]grpc.Codec) {
+				codecs[ct] = true
+			}
+		}
+	}
+
+	cts := make([]string, 0, len(codecs))
+	for ct := range codecs {
+		cts = append(cts, ct)
+	}
+	return cts
+}
+
 func (g *grpcServer) newCodec(contentType string) (codec.NewCodec, error) {
 	if cf, ok := g.opts.Codecs[contentType]; ok {
 		return cf, nil
@@ -585,6 +675,9 @@ func (g *grpcServer) Register() error {
 	node.Metadata["transport"] = g.String()
 	// node.Metadata["transport"] = config.Transport.String()
 
+	// advertise the codecs we support for negotiation
+	negotiate.Advertise(node.Metadata, g.contentTypes())
+
 	g.RLock()
 	// Maps are ordered randomly, sort the keys for consistency
 	var handlerList []string
@@ -751,8 +844,23 @@ func (g *grpcServer) Start() error {
 		// wait for exit
 		ch := <-g.exit
 
-		// wait for waitgroup
-		if wait(g.opts.Context) {
+		if d := drain(g.opts.Context); d > 0 {
+			// remove the node before rejecting requests
+			if err := g.Deregister(); err != nil {
+				log.Logf("Failed to deregister while draining: %v", err)
+			}
+
+			g.Lock()
+			g.draining = true
+			g.Unlock()
+
+			log.Logf("Draining for %v", d)
+			time.Sleep(d)
+
+			// wait for in-flight requests
+			g.wg.Wait()
+		} else if wait(g.opts.Context) {
+			// wait for waitgroup
 			g.wg.Wait()
 		}
 
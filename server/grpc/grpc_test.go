@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/micro/go-micro/registry/mock"
 	"github.com/micro/go-micro/server"
@@ -72,3 +73,50 @@ func TestGRPCServer(t *testing.T) {
 		}
 	}
 }
+
+func TestGRPCServerInterceptor(t *testing.T) {
+	var calls []string
+
+	intercept := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("expected handler deadline for %s", info.FullMethod)
+			}
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+
+	s := NewServer(
+		server.Name("foo"),
+		server.Registry(mock.NewRegistry()),
+		UnaryInterceptor(intercept("a"), intercept("b")),
+		HandlerTimeout("Say.Hello", time.Second),
+		MaxConcurrentStreams(10),
+	)
+
+	pb.RegisterSayHandler(s, &sayServer{})
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer s.Stop()
+
+	cc, err := grpc.Dial(s.Options().Address, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("failed to dial server: %v", err)
+	}
+
+	rsp := pb.Response{}
+	if err := grpc.Invoke(context.Background(), "Say.Hello", &pb.Request{Name: "John"}, &rsp, cc); err != nil {
+		t.Fatalf("error calling server: %v", err)
+	}
+
+	if rsp.Msg != "Hello John" {
+		t.Fatalf("Got unexpected response %v", rsp.Msg)
+	}
+
+	if len(calls) != 2 || calls[0] != "a" || calls[1] != "b" {
+		t.Fatalf("unexpected interceptor calls %v", calls)
+	}
+}
@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/server"
+	"github.com/micro/grpc-go"
+	"github.com/micro/grpc-go/metadata"
+)
+
+// serverStream adapts a grpc.ServerStream returned by
+// an interceptor to the server.Stream used by handlers
+type serverStream struct {
+	grpc.ServerStream
+	request server.Request
+}
+
+func (s *serverStream) Request() server.Request {
+	return s.request
+}
+
+func (s *serverStream) Send(m interface{}) error {
+	return s.SendMsg(m)
+}
+
+func (s *serverStream) Recv(m interface{}) error {
+	return s.RecvMsg(m)
+}
+
+func (s *serverStream) Error() error {
+	return nil
+}
+
+func (s *serverStream) Close() error {
+	return nil
+}
+
+func (r *rpcStream) SetHeader(md metadata.MD) error {
+	return r.s.SetHeader(md)
+}
+
+func (r *rpcStream) SendHeader(md metadata.MD) error {
+	return r.t.WriteHeader(r.s, md)
+}
+
+func (r *rpcStream) SetTrailer(md metadata.MD) {
+	r.s.SetTrailer(md)
+}
+
+func (r *rpcStream) SendMsg(m interface{}) error {
+	return r.Send(m)
+}
+
+func (r *rpcStream) RecvMsg(m interface{}) error {
+	return r.Recv(m)
+}
+
+func (g *grpcServer) unaryInterceptor() grpc.UnaryServerInterceptor {
+	var ints []grpc.UnaryServerInterceptor
+	if g.opts.Context != nil {
+		ints, _ = g.opts.Context.Value(unaryInterceptors{}).([]grpc.UnaryServerInterceptor)
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(ints); i > 0; i-- {
+			next, h := ints[i-1], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return next(ctx, req, info, h)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (g *grpcServer) streamInterceptor() grpc.StreamServerInterceptor {
+	var ints []grpc.StreamServerInterceptor
+	if g.opts.Context != nil {
+		ints, _ = g.opts.Context.Value(streamInterceptors{}).([]grpc.StreamServerInterceptor)
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(ints); i > 0; i-- {
+			next, h := ints[i-1], handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return next(srv, ss, info, h)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// handlerTimeout returns the deadline configured for the endpoint
+func (g *grpcServer) handlerTimeout(endpoint string) time.Duration {
+	if g.opts.Context == nil {
+		return 0
+	}
+	timeouts, _ := g.opts.Context.Value(handlerTimeouts{}).(map[string]time.Duration)
+	return timeouts[endpoint]
+}
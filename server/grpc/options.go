@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"time"
 
 	"github.com/micro/go-micro/broker"
 	"github.com/micro/go-micro/codec"
@@ -17,6 +18,11 @@ import (
 type codecsKey struct{}
 type tlsAuth struct{}
 type transportConfig struct{}
+type unaryInterceptors struct{}
+type streamInterceptors struct{}
+type handlerTimeouts struct{}
+type maxConcurrentStreams struct{}
+type drainPeriod struct{}
 
 // gRPC Codec to be used to encode/decode requests for a given content type
 func Codec(contentType string, c grpc.Codec) server.Option {
@@ -53,6 +59,74 @@ func TransportConfig(sc *grpcTransport.ServerConfig) server.Option {
 	}
 }
 
+// UnaryInterceptor adds gRPC unary server interceptors. They are chained in
+// order with the first being outermost and are called before handler wrappers.
+func UnaryInterceptor(i ...grpc.UnaryServerInterceptor) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		if v, ok := o.Context.Value(unaryInterceptors{}).([]grpc.UnaryServerInterceptor); ok {
+			i = append(v, i...)
+		}
+		o.Context = context.WithValue(o.Context, unaryInterceptors{}, i)
+	}
+}
+
+// StreamInterceptor adds gRPC stream server interceptors. They are chained in
+// order with the first being outermost and are called before handler wrappers.
+func StreamInterceptor(i ...grpc.StreamServerInterceptor) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		if v, ok := o.Context.Value(streamInterceptors{}).([]grpc.StreamServerInterceptor); ok {
+			i = append(v, i...)
+		}
+		o.Context = context.WithValue(o.Context, streamInterceptors{}, i)
+	}
+}
+
+// HandlerTimeout sets the deadline for an endpoint e.g Greeter.Hello.
+// The deadline sent by the client is used if it's sooner.
+func HandlerTimeout(endpoint string, d time.Duration) server.Option {
+	return func(o *server.Options) {
+		timeouts := make(map[string]time.Duration)
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		if v, ok := o.Context.Value(handlerTimeouts{}).(map[string]time.Duration); ok {
+			for k, t := range v {
+				timeouts[k] = t
+			}
+		}
+		timeouts[endpoint] = d
+		o.Context = context.WithValue(o.Context, handlerTimeouts{}, timeouts)
+	}
+}
+
+// MaxConcurrentStreams limits the number of concurrent streams per connection
+func MaxConcurrentStreams(n uint32) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, maxConcurrentStreams{}, n)
+	}
+}
+
+// DrainPeriod enables graceful drain on Stop. The server deregisters, then
+// rejects new requests as unavailable for the period so clients retry elsewhere,
+// waits for in-flight requests to complete and finally closes.
+func DrainPeriod(d time.Duration) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, drainPeriod{}, d)
+	}
+}
+
 func newOptions(opt ...server.Option) server.Options {
 	opts := server.Options{
 		Codecs:   make(map[string]codec.NewCodec),
@@ -60,6 +60,7 @@ type rpcStream struct {
 
 	// micro things
 	request server.Request
+	ctx     context.Context
 }
 
 func (r *rpcStream) Close() error {
@@ -75,6 +76,9 @@ func (r *rpcStream) Request() server.Request {
 }
 
 func (r *rpcStream) Context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
 	return r.s.Context()
 }
 
@@ -41,6 +41,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"time"
 
 	"github.com/micro/grpc-go"
 	"github.com/micro/grpc-go/codes"
@@ -304,6 +305,14 @@ func convertCode(err error) codes.Code {
 	return codes.Unknown
 }
 
+func drain(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
+	}
+	d, _ := ctx.Value(drainPeriod{}).(time.Duration)
+	return d
+}
+
 func wait(ctx context.Context) bool {
 	if ctx == nil {
 		return false
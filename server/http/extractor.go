@@ -6,10 +6,20 @@ import (
 
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/server"
+	"github.com/micro/go-plugins/codec/negotiate"
 
 	"github.com/micro/util/go/lib/addr"
 )
 
+// contentTypes returns the content types of the configured codecs
+func contentTypes(opts server.Options) []string {
+	cts := make([]string, 0, len(opts.Codecs))
+	for ct := range opts.Codecs {
+		cts = append(cts, ct)
+	}
+	return cts
+}
+
 func serviceDef(opts server.Options) *registry.Service {
 	var advt, host string
 	var port int
@@ -42,6 +52,9 @@ func serviceDef(opts server.Options) *registry.Service {
 
 	node.Metadata["server"] = "http"
 
+	// advertise the codecs we support for negotiation
+	negotiate.Advertise(node.Metadata, contentTypes(opts))
+
 	return &registry.Service{
 		Name:    opts.Name,
 		Version: opts.Version,
@@ -2,11 +2,15 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"sync"
+	"time"
 
+	"github.com/micro/go-log"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/server"
@@ -15,8 +19,10 @@ import (
 type httpServer struct {
 	sync.Mutex
 	opts server.Options
-	hd   server.Handler
+	hds  []server.Handler
 	exit chan chan error
+	// rejecting new requests while stopping
+	draining bool
 }
 
 func init() {
@@ -39,13 +45,24 @@ func (h *httpServer) Init(opts ...server.Option) error {
 	return nil
 }
 
+// Handle registers a http.Handler. It may be called multiple times
+// with handlers created from Mount to serve them at their prefixes.
 func (h *httpServer) Handle(handler server.Handler) error {
 	if _, ok := handler.Handler().(http.Handler); !ok {
 		return errors.New("Handle requires http.Handler")
 	}
 	h.Lock()
-	h.hd = handler
-	h.Unlock()
+	defer h.Unlock()
+
+	// the mux panics on duplicate patterns so they're rejected here
+	p := pattern(handler)
+	for _, hd := range h.hds {
+		if pattern(hd) == p {
+			return fmt.Errorf("a handler is already served at %s", p)
+		}
+	}
+
+	h.hds = append(h.hds, handler)
 	return nil
 }
 
@@ -96,7 +113,10 @@ func (h *httpServer) Subscribe(s server.Subscriber) error {
 func (h *httpServer) Register() error {
 	h.Lock()
 	opts := h.opts
-	eps := h.hd.Endpoints()
+	var eps []*registry.Endpoint
+	for _, hd := range h.hds {
+		eps = append(eps, hd.Endpoints()...)
+	}
 	h.Unlock()
 
 	service := serviceDef(opts)
@@ -121,9 +141,13 @@ func (h *httpServer) Deregister() error {
 func (h *httpServer) Start() error {
 	h.Lock()
 	opts := h.opts
-	hd := h.hd
+	hds := h.hds
 	h.Unlock()
 
+	if len(hds) == 0 {
+		return errors.New("Server required http.Handler")
+	}
+
 	ln, err := net.Listen("tcp", opts.Address)
 	if err != nil {
 		return err
@@ -133,21 +157,61 @@ func (h *httpServer) Start() error {
 	h.opts.Address = ln.Addr().String()
 	h.Unlock()
 
-	handler, ok := hd.Handler().(http.Handler)
-	if !ok {
-		return errors.New("Server required http.Handler")
+	srv := &http.Server{
+		Handler: h.drain(wrap(opts, newMux(hds))),
 	}
 
-	go http.Serve(ln, handler)
+	go srv.Serve(ln)
 
 	go func() {
 		ch := <-h.exit
-		ch <- ln.Close()
+
+		if d := drainPeriod(opts); d > 0 {
+			// remove the node before rejecting requests
+			if err := h.Deregister(); err != nil {
+				log.Logf("Failed to deregister while draining: %v", err)
+			}
+
+			h.Lock()
+			h.draining = true
+			h.Unlock()
+
+			log.Logf("Draining for %v", d)
+			time.Sleep(d)
+		}
+
+		// wait for active requests to complete
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout(opts))
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			ch <- srv.Close()
+			return
+		}
+		ch <- nil
 	}()
 
 	return nil
 }
 
+// drain rejects new requests while the server is draining
+func (h *httpServer) drain(hd http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Lock()
+		draining := h.draining
+		h.Unlock()
+
+		if draining {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "server is draining", http.StatusServiceUnavailable)
+			return
+		}
+
+		hd.ServeHTTP(w, r)
+	})
+}
+
 func (h *httpServer) Stop() error {
 	ch := make(chan error)
 	h.exit <- ch
@@ -1,14 +1,20 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/registry/mock"
 	"github.com/micro/go-micro/server"
+	"github.com/micro/go-plugins/codec/negotiate"
 )
 
 func TestHTTPServer(t *testing.T) {
@@ -87,3 +93,168 @@ func TestHTTPServer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestHTTPServerMount(t *testing.T) {
+	reg := mock.NewRegistry()
+
+	// reject requests without a token
+	auth := func(fn server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			md, _ := metadata.FromContext(ctx)
+			if md["X-Token"] != "secret" {
+				return errors.Unauthorized(req.Service(), "invalid token")
+			}
+			return fn(ctx, req, rsp)
+		}
+	}
+
+	var mtx sync.Mutex
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mtx.Lock()
+				order = append(order, name)
+				mtx.Unlock()
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	srv := NewServer(
+		server.Registry(reg),
+		server.WrapHandler(auth),
+		Middleware(mw("a"), mw("b")),
+	)
+
+	v1 := http.NewServeMux()
+	v1.HandleFunc("/foo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`v1 ` + r.URL.Path))
+	})
+
+	root := http.NewServeMux()
+	root.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`root ` + r.URL.Path))
+	})
+
+	if err := srv.Handle(srv.NewHandler(Mount("/v1", v1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Handle(srv.NewHandler(root)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	addr := srv.Options().Address
+
+	get := func(path, token string) (int, string) {
+		req, _ := http.NewRequest("GET", "http://"+addr+path, nil)
+		if len(token) > 0 {
+			req.Header.Set("X-Token", token)
+		}
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rsp.Body.Close()
+		b, _ := ioutil.ReadAll(rsp.Body)
+		return rsp.StatusCode, string(b)
+	}
+
+	testData := []struct {
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{"/v1/foo", "secret", 200, "v1 /foo"},
+		{"/bar", "secret", 200, "root /bar"},
+	}
+
+	for _, d := range testData {
+		status, body := get(d.path, d.token)
+		if status != d.status || body != d.body {
+			t.Fatalf("%s: expected %d %s got %d %s", d.path, d.status, d.body, status, body)
+		}
+	}
+
+	if status, _ := get("/v1/foo", ""); status != 401 {
+		t.Fatalf("expected 401 got %d", status)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	if len(order) != 6 || order[0] != "a" || order[1] != "b" {
+		t.Fatalf("unexpected middleware order %v", order)
+	}
+}
+
+func TestHTTPServerDuplicateHandler(t *testing.T) {
+	srv := NewServer(server.Registry(mock.NewRegistry()))
+
+	h := http.NotFoundHandler()
+
+	if err := srv.Handle(srv.NewHandler(h)); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Handle(srv.NewHandler(Mount("/v1", h))); err != nil {
+		t.Fatal(err)
+	}
+
+	// patterns the mux would panic on are rejected
+	for _, hd := range []http.Handler{h, Mount("/", h), Mount("/v1/", h)} {
+		if err := srv.Handle(srv.NewHandler(hd)); err == nil {
+			t.Fatal("expected duplicate handler to be rejected")
+		}
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Stop()
+}
+
+func TestHTTPServerDrain(t *testing.T) {
+	srv := NewServer(server.Registry(mock.NewRegistry())).(*httpServer)
+
+	h := srv.drain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`ok`))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 200 {
+		t.Fatalf("expected 200 got %d", w.Code)
+	}
+
+	srv.draining = true
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 503 || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected retryable 503 got %d", w.Code)
+	}
+}
+
+func TestHTTPServerCodecs(t *testing.T) {
+	opts := newOptions(
+		server.Codec("application/json", nil),
+		server.Codec("application/protobuf", nil),
+	)
+
+	s := serviceDef(opts)
+	if cts := negotiate.Supported(s.Nodes[0]); len(cts) != 2 || cts[0] != "application/json" || cts[1] != "application/protobuf" {
+		t.Fatalf("expected the configured codecs to be advertised got %v", cts)
+	}
+
+	// nothing is advertised without codecs
+	s = serviceDef(newOptions())
+	if cts := negotiate.Supported(s.Nodes[0]); cts != nil {
+		t.Fatalf("expected no codecs to be advertised got %v", cts)
+	}
+}
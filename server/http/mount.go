@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+// mount is a http.Handler served at a path prefix
+type mount struct {
+	prefix string
+	http.Handler
+}
+
+// writer records whether the response was started
+type writer struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+// Mount serves the handler at the path prefix with the prefix stripped.
+// Use it to register multiple routers e.g chi or gin with the same server.
+//
+//	srv.Handle(srv.NewHandler(httpServer.Mount("/v1", router)))
+func Mount(prefix string, h http.Handler) http.Handler {
+	return &mount{
+		prefix:  "/" + strings.Trim(prefix, "/"),
+		Handler: h,
+	}
+}
+
+func (w *writer) WriteHeader(code int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// pattern returns the path prefix the handler is served at
+func pattern(hd server.Handler) string {
+	if m, ok := hd.Handler().(*mount); ok {
+		return m.prefix
+	}
+	return "/"
+}
+
+// newMux builds the handler serving all mounted handlers
+func newMux(hds []server.Handler) http.Handler {
+	// a single handler is served as is
+	if len(hds) == 1 {
+		if hd, ok := hds[0].Handler().(http.Handler); ok {
+			if _, ok := hd.(*mount); !ok {
+				return hd
+			}
+		}
+	}
+
+	mux := http.NewServeMux()
+
+	for _, hd := range hds {
+		switch h := hd.Handler().(type) {
+		case *mount:
+			if h.prefix == "/" {
+				mux.Handle("/", h.Handler)
+				continue
+			}
+			sh := http.StripPrefix(h.prefix, h.Handler)
+			mux.Handle(h.prefix, sh)
+			mux.Handle(h.prefix+"/", sh)
+		case http.Handler:
+			mux.Handle("/", h)
+		}
+	}
+
+	return mux
+}
+
+// wrap applies the handler wrappers and then the middleware
+// so the middleware sees every request including rejected ones
+func wrap(opts server.Options, h http.Handler) http.Handler {
+	h = wrapHandler(opts, h)
+
+	if mw, ok := opts.Context.Value(middlewareKey{}).([]func(http.Handler) http.Handler); ok {
+		for i := len(mw); i > 0; i-- {
+			h = mw[i-1](h)
+		}
+	}
+
+	return h
+}
+
+func wrapHandler(opts server.Options, h http.Handler) http.Handler {
+	if len(opts.HdlrWrappers) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := make(metadata.Metadata, len(r.Header))
+		for k, v := range r.Header {
+			md[k] = strings.Join(v, ",")
+		}
+
+		ctx := metadata.NewContext(r.Context(), md)
+		wr := &writer{ResponseWriter: w}
+
+		fn := func(ctx context.Context, req server.Request, rsp interface{}) error {
+			h.ServeHTTP(rsp.(http.ResponseWriter), req.Request().(*http.Request).WithContext(ctx))
+			return nil
+		}
+
+		for i := len(opts.HdlrWrappers); i > 0; i-- {
+			fn = opts.HdlrWrappers[i-1](fn)
+		}
+
+		req := &httpRequest{service: opts.Name, r: r}
+
+		err := fn(ctx, req, wr)
+		if err == nil || wr.wrote {
+			return
+		}
+
+		// the wrapper rejected the request
+		merr := errors.Parse(err.Error())
+		if merr.Code == 0 {
+			merr.Code = http.StatusInternalServerError
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(int(merr.Code))
+		w.Write([]byte(merr.Error()))
+	})
+}
@@ -2,12 +2,70 @@ package http
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/micro/go-micro/codec"
 	"github.com/micro/go-micro/registry"
 	"github.com/micro/go-micro/server"
 )
 
+var (
+	// DefaultShutdownTimeout is how long Stop waits for active requests
+	DefaultShutdownTimeout = time.Second * 10
+)
+
+type middlewareKey struct{}
+type shutdownTimeoutKey struct{}
+type drainPeriodKey struct{}
+
+// Middleware to wrap the handler with, applied in order so the first is outermost
+func Middleware(mw ...func(http.Handler) http.Handler) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		if v, ok := o.Context.Value(middlewareKey{}).([]func(http.Handler) http.Handler); ok {
+			mw = append(v, mw...)
+		}
+		o.Context = context.WithValue(o.Context, middlewareKey{}, mw)
+	}
+}
+
+// ShutdownTimeout is how long Stop waits for active requests to complete
+func ShutdownTimeout(d time.Duration) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, shutdownTimeoutKey{}, d)
+	}
+}
+
+// DrainPeriod enables graceful drain on Stop. The server deregisters, then
+// rejects new requests with 503 for the period so clients retry elsewhere,
+// waits for in-flight requests to complete and finally closes.
+func DrainPeriod(d time.Duration) server.Option {
+	return func(o *server.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, drainPeriodKey{}, d)
+	}
+}
+
+func drainPeriod(opts server.Options) time.Duration {
+	d, _ := opts.Context.Value(drainPeriodKey{}).(time.Duration)
+	return d
+}
+
+func shutdownTimeout(opts server.Options) time.Duration {
+	if d, ok := opts.Context.Value(shutdownTimeoutKey{}).(time.Duration); ok && d > 0 {
+		return d
+	}
+	return DefaultShutdownTimeout
+}
+
 func newOptions(opt ...server.Option) server.Options {
 	opts := server.Options{
 		Codecs:   make(map[string]codec.NewCodec),
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"net/http"
+)
+
+// httpRequest is the server.Request passed to handler wrappers
+type httpRequest struct {
+	service string
+	r       *http.Request
+}
+
+func (r *httpRequest) ContentType() string {
+	return r.r.Header.Get("Content-Type")
+}
+
+func (r *httpRequest) Service() string {
+	return r.service
+}
+
+// Method is the http method and path e.g GET /foo
+func (r *httpRequest) Method() string {
+	return r.r.Method + " " + r.r.URL.Path
+}
+
+// Request returns the *http.Request
+func (r *httpRequest) Request() interface{} {
+	return r.r
+}
+
+func (r *httpRequest) Stream() bool {
+	return false
+}
@@ -0,0 +1,342 @@
+// Package crdt is an eventually consistent data implementation replicated
+// between instances over a broker. Keys are last writer wins registers and
+// named observed remove sets are available for presence data. Instances
+// request the full state when they join and periodically broadcast their
+// recent changes, and less often the full state, so missed messages are
+// repaired. Tombstones are dropped once older than the tombstone ttl.
+package crdt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-sync/data"
+	"github.com/pborman/uuid"
+)
+
+var (
+	// DefaultTopic updates are published on
+	DefaultTopic = "go.micro.sync.crdt"
+	// DefaultInterval between broadcasts of recent changes
+	DefaultInterval = time.Minute
+	// DefaultTombstoneTTL is how long deletes are kept
+	DefaultTombstoneTTL = time.Hour
+
+	// every nth broadcast is the full state
+	fullEvery = 10
+)
+
+// Map is a replicated map, the value returned by NewMap implements it
+type Map interface {
+	data.Data
+	// Set returns the named observed remove set
+	Set(name string) *Set
+	// Close stops replication
+	Close() error
+}
+
+type message struct {
+	// Type is update, set, sync or state
+	Type string               `json:"type"`
+	From string               `json:"from"`
+	Key  string               `json:"key,omitempty"`
+	Reg  *register            `json:"reg,omitempty"`
+	Name string               `json:"name,omitempty"`
+	Set  *setState            `json:"set,omitempty"`
+	Regs map[string]*register `json:"regs,omitempty"`
+	Sets map[string]*setState `json:"sets,omitempty"`
+}
+
+type crdtMap struct {
+	opts Options
+	sub  broker.Subscriber
+	exit chan bool
+	seq  uint64
+
+	sync.RWMutex
+	regs map[string]*register
+	sets map[string]*Set
+	// keys and sets changed locally since the last broadcast
+	dirty     map[string]bool
+	dirtySets map[string]bool
+}
+
+func (m *crdtMap) tag() string {
+	return fmt.Sprintf("%s-%d", m.opts.Id, atomic.AddUint64(&m.seq, 1))
+}
+
+func (m *crdtMap) stamp() stamp {
+	return stamp{Time: time.Now().UnixNano(), Node: m.opts.Id}
+}
+
+func (m *crdtMap) publish(msg *message) error {
+	msg.From = m.opts.Id
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return m.opts.Broker.Publish(m.opts.Topic, &broker.Message{Body: b})
+}
+
+// changed marks a set as changed since the last broadcast
+func (m *crdtMap) changed(name string) {
+	m.Lock()
+	m.dirtySets[name] = true
+	m.Unlock()
+}
+
+func (m *crdtMap) set(name string) *Set {
+	m.Lock()
+	defer m.Unlock()
+
+	s, ok := m.sets[name]
+	if !ok {
+		s = newSet(m, name)
+		m.sets[name] = s
+	}
+	return s
+}
+
+// state returns the full state, or only what changed locally
+// since the last broadcast, and resets the changes
+func (m *crdtMap) state(full bool) *message {
+	m.Lock()
+	defer m.Unlock()
+
+	msg := &message{
+		Type: "state",
+		Regs: make(map[string]*register),
+		Sets: make(map[string]*setState),
+	}
+	for k, r := range m.regs {
+		if full || m.dirty[k] {
+			msg.Regs[k] = r
+		}
+	}
+	for name, s := range m.sets {
+		if full || m.dirtySets[name] {
+			msg.Sets[name] = s.state()
+		}
+	}
+
+	m.dirty = make(map[string]bool)
+	m.dirtySets = make(map[string]bool)
+
+	return msg
+}
+
+// gc drops tombstones and expired registers older than the tombstone
+// ttl. Writes older than a dropped tombstone arriving later than the
+// ttl are applied again, so it should exceed how late messages arrive.
+func (m *crdtMap) gc() {
+	before := time.Now().Add(-m.opts.TombstoneTTL).UnixNano()
+
+	m.Lock()
+	for k, r := range m.regs {
+		if (r.Deleted && r.Stamp.Time < before) || (r.Expires > 0 && r.Expires < before) {
+			delete(m.regs, k)
+			delete(m.dirty, k)
+		}
+	}
+	sets := make([]*Set, 0, len(m.sets))
+	for _, s := range m.sets {
+		sets = append(sets, s)
+	}
+	m.Unlock()
+
+	for _, s := range sets {
+		s.gc(before)
+	}
+}
+
+func (m *crdtMap) handle(p broker.Publication) error {
+	var msg message
+	if err := json.Unmarshal(p.Message().Body, &msg); err != nil {
+		return err
+	}
+
+	// our own messages are already applied
+	if msg.From == m.opts.Id {
+		return nil
+	}
+
+	switch msg.Type {
+	case "update":
+		if msg.Reg != nil {
+			m.Lock()
+			merge(m.regs, msg.Key, msg.Reg)
+			m.Unlock()
+		}
+	case "set":
+		if msg.Set != nil {
+			m.set(msg.Name).merge(msg.Set)
+		}
+	case "sync":
+		// a new instance joined, send it our state
+		return m.publish(m.state(true))
+	case "state":
+		m.Lock()
+		for k, r := range msg.Regs {
+			merge(m.regs, k, r)
+		}
+		m.Unlock()
+		for name, st := range msg.Sets {
+			m.set(name).merge(st)
+		}
+	}
+
+	return nil
+}
+
+func (m *crdtMap) run() {
+	t := time.NewTicker(m.opts.Interval)
+	defer t.Stop()
+
+	for i := 1; ; i++ {
+		select {
+		case <-m.exit:
+			return
+		case <-t.C:
+		}
+
+		m.gc()
+
+		msg := m.state(i%fullEvery == 0)
+		if len(msg.Regs) == 0 && len(msg.Sets) == 0 {
+			continue
+		}
+
+		if err := m.publish(msg); err != nil {
+			log.Logf("[crdt] failed to publish state: %v", err)
+		}
+	}
+}
+
+func (m *crdtMap) Read(key string) (*data.Record, error) {
+	m.RLock()
+	r, ok := m.regs[key]
+	m.RUnlock()
+
+	if !ok || r.Deleted || r.expired() {
+		return nil, data.ErrNotFound
+	}
+
+	var expiration time.Duration
+	if r.Expires > 0 {
+		expiration = time.Duration(r.Expires - time.Now().UnixNano())
+	}
+
+	return &data.Record{
+		Key:        key,
+		Value:      r.Value,
+		Expiration: expiration,
+	}, nil
+}
+
+func (m *crdtMap) Write(rec *data.Record) error {
+	r := &register{
+		Value: rec.Value,
+		Stamp: m.stamp(),
+	}
+	if rec.Expiration > 0 {
+		r.Expires = time.Now().Add(rec.Expiration).UnixNano()
+	}
+
+	m.Lock()
+	merge(m.regs, rec.Key, r)
+	m.dirty[rec.Key] = true
+	m.Unlock()
+
+	return m.publish(&message{Type: "update", Key: rec.Key, Reg: r})
+}
+
+func (m *crdtMap) Delete(key string) error {
+	r := &register{
+		Deleted: true,
+		Stamp:   m.stamp(),
+	}
+
+	m.Lock()
+	merge(m.regs, key, r)
+	m.dirty[key] = true
+	m.Unlock()
+
+	return m.publish(&message{Type: "update", Key: key, Reg: r})
+}
+
+func (m *crdtMap) Iterate() ([]*data.Record, error) {
+	m.RLock()
+	var keys []string
+	for k := range m.regs {
+		keys = append(keys, k)
+	}
+	m.RUnlock()
+
+	var records []*data.Record
+	for _, k := range keys {
+		if r, err := m.Read(k); err == nil {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+func (m *crdtMap) Set(name string) *Set {
+	return m.set(name)
+}
+
+func (m *crdtMap) Close() error {
+	select {
+	case <-m.exit:
+		return nil
+	default:
+		close(m.exit)
+	}
+	return m.sub.Unsubscribe()
+}
+
+// NewMap returns a map replicated over the broker. The broker must be connected.
+func NewMap(opts ...Option) (Map, error) {
+	options := Options{
+		Broker:       broker.DefaultBroker,
+		Topic:        DefaultTopic,
+		Id:           uuid.NewUUID().String(),
+		Interval:     DefaultInterval,
+		TombstoneTTL: DefaultTombstoneTTL,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	m := &crdtMap{
+		opts:      options,
+		exit:      make(chan bool),
+		regs:      make(map[string]*register),
+		sets:      make(map[string]*Set),
+		dirty:     make(map[string]bool),
+		dirtySets: make(map[string]bool),
+	}
+
+	// every instance needs every message so no queue is used
+	sub, err := options.Broker.Subscribe(options.Topic, m.handle)
+	if err != nil {
+		return nil, err
+	}
+	m.sub = sub
+
+	// anti-entropy on join
+	if err := m.publish(&message{Type: "sync"}); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+
+	go m.run()
+
+	return m, nil
+}
@@ -0,0 +1,147 @@
+package crdt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/broker"
+	"github.com/micro/go-micro/broker/memory"
+	"github.com/micro/go-sync/data"
+)
+
+func eventually(t *testing.T, fn func() bool) {
+	for i := 0; i < 100; i++ {
+		if fn() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met")
+}
+
+func newTestMap(t *testing.T, b broker.Broker, id string) Map {
+	m, err := NewMap(Broker(b), Id(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMap(t *testing.T) {
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newTestMap(t, b, "a")
+	defer a.Close()
+
+	if err := a.Write(&data.Record{Key: "flag", Value: []byte("on")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// joins late and syncs the existing state
+	c := newTestMap(t, b, "c")
+	defer c.Close()
+
+	eventually(t, func() bool {
+		r, err := c.Read("flag")
+		return err == nil && string(r.Value) == "on"
+	})
+
+	if err := c.Delete("flag"); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, func() bool {
+		_, err := a.Read("flag")
+		return err == data.ErrNotFound
+	})
+}
+
+func TestSet(t *testing.T) {
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newTestMap(t, b, "a")
+	defer a.Close()
+	c := newTestMap(t, b, "c")
+	defer c.Close()
+
+	a.Set("online").Add("bob")
+	c.Set("online").Add("alice")
+
+	eventually(t, func() bool {
+		return len(a.Set("online").Elements()) == 2 && len(c.Set("online").Elements()) == 2
+	})
+
+	c.Set("online").Remove("bob")
+
+	eventually(t, func() bool {
+		return !a.Set("online").Contains("bob")
+	})
+}
+
+func TestGC(t *testing.T) {
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMap(Broker(b), Id("a"), TombstoneTTL(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	m.Write(&data.Record{Key: "foo", Value: []byte("bar")})
+	m.Delete("foo")
+	m.Set("online").Add("bob")
+	m.Set("online").Remove("bob")
+
+	time.Sleep(time.Millisecond * 5)
+
+	cm := m.(*crdtMap)
+	cm.gc()
+
+	if len(cm.regs) != 0 {
+		t.Fatalf("expected tombstone to be dropped got %v", cm.regs)
+	}
+	if s := cm.set("online"); len(s.removes) != 0 {
+		t.Fatalf("expected removes to be dropped got %v", s.removes)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestMap(t, b, "a")
+	defer m.Close()
+
+	m.Write(&data.Record{Key: "foo", Value: []byte("bar")})
+	m.Set("online").Add("bob")
+
+	cm := m.(*crdtMap)
+
+	// only the changes since the last broadcast are sent
+	msg := cm.state(false)
+	if len(msg.Regs) != 1 || len(msg.Sets) != 1 {
+		t.Fatalf("expected the changes got %d registers and %d sets", len(msg.Regs), len(msg.Sets))
+	}
+
+	m.Write(&data.Record{Key: "baz", Value: []byte("qux")})
+
+	msg = cm.state(false)
+	if len(msg.Regs) != 1 || msg.Regs["baz"] == nil || len(msg.Sets) != 0 {
+		t.Fatalf("expected only baz got %d registers and %d sets", len(msg.Regs), len(msg.Sets))
+	}
+
+	if msg = cm.state(true); len(msg.Regs) != 2 || len(msg.Sets) != 1 {
+		t.Fatalf("expected the full state got %d registers and %d sets", len(msg.Regs), len(msg.Sets))
+	}
+}
@@ -0,0 +1,42 @@
+package crdt
+
+import (
+	"time"
+)
+
+// stamp orders writes. Later timestamps win and the node id breaks ties
+// so every replica converges on the same value.
+type stamp struct {
+	Time int64  `json:"t"`
+	Node string `json:"n"`
+}
+
+func (s stamp) after(o stamp) bool {
+	if s.Time != o.Time {
+		return s.Time > o.Time
+	}
+	return s.Node > o.Node
+}
+
+// register is a last writer wins register. Deletes are tombstones so
+// they win over older writes arriving late.
+type register struct {
+	Value   []byte `json:"v,omitempty"`
+	Expires int64  `json:"e,omitempty"`
+	Deleted bool   `json:"d,omitempty"`
+	Stamp   stamp  `json:"s"`
+}
+
+func (r *register) expired() bool {
+	return r.Expires > 0 && time.Now().UnixNano() > r.Expires
+}
+
+// merge applies the remote register and returns true if it won
+func merge(regs map[string]*register, key string, r *register) bool {
+	cur, ok := regs[key]
+	if ok && !r.Stamp.after(cur.Stamp) {
+		return false
+	}
+	regs[key] = r
+	return true
+}
@@ -0,0 +1,62 @@
+package crdt
+
+import (
+	"time"
+
+	"github.com/micro/go-micro/broker"
+)
+
+// Options for the crdt map
+type Options struct {
+	// Broker state is replicated over
+	Broker broker.Broker
+	// Topic updates are published on, instances sharing a topic
+	// share the map
+	Topic string
+	// Id of this instance, defaults to a random uuid
+	Id string
+	// Interval between broadcasts of recent changes for anti-entropy,
+	// every tenth broadcast is the full state
+	Interval time.Duration
+	// TombstoneTTL is how long deletes and removes are kept
+	TombstoneTTL time.Duration
+}
+
+type Option func(*Options)
+
+// Broker sets the broker state is replicated over
+func Broker(b broker.Broker) Option {
+	return func(o *Options) {
+		o.Broker = b
+	}
+}
+
+// Topic sets the topic updates are published on
+func Topic(t string) Option {
+	return func(o *Options) {
+		o.Topic = t
+	}
+}
+
+// Id sets the id of this instance
+func Id(id string) Option {
+	return func(o *Options) {
+		o.Id = id
+	}
+}
+
+// Interval sets the anti-entropy interval
+func Interval(d time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = d
+	}
+}
+
+// TombstoneTTL sets how long deletes and removes are kept. It should
+// exceed how late messages can arrive, as writes older than a dropped
+// tombstone are applied again.
+func TombstoneTTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.TombstoneTTL = d
+	}
+}
@@ -0,0 +1,174 @@
+package crdt
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Set is an observed remove set. Elements are tagged on add and a remove
+// only removes the tags it has observed, so a concurrent add wins over a
+// remove. It's suited to presence data such as the members of a room.
+type Set struct {
+	m    *crdtMap
+	name string
+
+	sync.RWMutex
+	// tags of each element
+	adds map[string]map[string]bool
+	// removed tags and when the remove was observed
+	removes map[string]int64
+}
+
+type setState struct {
+	Adds    map[string]map[string]bool `json:"a"`
+	Removes map[string]bool            `json:"r"`
+}
+
+func newSet(m *crdtMap, name string) *Set {
+	return &Set{
+		m:       m,
+		name:    name,
+		adds:    make(map[string]map[string]bool),
+		removes: make(map[string]int64),
+	}
+}
+
+// Add adds the element to the set
+func (s *Set) Add(elem string) error {
+	tag := s.m.tag()
+
+	s.Lock()
+	if s.adds[elem] == nil {
+		s.adds[elem] = make(map[string]bool)
+	}
+	s.adds[elem][tag] = true
+	s.Unlock()
+
+	s.m.changed(s.name)
+
+	return s.m.publish(&message{
+		Type: "set",
+		Name: s.name,
+		Set: &setState{
+			Adds: map[string]map[string]bool{elem: {tag: true}},
+		},
+	})
+}
+
+// Remove removes the element from the set
+func (s *Set) Remove(elem string) error {
+	now := time.Now().UnixNano()
+
+	s.Lock()
+	removes := make(map[string]bool)
+	for tag := range s.adds[elem] {
+		s.removes[tag] = now
+		removes[tag] = true
+	}
+	delete(s.adds, elem)
+	s.Unlock()
+
+	if len(removes) == 0 {
+		return nil
+	}
+
+	s.m.changed(s.name)
+
+	return s.m.publish(&message{
+		Type: "set",
+		Name: s.name,
+		Set:  &setState{Removes: removes},
+	})
+}
+
+// Contains returns true if the element is in the set
+func (s *Set) Contains(elem string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.adds[elem]) > 0
+}
+
+// Elements returns the sorted elements of the set
+func (s *Set) Elements() []string {
+	s.RLock()
+	defer s.RUnlock()
+
+	var elems []string
+	for e, tags := range s.adds {
+		if len(tags) > 0 {
+			elems = append(elems, e)
+		}
+	}
+	sort.Strings(elems)
+	return elems
+}
+
+func (s *Set) merge(st *setState) {
+	now := time.Now().UnixNano()
+
+	s.Lock()
+	defer s.Unlock()
+
+	for tag := range st.Removes {
+		if _, ok := s.removes[tag]; !ok {
+			s.removes[tag] = now
+		}
+	}
+
+	for elem, tags := range st.Adds {
+		for tag := range tags {
+			if _, ok := s.removes[tag]; ok {
+				continue
+			}
+			if s.adds[elem] == nil {
+				s.adds[elem] = make(map[string]bool)
+			}
+			s.adds[elem][tag] = true
+		}
+	}
+
+	// drop observed removes
+	for elem, tags := range s.adds {
+		for tag := range tags {
+			if _, ok := s.removes[tag]; ok {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(s.adds, elem)
+		}
+	}
+}
+
+// gc drops the removed tags observed before the time
+func (s *Set) gc(before int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	for tag, t := range s.removes {
+		if t < before {
+			delete(s.removes, tag)
+		}
+	}
+}
+
+func (s *Set) state() *setState {
+	s.RLock()
+	defer s.RUnlock()
+
+	st := &setState{
+		Adds:    make(map[string]map[string]bool, len(s.adds)),
+		Removes: make(map[string]bool, len(s.removes)),
+	}
+	for elem, tags := range s.adds {
+		st.Adds[elem] = make(map[string]bool, len(tags))
+		for tag := range tags {
+			st.Adds[elem][tag] = true
+		}
+	}
+	for tag := range s.removes {
+		st.Removes[tag] = true
+	}
+	return st
+}
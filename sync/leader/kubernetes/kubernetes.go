@@ -0,0 +1,351 @@
+// Package kubernetes is a leader election implementation backed by
+// Kubernetes coordination leases
+package kubernetes
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	"github.com/micro/go-plugins/registry/kubernetes/client/api"
+	"github.com/micro/go-sync/leader"
+)
+
+var (
+	// DefaultGroup is the lease name when no group is set
+	DefaultGroup = "micro-leader"
+	// LeaseDuration is how long a lease is valid without renewal
+	LeaseDuration = 15 * time.Second
+	// RetryPeriod is the interval between acquire and renew attempts
+	RetryPeriod = 2 * time.Second
+
+	// ErrNotLeader is returned when resigning a lease no longer held
+	ErrNotLeader = errors.New("not the leader")
+)
+
+type kleader struct {
+	opts   leader.Options
+	client client.Kubernetes
+	name   string
+	exit   chan bool
+
+	sync.Mutex
+	// record is the lease last observed and observed when it changed
+	record   record
+	observed time.Time
+}
+
+// record identifies a version of the lease held by a holder
+type record struct {
+	holder      string
+	renew       int64
+	transitions int
+}
+
+type elected struct {
+	id string
+	l  *kleader
+
+	sync.Mutex
+	revoked chan bool
+	exit    chan bool
+}
+
+func now() *client.MicroTime {
+	return &client.MicroTime{Time: time.Now()}
+}
+
+// expired returns true if the lease hasn't been renewed in time. The lease
+// is expired relative to when we last observed it change rather than its
+// renew time so clock skew between nodes doesn't cause early takeovers.
+func (k *kleader) expired(l *client.Lease) bool {
+	if l.Spec == nil || len(l.Spec.HolderIdentity) == 0 || l.Spec.RenewTime == nil {
+		return true
+	}
+
+	r := record{
+		holder:      l.Spec.HolderIdentity,
+		renew:       l.Spec.RenewTime.UnixNano(),
+		transitions: l.Spec.LeaseTransitions,
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	if r != k.record {
+		k.record = r
+		k.observed = time.Now()
+	}
+
+	d := time.Duration(l.Spec.LeaseDurationSeconds) * time.Second
+	return time.Now().After(k.observed.Add(d))
+}
+
+// try acquires or renews the lease for the id and returns whether it's held
+func (k *kleader) try(id string) (bool, error) {
+	l, err := k.client.GetLease(k.name)
+	if err == api.ErrNotFound {
+		_, err := k.client.CreateLease(&client.Lease{
+			Metadata: &client.Meta{
+				Name: k.name,
+			},
+			Spec: &client.LeaseSpec{
+				HolderIdentity:       id,
+				LeaseDurationSeconds: int(LeaseDuration / time.Second),
+				AcquireTime:          now(),
+				RenewTime:            now(),
+			},
+		})
+		if err == api.ErrConflict {
+			return false, nil
+		}
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	}
+
+	if l.Spec == nil {
+		l.Spec = &client.LeaseSpec{}
+	}
+
+	switch {
+	case l.Spec.HolderIdentity == id:
+		// renew
+	case !k.expired(l):
+		return false, nil
+	default:
+		// take over
+		l.Spec.HolderIdentity = id
+		l.Spec.AcquireTime = now()
+		l.Spec.LeaseTransitions++
+	}
+
+	l.Spec.RenewTime = now()
+	l.Spec.LeaseDurationSeconds = int(LeaseDuration / time.Second)
+
+	// the resource version guards against concurrent updates
+	if _, err := k.client.UpdateLease(k.name, l); err == api.ErrConflict {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// campaign blocks until the lease is acquired
+func (k *kleader) campaign(id string) {
+	for {
+		ok, err := k.try(id)
+		if err != nil {
+			log.Logf("[leader] failed to acquire lease %s: %v", k.name, err)
+		}
+		if ok {
+			return
+		}
+		time.Sleep(RetryPeriod)
+	}
+}
+
+func (k *kleader) Elect(id string, opts ...leader.ElectOption) (leader.Elected, error) {
+	k.campaign(id)
+
+	e := &elected{
+		id:      id,
+		l:       k,
+		revoked: make(chan bool, 1),
+		exit:    make(chan bool),
+	}
+
+	go e.renew(e.exit)
+
+	return e, nil
+}
+
+// Follow returns the identity of the leader each time it changes. Only
+// the latest change is kept if it isn't received before the next one.
+// The channel is closed when the leader is closed.
+func (k *kleader) Follow() chan string {
+	ch := make(chan string, 1)
+
+	go func() {
+		defer close(ch)
+
+		var current string
+
+		for {
+			l, err := k.client.GetLease(k.name)
+			if err == nil && l.Spec != nil {
+				holder := l.Spec.HolderIdentity
+				if k.expired(l) {
+					holder = ""
+				}
+				if holder != current {
+					current = holder
+					send(ch, holder)
+				}
+			}
+
+			select {
+			case <-k.exit:
+				return
+			case <-time.After(RetryPeriod):
+			}
+		}
+	}()
+
+	return ch
+}
+
+// send replaces any change the follower hasn't received yet
+func send(ch chan string, id string) {
+	select {
+	case ch <- id:
+		return
+	default:
+	}
+
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- id
+}
+
+// Close stops following the leader
+func (k *kleader) Close() error {
+	k.Lock()
+	defer k.Unlock()
+
+	select {
+	case <-k.exit:
+	default:
+		close(k.exit)
+	}
+	return nil
+}
+
+// renew keeps the lease until it's lost or resigned
+func (e *elected) renew(exit chan bool) {
+	t := time.NewTicker(RetryPeriod)
+	defer t.Stop()
+
+	last := time.Now()
+
+	for {
+		select {
+		case <-exit:
+			return
+		case <-t.C:
+		}
+
+		ok, err := e.l.try(e.id)
+		if ok {
+			last = time.Now()
+			continue
+		}
+
+		// someone else took over, or we couldn't renew in time
+		if err == nil || time.Since(last) > LeaseDuration {
+			log.Logf("[leader] lost lease %s", e.l.name)
+			select {
+			case e.revoked <- true:
+			default:
+			}
+			return
+		}
+	}
+}
+
+func (e *elected) Id() string {
+	return e.id
+}
+
+// Reelect blocks until the lease is acquired again
+func (e *elected) Reelect() error {
+	e.stop()
+	e.l.campaign(e.id)
+
+	e.Lock()
+	e.exit = make(chan bool)
+	exit := e.exit
+	e.Unlock()
+
+	go e.renew(exit)
+	return nil
+}
+
+func (e *elected) Revoked() chan bool {
+	return e.revoked
+}
+
+func (e *elected) stop() {
+	e.Lock()
+	defer e.Unlock()
+
+	select {
+	case <-e.exit:
+	default:
+		close(e.exit)
+	}
+}
+
+// Resign releases the lease so another candidate can take over
+// without waiting for it to expire
+func (e *elected) Resign() error {
+	e.stop()
+
+	l, err := e.l.client.GetLease(e.l.name)
+	if err != nil {
+		return err
+	}
+
+	if l.Spec == nil || l.Spec.HolderIdentity != e.id {
+		return ErrNotLeader
+	}
+
+	l.Spec.HolderIdentity = ""
+	l.Spec.RenewTime = nil
+
+	_, err = e.l.client.UpdateLease(e.l.name, l)
+	return err
+}
+
+func newLeader(c client.Kubernetes, opts ...leader.Option) leader.Leader {
+	var options leader.Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	name := options.Group
+	if len(name) == 0 {
+		name = DefaultGroup
+	}
+
+	return &kleader{
+		opts:   options,
+		client: c,
+		name:   name,
+		exit:   make(chan bool),
+	}
+}
+
+// NewLeader returns a lease based leader. The first node is used as the
+// api server host, otherwise the in cluster config is used.
+func NewLeader(opts ...leader.Option) leader.Leader {
+	var options leader.Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	var c client.Kubernetes
+	if len(options.Nodes) > 0 && len(options.Nodes[0]) > 0 {
+		c = client.NewClientByHost(options.Nodes[0])
+	} else {
+		c = client.NewClientInCluster()
+	}
+
+	return newLeader(c, opts...)
+}
@@ -0,0 +1,124 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/micro/go-plugins/registry/kubernetes/client"
+	"github.com/micro/go-plugins/registry/kubernetes/client/mock"
+	"github.com/micro/go-sync/leader"
+)
+
+func TestElection(t *testing.T) {
+	RetryPeriod = 10 * time.Millisecond
+	LeaseDuration = time.Second
+
+	c := mock.NewClient()
+	l := newLeader(c, leader.Group("test"))
+
+	follow := l.Follow()
+
+	e, err := l.Elect("node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id := <-follow; id != "node-1" {
+		t.Fatalf("expected node-1 to lead got %s", id)
+	}
+
+	// a second candidate blocks until the first resigns
+	done := make(chan string)
+	go func() {
+		e2, err := newLeader(c, leader.Group("test")).Elect("node-2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		done <- e2.Id()
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected node-2 to wait for the lease")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := e.Resign(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case id := <-done:
+		if id != "node-2" {
+			t.Fatalf("expected node-2 got %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected node-2 to take over")
+	}
+
+	// node-1 resigned so isn't revoked, node-2 is observed
+	for id := range follow {
+		if id == "node-2" {
+			break
+		}
+	}
+
+	if l := c.Leases["test"]; l.Spec.LeaseTransitions != 1 {
+		t.Fatalf("expected 1 transition got %d", l.Spec.LeaseTransitions)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	LeaseDuration = time.Second
+
+	k := newLeader(mock.NewClient()).(*kleader)
+
+	// a renew time in the past due to clock skew isn't expired
+	l := &client.Lease{
+		Spec: &client.LeaseSpec{
+			HolderIdentity:       "node-1",
+			LeaseDurationSeconds: 1,
+			RenewTime:            &client.MicroTime{Time: time.Now().Add(-time.Hour)},
+		},
+	}
+	if k.expired(l) {
+		t.Fatal("expected a newly observed lease not to be expired")
+	}
+
+	// the lease is expired once it hasn't changed for the duration
+	k.observed = k.observed.Add(-2 * time.Second)
+	if !k.expired(l) {
+		t.Fatal("expected an unchanged lease to expire")
+	}
+
+	// a renewal is observed
+	l.Spec.RenewTime = &client.MicroTime{Time: time.Now().Add(-time.Minute)}
+	if k.expired(l) {
+		t.Fatal("expected a renewed lease not to be expired")
+	}
+
+	l.Spec.HolderIdentity = ""
+	if !k.expired(l) {
+		t.Fatal("expected a lease without a holder to be expired")
+	}
+}
+
+func TestFollowClose(t *testing.T) {
+	RetryPeriod = 10 * time.Millisecond
+
+	l := newLeader(mock.NewClient()).(*kleader)
+	follow := l.Follow()
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, ok := <-follow:
+		if ok {
+			t.Fatal("expected no leader")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected follow to stop on close")
+	}
+}
@@ -0,0 +1,339 @@
+// Package redis is a redis lock implementation. A single node is a plain
+// SET NX lock, multiple nodes use the Redlock algorithm where the lock is
+// held once a majority of nodes agree. Held locks are extended by a
+// watchdog until released and every acquisition gets a fencing token.
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-sync/lock"
+)
+
+var (
+	// DefaultTTL of a lock
+	DefaultTTL = 30 * time.Second
+	// DefaultRetry is the delay between acquisition attempts
+	DefaultRetry = 100 * time.Millisecond
+	// FencingKey is the metadata key the fencing token is set on
+	FencingKey = "Fencing-Token"
+
+	// ErrLockNotHeld is returned when releasing a lock not held
+	ErrLockNotHeld = errors.New("lock not held")
+	// ErrLockTimeout is returned when the lock couldn't be acquired in time
+	ErrLockTimeout = errors.New("lock acquisition timed out")
+)
+
+// release deletes the lock only if we still hold it
+var release = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extend resets the ttl only if we still hold the lock
+var extend = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Locker is the redis lock with context aware acquisition and fencing
+// tokens, the value returned by NewLock implements it
+type Locker interface {
+	lock.Lock
+	// AcquireContext acquires the lock until the context is done and
+	// returns the fencing token of the acquisition
+	AcquireContext(ctx context.Context, id string, opts ...lock.AcquireOption) (int64, error)
+	// Token returns the fencing token of a held lock
+	Token(id string) (int64, bool)
+}
+
+type held struct {
+	value string
+	token int64
+	exit  chan bool
+}
+
+type redisLock struct {
+	opts  lock.Options
+	pools []*redis.Pool
+
+	sync.Mutex
+	locks map[string]*held
+}
+
+func newPool(addr string) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", addr,
+				redis.DialConnectTimeout(time.Second),
+				redis.DialReadTimeout(time.Second),
+				redis.DialWriteTimeout(time.Second),
+			)
+		},
+	}
+}
+
+func random() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (r *redisLock) key(id string) string {
+	return r.opts.Prefix + id
+}
+
+func (r *redisLock) quorum() int {
+	return len(r.pools)/2 + 1
+}
+
+// each runs fn against every node and returns the number of successes
+func (r *redisLock) each(fn func(redis.Conn) (bool, error)) int {
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	var n int
+
+	for _, p := range r.pools {
+		wg.Add(1)
+		go func(p *redis.Pool) {
+			defer wg.Done()
+			c := p.Get()
+			defer c.Close()
+			if ok, err := fn(c); err == nil && ok {
+				mtx.Lock()
+				n++
+				mtx.Unlock()
+			}
+		}(p)
+	}
+
+	wg.Wait()
+	return n
+}
+
+// fence increments the fencing counter on every node and returns the
+// highest value seen by a majority
+func (r *redisLock) fence(id string) (int64, error) {
+	var mtx sync.Mutex
+	var token int64
+
+	n := r.each(func(c redis.Conn) (bool, error) {
+		t, err := redis.Int64(c.Do("INCR", r.key(id)+":fence"))
+		if err != nil {
+			return false, err
+		}
+		mtx.Lock()
+		if t > token {
+			token = t
+		}
+		mtx.Unlock()
+		return true, nil
+	})
+
+	if n < r.quorum() {
+		return 0, errors.New("failed to get fencing token from a majority of nodes")
+	}
+	return token, nil
+}
+
+// try makes a single Redlock attempt
+func (r *redisLock) try(id, value string, ttl time.Duration) bool {
+	start := time.Now()
+
+	n := r.each(func(c redis.Conn) (bool, error) {
+		rsp, err := redis.String(c.Do("SET", r.key(id), value, "NX", "PX", int64(ttl/time.Millisecond)))
+		return rsp == "OK", err
+	})
+
+	// account for clock drift between nodes
+	drift := ttl/100 + 2*time.Millisecond
+	if n >= r.quorum() && time.Since(start) < ttl-drift {
+		return true
+	}
+
+	// undo a partial acquisition
+	r.each(func(c redis.Conn) (bool, error) {
+		_, err := release.Do(c, r.key(id), value)
+		return err == nil, err
+	})
+	return false
+}
+
+// watchdog extends the lock at a third of its ttl until released
+func (r *redisLock) watchdog(id string, h *held, ttl time.Duration) {
+	t := time.NewTicker(ttl / 3)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-h.exit:
+			return
+		case <-t.C:
+			n := r.each(func(c redis.Conn) (bool, error) {
+				v, err := redis.Int(extend.Do(c, r.key(id), h.value, int64(ttl/time.Millisecond)))
+				return v == 1, err
+			})
+			if n < r.quorum() {
+				log.Logf("[lock] lost lock %s", id)
+				r.Lock()
+				if r.locks[id] == h {
+					delete(r.locks, id)
+				}
+				r.Unlock()
+				return
+			}
+		}
+	}
+}
+
+func (r *redisLock) AcquireContext(ctx context.Context, id string, opts ...lock.AcquireOption) (int64, error) {
+	options := lock.AcquireOptions{
+		TTL: DefaultTTL,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	if options.Wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Wait)
+		defer cancel()
+	}
+
+	value := random()
+
+	for {
+		if r.try(id, value, options.TTL) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return 0, ErrLockTimeout
+			}
+			return 0, ctx.Err()
+		case <-time.After(DefaultRetry):
+		}
+	}
+
+	token, err := r.fence(id)
+	if err != nil {
+		r.each(func(c redis.Conn) (bool, error) {
+			_, err := release.Do(c, r.key(id), value)
+			return err == nil, err
+		})
+		return 0, err
+	}
+
+	h := &held{
+		value: value,
+		token: token,
+		exit:  make(chan bool),
+	}
+
+	r.Lock()
+	r.locks[id] = h
+	r.Unlock()
+
+	go r.watchdog(id, h, options.TTL)
+
+	return token, nil
+}
+
+func (r *redisLock) Acquire(id string, opts ...lock.AcquireOption) error {
+	_, err := r.AcquireContext(context.Background(), id, opts...)
+	return err
+}
+
+func (r *redisLock) Release(id string) error {
+	r.Lock()
+	h, ok := r.locks[id]
+	delete(r.locks, id)
+	r.Unlock()
+
+	if !ok {
+		return ErrLockNotHeld
+	}
+
+	close(h.exit)
+
+	n := r.each(func(c redis.Conn) (bool, error) {
+		v, err := redis.Int(release.Do(c, r.key(id), h.value))
+		return v == 1, err
+	})
+	if n < r.quorum() {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (r *redisLock) Token(id string) (int64, bool) {
+	r.Lock()
+	defer r.Unlock()
+	h, ok := r.locks[id]
+	if !ok {
+		return 0, false
+	}
+	return h.token, true
+}
+
+// FencingContext returns a context with the fencing token set in the
+// metadata so downstream services can reject stale lock holders
+func FencingContext(ctx context.Context, token int64) context.Context {
+	md, _ := metadata.FromContext(ctx)
+
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		nmd[k] = v
+	}
+	nmd[FencingKey] = strconv.FormatInt(token, 10)
+
+	return metadata.NewContext(ctx, nmd)
+}
+
+// NewLock returns a redis lock. Pass one node for a single redis or
+// an odd number of independent nodes for Redlock.
+func NewLock(opts ...lock.Option) lock.Lock {
+	var options lock.Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if len(options.Nodes) == 0 {
+		options.Nodes = []string{"127.0.0.1:6379"}
+	}
+
+	if len(options.Prefix) == 0 {
+		options.Prefix = "micro:lock:"
+	}
+
+	var pools []*redis.Pool
+	for _, addr := range options.Nodes {
+		pools = append(pools, newPool(addr))
+	}
+
+	return &redisLock{
+		opts:  options,
+		pools: pools,
+		locks: make(map[string]*held),
+	}
+}
@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-sync/lock"
+)
+
+func TestLock(t *testing.T) {
+	if tr := os.Getenv("TRAVIS"); len(tr) > 0 {
+		t.Skip()
+	}
+
+	l := NewLock(lock.Prefix("test:")).(Locker)
+
+	token, err := l.AcquireContext(context.TODO(), "foo", lock.TTL(time.Second))
+	if err != nil {
+		t.Skipf("redis unavailable: %v", err)
+	}
+
+	// held so a second acquisition times out
+	if err := l.Acquire("foo", lock.Wait(200*time.Millisecond)); err != ErrLockTimeout {
+		t.Fatalf("expected timeout got %v", err)
+	}
+
+	// the watchdog keeps the lock past its ttl
+	time.Sleep(1500 * time.Millisecond)
+	if _, ok := l.Token("foo"); !ok {
+		t.Fatal("expected the lock to still be held")
+	}
+
+	if err := l.Release("foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := l.AcquireContext(context.TODO(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release("foo")
+
+	if next <= token {
+		t.Fatalf("expected fencing token to increase, got %d then %d", token, next)
+	}
+
+	md, _ := metadata.FromContext(FencingContext(context.TODO(), next))
+	if len(md[FencingKey]) == 0 {
+		t.Fatal("expected fencing token in metadata")
+	}
+}
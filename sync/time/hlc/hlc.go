@@ -0,0 +1,139 @@
+// Package hlc is a hybrid logical clock. Timestamps combine the physical
+// time with a logical counter so they stay close to wall time while
+// preserving causality: an event always has a greater timestamp than any
+// event it could have observed, across services and regions.
+package hlc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	stime "github.com/micro/go-sync/time"
+)
+
+var (
+	// DefaultMaxDrift is the furthest ahead a remote clock is accepted
+	DefaultMaxDrift = 500 * time.Millisecond
+
+	// ErrDrift is returned when a remote timestamp is too far ahead
+	ErrDrift = errors.New("remote clock too far ahead")
+)
+
+// Timestamp is a hybrid logical timestamp
+type Timestamp struct {
+	// Wall is the physical time in unix nanoseconds
+	Wall int64
+	// Logical orders events within the same wall time
+	Logical uint32
+}
+
+// Before returns true if the timestamp is ordered before t
+func (ts Timestamp) Before(t Timestamp) bool {
+	return ts.Wall < t.Wall || (ts.Wall == t.Wall && ts.Logical < t.Logical)
+}
+
+// Time returns the physical time of the timestamp
+func (ts Timestamp) Time() time.Time {
+	return time.Unix(0, ts.Wall)
+}
+
+func (ts Timestamp) String() string {
+	return fmt.Sprintf("%d.%d", ts.Wall, ts.Logical)
+}
+
+// Parse parses a timestamp formatted by String
+func Parse(s string) (Timestamp, error) {
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) != 2 {
+		return Timestamp{}, fmt.Errorf("invalid timestamp %q", s)
+	}
+	wall, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Timestamp{}, err
+	}
+	logical, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return Timestamp{}, err
+	}
+	return Timestamp{Wall: wall, Logical: uint32(logical)}, nil
+}
+
+// Clock is a hybrid logical clock
+type Clock struct {
+	now      func() int64
+	maxDrift time.Duration
+
+	sync.Mutex
+	last Timestamp
+}
+
+// Tick returns the timestamp of a local or send event
+func (c *Clock) Tick() Timestamp {
+	pt := c.now()
+
+	c.Lock()
+	defer c.Unlock()
+
+	if pt > c.last.Wall {
+		c.last = Timestamp{Wall: pt}
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Update merges a remote timestamp on receipt of a message and
+// returns the timestamp of the receive event
+func (c *Clock) Update(remote Timestamp) (Timestamp, error) {
+	pt := c.now()
+
+	if c.maxDrift > 0 && remote.Wall-pt > int64(c.maxDrift) {
+		return Timestamp{}, ErrDrift
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	switch {
+	case pt > c.last.Wall && pt > remote.Wall:
+		c.last = Timestamp{Wall: pt}
+	case c.last.Wall == remote.Wall:
+		if remote.Logical > c.last.Logical {
+			c.last.Logical = remote.Logical
+		}
+		c.last.Logical++
+	case c.last.Wall > remote.Wall:
+		c.last.Logical++
+	default:
+		c.last = Timestamp{Wall: remote.Wall, Logical: remote.Logical + 1}
+	}
+
+	return c.last, nil
+}
+
+// Now implements the go-sync time interface
+func (c *Clock) Now() (time.Time, error) {
+	return c.Tick().Time(), nil
+}
+
+func (c *Clock) String() string {
+	return "hlc"
+}
+
+// NewClock returns a clock rejecting remote timestamps more than
+// maxDrift ahead of the local clock, zero disables the check
+func NewClock(maxDrift time.Duration) *Clock {
+	return &Clock{
+		now:      func() int64 { return time.Now().UnixNano() },
+		maxDrift: maxDrift,
+	}
+}
+
+// NewTime returns a clock as a go-sync time
+func NewTime() stime.Time {
+	return NewClock(DefaultMaxDrift)
+}
@@ -0,0 +1,106 @@
+package hlc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+func testClock(pt *int64) *Clock {
+	c := NewClock(time.Second)
+	c.now = func() int64 { return *pt }
+	return c
+}
+
+func TestClock(t *testing.T) {
+	var pt int64 = 100
+	c := testClock(&pt)
+
+	a := c.Tick()
+	b := c.Tick()
+	if !a.Before(b) || b.Logical != 1 {
+		t.Fatalf("expected %s before %s", a, b)
+	}
+
+	// a remote clock ahead pushes ours forward
+	ts, err := c.Update(Timestamp{Wall: 200, Logical: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Wall != 200 || ts.Logical != 6 {
+		t.Fatalf("unexpected timestamp %s", ts)
+	}
+
+	// and the next local event is still after it
+	if next := c.Tick(); !ts.Before(next) {
+		t.Fatalf("expected %s before %s", ts, next)
+	}
+
+	// physical time catches up
+	pt = 300
+	if ts := c.Tick(); ts.Wall != 300 || ts.Logical != 0 {
+		t.Fatalf("unexpected timestamp %s", ts)
+	}
+
+	if _, err := c.Update(Timestamp{Wall: pt + int64(2*time.Second)}); err != ErrDrift {
+		t.Fatalf("expected drift error got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	ts := Timestamp{Wall: 1234, Logical: 7}
+
+	p, err := Parse(ts.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != ts {
+		t.Fatalf("expected %s got %s", ts, p)
+	}
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	sender := NewClock(0)
+	receiver := NewClock(0)
+
+	sent := sender.Tick()
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{MetadataKey: sent.String()})
+
+	fn := NewHandlerWrapper(receiver)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		ts, ok := FromContext(ctx)
+		if !ok {
+			t.Fatal("expected timestamp in context")
+		}
+		if !sent.Before(ts) {
+			t.Fatalf("expected %s before %s", sent, ts)
+		}
+		return nil
+	})
+
+	if err := fn(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	var pt int64 = 100
+	c := testClock(&pt)
+
+	// a remote timestamp ahead of the clock is merged with a single tick
+	remote := Timestamp{Wall: 200, Logical: 3}
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{MetadataKey: remote.String()})
+
+	ts, _ := FromContext(receive(ctx, c))
+	if want := (Timestamp{Wall: 200, Logical: 4}); ts != want {
+		t.Fatalf("expected %s got %s", want, ts)
+	}
+
+	// without a remote timestamp the clock ticks once
+	ts, _ = FromContext(receive(context.TODO(), c))
+	if want := (Timestamp{Wall: 200, Logical: 5}); ts != want {
+		t.Fatalf("expected %s got %s", want, ts)
+	}
+}
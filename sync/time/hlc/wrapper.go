@@ -0,0 +1,104 @@
+package hlc
+
+import (
+	"context"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+var (
+	// MetadataKey the timestamp is carried in
+	MetadataKey = "Micro-Hlc"
+)
+
+type hlcKey struct{}
+
+// FromContext returns the timestamp of the receive event
+func FromContext(ctx context.Context) (Timestamp, bool) {
+	ts, ok := ctx.Value(hlcKey{}).(Timestamp)
+	return ts, ok
+}
+
+// stamp ticks the clock and sets the timestamp in the outgoing metadata
+func stamp(ctx context.Context, c *Clock) context.Context {
+	md, _ := metadata.FromContext(ctx)
+
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		nmd[k] = v
+	}
+	nmd[MetadataKey] = c.Tick().String()
+
+	return metadata.NewContext(ctx, nmd)
+}
+
+// receive updates the clock from the incoming metadata, ticking it
+// only when there's no usable remote timestamp
+func receive(ctx context.Context, c *Clock) context.Context {
+	md, _ := metadata.FromContext(ctx)
+
+	v, ok := md[MetadataKey]
+	if !ok {
+		return context.WithValue(ctx, hlcKey{}, c.Tick())
+	}
+
+	remote, err := Parse(v)
+	if err != nil {
+		log.Logf("[hlc] ignoring remote timestamp %s: %v", v, err)
+		return context.WithValue(ctx, hlcKey{}, c.Tick())
+	}
+
+	ts, err := c.Update(remote)
+	if err != nil {
+		log.Logf("[hlc] ignoring remote timestamp %s: %v", v, err)
+		ts = c.Tick()
+	}
+
+	return context.WithValue(ctx, hlcKey{}, ts)
+}
+
+type hlcWrapper struct {
+	c *Clock
+	client.Client
+}
+
+func (h *hlcWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return h.Client.Call(stamp(ctx, h.c), req, rsp, opts...)
+}
+
+func (h *hlcWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return h.Client.Stream(stamp(ctx, h.c), req, opts...)
+}
+
+func (h *hlcWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	return h.Client.Publish(stamp(ctx, h.c), p, opts...)
+}
+
+// NewClientWrapper stamps outgoing requests and messages with the clock
+func NewClientWrapper(c *Clock) client.Wrapper {
+	return func(cl client.Client) client.Client {
+		return &hlcWrapper{c, cl}
+	}
+}
+
+// NewHandlerWrapper updates the clock from incoming requests. The
+// timestamp of the request is available through FromContext.
+func NewHandlerWrapper(c *Clock) server.HandlerWrapper {
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			return h(receive(ctx, c), req, rsp)
+		}
+	}
+}
+
+// NewSubscriberWrapper updates the clock from incoming messages
+func NewSubscriberWrapper(c *Clock) server.SubscriberWrapper {
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			return fn(receive(ctx, c), msg)
+		}
+	}
+}
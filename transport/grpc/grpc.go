@@ -116,6 +116,10 @@ func (t *grpcTransport) Dial(addr string, opts ...transport.DialOption) (transpo
 		options = append(options, grpc.WithInsecure())
 	}
 
+	if d, ok := proxyDialer(t.opts); ok {
+		options = append(options, d)
+	}
+
 	// dial the server
 	conn, err := grpc.Dial(addr, options...)
 	if err != nil {
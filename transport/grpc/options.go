@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/micro/go-micro/transport"
+	"github.com/micro/go-plugins/transport/proxy"
+	"github.com/micro/grpc-go"
+)
+
+type proxyKey struct{}
+
+// Proxy dials connections through the proxy returned by fn, such as
+// proxy.URL("socks5://bastion:1080"). The MICRO_PROXY env var is
+// used when it isn't set.
+func Proxy(fn proxy.Func) transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, proxyKey{}, fn)
+	}
+}
+
+// proxyDialer returns the dial option connecting through the
+// proxy, or false if connections are dialed directly
+func proxyDialer(o transport.Options) (grpc.DialOption, bool) {
+	var fn proxy.Func
+	if o.Context != nil {
+		fn, _ = o.Context.Value(proxyKey{}).(proxy.Func)
+	}
+
+	fn = proxy.Get(fn)
+	if fn == nil {
+		return nil, false
+	}
+
+	return grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return proxy.Dial(context.Background(), fn, "tcp", addr, timeout)
+	}), true
+}
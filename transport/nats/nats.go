@@ -13,7 +13,7 @@ import (
 	"github.com/micro/go-micro/server"
 	"github.com/micro/go-micro/transport"
 	"github.com/micro/go-micro/transport/codec/json"
-	"github.com/nats-io/go-nats"
+	"github.com/nats-io/nats.go"
 )
 
 type ntport struct {
@@ -309,6 +309,10 @@ func (n *ntport) Dial(addr string, dialOpts ...transport.DialOption) (transport.
 		opts.Secure = true
 	}
 
+	if err := applyConnOptions(n.opts, &opts); err != nil {
+		return nil, err
+	}
+
 	c, err := opts.Connect()
 	if err != nil {
 		return nil, err
@@ -340,6 +344,10 @@ func (n *ntport) Listen(addr string, listenOpts ...transport.ListenOption) (tran
 		opts.Secure = true
 	}
 
+	if err := applyConnOptions(n.opts, &opts); err != nil {
+		return nil, err
+	}
+
 	c, err := opts.Connect()
 	if err != nil {
 		return nil, err
@@ -4,7 +4,7 @@ import (
 	"context"
 
 	"github.com/micro/go-micro/transport"
-	"github.com/nats-io/go-nats"
+	"github.com/nats-io/nats.go"
 )
 
 type optionsKey struct{}
@@ -19,3 +19,52 @@ func Options(nopts nats.Options) transport.Option {
 		o.Context = context.WithValue(o.Context, optionsKey{}, nopts)
 	}
 }
+
+type connOptionsKey struct{}
+
+func connOption(opt nats.Option) transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		prev, _ := o.Context.Value(connOptionsKey{}).([]nats.Option)
+		opts := append(prev[:len(prev):len(prev)], opt)
+		o.Context = context.WithValue(o.Context, connOptionsKey{}, opts)
+	}
+}
+
+// applyConnOptions applies the connection options to the nats options
+func applyConnOptions(o transport.Options, nopts *nats.Options) error {
+	if o.Context == nil {
+		return nil
+	}
+	opts, _ := o.Context.Value(connOptionsKey{}).([]nats.Option)
+	for _, opt := range opts {
+		if err := opt(nopts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Credentials authenticates with the user JWT and nkey seed
+// in a NATS 2.x .creds file, as issued by NGS
+func Credentials(file string) transport.Option {
+	return connOption(nats.UserCredentials(file))
+}
+
+// JWT authenticates with the user JWT and nkey seed
+func JWT(jwt, seed string) transport.Option {
+	return connOption(nats.UserJWTAndSeed(jwt, seed))
+}
+
+// TLSHandshakeFirst performs the TLS handshake before the server
+// sends its INFO, for servers configured with handshake_first
+func TLSHandshakeFirst() transport.Option {
+	return connOption(nats.TLSHandshakeFirst())
+}
+
+// Name sets the connection name shown in the server's monitoring
+func Name(name string) transport.Option {
+	return connOption(nats.Name(name))
+}
@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// listen starts an echo server returning its address
+func listen(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(c, c)
+		}
+	}()
+
+	return l
+}
+
+// connectProxy starts a proxy tunnelling CONNECT requests
+func connectProxy(t *testing.T, tunnels chan string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				req, err := http.ReadRequest(bufio.NewReader(c))
+				if err != nil || req.Method != "CONNECT" {
+					c.Close()
+					return
+				}
+				tunnels <- req.Host
+
+				tc, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					c.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+					c.Close()
+					return
+				}
+
+				c.Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+				go io.Copy(tc, c)
+				io.Copy(c, tc)
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestConnect(t *testing.T) {
+	echo := listen(t)
+	defer echo.Close()
+
+	tunnels := make(chan string, 1)
+	p := connectProxy(t, tunnels)
+	defer p.Close()
+
+	c, err := Dial(context.TODO(), URL("http://"+p.Addr().String()), "tcp", echo.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if host := <-tunnels; host != echo.Addr().String() {
+		t.Fatalf("expected tunnel to %s got %s", echo.Addr(), host)
+	}
+
+	if _, err := c.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(c, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "ping" {
+		t.Fatalf("expected ping got %s", b)
+	}
+}
+
+func TestStalledProxy(t *testing.T) {
+	// a proxy which accepts connections but never responds
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	// the handshakes of every scheme are bounded by the context
+	for _, scheme := range []string{"http", "https", "socks5"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		errc := make(chan error, 1)
+		go func() {
+			_, err := Dial(ctx, URL(scheme+"://"+l.Addr().String()), "tcp", "10.0.0.1:8080", 0)
+			errc <- err
+		}()
+
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Fatalf("expected %s dial to fail", scheme)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %s dial to time out", scheme)
+		}
+
+		cancel()
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	os.Setenv("MICRO_PROXY", "socks5://bastion:1080")
+	os.Setenv("NO_PROXY", ".internal")
+	defer os.Unsetenv("MICRO_PROXY")
+	defer os.Unsetenv("NO_PROXY")
+
+	fn := Get(nil)
+	if fn == nil {
+		t.Fatal("expected proxy from env")
+	}
+
+	u, err := fn("10.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u == nil || u.Host != "bastion:1080" {
+		t.Fatalf("expected bastion proxy got %v", u)
+	}
+
+	for _, addr := range []string{"foo.internal:8080", "127.0.0.1:8080"} {
+		if u, _ := fn(addr); u != nil {
+			t.Fatalf("expected %s to be dialed directly got %v", addr, u)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/transport"
+)
+
+var (
+	// heartbeatHeader marks heartbeat frames
+	heartbeatHeader = "Micro-Heartbeat"
+
+	errRecvTimeout = errors.New("tcp: receive timed out")
+)
+
+// heartbeat reads frames in the background, dropping heartbeats and
+// enforcing a read deadline, and sends heartbeats while the conn is open
+type heartbeat struct {
+	opts heartbeatOptions
+	conn net.Conn
+	dec  *gob.Decoder
+
+	// guards the encoder shared with heartbeats
+	sync.Mutex
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+
+	msgs chan *transport.Message
+	// set before msgs is closed
+	err error
+
+	exit chan bool
+	once sync.Once
+}
+
+func newHeartbeat(conn net.Conn, enc *gob.Encoder, encBuf *bufio.Writer, dec *gob.Decoder, opts heartbeatOptions) *heartbeat {
+	h := &heartbeat{
+		opts:   opts,
+		conn:   conn,
+		dec:    dec,
+		enc:    enc,
+		encBuf: encBuf,
+		msgs:   make(chan *transport.Message),
+		exit:   make(chan bool),
+	}
+
+	go h.read()
+	go h.ping()
+
+	return h
+}
+
+func (h *heartbeat) read() {
+	defer close(h.msgs)
+
+	for {
+		h.conn.SetReadDeadline(time.Now().Add(h.opts.timeout))
+
+		var m transport.Message
+		if err := h.dec.Decode(&m); err != nil {
+			h.err = err
+			h.Close()
+			return
+		}
+
+		if _, ok := m.Header[heartbeatHeader]; ok && len(m.Body) == 0 {
+			continue
+		}
+
+		select {
+		case h.msgs <- &m:
+		case <-h.exit:
+			h.err = errors.New("tcp: connection closed")
+			return
+		}
+	}
+}
+
+func (h *heartbeat) ping() {
+	t := time.NewTicker(h.opts.interval)
+	defer t.Stop()
+
+	ping := &transport.Message{
+		Header: map[string]string{heartbeatHeader: "1"},
+	}
+
+	for {
+		select {
+		case <-h.exit:
+			return
+		case <-t.C:
+			if err := h.Send(ping, 0); err != nil {
+				h.Close()
+				return
+			}
+		}
+	}
+}
+
+// Send writes the message within the timeout, or the heartbeat
+// timeout if not set, so blocked writes to dead peers fail
+func (h *heartbeat) Send(m *transport.Message, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = h.opts.timeout
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	h.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if err := h.enc.Encode(m); err != nil {
+		return err
+	}
+	return h.encBuf.Flush()
+}
+
+// Recv returns the next message other than a heartbeat
+func (h *heartbeat) Recv(m *transport.Message, timeout time.Duration) error {
+	var after <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		after = t.C
+	}
+
+	select {
+	case msg, ok := <-h.msgs:
+		if !ok {
+			return h.err
+		}
+		*m = *msg
+		return nil
+	case <-after:
+		return errRecvTimeout
+	}
+}
+
+func (h *heartbeat) Close() error {
+	h.once.Do(func() {
+		close(h.exit)
+	})
+	return h.conn.Close()
+}
@@ -0,0 +1,65 @@
+package tcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/transport"
+	"github.com/micro/go-plugins/transport/proxy"
+)
+
+type heartbeatKey struct{}
+
+type proxyKey struct{}
+
+type heartbeatOptions struct {
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// Heartbeat sends a heartbeat frame every interval and closes connections
+// which receive nothing, heartbeats included, within the timeout. Half open
+// connections, e.g. after a NAT timeout, are then detected within seconds
+// rather than waiting for the OS. It must be enabled on both sides.
+func Heartbeat(interval, timeout time.Duration) transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, heartbeatKey{}, heartbeatOptions{interval, timeout})
+	}
+}
+
+func getHeartbeat(o transport.Options) (heartbeatOptions, bool) {
+	if o.Context == nil {
+		return heartbeatOptions{}, false
+	}
+	h, ok := o.Context.Value(heartbeatKey{}).(heartbeatOptions)
+	if !ok || h.interval <= 0 {
+		return heartbeatOptions{}, false
+	}
+	if h.timeout <= h.interval {
+		h.timeout = h.interval * 3
+	}
+	return h, true
+}
+
+// Proxy dials connections through the proxy returned by fn, such as
+// proxy.URL("socks5://bastion:1080"). The MICRO_PROXY env var is
+// used when it isn't set.
+func Proxy(fn proxy.Func) transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, proxyKey{}, fn)
+	}
+}
+
+func getProxy(o transport.Options) proxy.Func {
+	var fn proxy.Func
+	if o.Context != nil {
+		fn, _ = o.Context.Value(proxyKey{}).(proxy.Func)
+	}
+	return proxy.Get(fn)
+}
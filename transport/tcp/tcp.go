@@ -3,6 +3,7 @@ package tcp
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"encoding/gob"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"github.com/micro/go-log"
 	"github.com/micro/go-micro/cmd"
 	"github.com/micro/go-micro/transport"
+	"github.com/micro/go-plugins/transport/proxy"
 	maddr "github.com/micro/util/go/lib/addr"
 	mnet "github.com/micro/util/go/lib/net"
 	mls "github.com/micro/util/go/lib/tls"
@@ -28,6 +30,7 @@ type tcpTransportClient struct {
 	dec      *gob.Decoder
 	encBuf   *bufio.Writer
 	timeout  time.Duration
+	hb       *heartbeat
 }
 
 type tcpTransportSocket struct {
@@ -36,11 +39,14 @@ type tcpTransportSocket struct {
 	dec     *gob.Decoder
 	encBuf  *bufio.Writer
 	timeout time.Duration
+	hb      *heartbeat
 }
 
 type tcpTransportListener struct {
-	listener net.Listener
-	timeout  time.Duration
+	listener  net.Listener
+	timeout   time.Duration
+	heartbeat bool
+	hbOpts    heartbeatOptions
 }
 
 func init() {
@@ -48,6 +54,9 @@ func init() {
 }
 
 func (t *tcpTransportClient) Send(m *transport.Message) error {
+	if t.hb != nil {
+		return t.hb.Send(m, t.timeout)
+	}
 	// set timeout if its greater than 0
 	if t.timeout > time.Duration(0) {
 		t.conn.SetDeadline(time.Now().Add(t.timeout))
@@ -59,6 +68,9 @@ func (t *tcpTransportClient) Send(m *transport.Message) error {
 }
 
 func (t *tcpTransportClient) Recv(m *transport.Message) error {
+	if t.hb != nil {
+		return t.hb.Recv(m, t.timeout)
+	}
 	// set timeout if its greater than 0
 	if t.timeout > time.Duration(0) {
 		t.conn.SetDeadline(time.Now().Add(t.timeout))
@@ -67,6 +79,9 @@ func (t *tcpTransportClient) Recv(m *transport.Message) error {
 }
 
 func (t *tcpTransportClient) Close() error {
+	if t.hb != nil {
+		return t.hb.Close()
+	}
 	return t.conn.Close()
 }
 
@@ -75,6 +90,10 @@ func (t *tcpTransportSocket) Recv(m *transport.Message) error {
 		return errors.New("message passed in is nil")
 	}
 
+	if t.hb != nil {
+		return t.hb.Recv(m, t.timeout)
+	}
+
 	// set timeout if its greater than 0
 	if t.timeout > time.Duration(0) {
 		t.conn.SetDeadline(time.Now().Add(t.timeout))
@@ -84,6 +103,9 @@ func (t *tcpTransportSocket) Recv(m *transport.Message) error {
 }
 
 func (t *tcpTransportSocket) Send(m *transport.Message) error {
+	if t.hb != nil {
+		return t.hb.Send(m, t.timeout)
+	}
 	// set timeout if its greater than 0
 	if t.timeout > time.Duration(0) {
 		t.conn.SetDeadline(time.Now().Add(t.timeout))
@@ -95,6 +117,9 @@ func (t *tcpTransportSocket) Send(m *transport.Message) error {
 }
 
 func (t *tcpTransportSocket) Close() error {
+	if t.hb != nil {
+		return t.hb.Close()
+	}
 	return t.conn.Close()
 }
 
@@ -136,6 +161,9 @@ func (t *tcpTransportListener) Accept(fn func(transport.Socket)) error {
 			enc:     gob.NewEncoder(encBuf),
 			dec:     gob.NewDecoder(c),
 		}
+		if t.heartbeat {
+			sock.hb = newHeartbeat(c, sock.enc, sock.encBuf, sock.dec, t.hbOpts)
+		}
 
 		go func() {
 			// TODO: think of a better error response strategy
@@ -159,8 +187,10 @@ func (t *tcpTransport) Dial(addr string, opts ...transport.DialOption) (transpor
 		opt(&dopts)
 	}
 
-	var conn net.Conn
-	var err error
+	conn, err := proxy.Dial(context.Background(), getProxy(t.opts), "tcp", addr, dopts.Timeout)
+	if err != nil {
+		return nil, err
+	}
 
 	// TODO: support dial option here rather than using internal config
 	if t.opts.Secure || t.opts.TLSConfig != nil {
@@ -170,25 +200,38 @@ func (t *tcpTransport) Dial(addr string, opts ...transport.DialOption) (transpor
 				InsecureSkipVerify: true,
 			}
 		}
-		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dopts.Timeout}, "tcp", addr, config)
-	} else {
-		conn, err = net.DialTimeout("tcp", addr, dopts.Timeout)
-	}
+		if len(config.ServerName) == 0 {
+			config = config.Clone()
+			config.ServerName, _, _ = net.SplitHostPort(addr)
+		}
 
-	if err != nil {
-		return nil, err
+		tc := tls.Client(conn, config)
+		if dopts.Timeout > 0 {
+			conn.SetDeadline(time.Now().Add(dopts.Timeout))
+		}
+		if err := tc.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+		conn = tc
 	}
 
 	encBuf := bufio.NewWriter(conn)
 
-	return &tcpTransportClient{
+	client := &tcpTransportClient{
 		dialOpts: dopts,
 		conn:     conn,
 		encBuf:   encBuf,
 		enc:      gob.NewEncoder(encBuf),
 		dec:      gob.NewDecoder(conn),
 		timeout:  t.opts.Timeout,
-	}, nil
+	}
+	if hb, ok := getHeartbeat(t.opts); ok {
+		client.hb = newHeartbeat(conn, client.enc, client.encBuf, client.dec, hb)
+	}
+
+	return client, nil
 }
 
 func (t *tcpTransport) Listen(addr string, opts ...transport.ListenOption) (transport.Listener, error) {
@@ -240,9 +283,13 @@ func (t *tcpTransport) Listen(addr string, opts ...transport.ListenOption) (tran
 		return nil, err
 	}
 
+	hb, ok := getHeartbeat(t.opts)
+
 	return &tcpTransportListener{
-		timeout:  t.opts.Timeout,
-		listener: l,
+		timeout:   t.opts.Timeout,
+		listener:  l,
+		heartbeat: ok,
+		hbOpts:    hb,
 	}, nil
 }
 
@@ -2,6 +2,8 @@ package tcp
 
 import (
 	"io"
+	"io/ioutil"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -229,3 +231,81 @@ func TestTCPTransportTimeout(t *testing.T) {
 
 	<-done
 }
+
+func TestTCPTransportHeartbeat(t *testing.T) {
+	tr := NewTransport(Heartbeat(time.Millisecond*20, time.Millisecond*100))
+
+	l, err := tr.Listen(":0")
+	if err != nil {
+		t.Fatalf("Unexpected listen err: %v", err)
+	}
+	defer l.Close()
+
+	fn := func(sock transport.Socket) {
+		defer sock.Close()
+
+		for {
+			var m transport.Message
+			if err := sock.Recv(&m); err != nil {
+				return
+			}
+			if err := sock.Send(&m); err != nil {
+				return
+			}
+		}
+	}
+
+	go l.Accept(fn)
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	defer c.Close()
+
+	// heartbeats keep an idle connection alive past the timeout
+	time.Sleep(time.Millisecond * 300)
+
+	m := transport.Message{Body: []byte(`hello`)}
+	if err := c.Send(&m); err != nil {
+		t.Fatalf("Unexpected send err: %v", err)
+	}
+
+	var rm transport.Message
+	if err := c.Recv(&rm); err != nil {
+		t.Fatalf("Unexpected recv err: %v", err)
+	}
+	if string(rm.Body) != "hello" {
+		t.Fatalf("Expected hello, got %s", rm.Body)
+	}
+
+	// a peer which stops responding is detected
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dead.Close()
+
+	go func() {
+		conn, err := dead.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(ioutil.Discard, conn)
+	}()
+
+	dc, err := tr.Dial(dead.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	defer dc.Close()
+
+	start := time.Now()
+	if err := dc.Recv(&rm); err == nil {
+		t.Fatal("Expected dead peer to be detected")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Expected dead peer to be detected within the timeout, took %v", d)
+	}
+}
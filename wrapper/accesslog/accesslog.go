@@ -0,0 +1,213 @@
+// Package accesslog provides wrappers which write structured json access logs
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+const redacted = "[REDACTED]"
+
+// Entry is a single access log line
+type Entry struct {
+	Time     time.Time         `json:"time"`
+	Kind     string            `json:"kind"`
+	Service  string            `json:"service"`
+	Endpoint string            `json:"endpoint"`
+	Peer     string            `json:"peer,omitempty"`
+	TraceId  string            `json:"trace_id,omitempty"`
+	Latency  float64           `json:"latency_ms"`
+	Status   int32             `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Request  interface{}       `json:"request,omitempty"`
+	Response interface{}       `json:"response,omitempty"`
+}
+
+type logger struct {
+	opts   Options
+	redact map[string]bool
+
+	sync.Mutex
+	enc *json.Encoder
+}
+
+func newLogger(opts ...Option) *logger {
+	options := newOptions(opts...)
+
+	redact := make(map[string]bool)
+	for _, f := range options.Redact {
+		redact[strings.ToLower(f)] = true
+	}
+
+	return &logger{
+		opts:   options,
+		redact: redact,
+		enc:    json.NewEncoder(options.Output),
+	}
+}
+
+func (l *logger) sampled(err error) bool {
+	rate := l.opts.SampleRate
+	if err != nil {
+		rate = l.opts.ErrorSampleRate
+	}
+	if rate >= 1 {
+		return true
+	}
+	return rand.Float64() < rate
+}
+
+// scrub returns a copy of the body with redacted fields replaced
+func (l *logger) scrub(v interface{}) interface{} {
+	if v == nil || len(l.redact) == 0 {
+		return v
+	}
+
+	// round trip through json to get a generic representation
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var g interface{}
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil
+	}
+
+	return l.scrubValue(g)
+}
+
+func (l *logger) scrubValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if l.redact[strings.ToLower(k)] {
+				t[k] = redacted
+				continue
+			}
+			t[k] = l.scrubValue(val)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = l.scrubValue(val)
+		}
+	}
+	return v
+}
+
+func traceId(md metadata.Metadata) string {
+	for k, v := range md {
+		switch strings.ToLower(k) {
+		case "traceparent":
+			if parts := strings.Split(v, "-"); len(parts) == 4 {
+				return parts[1]
+			}
+		case "x-b3-traceid", "x-trace-id", "x-amzn-trace-id":
+			return v
+		}
+	}
+	return ""
+}
+
+func peer(md metadata.Metadata) string {
+	for k, v := range md {
+		switch strings.ToLower(k) {
+		case "x-forwarded-for", "remote", "x-micro-from-service":
+			return v
+		}
+	}
+	return ""
+}
+
+func (l *logger) log(ctx context.Context, kind, service, endpoint string, start time.Time, req, rsp interface{}, err error) {
+	if !l.sampled(err) {
+		return
+	}
+
+	md, _ := metadata.FromContext(ctx)
+
+	e := Entry{
+		Time:     start,
+		Kind:     kind,
+		Service:  service,
+		Endpoint: endpoint,
+		Peer:     peer(md),
+		TraceId:  traceId(md),
+		Latency:  float64(time.Since(start)) / float64(time.Millisecond),
+		Status:   200,
+	}
+
+	if err != nil {
+		e.Error = err.Error()
+		e.Status = 500
+		if me, ok := err.(*errors.Error); ok && me.Code > 0 {
+			e.Status = me.Code
+		}
+	}
+
+	if len(md) > 0 {
+		e.Metadata = make(map[string]string, len(md))
+		for k, v := range md {
+			if l.redact[strings.ToLower(k)] {
+				v = redacted
+			}
+			e.Metadata[k] = v
+		}
+	}
+
+	if l.opts.Body {
+		e.Request = l.scrub(req)
+		if err == nil {
+			e.Response = l.scrub(rsp)
+		}
+	}
+
+	l.Lock()
+	l.enc.Encode(e)
+	l.Unlock()
+}
+
+type clientWrapper struct {
+	l *logger
+	client.Client
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	start := time.Now()
+	err := c.Client.Call(ctx, req, rsp, opts...)
+	c.l.log(ctx, "client", req.Service(), req.Method(), start, req.Request(), rsp, err)
+	return err
+}
+
+// NewClientWrapper returns a client Wrapper which logs outgoing calls
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	l := newLogger(opts...)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{l, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which logs incoming requests
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	l := newLogger(opts...)
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			start := time.Now()
+			err := h(ctx, req, rsp)
+			l.log(ctx, "server", req.Service(), req.Method(), start, req.Request(), rsp, err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+)
+
+func TestLog(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := newLogger(Output(buf), Body(true))
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"Authorization": "Bearer secret",
+		"traceparent":   "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+	})
+
+	req := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":     "john",
+			"password": "hunter2",
+		},
+	}
+
+	l.log(ctx, "server", "test.service", "Test.Method", time.Now(), req, nil, errors.NotFound("test.service", "not found"))
+
+	var e Entry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Status != 404 {
+		t.Fatalf("expected status 404 got %d", e.Status)
+	}
+	if e.TraceId != "0102030405060708090a0b0c0d0e0f10" {
+		t.Fatalf("unexpected trace id %s", e.TraceId)
+	}
+	if e.Metadata["Authorization"] != redacted {
+		t.Fatalf("expected redacted authorization got %s", e.Metadata["Authorization"])
+	}
+
+	user := e.Request.(map[string]interface{})["user"].(map[string]interface{})
+	if user["password"] != redacted || user["name"] != "john" {
+		t.Fatalf("unexpected request body %v", user)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := newLogger(Output(buf), Sample(0, 1))
+
+	l.log(context.TODO(), "server", "test.service", "Test.Method", time.Now(), nil, nil, nil)
+	if buf.Len() != 0 {
+		t.Fatal("expected success to be dropped")
+	}
+
+	l.log(context.TODO(), "server", "test.service", "Test.Method", time.Now(), nil, nil, errors.InternalServerError("test", "error"))
+	if buf.Len() == 0 {
+		t.Fatal("expected failure to be logged")
+	}
+}
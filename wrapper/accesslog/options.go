@@ -0,0 +1,68 @@
+package accesslog
+
+import (
+	"io"
+	"os"
+)
+
+// Options for the access log wrappers
+type Options struct {
+	// Output logs are written to as json lines, defaults to stdout
+	Output io.Writer
+	// Body logs the request and response bodies
+	Body bool
+	// Redact replaces the value of matching body fields and
+	// metadata keys, case insensitive
+	Redact []string
+	// SampleRate is the fraction of successful calls logged
+	SampleRate float64
+	// ErrorSampleRate is the fraction of failed calls logged
+	ErrorSampleRate float64
+}
+
+type Option func(*Options)
+
+// Output sets where logs are written
+func Output(w io.Writer) Option {
+	return func(o *Options) {
+		o.Output = w
+	}
+}
+
+// Body enables logging of request and response bodies
+func Body(b bool) Option {
+	return func(o *Options) {
+		o.Body = b
+	}
+}
+
+// Redact sets the fields whose values are redacted
+func Redact(fields ...string) Option {
+	return func(o *Options) {
+		o.Redact = append(o.Redact, fields...)
+	}
+}
+
+// Sample sets the fraction of successful and failed calls logged.
+// Failures are usually sampled at a higher rate than successes.
+func Sample(success, failure float64) Option {
+	return func(o *Options) {
+		o.SampleRate = success
+		o.ErrorSampleRate = failure
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Output:          os.Stdout,
+		Redact:          []string{"Authorization", "password", "token", "secret"},
+		SampleRate:      1,
+		ErrorSampleRate: 1,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
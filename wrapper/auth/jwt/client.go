@@ -0,0 +1,78 @@
+package jwt
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+type clientWrapper struct {
+	ts oauth2.TokenSource
+	client.Client
+}
+
+func (c *clientWrapper) token(ctx context.Context) (context.Context, error) {
+	t, err := c.ts.Token()
+	if err != nil {
+		return ctx, errors.Unauthorized("go.micro.client", "failed to get token: %v", err)
+	}
+
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		md = make(map[string]string)
+	}
+
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		nmd[k] = v
+	}
+	nmd["Authorization"] = "Bearer " + t.AccessToken
+
+	return metadata.NewContext(ctx, nmd), nil
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	ctx, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.Call(ctx, req, rsp, opts...)
+}
+
+func (c *clientWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	ctx, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.Stream(ctx, req, opts...)
+}
+
+func (c *clientWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	ctx, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Client.Publish(ctx, p, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which sets the bearer token
+// from the token source on all outgoing calls.
+func NewClientWrapper(ts oauth2.TokenSource) client.Wrapper {
+	// reuse tokens until they expire
+	ts = oauth2.ReuseTokenSource(nil, ts)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{ts, c}
+	}
+}
+
+// NewClientCredentialsWrapper returns a client Wrapper which obtains
+// service account tokens with the oauth2 client credentials flow,
+// refreshing them as they expire.
+func NewClientCredentialsWrapper(cfg *clientcredentials.Config) client.Wrapper {
+	return NewClientWrapper(cfg.TokenSource(context.Background()))
+}
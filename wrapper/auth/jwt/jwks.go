@@ -0,0 +1,164 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrKeyNotFound = errors.New("signing key not found")
+
+	// retryInterval is the least time between fetches of a stale key set,
+	// so requests don't refetch it each time while the issuer is failing
+	retryInterval = time.Second * 10
+)
+
+// KeySet looks up token verification keys by key id
+type KeySet interface {
+	Key(kid string) (interface{}, error)
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	sync.RWMutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+
+	// serialises fetches, guarding the last attempt and its error
+	mtx       sync.Mutex
+	attempted time.Time
+	err       error
+}
+
+func (j *jwks) fetch() error {
+	rsp, err := j.client.Get(j.url)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: unexpected status %d from %s", rsp.StatusCode, j.url)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.NewDecoder(rsp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (len(k.Use) > 0 && k.Use != "sig") {
+			continue
+		}
+		key, err := rsaKey(k)
+		if err != nil {
+			return err
+		}
+		keys[k.Kid] = key
+	}
+
+	j.Lock()
+	j.keys = keys
+	j.fetched = time.Now()
+	j.Unlock()
+
+	return nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// refetch fetches the key set unless it was attempted within the
+// interval, returning the error of the last attempt. Concurrent
+// callers wait for the fetch in flight.
+func (j *jwks) refetch(interval time.Duration) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	if time.Since(j.attempted) < interval {
+		return j.err
+	}
+
+	j.attempted = time.Now()
+	j.err = j.fetch()
+	return j.err
+}
+
+// Key returns the key for the id. The set is refetched when stale or
+// when the key isn't known, which picks up rotated keys. Unknown keys
+// trigger at most one fetch per minute, and a stale set one fetch per
+// retry interval, to avoid hammering the issuer.
+func (j *jwks) Key(kid string) (interface{}, error) {
+	j.RLock()
+	key, ok := j.keys[kid]
+	fetched := j.fetched
+	j.RUnlock()
+
+	stale := time.Since(fetched) > j.refresh
+	if ok && !stale {
+		return key, nil
+	}
+
+	interval := time.Minute
+	if stale {
+		interval = retryInterval
+	}
+
+	if err := j.refetch(interval); err != nil && !ok {
+		return nil, err
+	}
+
+	j.RLock()
+	key, ok = j.keys[kid]
+	j.RUnlock()
+
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// JWKS returns a key set fetched from the url and cached for the refresh period
+func JWKS(url string, refresh time.Duration) KeySet {
+	return &jwks{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: time.Second * 10},
+		keys:    make(map[string]*rsa.PublicKey),
+	}
+}
@@ -0,0 +1,173 @@
+// Package jwt provides wrappers for JWT/OIDC authentication and authorization.
+// The handler wrapper validates bearer tokens against a JWKS and enforces per
+// endpoint scope and role policies. The client wrapper injects service account
+// tokens obtained with the client credentials flow.
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type claimsKey struct{}
+
+// Claims returns the verified token claims from the context
+func Claims(ctx context.Context) (jwtgo.MapClaims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(jwtgo.MapClaims)
+	return c, ok
+}
+
+func bearer(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	for k, v := range md {
+		if !strings.EqualFold(k, "Authorization") {
+			continue
+		}
+		if len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+			return v[7:]
+		}
+	}
+	return ""
+}
+
+func stringSlice(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Fields(t)
+	case []interface{}:
+		var s []string
+		for _, i := range t {
+			if str, ok := i.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+// authorize checks the claims satisfy the policy
+func authorize(claims jwtgo.MapClaims, p Policy, rolesClaim string) error {
+	// scopes are space separated in "scope" or a list in "scp"
+	scopes := make(map[string]bool)
+	for _, s := range append(stringSlice(claims["scope"]), stringSlice(claims["scp"])...) {
+		scopes[s] = true
+	}
+	for _, s := range p.Scopes {
+		if !scopes[s] {
+			return fmt.Errorf("missing scope %s", s)
+		}
+	}
+
+	if len(p.Roles) == 0 {
+		return nil
+	}
+
+	for _, r := range stringSlice(claims[rolesClaim]) {
+		for _, want := range p.Roles {
+			if r == want {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("missing role")
+}
+
+func verify(token string, opts Options) (jwtgo.MapClaims, error) {
+	claims := jwtgo.MapClaims{}
+
+	_, err := jwtgo.ParseWithClaims(token, claims, func(t *jwtgo.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwtgo.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return opts.KeySet.Key(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.Issuer) > 0 && !claims.VerifyIssuer(opts.Issuer, true) {
+		return nil, fmt.Errorf("invalid issuer")
+	}
+
+	if len(opts.Audience) > 0 && !verifyAudience(claims, opts.Audience) {
+		return nil, fmt.Errorf("invalid audience")
+	}
+
+	return claims, nil
+}
+
+// verifyAudience handles aud as a string or a list
+func verifyAudience(claims jwtgo.MapClaims, aud string) bool {
+	for _, a := range stringSlice(claims["aud"]) {
+		if a == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which requires a valid
+// bearer token and enforces the endpoint policies. Claims are available to
+// handlers through Claims(ctx). Without a KeySet every request is denied.
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := Options{
+		RolesClaim: "roles",
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.KeySet == nil {
+		log.Log("[jwt] no key set configured, requests will be denied")
+	}
+
+	public := make(map[string]bool)
+	for _, e := range options.Public {
+		public[e] = true
+	}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if public[req.Method()] {
+				return h(ctx, req, rsp)
+			}
+
+			// tokens can't be verified without keys
+			if options.KeySet == nil {
+				return errors.InternalServerError(req.Service(), "no key set configured")
+			}
+
+			token := bearer(ctx)
+			if len(token) == 0 {
+				return errors.Unauthorized(req.Service(), "missing bearer token")
+			}
+
+			claims, err := verify(token, options)
+			if err != nil {
+				return errors.Unauthorized(req.Service(), "invalid token: %v", err)
+			}
+
+			if p, ok := options.Policies[req.Method()]; ok {
+				if err := authorize(claims, p, options.RolesClaim); err != nil {
+					return errors.Forbidden(req.Service(), "%v", err)
+				}
+			}
+
+			return h(context.WithValue(ctx, claimsKey{}, claims), req, rsp)
+		}
+	}
+}
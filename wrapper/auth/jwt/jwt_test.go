@@ -0,0 +1,139 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type staticKeys map[string]interface{}
+
+func (s staticKeys) Key(kid string) (interface{}, error) {
+	k, ok := s[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return k, nil
+}
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, claims jwtgo.MapClaims) string {
+	tok := jwtgo.NewWithClaims(jwtgo.SigningMethodRS256, claims)
+	tok.Header["kid"] = "1"
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := NewHandlerWrapper(
+		WithKeySet(staticKeys{"1": &key.PublicKey}),
+		Issuer("https://issuer"),
+		Audience("test"),
+		WithPolicy("Test.Method", Policy{Scopes: []string{"read"}}),
+	)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		if _, ok := Claims(ctx); !ok {
+			t.Fatal("expected claims in context")
+		}
+		return nil
+	})
+
+	testData := []struct {
+		claims jwtgo.MapClaims
+		code   int32
+	}{
+		{jwtgo.MapClaims{"iss": "https://issuer", "aud": "test", "scope": "read write"}, 0},
+		{jwtgo.MapClaims{"iss": "https://issuer", "aud": []string{"other", "test"}, "scope": "read"}, 0},
+		{jwtgo.MapClaims{"iss": "https://other", "aud": "test", "scope": "read"}, 401},
+		{jwtgo.MapClaims{"iss": "https://issuer", "aud": "other", "scope": "read"}, 401},
+		{jwtgo.MapClaims{"iss": "https://issuer", "aud": "test", "scope": "write"}, 403},
+		{jwtgo.MapClaims{"iss": "https://issuer", "aud": "test", "scope": "read", "exp": time.Now().Add(-time.Hour).Unix()}, 401},
+	}
+
+	for _, d := range testData {
+		ctx := metadata.NewContext(context.TODO(), map[string]string{
+			"Authorization": "Bearer " + sign(t, key, d.claims),
+		})
+
+		err := fn(ctx, &testRequest{}, nil)
+		if d.code == 0 {
+			if err != nil {
+				t.Fatalf("expected no error for %v got %v", d.claims, err)
+			}
+			continue
+		}
+
+		if e, ok := err.(*errors.Error); !ok || e.Code != d.code {
+			t.Fatalf("expected %d for %v got %v", d.code, d.claims, err)
+		}
+	}
+
+	if err := fn(context.TODO(), &testRequest{}, nil); err == nil {
+		t.Fatal("expected error for missing token")
+	}
+}
+
+func TestNoKeySet(t *testing.T) {
+	fn := NewHandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return nil
+	})
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"Authorization": "Bearer token",
+	})
+
+	if e, ok := fn(ctx, &testRequest{}, nil).(*errors.Error); !ok || e.Code != 500 {
+		t.Fatalf("expected 500 without a key set got %v", e)
+	}
+}
+
+func TestJWKSRetry(t *testing.T) {
+	var fetches int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ks := JWKS(srv.URL, time.Hour)
+
+	// failed fetches aren't retried on every request
+	for i := 0; i < 10; i++ {
+		if _, err := ks.Key("1"); err == nil {
+			t.Fatal("expected error fetching keys")
+		}
+	}
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("expected 1 fetch got %d", n)
+	}
+}
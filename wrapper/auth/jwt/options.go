@@ -0,0 +1,71 @@
+package jwt
+
+// Policy is the access required for an endpoint. The token
+// needs all the scopes and any one of the roles.
+type Policy struct {
+	Scopes []string
+	Roles  []string
+}
+
+// Options for the jwt handler wrapper
+type Options struct {
+	// KeySet used to verify tokens
+	KeySet KeySet
+	// Issuer the token must be issued by
+	Issuer string
+	// Audience the token must be issued for
+	Audience string
+	// Policies by endpoint, e.g. Greeter.Hello
+	Policies map[string]Policy
+	// Public endpoints which don't require a token
+	Public []string
+	// RolesClaim is the claim holding the roles, defaults to roles
+	RolesClaim string
+}
+
+type Option func(*Options)
+
+// WithKeySet sets the keys tokens are verified with
+func WithKeySet(k KeySet) Option {
+	return func(o *Options) {
+		o.KeySet = k
+	}
+}
+
+// Issuer sets the required issuer
+func Issuer(iss string) Option {
+	return func(o *Options) {
+		o.Issuer = iss
+	}
+}
+
+// Audience sets the required audience
+func Audience(aud string) Option {
+	return func(o *Options) {
+		o.Audience = aud
+	}
+}
+
+// WithPolicy sets the policy for an endpoint
+func WithPolicy(endpoint string, p Policy) Option {
+	return func(o *Options) {
+		if o.Policies == nil {
+			o.Policies = make(map[string]Policy)
+		}
+		o.Policies[endpoint] = p
+	}
+}
+
+// Public allows the endpoints to be called without a token
+func Public(endpoints ...string) Option {
+	return func(o *Options) {
+		o.Public = append(o.Public, endpoints...)
+	}
+}
+
+// RolesClaim sets the claim roles are read from
+func RolesClaim(c string) Option {
+	return func(o *Options) {
+		o.RolesClaim = c
+	}
+}
@@ -0,0 +1,144 @@
+// Package opa provides a wrapper which authorizes requests with
+// Open Policy Agent policies, either embedded rego or a remote OPA server
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/micro/go-plugins/wrapper/auth/jwt"
+)
+
+// Options for the opa wrapper
+type Options struct {
+	// Policy requests are evaluated against
+	Policy Policy
+	// Request includes the request body in the input
+	Request bool
+	// FailOpen allows requests when the policy can't be evaluated
+	FailOpen bool
+	// Skip endpoints which aren't evaluated
+	Skip []string
+}
+
+type Option func(*Options)
+
+// WithPolicy sets the policy
+func WithPolicy(p Policy) Option {
+	return func(o *Options) {
+		o.Policy = p
+	}
+}
+
+// Request includes the request body in the policy input
+func Request(b bool) Option {
+	return func(o *Options) {
+		o.Request = b
+	}
+}
+
+// FailOpen allows requests when evaluation fails
+func FailOpen(b bool) Option {
+	return func(o *Options) {
+		o.FailOpen = b
+	}
+}
+
+// Skip endpoints, e.g. Debug.Health
+func Skip(endpoints ...string) Option {
+	return func(o *Options) {
+		o.Skip = append(o.Skip, endpoints...)
+	}
+}
+
+// input builds the policy input for a request
+func input(ctx context.Context, req server.Request, body bool) *Input {
+	md, _ := metadata.FromContext(ctx)
+
+	in := &Input{
+		Service:  req.Service(),
+		Endpoint: req.Method(),
+		Metadata: make(map[string]string, len(md)),
+	}
+
+	for k, v := range md {
+		// never hand raw credentials to the policy
+		if strings.EqualFold(k, "Authorization") {
+			continue
+		}
+		in.Metadata[k] = v
+	}
+
+	if claims, ok := jwt.Claims(ctx); ok {
+		in.Claims = claims
+	}
+
+	if body {
+		in.Request = req.Request()
+	}
+
+	return in
+}
+
+// deny returns a forbidden error with the reasons encoded in the detail
+func deny(id string, reasons []string) error {
+	if len(reasons) == 0 {
+		reasons = []string{"denied by policy"}
+	}
+	b, _ := json.Marshal(map[string][]string{"reasons": reasons})
+	return &errors.Error{
+		Id:     id,
+		Code:   403,
+		Detail: string(b),
+		Status: "Forbidden",
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which evaluates the policy
+// for every request and denies with a 403 carrying the policy reasons. Claims
+// set by the jwt wrapper are included in the input, so it should be applied
+// before this one. Requests are denied when no policy is set.
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	skip := make(map[string]bool)
+	for _, e := range options.Skip {
+		skip[e] = true
+	}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if skip[req.Method()] {
+				return h(ctx, req, rsp)
+			}
+
+			// without a policy nothing is allowed
+			if options.Policy == nil {
+				return deny(req.Service(), []string{"no policy configured"})
+			}
+
+			d, err := options.Policy.Eval(ctx, input(ctx, req, options.Request))
+			if err != nil {
+				log.Logf("[opa] policy evaluation failed for %s: %v", req.Method(), err)
+				if options.FailOpen {
+					return h(ctx, req, rsp)
+				}
+				return errors.InternalServerError(req.Service(), "policy evaluation failed")
+			}
+
+			if !d.Allow {
+				return deny(req.Service(), d.Reasons)
+			}
+
+			return h(ctx, req, rsp)
+		}
+	}
+}
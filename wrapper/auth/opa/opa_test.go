@@ -0,0 +1,132 @@
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testRequest struct {
+	server.Request
+	method string
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return t.method
+}
+
+func (t *testRequest) Request() interface{} {
+	return nil
+}
+
+const module = `
+package micro.authz
+
+default decision = {"allow": false, "reasons": ["not an admin"]}
+
+decision = {"allow": true} {
+	input.metadata["X-Role"] == "admin"
+}
+
+decision = {"allow": true} {
+	input.endpoint == "Greeter.Hello"
+}
+`
+
+func handler(ctx context.Context, req server.Request, rsp interface{}) error {
+	return nil
+}
+
+func TestRego(t *testing.T) {
+	policy, err := Rego("data.micro.authz.decision", module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := NewHandlerWrapper(WithPolicy(policy))(handler)
+
+	testData := []struct {
+		method string
+		md     metadata.Metadata
+		allow  bool
+	}{
+		{"Greeter.Hello", nil, true},
+		{"Greeter.Delete", nil, false},
+		{"Greeter.Delete", metadata.Metadata{"X-Role": "admin"}, true},
+	}
+
+	for _, d := range testData {
+		ctx := metadata.NewContext(context.TODO(), d.md)
+		err := fn(ctx, &testRequest{method: d.method}, nil)
+		if d.allow && err != nil {
+			t.Fatalf("%s: unexpected error %v", d.method, err)
+		}
+		if !d.allow {
+			merr, ok := err.(*errors.Error)
+			if !ok || merr.Code != 403 {
+				t.Fatalf("%s: expected forbidden got %v", d.method, err)
+			}
+			var detail map[string][]string
+			if err := json.Unmarshal([]byte(merr.Detail), &detail); err != nil {
+				t.Fatal(err)
+			}
+			if len(detail["reasons"]) != 1 || detail["reasons"][0] != "not an admin" {
+				t.Fatalf("unexpected reasons %v", detail)
+			}
+		}
+	}
+}
+
+func TestRegoInvalid(t *testing.T) {
+	if _, err := Rego("data.micro.authz.decision", "package micro.authz\n\ndecision = {"); err == nil {
+		t.Fatal("expected a compile error for an invalid module")
+	}
+}
+
+func TestRemote(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/data/micro/authz/allow" {
+			w.WriteHeader(404)
+			return
+		}
+		var body struct {
+			Input Input `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": body.Input.Endpoint == "Greeter.Hello",
+		})
+	}))
+	defer ts.Close()
+
+	fn := NewHandlerWrapper(WithPolicy(Remote(ts.URL, "micro/authz/allow")))(handler)
+
+	if err := fn(context.TODO(), &testRequest{method: "Greeter.Hello"}, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := fn(context.TODO(), &testRequest{method: "Greeter.Delete"}, nil); err == nil {
+		t.Fatal("expected denial")
+	}
+}
+
+func TestNoPolicy(t *testing.T) {
+	fn := NewHandlerWrapper(Skip("Debug.Health"))(handler)
+
+	err := fn(context.TODO(), &testRequest{method: "Greeter.Hello"}, nil)
+	if merr, ok := err.(*errors.Error); !ok || merr.Code != 403 {
+		t.Fatalf("expected 403 without a policy got %v", err)
+	}
+	if err := fn(context.TODO(), &testRequest{method: "Debug.Health"}, nil); err != nil {
+		t.Fatalf("unexpected error for skipped endpoint %v", err)
+	}
+}
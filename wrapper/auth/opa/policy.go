@@ -0,0 +1,147 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Input is the document a policy is evaluated against
+type Input struct {
+	Service  string                 `json:"service"`
+	Endpoint string                 `json:"endpoint"`
+	Metadata map[string]string      `json:"metadata"`
+	Claims   map[string]interface{} `json:"claims,omitempty"`
+	Request  interface{}            `json:"request,omitempty"`
+}
+
+// Decision is the result of a policy evaluation. Policies return either
+// a boolean or an object with allow and reasons fields.
+type Decision struct {
+	Allow   bool     `json:"allow"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Policy evaluates an input and returns a decision
+type Policy interface {
+	Eval(ctx context.Context, in *Input) (*Decision, error)
+}
+
+// decision converts a rego result to a decision
+func decision(v interface{}) (*Decision, error) {
+	switch t := v.(type) {
+	case bool:
+		return &Decision{Allow: t}, nil
+	case map[string]interface{}:
+		d := &Decision{}
+		d.Allow, _ = t["allow"].(bool)
+		switch r := t["reasons"].(type) {
+		case []interface{}:
+			for _, i := range r {
+				d.Reasons = append(d.Reasons, fmt.Sprintf("%v", i))
+			}
+		case string:
+			d.Reasons = []string{r}
+		}
+		return d, nil
+	case nil:
+		// undefined decisions deny
+		return &Decision{Allow: false, Reasons: []string{"policy undefined"}}, nil
+	}
+	return nil, fmt.Errorf("unexpected policy result %T", v)
+}
+
+type embedded struct {
+	pq rego.PreparedEvalQuery
+}
+
+func (e *embedded) Eval(ctx context.Context, in *Input) (*Decision, error) {
+	// round trip the input so the policy sees plain json values
+	var doc interface{}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	rs, err := e.pq.Eval(ctx, rego.EvalInput(doc))
+	if err != nil {
+		return nil, err
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return decision(nil)
+	}
+	return decision(rs[0].Expressions[0].Value)
+}
+
+// Rego returns a policy evaluating the query, e.g. data.micro.authz.allow,
+// against an embedded rego module. The module is compiled up front so
+// syntax errors are returned here rather than failing every request.
+func Rego(query, module string) (Policy, error) {
+	pq, err := rego.New(
+		rego.Query(query),
+		rego.Module("policy.rego", module),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &embedded{pq: pq}, nil
+}
+
+// DefaultTimeout bounds requests to a remote OPA server
+var DefaultTimeout = 5 * time.Second
+
+type remote struct {
+	url    string
+	client *http.Client
+}
+
+func (r *remote) Eval(ctx context.Context, in *Input) (*Decision, error) {
+	b, err := json.Marshal(map[string]interface{}{"input": in})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", r.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("opa returned %s", rsp.Status)
+	}
+
+	var res struct {
+		Result interface{} `json:"result"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return decision(res.Result)
+}
+
+// Remote returns a policy evaluated by an OPA server, usually a sidecar.
+// The path is the data path of the decision, e.g. micro/authz.
+func Remote(addr, path string) Policy {
+	return &remote{
+		url: strings.TrimSuffix(addr, "/") + "/v1/data/" + strings.Trim(path, "/"),
+		client: &http.Client{
+			Timeout: DefaultTimeout,
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package spiffe
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+)
+
+type clientWrapper struct {
+	client.Client
+}
+
+// origin sets the origin header from the context of the handler
+func origin(ctx context.Context) context.Context {
+	id, ok := Origin(ctx)
+	if !ok {
+		return ctx
+	}
+
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		md = make(map[string]string)
+	}
+
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		nmd[k] = v
+	}
+	nmd[OriginHeader] = id.String()
+
+	return metadata.NewContext(ctx, nmd)
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return c.Client.Call(origin(ctx), req, rsp, opts...)
+}
+
+func (c *clientWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return c.Client.Stream(origin(ctx), req, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which forwards the origin of
+// the request being handled on outgoing calls. Services only use it if
+// the caller is listed in their Forwarders.
+func NewClientWrapper() client.Wrapper {
+	return func(c client.Client) client.Client {
+		return &clientWrapper{c}
+	}
+}
@@ -0,0 +1,72 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+// Options for the spiffe handler wrapper
+type Options struct {
+	// Policies are the ids allowed to call an endpoint, e.g. Greeter.Hello.
+	// An id ending in /* allows any id under the path.
+	Policies map[string][]string
+	// Default ids allowed to call endpoints without a policy,
+	// any authenticated caller if empty
+	Default []string
+	// TrustDomain callers must belong to, e.g. example.org
+	TrustDomain string
+	// Public endpoints which don't require a certificate
+	Public []string
+	// Forwarders are the ids, e.g. gateways, trusted to forward the origin
+	Forwarders []string
+	// Certificate returns the peer certificate of the request
+	Certificate func(ctx context.Context) (*x509.Certificate, bool)
+}
+
+type Option func(*Options)
+
+// WithPolicy sets the ids allowed to call the endpoint
+func WithPolicy(endpoint string, ids ...string) Option {
+	return func(o *Options) {
+		if o.Policies == nil {
+			o.Policies = make(map[string][]string)
+		}
+		o.Policies[endpoint] = append(o.Policies[endpoint], ids...)
+	}
+}
+
+// Default sets the ids allowed to call endpoints without a policy
+func Default(ids ...string) Option {
+	return func(o *Options) {
+		o.Default = append(o.Default, ids...)
+	}
+}
+
+// TrustDomain requires callers to belong to the trust domain
+func TrustDomain(td string) Option {
+	return func(o *Options) {
+		o.TrustDomain = td
+	}
+}
+
+// Public allows the endpoints to be called without a certificate
+func Public(endpoints ...string) Option {
+	return func(o *Options) {
+		o.Public = append(o.Public, endpoints...)
+	}
+}
+
+// Forwarders sets the ids trusted to forward the origin of a request
+func Forwarders(ids ...string) Option {
+	return func(o *Options) {
+		o.Forwarders = append(o.Forwarders, ids...)
+	}
+}
+
+// Certificate sets the function returning the peer certificate,
+// by default that of the grpc connection
+func Certificate(fn func(ctx context.Context) (*x509.Certificate, bool)) Option {
+	return func(o *Options) {
+		o.Certificate = fn
+	}
+}
@@ -0,0 +1,185 @@
+// Package spiffe provides wrappers for service to service authentication
+// with mTLS SPIFFE ids. The handler wrapper extracts the id from the URI SAN
+// of the peer certificate, places it in the context and enforces per endpoint
+// allowlists. The client wrapper forwards the origin of requests through
+// trusted intermediaries such as gateways.
+package spiffe
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/micro/grpc-go/credentials"
+	"github.com/micro/grpc-go/peer"
+)
+
+var (
+	// OriginHeader holds the id of the origin of a forwarded request
+	OriginHeader = "Micro-Spiffe-Origin"
+)
+
+type callerKey struct{}
+
+type originKey struct{}
+
+// Caller returns the id of the peer calling the handler
+func Caller(ctx context.Context) (*url.URL, bool) {
+	id, ok := ctx.Value(callerKey{}).(*url.URL)
+	return id, ok
+}
+
+// Origin returns the id of the origin of the request. It's the
+// forwarded origin if the caller is a trusted forwarder and the
+// caller otherwise.
+func Origin(ctx context.Context) (*url.URL, bool) {
+	if id, ok := ctx.Value(originKey{}).(*url.URL); ok {
+		return id, true
+	}
+	return Caller(ctx)
+}
+
+// ID returns the spiffe id of the certificate
+func ID(cert *x509.Certificate) (*url.URL, error) {
+	var id *url.URL
+	for _, u := range cert.URIs {
+		if u.Scheme != "spiffe" {
+			continue
+		}
+		// an svid holds exactly one id
+		if id != nil {
+			return nil, fmt.Errorf("multiple spiffe ids")
+		}
+		id = u
+	}
+	if id == nil {
+		return nil, fmt.Errorf("no spiffe id")
+	}
+	if len(id.Host) == 0 {
+		return nil, fmt.Errorf("missing trust domain")
+	}
+	return id, nil
+}
+
+// Parse parses and validates a spiffe id
+func Parse(s string) (*url.URL, error) {
+	id, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if id.Scheme != "spiffe" || len(id.Host) == 0 {
+		return nil, fmt.Errorf("invalid spiffe id %s", s)
+	}
+	return id, nil
+}
+
+// grpcCertificate returns the verified peer certificate of the grpc connection
+func grpcCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, false
+	}
+	// use the verified chain so unverified certificates are never trusted
+	for _, chain := range info.State.VerifiedChains {
+		if len(chain) > 0 {
+			return chain[0], true
+		}
+	}
+	return nil, false
+}
+
+// match checks the id is in the allowlist
+func match(id *url.URL, allowed []string) bool {
+	s := id.String()
+	for _, a := range allowed {
+		if a == s {
+			return true
+		}
+		if strings.HasSuffix(a, "/*") && strings.HasPrefix(s, a[:len(a)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func forwarded(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	for k, v := range md {
+		if strings.EqualFold(k, OriginHeader) {
+			return v
+		}
+	}
+	return ""
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which requires a peer
+// certificate with a spiffe id allowed by the endpoint policy. The ids are
+// available to handlers through Caller(ctx) and Origin(ctx).
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := Options{
+		Certificate: grpcCertificate,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	public := make(map[string]bool)
+	for _, e := range options.Public {
+		public[e] = true
+	}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if public[req.Method()] {
+				return h(ctx, req, rsp)
+			}
+
+			cert, ok := options.Certificate(ctx)
+			if !ok {
+				return errors.Unauthorized(req.Service(), "missing peer certificate")
+			}
+
+			id, err := ID(cert)
+			if err != nil {
+				return errors.Unauthorized(req.Service(), "invalid peer certificate: %v", err)
+			}
+
+			if len(options.TrustDomain) > 0 && id.Host != options.TrustDomain {
+				return errors.Forbidden(req.Service(), "untrusted domain %s", id.Host)
+			}
+
+			allowed, ok := options.Policies[req.Method()]
+			if !ok {
+				allowed = options.Default
+			}
+			if (ok || len(allowed) > 0) && !match(id, allowed) {
+				return errors.Forbidden(req.Service(), "%s is not allowed to call %s", id, req.Method())
+			}
+
+			ctx = context.WithValue(ctx, callerKey{}, id)
+
+			if o := forwarded(ctx); len(o) > 0 && match(id, options.Forwarders) {
+				origin, err := Parse(o)
+				if err != nil {
+					return errors.BadRequest(req.Service(), "invalid origin: %v", err)
+				}
+				ctx = context.WithValue(ctx, originKey{}, origin)
+			}
+
+			return h(ctx, req, rsp)
+		}
+	}
+}
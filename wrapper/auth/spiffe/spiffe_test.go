@@ -0,0 +1,118 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+type certKey struct{}
+
+func withCert(ctx context.Context, ids ...string) context.Context {
+	cert := &x509.Certificate{}
+	for _, id := range ids {
+		u, _ := url.Parse(id)
+		cert.URIs = append(cert.URIs, u)
+	}
+	return context.WithValue(ctx, certKey{}, cert)
+}
+
+func testCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	c, ok := ctx.Value(certKey{}).(*x509.Certificate)
+	return c, ok
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	var origin string
+
+	fn := NewHandlerWrapper(
+		Certificate(testCertificate),
+		TrustDomain("example.org"),
+		WithPolicy("Test.Method", "spiffe://example.org/ns/prod/*", "spiffe://example.org/gateway"),
+		Forwarders("spiffe://example.org/gateway"),
+	)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		if _, ok := Caller(ctx); !ok {
+			t.Fatal("expected caller in context")
+		}
+		id, _ := Origin(ctx)
+		origin = id.String()
+		return nil
+	})
+
+	testData := []struct {
+		ctx    context.Context
+		code   int32
+		origin string
+	}{
+		{context.TODO(), 401, ""},
+		{withCert(context.TODO()), 401, ""},
+		{withCert(context.TODO(), "spiffe://example.org/a", "spiffe://example.org/b"), 401, ""},
+		{withCert(context.TODO(), "spiffe://other.org/ns/prod/sa/a"), 403, ""},
+		{withCert(context.TODO(), "spiffe://example.org/ns/dev/sa/a"), 403, ""},
+		{withCert(context.TODO(), "spiffe://example.org/ns/prod/sa/a"), 0, "spiffe://example.org/ns/prod/sa/a"},
+		// origin is only taken from forwarders
+		{
+			metadata.NewContext(withCert(context.TODO(), "spiffe://example.org/ns/prod/sa/a"), metadata.Metadata{OriginHeader: "spiffe://example.org/ns/prod/sa/b"}),
+			0, "spiffe://example.org/ns/prod/sa/a",
+		},
+		{
+			metadata.NewContext(withCert(context.TODO(), "spiffe://example.org/gateway"), metadata.Metadata{OriginHeader: "spiffe://example.org/ns/prod/sa/b"}),
+			0, "spiffe://example.org/ns/prod/sa/b",
+		},
+	}
+
+	for i, d := range testData {
+		origin = ""
+		err := fn(d.ctx, &testRequest{}, nil)
+		if d.code == 0 {
+			if err != nil {
+				t.Fatalf("%d: unexpected error %v", i, err)
+			}
+			if origin != d.origin {
+				t.Fatalf("%d: expected origin %s got %s", i, d.origin, origin)
+			}
+			continue
+		}
+		if e := errors.Parse(err.Error()); e.Code != d.code {
+			t.Fatalf("%d: expected %d got %v", i, d.code, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	id, err := Parse("spiffe://example.org/ns/prod/sa/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !match(id, []string{"spiffe://example.org/ns/prod/*"}) {
+		t.Fatal("expected prefix match")
+	}
+	if match(id, []string{"spiffe://example.org/ns/pro*"}) {
+		t.Fatal("expected partial segment not to match")
+	}
+	if match(id, []string{"spiffe://example.org/ns/prod/sa/ab"}) {
+		t.Fatal("expected exact id not to match")
+	}
+
+	if _, err := Parse("https://example.org/a"); err == nil {
+		t.Fatal("expected non spiffe id to fail")
+	}
+}
@@ -0,0 +1,125 @@
+package gobreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/sony/gobreaker"
+)
+
+var (
+	errSlowCall = errors.New("slow call")
+)
+
+// Breakers holds a circuit breaker per service endpoint
+type Breakers struct {
+	opts   Options
+	events *events
+
+	sync.RWMutex
+	breakers map[string]*gobreaker.CircuitBreaker
+}
+
+type endpointWrapper struct {
+	b *Breakers
+	client.Client
+}
+
+func (b *Breakers) get(name string) *gobreaker.CircuitBreaker {
+	b.RLock()
+	cb, ok := b.breakers[name]
+	b.RUnlock()
+	if ok {
+		return cb
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if cb, ok := b.breakers[name]; ok {
+		return cb
+	}
+
+	opts := b.opts
+
+	cb = gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: opts.MaxRequests,
+		Interval:    opts.Interval,
+		Timeout:     opts.Timeout,
+		ReadyToTrip: func(c gobreaker.Counts) bool {
+			if c.Requests < opts.MinRequests {
+				return false
+			}
+			return float64(c.TotalFailures)/float64(c.Requests) >= opts.FailureRate
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			b.events.publish(Event{
+				Name: name,
+				From: from,
+				To:   to,
+				Time: time.Now(),
+			})
+		},
+	})
+
+	b.breakers[name] = cb
+	return cb
+}
+
+// State returns the state of the breaker for the service endpoint
+func (b *Breakers) State(service, endpoint string) gobreaker.State {
+	return b.get(service + "." + endpoint).State()
+}
+
+// Subscribe returns a channel of breaker state changes and a func
+// to stop the subscription. Events are dropped for slow subscribers.
+func (b *Breakers) Subscribe() (<-chan Event, func()) {
+	return b.events.subscribe()
+}
+
+func (b *Breakers) execute(name string, fn func() error) error {
+	slow := b.opts.SlowCallDuration
+
+	_, err := b.get(name).Execute(func() (interface{}, error) {
+		start := time.Now()
+		err := fn()
+		if err == nil && slow > 0 && time.Since(start) > slow {
+			return nil, errSlowCall
+		}
+		return nil, err
+	})
+
+	// slow calls count as failures but still succeeded
+	if err == errSlowCall {
+		return nil
+	}
+
+	return err
+}
+
+func (e *endpointWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return e.b.execute(req.Service()+"."+req.Method(), func() error {
+		return e.Client.Call(ctx, req, rsp, opts...)
+	})
+}
+
+// NewBreakers returns a set of per endpoint breakers
+func NewBreakers(opts ...Option) *Breakers {
+	return &Breakers{
+		opts:     newOptions(opts...),
+		events:   &events{subs: make(map[chan Event]bool)},
+		breakers: make(map[string]*gobreaker.CircuitBreaker),
+	}
+}
+
+// NewEndpointClientWrapper returns a client Wrapper which keys breakers
+// by service and endpoint so one failing endpoint doesn't trip the others.
+func NewEndpointClientWrapper(b *Breakers) client.Wrapper {
+	return func(c client.Client) client.Client {
+		return &endpointWrapper{b, c}
+	}
+}
@@ -0,0 +1,59 @@
+package gobreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+func TestEndpointBreakers(t *testing.T) {
+	b := NewBreakers(FailureRate(0.5, 4))
+
+	events, stop := b.Subscribe()
+	defer stop()
+
+	fail := func() error { return errors.New("failed") }
+
+	for i := 0; i < 4; i++ {
+		b.execute("test.service.Foo.Bar", fail)
+	}
+
+	if s := b.State("test.service", "Foo.Bar"); s != gobreaker.StateOpen {
+		t.Fatalf("expected open breaker got %v", s)
+	}
+
+	// other endpoints are unaffected
+	if s := b.State("test.service", "Foo.Baz"); s != gobreaker.StateClosed {
+		t.Fatalf("expected closed breaker got %v", s)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Name != "test.service.Foo.Bar" || ev.To != gobreaker.StateOpen {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected state change event")
+	}
+}
+
+func TestSlowCalls(t *testing.T) {
+	b := NewBreakers(FailureRate(1, 2), SlowCallDuration(time.Millisecond))
+
+	slow := func() error {
+		time.Sleep(time.Millisecond * 5)
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.execute("test.service.Foo.Bar", slow); err != nil {
+			t.Fatalf("expected slow call to succeed got %v", err)
+		}
+	}
+
+	if err := b.execute("test.service.Foo.Bar", slow); err != gobreaker.ErrOpenState {
+		t.Fatalf("expected %v got %v", gobreaker.ErrOpenState, err)
+	}
+}
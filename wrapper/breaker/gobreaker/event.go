@@ -0,0 +1,54 @@
+package gobreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// Event is emitted when a breaker changes state
+type Event struct {
+	// Name of the breaker, the service and endpoint
+	Name string
+	From gobreaker.State
+	To   gobreaker.State
+	Time time.Time
+}
+
+// events fans out state changes to subscribers
+type events struct {
+	sync.RWMutex
+	subs map[chan Event]bool
+}
+
+func (e *events) publish(ev Event) {
+	e.RLock()
+	defer e.RUnlock()
+
+	for ch := range e.subs {
+		// never block the call path on slow subscribers
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (e *events) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	e.Lock()
+	e.subs[ch] = true
+	e.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.Lock()
+			delete(e.subs, ch)
+			e.Unlock()
+			close(ch)
+		})
+	}
+}
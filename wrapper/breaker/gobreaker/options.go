@@ -0,0 +1,76 @@
+package gobreaker
+
+import (
+	"time"
+)
+
+// Options for the per endpoint breakers
+type Options struct {
+	// MaxRequests allowed through while half-open
+	MaxRequests uint32
+	// Interval after which closed breaker counts are cleared
+	Interval time.Duration
+	// Timeout after which an open breaker becomes half-open
+	Timeout time.Duration
+	// MinRequests before the failure rate is considered
+	MinRequests uint32
+	// FailureRate in the range (0, 1] at which the breaker trips
+	FailureRate float64
+	// SlowCallDuration over which successful calls count as failures.
+	// Zero disables slow call detection.
+	SlowCallDuration time.Duration
+}
+
+type Option func(*Options)
+
+// MaxRequests sets the number of probe requests allowed while half-open
+func MaxRequests(n uint32) Option {
+	return func(o *Options) {
+		o.MaxRequests = n
+	}
+}
+
+// Interval sets the period after which counts are cleared
+func Interval(d time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = d
+	}
+}
+
+// Timeout sets how long a breaker stays open before probing
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// FailureRate trips the breaker once the ratio of failed calls reaches
+// rate, after at least min requests have been made
+func FailureRate(rate float64, min uint32) Option {
+	return func(o *Options) {
+		o.FailureRate = rate
+		o.MinRequests = min
+	}
+}
+
+// SlowCallDuration counts calls slower than d as failures
+func SlowCallDuration(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowCallDuration = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		MaxRequests: 1,
+		Timeout:     time.Second * 60,
+		MinRequests: 10,
+		FailureRate: 0.5,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
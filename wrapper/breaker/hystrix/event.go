@@ -0,0 +1,60 @@
+package hystrix
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is emitted when a circuit opens or closes
+type Event struct {
+	// Name of the command, the service and endpoint
+	Name string
+	Open bool
+	Time time.Time
+}
+
+// Events fans out circuit state changes to subscribers
+type Events struct {
+	sync.RWMutex
+	subs map[chan Event]bool
+}
+
+func (e *Events) publish(ev Event) {
+	e.RLock()
+	defer e.RUnlock()
+
+	for ch := range e.subs {
+		// never block the call path on slow subscribers
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of state changes and a func to stop
+// the subscription. Events are dropped for slow subscribers.
+func (e *Events) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	e.Lock()
+	e.subs[ch] = true
+	e.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.Lock()
+			delete(e.subs, ch)
+			e.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// NewEvents returns an event stream to pass to WithEvents
+func NewEvents() *Events {
+	return &Events{
+		subs: make(map[chan Event]bool),
+	}
+}
@@ -1,6 +1,9 @@
 package hystrix
 
 import (
+	"sync"
+	"time"
+
 	"github.com/afex/hystrix-go/hystrix"
 	"github.com/micro/go-micro/client"
 
@@ -8,18 +11,82 @@ import (
 )
 
 type clientWrapper struct {
+	opts Options
+
+	sync.Mutex
+	// commands configured and whether their circuit was open
+	commands map[string]bool
 	client.Client
 }
 
+// configure sets up the command the first time it's seen
+func (c *clientWrapper) configure(name string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.commands[name]; ok {
+		return
+	}
+	c.commands[name] = false
+
+	if c.opts.Config == nil {
+		return
+	}
+
+	hystrix.ConfigureCommand(name, *c.opts.Config)
+}
+
+// notify emits an event if the circuit changed state since the last call
+func (c *clientWrapper) notify(name string) {
+	if c.opts.Events == nil {
+		return
+	}
+
+	cb, _, err := hystrix.GetCircuit(name)
+	if err != nil {
+		return
+	}
+
+	open := cb.IsOpen()
+
+	c.Lock()
+	changed := c.commands[name] != open
+	c.commands[name] = open
+	c.Unlock()
+
+	if changed {
+		c.opts.Events.publish(Event{
+			Name: name,
+			Open: open,
+			Time: time.Now(),
+		})
+	}
+}
+
 func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
-	return hystrix.Do(req.Service()+"."+req.Method(), func() error {
+	name := req.Service() + "." + req.Method()
+
+	c.configure(name)
+	defer c.notify(name)
+
+	return hystrix.Do(name, func() error {
 		return c.Client.Call(ctx, req, rsp, opts...)
 	}, nil)
 }
 
-// NewClientWrapper returns a hystrix client Wrapper.
-func NewClientWrapper() client.Wrapper {
+// NewClientWrapper returns a hystrix client Wrapper. Commands are
+// named by service and endpoint so each endpoint has its own circuit.
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
 	return func(c client.Client) client.Client {
-		return &clientWrapper{c}
+		return &clientWrapper{
+			opts:     options,
+			commands: make(map[string]bool),
+			Client:   c,
+		}
 	}
 }
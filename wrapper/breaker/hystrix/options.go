@@ -0,0 +1,61 @@
+package hystrix
+
+import (
+	"time"
+
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+// Options for the hystrix wrapper
+type Options struct {
+	// Config applied to each endpoint command
+	Config *hystrix.CommandConfig
+	// Events receives circuit state changes
+	Events *Events
+}
+
+type Option func(*Options)
+
+func config(o *Options) *hystrix.CommandConfig {
+	if o.Config == nil {
+		o.Config = &hystrix.CommandConfig{
+			Timeout:                hystrix.DefaultTimeout,
+			MaxConcurrentRequests:  hystrix.DefaultMaxConcurrent,
+			RequestVolumeThreshold: hystrix.DefaultVolumeThreshold,
+			SleepWindow:            hystrix.DefaultSleepWindow,
+			ErrorPercentThreshold:  hystrix.DefaultErrorPercentThreshold,
+		}
+	}
+	return o.Config
+}
+
+// FailureRate trips the circuit once percent of calls fail,
+// after at least volume requests in the rolling window
+func FailureRate(percent, volume int) Option {
+	return func(o *Options) {
+		c := config(o)
+		c.ErrorPercentThreshold = percent
+		c.RequestVolumeThreshold = volume
+	}
+}
+
+// SlowCallDuration fails calls which take longer than d
+func SlowCallDuration(d time.Duration) Option {
+	return func(o *Options) {
+		config(o).Timeout = int(d / time.Millisecond)
+	}
+}
+
+// SleepWindow sets how long the circuit stays open before probing
+func SleepWindow(d time.Duration) Option {
+	return func(o *Options) {
+		config(o).SleepWindow = int(d / time.Millisecond)
+	}
+}
+
+// WithEvents publishes circuit state changes to e
+func WithEvents(e *Events) Option {
+	return func(o *Options) {
+		o.Events = e
+	}
+}
@@ -0,0 +1,238 @@
+// Package buildinfo provides wrappers which set the version, git commit,
+// environment and pod of the caller on all outgoing calls and broker
+// messages, and place the caller's build info in the server context.
+package buildinfo
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+const (
+	// VersionHeader is the metadata key holding the caller's version
+	VersionHeader = "Micro-Caller-Version"
+	// CommitHeader is the metadata key holding the caller's git commit
+	CommitHeader = "Micro-Caller-Commit"
+	// EnvironmentHeader is the metadata key holding the caller's environment
+	EnvironmentHeader = "Micro-Caller-Environment"
+	// PodHeader is the metadata key holding the caller's pod name
+	PodHeader = "Micro-Caller-Pod"
+)
+
+var (
+	// Version of the build, set with -ldflags
+	// "-X github.com/micro/go-plugins/wrapper/buildinfo.Version=1.0.0"
+	Version string
+	// Commit of the build, set with -ldflags
+	// "-X github.com/micro/go-plugins/wrapper/buildinfo.Commit=$(git rev-parse HEAD)"
+	Commit string
+)
+
+type infoKey struct{}
+
+// Info is the build and deploy info of a service
+type Info struct {
+	Version     string
+	Commit      string
+	Environment string
+	Pod         string
+}
+
+type Option func(*Info)
+
+// WithVersion sets the version, defaults to the Version
+// variable then the VERSION env var
+func WithVersion(v string) Option {
+	return func(i *Info) {
+		i.Version = v
+	}
+}
+
+// WithCommit sets the git commit, defaults to the Commit
+// variable then the GIT_COMMIT env var
+func WithCommit(c string) Option {
+	return func(i *Info) {
+		i.Commit = c
+	}
+}
+
+// WithEnvironment sets the deploy environment,
+// defaults to the ENVIRONMENT env var
+func WithEnvironment(e string) Option {
+	return func(i *Info) {
+		i.Environment = e
+	}
+}
+
+// WithPod sets the pod name, defaults to the POD_NAME
+// env var then the hostname
+func WithPod(p string) Option {
+	return func(i *Info) {
+		i.Pod = p
+	}
+}
+
+func env(vals ...string) string {
+	for _, v := range vals {
+		if len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
+
+func newInfo(opts ...Option) Info {
+	hostname, _ := os.Hostname()
+
+	info := Info{
+		Version:     env(Version, os.Getenv("VERSION")),
+		Commit:      env(Commit, os.Getenv("GIT_COMMIT")),
+		Environment: os.Getenv("ENVIRONMENT"),
+		Pod:         env(os.Getenv("POD_NAME"), hostname),
+	}
+	for _, o := range opts {
+		o(&info)
+	}
+	return info
+}
+
+// NewInfo returns the build info of this service, the options
+// override the defaults read from the build and environment
+func NewInfo(opts ...Option) Info {
+	return newInfo(opts...)
+}
+
+// headers returns the non empty info as metadata
+func (i Info) headers() map[string]string {
+	md := make(map[string]string, 4)
+	for k, v := range map[string]string{
+		VersionHeader:     i.Version,
+		CommitHeader:      i.Commit,
+		EnvironmentHeader: i.Environment,
+		PodHeader:         i.Pod,
+	} {
+		if len(v) > 0 {
+			md[k] = v
+		}
+	}
+	return md
+}
+
+// NewContext returns a context holding the caller's info
+func NewContext(ctx context.Context, i Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, i)
+}
+
+// FromContext returns the caller's info from the context
+func FromContext(ctx context.Context) (Info, bool) {
+	i, ok := ctx.Value(infoKey{}).(Info)
+	return i, ok
+}
+
+// extract reads the caller's info from the incoming metadata
+func extract(ctx context.Context) (Info, bool) {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return Info{}, false
+	}
+
+	var info Info
+	var found bool
+
+	for k, v := range md {
+		switch {
+		case strings.EqualFold(k, VersionHeader):
+			info.Version = v
+		case strings.EqualFold(k, CommitHeader):
+			info.Commit = v
+		case strings.EqualFold(k, EnvironmentHeader):
+			info.Environment = v
+		case strings.EqualFold(k, PodHeader):
+			info.Pod = v
+		default:
+			continue
+		}
+		found = true
+	}
+
+	return info, found
+}
+
+// inject sets the info on the outgoing metadata, replacing any
+// caller info received from upstream
+func inject(ctx context.Context, headers map[string]string) context.Context {
+	md, _ := metadata.FromContext(ctx)
+	nmd := make(metadata.Metadata, len(md)+len(headers))
+	for k, v := range md {
+		switch {
+		case strings.EqualFold(k, VersionHeader),
+			strings.EqualFold(k, CommitHeader),
+			strings.EqualFold(k, EnvironmentHeader),
+			strings.EqualFold(k, PodHeader):
+			continue
+		}
+		nmd[k] = v
+	}
+	for k, v := range headers {
+		nmd[k] = v
+	}
+	return metadata.NewContext(ctx, nmd)
+}
+
+type clientWrapper struct {
+	headers map[string]string
+	client.Client
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return c.Client.Call(inject(ctx, c.headers), req, rsp, opts...)
+}
+
+func (c *clientWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return c.Client.Stream(inject(ctx, c.headers), req, opts...)
+}
+
+func (c *clientWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	return c.Client.Publish(inject(ctx, c.headers), p, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which sets the build
+// info on outgoing calls and broker messages
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	headers := newInfo(opts...).headers()
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{headers, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which places
+// the caller's build info in the context
+func NewHandlerWrapper() server.HandlerWrapper {
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if info, ok := extract(ctx); ok {
+				ctx = NewContext(ctx, info)
+			}
+			return h(ctx, req, rsp)
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which
+// places the publisher's build info in the context
+func NewSubscriberWrapper() server.SubscriberWrapper {
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			if info, ok := extract(ctx); ok {
+				ctx = NewContext(ctx, info)
+			}
+			return fn(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package buildinfo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func TestInject(t *testing.T) {
+	info := newInfo(WithVersion("1.0.0"), WithCommit("abc123"), WithEnvironment("prod"), WithPod("foo-1"))
+
+	// info from upstream is replaced
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"micro-caller-version": "0.9.0",
+		"Foo":                  "bar",
+	})
+	md, _ := metadata.FromContext(inject(ctx, info.headers()))
+
+	expect := map[string]string{
+		VersionHeader:     "1.0.0",
+		CommitHeader:      "abc123",
+		EnvironmentHeader: "prod",
+		PodHeader:         "foo-1",
+		"Foo":             "bar",
+	}
+	if len(md) != len(expect) {
+		t.Fatalf("expected %d headers got %v", len(expect), md)
+	}
+	for k, v := range expect {
+		if md[k] != v {
+			t.Fatalf("expected %s to be %s got %s", k, v, md[k])
+		}
+	}
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	var got Info
+	var ok bool
+
+	fn := NewHandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		got, ok = FromContext(ctx)
+		return nil
+	})
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"Micro-Caller-Version": "1.0.0",
+		"micro-caller-pod":     "foo-1",
+	})
+	if err := fn(ctx, &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || got.Version != "1.0.0" || got.Pod != "foo-1" {
+		t.Fatalf("unexpected info %+v", got)
+	}
+
+	if err := fn(context.TODO(), &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected no info without headers")
+	}
+}
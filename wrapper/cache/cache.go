@@ -0,0 +1,241 @@
+// Package cache provides a client wrapper which caches responses for read
+// heavy endpoints with a TTL, stale-while-revalidate and explicit invalidation
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+)
+
+// Policy is the caching policy for an endpoint
+type Policy struct {
+	// TTL responses are fresh for
+	TTL time.Duration
+	// Stale is how long after the TTL a response may still be
+	// returned while it's refreshed in the background
+	Stale time.Duration
+	// Vary is the metadata, in addition to the Authorization, the
+	// response depends on. Responses are cached per caller.
+	Vary []string
+}
+
+// Cache caches responses for the configured endpoints
+type Cache struct {
+	store    Store
+	policies map[string]Policy
+
+	sync.Mutex
+	// refreshes in flight
+	refreshing map[string]bool
+}
+
+type clientWrapper struct {
+	c *Cache
+	client.Client
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Marshal(pb)
+	}
+	return json.Marshal(v)
+}
+
+func unmarshal(b []byte, v interface{}) error {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(b, pb)
+	}
+	return json.Unmarshal(b, v)
+}
+
+func prefix(service, endpoint string) string {
+	return service + ":" + endpoint + ":"
+}
+
+// requestKey is the prefix of the keys of the request for all callers
+func requestKey(service, endpoint string, req interface{}) (string, error) {
+	b, err := marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return prefix(service, endpoint) + hex.EncodeToString(h[:]) + ":", nil
+}
+
+func header(md metadata.Metadata, key string) string {
+	if v, ok := md[key]; ok {
+		return v
+	}
+	for k, v := range md {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// key scopes the request key to the caller by a hash of the
+// authorization and vary metadata so responses aren't shared
+func key(ctx context.Context, service, endpoint string, req interface{}, p Policy) (string, error) {
+	k, err := requestKey(service, endpoint, req)
+	if err != nil {
+		return "", err
+	}
+
+	md, _ := metadata.FromContext(ctx)
+
+	h := sha256.New()
+	for _, v := range append([]string{"Authorization"}, p.Vary...) {
+		h.Write([]byte(header(md, v)))
+		h.Write([]byte{0})
+	}
+	return k + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// detach returns a context with the metadata of the caller which
+// isn't cancelled when the call returns
+func detach(ctx context.Context) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return context.Background()
+	}
+	cp := make(metadata.Metadata, len(md))
+	for k, v := range md {
+		cp[k] = v
+	}
+	return metadata.NewContext(context.Background(), cp)
+}
+
+// encode prefixes the response with the time it was cached
+func encode(t time.Time, b []byte) []byte {
+	buf := make([]byte, 8+len(b))
+	binary.BigEndian.PutUint64(buf, uint64(t.UnixNano()))
+	copy(buf[8:], b)
+	return buf
+}
+
+func decode(b []byte) (time.Time, []byte, bool) {
+	if len(b) < 8 {
+		return time.Time{}, nil, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b))), b[8:], true
+}
+
+func (c *Cache) set(k string, p Policy, rsp interface{}) {
+	b, err := marshal(rsp)
+	if err != nil {
+		log.Logf("cache: failed to encode response for %s: %v", k, err)
+		return
+	}
+	if err := c.store.Set(k, encode(time.Now(), b), p.TTL+p.Stale); err != nil {
+		log.Logf("cache: failed to set %s: %v", k, err)
+	}
+}
+
+// refresh updates the cached response in the background once per key
+func (c *Cache) refresh(k string, p Policy, fn func() (interface{}, error)) {
+	c.Lock()
+	if c.refreshing[k] {
+		c.Unlock()
+		return
+	}
+	c.refreshing[k] = true
+	c.Unlock()
+
+	go func() {
+		defer func() {
+			c.Lock()
+			delete(c.refreshing, k)
+			c.Unlock()
+		}()
+
+		rsp, err := fn()
+		if err != nil {
+			return
+		}
+		c.set(k, p, rsp)
+	}()
+}
+
+// Invalidate removes all cached responses for the endpoint
+func (c *Cache) Invalidate(service, endpoint string) error {
+	return c.store.Delete(prefix(service, endpoint))
+}
+
+// InvalidateRequest removes the cached responses for the request
+func (c *Cache) InvalidateRequest(service, endpoint string, req interface{}) error {
+	k, err := requestKey(service, endpoint, req)
+	if err != nil {
+		return err
+	}
+	return c.store.Delete(k)
+}
+
+func (w *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	p, ok := w.c.policies[req.Service()+"."+req.Method()]
+	if !ok {
+		return w.Client.Call(ctx, req, rsp, opts...)
+	}
+
+	k, err := key(ctx, req.Service(), req.Method(), req.Request(), p)
+	if err != nil {
+		return w.Client.Call(ctx, req, rsp, opts...)
+	}
+
+	if b, err := w.c.store.Get(k); err == nil && b != nil {
+		if t, data, ok := decode(b); ok {
+			age := time.Since(t)
+			if age < p.TTL+p.Stale && unmarshal(data, rsp) == nil {
+				if age >= p.TTL {
+					rctx := detach(ctx)
+					w.c.refresh(k, p, func() (interface{}, error) {
+						// decode into a fresh value of the same type
+						fresh := newValue(rsp)
+						err := w.Client.Call(rctx, req, fresh, opts...)
+						return fresh, err
+					})
+				}
+				return nil
+			}
+		}
+	}
+
+	if err := w.Client.Call(ctx, req, rsp, opts...); err != nil {
+		return err
+	}
+
+	w.c.set(k, p, rsp)
+	return nil
+}
+
+// New returns a cache for the endpoints, keyed by service.Endpoint
+// e.g. go.micro.srv.greeter.Greeter.Hello. A nil store uses memory.
+func New(store Store, policies map[string]Policy) *Cache {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	return &Cache{
+		store:      store,
+		policies:   policies,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// NewClientWrapper returns a client Wrapper which serves responses from the cache
+func NewClientWrapper(c *Cache) client.Wrapper {
+	return func(cl client.Client) client.Client {
+		return &clientWrapper{c, cl}
+	}
+}
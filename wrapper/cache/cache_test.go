@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+)
+
+type testRequest struct {
+	client.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func (t *testRequest) Request() interface{} {
+	return map[string]string{"id": "1"}
+}
+
+type testClient struct {
+	client.Client
+	calls int
+	mds   chan metadata.Metadata
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	t.calls++
+	if t.mds != nil {
+		md, _ := metadata.FromContext(ctx)
+		t.mds <- md
+	}
+	(*rsp.(*map[string]int))["calls"] = t.calls
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	c := New(nil, map[string]Policy{
+		"test.service.Test.Method": {TTL: time.Minute},
+	})
+
+	tc := &testClient{}
+	cl := NewClientWrapper(c)(tc)
+
+	for i := 0; i < 3; i++ {
+		rsp := map[string]int{}
+		if err := cl.Call(context.TODO(), &testRequest{}, &rsp); err != nil {
+			t.Fatal(err)
+		}
+		if rsp["calls"] != 1 {
+			t.Fatalf("expected cached response got %v", rsp)
+		}
+	}
+
+	if err := c.Invalidate("test.service", "Test.Method"); err != nil {
+		t.Fatal(err)
+	}
+
+	rsp := map[string]int{}
+	cl.Call(context.TODO(), &testRequest{}, &rsp)
+	if rsp["calls"] != 2 {
+		t.Fatalf("expected fresh response after invalidation got %v", rsp)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	c := New(nil, map[string]Policy{
+		"test.service.Test.Method": {TTL: time.Millisecond, Stale: time.Minute},
+	})
+
+	tc := &testClient{}
+	cl := NewClientWrapper(c)(tc)
+
+	rsp := map[string]int{}
+	cl.Call(context.TODO(), &testRequest{}, &rsp)
+
+	time.Sleep(time.Millisecond * 5)
+
+	// stale response is returned while refreshing
+	tc.mds = make(chan metadata.Metadata, 1)
+	ctx, cancel := context.WithCancel(metadata.NewContext(context.TODO(), metadata.Metadata{
+		"Foo": "bar",
+	}))
+
+	rsp = map[string]int{}
+	cl.Call(ctx, &testRequest{}, &rsp)
+	cancel()
+	if rsp["calls"] != 1 {
+		t.Fatalf("expected stale response got %v", rsp)
+	}
+
+	// the refresh carries the metadata of the caller
+	select {
+	case md := <-tc.mds:
+		if md["Foo"] != "bar" {
+			t.Fatalf("expected the caller metadata in the refresh got %v", md)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the response to be refreshed")
+	}
+}
+
+func TestCallerScope(t *testing.T) {
+	c := New(nil, map[string]Policy{
+		"test.service.Test.Method": {TTL: time.Minute, Vary: []string{"Tenant"}},
+	})
+
+	tc := &testClient{}
+	cl := NewClientWrapper(c)(tc)
+
+	call := func(md metadata.Metadata) int {
+		rsp := map[string]int{}
+		if err := cl.Call(metadata.NewContext(context.TODO(), md), &testRequest{}, &rsp); err != nil {
+			t.Fatal(err)
+		}
+		return rsp["calls"]
+	}
+
+	if n := call(metadata.Metadata{"Authorization": "Bearer a"}); n != 1 {
+		t.Fatalf("expected 1 call got %d", n)
+	}
+	if n := call(metadata.Metadata{"authorization": "Bearer a"}); n != 1 {
+		t.Fatalf("expected the response of the same caller got %d", n)
+	}
+
+	// other callers and vary metadata aren't served the response
+	if n := call(metadata.Metadata{"Authorization": "Bearer b"}); n != 2 {
+		t.Fatalf("expected a response for another caller got %d", n)
+	}
+	if n := call(metadata.Metadata{"Authorization": "Bearer a", "Tenant": "1"}); n != 3 {
+		t.Fatalf("expected a response for another tenant got %d", n)
+	}
+
+	// the request is invalidated for all callers
+	if err := c.InvalidateRequest("test.service", "Test.Method", map[string]string{"id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := call(metadata.Metadata{"Authorization": "Bearer a"}); n != 4 {
+		t.Fatalf("expected a fresh response after invalidation got %d", n)
+	}
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-plugins/wrapper/store"
+)
+
+// Store holds encoded responses by key
+type Store interface {
+	Get(key string) ([]byte, error)
+	Set(key string, b []byte, ttl time.Duration) error
+	// Delete removes all keys with the prefix
+	Delete(prefix string) error
+}
+
+// NewMemoryStore returns a store local to the process
+func NewMemoryStore() Store {
+	return store.NewMemoryStore()
+}
+
+// NewRedisStore returns a store shared across instances through redis
+func NewRedisStore(pool *redis.Pool) Store {
+	return store.NewRedisStore(pool, "micro:cache:")
+}
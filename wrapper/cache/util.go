@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"reflect"
+)
+
+// newValue returns a new zero value of the same type as v,
+// with maps initialised so they can be decoded into
+func newValue(v interface{}) interface{} {
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Ptr {
+		return reflect.New(t).Interface()
+	}
+
+	p := reflect.New(t.Elem())
+	if t.Elem().Kind() == reflect.Map {
+		p.Elem().Set(reflect.MakeMap(t.Elem()))
+	}
+	return p.Interface()
+}
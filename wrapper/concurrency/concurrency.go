@@ -0,0 +1,84 @@
+// Package concurrency provides a server wrapper which adapts the number
+// of concurrent requests to the measured latency, shedding load with a
+// retryable error rather than relying on hand tuned static limits.
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+)
+
+type limiter struct {
+	limit Limit
+
+	sync.Mutex
+	inflight int
+}
+
+func (l *limiter) acquire() (int, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	// a limit below one would block all requests
+	if limit := l.limit.Limit(); l.inflight >= limit && l.inflight >= 1 {
+		return l.inflight, false
+	}
+
+	l.inflight++
+	return l.inflight, true
+}
+
+func (l *limiter) release(inflight int, rtt time.Duration, dropped bool) {
+	l.Lock()
+	l.inflight--
+	l.Unlock()
+
+	l.limit.Update(rtt, inflight, dropped)
+}
+
+// dropped reports whether the error signals overload
+func dropped(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return true
+	}
+	if e, ok := err.(*errors.Error); ok && e.Code == 408 {
+		return true
+	}
+	return false
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which limits the number
+// of concurrent requests to the given Limit. Requests over the limit fail
+// with a 503 so clients can retry against another node.
+func NewHandlerWrapper(limit Limit) server.HandlerWrapper {
+	l := &limiter{limit: limit}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			inflight, ok := l.acquire()
+			if !ok {
+				return errors.New("go.micro.server", "concurrency limit exceeded", 503)
+			}
+
+			// released even if the handler panics, which counts as dropped
+			var err error
+			var done bool
+			start := time.Now()
+			defer func() {
+				l.release(inflight, time.Since(start), !done || dropped(ctx, err))
+			}()
+
+			err = h(ctx, req, rsp)
+			done = true
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/server"
+)
+
+func TestAIMD(t *testing.T) {
+	l := AIMD(10, 1, 20, 0.5, time.Millisecond*100)
+
+	l.Update(time.Millisecond, 10, false)
+	if v := l.Limit(); v != 11 {
+		t.Fatalf("expected limit 11 got %d", v)
+	}
+
+	// unused limit doesn't grow
+	l.Update(time.Millisecond, 1, false)
+	if v := l.Limit(); v != 11 {
+		t.Fatalf("expected limit 11 got %d", v)
+	}
+
+	l.Update(time.Second, 10, false)
+	if v := l.Limit(); v != 5 {
+		t.Fatalf("expected limit 5 got %d", v)
+	}
+
+	for i := 0; i < 10; i++ {
+		l.Update(time.Millisecond, 0, true)
+	}
+	if v := l.Limit(); v != 1 {
+		t.Fatalf("expected limit 1 got %d", v)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	l := Gradient(20, 1, 100)
+
+	// steady latency grows the limit
+	for i := 0; i < 50; i++ {
+		l.Update(time.Millisecond*10, l.Limit(), false)
+	}
+	grown := l.Limit()
+	if grown <= 20 {
+		t.Fatalf("expected limit to grow from 20 got %d", grown)
+	}
+
+	// rising latency shrinks it
+	for i := 0; i < 50; i++ {
+		l.Update(time.Millisecond*100, l.Limit(), false)
+	}
+	if v := l.Limit(); v >= grown {
+		t.Fatalf("expected limit to shrink from %d got %d", grown, v)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := &limiter{limit: AIMD(1, 1, 1, 0.5, time.Second)}
+
+	n, ok := l.acquire()
+	if !ok {
+		t.Fatal("expected request to be allowed")
+	}
+
+	if _, ok := l.acquire(); ok {
+		t.Fatal("expected request to be rejected")
+	}
+
+	l.release(n, time.Millisecond, false)
+
+	if _, ok := l.acquire(); !ok {
+		t.Fatal("expected request to be allowed after release")
+	}
+}
+
+func TestMinLimit(t *testing.T) {
+	for _, l := range []Limit{
+		AIMD(0, 0, 10, 0.5, time.Second),
+		Gradient(0, 0, 10),
+	} {
+		for i := 0; i < 10; i++ {
+			l.Update(time.Second, 0, true)
+		}
+		if v := l.Limit(); v != 1 {
+			t.Fatalf("expected limit 1 got %d", v)
+		}
+	}
+}
+
+func TestPanicRelease(t *testing.T) {
+	l := AIMD(1, 1, 1, 0.5, time.Second)
+
+	h := NewHandlerWrapper(l)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		panic("failed")
+	})
+
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("expected handler to panic")
+				}
+			}()
+			h(context.Background(), nil, nil)
+		}()
+	}
+}
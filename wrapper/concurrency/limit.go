@@ -0,0 +1,140 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit computes the concurrency limit from observed latencies
+type Limit interface {
+	// Limit returns the current limit
+	Limit() int
+	// Update records a sample. Dropped is true when the request
+	// failed in a way that signals overload, e.g. a timeout.
+	Update(rtt time.Duration, inflight int, dropped bool)
+}
+
+type aimd struct {
+	sync.Mutex
+	limit    float64
+	min, max float64
+	backoff  float64
+	timeout  time.Duration
+}
+
+func (a *aimd) Limit() int {
+	a.Lock()
+	defer a.Unlock()
+	return int(a.limit)
+}
+
+// bounds clamps the min and max to at least one so the limit never
+// drops to zero, which would block all traffic
+func bounds(min, max int) (float64, float64) {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return float64(min), float64(max)
+}
+
+func (a *aimd) Update(rtt time.Duration, inflight int, dropped bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	switch {
+	case dropped || rtt > a.timeout:
+		a.limit = a.limit * a.backoff
+	case float64(inflight)*2 >= a.limit:
+		// only grow when the limit is being used
+		a.limit++
+	}
+
+	a.limit = math.Min(a.max, math.Max(a.min, a.limit))
+}
+
+// AIMD returns an additive increase, multiplicative decrease limit.
+// The limit grows by one per sample and is multiplied by backoff when
+// a request is dropped or takes longer than timeout.
+func AIMD(initial, min, max int, backoff float64, timeout time.Duration) Limit {
+	lo, hi := bounds(min, max)
+	return &aimd{
+		limit:   math.Min(hi, math.Max(lo, float64(initial))),
+		min:     lo,
+		max:     hi,
+		backoff: backoff,
+		timeout: timeout,
+	}
+}
+
+type gradient struct {
+	sync.Mutex
+	limit    float64
+	min, max float64
+	// smoothing applied to limit changes
+	smoothing float64
+	// tolerance of short rtt over long rtt before backing off
+	tolerance float64
+
+	// long term and short term exponential averages of the rtt
+	long, short float64
+	longDecay   float64
+	shortDecay  float64
+}
+
+func (g *gradient) Limit() int {
+	g.Lock()
+	defer g.Unlock()
+	return int(g.limit)
+}
+
+func (g *gradient) Update(rtt time.Duration, inflight int, dropped bool) {
+	g.Lock()
+	defer g.Unlock()
+
+	sample := float64(rtt)
+
+	if g.long == 0 {
+		g.long = sample
+		g.short = sample
+	} else {
+		g.long = g.long*(1-g.longDecay) + sample*g.longDecay
+		g.short = g.short*(1-g.shortDecay) + sample*g.shortDecay
+	}
+
+	// don't grow the limit if it's not being used
+	if float64(inflight)*2 < g.limit && !dropped {
+		return
+	}
+
+	// the gradient is below 1 when latency is rising
+	grad := math.Max(0.5, math.Min(1.0, g.tolerance*g.long/g.short))
+	if dropped {
+		grad = 0.5
+	}
+
+	// allow a queue of sqrt(limit) to probe for more capacity
+	next := g.limit*grad + math.Sqrt(g.limit)
+	next = g.limit*(1-g.smoothing) + next*g.smoothing
+
+	g.limit = math.Min(g.max, math.Max(g.min, next))
+}
+
+// Gradient returns a limit which compares the short term average
+// latency against the long term average and shrinks the limit as
+// the short term latency rises, in the style of Netflix's Gradient2.
+func Gradient(initial, min, max int) Limit {
+	lo, hi := bounds(min, max)
+	return &gradient{
+		limit:      math.Min(hi, math.Max(lo, float64(initial))),
+		min:        lo,
+		max:        hi,
+		smoothing:  0.2,
+		tolerance:  1.5,
+		longDecay:  2.0 / (600 + 1),
+		shortDecay: 2.0 / (10 + 1),
+	}
+}
@@ -0,0 +1,219 @@
+package endpoint
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/juju/ratelimit"
+	"github.com/micro/go-config"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+)
+
+var (
+	// DefaultPath of the endpoint settings in the config
+	DefaultPath = []string{"endpoints"}
+)
+
+type featuresKey struct{}
+
+// endpoint is the parsed settings of an endpoint
+type endpoint struct {
+	settings *Settings
+	timeout  time.Duration
+	bucket   *ratelimit.Bucket
+}
+
+type settings struct {
+	sync.RWMutex
+	endpoints map[string]*endpoint
+}
+
+type configWrapper struct {
+	s *settings
+	client.Client
+}
+
+func (s *settings) update(raw map[string]*Settings) {
+	s.RLock()
+	old := s.endpoints
+	s.RUnlock()
+
+	endpoints := make(map[string]*endpoint, len(raw))
+
+	for name, st := range raw {
+		if st == nil {
+			continue
+		}
+
+		e := &endpoint{settings: st}
+
+		if len(st.Timeout) > 0 {
+			d, err := time.ParseDuration(st.Timeout)
+			if err != nil {
+				log.Logf("[endpoint] invalid timeout for %s: %v", name, err)
+			}
+			e.timeout = d
+		}
+
+		if st.Rate > 0 {
+			burst := st.Burst
+			if burst <= 0 {
+				burst = 1
+			}
+			// keep the bucket when unchanged so updates don't refill it
+			if o, ok := old[name]; ok && o.bucket != nil && o.settings.Rate == st.Rate && o.bucket.Capacity() == burst {
+				e.bucket = o.bucket
+			} else {
+				e.bucket = ratelimit.NewBucketWithRate(st.Rate, burst)
+			}
+		}
+
+		endpoints[name] = e
+	}
+
+	s.Lock()
+	s.endpoints = endpoints
+	s.Unlock()
+}
+
+func (s *settings) run(conf config.Config, path []string) {
+	w, err := conf.Watch(path...)
+	if err != nil {
+		log.Logf("[endpoint] failed to watch settings: %v", err)
+		return
+	}
+
+	for {
+		v, err := w.Next()
+		if err != nil {
+			log.Logf("[endpoint] watcher error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var raw map[string]*Settings
+		if err := v.Scan(&raw); err != nil {
+			log.Logf("[endpoint] failed to scan settings, skipping update: %v", err)
+			continue
+		}
+
+		s.update(raw)
+	}
+}
+
+// get returns the settings of the endpoint, most specific first
+func (s *settings) get(service, method string) *endpoint {
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, k := range []string{service + "/" + method, method, "*"} {
+		if e, ok := s.endpoints[k]; ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// allow takes a token from the endpoint's rate limit
+func (e *endpoint) allow(id string) error {
+	if e.bucket != nil && e.bucket.TakeAvailable(1) == 0 {
+		return errors.New(id, "too many requests", 429)
+	}
+	return nil
+}
+
+func newSettings(opts ...Option) *settings {
+	options := newOptions(opts...)
+
+	s := &settings{}
+
+	var raw map[string]*Settings
+	if options.Config != nil {
+		if err := options.Config.Get(options.Path...).Scan(&raw); err != nil {
+			log.Logf("[endpoint] failed to load settings: %v", err)
+		}
+		go s.run(options.Config, options.Path)
+	}
+
+	s.update(raw)
+
+	return s
+}
+
+func (c *configWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	e := c.s.get(req.Service(), req.Method())
+	if e == nil {
+		return c.Client.Call(ctx, req, rsp, opts...)
+	}
+
+	if err := e.allow("go.micro.client"); err != nil {
+		return err
+	}
+
+	// the settings override the options of the caller
+	if e.timeout > 0 {
+		opts = append(opts, client.WithRequestTimeout(e.timeout))
+	}
+	if e.settings.Retries != nil {
+		opts = append(opts, client.WithRetries(*e.settings.Retries))
+	}
+
+	return c.Client.Call(ctx, req, rsp, opts...)
+}
+
+// Enabled returns whether the feature is toggled on
+// for the endpoint of the request
+func Enabled(ctx context.Context, feature string) bool {
+	features, ok := ctx.Value(featuresKey{}).(map[string]bool)
+	if !ok {
+		return false
+	}
+	return features[feature]
+}
+
+// NewConfigClientWrapper returns a client Wrapper applying the timeout,
+// retries and rate limit of each endpoint from config. Changes to the
+// config are applied without a restart.
+func NewConfigClientWrapper(opts ...Option) client.Wrapper {
+	s := newSettings(opts...)
+
+	return func(c client.Client) client.Client {
+		return &configWrapper{s, c}
+	}
+}
+
+// NewConfigHandlerWrapper returns a server HandlerWrapper applying the
+// timeout, rate limit and feature toggles of each endpoint from config.
+// Changes to the config are applied without a restart.
+func NewConfigHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	s := newSettings(opts...)
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			e := s.get(req.Service(), req.Method())
+			if e == nil {
+				return h(ctx, req, rsp)
+			}
+
+			if err := e.allow("go.micro.server"); err != nil {
+				return err
+			}
+
+			if e.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, e.timeout)
+				defer cancel()
+			}
+
+			if len(e.settings.Features) > 0 {
+				ctx = context.WithValue(ctx, featuresKey{}, e.settings.Features)
+			}
+
+			return h(ctx, req, rsp)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package endpoint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-config"
+	"github.com/micro/go-config/source/memory"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+)
+
+type testClient struct {
+	client.Client
+	opts client.CallOptions
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	t.opts = client.CallOptions{}
+	for _, o := range opts {
+		o(&t.opts)
+	}
+	return nil
+}
+
+type testRequest struct {
+	client.Request
+	service string
+	method  string
+}
+
+type testServerRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return t.service
+}
+
+func (t *testRequest) Method() string {
+	return t.method
+}
+
+func (t *testServerRequest) Service() string {
+	return "go.micro.srv.foo"
+}
+
+func (t *testServerRequest) Method() string {
+	return "Foo.Bar"
+}
+
+func testConfig(data string) config.Config {
+	return config.NewConfig(config.WithSource(memory.NewSource(memory.WithData([]byte(data)))))
+}
+
+func TestConfigClientWrapper(t *testing.T) {
+	c := testConfig(`{"endpoints": {
+		"Foo.Bar": {"timeout": "2s", "retries": 3},
+		"go.micro.srv.bar/Foo.Bar": {"timeout": "5s", "retries": 0},
+		"Foo.Baz": {"rate": 1, "burst": 1}
+	}}`)
+
+	tc := &testClient{}
+	cl := NewConfigClientWrapper(Config(c))(tc)
+
+	if err := cl.Call(context.TODO(), &testRequest{service: "go.micro.srv.foo", method: "Foo.Bar"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tc.opts.RequestTimeout != 2*time.Second || tc.opts.Retries != 3 {
+		t.Fatalf("unexpected call options %+v", tc.opts)
+	}
+
+	if err := cl.Call(context.TODO(), &testRequest{service: "go.micro.srv.bar", method: "Foo.Bar"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tc.opts.RequestTimeout != 5*time.Second || tc.opts.Retries != 0 {
+		t.Fatalf("expected service settings got %+v", tc.opts)
+	}
+
+	req := &testRequest{service: "go.micro.srv.foo", method: "Foo.Baz"}
+	if err := cl.Call(context.TODO(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+	err := cl.Call(context.TODO(), req, nil)
+	if merr, ok := err.(*errors.Error); !ok || merr.Code != 429 {
+		t.Fatalf("expected rate limit error got %v", err)
+	}
+}
+
+func TestConfigHandlerWrapper(t *testing.T) {
+	c := testConfig(`{"endpoints": {
+		"*": {"timeout": "1s", "features": {"fast": true}}
+	}}`)
+
+	var enabled bool
+	var deadline bool
+
+	fn := NewConfigHandlerWrapper(Config(c))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		enabled = Enabled(ctx, "fast")
+		_, deadline = ctx.Deadline()
+		return nil
+	})
+
+	if err := fn(context.TODO(), &testServerRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Fatal("expected feature to be enabled")
+	}
+	if !deadline {
+		t.Fatal("expected timeout to set a deadline")
+	}
+}
@@ -1,4 +1,5 @@
-// Package endpoint provides a wrapper that executes other wrappers for specific methods
+// Package endpoint provides wrappers that execute other wrappers for specific
+// methods or apply per endpoint settings loaded from config
 package endpoint
 
 import (
@@ -0,0 +1,57 @@
+package endpoint
+
+import (
+	"github.com/micro/go-config"
+)
+
+// Settings of an endpoint loaded from config, unset values
+// leave the behaviour of the endpoint unchanged
+type Settings struct {
+	// Timeout of requests, such as "2s"
+	Timeout string `json:"timeout"`
+	// Retries of calls, applied by the client wrapper
+	Retries *int `json:"retries"`
+	// Rate of requests allowed per second, unlimited if zero
+	Rate float64 `json:"rate"`
+	// Burst of requests allowed over the rate, defaults to one
+	Burst int64 `json:"burst"`
+	// Features toggled on or off, checked with Enabled
+	Features map[string]bool `json:"features"`
+}
+
+// Options for the config wrappers
+type Options struct {
+	// Config the settings are loaded from and watched
+	Config config.Config
+	// Path of the settings within the config, keyed by endpoint,
+	// by service and endpoint as "service/Endpoint" or "*" for all
+	Path []string
+}
+
+type Option func(*Options)
+
+// Config sets the config the settings are loaded from
+func Config(c config.Config) Option {
+	return func(o *Options) {
+		o.Config = c
+	}
+}
+
+// Path sets the path of the settings within the config
+func Path(path ...string) Option {
+	return func(o *Options) {
+		o.Path = path
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Path: DefaultPath,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
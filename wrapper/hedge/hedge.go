@@ -0,0 +1,257 @@
+// Package hedge provides a client wrapper which hedges requests to reduce
+// tail latency. If the first attempt hasn't responded within the recent
+// percentile latency a second attempt is sent to a different node and
+// whichever responds first wins, cancelling the other. Only calls with a
+// pointer response are hedged, each attempt decoding into its own value.
+package hedge
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+// Options for the hedging wrapper
+type Options struct {
+	// Endpoints to hedge, as service.Endpoint. Only endpoints which are
+	// safe to call twice should be listed.
+	Endpoints []string
+	// Percentile of recent latency after which to hedge
+	Percentile float64
+	// Delay used until enough latencies have been recorded
+	Delay time.Duration
+	// MinSamples required before the percentile is used
+	MinSamples int
+	// IdempotencyKey only hedges requests carrying the metadata key
+	IdempotencyKey string
+}
+
+type Option func(*Options)
+
+// Endpoints opts the endpoints in to hedging
+func Endpoints(e ...string) Option {
+	return func(o *Options) {
+		o.Endpoints = append(o.Endpoints, e...)
+	}
+}
+
+// Percentile sets the latency percentile, e.g. 0.95, to hedge after
+func Percentile(p float64) Option {
+	return func(o *Options) {
+		o.Percentile = p
+	}
+}
+
+// Delay sets the hedge delay used before the percentile is known
+func Delay(d time.Duration) Option {
+	return func(o *Options) {
+		o.Delay = d
+	}
+}
+
+// RequireIdempotencyKey only hedges requests with the metadata key set
+func RequireIdempotencyKey(key string) Option {
+	return func(o *Options) {
+		o.IdempotencyKey = key
+	}
+}
+
+type hedgeWrapper struct {
+	opts      Options
+	endpoints map[string]bool
+
+	sync.Mutex
+	windows map[string]*window
+	client.Client
+}
+
+type result struct {
+	rsp interface{}
+	err error
+}
+
+func (h *hedgeWrapper) window(name string) *window {
+	h.Lock()
+	defer h.Unlock()
+
+	w, ok := h.windows[name]
+	if !ok {
+		w = newWindow(100)
+		h.windows[name] = w
+	}
+	return w
+}
+
+func (h *hedgeWrapper) delay(w *window) time.Duration {
+	if d, ok := w.percentile(h.opts.Percentile, h.opts.MinSamples); ok {
+		return d
+	}
+	return h.opts.Delay
+}
+
+// safe checks the request is allowed to be sent twice
+func (h *hedgeWrapper) safe(ctx context.Context, req client.Request) bool {
+	if req.Stream() || !h.endpoints[req.Service()+"."+req.Method()] {
+		return false
+	}
+	if len(h.opts.IdempotencyKey) == 0 {
+		return true
+	}
+	md, _ := metadata.FromContext(ctx)
+	for k, v := range md {
+		if strings.EqualFold(k, h.opts.IdempotencyKey) && len(v) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// exclude filters the node with the address out of the selection
+func exclude(addr string) selector.SelectOption {
+	return selector.WithFilter(func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+		for _, s := range services {
+			var nodes []*registry.Node
+			for _, n := range s.Nodes {
+				if n.Address != addr && !strings.HasPrefix(addr, n.Address+":") {
+					nodes = append(nodes, n)
+				}
+			}
+			if len(nodes) == 0 {
+				continue
+			}
+			ns := *s
+			ns.Nodes = nodes
+			filtered = append(filtered, &ns)
+		}
+		// nowhere else to go, allow the same node
+		if len(filtered) == 0 {
+			return services
+		}
+		return filtered
+	})
+}
+
+// pointer checks the response is a pointer which can be allocated
+// per attempt, otherwise both attempts would write to the same value
+func pointer(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	return t != nil && t.Kind() == reflect.Ptr && !reflect.ValueOf(v).IsNil()
+}
+
+// newValue allocates a response of the same type as the pointer
+func newValue(v interface{}) interface{} {
+	t := reflect.TypeOf(v)
+	p := reflect.New(t.Elem())
+	if t.Elem().Kind() == reflect.Map {
+		p.Elem().Set(reflect.MakeMap(t.Elem()))
+	}
+	return p.Interface()
+}
+
+func assign(dst, src interface{}) {
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
+
+func (h *hedgeWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	if !h.safe(ctx, req) || !pointer(rsp) {
+		return h.Client.Call(ctx, req, rsp, opts...)
+	}
+
+	w := h.window(req.Service() + "." + req.Method())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan result, 2)
+	addr := make(chan string, 1)
+
+	// record the node used by the first attempt
+	record := func(cf client.CallFunc) client.CallFunc {
+		return func(ctx context.Context, a string, req client.Request, rsp interface{}, opts client.CallOptions) error {
+			select {
+			case addr <- a:
+			default:
+			}
+			return cf(ctx, a, req, rsp, opts)
+		}
+	}
+
+	start := time.Now()
+
+	call := func(copts ...client.CallOption) {
+		r := newValue(rsp)
+		err := h.Client.Call(ctx, req, r, append(opts, copts...)...)
+		ch <- result{r, err}
+	}
+
+	go call(client.WithCallWrapper(record))
+
+	timer := time.NewTimer(h.delay(w))
+	defer timer.Stop()
+
+	var res result
+	pending := 1
+
+	select {
+	case res = <-ch:
+		pending--
+	case <-timer.C:
+		var hopts []client.CallOption
+		select {
+		case a := <-addr:
+			hopts = append(hopts, client.WithSelectOption(exclude(a)))
+		default:
+		}
+		go call(hopts...)
+		pending++
+		res = <-ch
+		pending--
+	}
+
+	// use the other attempt if the first to respond failed
+	if res.err != nil && pending > 0 {
+		res = <-ch
+	}
+
+	if res.err == nil {
+		w.add(time.Since(start))
+		assign(rsp, res.rsp)
+	}
+
+	return res.err
+}
+
+// NewClientWrapper returns a client Wrapper which hedges calls to the endpoints
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := Options{
+		Percentile: 0.95,
+		Delay:      time.Millisecond * 100,
+		MinSamples: 20,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	endpoints := make(map[string]bool)
+	for _, e := range options.Endpoints {
+		endpoints[e] = true
+	}
+
+	return func(c client.Client) client.Client {
+		return &hedgeWrapper{
+			opts:      options,
+			endpoints: endpoints,
+			windows:   make(map[string]*window),
+			Client:    c,
+		}
+	}
+}
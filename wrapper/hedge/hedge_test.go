@@ -0,0 +1,125 @@
+package hedge
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/client"
+)
+
+type testRequest struct {
+	client.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func (t *testRequest) Stream() bool {
+	return false
+}
+
+type testClient struct {
+	client.Client
+
+	sync.Mutex
+	calls int
+}
+
+// the first call is slow, the second fast
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	t.Lock()
+	t.calls++
+	n := t.calls
+	t.Unlock()
+
+	if n == 1 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	(*rsp.(*map[string]int))["attempt"] = n
+	return nil
+}
+
+func TestHedge(t *testing.T) {
+	tc := &testClient{}
+	c := NewClientWrapper(
+		Endpoints("test.service.Test.Method"),
+		Delay(time.Millisecond*10),
+	)(tc)
+
+	rsp := map[string]int{}
+
+	start := time.Now()
+	if err := c.Call(context.TODO(), &testRequest{}, &rsp); err != nil {
+		t.Fatal(err)
+	}
+
+	if rsp["attempt"] != 2 {
+		t.Fatalf("expected hedged attempt to win got %v", rsp)
+	}
+	if time.Since(start) > time.Millisecond*500 {
+		t.Fatal("expected hedged call to return early")
+	}
+}
+
+type slowClient struct {
+	client.Client
+
+	sync.Mutex
+	calls int
+}
+
+func (s *slowClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	s.Lock()
+	s.calls++
+	s.Unlock()
+
+	time.Sleep(time.Millisecond * 50)
+	rsp.(map[string]int)["attempt"] = 1
+	return nil
+}
+
+func TestNotPointer(t *testing.T) {
+	sc := &slowClient{}
+	c := NewClientWrapper(
+		Endpoints("test.service.Test.Method"),
+		Delay(time.Millisecond),
+	)(sc)
+
+	// attempts can't write to separate responses so aren't hedged
+	rsp := map[string]int{}
+	if err := c.Call(context.TODO(), &testRequest{}, rsp); err != nil {
+		t.Fatal(err)
+	}
+
+	if sc.calls != 1 || rsp["attempt"] != 1 {
+		t.Fatalf("expected 1 call got %d with %v", sc.calls, rsp)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	w := newWindow(10)
+
+	if _, ok := w.percentile(0.9, 5); ok {
+		t.Fatal("expected too few samples")
+	}
+
+	for i := 1; i <= 10; i++ {
+		w.add(time.Duration(i) * time.Millisecond)
+	}
+
+	if d, _ := w.percentile(0.9, 5); d != 9*time.Millisecond {
+		t.Fatalf("expected 9ms got %v", d)
+	}
+}
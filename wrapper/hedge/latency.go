@@ -0,0 +1,51 @@
+package hedge
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// window keeps the most recent latencies for an endpoint
+type window struct {
+	sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func newWindow(size int) *window {
+	return &window{samples: make([]time.Duration, size)}
+}
+
+func (w *window) add(d time.Duration) {
+	w.Lock()
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+	w.Unlock()
+}
+
+// percentile returns the latency at p in the range [0, 1] and
+// false if there aren't yet min samples
+func (w *window) percentile(p float64, min int) (time.Duration, bool) {
+	w.Lock()
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	if n < min || n == 0 {
+		w.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, w.samples[:n])
+	w.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(float64(n-1) * p)
+	return sorted[i], true
+}
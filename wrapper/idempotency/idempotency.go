@@ -0,0 +1,246 @@
+// Package idempotency provides a server wrapper which caches responses by
+// idempotency key so that retried requests don't repeat side effects.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+const (
+	// DefaultHeader is the metadata key holding the idempotency key
+	DefaultHeader = "Idempotency-Key"
+)
+
+var (
+	// DefaultLock is how long a key is reserved while the handler runs
+	DefaultLock = time.Minute
+	// pollInterval is how often a duplicate checks for the response
+	pollInterval = 50 * time.Millisecond
+)
+
+// stored values are prefixed with their state
+const (
+	pending  = 'p'
+	complete = 'c'
+)
+
+// Options for the idempotency wrapper
+type Options struct {
+	// Store for responses, defaults to memory
+	Store Store
+	// TTL responses are kept for
+	TTL time.Duration
+	// Header the idempotency key is read from
+	Header string
+	// Lock is how long a key is reserved while the handler runs,
+	// so a crashed instance doesn't hold it forever
+	Lock time.Duration
+	// Wait is how long a duplicate of an in flight request waits for
+	// its response before it's rejected with a conflict
+	Wait time.Duration
+	// Identity returns the caller identity keys are scoped to
+	Identity func(ctx context.Context) string
+}
+
+type Option func(*Options)
+
+// WithStore sets the response store
+func WithStore(s Store) Option {
+	return func(o *Options) {
+		o.Store = s
+	}
+}
+
+// TTL sets how long responses are kept
+func TTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = d
+	}
+}
+
+// Header sets the metadata key the idempotency key is read from
+func Header(h string) Option {
+	return func(o *Options) {
+		o.Header = h
+	}
+}
+
+// Lock sets how long a key is reserved while the handler runs
+func Lock(d time.Duration) Option {
+	return func(o *Options) {
+		o.Lock = d
+	}
+}
+
+// Wait sets how long duplicates of an in flight request wait for its
+// response. By default they're rejected immediately with a conflict.
+func Wait(d time.Duration) Option {
+	return func(o *Options) {
+		o.Wait = d
+	}
+}
+
+// Identity sets the function returning the caller identity keys are
+// scoped to, such as the subject of a verified token
+func Identity(fn func(ctx context.Context) string) Option {
+	return func(o *Options) {
+		o.Identity = fn
+	}
+}
+
+func header(ctx context.Context, key string) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v, ok := md[key]; ok {
+		return v
+	}
+	for k, v := range md {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// authorization identifies the caller by a hash of its authorization
+// so one caller can't replay the response of another
+func authorization(ctx context.Context) string {
+	auth := header(ctx, "Authorization")
+	if len(auth) == 0 {
+		return ""
+	}
+	h := sha256.Sum256([]byte(auth))
+	return hex.EncodeToString(h[:])
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Marshal(pb)
+	}
+	return json.Marshal(v)
+}
+
+func unmarshal(b []byte, v interface{}) error {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(b, pb)
+	}
+	return json.Unmarshal(b, v)
+}
+
+// reserve returns the cached response for the key or reserves the key
+// for the caller to run the handler, waiting for in flight duplicates
+func reserve(key string, opts Options) ([]byte, bool, error) {
+	deadline := time.Now().Add(opts.Wait)
+
+	for {
+		b, err := opts.Store.Get(key)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if len(b) == 0 {
+			ok, err := opts.Store.Add(key, []byte{pending}, opts.Lock)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				return nil, true, nil
+			}
+			// reserved meanwhile
+			continue
+		}
+
+		if b[0] == complete {
+			return b[1:], false, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, false, errors.Conflict("go.micro.server", "a request with the same idempotency key is in progress")
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which returns the cached
+// response for requests carrying an idempotency key that's been seen before.
+// The key is reserved while the handler runs so concurrent duplicates wait
+// for the response or are rejected. Only successful responses are cached so
+// failed requests can be retried. Keys are scoped to the caller identity.
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := Options{
+		TTL:      time.Hour * 24,
+		Header:   DefaultHeader,
+		Lock:     DefaultLock,
+		Identity: authorization,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Store == nil {
+		options.Store = NewMemoryStore()
+	}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			id := header(ctx, options.Header)
+			if len(id) == 0 {
+				return h(ctx, req, rsp)
+			}
+
+			key := req.Service() + ":" + req.Method() + ":" + options.Identity(ctx) + ":" + id
+
+			b, reserved, err := reserve(key, options)
+			if merr, ok := err.(*errors.Error); ok {
+				return merr
+			} else if err != nil {
+				// run unguarded rather than fail when the store is down
+				log.Logf("idempotency: failed to reserve %s: %v", key, err)
+			} else if !reserved {
+				if err := unmarshal(b, rsp); err == nil {
+					return nil
+				}
+				log.Logf("idempotency: failed to decode response for %s", key)
+			}
+
+			if err := h(ctx, req, rsp); err != nil {
+				// release the key so the request can be retried
+				if reserved {
+					if rerr := options.Store.Remove(key); rerr != nil {
+						log.Logf("idempotency: failed to release %s: %v", key, rerr)
+					}
+				}
+				return err
+			}
+
+			b, err = marshal(rsp)
+			if err != nil {
+				log.Logf("idempotency: failed to encode response for %s: %v", key, err)
+				if reserved {
+					options.Store.Remove(key)
+				}
+				return nil
+			}
+
+			if err := options.Store.Set(key, append([]byte{complete}, b...), options.TTL); err != nil {
+				log.Logf("idempotency: failed to set %s: %v", key, err)
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+type testResponse struct {
+	Count int
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	var calls int
+
+	h := func(ctx context.Context, req server.Request, rsp interface{}) error {
+		calls++
+		rsp.(*testResponse).Count = calls
+		return nil
+	}
+
+	fn := NewHandlerWrapper()(h)
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		DefaultHeader: "abc",
+	})
+
+	for i := 0; i < 3; i++ {
+		rsp := new(testResponse)
+		if err := fn(ctx, &testRequest{}, rsp); err != nil {
+			t.Fatal(err)
+		}
+		if rsp.Count != 1 {
+			t.Fatalf("expected cached response 1 got %d", rsp.Count)
+		}
+	}
+
+	// requests without a key aren't cached
+	rsp := new(testResponse)
+	if err := fn(context.TODO(), &testRequest{}, rsp); err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Count != 2 {
+		t.Fatalf("expected response 2 got %d", rsp.Count)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	s := NewMemoryStore()
+	s.Set("foo", []byte("bar"), time.Millisecond)
+
+	time.Sleep(time.Millisecond * 5)
+
+	b, err := s.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != nil {
+		t.Fatalf("expected expired entry got %s", b)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	started := make(chan bool)
+	release := make(chan bool)
+	var calls int
+
+	h := func(ctx context.Context, req server.Request, rsp interface{}) error {
+		calls++
+		started <- true
+		<-release
+		rsp.(*testResponse).Count = calls
+		return nil
+	}
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		DefaultHeader: "abc",
+	})
+
+	for _, wait := range []time.Duration{0, time.Minute} {
+		calls = 0
+		fn := NewHandlerWrapper(Wait(wait))(h)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- fn(ctx, &testRequest{}, new(testResponse))
+		}()
+		<-started
+
+		if wait == 0 {
+			// duplicates are rejected while the request is in flight
+			err := fn(ctx, &testRequest{}, new(testResponse))
+			if merr, ok := err.(*errors.Error); !ok || merr.Code != 409 {
+				t.Fatalf("expected conflict got %v", err)
+			}
+			close(release)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			release = make(chan bool)
+			continue
+		}
+
+		// or wait for the response
+		dup := make(chan *testResponse, 1)
+		go func() {
+			rsp := new(testResponse)
+			if err := fn(ctx, &testRequest{}, rsp); err != nil {
+				t.Error(err)
+			}
+			dup <- rsp
+		}()
+
+		time.Sleep(pollInterval * 2)
+		close(release)
+
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		if rsp := <-dup; rsp.Count != 1 {
+			t.Fatalf("expected the duplicate to get response 1 got %d", rsp.Count)
+		}
+		if calls != 1 {
+			t.Fatalf("expected the handler to run once got %d", calls)
+		}
+	}
+}
+
+func TestRelease(t *testing.T) {
+	var calls int
+
+	fn := NewHandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	})
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		DefaultHeader: "abc",
+	})
+
+	if err := fn(ctx, &testRequest{}, new(testResponse)); err == nil {
+		t.Fatal("expected the first request to fail")
+	}
+
+	// failures release the key so the retry runs the handler
+	if err := fn(ctx, &testRequest{}, new(testResponse)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the handler to run twice got %d", calls)
+	}
+}
+
+func TestCallerScope(t *testing.T) {
+	var calls int
+
+	fn := NewHandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		calls++
+		rsp.(*testResponse).Count = calls
+		return nil
+	})
+
+	for i, auth := range []string{"Bearer a", "Bearer b", "Bearer a"} {
+		ctx := metadata.NewContext(context.TODO(), map[string]string{
+			DefaultHeader:   "abc",
+			"Authorization": auth,
+		})
+
+		rsp := new(testResponse)
+		if err := fn(ctx, &testRequest{}, rsp); err != nil {
+			t.Fatal(err)
+		}
+
+		// another caller's response isn't replayed
+		expected := []int{1, 2, 1}[i]
+		if rsp.Count != expected {
+			t.Fatalf("expected response %d for %s got %d", expected, auth, rsp.Count)
+		}
+	}
+}
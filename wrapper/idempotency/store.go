@@ -0,0 +1,31 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-plugins/wrapper/store"
+)
+
+// Store holds encoded responses by idempotency key
+type Store interface {
+	// Get returns the response for the key, nil if not found
+	Get(key string) ([]byte, error)
+	// Set stores the response for the key for the ttl
+	Set(key string, b []byte, ttl time.Duration) error
+	// Add stores the value for the key for the ttl only if the
+	// key isn't set, returning false if it is
+	Add(key string, b []byte, ttl time.Duration) (bool, error)
+	// Remove removes the key
+	Remove(key string) error
+}
+
+// NewMemoryStore returns an in-memory store local to the process
+func NewMemoryStore() Store {
+	return store.NewMemoryStore()
+}
+
+// NewRedisStore returns a store shared across instances through redis
+func NewRedisStore(pool *redis.Pool) Store {
+	return store.NewRedisStore(pool, "micro:idempotency:")
+}
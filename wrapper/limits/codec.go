@@ -0,0 +1,89 @@
+package limits
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/micro/go-micro/codec"
+	"github.com/micro/go-micro/errors"
+)
+
+// reader fails reads past the maximum size so oversized
+// bodies are rejected before they're decoded
+type reader struct {
+	io.ReadWriteCloser
+	max  int
+	read int
+}
+
+type limitsCodec struct {
+	codec.Codec
+	r    *reader
+	opts Options
+}
+
+func (r *reader) exceeded() bool {
+	return r.max > 0 && r.read > r.max
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.max > 0 && len(p) > r.max-r.read+1 {
+		// read one byte past the limit to detect it
+		p = p[:r.max-r.read+1]
+	}
+	n, err := r.ReadWriteCloser.Read(p)
+	r.read += n
+	if r.exceeded() {
+		return n, errTooLarge(r.max)
+	}
+	return n, err
+}
+
+func errTooLarge(max int) error {
+	return errors.New("go.micro.server", fmt.Sprintf("request exceeds maximum size of %d bytes", max), 413)
+}
+
+func (c *limitsCodec) ReadHeader(m *codec.Message, mt codec.MessageType) error {
+	if err := c.Codec.ReadHeader(m, mt); err != nil {
+		return err
+	}
+	// the endpoint is known once the header is read
+	c.r.max = c.opts.limits(m.Method).Size
+	if c.r.exceeded() {
+		return errTooLarge(c.r.max)
+	}
+	return nil
+}
+
+func (c *limitsCodec) ReadBody(b interface{}) error {
+	if c.r.exceeded() {
+		return errTooLarge(c.r.max)
+	}
+	return c.Codec.ReadBody(b)
+}
+
+func (c *limitsCodec) String() string {
+	return "limits-" + c.Codec.String()
+}
+
+// NewCodec wraps a server codec so requests larger than the size limit
+// of their endpoint are rejected as the raw body is read, before it's
+// decoded. The depth and element limits need the decoded request so
+// they're checked by the handler wrapper.
+func NewCodec(c codec.NewCodec, opts ...Option) codec.NewCodec {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return func(rwc io.ReadWriteCloser) codec.Codec {
+		// the endpoint isn't known until the header is read
+		r := &reader{ReadWriteCloser: rwc, max: options.maxSize()}
+
+		return &limitsCodec{
+			Codec: c(r),
+			r:     r,
+			opts:  options,
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package limits provides a server wrapper which enforces maximum request
+// sizes, nesting depth and element counts, defending against decompression
+// bombs and abusive clients. Violations fail with a 413 which the grpc
+// server maps to ResourceExhausted. Wrap the server codecs with NewCodec
+// to reject oversized requests before they're decoded.
+package limits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+)
+
+// Limits for a request. Zero values are unlimited.
+type Limits struct {
+	// Size is the maximum encoded size in bytes
+	Size int
+	// Depth is the maximum nesting of messages, maps and slices
+	Depth int
+	// Elements is the maximum number of map and slice elements in total
+	Elements int
+}
+
+// Options for the limits wrapper
+type Options struct {
+	// Default limits applied to all endpoints
+	Default Limits
+	// Endpoints overrides the limits by endpoint e.g. Greeter.Hello
+	Endpoints map[string]Limits
+}
+
+type Option func(*Options)
+
+// Default sets the limits applied to all endpoints
+func Default(l Limits) Option {
+	return func(o *Options) {
+		o.Default = l
+	}
+}
+
+// Endpoint overrides the limits for an endpoint
+func Endpoint(name string, l Limits) Option {
+	return func(o *Options) {
+		if o.Endpoints == nil {
+			o.Endpoints = make(map[string]Limits)
+		}
+		o.Endpoints[name] = l
+	}
+}
+
+// limits returns the limits of the endpoint
+func (o Options) limits(endpoint string) Limits {
+	if l, ok := o.Endpoints[endpoint]; ok {
+		return l
+	}
+	return o.Default
+}
+
+// maxSize returns the largest size limit of any endpoint or zero if
+// any endpoint is unlimited
+func (o Options) maxSize() int {
+	max := o.Default.Size
+	for _, l := range o.Endpoints {
+		if max == 0 || l.Size == 0 {
+			return 0
+		}
+		if l.Size > max {
+			max = l.Size
+		}
+	}
+	return max
+}
+
+func size(v interface{}) (int, error) {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Size(pb), nil
+	}
+	if b, ok := v.([]byte); ok {
+		return len(b), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+type walker struct {
+	limits   Limits
+	elements int
+	// values already walked so cyclic values terminate
+	visited map[visit]bool
+}
+
+type visit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// seen returns true if the pointer, map or slice was already walked
+func (w *walker) seen(v reflect.Value) bool {
+	if v.Pointer() == 0 {
+		return false
+	}
+	k := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		k.len = v.Len()
+	}
+	if w.visited[k] {
+		return true
+	}
+	w.visited[k] = true
+	return false
+}
+
+// walk visits the value counting depth and elements, bailing as
+// soon as a limit is exceeded rather than walking the whole tree
+func (w *walker) walk(v reflect.Value, depth int) error {
+	if w.limits.Depth > 0 && depth > w.limits.Depth {
+		return fmt.Errorf("exceeds maximum depth of %d", w.limits.Depth)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || w.seen(v) {
+			return nil
+		}
+		return w.walk(v.Elem(), depth)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return w.walk(v.Elem(), depth)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// skip unexported fields such as proto internals
+			if len(v.Type().Field(i).PkgPath) > 0 {
+				continue
+			}
+			if err := w.walk(v.Field(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		// byte slices are bounded by size
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		if v.Kind() == reflect.Slice && w.seen(v) {
+			return nil
+		}
+		if err := w.count(v.Len()); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walk(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if w.seen(v) {
+			return nil
+		}
+		if err := w.count(v.Len()); err != nil {
+			return err
+		}
+		for _, k := range v.MapKeys() {
+			if err := w.walk(v.MapIndex(k), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) count(n int) error {
+	w.elements += n
+	if w.limits.Elements > 0 && w.elements > w.limits.Elements {
+		return fmt.Errorf("exceeds maximum of %d elements", w.limits.Elements)
+	}
+	return nil
+}
+
+// Check returns an error if the value exceeds the limits
+func Check(v interface{}, l Limits) error {
+	if v == nil {
+		return nil
+	}
+
+	if l.Size > 0 {
+		n, err := size(v)
+		if err != nil {
+			return err
+		}
+		if n > l.Size {
+			return fmt.Errorf("size %d exceeds maximum of %d bytes", n, l.Size)
+		}
+	}
+
+	if l.Depth == 0 && l.Elements == 0 {
+		return nil
+	}
+
+	w := &walker{
+		limits:  l,
+		visited: make(map[visit]bool),
+	}
+	return w.walk(reflect.ValueOf(v), 0)
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which rejects requests exceeding the limits
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if err := Check(req.Request(), options.limits(req.Method())); err != nil {
+				return errors.New(req.Service(), "request "+err.Error(), 413)
+			}
+
+			return h(ctx, req, rsp)
+		}
+	}
+}
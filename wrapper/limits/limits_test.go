@@ -0,0 +1,145 @@
+package limits
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/micro/go-micro/codec"
+	"github.com/micro/go-micro/errors"
+)
+
+type node struct {
+	Name     string
+	Children []*node
+	Tags     map[string]string
+}
+
+func deep(n int) *node {
+	root := &node{}
+	cur := root
+	for i := 0; i < n; i++ {
+		child := &node{}
+		cur.Children = []*node{child}
+		cur = child
+	}
+	return root
+}
+
+func cyclic() *node {
+	n := &node{Name: "foo"}
+	n.Children = []*node{n}
+	return n
+}
+
+func TestCheck(t *testing.T) {
+	testData := []struct {
+		v     interface{}
+		l     Limits
+		valid bool
+	}{
+		{&node{Name: "foo"}, Limits{}, true},
+		{&node{Name: "foo"}, Limits{Size: 5}, false},
+		{deep(3), Limits{Depth: 10}, true},
+		{deep(10), Limits{Depth: 10}, false},
+		{&node{Tags: map[string]string{"a": "1", "b": "2"}}, Limits{Elements: 2}, true},
+		{&node{Tags: map[string]string{"a": "1", "b": "2", "c": "3"}}, Limits{Elements: 2}, false},
+		{cyclic(), Limits{Depth: 10, Elements: 10}, true},
+	}
+
+	for i, d := range testData {
+		err := Check(d.v, d.l)
+		if d.valid && err != nil {
+			t.Fatalf("%d: expected valid got %v", i, err)
+		}
+		if !d.valid && err == nil {
+			t.Fatalf("%d: expected error", i)
+		}
+	}
+}
+
+// testCodec reads the method from the first line and the body from the rest
+type testCodec struct {
+	rwc  io.ReadWriteCloser
+	body []byte
+}
+
+func (c *testCodec) ReadHeader(m *codec.Message, mt codec.MessageType) error {
+	b, err := ioutil.ReadAll(c.rwc)
+	if err != nil {
+		return err
+	}
+	parts := bytes.SplitN(b, []byte("\n"), 2)
+	m.Method = string(parts[0])
+	if len(parts) > 1 {
+		c.body = parts[1]
+	}
+	return nil
+}
+
+func (c *testCodec) ReadBody(b interface{}) error {
+	*(b.(*[]byte)) = c.body
+	return nil
+}
+
+func (c *testCodec) Write(m *codec.Message, b interface{}) error {
+	return nil
+}
+
+func (c *testCodec) Close() error {
+	return nil
+}
+
+func (c *testCodec) String() string {
+	return "test"
+}
+
+type testRWC struct {
+	*bytes.Buffer
+}
+
+func (t *testRWC) Close() error {
+	return nil
+}
+
+func TestCodec(t *testing.T) {
+	nc := NewCodec(func(rwc io.ReadWriteCloser) codec.Codec {
+		return &testCodec{rwc: rwc}
+	}, Default(Limits{Size: 20}), Endpoint("Foo.Upload", Limits{Size: 100}))
+
+	testData := []struct {
+		method string
+		size   int
+		valid  bool
+	}{
+		{"Foo.Bar", 5, true},
+		{"Foo.Bar", 50, false},
+		{"Foo.Upload", 50, true},
+		{"Foo.Upload", 200, false},
+	}
+
+	for i, d := range testData {
+		rwc := &testRWC{bytes.NewBufferString(d.method + "\n" + string(make([]byte, d.size)))}
+		c := nc(rwc)
+
+		var m codec.Message
+		err := c.ReadHeader(&m, codec.Request)
+		if err == nil {
+			var b []byte
+			err = c.ReadBody(&b)
+		}
+
+		if d.valid && err != nil {
+			t.Fatalf("%d: expected valid got %v", i, err)
+		}
+		if !d.valid {
+			if err == nil {
+				t.Fatalf("%d: expected error", i)
+			}
+			if e, ok := err.(*errors.Error); !ok || e.Code != 413 {
+				t.Fatalf("%d: expected 413 got %v", i, err)
+			}
+		}
+	}
+}
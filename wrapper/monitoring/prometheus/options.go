@@ -0,0 +1,84 @@
+package prometheus
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options for the prometheus wrapper
+type Options struct {
+	// Namespace prefixes the metric names
+	Namespace string
+	// Buckets for the latency histogram in seconds
+	Buckets []float64
+	// Endpoints is an allowlist of endpoint label values. Endpoints
+	// not listed are recorded as "other". Empty allows all endpoints.
+	Endpoints []string
+	// DropLabels removes labels from the metrics entirely
+	DropLabels []string
+	// Exemplar returns the exemplar labels for the call, usually
+	// holding the trace id. Nil labels record no exemplar.
+	Exemplar func(ctx context.Context) prometheus.Labels
+	// Registerer the metrics are registered with
+	Registerer prometheus.Registerer
+}
+
+type Option func(*Options)
+
+// Namespace sets the metric namespace, defaults to micro
+func Namespace(n string) Option {
+	return func(o *Options) {
+		o.Namespace = n
+	}
+}
+
+// Buckets sets the latency histogram buckets in seconds
+func Buckets(b ...float64) Option {
+	return func(o *Options) {
+		o.Buckets = b
+	}
+}
+
+// Endpoints sets the endpoint allowlist to bound label cardinality
+func Endpoints(e ...string) Option {
+	return func(o *Options) {
+		o.Endpoints = e
+	}
+}
+
+// DropLabels removes the named labels (service, endpoint, status)
+func DropLabels(l ...string) Option {
+	return func(o *Options) {
+		o.DropLabels = l
+	}
+}
+
+// Exemplar sets the function used to link observations to traces
+func Exemplar(fn func(ctx context.Context) prometheus.Labels) Option {
+	return func(o *Options) {
+		o.Exemplar = fn
+	}
+}
+
+// Registerer sets the registerer, defaults to prometheus.DefaultRegisterer
+func Registerer(r prometheus.Registerer) Option {
+	return func(o *Options) {
+		o.Registerer = r
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Namespace:  "micro",
+		Buckets:    prometheus.DefBuckets,
+		Exemplar:   traceExemplar,
+		Registerer: prometheus.DefaultRegisterer,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
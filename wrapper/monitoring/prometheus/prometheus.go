@@ -0,0 +1,204 @@
+// Package prometheus provides wrappers which record prometheus metrics
+package prometheus
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	other = "other"
+)
+
+type metrics struct {
+	opts      Options
+	labels    []string
+	endpoints map[string]bool
+
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+type clientWrapper struct {
+	m *metrics
+	client.Client
+}
+
+// traceExemplar uses the trace id from the w3c traceparent header
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	for k, v := range md {
+		if !strings.EqualFold(k, "traceparent") {
+			continue
+		}
+		// version-traceid-spanid-flags
+		parts := strings.Split(v, "-")
+		if len(parts) != 4 {
+			return nil
+		}
+		return prometheus.Labels{"trace_id": parts[1]}
+	}
+
+	return nil
+}
+
+// register returns the collector already registered by another wrapper if
+// any. Metrics are still recorded, but not exported, if registration fails
+// e.g. because a metric of the same name has different labels.
+func register(r prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	err := r.Register(c)
+	if err == nil {
+		return c
+	}
+	if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return are.ExistingCollector
+	}
+	log.Logf("[prometheus] failed to register metrics: %v", err)
+	return c
+}
+
+func newMetrics(role string, opts Options) *metrics {
+	drop := make(map[string]bool)
+	for _, l := range opts.DropLabels {
+		drop[l] = true
+	}
+
+	var labels []string
+	for _, l := range []string{"service", "endpoint", "status"} {
+		if !drop[l] {
+			labels = append(labels, l)
+		}
+	}
+
+	var latencyLabels []string
+	for _, l := range labels {
+		if l != "status" {
+			latencyLabels = append(latencyLabels, l)
+		}
+	}
+
+	endpoints := make(map[string]bool)
+	for _, e := range opts.Endpoints {
+		endpoints[e] = true
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: opts.Namespace,
+		Subsystem: role,
+		Name:      "requests_total",
+		Help:      "Requests processed, partitioned by endpoint and status",
+	}, labels)
+
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Subsystem: role,
+		Name:      "request_duration_seconds",
+		Help:      "Request latencies in seconds, partitioned by endpoint",
+		Buckets:   opts.Buckets,
+	}, latencyLabels)
+
+	if c, ok := register(opts.Registerer, requests).(*prometheus.CounterVec); ok {
+		requests = c
+	}
+	if c, ok := register(opts.Registerer, latency).(*prometheus.HistogramVec); ok {
+		latency = c
+	}
+
+	return &metrics{
+		opts:      opts,
+		labels:    labels,
+		endpoints: endpoints,
+		requests:  requests,
+		latency:   latency,
+	}
+}
+
+// endpoint returns the endpoint label value bounded by the allowlist
+func (m *metrics) endpoint(e string) string {
+	if len(m.endpoints) == 0 || m.endpoints[e] {
+		return e
+	}
+	return other
+}
+
+func (m *metrics) observe(ctx context.Context, service, endpoint string, d time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+
+	values := map[string]string{
+		"service":  service,
+		"endpoint": m.endpoint(endpoint),
+		"status":   status,
+	}
+
+	var lv []string
+	var hv []string
+	for _, l := range m.labels {
+		lv = append(lv, values[l])
+		if l != "status" {
+			hv = append(hv, values[l])
+		}
+	}
+
+	var exemplar prometheus.Labels
+	if m.opts.Exemplar != nil {
+		exemplar = m.opts.Exemplar(ctx)
+	}
+
+	counter := m.requests.WithLabelValues(lv...)
+	if ea, ok := counter.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		ea.AddWithExemplar(1, exemplar)
+	} else {
+		counter.Inc()
+	}
+
+	observer := m.latency.WithLabelValues(hv...)
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(d.Seconds(), exemplar)
+	} else {
+		observer.Observe(d.Seconds())
+	}
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	start := time.Now()
+	err := c.Client.Call(ctx, req, rsp, opts...)
+	c.m.observe(ctx, req.Service(), req.Method(), time.Since(start), err)
+	return err
+}
+
+// NewClientWrapper returns a client Wrapper which records call metrics
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	m := newMetrics("client", newOptions(opts...))
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{m, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which records request metrics
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	m := newMetrics("server", newOptions(opts...))
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			start := time.Now()
+			err := h(ctx, req, rsp)
+			m.observe(ctx, req.Service(), req.Method(), time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEndpointAllowlist(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newMetrics("server", newOptions(
+		Registerer(reg),
+		Endpoints("Foo.Bar"),
+	))
+
+	ctx := context.Background()
+	m.observe(ctx, "test", "Foo.Bar", time.Millisecond, nil)
+	m.observe(ctx, "test", "Foo.Baz", time.Millisecond, nil)
+	m.observe(ctx, "test", "Foo.Qux", time.Millisecond, errors.New("error"))
+
+	if v := testutil.ToFloat64(m.requests.WithLabelValues("test", "Foo.Bar", "success")); v != 1 {
+		t.Fatalf("expected 1 got %v", v)
+	}
+	if v := testutil.ToFloat64(m.requests.WithLabelValues("test", other, "success")); v != 1 {
+		t.Fatalf("expected 1 got %v", v)
+	}
+	if v := testutil.ToFloat64(m.requests.WithLabelValues("test", other, "failure")); v != 1 {
+		t.Fatalf("expected 1 got %v", v)
+	}
+}
+
+func TestDropLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newMetrics("server", newOptions(
+		Registerer(reg),
+		DropLabels("endpoint"),
+	))
+
+	m.observe(context.Background(), "test", "Foo.Bar", time.Millisecond, nil)
+
+	if v := testutil.ToFloat64(m.requests.WithLabelValues("test", "success")); v != 1 {
+		t.Fatalf("expected 1 got %v", v)
+	}
+}
+
+func TestTraceExemplar(t *testing.T) {
+	ctx := metadata.NewContext(context.Background(), map[string]string{
+		"Traceparent": "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+	})
+
+	l := traceExemplar(ctx)
+	if l["trace_id"] != "0102030405060708090a0b0c0d0e0f10" {
+		t.Fatalf("unexpected exemplar %v", l)
+	}
+}
+
+func TestRegisterConflict(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a := newMetrics("server", newOptions(Registerer(reg)))
+
+	// the same metrics are shared
+	if b := newMetrics("server", newOptions(Registerer(reg))); b.requests != a.requests {
+		t.Fatal("expected the registered metrics to be reused")
+	}
+
+	// different labels fail to register without panicking
+	m := newMetrics("server", newOptions(Registerer(reg), DropLabels("endpoint")))
+	m.observe(context.Background(), "test", "Foo.Bar", time.Millisecond, nil)
+
+	if v := testutil.ToFloat64(m.requests.WithLabelValues("test", "success")); v != 1 {
+		t.Fatalf("expected 1 got %v", v)
+	}
+}
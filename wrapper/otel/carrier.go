@@ -0,0 +1,37 @@
+package otel
+
+import (
+	"strings"
+
+	"github.com/micro/go-micro/metadata"
+)
+
+// metadataCarrier adapts go-micro metadata to a propagation.TextMapCarrier.
+// Metadata passes through broker message headers so the trace is carried
+// across publish and subscribe.
+type metadataCarrier metadata.Metadata
+
+func (m metadataCarrier) Get(key string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	// transports may change the case of keys
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	m[key] = value
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
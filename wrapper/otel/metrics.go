@@ -0,0 +1,51 @@
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type metrics struct {
+	duration metric.Float64Histogram
+	requests metric.Int64Counter
+}
+
+func newMetrics(mp metric.MeterProvider, role string) *metrics {
+	meter := mp.Meter(instrumentationName)
+
+	// errors creating instruments leave a no-op instrument in place
+	duration, _ := meter.Float64Histogram(
+		"rpc."+role+".duration",
+		metric.WithDescription("Duration of rpc calls"),
+		metric.WithUnit("ms"),
+	)
+	requests, _ := meter.Int64Counter(
+		"rpc."+role+".requests",
+		metric.WithDescription("Number of rpc calls"),
+	)
+
+	return &metrics{
+		duration: duration,
+		requests: requests,
+	}
+}
+
+func (m *metrics) record(ctx context.Context, start time.Time, attrs []attribute.KeyValue, err error) {
+	if err != nil {
+		attrs = append(attrs, attribute.String("rpc.status", "error"))
+	} else {
+		attrs = append(attrs, attribute.String("rpc.status", "ok"))
+	}
+
+	opt := metric.WithAttributes(attrs...)
+
+	if m.duration != nil {
+		m.duration.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), opt)
+	}
+	if m.requests != nil {
+		m.requests.Add(ctx, 1, opt)
+	}
+}
@@ -0,0 +1,55 @@
+package otel
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options for the otel wrappers
+type Options struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+	Propagators    propagation.TextMapPropagator
+}
+
+type Option func(*Options)
+
+// WithTracerProvider sets the tracer provider, defaults to the global provider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *Options) {
+		o.TracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the meter provider, defaults to the global provider
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *Options) {
+		o.MeterProvider = mp
+	}
+}
+
+// WithPropagators sets the propagators, defaults to W3C trace context and baggage
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(o *Options) {
+		o.Propagators = p
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		TracerProvider: otel.GetTracerProvider(),
+		MeterProvider:  otel.GetMeterProvider(),
+		Propagators: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
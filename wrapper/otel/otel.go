@@ -0,0 +1,175 @@
+// Package otel provides wrappers for OpenTelemetry tracing, metrics and baggage propagation
+package otel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/micro/go-plugins/wrapper/otel"
+)
+
+type otelWrapper struct {
+	opts    Options
+	tracer  trace.Tracer
+	metrics *metrics
+	client.Client
+}
+
+func requestAttributes(service, method string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "go-micro"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", method),
+	}
+}
+
+func topicAttributes(topic string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", "go-micro"),
+		attribute.String("messaging.destination", topic),
+	}
+}
+
+// inject writes the span context and baggage into the outgoing metadata
+func inject(ctx context.Context, opts Options) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		md = make(map[string]string)
+	}
+
+	// copy so we don't mutate the callers metadata
+	nmd := make(metadata.Metadata, len(md))
+	for k, v := range md {
+		nmd[k] = v
+	}
+
+	opts.Propagators.Inject(ctx, metadataCarrier(nmd))
+	return metadata.NewContext(ctx, nmd)
+}
+
+// extract reads the remote span context and baggage from incoming metadata
+func extract(ctx context.Context, opts Options) context.Context {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return opts.Propagators.Extract(ctx, metadataCarrier(md))
+}
+
+func finish(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (o *otelWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) (err error) {
+	attrs := requestAttributes(req.Service(), req.Method())
+	name := fmt.Sprintf("%s.%s", req.Service(), req.Method())
+
+	ctx, span := o.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	start := time.Now()
+
+	defer func() {
+		o.metrics.record(ctx, start, attrs, err)
+		finish(span, err)
+	}()
+
+	err = o.Client.Call(inject(ctx, o.opts), req, rsp, opts...)
+	return
+}
+
+func (o *otelWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) (err error) {
+	attrs := topicAttributes(p.Topic())
+	name := fmt.Sprintf("Pub to %s", p.Topic())
+
+	ctx, span := o.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(attrs...))
+	start := time.Now()
+
+	defer func() {
+		o.metrics.record(ctx, start, attrs, err)
+		finish(span, err)
+	}()
+
+	err = o.Client.Publish(inject(ctx, o.opts), p, opts...)
+	return
+}
+
+// NewClientWrapper returns a client.Wrapper which creates spans,
+// propagates trace context and records metrics for outgoing calls
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &otelWrapper{
+			opts:    options,
+			tracer:  options.TracerProvider.Tracer(instrumentationName),
+			metrics: newMetrics(options.MeterProvider, "client"),
+			Client:  c,
+		}
+	}
+}
+
+// NewHandlerWrapper returns a server.HandlerWrapper which continues the
+// callers trace and records metrics for incoming requests
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+	tracer := options.TracerProvider.Tracer(instrumentationName)
+	m := newMetrics(options.MeterProvider, "server")
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) (err error) {
+			attrs := requestAttributes(req.Service(), req.Method())
+			name := fmt.Sprintf("%s.%s", req.Service(), req.Method())
+
+			ctx, span := tracer.Start(extract(ctx, options), name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+			start := time.Now()
+
+			defer func() {
+				m.record(ctx, start, attrs, err)
+				finish(span, err)
+			}()
+
+			err = h(ctx, req, rsp)
+			return
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server.SubscriberWrapper which continues
+// the publishers trace and records metrics for incoming messages
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := newOptions(opts...)
+	tracer := options.TracerProvider.Tracer(instrumentationName)
+	m := newMetrics(options.MeterProvider, "subscriber")
+
+	return func(next server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) (err error) {
+			attrs := topicAttributes(msg.Topic())
+			name := "Sub from " + msg.Topic()
+
+			ctx, span := tracer.Start(extract(ctx, options), name, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...))
+			start := time.Now()
+
+			defer func() {
+				m.record(ctx, start, attrs, err)
+				finish(span, err)
+			}()
+
+			err = next(ctx, msg)
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagation(t *testing.T) {
+	opts := newOptions()
+
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	member, _ := baggage.NewMember("tenant", "acme")
+	bag, _ := baggage.New(member)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+	ctx = inject(ctx, opts)
+
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		t.Fatal("expected metadata in context")
+	}
+	if len(md["traceparent"]) == 0 {
+		t.Fatal("expected traceparent to be injected")
+	}
+
+	// simulate a transport which changes the case of keys
+	rmd := metadata.Metadata{}
+	for k, v := range md {
+		rmd[strings.Title(k)] = v
+	}
+
+	rctx := extract(metadata.NewContext(context.Background(), rmd), opts)
+
+	if got := trace.SpanContextFromContext(rctx); got.TraceID() != traceID {
+		t.Fatalf("expected trace id %s got %s", traceID, got.TraceID())
+	}
+	if got := baggage.FromContext(rctx).Member("tenant").Value(); got != "acme" {
+		t.Fatalf("expected baggage acme got %s", got)
+	}
+}
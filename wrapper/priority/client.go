@@ -0,0 +1,227 @@
+package priority
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+// overload is the state of a node advertised by its shed requests
+type overload struct {
+	// priority being shed, along with lower classes
+	priority Priority
+	until    time.Time
+}
+
+type clientWrapper struct {
+	opts Options
+
+	sync.Mutex
+	// overloads keyed by node address
+	overloads map[string]overload
+	client.Client
+}
+
+// NewContext returns a context with the priority set in the metadata
+// key of the options, Micro-Priority by default
+func NewContext(ctx context.Context, p Priority, opts ...Option) context.Context {
+	options := newOptions(opts...)
+
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		md = make(map[string]string)
+	}
+
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		if !strings.EqualFold(k, options.Header) {
+			nmd[k] = v
+		}
+	}
+	nmd[options.Header] = p.String()
+
+	return metadata.NewContext(ctx, nmd)
+}
+
+// address of the node as dialled by the client
+func address(node *registry.Node) string {
+	if node.Port > 0 {
+		return fmt.Sprintf("%s:%d", node.Address, node.Port)
+	}
+	return node.Address
+}
+
+// overloaded returns how long requests of the priority should
+// be held back from the node
+func (c *clientWrapper) overloaded(addr string, p Priority) time.Duration {
+	c.Lock()
+	defer c.Unlock()
+
+	o, ok := c.overloads[addr]
+	if !ok {
+		return 0
+	}
+
+	d := time.Until(o.until)
+	if d <= 0 {
+		delete(c.overloads, addr)
+		return 0
+	}
+	if p < o.priority {
+		return 0
+	}
+	return d
+}
+
+// observe records the overload state advertised by shed requests
+func (c *clientWrapper) observe(addr string, err error) {
+	p, ok := Overloaded(err)
+	if !ok {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+
+	// the most severe overload wins while active
+	if o, ok := c.overloads[addr]; ok && now.Before(o.until) && o.priority < p {
+		p = o.priority
+	}
+
+	c.overloads[addr] = overload{
+		priority: p,
+		until:    now.Add(c.opts.Backoff),
+	}
+}
+
+// filter removes nodes which are overloaded at the priority so
+// requests go to those which aren't. All nodes are kept if they're
+// all overloaded so the request is delayed or shed when called.
+func (c *clientWrapper) filter(p Priority) selector.Filter {
+	return func(services []*registry.Service) []*registry.Service {
+		var filtered []*registry.Service
+
+		for _, service := range services {
+			var nodes []*registry.Node
+			for _, node := range service.Nodes {
+				if c.overloaded(address(node), p) == 0 {
+					nodes = append(nodes, node)
+				}
+			}
+			if len(nodes) == 0 {
+				continue
+			}
+			s := *service
+			s.Nodes = nodes
+			filtered = append(filtered, &s)
+		}
+
+		if len(filtered) == 0 {
+			return services
+		}
+		return filtered
+	}
+}
+
+// admit delays the request until the node has recovered or
+// sheds it if that takes longer than the max delay
+func (c *clientWrapper) admit(ctx context.Context, service, addr string, p Priority) error {
+	var waited time.Duration
+
+	for {
+		d := c.overloaded(addr, p)
+		if d == 0 {
+			return nil
+		}
+
+		if waited+d > c.opts.MaxDelay {
+			return errors.New("go.micro.client", "request shed at priority "+p.String()+", "+service+" at "+addr+" is overloaded", 503)
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Timeout("go.micro.client", "request delayed at priority %s: %v", p, ctx.Err())
+		case <-t.C:
+		}
+		waited += d
+	}
+}
+
+// wrap admits calls to the node and observes whether it's overloaded
+func (c *clientWrapper) wrap(p Priority) client.CallWrapper {
+	return func(cf client.CallFunc) client.CallFunc {
+		return func(ctx context.Context, addr string, req client.Request, rsp interface{}, opts client.CallOptions) error {
+			if err := c.admit(ctx, req.Service(), addr, p); err != nil {
+				return err
+			}
+			err := cf(ctx, addr, req, rsp, opts)
+			c.observe(addr, err)
+			return err
+		}
+	}
+}
+
+// priority sets the priority header if missing and returns the priority
+func (c *clientWrapper) priority(ctx context.Context) (context.Context, Priority) {
+	p := fromContext(ctx, c.opts.Header, c.opts.Default)
+	md, _ := metadata.FromContext(ctx)
+	for k := range md {
+		if strings.EqualFold(k, c.opts.Header) {
+			return ctx, p
+		}
+	}
+
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		nmd[k] = v
+	}
+	nmd[c.opts.Header] = p.String()
+
+	return metadata.NewContext(ctx, nmd), p
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	ctx, p := c.priority(ctx)
+	opts = append(opts,
+		client.WithSelectOption(selector.WithFilter(c.filter(p))),
+		client.WithCallWrapper(c.wrap(p)),
+	)
+	return c.Client.Call(ctx, req, rsp, opts...)
+}
+
+// Stream avoids overloaded nodes. The node a stream is opened to isn't
+// known so streams don't record overloads.
+func (c *clientWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	ctx, p := c.priority(ctx)
+	opts = append(opts, client.WithSelectOption(selector.WithFilter(c.filter(p))))
+	return c.Client.Stream(ctx, req, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which reacts to nodes
+// shedding requests by holding back requests of the shed priority and
+// lower. They're sent to other nodes or, if all are overloaded, delayed
+// up to the max delay before being shed locally. Higher priority requests
+// pass through so load on an overloaded node drops without retry storms.
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{
+			opts:      options,
+			overloads: make(map[string]overload),
+			Client:    c,
+		}
+	}
+}
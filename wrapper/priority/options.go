@@ -0,0 +1,99 @@
+package priority
+
+import (
+	"time"
+)
+
+// Options for the priority queue
+type Options struct {
+	// Concurrency is the number of requests served at once
+	Concurrency int
+	// MaxQueue is the number of requests which may wait across all classes
+	MaxQueue int
+	// MaxWait is how long a request may wait before it's shed
+	MaxWait time.Duration
+	// Header is the metadata key holding the priority
+	Header string
+	// Default priority of requests without the header
+	Default Priority
+	// Backoff is how long clients consider a service overloaded after
+	// a request is shed
+	Backoff time.Duration
+	// MaxDelay is how long clients delay a request when all nodes
+	// are overloaded before shedding it, zero sheds it straight away
+	MaxDelay time.Duration
+}
+
+type Option func(*Options)
+
+// Concurrency sets the number of requests served at once
+func Concurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// MaxQueue sets the number of requests which may wait
+func MaxQueue(n int) Option {
+	return func(o *Options) {
+		o.MaxQueue = n
+	}
+}
+
+// MaxWait sets how long a request may wait to be served
+func MaxWait(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxWait = d
+	}
+}
+
+// Header sets the metadata key holding the priority
+func Header(key string) Option {
+	return func(o *Options) {
+		o.Header = key
+	}
+}
+
+// Default sets the priority of requests without the header
+func Default(p Priority) Option {
+	return func(o *Options) {
+		o.Default = p
+	}
+}
+
+// Backoff sets how long clients consider a service overloaded
+func Backoff(d time.Duration) Option {
+	return func(o *Options) {
+		o.Backoff = d
+	}
+}
+
+// MaxDelay sets how long clients delay requests when all nodes are
+// overloaded, zero sheds them straight away
+func MaxDelay(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxDelay = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Concurrency: 100,
+		MaxQueue:    1000,
+		MaxWait:     time.Second,
+		Header:      "Micro-Priority",
+		Default:     Normal,
+		Backoff:     time.Second,
+		MaxDelay:    100 * time.Millisecond,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Default < High || options.Default > Low {
+		options.Default = Normal
+	}
+
+	return options
+}
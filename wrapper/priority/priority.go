@@ -0,0 +1,253 @@
+// Package priority provides a server wrapper which queues requests by
+// priority class once the server is at capacity. Requests carry their
+// class in metadata and low priority work is shed first under load.
+package priority
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+// Priority class of a request, lower values are served first
+type Priority int
+
+const (
+	High Priority = iota
+	Normal
+	Low
+)
+
+const numClasses = 3
+
+// Stats for a priority class
+type Stats struct {
+	// Queued is the current queue length
+	Queued int
+	// Admitted requests which were served
+	Admitted int64
+	// Shed requests which were rejected
+	Shed int64
+	// Wait is the total time admitted requests spent queued
+	Wait time.Duration
+}
+
+// Queue is an admission queue with priority classes
+type Queue struct {
+	opts Options
+
+	sync.Mutex
+	inflight int
+	queued   int
+	queues   [numClasses][]*waiter
+	stats    [numClasses]Stats
+}
+
+type waiter struct {
+	// receives true when admitted or false when shed
+	ch    chan bool
+	start time.Time
+}
+
+var names = map[string]Priority{
+	"high":   High,
+	"normal": Normal,
+	"low":    Low,
+}
+
+func (p Priority) String() string {
+	switch p {
+	case High:
+		return "high"
+	case Low:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// fromContext returns the class from the request metadata
+func fromContext(ctx context.Context, header string, def Priority) Priority {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return def
+	}
+
+	for k, v := range md {
+		if !strings.EqualFold(k, header) {
+			continue
+		}
+		if p, ok := names[strings.ToLower(strings.TrimSpace(v))]; ok {
+			return p
+		}
+	}
+
+	return def
+}
+
+// priority returns the class from the request metadata
+func (q *Queue) priority(ctx context.Context) Priority {
+	return fromContext(ctx, q.opts.Header, q.opts.Default)
+}
+
+const shedDetail = "request shed at priority "
+
+func shed(p Priority) error {
+	return errors.New("go.micro.server", shedDetail+p.String(), 503)
+}
+
+// Overloaded returns the priority shed by an overloaded server. Requests
+// of the priority and lower are being shed.
+func Overloaded(err error) (Priority, bool) {
+	if err == nil {
+		return 0, false
+	}
+	e := errors.Parse(err.Error())
+	if e.Code != 503 || e.Id != "go.micro.server" || !strings.HasPrefix(e.Detail, shedDetail) {
+		return 0, false
+	}
+	p, ok := names[strings.TrimPrefix(e.Detail, shedDetail)]
+	return p, ok
+}
+
+// acquire admits the request immediately or waits in its class queue
+func (q *Queue) acquire(ctx context.Context, p Priority) error {
+	q.Lock()
+
+	if q.inflight < q.opts.Concurrency && q.queued == 0 {
+		q.inflight++
+		q.stats[p].Admitted++
+		q.Unlock()
+		return nil
+	}
+
+	// make room by shedding the newest request of a lower class
+	if q.queued >= q.opts.MaxQueue {
+		evicted := false
+		for c := Priority(numClasses - 1); c > p; c-- {
+			if n := len(q.queues[c]); n > 0 {
+				w := q.queues[c][n-1]
+				q.queues[c] = q.queues[c][:n-1]
+				q.queued--
+				q.stats[c].Shed++
+				w.ch <- false
+				evicted = true
+				break
+			}
+		}
+		if !evicted {
+			q.stats[p].Shed++
+			q.Unlock()
+			return shed(p)
+		}
+	}
+
+	w := &waiter{ch: make(chan bool, 1), start: time.Now()}
+	q.queues[p] = append(q.queues[p], w)
+	q.queued++
+	q.Unlock()
+
+	t := time.NewTimer(q.opts.MaxWait)
+	defer t.Stop()
+
+	select {
+	case ok := <-w.ch:
+		if !ok {
+			return shed(p)
+		}
+		return nil
+	case <-ctx.Done():
+	case <-t.C:
+	}
+
+	q.Lock()
+	if q.remove(p, w) {
+		q.stats[p].Shed++
+		q.Unlock()
+		return shed(p)
+	}
+	q.Unlock()
+
+	// admitted while timing out so pass the slot on
+	if <-w.ch {
+		q.release()
+	}
+	return shed(p)
+}
+
+// remove deletes the waiter from the queue returning false if it was dequeued
+func (q *Queue) remove(p Priority, w *waiter) bool {
+	for i, qw := range q.queues[p] {
+		if qw == w {
+			q.queues[p] = append(q.queues[p][:i], q.queues[p][i+1:]...)
+			q.queued--
+			return true
+		}
+	}
+	return false
+}
+
+// release hands the slot to the oldest request of the highest class
+func (q *Queue) release() {
+	q.Lock()
+	defer q.Unlock()
+
+	for c := range q.queues {
+		if len(q.queues[c]) == 0 {
+			continue
+		}
+		w := q.queues[c][0]
+		q.queues[c] = q.queues[c][1:]
+		q.queued--
+		q.stats[c].Admitted++
+		q.stats[c].Wait += time.Since(w.start)
+		w.ch <- true
+		return
+	}
+
+	q.inflight--
+}
+
+// Stats returns the stats for each priority class
+func (q *Queue) Stats() map[Priority]Stats {
+	q.Lock()
+	defer q.Unlock()
+
+	stats := make(map[Priority]Stats, numClasses)
+	for c := range q.stats {
+		s := q.stats[c]
+		s.Queued = len(q.queues[c])
+		stats[Priority(c)] = s
+	}
+	return stats
+}
+
+// HandlerWrapper returns a server HandlerWrapper which admits requests through the queue
+func (q *Queue) HandlerWrapper() server.HandlerWrapper {
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if err := q.acquire(ctx, q.priority(ctx)); err != nil {
+				return err
+			}
+			defer q.release()
+			return h(ctx, req, rsp)
+		}
+	}
+}
+
+// NewQueue returns an admission queue with priority classes
+func NewQueue(opts ...Option) *Queue {
+	return &Queue{
+		opts: newOptions(opts...),
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper with a new admission queue
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	return NewQueue(opts...).HandlerWrapper()
+}
@@ -0,0 +1,262 @@
+package priority
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/registry"
+	"github.com/micro/go-micro/selector"
+)
+
+type testRequest struct {
+	client.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+// testClient calls the first node left by the select filters
+// through the call wrappers
+type testClient struct {
+	client.Client
+	err   error
+	nodes []*registry.Node
+	calls map[string]int
+	addrs map[string]int
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	var options client.CallOptions
+	for _, o := range opts {
+		o(&options)
+	}
+	var sopts selector.SelectOptions
+	for _, o := range options.SelectOptions {
+		o(&sopts)
+	}
+
+	nodes := t.nodes
+	if len(nodes) == 0 {
+		nodes = []*registry.Node{{Id: "1", Address: "10.0.0.1", Port: 8080}}
+	}
+
+	services := []*registry.Service{{Name: req.Service(), Nodes: nodes}}
+	for _, f := range sopts.Filters {
+		services = f(services)
+	}
+
+	cf := func(ctx context.Context, addr string, req client.Request, rsp interface{}, opts client.CallOptions) error {
+		md, _ := metadata.FromContext(ctx)
+		t.calls[md["Micro-Priority"]]++
+		if t.addrs != nil {
+			t.addrs[addr]++
+		}
+		return t.err
+	}
+	for i := len(options.CallWrappers); i > 0; i-- {
+		cf = options.CallWrappers[i-1](cf)
+	}
+
+	return cf(ctx, address(services[0].Nodes[0]), req, rsp, options)
+}
+
+func TestPriority(t *testing.T) {
+	q := NewQueue(Header("X-Priority"))
+
+	ctx := metadata.NewContext(context.Background(), metadata.Metadata{"x-priority": "High"})
+	if p := q.priority(ctx); p != High {
+		t.Fatalf("expected high got %s", p)
+	}
+
+	if p := q.priority(context.Background()); p != Normal {
+		t.Fatalf("expected normal got %s", p)
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	q := NewQueue(Concurrency(1), MaxWait(time.Second))
+	ctx := context.Background()
+
+	// take the only slot
+	if err := q.acquire(ctx, Normal); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan Priority, 2)
+	for _, p := range []Priority{Low, High} {
+		go func(p Priority) {
+			if err := q.acquire(ctx, p); err != nil {
+				t.Error(err)
+				return
+			}
+			order <- p
+			q.release()
+		}(p)
+		// wait for the request to be queued
+		for q.Stats()[p].Queued == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	q.release()
+
+	if p := <-order; p != High {
+		t.Fatalf("expected high priority first got %s", p)
+	}
+	if p := <-order; p != Low {
+		t.Fatalf("expected low priority second got %s", p)
+	}
+
+	if s := q.Stats()[Low]; s.Admitted != 1 || s.Wait == 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestQueueShed(t *testing.T) {
+	q := NewQueue(Concurrency(1), MaxQueue(1), MaxWait(time.Second))
+	ctx := context.Background()
+
+	if err := q.acquire(ctx, Normal); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.acquire(ctx, Low)
+	}()
+	for q.Stats()[Low].Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the queue is full so a low priority request is rejected
+	if err := q.acquire(ctx, Low); err == nil {
+		t.Fatal("expected low priority request to be shed")
+	}
+
+	// and a high priority request evicts the queued low priority one
+	go q.acquire(ctx, High)
+
+	if err := <-done; err == nil {
+		t.Fatal("expected queued low priority request to be shed")
+	}
+
+	if s := q.Stats()[Low]; s.Shed != 2 {
+		t.Fatalf("expected 2 low priority requests shed got %d", s.Shed)
+	}
+}
+
+func TestQueueWait(t *testing.T) {
+	q := NewQueue(Concurrency(1), MaxWait(time.Millisecond*10))
+	ctx := context.Background()
+
+	if err := q.acquire(ctx, Normal); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.acquire(ctx, High); err == nil {
+		t.Fatal("expected request to be shed after waiting")
+	}
+
+	if s := q.Stats()[High]; s.Queued != 0 || s.Shed != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestOverloaded(t *testing.T) {
+	if p, ok := Overloaded(shed(Low)); !ok || p != Low {
+		t.Fatalf("expected low to be shed got %s %v", p, ok)
+	}
+	if _, ok := Overloaded(errors.New("go.micro.server", "unavailable", 503)); ok {
+		t.Fatal("expected other errors not to signal overload")
+	}
+}
+
+func TestClientWrapper(t *testing.T) {
+	tc := &testClient{err: shed(Normal), calls: make(map[string]int)}
+	c := NewClientWrapper(Backoff(time.Millisecond*50), MaxDelay(0))(tc)
+
+	// the server sheds a normal request
+	if err := c.Call(context.TODO(), &testRequest{}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	tc.err = nil
+
+	// normal and low are shed locally while high passes
+	for _, p := range []Priority{Low, Normal, High} {
+		err := c.Call(NewContext(context.TODO(), p), &testRequest{}, nil)
+		if p == High && err != nil {
+			t.Fatalf("expected high to pass got %v", err)
+		}
+		if p != High && err == nil {
+			t.Fatalf("expected %s to be shed", p)
+		}
+	}
+
+	if tc.calls["normal"] != 1 || tc.calls["low"] != 0 || tc.calls["high"] != 1 {
+		t.Fatalf("unexpected calls %v", tc.calls)
+	}
+
+	// once recovered requests flow again
+	time.Sleep(time.Millisecond * 60)
+	if err := c.Call(NewContext(context.TODO(), Low), &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// requests are delayed rather than shed within the max delay
+	tc.err = shed(Low)
+	c = NewClientWrapper(Backoff(time.Millisecond*20), MaxDelay(time.Millisecond*100))(tc)
+	c.Call(NewContext(context.TODO(), Low), &testRequest{}, nil)
+	tc.err = nil
+
+	start := time.Now()
+	if err := c.Call(NewContext(context.TODO(), Low), &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < time.Millisecond*10 {
+		t.Fatal("expected request to be delayed")
+	}
+}
+
+func TestClientWrapperNodes(t *testing.T) {
+	tc := &testClient{
+		err: shed(Normal),
+		nodes: []*registry.Node{
+			{Id: "1", Address: "10.0.0.1", Port: 8080},
+			{Id: "2", Address: "10.0.0.2", Port: 8080},
+		},
+		calls: make(map[string]int),
+		addrs: make(map[string]int),
+	}
+	c := NewClientWrapper(Backoff(time.Minute), MaxDelay(0))(tc)
+
+	// the first node sheds the request
+	if err := c.Call(context.TODO(), &testRequest{}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	tc.err = nil
+
+	// requests go to the node which isn't overloaded
+	if err := c.Call(context.TODO(), &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tc.addrs["10.0.0.1:8080"] != 1 || tc.addrs["10.0.0.2:8080"] != 1 {
+		t.Fatalf("expected the second node to be called got %v", tc.addrs)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	ctx := NewContext(context.TODO(), Low, Header("X-Priority"))
+
+	md, _ := metadata.FromContext(ctx)
+	if md["X-Priority"] != "low" {
+		t.Fatalf("expected the configured header got %v", md)
+	}
+	if p := fromContext(ctx, "X-Priority", Normal); p != Low {
+		t.Fatalf("expected low got %s", p)
+	}
+}
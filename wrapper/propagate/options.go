@@ -0,0 +1,97 @@
+package propagate
+
+import (
+	"strings"
+)
+
+var (
+	// DefaultHeaders are the headers propagated by default
+	DefaultHeaders = []string{
+		"X-Request-Id",
+		"X-Tenant-Id",
+		"Baggage",
+		"Traceparent",
+		"Tracestate",
+	}
+
+	// SensitiveHeaders are never set on published messages, where
+	// they'd be persisted, unless allowed with AllowPublish
+	SensitiveHeaders = []string{
+		"Authorization",
+	}
+)
+
+// Options for the propagation wrappers
+type Options struct {
+	// Allow is the allowlist of incoming headers propagated, matched
+	// ignoring case. A trailing * matches the prefix, such as "X-Baggage-*".
+	Allow []string
+	// Deny headers are never propagated, even when allowed. The client
+	// wrapper removes them from outgoing calls, such as to external services.
+	Deny []string
+	// Publish are the sensitive headers set on published messages
+	Publish []string
+}
+
+type Option func(*Options)
+
+// Allow adds headers to the allowlist
+func Allow(headers ...string) Option {
+	return func(o *Options) {
+		o.Allow = append(o.Allow, headers...)
+	}
+}
+
+// Deny adds headers which are never propagated
+func Deny(headers ...string) Option {
+	return func(o *Options) {
+		o.Deny = append(o.Deny, headers...)
+	}
+}
+
+// AllowPublish sets sensitive headers, such as Authorization, on published
+// messages. They must also be allowed to be propagated.
+func AllowPublish(headers ...string) Option {
+	return func(o *Options) {
+		o.Publish = append(o.Publish, headers...)
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Allow: append([]string{}, DefaultHeaders...),
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
+
+// match returns whether the header matches one of the patterns
+func match(patterns []string, header string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "*") {
+			prefix := p[:len(p)-1]
+			if len(header) >= len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(p, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed returns whether the header is propagated
+func (o Options) allowed(header string) bool {
+	return match(o.Allow, header) && !match(o.Deny, header)
+}
+
+// publishable returns whether the header may be set on published messages
+func (o Options) publishable(header string) bool {
+	return !match(SensitiveHeaders, header) || match(o.Publish, header)
+}
@@ -0,0 +1,179 @@
+// Package propagate provides client and server wrappers which
+// copy an allowlist of headers, such as the request id, tenant, baggage
+// and trace context, across RPC hops and broker messages. Incoming
+// headers which aren't allowed are no longer forwarded on outgoing calls.
+package propagate
+
+import (
+	"context"
+	"strings"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type propagateKey struct{}
+
+// state is the propagation state of a request
+type state struct {
+	// headers propagated on outgoing calls
+	headers map[string]string
+	// incoming metadata of the request
+	incoming metadata.Metadata
+}
+
+type clientWrapper struct {
+	opts Options
+	client.Client
+}
+
+// FromContext returns the headers propagated from the request
+func FromContext(ctx context.Context) (map[string]string, bool) {
+	s, ok := ctx.Value(propagateKey{}).(*state)
+	if !ok {
+		return nil, false
+	}
+	return s.headers, true
+}
+
+// Extract returns a context propagating the allowed headers, such
+// as those of a message received directly from a broker
+func Extract(ctx context.Context, header map[string]string, opts ...Option) context.Context {
+	return extract(ctx, header, newOptions(opts...))
+}
+
+// Inject sets the propagated headers in the context on the header
+// unless already set, such as on a message published directly to
+// a broker. Sensitive headers are only set if allowed with AllowPublish.
+func Inject(ctx context.Context, header map[string]string, opts ...Option) {
+	options := newOptions(opts...)
+	set(ctx, header, options.publishable)
+}
+
+// set sets the propagated headers accepted by fn unless already set
+func set(ctx context.Context, header map[string]string, fn func(string) bool) {
+	h, _ := FromContext(ctx)
+	for k, v := range h {
+		if !fn(k) {
+			continue
+		}
+		if _, ok := get(header, k); !ok {
+			header[k] = v
+		}
+	}
+}
+
+// get returns the header value ignoring case
+func get(header map[string]string, key string) (string, bool) {
+	if v, ok := header[key]; ok {
+		return v, true
+	}
+	for k, v := range header {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func extract(ctx context.Context, header map[string]string, opts Options) context.Context {
+	headers := make(map[string]string)
+	// copied as handlers often set headers on the incoming metadata
+	incoming := make(metadata.Metadata, len(header))
+
+	for k, v := range header {
+		incoming[k] = v
+		if opts.allowed(k) {
+			headers[k] = v
+		}
+	}
+
+	return context.WithValue(ctx, propagateKey{}, &state{
+		headers:  headers,
+		incoming: incoming,
+	})
+}
+
+// inject returns the context with the outgoing metadata of a call. Incoming
+// headers which aren't propagated are dropped unless changed for the call.
+// Sensitive headers are dropped from publications unless allowed.
+func inject(ctx context.Context, opts Options, publish bool) context.Context {
+	s, ok := ctx.Value(propagateKey{}).(*state)
+	if !ok {
+		if !publish {
+			return ctx
+		}
+		s = &state{}
+	}
+
+	md, _ := metadata.FromContext(ctx)
+	nmd := make(metadata.Metadata, len(md)+len(s.headers))
+
+	for k, v := range md {
+		if iv, ok := s.incoming[k]; ok && iv == v {
+			if _, ok := s.headers[k]; !ok {
+				continue
+			}
+		}
+		nmd[k] = v
+	}
+
+	set(ctx, nmd, func(string) bool { return true })
+
+	for k := range nmd {
+		if match(opts.Deny, k) || (publish && !opts.publishable(k)) {
+			delete(nmd, k)
+		}
+	}
+
+	return metadata.NewContext(ctx, nmd)
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return c.Client.Call(inject(ctx, c.opts, false), req, rsp, opts...)
+}
+
+func (c *clientWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return c.Client.Stream(inject(ctx, c.opts, false), req, opts...)
+}
+
+func (c *clientWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	return c.Client.Publish(inject(ctx, c.opts, true), p, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which sets the headers
+// propagated from the request on outgoing calls and publications
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{options, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which places the
+// allowed headers of the request in the context to be propagated
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			md, _ := metadata.FromContext(ctx)
+			return h(extract(ctx, md, options), req, rsp)
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which places
+// the allowed headers of the message in the context to be propagated
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			md, _ := metadata.FromContext(ctx)
+			return fn(extract(ctx, md, options), msg)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package propagate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testClient struct {
+	client.Client
+	md metadata.Metadata
+}
+
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	t.md, _ = metadata.FromContext(ctx)
+	return nil
+}
+
+func (t *testClient) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	t.md, _ = metadata.FromContext(ctx)
+	return nil
+}
+
+func TestPropagate(t *testing.T) {
+	tc := &testClient{}
+	c := NewClientWrapper()(tc)
+
+	fn := NewHandlerWrapper(Allow("X-Baggage-*"), Deny("Authorization"))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		// set a header for the call
+		md, _ := metadata.FromContext(ctx)
+		md["X-Call"] = "1"
+		return c.Call(metadata.NewContext(ctx, md), nil, nil)
+	})
+
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{
+		"X-Request-Id":   "abc",
+		"X-Baggage-User": "bob",
+		"Authorization":  "secret",
+		"Content-Type":   "application/json",
+	})
+
+	if err := fn(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"X-Request-Id":   "abc",
+		"X-Baggage-User": "bob",
+		"X-Call":         "1",
+	}
+
+	if len(tc.md) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, tc.md)
+	}
+	for k, v := range expected {
+		if tc.md[k] != v {
+			t.Fatalf("expected %s to be %s got %v", k, v, tc.md)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	ctx := Extract(context.TODO(), map[string]string{
+		"x-request-id": "abc",
+		"X-Other":      "1",
+	})
+
+	h, ok := FromContext(ctx)
+	if !ok || h["x-request-id"] != "abc" || len(h) != 1 {
+		t.Fatalf("expected request id to be propagated got %v", h)
+	}
+
+	header := map[string]string{"X-Request-Id": "def"}
+	Inject(ctx, header)
+	if len(header) != 1 || header["X-Request-Id"] != "def" {
+		t.Fatalf("expected header set on the message to be kept got %v", header)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	incoming := map[string]string{
+		"X-Request-Id":  "abc",
+		"Authorization": "Bearer secret",
+	}
+
+	// not propagated by default
+	h, _ := FromContext(Extract(context.TODO(), incoming))
+	if _, ok := h["Authorization"]; ok {
+		t.Fatalf("expected authorization not to be propagated by default got %v", h)
+	}
+
+	ctx := Extract(context.TODO(), incoming, Allow("Authorization"))
+
+	tc := &testClient{}
+	c := NewClientWrapper()(tc)
+
+	if err := c.Call(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tc.md["Authorization"] != "Bearer secret" {
+		t.Fatalf("expected allowed authorization on calls got %v", tc.md)
+	}
+
+	// never published unless allowed for publishes
+	if err := c.Publish(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tc.md["Authorization"]; ok || tc.md["X-Request-Id"] != "abc" {
+		t.Fatalf("expected authorization to be dropped from publications got %v", tc.md)
+	}
+
+	header := map[string]string{}
+	Inject(ctx, header)
+	if _, ok := header["Authorization"]; ok {
+		t.Fatalf("expected authorization not to be injected got %v", header)
+	}
+
+	c = NewClientWrapper(AllowPublish("Authorization"))(tc)
+	if err := c.Publish(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if tc.md["Authorization"] != "Bearer secret" {
+		t.Fatalf("expected authorization allowed on publications got %v", tc.md)
+	}
+
+	header = map[string]string{}
+	Inject(ctx, header, AllowPublish("Authorization"))
+	if header["Authorization"] != "Bearer secret" {
+		t.Fatalf("expected authorization to be injected got %v", header)
+	}
+}
@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// KeyFunc returns the bucket key for a call
+type KeyFunc func(ctx context.Context, service, endpoint string) string
+
+// Options for the redis rate limiter
+type Options struct {
+	// Pool of redis connections holding the bucket state
+	Pool *redis.Pool
+	// Prefix for the bucket keys
+	Prefix string
+	// Rate is the number of tokens added per second
+	Rate float64
+	// Capacity is the maximum number of tokens in a bucket
+	Capacity int64
+	// Key returns the bucket key, defaults to service and endpoint
+	Key KeyFunc
+	// Fallback enables a local in-memory bucket when redis is unavailable.
+	// When disabled calls are allowed through if redis fails.
+	Fallback bool
+	// Timeout for the redis round trip. It's applied to each command,
+	// including those on connections from a custom Pool.
+	Timeout time.Duration
+}
+
+type Option func(*Options)
+
+// Pool sets the redis connection pool
+func Pool(p *redis.Pool) Option {
+	return func(o *Options) {
+		o.Pool = p
+	}
+}
+
+// Prefix sets the key prefix
+func Prefix(p string) Option {
+	return func(o *Options) {
+		o.Prefix = p
+	}
+}
+
+// Rate sets the tokens per second and bucket capacity
+func Rate(rate float64, capacity int64) Option {
+	return func(o *Options) {
+		o.Rate = rate
+		o.Capacity = capacity
+	}
+}
+
+// Key sets the function used to derive bucket keys
+func Key(fn KeyFunc) Option {
+	return func(o *Options) {
+		o.Key = fn
+	}
+}
+
+// Fallback enables local rate limiting when redis is unavailable
+func Fallback(b bool) Option {
+	return func(o *Options) {
+		o.Fallback = b
+	}
+}
+
+// Timeout sets the redis round trip timeout
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Prefix:   "micro:ratelimit:",
+		Rate:     100,
+		Capacity: 100,
+		Key:      endpointKey,
+		Fallback: true,
+		Timeout:  time.Millisecond * 50,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Pool == nil {
+		options.Pool = &redis.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 240 * time.Second,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", "127.0.0.1:6379",
+					redis.DialConnectTimeout(options.Timeout),
+					redis.DialReadTimeout(options.Timeout),
+					redis.DialWriteTimeout(options.Timeout),
+				)
+			},
+		}
+	}
+
+	return options
+}
@@ -0,0 +1,194 @@
+// Package redis provides a distributed token bucket rate limiter backed by redis.
+// Bucket state is shared across the fleet so limits apply to all instances.
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/juju/ratelimit"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+// tokenBucket refills the bucket based on the time elapsed since the
+// last call and takes a token if one is available. It returns 1 when
+// a token was taken and 0 otherwise. The time is taken from redis so
+// clock skew between instances doesn't affect the refill.
+var tokenBucket = redis.NewScript(1, `
+redis.replicate_commands()
+
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local clock = redis.call("TIME")
+local now = tonumber(clock[1]) * 1000000 + tonumber(clock[2])
+
+local bucket = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + (elapsed * rate / 1000000))
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HMSET", key, "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", key, math.ceil(capacity / rate * 1000) + 1000)
+
+return allowed
+`)
+
+type limiter struct {
+	opts Options
+
+	sync.Mutex
+	local map[string]*bucket
+	// swept is when idle local buckets were last removed
+	swept time.Time
+}
+
+type bucket struct {
+	*ratelimit.Bucket
+	used time.Time
+}
+
+// timeoutConn applies the timeout to each command so it holds for
+// connections from a custom pool which may not set read timeouts
+type timeoutConn struct {
+	redis.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return redis.DoWithTimeout(c.Conn, c.timeout, cmd, args...)
+}
+
+type clientWrapper struct {
+	l *limiter
+	client.Client
+}
+
+// endpointKey keys the bucket by service and endpoint
+func endpointKey(ctx context.Context, service, endpoint string) string {
+	return service + ":" + endpoint
+}
+
+// CallerKey keys the bucket by the calling service, taken from the
+// metadata key, as well as the service and endpoint being called
+func CallerKey(key string) KeyFunc {
+	return func(ctx context.Context, service, endpoint string) string {
+		caller := "unknown"
+		if md, ok := metadata.FromContext(ctx); ok {
+			if v, ok := md[key]; ok && len(v) > 0 {
+				caller = v
+			}
+		}
+		return caller + ":" + service + ":" + endpoint
+	}
+}
+
+func (l *limiter) fallback(key string) bool {
+	if !l.opts.Fallback {
+		return true
+	}
+
+	now := time.Now()
+
+	l.Lock()
+	l.sweep(now)
+	b, ok := l.local[key]
+	if !ok {
+		b = &bucket{Bucket: ratelimit.NewBucketWithRate(l.opts.Rate, l.opts.Capacity)}
+		l.local[key] = b
+	}
+	b.used = now
+	l.Unlock()
+
+	return b.TakeAvailable(1) > 0
+}
+
+// sweep removes local buckets which have been idle long enough to
+// refill. They're equivalent to a new bucket so limits are unaffected
+// while the map only holds the keys seen within the refill period.
+func (l *limiter) sweep(now time.Time) {
+	refill := time.Duration(float64(l.opts.Capacity) / l.opts.Rate * float64(time.Second))
+	if now.Sub(l.swept) < refill {
+		return
+	}
+	for k, b := range l.local {
+		if now.Sub(b.used) >= refill {
+			delete(l.local, k)
+		}
+	}
+	l.swept = now
+}
+
+func (l *limiter) allow(ctx context.Context, service, endpoint string) bool {
+	key := l.opts.Key(ctx, service, endpoint)
+
+	conn := l.opts.Pool.Get()
+	defer conn.Close()
+
+	var c redis.Conn = conn
+	if l.opts.Timeout > 0 {
+		c = &timeoutConn{conn, l.opts.Timeout}
+	}
+
+	allowed, err := redis.Int(tokenBucket.Do(c, l.opts.Prefix+key, l.opts.Rate, l.opts.Capacity))
+	if err != nil {
+		return l.fallback(key)
+	}
+
+	return allowed == 1
+}
+
+func newLimiter(opts ...Option) *limiter {
+	return &limiter{
+		opts:  newOptions(opts...),
+		local: make(map[string]*bucket),
+	}
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	if !c.l.allow(ctx, req.Service(), req.Method()) {
+		return errors.New("go.micro.client", "too many request", 429)
+	}
+	return c.Client.Call(ctx, req, rsp, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which enforces a fleet wide rate limit
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	l := newLimiter(opts...)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{l, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which enforces a fleet wide rate limit
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	l := newLimiter(opts...)
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if !l.allow(ctx, req.Service(), req.Method()) {
+				return errors.New("go.micro.server", "too many request", 429)
+			}
+			return h(ctx, req, rsp)
+		}
+	}
+}
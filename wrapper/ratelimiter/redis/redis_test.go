@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/micro/go-micro/metadata"
+)
+
+func TestFallback(t *testing.T) {
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+
+	l := newLimiter(Pool(pool), Rate(1, 2))
+
+	for i := 0; i < 2; i++ {
+		if !l.allow(context.TODO(), "test.service", "Test.Method") {
+			t.Fatalf("expected call %d to be allowed", i)
+		}
+	}
+
+	if l.allow(context.TODO(), "test.service", "Test.Method") {
+		t.Fatal("expected call to be limited by the local bucket")
+	}
+
+	// buckets are per key
+	if !l.allow(context.TODO(), "test.service", "Test.Other") {
+		t.Fatal("expected call to another endpoint to be allowed")
+	}
+}
+
+func TestFailOpen(t *testing.T) {
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+
+	l := newLimiter(Pool(pool), Rate(1, 1), Fallback(false))
+
+	for i := 0; i < 5; i++ {
+		if !l.allow(context.TODO(), "test.service", "Test.Method") {
+			t.Fatalf("expected call %d to be allowed", i)
+		}
+	}
+}
+
+func TestCallerKey(t *testing.T) {
+	fn := CallerKey("X-Micro-From-Service")
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"X-Micro-From-Service": "go.micro.srv.foo",
+	})
+
+	if k := fn(ctx, "test.service", "Test.Method"); k != "go.micro.srv.foo:test.service:Test.Method" {
+		t.Fatalf("unexpected key %s", k)
+	}
+
+	if k := fn(context.TODO(), "test.service", "Test.Method"); k != "unknown:test.service:Test.Method" {
+		t.Fatalf("unexpected key %s", k)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+
+	l := newLimiter(Pool(pool), Rate(10, 1))
+
+	l.allow(context.TODO(), "test.service", "Test.Method")
+	if len(l.local) != 1 {
+		t.Fatalf("expected 1 local bucket got %d", len(l.local))
+	}
+
+	// buckets idle for the refill period are removed
+	l.sweep(time.Now().Add(time.Second))
+	if len(l.local) != 0 {
+		t.Fatalf("expected idle buckets to be removed got %d", len(l.local))
+	}
+}
@@ -0,0 +1,75 @@
+package recovery
+
+import (
+	"github.com/micro/go-micro/server"
+)
+
+// Options for the recovery wrappers
+type Options struct {
+	// Reporters crash reports are sent to, defaults to the LogReporter
+	Reporters []Reporter
+	// Headers is the allowlist of metadata keys included in reports
+	Headers []string
+	// Repanic re-raises panics after they're reported
+	Repanic bool
+	// Service name subscriber reports are sent for,
+	// defaults to the name of the default server
+	Service string
+}
+
+type Option func(*Options)
+
+// WithReporter adds a reporter crash reports are sent to,
+// replacing the default LogReporter
+func WithReporter(r Reporter) Option {
+	return func(o *Options) {
+		o.Reporters = append(o.Reporters, r)
+	}
+}
+
+// Headers sets the metadata keys included in reports
+func Headers(keys ...string) Option {
+	return func(o *Options) {
+		o.Headers = keys
+	}
+}
+
+// Repanic re-raises panics once reported rather than
+// returning an internal server error
+func Repanic(b bool) Option {
+	return func(o *Options) {
+		o.Repanic = b
+	}
+}
+
+// WithService sets the service name of subscriber reports
+func WithService(name string) Option {
+	return func(o *Options) {
+		o.Service = name
+	}
+}
+
+// service returns the configured service name or the default server's,
+// read on each call as the server is named after wrappers are created
+func (o Options) service() string {
+	if len(o.Service) > 0 {
+		return o.Service
+	}
+	return server.DefaultServer.Options().Name
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Headers: []string{"X-Micro-From-Service", "X-Request-Id", "Traceparent", "X-B3-Traceid", "Uber-Trace-Id"},
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if len(options.Reporters) == 0 {
+		options.Reporters = []Reporter{LogReporter()}
+	}
+
+	return options
+}
@@ -0,0 +1,131 @@
+// Package recovery provides wrappers which recover handler and subscriber
+// panics, returning an internal server error and sending a crash report
+// with the stack trace and request context to pluggable reporters.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/pborman/uuid"
+)
+
+// Report is the crash report of a recovered panic
+type Report struct {
+	// Id is returned in the error so callers can quote it
+	Id       string            `json:"id"`
+	Time     time.Time         `json:"time"`
+	Kind     string            `json:"kind"`
+	Service  string            `json:"service"`
+	Endpoint string            `json:"endpoint"`
+	Panic    string            `json:"panic"`
+	Stack    string            `json:"stack"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+var (
+	mtx     sync.Mutex
+	crashes = make(map[string]uint64)
+)
+
+// Crashes returns the number of panics recovered keyed by kind and
+// endpoint, e.g. handler:Greeter.Hello or subscriber:go.micro.topic
+func Crashes() map[string]uint64 {
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	c := make(map[string]uint64, len(crashes))
+	for k, v := range crashes {
+		c[k] = v
+	}
+	return c
+}
+
+// headers returns the allowlisted metadata, ignoring case
+func headers(ctx context.Context, keys []string) map[string]string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	var h map[string]string
+	for k, v := range md {
+		for _, key := range keys {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			if h == nil {
+				h = make(map[string]string)
+			}
+			h[key] = v
+		}
+	}
+	return h
+}
+
+// recover reports a panic and either re-raises it or turns it into an error
+func (o Options) recover(ctx context.Context, kind, service, endpoint string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	report := &Report{
+		Id:       uuid.NewUUID().String(),
+		Time:     time.Now(),
+		Kind:     kind,
+		Service:  service,
+		Endpoint: endpoint,
+		Panic:    fmt.Sprintf("%v", r),
+		Stack:    string(debug.Stack()),
+		Metadata: headers(ctx, o.Headers),
+	}
+
+	mtx.Lock()
+	crashes[kind+":"+endpoint]++
+	mtx.Unlock()
+
+	for _, rp := range o.Reporters {
+		send(rp, report)
+	}
+
+	if o.Repanic {
+		panic(r)
+	}
+
+	*err = errors.InternalServerError(service, "panic recovered, crash report %s", report.Id)
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which recovers
+// panics, returning them as internal server errors
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) (err error) {
+			defer options.recover(ctx, "handler", req.Service(), req.Method(), &err)
+			return fn(ctx, req, rsp)
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which recovers
+// panics, returning them as errors so the message isn't acked and the
+// subscription keeps consuming
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) (err error) {
+			defer options.recover(ctx, "subscriber", options.service(), msg.Topic(), &err)
+			return fn(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Panic"
+}
+
+type testMessage struct {
+	server.Message
+}
+
+func (t *testMessage) Topic() string {
+	return "test.topic"
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	var reports []*Report
+
+	fn := NewHandlerWrapper(WithReporter(ReporterFunc(func(r *Report) {
+		reports = append(reports, r)
+	})))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		panic("boom")
+	})
+
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{
+		"X-Request-Id":  "1",
+		"Authorization": "secret",
+	})
+
+	err := fn(ctx, &testRequest{}, nil)
+	merr, ok := err.(*errors.Error)
+	if !ok || merr.Code != 500 {
+		t.Fatalf("expected internal server error got %v", err)
+	}
+
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report got %d", len(reports))
+	}
+
+	r := reports[0]
+	if !strings.Contains(merr.Detail, r.Id) {
+		t.Fatalf("expected report id %s in error %s", r.Id, merr.Detail)
+	}
+	if r.Panic != "boom" || r.Endpoint != "Test.Panic" {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if !strings.Contains(r.Stack, "recovery_test.go") {
+		t.Fatalf("expected stack to include the panic site got %s", r.Stack)
+	}
+	if r.Metadata["X-Request-Id"] != "1" {
+		t.Fatalf("expected request id got %v", r.Metadata)
+	}
+	if _, ok := r.Metadata["Authorization"]; ok {
+		t.Fatal("unexpected header outside the allowlist")
+	}
+	if c := Crashes()["handler:Test.Panic"]; c != 1 {
+		t.Fatalf("expected 1 crash got %d", c)
+	}
+}
+
+func TestSubscriberWrapper(t *testing.T) {
+	var buf bytes.Buffer
+
+	fn := NewSubscriberWrapper(WithService("test.service"), WithReporter(JSONReporter(&buf)))(func(ctx context.Context, msg server.Message) error {
+		var m map[string]string
+		m["a"] = "b"
+		return nil
+	})
+
+	if err := fn(context.TODO(), &testMessage{}); err == nil {
+		t.Fatal("expected error from recovered panic")
+	}
+
+	var r Report
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Kind != "subscriber" || r.Service != "test.service" || r.Endpoint != "test.topic" {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if c := Crashes()["subscriber:test.topic"]; c != 1 {
+		t.Fatalf("expected 1 crash got %d", c)
+	}
+}
+
+func TestReporterPanic(t *testing.T) {
+	var reported bool
+
+	fn := NewHandlerWrapper(
+		WithReporter(ReporterFunc(func(*Report) {
+			panic("broken reporter")
+		})),
+		WithReporter(ReporterFunc(func(*Report) {
+			reported = true
+		})),
+	)(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		panic("boom")
+	})
+
+	if err := fn(context.TODO(), &testRequest{}, nil); err == nil {
+		t.Fatal("expected error from recovered panic")
+	}
+	if !reported {
+		t.Fatal("expected the remaining reporters to be called")
+	}
+}
+
+func TestRepanic(t *testing.T) {
+	fn := NewHandlerWrapper(Repanic(true), WithReporter(ReporterFunc(func(*Report) {})))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		panic("boom")
+	})
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("expected re-raised panic got %v", r)
+		}
+	}()
+
+	fn(context.TODO(), &testRequest{}, nil)
+}
@@ -0,0 +1,62 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/micro/go-log"
+)
+
+// Reporter receives the report of every recovered panic
+type Reporter interface {
+	Report(r *Report)
+}
+
+// ReporterFunc is a func used as a Reporter
+type ReporterFunc func(r *Report)
+
+type logReporter struct{}
+
+type jsonReporter struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+func (f ReporterFunc) Report(r *Report) {
+	f(r)
+}
+
+// send sends the report to the reporter, recovering
+// its panics so a broken reporter can't crash the service
+func send(rp Reporter, r *Report) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Logf("[recovery] reporter panicked sending crash report %s: %v", r.Id, p)
+		}
+	}()
+	rp.Report(r)
+}
+
+func (l *logReporter) Report(r *Report) {
+	log.Logf("[recovery] %s panic in %s %s (%s): %s\n%s", r.Kind, r.Service, r.Endpoint, r.Id, r.Panic, r.Stack)
+}
+
+func (j *jsonReporter) Report(r *Report) {
+	j.Lock()
+	defer j.Unlock()
+
+	if err := j.enc.Encode(r); err != nil {
+		log.Logf("[recovery] failed to write crash report %s: %v", r.Id, err)
+	}
+}
+
+// LogReporter logs reports with the go-log logger
+func LogReporter() Reporter {
+	return &logReporter{}
+}
+
+// JSONReporter writes reports to w as json lines
+func JSONReporter(w io.Writer) Reporter {
+	return &jsonReporter{enc: json.NewEncoder(w)}
+}
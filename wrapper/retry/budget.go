@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"sync"
+)
+
+// budget is a token bucket filled by a fraction of a token per call
+// and drained by a token per retry so retries can't exceed a ratio
+// of the traffic, preventing retry storms during an outage
+type budget struct {
+	sync.Mutex
+	ratio  float64
+	max    float64
+	tokens float64
+}
+
+func newBudget(ratio float64, burst int) *budget {
+	return &budget{
+		ratio:  ratio,
+		max:    float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// call deposits into the budget
+func (b *budget) call() {
+	b.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+	b.Unlock()
+}
+
+// retry withdraws from the budget and returns false if exhausted
+func (b *budget) retry() bool {
+	b.Lock()
+	defer b.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
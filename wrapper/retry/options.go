@@ -0,0 +1,173 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-plugins/client/grpc"
+	"github.com/micro/go-plugins/client/http"
+)
+
+// Class of error a retry policy applies to
+type Class int
+
+const (
+	// None is not retried
+	None Class = iota
+	// Timeout of the call
+	Timeout
+	// Unavailable service or node
+	Unavailable
+	// Conflict with a concurrent change
+	Conflict
+)
+
+func (c Class) String() string {
+	switch c {
+	case Timeout:
+		return "timeout"
+	case Unavailable:
+		return "unavailable"
+	case Conflict:
+		return "conflict"
+	default:
+		return "none"
+	}
+}
+
+// Policy for retrying a class of error
+type Policy struct {
+	// Attempts to retry after the first call
+	Attempts int
+	// Backoff is the base delay, doubled with each attempt
+	Backoff time.Duration
+	// MaxBackoff caps the delay
+	MaxBackoff time.Duration
+}
+
+// Options for the retry wrapper
+type Options struct {
+	// Policies per class of error
+	Policies map[Class]Policy
+	// Classify maps an error to its class
+	Classify func(err error) Class
+	// Budget is the ratio of retries to calls allowed per service
+	Budget float64
+	// RetryAfter returns the delay asked for by the server if any
+	RetryAfter func(err error) (time.Duration, bool)
+}
+
+type Option func(*Options)
+
+var (
+	// DefaultBudget allows retries to add 20% to the calls of a service
+	DefaultBudget = 0.2
+	// DefaultBudgetBurst is the number of retries the budget starts with
+	DefaultBudgetBurst = 10
+)
+
+// WithPolicy sets the policy for the class of error. A policy with
+// no attempts disables retries for the class.
+func WithPolicy(c Class, p Policy) Option {
+	return func(o *Options) {
+		o.Policies[c] = p
+	}
+}
+
+// Classify sets the function mapping errors to their class
+func Classify(fn func(err error) Class) Option {
+	return func(o *Options) {
+		o.Classify = fn
+	}
+}
+
+// Budget sets the ratio of retries to calls allowed per service
+func Budget(ratio float64) Option {
+	return func(o *Options) {
+		o.Budget = ratio
+	}
+}
+
+// RetryAfter sets the function returning the delay asked for by the server
+func RetryAfter(fn func(err error) (time.Duration, bool)) Option {
+	return func(o *Options) {
+		o.RetryAfter = fn
+	}
+}
+
+// classify maps go-micro error codes to their class
+func classify(err error) Class {
+	switch err {
+	case context.DeadlineExceeded:
+		return Timeout
+	case context.Canceled:
+		return None
+	}
+
+	e := errors.Parse(err.Error())
+	switch e.Code {
+	case 408, 504:
+		return Timeout
+	case 429, 502, 503:
+		return Unavailable
+	case 409:
+		return Conflict
+	case 500:
+		// connection errors raised by the client itself
+		if e.Id == "go.micro.client" {
+			return Unavailable
+		}
+	}
+	return None
+}
+
+// retryAfter uses the delay of grpc retry info, a http Retry-After
+// header or errors implementing RetryAfter
+func retryAfter(err error) (time.Duration, bool) {
+	if d, ok := grpc.RetryAfter(err); ok {
+		return d, true
+	}
+	if d, ok := http.RetryAfter(err); ok {
+		return d, true
+	}
+	if r, ok := err.(interface {
+		RetryAfter() (time.Duration, bool)
+	}); ok {
+		return r.RetryAfter()
+	}
+	return 0, false
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Policies: map[Class]Policy{
+			// timed out calls may have succeeded so aren't
+			// retried unless a policy is set
+			Timeout: {
+				Attempts:   0,
+				Backoff:    time.Millisecond * 50,
+				MaxBackoff: time.Second,
+			},
+			Unavailable: {
+				Attempts:   3,
+				Backoff:    time.Millisecond * 50,
+				MaxBackoff: time.Second,
+			},
+			Conflict: {
+				Attempts:   2,
+				Backoff:    time.Millisecond * 100,
+				MaxBackoff: time.Second * 2,
+			},
+		},
+		Classify:   classify,
+		Budget:     DefaultBudget,
+		RetryAfter: retryAfter,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
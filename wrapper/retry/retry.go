@@ -0,0 +1,107 @@
+// Package retry provides a client wrapper which retries calls according
+// to a policy per class of error, backing off exponentially with jitter,
+// honouring the delay asked for by the server and limited by a retry
+// budget per service.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/micro/go-log"
+	"github.com/micro/go-micro/client"
+)
+
+type retryWrapper struct {
+	opts Options
+
+	sync.Mutex
+	budgets map[string]*budget
+	client.Client
+}
+
+func (r *retryWrapper) budget(service string) *budget {
+	r.Lock()
+	defer r.Unlock()
+
+	b, ok := r.budgets[service]
+	if !ok {
+		b = newBudget(r.opts.Budget, DefaultBudgetBurst)
+		r.budgets[service] = b
+	}
+	return b
+}
+
+// backoff returns a random delay up to the base doubled per attempt
+func backoff(p Policy, attempt int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+func (r *retryWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	b := r.budget(req.Service())
+	b.call()
+
+	// retries are handled here rather than by the client
+	copts := make([]client.CallOption, 0, len(opts)+1)
+	copts = append(copts, opts...)
+	copts = append(copts, client.WithRetries(0))
+
+	for i := 0; ; i++ {
+		err := r.Client.Call(ctx, req, rsp, copts...)
+		if err == nil {
+			return nil
+		}
+
+		class := r.opts.Classify(err)
+		p, ok := r.opts.Policies[class]
+		if !ok || i >= p.Attempts {
+			return err
+		}
+
+		if !b.retry() {
+			log.Logf("[retry] budget exhausted for %s", req.Service())
+			return err
+		}
+
+		d := backoff(p, i)
+		if ra, ok := r.opts.RetryAfter(err); ok {
+			d = ra
+		}
+
+		// don't wait beyond the deadline
+		if dl, ok := ctx.Deadline(); ok && time.Now().Add(d).After(dl) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+	}
+}
+
+// NewClientWrapper returns a client Wrapper which retries failed calls
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &retryWrapper{
+			opts:    options,
+			budgets: make(map[string]*budget),
+			Client:  c,
+		}
+	}
+}
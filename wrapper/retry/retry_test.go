@@ -0,0 +1,138 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+)
+
+type testRequest struct {
+	client.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+type testClient struct {
+	client.Client
+	errs  []error
+	calls int
+}
+
+// returns the errors in order then succeeds
+func (t *testClient) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	t.calls++
+	if t.calls <= len(t.errs) {
+		return t.errs[t.calls-1]
+	}
+	return nil
+}
+
+type retryAfterError struct {
+	*errors.Error
+	d time.Duration
+}
+
+func (r *retryAfterError) RetryAfter() (time.Duration, bool) {
+	return r.d, true
+}
+
+var fast = Policy{Attempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond * 5}
+
+func TestRetry(t *testing.T) {
+	testData := []struct {
+		name  string
+		errs  []error
+		calls int
+		err   bool
+	}{
+		{"unavailable", []error{errors.New("test", "down", 503)}, 2, false},
+		{"conflict", []error{errors.Conflict("test", "conflict"), errors.Conflict("test", "conflict")}, 3, false},
+		{"attempts", []error{errors.Timeout("test", "slow"), errors.Timeout("test", "slow"), errors.Timeout("test", "slow")}, 3, true},
+		{"not retried", []error{errors.BadRequest("test", "bad")}, 1, true},
+	}
+
+	for _, d := range testData {
+		tc := &testClient{errs: d.errs}
+		c := NewClientWrapper(
+			WithPolicy(Timeout, fast),
+			WithPolicy(Unavailable, fast),
+			WithPolicy(Conflict, fast),
+		)(tc)
+
+		err := c.Call(context.TODO(), &testRequest{}, nil)
+		if (err != nil) != d.err {
+			t.Fatalf("%s: unexpected error %v", d.name, err)
+		}
+		if tc.calls != d.calls {
+			t.Fatalf("%s: expected %d calls got %d", d.name, d.calls, tc.calls)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tc := &testClient{errs: []error{
+		&retryAfterError{errors.New("test", "slow down", 429), time.Millisecond * 50},
+	}}
+	c := NewClientWrapper(WithPolicy(Unavailable, fast))(tc)
+
+	start := time.Now()
+	if err := c.Call(context.TODO(), &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < time.Millisecond*50 {
+		t.Fatal("expected retry after delay to be honoured")
+	}
+
+	// a delay beyond the deadline isn't waited for
+	tc = &testClient{errs: []error{
+		&retryAfterError{errors.New("test", "slow down", 429), time.Minute},
+	}}
+	c = NewClientWrapper(WithPolicy(Unavailable, fast))(tc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := c.Call(ctx, &testRequest{}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if tc.calls != 1 {
+		t.Fatalf("expected 1 call got %d", tc.calls)
+	}
+}
+
+func TestDefaultRetryAfter(t *testing.T) {
+	// the Retry-After header of a http response
+	err := errors.New("go.micro.client", `{"detail":"busy","retry_after":"1"}`, 503)
+	if d, ok := retryAfter(err); !ok || d != time.Second {
+		t.Fatalf("expected retry after 1s got %v", d)
+	}
+
+	if _, ok := retryAfter(errors.New("test", "busy", 503)); ok {
+		t.Fatal("expected no retry after")
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := newBudget(0.5, 2)
+
+	if !b.retry() || !b.retry() {
+		t.Fatal("expected burst to allow retries")
+	}
+	if b.retry() {
+		t.Fatal("expected budget to be exhausted")
+	}
+
+	b.call()
+	if b.retry() {
+		t.Fatal("expected half a token to be insufficient")
+	}
+	b.call()
+	if !b.retry() {
+		t.Fatal("expected two calls to allow a retry")
+	}
+}
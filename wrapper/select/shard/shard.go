@@ -2,7 +2,9 @@
 package shard
 
 import (
-	"hash/crc32"
+	"hash/fnv"
+	"sort"
+	"strings"
 
 	"github.com/micro/go-micro/client"
 	"github.com/micro/go-micro/metadata"
@@ -13,56 +15,104 @@ import (
 )
 
 type shard struct {
-	key string
+	keys []string
 	client.Client
 }
 
-func (s *shard) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+// value returns the value of the first key present in the metadata,
+// matching keys case insensitively
+func value(md metadata.Metadata, keys []string) string {
+	for _, key := range keys {
+		if v, ok := md[key]; ok && len(v) > 0 {
+			return v
+		}
+		for k, v := range md {
+			if strings.EqualFold(k, key) && len(v) > 0 {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// score is the rendezvous hash weight of the node for the value
+func score(node *registry.Node, val string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(node.Id))
+	h.Write([]byte{0})
+	h.Write([]byte(val))
+	return h.Sum64()
+}
+
+// Strategy returns a selector strategy using rendezvous (highest random
+// weight) hashing. Every node is scored against the value and the highest
+// wins, so when nodes join or leave only the values owned by those nodes
+// move. Subsequent calls to next return the runners up for retries.
+func Strategy(val string) selector.Strategy {
+	return func(services []*registry.Service) selector.Next {
+		// flatten
+		var nodes []*registry.Node
+		for _, service := range services {
+			nodes = append(nodes, service.Nodes...)
+		}
+
+		scores := make(map[string]uint64, len(nodes))
+		for _, node := range nodes {
+			scores[node.Id] = score(node, val)
+		}
+
+		sort.Slice(nodes, func(i, j int) bool {
+			return scores[nodes[i].Id] > scores[nodes[j].Id]
+		})
+
+		var i int
+
+		return func() (*registry.Node, error) {
+			if len(nodes) == 0 {
+				return nil, selector.ErrNoneAvailable
+			}
+			node := nodes[i%len(nodes)]
+			i++
+			return node, nil
+		}
+	}
+}
+
+func (s *shard) options(ctx context.Context, opts []client.CallOption) []client.CallOption {
 	// get headers
 	md, ok := metadata.FromContext(ctx)
 	if !ok {
 		// noop, defer to client
-		return s.Client.Call(ctx, req, rsp, opts...)
+		return opts
 	}
 
 	// get key val
-	val := md[s.key]
+	val := value(md, s.keys)
 
 	// noop on nil value
 	if len(val) == 0 {
-		return s.Client.Call(ctx, req, rsp, opts...)
+		return opts
 	}
 
-	// checksum it
-	cs := crc32.ChecksumIEEE([]byte(val))
-
-	nOpts := append(opts, client.WithSelectOption(
-		// create a selector strategy
-		selector.WithStrategy(func(services []*registry.Service) selector.Next {
-			// flatten
-			var nodes []*registry.Node
-			for _, service := range services {
-				nodes = append(nodes, service.Nodes...)
-			}
-
-			// create the next func that always returns our node
-			return func() (*registry.Node, error) {
-				if len(nodes) == 0 {
-					return nil, selector.ErrNoneAvailable
-				}
-				return nodes[cs%uint32(len(nodes))], nil
-			}
-		}),
+	return append(opts, client.WithSelectOption(
+		selector.WithStrategy(Strategy(val)),
 	))
+}
+
+func (s *shard) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return s.Client.Call(ctx, req, rsp, s.options(ctx, opts)...)
+}
 
-	return s.Client.Call(ctx, req, rsp, nOpts...)
+func (s *shard) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return s.Client.Stream(ctx, req, s.options(ctx, opts)...)
 }
 
-// NewClientWrapper is a wrapper which shards based on a header key value
-func NewClientWrapper(key string) client.Wrapper {
+// NewClientWrapper is a wrapper which shards based on a header key value.
+// Multiple keys can be given and the first present in the metadata is used.
+func NewClientWrapper(keys ...string) client.Wrapper {
 	return func(c client.Client) client.Client {
 		return &shard{
-			key:    key,
+			keys:   keys,
 			Client: c,
 		}
 	}
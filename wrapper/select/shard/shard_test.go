@@ -0,0 +1,56 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/micro/go-micro/registry"
+)
+
+func testServices(n int) []*registry.Service {
+	var nodes []*registry.Node
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, &registry.Node{Id: fmt.Sprintf("node-%d", i)})
+	}
+	return []*registry.Service{{Name: "foo", Nodes: nodes}}
+}
+
+func owner(t *testing.T, services []*registry.Service, val string) string {
+	node, err := Strategy(val)(services)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node.Id
+}
+
+func TestRendezvous(t *testing.T) {
+	before := testServices(5)
+	after := testServices(6)
+
+	var moved int
+	for i := 0; i < 1000; i++ {
+		val := fmt.Sprintf("session-%d", i)
+
+		a, b := owner(t, before, val), owner(t, after, val)
+		if a != b {
+			// only values taken by the new node move
+			if b != "node-5" {
+				t.Fatalf("value %s moved from %s to %s", val, a, b)
+			}
+			moved++
+		}
+	}
+
+	// roughly a sixth of the values move to the new node
+	if moved == 0 || moved > 300 {
+		t.Fatalf("unexpected number of values moved %d", moved)
+	}
+}
+
+func TestValue(t *testing.T) {
+	md := map[string]string{"X-From-User": "bob"}
+
+	if v := value(md, []string{"X-From-Session", "x-from-user"}); v != "bob" {
+		t.Fatalf("expected bob got %s", v)
+	}
+}
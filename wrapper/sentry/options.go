@@ -0,0 +1,76 @@
+package sentry
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Options for the sentry wrappers
+type Options struct {
+	// Hub events are captured on, defaults to the current hub
+	Hub *sentry.Hub
+	// Headers is the allowlist of metadata keys attached to events
+	Headers []string
+	// TraceKeys are the metadata keys checked for a trace id
+	TraceKeys []string
+	// Repanic re-raises recovered panics after they're captured
+	Repanic bool
+	// Timeout waits for events to be flushed before re-raising a panic
+	Timeout time.Duration
+	// Filter decides whether a returned error is reported, all errors
+	// are reported if nil
+	Filter func(error) bool
+}
+
+type Option func(*Options)
+
+// Hub sets the sentry hub events are captured on
+func Hub(h *sentry.Hub) Option {
+	return func(o *Options) {
+		o.Hub = h
+	}
+}
+
+// Headers sets the metadata keys attached to events
+func Headers(keys ...string) Option {
+	return func(o *Options) {
+		o.Headers = append(o.Headers, keys...)
+	}
+}
+
+// TraceKeys sets the metadata keys checked for a trace id
+func TraceKeys(keys ...string) Option {
+	return func(o *Options) {
+		o.TraceKeys = keys
+	}
+}
+
+// Repanic re-raises recovered panics, waiting up to the timeout
+// for the event to be delivered first
+func Repanic(b bool, timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Repanic = b
+		o.Timeout = timeout
+	}
+}
+
+// Filter sets the func deciding which errors are reported
+func Filter(fn func(error) bool) Option {
+	return func(o *Options) {
+		o.Filter = fn
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		TraceKeys: []string{"Traceparent", "X-B3-Traceid", "Uber-Trace-Id", "X-Amzn-Trace-Id"},
+		Timeout:   2 * time.Second,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
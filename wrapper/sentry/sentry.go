@@ -0,0 +1,157 @@
+// Package sentry provides wrappers which report errors and panics to Sentry
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+// header returns the metadata value for key, ignoring case
+func header(md metadata.Metadata, key string) (string, bool) {
+	if v, ok := md[key]; ok {
+		return v, true
+	}
+	for k, v := range md {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// traceID extracts the trace id from the value of a trace header,
+// e.g. the 32 hex digits of a W3C traceparent
+func traceID(key, v string) string {
+	switch strings.ToLower(key) {
+	case "traceparent":
+		// version-traceid-parentid-flags
+		if parts := strings.Split(v, "-"); len(parts) >= 4 {
+			return parts[1]
+		}
+	case "uber-trace-id":
+		// traceid:spanid:parentid:flags
+		if parts := strings.Split(v, ":"); len(parts) == 4 {
+			return parts[0]
+		}
+	case "x-amzn-trace-id":
+		// Root=1-xxxxxxxx-xxxxxxxxxxxxxxxxxxxxxxxx;Parent=...;Sampled=1
+		for _, p := range strings.Split(v, ";") {
+			if strings.HasPrefix(p, "Root=") {
+				return strings.TrimPrefix(p, "Root=")
+			}
+		}
+	}
+	return v
+}
+
+// hub builds the hub for a single request
+func (o Options) hub(ctx context.Context, service, endpoint string) *sentry.Hub {
+	hub := o.Hub
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+
+	md, _ := metadata.FromContext(ctx)
+
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetTag("micro.service", service)
+		scope.SetTag("micro.endpoint", endpoint)
+		scope.SetTransaction(endpoint)
+
+		for _, k := range o.TraceKeys {
+			if v, ok := header(md, k); ok {
+				scope.SetTag("trace_id", traceID(k, v))
+				break
+			}
+		}
+
+		headers := make(map[string]interface{})
+		for _, k := range o.Headers {
+			if v, ok := header(md, k); ok {
+				headers[k] = v
+			}
+		}
+		if len(headers) > 0 {
+			scope.SetContext("metadata", headers)
+		}
+	})
+
+	return hub
+}
+
+// report captures a returned error
+func (o Options) report(hub *sentry.Hub, err error) {
+	if err == nil {
+		return
+	}
+	if o.Filter != nil && !o.Filter(err) {
+		return
+	}
+	if merr, ok := err.(*errors.Error); ok {
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetTag("micro.error.code", fmt.Sprintf("%d", merr.Code))
+			scope.SetTag("micro.error.id", merr.Id)
+			hub.CaptureException(err)
+		})
+		return
+	}
+	hub.CaptureException(err)
+}
+
+// recover captures a panic and either re-raises it or turns it into an error
+func (o Options) recover(hub *sentry.Hub, id string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	hub.RecoverWithContext(context.Background(), r)
+
+	if o.Repanic {
+		hub.Flush(o.Timeout)
+		panic(r)
+	}
+
+	*err = errors.InternalServerError(id, "panic recovered: %v", r)
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which reports
+// returned errors and panics to sentry
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) (err error) {
+			hub := options.hub(ctx, req.Service(), req.Method())
+			defer options.recover(hub, req.Service(), &err)
+
+			err = fn(sentry.SetHubOnContext(ctx, hub), req, rsp)
+			options.report(hub, err)
+			return err
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which reports
+// returned errors and panics to sentry
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) (err error) {
+			hub := options.hub(ctx, msg.Topic(), msg.Topic())
+			defer options.recover(hub, msg.Topic(), &err)
+
+			err = fn(sentry.SetHubOnContext(ctx, hub), msg)
+			options.report(hub, err)
+			return err
+		}
+	}
+}
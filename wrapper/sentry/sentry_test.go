@@ -0,0 +1,131 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testTransport struct {
+	events []*sentry.Event
+}
+
+func (t *testTransport) Configure(sentry.ClientOptions) {}
+
+func (t *testTransport) SendEvent(e *sentry.Event) {
+	t.events = append(t.events, e)
+}
+
+func (t *testTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func newHub(t *testing.T) (*sentry.Hub, *testTransport) {
+	tr := &testTransport{}
+	c, err := sentry.NewClient(sentry.ClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sentry.NewHub(c, sentry.NewScope()), tr
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	hub, tr := newHub(t)
+
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{
+		"X-B3-Traceid":  "abc",
+		"Authorization": "secret",
+		"User-Agent":    "test",
+	})
+
+	fn := NewHandlerWrapper(Hub(hub), Headers("user-agent"))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return errors.New("failed")
+	})
+
+	if err := fn(ctx, &testRequest{}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(tr.events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(tr.events))
+	}
+
+	e := tr.events[0]
+	if e.Tags["trace_id"] != "abc" {
+		t.Fatalf("expected trace id abc got %s", e.Tags["trace_id"])
+	}
+	if e.Tags["micro.endpoint"] != "Test.Method" {
+		t.Fatalf("unexpected endpoint %s", e.Tags["micro.endpoint"])
+	}
+
+	headers, _ := e.Contexts["metadata"].(map[string]interface{})
+	if headers["user-agent"] != "test" {
+		t.Fatalf("expected user-agent header got %v", headers)
+	}
+	if _, ok := headers["Authorization"]; ok {
+		t.Fatal("unexpected header outside the allowlist")
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	testData := []struct {
+		key, value, id string
+	}{
+		{"Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"Uber-Trace-Id", "5b4a4e2d7c8f9a1b:6c7d8e9f:0:1", "5b4a4e2d7c8f9a1b"},
+		{"X-Amzn-Trace-Id", "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", "1-5759e988-bd862e3fe1be46a994272793"},
+		{"X-B3-Traceid", "abc", "abc"},
+		{"Traceparent", "invalid", "invalid"},
+	}
+
+	for _, d := range testData {
+		if id := traceID(d.key, d.value); id != d.id {
+			t.Fatalf("expected trace id %s for %s: %s got %s", d.id, d.key, d.value, id)
+		}
+	}
+}
+
+func TestHandlerWrapperPanic(t *testing.T) {
+	hub, tr := newHub(t)
+
+	fn := NewHandlerWrapper(Hub(hub))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		panic("boom")
+	})
+
+	if err := fn(context.TODO(), &testRequest{}, nil); err == nil {
+		t.Fatal("expected error from recovered panic")
+	}
+	if len(tr.events) != 1 {
+		t.Fatalf("expected 1 event got %d", len(tr.events))
+	}
+
+	fn = NewHandlerWrapper(Hub(hub), Repanic(true, 0))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		panic("boom")
+	})
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("expected re-raised panic got %v", r)
+		}
+	}()
+
+	fn(context.TODO(), &testRequest{}, nil)
+}
@@ -0,0 +1,258 @@
+// Package store provides the expiring response stores shared by the
+// cache and idempotency wrappers
+package store
+
+import (
+	"container/heap"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// Store holds encoded responses by key until they expire
+type Store interface {
+	// Get returns the value of the key, nil if not found
+	Get(key string) ([]byte, error)
+	// Set stores the value of the key for the ttl
+	Set(key string, b []byte, ttl time.Duration) error
+	// Add stores the value of the key for the ttl only if the key
+	// isn't set, returning false if it is
+	Add(key string, b []byte, ttl time.Duration) (bool, error)
+	// Remove removes the key
+	Remove(key string) error
+	// Delete removes all keys with the prefix
+	Delete(prefix string) error
+}
+
+type entry struct {
+	key     string
+	b       []byte
+	expires time.Time
+	// index in the expiry heap
+	index int
+}
+
+// expiry is a heap of entries ordered by expiry time
+type expiry []*entry
+
+func (e expiry) Len() int {
+	return len(e)
+}
+
+func (e expiry) Less(i, j int) bool {
+	return e[i].expires.Before(e[j].expires)
+}
+
+func (e expiry) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+	e[i].index = i
+	e[j].index = j
+}
+
+func (e *expiry) Push(x interface{}) {
+	en := x.(*entry)
+	en.index = len(*e)
+	*e = append(*e, en)
+}
+
+func (e *expiry) Pop() interface{} {
+	old := *e
+	n := len(old)
+	en := old[n-1]
+	old[n-1] = nil
+	*e = old[:n-1]
+	return en
+}
+
+// memoryStore expires entries in order of expiry on write,
+// so each write only removes the entries which have expired
+type memoryStore struct {
+	sync.RWMutex
+	entries map[string]*entry
+	expiry  expiry
+}
+
+func (m *memoryStore) Get(key string) ([]byte, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	e, ok := m.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, nil
+	}
+
+	return e.b, nil
+}
+
+func (m *memoryStore) Set(key string, b []byte, ttl time.Duration) error {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	m.expire(now)
+	m.set(key, b, now.Add(ttl))
+	return nil
+}
+
+func (m *memoryStore) Add(key string, b []byte, ttl time.Duration) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	m.expire(now)
+
+	if _, ok := m.entries[key]; ok {
+		return false, nil
+	}
+
+	m.set(key, b, now.Add(ttl))
+	return true, nil
+}
+
+func (m *memoryStore) Remove(key string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		heap.Remove(&m.expiry, e.index)
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// expire removes the expired entries, the lock must be held
+func (m *memoryStore) expire(now time.Time) {
+	for len(m.expiry) > 0 && now.After(m.expiry[0].expires) {
+		e := heap.Pop(&m.expiry).(*entry)
+		delete(m.entries, e.key)
+	}
+}
+
+// set stores the entry, the lock must be held
+func (m *memoryStore) set(key string, b []byte, expires time.Time) {
+	if e, ok := m.entries[key]; ok {
+		e.b = b
+		e.expires = expires
+		heap.Fix(&m.expiry, e.index)
+		return
+	}
+
+	e := &entry{key: key, b: b, expires: expires}
+	m.entries[key] = e
+	heap.Push(&m.expiry, e)
+}
+
+func (m *memoryStore) Delete(prefix string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for k, e := range m.entries {
+		if strings.HasPrefix(k, prefix) {
+			heap.Remove(&m.expiry, e.index)
+			delete(m.entries, k)
+		}
+	}
+	return nil
+}
+
+// NewMemoryStore returns a store local to the process
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]*entry),
+	}
+}
+
+type redisStore struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+func (r *redisStore) Get(key string) ([]byte, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	b, err := redis.Bytes(conn.Do("GET", r.prefix+key))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (r *redisStore) Set(key string, b []byte, ttl time.Duration) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SET", r.prefix+key, b, "PX", int64(ttl/time.Millisecond))
+	return err
+}
+
+func (r *redisStore) Add(key string, b []byte, ttl time.Duration) (bool, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	_, err := redis.String(conn.Do("SET", r.prefix+key, b, "PX", int64(ttl/time.Millisecond), "NX"))
+	if err == redis.ErrNil {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *redisStore) Remove(key string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", r.prefix+key)
+	return err
+}
+
+// escape escapes the glob characters of a SCAN MATCH pattern
+func escape(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b = append(b, '\\')
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+func (r *redisStore) Delete(prefix string) error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	cursor := 0
+	for {
+		v, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", escape(r.prefix+prefix)+"*", "COUNT", 100))
+		if err != nil {
+			return err
+		}
+
+		cursor, _ = redis.Int(v[0], nil)
+		keys, _ := redis.Strings(v[1], nil)
+
+		for _, k := range keys {
+			if _, err := conn.Do("DEL", k); err != nil {
+				return err
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// NewRedisStore returns a store shared across instances through
+// redis, the keys are prefixed with the prefix
+func NewRedisStore(pool *redis.Pool, prefix string) Store {
+	return &redisStore{
+		pool:   pool,
+		prefix: prefix,
+	}
+}
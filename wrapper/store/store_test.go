@@ -0,0 +1,74 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore().(*memoryStore)
+
+	s.Set("foo", []byte("bar"), time.Millisecond)
+	s.Set("baz", []byte("qux"), time.Hour)
+
+	time.Sleep(time.Millisecond * 5)
+
+	if b, _ := s.Get("foo"); b != nil {
+		t.Fatalf("expected expired entry got %s", b)
+	}
+
+	// writes remove the expired entries
+	s.Set("foo.bar", []byte("bar"), time.Hour)
+	if _, ok := s.entries["foo"]; ok {
+		t.Fatal("expected expired entry to be removed")
+	}
+
+	// setting again extends the expiry
+	s.Set("baz", []byte("quux"), time.Hour*2)
+	if b, _ := s.Get("baz"); string(b) != "quux" {
+		t.Fatalf("expected quux got %s", b)
+	}
+
+	s.Delete("foo")
+	if b, _ := s.Get("foo.bar"); b != nil {
+		t.Fatalf("expected deleted entry got %s", b)
+	}
+
+	if len(s.entries) != 1 || len(s.expiry) != 1 {
+		t.Fatalf("expected 1 entry got %d with %d in the heap", len(s.entries), len(s.expiry))
+	}
+}
+
+func TestMemoryStoreAdd(t *testing.T) {
+	s := NewMemoryStore()
+
+	if ok, _ := s.Add("foo", []byte("bar"), time.Millisecond); !ok {
+		t.Fatal("expected the key to be added")
+	}
+	if ok, _ := s.Add("foo", []byte("baz"), time.Hour); ok {
+		t.Fatal("expected the set key not to be added")
+	}
+
+	// expired keys may be added again
+	time.Sleep(time.Millisecond * 5)
+	if ok, _ := s.Add("foo", []byte("baz"), time.Hour); !ok {
+		t.Fatal("expected the expired key to be added")
+	}
+
+	s.Set("foo.bar", []byte("bar"), time.Hour)
+
+	// remove only removes the exact key
+	s.Remove("foo")
+	if b, _ := s.Get("foo"); b != nil {
+		t.Fatalf("expected removed entry got %s", b)
+	}
+	if b, _ := s.Get("foo.bar"); string(b) != "bar" {
+		t.Fatalf("expected bar got %s", b)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if e := escape(`micro:cache:a*b?c[d]\`); e != `micro:cache:a\*b\?c\[d\]\\` {
+		t.Fatalf("unexpected escaped pattern %s", e)
+	}
+}
@@ -0,0 +1,219 @@
+// Package tenant provides wrappers which propagate and enforce a tenant id.
+// The tenant is extracted from incoming metadata or a verified JWT claim,
+// stored in the context and set on all outgoing calls and publications.
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"github.com/micro/go-plugins/wrapper/auth/jwt"
+)
+
+const (
+	// DefaultHeader is the metadata key holding the tenant id
+	DefaultHeader = "X-Tenant-Id"
+)
+
+type tenantKey struct{}
+
+// Options for the tenant wrappers
+type Options struct {
+	// Header the tenant id is read from and written to
+	Header string
+	// Claim the tenant id is read from when the request was
+	// authenticated by the jwt wrapper. The header isn't trusted
+	// when set, so requests without the claim are rejected.
+	Claim string
+	// Required rejects requests without a tenant
+	Required bool
+	// Exempt endpoints which don't require a tenant
+	Exempt []string
+}
+
+type Option func(*Options)
+
+// Header sets the metadata key used for the tenant id
+func Header(h string) Option {
+	return func(o *Options) {
+		o.Header = h
+	}
+}
+
+// Claim sets the jwt claim holding the tenant id
+func Claim(c string) Option {
+	return func(o *Options) {
+		o.Claim = c
+	}
+}
+
+// Required rejects requests which have no tenant
+func Required(b bool) Option {
+	return func(o *Options) {
+		o.Required = b
+	}
+}
+
+// Exempt allows the endpoints to be called without a tenant
+func Exempt(endpoints ...string) Option {
+	return func(o *Options) {
+		o.Exempt = append(o.Exempt, endpoints...)
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	options := Options{
+		Header: DefaultHeader,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+// NewContext returns a context holding the tenant id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant id from the context
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && len(id) > 0
+}
+
+// extract reads the tenant from the jwt claim if set, or the metadata
+func extract(ctx context.Context, opts Options) string {
+	if len(opts.Claim) > 0 {
+		return fromClaims(ctx, opts.Claim)
+	}
+	return fromMetadata(ctx, opts.Header)
+}
+
+// fromClaims reads the tenant from the verified jwt claims
+func fromClaims(ctx context.Context, claim string) string {
+	claims, ok := jwt.Claims(ctx)
+	if !ok {
+		return ""
+	}
+	id, _ := claims[claim].(string)
+	return id
+}
+
+// fromMetadata reads the tenant from the metadata
+func fromMetadata(ctx context.Context, header string) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if id, ok := md[header]; ok {
+		return id
+	}
+	for k, v := range md {
+		if strings.EqualFold(k, header) {
+			return v
+		}
+	}
+	return ""
+}
+
+// inject sets the tenant from the context on the outgoing metadata
+func inject(ctx context.Context, opts Options) context.Context {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	md, _ := metadata.FromContext(ctx)
+	nmd := make(metadata.Metadata, len(md)+1)
+	for k, v := range md {
+		nmd[k] = v
+	}
+	nmd[opts.Header] = id
+
+	return metadata.NewContext(ctx, nmd)
+}
+
+type clientWrapper struct {
+	opts Options
+	client.Client
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	return c.Client.Call(inject(ctx, c.opts), req, rsp, opts...)
+}
+
+func (c *clientWrapper) Stream(ctx context.Context, req client.Request, opts ...client.CallOption) (client.Stream, error) {
+	return c.Client.Stream(inject(ctx, c.opts), req, opts...)
+}
+
+func (c *clientWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	return c.Client.Publish(inject(ctx, c.opts), p, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which propagates the tenant
+// in the context on outgoing calls and broker messages
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{options, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which places the
+// tenant in the context and rejects requests without one if required
+// or if the tenant claim is set
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+
+	exempt := make(map[string]bool)
+	for _, e := range options.Exempt {
+		exempt[e] = true
+	}
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			id := extract(ctx, options)
+			if len(id) == 0 {
+				if exempt[req.Method()] {
+					return h(ctx, req, rsp)
+				}
+				// callers can't choose the tenant of a token
+				if len(options.Claim) > 0 {
+					return errors.Forbidden(req.Service(), "missing tenant claim")
+				}
+				if options.Required {
+					return errors.BadRequest(req.Service(), "missing tenant")
+				}
+				return h(ctx, req, rsp)
+			}
+			return h(NewContext(ctx, id), req, rsp)
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which places
+// the tenant from the message header in the context. Messages without
+// one are rejected if required. Messages carry no token so the header
+// is used even if a claim is set.
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			id := fromMetadata(ctx, options.Header)
+			if len(id) == 0 {
+				if options.Required {
+					return errors.BadRequest("go.micro.server", "missing tenant for message on %s", msg.Topic())
+				}
+				return fn(ctx, msg)
+			}
+			return fn(NewContext(ctx, id), msg)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	var got string
+
+	fn := NewHandlerWrapper(Required(true))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		got, _ = FromContext(ctx)
+		return nil
+	})
+
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"x-tenant-id": "acme",
+	})
+
+	if err := fn(ctx, &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got != "acme" {
+		t.Fatalf("expected tenant acme got %s", got)
+	}
+
+	if err := fn(context.TODO(), &testRequest{}, nil); err == nil {
+		t.Fatal("expected missing tenant to be rejected")
+	}
+}
+
+func TestHandlerWrapperClaim(t *testing.T) {
+	fn := NewHandlerWrapper(Claim("tenant"))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return nil
+	})
+
+	// the header can't stand in for a missing claim
+	ctx := metadata.NewContext(context.TODO(), map[string]string{
+		"X-Tenant-Id": "acme",
+	})
+
+	if err := fn(ctx, &testRequest{}, nil); err == nil {
+		t.Fatal("expected request without the tenant claim to be rejected")
+	}
+}
+
+func TestInject(t *testing.T) {
+	ctx := inject(NewContext(context.TODO(), "acme"), newOptions())
+
+	md, _ := metadata.FromContext(ctx)
+	if md[DefaultHeader] != "acme" {
+		t.Fatalf("expected tenant header got %v", md)
+	}
+}
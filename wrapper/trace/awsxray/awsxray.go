@@ -28,6 +28,21 @@ func (x *xrayWrapper) Call(ctx context.Context, req client.Request, rsp interfac
 	return err
 }
 
+func (x *xrayWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	var err error
+	s := getSegment(x.opts.Name, ctx)
+
+	defer func() {
+		setCallStatus(s, p.Topic(), "PUBLISH", err)
+		go record(x.x, s)
+	}()
+
+	// the trace header is carried to subscribers in the message header
+	ctx = newContext(ctx, s)
+	err = x.Client.Publish(ctx, p, opts...)
+	return err
+}
+
 // NewCallWrapper accepts Options and returns a Trace Call Wrapper for individual node calls made by the client
 func NewCallWrapper(opts ...Option) client.CallWrapper {
 	options := Options{
@@ -108,3 +123,39 @@ func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
 		}
 	}
 }
+
+// NewSubscriberWrapper accepts Options and returns a Trace Subscriber Wrapper.
+// The trace is continued from the header set by the publishing client.
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := Options{
+		Daemon: "localhost:2000",
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	x := newXRay(options)
+
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			name := options.Name
+			if len(name) == 0 {
+				// default name
+				name = "Sub from " + msg.Topic()
+			}
+
+			var err error
+			s := getSegment(name, ctx)
+
+			defer func() {
+				setCallStatus(s, msg.Topic(), "SUBSCRIBE", err)
+				go record(x, s)
+			}()
+
+			ctx = newContext(ctx, s)
+			err = fn(ctx, msg)
+			return err
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package awsxray
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/asim/go-awsxray"
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+)
+
+const (
+	testTraceId  = "1-5759e988-bd862e3fe1be46a994272793"
+	testParentId = "53995c3f42cd8ad8"
+)
+
+type testPublication struct {
+	client.Message
+}
+
+func (t *testPublication) Topic() string {
+	return "test.topic"
+}
+
+type testClient struct {
+	client.Client
+	ctx context.Context
+}
+
+func (t *testClient) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	t.ctx = ctx
+	return nil
+}
+
+type testMessage struct {
+	server.Message
+}
+
+func (t *testMessage) Topic() string {
+	return "test.topic"
+}
+
+func TestPublish(t *testing.T) {
+	tc := &testClient{}
+	c := NewClientWrapper(WithName("test.service"))(tc)
+
+	if err := c.Publish(context.TODO(), &testPublication{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, ok := awsxray.FromContext(tc.ctx)
+	if !ok {
+		t.Fatal("expected segment in the publish context")
+	}
+	if s.Name != "test.service" || s.HTTP == nil || s.HTTP.Request.Method != "PUBLISH" || s.HTTP.Request.URL != "test.topic" {
+		t.Fatalf("unexpected publish segment %+v", s)
+	}
+
+	// the trace is carried to subscribers in the header
+	md, _ := metadata.FromContext(tc.ctx)
+	if h := md[awsxray.TraceHeader]; awsxray.GetTraceId(h) != s.TraceId {
+		t.Fatalf("expected trace %s in header got %s", s.TraceId, h)
+	}
+}
+
+func TestSubscriberWrapper(t *testing.T) {
+	var s *awsxray.Segment
+
+	fn := NewSubscriberWrapper()(func(ctx context.Context, msg server.Message) error {
+		s, _ = awsxray.FromContext(ctx)
+		return errors.New("failed")
+	})
+
+	ctx := metadata.NewContext(context.TODO(), metadata.Metadata{
+		awsxray.TraceHeader: "Root=" + testTraceId + ";Parent=" + testParentId,
+	})
+
+	if err := fn(ctx, &testMessage{}); err == nil {
+		t.Fatal("expected the subscriber error")
+	}
+
+	if s == nil {
+		t.Fatal("expected segment in the subscriber context")
+	}
+	if s.Name != "Sub from test.topic" {
+		t.Fatalf("unexpected segment name %s", s.Name)
+	}
+	if s.TraceId != testTraceId || s.ParentId != testParentId {
+		t.Fatalf("expected trace to be continued from the header got %+v", s)
+	}
+	if s.HTTP.Request.Method != "SUBSCRIBE" || !s.Fault {
+		t.Fatalf("expected failed subscribe segment got %+v", s)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tr := NewTracer()
+
+	parent := &awsxray.Segment{Id: testParentId, TraceId: testTraceId}
+	ctx := awsxray.NewContext(context.TODO(), parent)
+
+	ctx, sub := tr.SQL(ctx, "users", "postgres://db/users", "  select * from users")
+	sub.End(nil)
+
+	s, _ := awsxray.FromContext(ctx)
+	if s != sub.s {
+		t.Fatal("expected the subsegment in the returned context")
+	}
+	if s.ParentId != parent.Id || s.TraceId != parent.TraceId || s.Type != "subsegment" {
+		t.Fatalf("expected subsegment of the parent got %+v", s)
+	}
+	if s.HTTP.Request.Method != "SELECT" || s.HTTP.Request.URL != "postgres://db/users" {
+		t.Fatalf("unexpected sql subsegment %+v", s.HTTP.Request)
+	}
+
+	_, sub = tr.HTTP(context.TODO(), "api", "http://api/users", "GET")
+	sub.End(errors.New("timeout"))
+
+	if !sub.s.Fault || sub.s.HTTP.Response.Status != 500 {
+		t.Fatalf("expected failed http subsegment got %+v", sub.s)
+	}
+	if !strings.HasPrefix(sub.s.TraceId, "1-") {
+		t.Fatalf("expected a new trace got %s", sub.s.TraceId)
+	}
+}
@@ -0,0 +1,67 @@
+package awsxray
+
+import (
+	"context"
+	"strings"
+
+	"github.com/asim/go-awsxray"
+)
+
+// Tracer records subsegments for downstream calls made while handling
+// a traced request, such as SQL queries and HTTP requests
+type Tracer struct {
+	x *awsxray.AWSXRay
+}
+
+// Subsegment is an in flight downstream call
+type Subsegment struct {
+	x      *awsxray.AWSXRay
+	s      *awsxray.Segment
+	url    string
+	method string
+}
+
+// End records the subsegment with the outcome of the call
+func (s *Subsegment) End(err error) {
+	setCallStatus(s.s, s.url, s.method, err)
+	go record(s.x, s.s)
+}
+
+func (t *Tracer) start(ctx context.Context, name, url, method string) (context.Context, *Subsegment) {
+	s := getSegment(name, ctx)
+	return newContext(ctx, s), &Subsegment{
+		x:      t.x,
+		s:      s,
+		url:    url,
+		method: method,
+	}
+}
+
+// SQL starts a subsegment for a query against the database. The statement
+// verb, e.g. SELECT, is recorded as the method. The returned context should
+// be used for the query so nested calls are attributed to the subsegment.
+func (t *Tracer) SQL(ctx context.Context, database, url, query string) (context.Context, *Subsegment) {
+	method := "QUERY"
+	if f := strings.Fields(query); len(f) > 0 {
+		method = strings.ToUpper(f[0])
+	}
+	return t.start(ctx, database, url, method)
+}
+
+// HTTP starts a subsegment for a request to a downstream http service
+func (t *Tracer) HTTP(ctx context.Context, name, url, method string) (context.Context, *Subsegment) {
+	return t.start(ctx, name, url, method)
+}
+
+// NewTracer accepts Options and returns a Tracer for downstream subsegments
+func NewTracer(opts ...Option) *Tracer {
+	options := Options{
+		Daemon: "localhost:2000",
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &Tracer{newXRay(options)}
+}
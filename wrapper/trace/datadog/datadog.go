@@ -0,0 +1,138 @@
+// Package datadog provides wrappers for Datadog APM tracing
+package datadog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type ddWrapper struct {
+	opts Options
+	client.Client
+}
+
+// spanOptions names the span after the configured service, the go-micro
+// service if any or otherwise the service the tracer was started with
+func spanOptions(opts Options, service, spanType string) []ddtrace.StartSpanOption {
+	sopts := []ddtrace.StartSpanOption{
+		tracer.SpanType(spanType),
+	}
+	if len(service) > 0 {
+		sopts = append(sopts, tracer.Tag("micro.service", service))
+	}
+	if len(opts.Service) > 0 {
+		sopts = append(sopts, tracer.ServiceName(opts.Service))
+	} else if len(service) > 0 {
+		sopts = append(sopts, tracer.ServiceName(service))
+	}
+	if len(opts.Version) > 0 {
+		sopts = append(sopts, tracer.Tag(ext.Version, opts.Version))
+	}
+	if opts.Analytics {
+		sopts = append(sopts, tracer.Tag(ext.EventSampleRate, 1.0))
+	}
+	return sopts
+}
+
+// startSpan continues the trace from the incoming metadata if present
+func startSpan(ctx context.Context, operation, resource string, sopts []ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	md, _ := metadata.FromContext(ctx)
+
+	if sctx, err := tracer.Extract(tracer.TextMapCarrier(md)); err == nil {
+		sopts = append(sopts, tracer.ChildOf(sctx))
+	}
+
+	sopts = append(sopts, tracer.ResourceName(resource))
+	return tracer.StartSpanFromContext(ctx, operation, sopts...)
+}
+
+// inject writes the span context into the outgoing metadata
+func inject(ctx context.Context, span ddtrace.Span) context.Context {
+	md, _ := metadata.FromContext(ctx)
+
+	nmd := make(metadata.Metadata, len(md))
+	for k, v := range md {
+		nmd[k] = v
+	}
+
+	tracer.Inject(span.Context(), tracer.TextMapCarrier(nmd))
+	return metadata.NewContext(ctx, nmd)
+}
+
+func (d *ddWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	resource := fmt.Sprintf("%s.%s", req.Service(), req.Method())
+	sopts := spanOptions(d.opts, req.Service(), ext.AppTypeRPC)
+	sopts = append(sopts, tracer.Tag("micro.endpoint", req.Method()))
+
+	span, ctx := tracer.StartSpanFromContext(ctx, "micro.client.call", append(sopts, tracer.ResourceName(resource))...)
+
+	err := d.Client.Call(inject(ctx, span), req, rsp, opts...)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+func (d *ddWrapper) Publish(ctx context.Context, p client.Message, opts ...client.PublishOption) error {
+	// the topic is the resource rather than the service
+	sopts := spanOptions(d.opts, "", ext.SpanTypeMessageProducer)
+	sopts = append(sopts, tracer.Tag("micro.topic", p.Topic()))
+
+	span, ctx := tracer.StartSpanFromContext(ctx, "micro.client.publish", append(sopts, tracer.ResourceName(p.Topic()))...)
+
+	err := d.Client.Publish(inject(ctx, span), p, opts...)
+	span.Finish(tracer.WithError(err))
+	return err
+}
+
+// NewClientWrapper returns a client Wrapper which traces calls and publications
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &ddWrapper{options, c}
+	}
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which traces requests
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			resource := fmt.Sprintf("%s.%s", req.Service(), req.Method())
+			sopts := spanOptions(options, req.Service(), ext.AppTypeRPC)
+			sopts = append(sopts, tracer.Tag("micro.endpoint", req.Method()))
+
+			span, ctx := startSpan(ctx, "micro.server.request", resource, sopts)
+
+			err := h(ctx, req, rsp)
+			span.Finish(tracer.WithError(err))
+			return err
+		}
+	}
+}
+
+// NewSubscriberWrapper returns a server SubscriberWrapper which traces messages
+func NewSubscriberWrapper(opts ...Option) server.SubscriberWrapper {
+	options := newOptions(opts...)
+
+	return func(fn server.SubscriberFunc) server.SubscriberFunc {
+		return func(ctx context.Context, msg server.Message) error {
+			sopts := spanOptions(options, "", ext.SpanTypeMessageConsumer)
+			sopts = append(sopts, tracer.Tag("micro.topic", msg.Topic()))
+
+			span, ctx := startSpan(ctx, "micro.server.subscribe", msg.Topic(), sopts)
+
+			err := fn(ctx, msg)
+			span.Finish(tracer.WithError(err))
+			return err
+		}
+	}
+}
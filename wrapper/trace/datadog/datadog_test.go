@@ -0,0 +1,92 @@
+package datadog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/micro/go-micro/metadata"
+	"github.com/micro/go-micro/server"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type testMessage struct {
+	server.Message
+}
+
+func (t *testMessage) Topic() string {
+	return "test.topic"
+}
+
+type testRequest struct {
+	server.Request
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Method() string {
+	return "Test.Method"
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	parent := tracer.StartSpan("parent")
+	ctx := inject(context.TODO(), parent)
+
+	md, _ := metadata.FromContext(ctx)
+	if len(md) == 0 {
+		t.Fatal("expected span context in metadata")
+	}
+
+	fn := NewHandlerWrapper(WithVersion("1.0.0"))(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return nil
+	})
+
+	if err := fn(ctx, &testRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.ParentID() != parent.Context().SpanID() {
+		t.Fatalf("expected parent %d got %d", parent.Context().SpanID(), span.ParentID())
+	}
+	if span.Tag("resource.name") != "test.service.Test.Method" {
+		t.Fatalf("unexpected resource %v", span.Tag("resource.name"))
+	}
+}
+
+func TestSubscriberWrapper(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	fn := NewSubscriberWrapper(WithService("test.service"))(func(ctx context.Context, msg server.Message) error {
+		return nil
+	})
+
+	if err := fn(context.TODO(), &testMessage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Tag("service.name") != "test.service" {
+		t.Fatalf("expected the service name got %v", span.Tag("service.name"))
+	}
+	if span.Tag("resource.name") != "test.topic" || span.Tag("micro.topic") != "test.topic" {
+		t.Fatalf("expected the topic as the resource got %v", span.Tag("resource.name"))
+	}
+}
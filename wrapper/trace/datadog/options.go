@@ -0,0 +1,56 @@
+package datadog
+
+import (
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Options for the datadog wrappers
+type Options struct {
+	// Service name tagged on spans, defaults to the go-micro service
+	Service string
+	// Version tagged on spans
+	Version string
+	// Analytics enables trace search and analytics for the spans
+	Analytics bool
+}
+
+type Option func(*Options)
+
+// WithService sets the datadog service name
+func WithService(s string) Option {
+	return func(o *Options) {
+		o.Service = s
+	}
+}
+
+// WithVersion sets the version tag
+func WithVersion(v string) Option {
+	return func(o *Options) {
+		o.Version = v
+	}
+}
+
+// WithAnalytics enables analytics for the spans
+func WithAnalytics(b bool) Option {
+	return func(o *Options) {
+		o.Analytics = b
+	}
+}
+
+// Start starts the global datadog tracer, optionally shipping runtime
+// metrics. It should be called once in main before any wrapper is used
+// and paired with a deferred tracer.Stop().
+func Start(runtimeMetrics bool, opts ...tracer.StartOption) {
+	if runtimeMetrics {
+		opts = append(opts, tracer.WithRuntimeMetrics())
+	}
+	tracer.Start(opts...)
+}
+
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
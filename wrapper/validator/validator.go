@@ -0,0 +1,214 @@
+// Package validator provides wrappers which enforce protoc-gen-validate
+// constraints on requests and responses. Messages generated with the
+// validate plugin have a Validate method which is called before the
+// request reaches the handler.
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/micro/go-micro/client"
+	"github.com/micro/go-micro/errors"
+	"github.com/micro/go-micro/server"
+)
+
+var (
+	// ErrNoViolations is returned by Parse for errors without field violations
+	ErrNoViolations = fmt.Errorf("no field violations")
+)
+
+// validator is implemented by protoc-gen-validate messages
+type validator interface {
+	Validate() error
+}
+
+// allValidator is implemented by newer protoc-gen-validate messages
+// and returns every violation rather than the first
+type allValidator interface {
+	ValidateAll() error
+}
+
+// fieldError is implemented by protoc-gen-validate validation errors
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// multiError is implemented by the errors returned from ValidateAll
+type multiError interface {
+	AllErrors() []error
+}
+
+// FieldViolation describes an invalid field
+type FieldViolation struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Violations is the error detail returned for invalid messages
+type Violations struct {
+	Message    string           `json:"message"`
+	Violations []FieldViolation `json:"violations"`
+}
+
+// Options for the validator wrappers
+type Options struct {
+	// All collects every violation rather than stopping at the first
+	All bool
+	// Responses also validates handler responses
+	Responses bool
+}
+
+type Option func(*Options)
+
+// All collects every violation where the message supports it
+func All(b bool) Option {
+	return func(o *Options) {
+		o.All = b
+	}
+}
+
+// Responses validates responses as well as requests
+func Responses(b bool) Option {
+	return func(o *Options) {
+		o.Responses = b
+	}
+}
+
+// violations flattens the validation error into field violations,
+// prefixing nested fields with their parent
+func violations(err error, prefix string) []FieldViolation {
+	if m, ok := err.(multiError); ok {
+		var v []FieldViolation
+		for _, e := range m.AllErrors() {
+			v = append(v, violations(e, prefix)...)
+		}
+		return v
+	}
+
+	f, ok := err.(fieldError)
+	if !ok {
+		return []FieldViolation{{Field: prefix, Reason: err.Error()}}
+	}
+
+	field := f.Field()
+	if len(prefix) > 0 {
+		field = prefix + "." + field
+	}
+
+	// embedded message errors carry the nested violation as the cause
+	if cause := f.Cause(); cause != nil {
+		if _, ok := cause.(fieldError); ok {
+			return violations(cause, field)
+		}
+		if _, ok := cause.(multiError); ok {
+			return violations(cause, field)
+		}
+	}
+
+	return []FieldViolation{{Field: field, Reason: f.Reason()}}
+}
+
+func validate(v interface{}, all bool) error {
+	if all {
+		if a, ok := v.(allValidator); ok {
+			return a.ValidateAll()
+		}
+	}
+	if vv, ok := v.(validator); ok {
+		return vv.Validate()
+	}
+	return nil
+}
+
+// newError returns a go-micro error with the violations as json in the detail
+func newError(id string, code int32, err error) error {
+	b, merr := json.Marshal(Violations{
+		Message:    err.Error(),
+		Violations: violations(err, ""),
+	})
+	if merr != nil {
+		return errors.New(id, err.Error(), code)
+	}
+	return errors.New(id, string(b), code)
+}
+
+// Parse returns the field violations from an error returned by the wrappers.
+// ErrNoViolations is returned for nil or any other error.
+func Parse(err error) (*Violations, error) {
+	if err == nil {
+		return nil, ErrNoViolations
+	}
+
+	e, ok := err.(*errors.Error)
+	if !ok {
+		e = errors.Parse(err.Error())
+	}
+
+	v := new(Violations)
+	if jerr := json.Unmarshal([]byte(e.Detail), v); jerr != nil || len(v.Violations) == 0 {
+		return nil, ErrNoViolations
+	}
+	return v, nil
+}
+
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+// NewHandlerWrapper returns a server HandlerWrapper which rejects invalid
+// requests with a 400 and, optionally, invalid responses with a 500
+func NewHandlerWrapper(opts ...Option) server.HandlerWrapper {
+	options := newOptions(opts...)
+
+	return func(h server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			if err := validate(req.Request(), options.All); err != nil {
+				return newError(req.Service(), 400, err)
+			}
+
+			if err := h(ctx, req, rsp); err != nil {
+				return err
+			}
+
+			if !options.Responses {
+				return nil
+			}
+
+			if err := validate(rsp, options.All); err != nil {
+				return newError(req.Service(), 500, err)
+			}
+
+			return nil
+		}
+	}
+}
+
+type clientWrapper struct {
+	opts Options
+	client.Client
+}
+
+func (c *clientWrapper) Call(ctx context.Context, req client.Request, rsp interface{}, opts ...client.CallOption) error {
+	if err := validate(req.Request(), c.opts.All); err != nil {
+		return newError("go.micro.client", 400, err)
+	}
+	return c.Client.Call(ctx, req, rsp, opts...)
+}
+
+// NewClientWrapper returns a client Wrapper which validates requests
+// before they're sent, saving a round trip for invalid requests
+func NewClientWrapper(opts ...Option) client.Wrapper {
+	options := newOptions(opts...)
+
+	return func(c client.Client) client.Client {
+		return &clientWrapper{options, c}
+	}
+}
@@ -0,0 +1,89 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/micro/go-micro/server"
+)
+
+// testValidationError mimics the errors generated by protoc-gen-validate
+type testValidationError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e testValidationError) Field() string  { return e.field }
+func (e testValidationError) Reason() string { return e.reason }
+func (e testValidationError) Cause() error   { return e.cause }
+func (e testValidationError) Error() string  { return "invalid " + e.field + ": " + e.reason }
+
+type testMessage struct {
+	err error
+}
+
+func (t *testMessage) Validate() error {
+	return t.err
+}
+
+type testRequest struct {
+	server.Request
+	msg *testMessage
+}
+
+func (t *testRequest) Service() string {
+	return "test.service"
+}
+
+func (t *testRequest) Request() interface{} {
+	return t.msg
+}
+
+func TestHandlerWrapper(t *testing.T) {
+	nested := testValidationError{
+		field:  "Address",
+		reason: "embedded message failed validation",
+		cause:  testValidationError{field: "Zip", reason: "value length must be 5 runes"},
+	}
+
+	fn := NewHandlerWrapper()(func(ctx context.Context, req server.Request, rsp interface{}) error {
+		return nil
+	})
+
+	if err := fn(context.TODO(), &testRequest{msg: &testMessage{}}, nil); err != nil {
+		t.Fatalf("expected valid request got %v", err)
+	}
+
+	err := fn(context.TODO(), &testRequest{msg: &testMessage{err: nested}}, nil)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	v, perr := Parse(err)
+	if perr != nil {
+		t.Fatalf("expected violations in %v: %v", err, perr)
+	}
+
+	if len(v.Violations) != 1 || v.Violations[0].Field != "Address.Zip" {
+		t.Fatalf("unexpected violations %+v", v.Violations)
+	}
+}
+
+func TestPlainError(t *testing.T) {
+	v := violations(errors.New("bad"), "")
+	if len(v) != 1 || v[0].Reason != "bad" {
+		t.Fatalf("unexpected violations %+v", v)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse(nil); err != ErrNoViolations {
+		t.Fatalf("expected %v got %v", ErrNoViolations, err)
+	}
+
+	if _, err := Parse(errors.New("bad")); err != ErrNoViolations {
+		t.Fatalf("expected %v got %v", ErrNoViolations, err)
+	}
+}